@@ -29,6 +29,26 @@ func main() {
 	migrator := migration.NewMigrator(db)
 	migrator.Register(&migrations.UpdateWalletRequestsTable{})
 	migrator.Register(&migrations.AddIsPrimaryToWhitelist{})
+	migrator.Register(&migrations.CreateWealthInterestRecord{})
+	migrator.Register(&migrations.CreateSchedulerRun{})
+	migrator.Register(&migrations.CreateSchedulerJob{})
+	migrator.Register(&migrations.AddEarlyRedeemRule{})
+	migrator.Register(&migrations.AddFlexibleProducts{})
+	migrator.Register(&migrations.CreateWealthProductRate{})
+	migrator.Register(&migrations.AddAutoRenewCompound{})
+	migrator.Register(&migrations.AddProductLifecycle{})
+	migrator.Register(&migrations.CreateWealthSubscribeQuote{})
+	migrator.Register(&migrations.AddProductBusinessCalendar{})
+	migrator.Register(&migrations.AddLendingLifecycle{})
+	migrator.Register(&migrations.AddLendingRates{})
+	migrator.Register(&migrations.CreateLoan{})
+	migrator.Register(&migrations.AddAccountTypeCurrencyUnique{})
+	migrator.Register(&migrations.CreateP2PTransfer{})
+	migrator.Register(&migrations.AddAccountBalanceConstraints{})
+	migrator.Register(&migrations.AddWithdrawalLifecycle{})
+	migrator.Register(&migrations.WidenAmountColumns{})
+	migrator.Register(&migrations.CreateAuditTrail{})
+	migrator.Register(&migrations.CreateTransferRecord{})
 
 	if err := migrator.Migrate(); err != nil {
 		log.Fatal("Migration failed:", err)
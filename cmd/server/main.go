@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -14,7 +15,7 @@ import (
 	"monera-digital/internal/logger"
 	"monera-digital/internal/middleware"
 	"monera-digital/internal/routes"
-	"monera-digital/internal/scheduler"
+	"monera-digital/internal/services"
 )
 
 func main() {
@@ -69,7 +70,18 @@ func main() {
 	// Initialize container
 	cont := container.NewContainer(database, cfg.JWTSecret,
 		container.WithEncryption(cfg.EncryptionKey),
-		container.WithRedisCache(redisCache))
+		container.WithRedisCache(redisCache),
+		container.WithAdminEmails(cfg.AdminEmails),
+		container.WithSafeheron(services.SafeheronConfig{
+			BaseURL:           cfg.Safeheron.BaseURL,
+			APIKey:            cfg.Safeheron.APIKey,
+			PrivateKey:        cfg.Safeheron.PrivateKey,
+			PlatformPublicKey: cfg.Safeheron.PlatformPublicKey,
+			WebhookPublicKey:  cfg.Safeheron.WebhookPublicKey,
+			WebhookPrivateKey: cfg.Safeheron.WebhookPrivateKey,
+			AccountKey:        cfg.Safeheron.AccountKey,
+			CoinKeys:          cfg.Safeheron.CoinKeys,
+		}))
 
 	// Verify container
 	if err := cont.Verify(); err != nil {
@@ -86,10 +98,9 @@ func main() {
 	// Setup routes
 	routes.SetupRoutes(r, cont)
 
-	// Start interest scheduler
-	interestScheduler := scheduler.NewInterestScheduler(cont.Repository.Wealth, cont.Repository.AccountV2, cont.Repository.Journal)
-	go interestScheduler.Start()
-	logger.Info("Interest scheduler started")
+	// Start scheduled jobs (interest, price refresh, cleanups)
+	cont.JobRegistry.Start(context.Background())
+	logger.Info("Job registry started")
 
 	// Serve static files in production (MUST be after API routes)
 	distPath := "./dist"
@@ -6,11 +6,11 @@ import (
 	"fmt"
 	"log"
 	"os"
-	"strconv"
 	"strings"
 	"time"
 
 	"monera-digital/internal/db"
+	"monera-digital/internal/money"
 	"monera-digital/internal/repository"
 	"monera-digital/internal/repository/postgres"
 )
@@ -156,7 +156,7 @@ func main() {
 	printFinalReport(report)
 }
 
-func runScenario(wealthRepo *postgres.WealthRepository, accountRepo *postgres.AccountRepository, database *sql.DB, scenario TestScenario) ScenarioResult {
+func runScenario(wealthRepo *postgres.WealthRepository, accountRepo repository.AccountV2, database *sql.DB, scenario TestScenario) ScenarioResult {
 	result := ScenarioResult{
 		ScenarioName: scenario.Name,
 		UserID:       scenario.UserID,
@@ -180,9 +180,9 @@ func runScenario(wealthRepo *postgres.WealthRepository, accountRepo *postgres.Ac
 			ID:       accountID,
 			UserID:   scenario.UserID,
 			Currency: "USDT",
-			Balance:  "100000",
+			Balance:  money.NewFromInt(100000),
 		}
-		accountRepo.AddBalance(ctx, account.ID, "100000")
+		accountRepo.AddBalance(ctx, account.ID, money.NewFromInt(100000))
 		account, _ = accountRepo.GetAccountByUserIDAndCurrency(ctx, scenario.UserID, "USDT")
 	}
 
@@ -218,12 +218,10 @@ func runScenario(wealthRepo *postgres.WealthRepository, accountRepo *postgres.Ac
 	})
 
 	step3Start := time.Now()
-	amount, _ := strconv.ParseFloat(scenario.Amount, 64)
-	minAmount, _ := strconv.ParseFloat(product.MinAmount, 64)
-	maxAmount, _ := strconv.ParseFloat(product.MaxAmount, 64)
+	amount := money.ParseOrZero(scenario.Amount)
 	step3Duration := time.Since(step3Start)
 
-	if amount < minAmount {
+	if amount.LessThan(product.MinAmount) {
 		result.Steps = append(result.Steps, StepResult{
 			StepName: "验证金额",
 			Status:   "失败",
@@ -236,7 +234,7 @@ func runScenario(wealthRepo *postgres.WealthRepository, accountRepo *postgres.Ac
 		return result
 	}
 
-	if amount > maxAmount {
+	if amount.GreaterThan(product.MaxAmount) {
 		result.Steps = append(result.Steps, StepResult{
 			StepName: "验证金额",
 			Status:   "失败",
@@ -257,14 +255,14 @@ func runScenario(wealthRepo *postgres.WealthRepository, accountRepo *postgres.Ac
 	})
 
 	step4Start := time.Now()
-	balance, _ := strconv.ParseFloat(account.Balance, 64)
+	available := account.Available()
 	step4Duration := time.Since(step4Start)
 
-	if balance < amount {
+	if available.LessThan(amount) {
 		result.Steps = append(result.Steps, StepResult{
 			StepName: "检查余额",
 			Status:   "失败",
-			Details:  fmt.Sprintf("可用余额: %s, 申购金额: %s", account.Balance, scenario.Amount),
+			Details:  fmt.Sprintf("可用余额: %s, 申购金额: %s", available, scenario.Amount),
 			Duration: step4Duration,
 		})
 		result.Status = "失败"
@@ -276,7 +274,7 @@ func runScenario(wealthRepo *postgres.WealthRepository, accountRepo *postgres.Ac
 	result.Steps = append(result.Steps, StepResult{
 		StepName: "检查余额",
 		Status:   "通过",
-		Details:  fmt.Sprintf("余额充足: %s >= %s", account.Balance, scenario.Amount),
+		Details:  fmt.Sprintf("余额充足: %s >= %s", available, scenario.Amount),
 		Duration: step4Duration,
 	})
 
@@ -290,8 +288,8 @@ func runScenario(wealthRepo *postgres.WealthRepository, accountRepo *postgres.Ac
 		ProductID:       scenario.ProductID,
 		ProductTitle:    product.Title,
 		Currency:        product.Currency,
-		Amount:          scenario.Amount,
-		InterestAccrued: "0",
+		Amount:          amount,
+		InterestAccrued: money.Zero,
 		StartDate:       startDate,
 		EndDate:         endDate,
 		AutoRenew:       scenario.AutoRenew,
@@ -322,7 +320,7 @@ func runScenario(wealthRepo *postgres.WealthRepository, accountRepo *postgres.Ac
 	})
 
 	step6Start := time.Now()
-	err = accountRepo.FreezeBalance(ctx, account.ID, scenario.Amount)
+	err = accountRepo.FreezeBalance(ctx, account.ID, amount)
 	step6Duration := time.Since(step6Start)
 
 	if err != nil {
@@ -346,7 +344,7 @@ func runScenario(wealthRepo *postgres.WealthRepository, accountRepo *postgres.Ac
 	})
 
 	step7Start := time.Now()
-	err = wealthRepo.UpdateProductSoldQuota(ctx, scenario.ProductID, scenario.Amount)
+	err = wealthRepo.ReserveProductQuota(ctx, scenario.ProductID, amount)
 	step7Duration := time.Since(step7Start)
 
 	if err != nil {
@@ -387,7 +385,7 @@ func runScenario(wealthRepo *postgres.WealthRepository, accountRepo *postgres.Ac
 	}
 
 	if createdOrder.UserID != scenario.UserID ||
-		createdOrder.Amount != scenario.Amount ||
+		!createdOrder.Amount.Equal(amount) ||
 		createdOrder.AutoRenew != scenario.AutoRenew {
 		result.Status = "失败"
 		result.ErrorMessage = "数据验证不通过"
@@ -0,0 +1,348 @@
+// simulator 在内存仓储上逐日拨动时钟，重放申购、计息、到期结算与续期，最后输出对账报告。
+//
+//	go run ./cmd/simulator -start 2026-01-01 -days 180 -users 3
+//
+// The run is deterministic: every service reads time from one fake clock, so
+// the same flags always produce the same report. The process exits with
+// status 1 when any reconciliation check fails.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+
+	"monera-digital/internal/clock"
+	"monera-digital/internal/logger"
+	"monera-digital/internal/money"
+	"monera-digital/internal/repository"
+	"monera-digital/internal/repository/memory"
+	"monera-digital/internal/scheduler"
+	"monera-digital/internal/services"
+)
+
+// 模拟用产品编号，按创建顺序分配
+const (
+	productFixed30 int64 = iota + 1
+	productFixed7
+	productFlexible
+)
+
+// subscription 计划在某一天发起的申购
+type subscription struct {
+	userID    int
+	productID int64
+	amount    string
+	autoRenew bool
+	compound  bool
+}
+
+type simulation struct {
+	clock     *clock.Fake
+	store     *memory.Store
+	accounts  *memory.AccountRepository
+	wealth    *services.WealthService
+	scheduler *scheduler.InterestScheduler
+
+	// initial 开户时的余额，按账户 ID 记录
+	initial map[int64]repository.AccountModel
+
+	subscribed, rejected int
+	runFailures          []string
+}
+
+func main() {
+	startFlag := flag.String("start", "2026-01-01", "first simulated business date (YYYY-MM-DD)")
+	days := flag.Int("days", 180, "number of days to simulate")
+	users := flag.Int("users", 3, "number of simulated users")
+	verbose := flag.Bool("v", false, "print service and scheduler logs")
+	flag.Parse()
+
+	start, err := time.Parse("2006-01-02", *startFlag)
+	if err != nil {
+		log.Fatalf("invalid -start: %v", err)
+	}
+	if *days <= 0 || *users <= 0 {
+		log.Fatal("-days and -users must be positive")
+	}
+
+	if *verbose {
+		if err := logger.Init("development"); err != nil {
+			log.Fatalf("Failed to initialize logger: %v", err)
+		}
+	} else {
+		logger.Logger = zap.NewNop().Sugar()
+	}
+
+	ctx := context.Background()
+	sim, err := newSimulation(ctx, start, *users)
+	if err != nil {
+		log.Fatalf("Failed to set up simulation: %v", err)
+	}
+
+	plan := subscriptionPlan(*users)
+	for day := 0; day < *days; day++ {
+		// 业务时区 UTC+8 与调度器使用的 UTC 在 01:00 UTC 时处于同一日期
+		date := start.AddDate(0, 0, day)
+		sim.clock.Set(date.Add(time.Hour))
+
+		if err := sim.scheduler.RunOnce(ctx); err != nil {
+			sim.runFailures = append(sim.runFailures, fmt.Sprintf("%s: %v", date.Format("2006-01-02"), err))
+		}
+		for _, sub := range plan[day] {
+			sim.subscribe(ctx, date, sub)
+		}
+	}
+
+	end := start.AddDate(0, 0, *days-1)
+	if !sim.report(start, end, *days) {
+		os.Exit(1)
+	}
+}
+
+func newSimulation(ctx context.Context, start time.Time, users int) (*simulation, error) {
+	fake := clock.NewFake(start.Add(time.Hour))
+	store := memory.NewStore(fake)
+	wealthRepo := memory.NewWealthRepository(store)
+	accountRepo := memory.NewAccountRepository(store)
+	journalRepo := memory.NewJournalRepository(store)
+	uow := memory.NewUnitOfWork(store)
+
+	wealth := services.NewWealthService(wealthRepo, accountRepo, journalRepo, uow)
+	wealth.SetClock(fake)
+	interest := scheduler.NewInterestScheduler(wealthRepo, accountRepo, journalRepo, memory.NewSchedulerRunRepository(store), uow)
+	interest.SetClock(fake)
+
+	products := []*repository.WealthProductModel{
+		{Title: "USDT 30天定期", Currency: "USDT", APY: money.MustParse("5.5"), Duration: 30, AutoRenewAllowed: true},
+		{Title: "USDT 7天定期", Currency: "USDT", APY: money.MustParse("4"), Duration: 7, AutoRenewAllowed: true},
+		{Title: "USDC 活期", Currency: "USDC", APY: money.MustParse("2"), ProductType: repository.WealthProductTypeFlexible},
+	}
+	for _, p := range products {
+		p.MinAmount = money.MustParse("100")
+		p.MaxAmount = money.MustParse("1000000")
+		p.TotalQuota = money.MustParse("100000000")
+		p.Status = repository.WealthProductStatusActive
+		if err := wealthRepo.CreateProduct(ctx, p); err != nil {
+			return nil, err
+		}
+	}
+
+	// 45 天后 30 天定期调价并分档，覆盖计息与报价按日取利率的路径
+	rateDate := start.AddDate(0, 0, 45).Format("2006-01-02")
+	if err := wealthRepo.SaveProductRates(ctx, productFixed30, rateDate, []*repository.WealthRateModel{
+		{TierFloor: money.Zero, APY: money.MustParse("6.2")},
+		{TierFloor: money.MustParse("15000"), APY: money.MustParse("4.8")},
+	}); err != nil {
+		return nil, err
+	}
+
+	sim := &simulation{
+		clock:     fake,
+		store:     store,
+		accounts:  accountRepo,
+		wealth:    wealth,
+		scheduler: interest,
+		initial:   make(map[int64]repository.AccountModel),
+	}
+	for userID := int64(1); userID <= int64(users); userID++ {
+		for currency, balance := range map[string]string{"USDT": "100000", "USDC": "50000"} {
+			account := &repository.AccountModel{
+				UserID:        userID,
+				Type:          "FUND",
+				Currency:      currency,
+				Balance:       money.MustParse(balance),
+				FrozenBalance: money.Zero,
+			}
+			if err := accountRepo.CreateAccount(ctx, account); err != nil {
+				return nil, err
+			}
+			sim.initial[account.ID] = *account
+		}
+	}
+	return sim, nil
+}
+
+// subscriptionPlan 第 i 个用户从第 3(i-1) 天起申购，按天分组
+func subscriptionPlan(users int) map[int][]subscription {
+	plan := make(map[int][]subscription)
+	for i := 0; i < users; i++ {
+		userID := i + 1
+		day := 3 * i
+		plan[day] = append(plan[day],
+			subscription{userID: userID, productID: productFixed30, amount: "20000", autoRenew: true, compound: userID%2 == 1},
+			subscription{userID: userID, productID: productFixed7, amount: "5000"},
+			subscription{userID: userID, productID: productFlexible, amount: "10000"},
+		)
+		// 一周后再申购一笔自动续期的 7 天定期
+		plan[day+7] = append(plan[day+7],
+			subscription{userID: userID, productID: productFixed7, amount: "3000", autoRenew: true},
+		)
+	}
+	return plan
+}
+
+// subscribe 先报价再凭报价下单，与客户端的调用顺序一致
+func (sim *simulation) subscribe(ctx context.Context, date time.Time, sub subscription) {
+	quote, err := sim.wealth.QuoteSubscription(ctx, sub.userID, sub.productID, sub.amount)
+	if err == nil {
+		_, err = sim.wealth.Subscribe(ctx, sub.userID, quote.QuoteID, sub.autoRenew, sub.compound)
+	}
+	if err != nil {
+		sim.rejected++
+		fmt.Printf("%s 用户 %d 申购产品 %d 失败: %v\n", date.Format("2006-01-02"), sub.userID, sub.productID, err)
+		return
+	}
+	sim.subscribed++
+}
+
+// check 一项对账规则的结果
+type check struct {
+	name     string
+	total    int
+	failures []string
+}
+
+func (c *check) expect(ok bool, format string, args ...interface{}) {
+	c.total++
+	if !ok {
+		c.failures = append(c.failures, fmt.Sprintf(format, args...))
+	}
+}
+
+// report 打印运行概况与对账结果，全部对账通过时返回 true
+func (sim *simulation) report(start, end time.Time, days int) bool {
+	accounts := sim.store.Accounts()
+	orders := sim.store.Orders()
+	journals := sim.store.Journals()
+	records := sim.store.InterestRecords()
+
+	accruedByOrder := make(map[int64]money.Decimal)
+	totalAccrued := money.Zero
+	for _, rec := range records {
+		accruedByOrder[rec.OrderID] = sumOf(accruedByOrder, rec.OrderID).Add(rec.Amount)
+		totalAccrued = totalAccrued.Add(rec.Amount)
+	}
+
+	journalByAccount := make(map[int64]money.Decimal)
+	payoutByAccount := make(map[int64]money.Decimal)
+	lastSnapshot := make(map[int64]money.Decimal)
+	for _, j := range journals {
+		journalByAccount[j.AccountID] = sumOf(journalByAccount, j.AccountID).Add(j.Amount)
+		if j.BizType == "INTEREST_PAYOUT" {
+			payoutByAccount[j.AccountID] = sumOf(payoutByAccount, j.AccountID).Add(j.Amount)
+		}
+		lastSnapshot[j.AccountID] = j.BalanceSnapshot
+	}
+
+	type holding struct {
+		userID   int64
+		currency string
+	}
+	frozenByHolding := make(map[holding]money.Decimal)
+	soldByProduct := make(map[int64]money.Decimal)
+	statusCount := make(map[int]int)
+	renewed, paid, unpaid := 0, money.Zero, money.Zero
+	for _, o := range orders {
+		statusCount[o.Status]++
+		paid = paid.Add(o.InterestPaid)
+		unpaid = unpaid.Add(o.InterestAccrued)
+		if o.RenewedFromOrderID != nil {
+			renewed++
+		}
+		if o.Status == repository.WealthOrderStatusActive {
+			key := holding{o.UserID, o.Currency}
+			frozenByHolding[key] = sumOf(frozenByHolding, key).Add(o.RemainingPrincipal())
+			soldByProduct[o.ProductID] = sumOf(soldByProduct, o.ProductID).Add(o.RemainingPrincipal())
+		}
+	}
+
+	fmt.Println("==============================================")
+	fmt.Println("   理财模拟报告")
+	fmt.Println("==============================================")
+	fmt.Printf("区间: %s ~ %s (%d 天)\n", start.Format("2006-01-02"), end.Format("2006-01-02"), days)
+	fmt.Printf("申购: 成功 %d 笔, 失败 %d 笔\n", sim.subscribed, sim.rejected)
+	fmt.Printf("计息任务失败: %d 天\n", len(sim.runFailures))
+	for _, f := range sim.runFailures {
+		fmt.Printf("  - %s\n", f)
+	}
+	fmt.Printf("订单: 持有中 %d, 已结清 %d, 由续期产生 %d\n",
+		statusCount[repository.WealthOrderStatusActive], statusCount[repository.WealthOrderStatusSettled], renewed)
+	fmt.Printf("利息: 计提 %s, 已派发 %s, 已计未付 %s\n", totalAccrued, paid, unpaid)
+	fmt.Println()
+
+	frozen := &check{name: "冻结余额 = 持有中订单剩余本金"}
+	available := &check{name: "可用余额变动 = 流水金额合计"}
+	snapshot := &check{name: "最新流水快照 = 当前可用余额"}
+	balance := &check{name: "余额增量 = 利息派发流水合计"}
+	for _, a := range accounts {
+		init := sim.initial[a.ID]
+		want := sumOf(frozenByHolding, holding{a.UserID, a.Currency})
+		frozen.expect(a.FrozenBalance.Equal(want), "账户 %d (%s): 冻结 %s, 订单本金 %s", a.ID, a.Currency, a.FrozenBalance, want)
+
+		delta := a.Available().Sub(init.Available())
+		flow := sumOf(journalByAccount, a.ID)
+		available.expect(delta.Equal(flow), "账户 %d (%s): 可用变动 %s, 流水合计 %s", a.ID, a.Currency, delta, flow)
+
+		if last, ok := lastSnapshot[a.ID]; ok {
+			snapshot.expect(last.Equal(a.Available()), "账户 %d (%s): 快照 %s, 可用 %s", a.ID, a.Currency, last, a.Available())
+		}
+
+		gain := a.Balance.Sub(init.Balance)
+		payout := sumOf(payoutByAccount, a.ID)
+		balance.expect(gain.Equal(payout), "账户 %d (%s): 余额增量 %s, 派息流水 %s", a.ID, a.Currency, gain, payout)
+	}
+
+	ledger := &check{name: "订单已派发 + 已计未付 = 计息记录合计"}
+	expected := &check{name: "已结清定期订单实付利息 = 预期利息"}
+	for _, o := range orders {
+		booked := sumOf(accruedByOrder, o.ID)
+		got := o.InterestPaid.Add(o.InterestAccrued)
+		ledger.expect(got.Equal(booked), "订单 %d: 订单利息 %s, 计息记录 %s", o.ID, got, booked)
+		if o.Status == repository.WealthOrderStatusSettled && !o.IsFlexible() {
+			expected.expect(o.InterestPaid.Equal(o.InterestExpected), "订单 %d (%s ~ %s): 实付 %s, 预期 %s",
+				o.ID, o.StartDate, o.EndDate, o.InterestPaid, o.InterestExpected)
+		}
+	}
+
+	quota := &check{name: "产品已售额度 = 持有中订单本金"}
+	products, _ := memory.NewWealthRepository(sim.store).ListProducts(context.Background())
+	sort.Slice(products, func(i, j int) bool { return products[i].ID < products[j].ID })
+	for _, p := range products {
+		want := sumOf(soldByProduct, p.ID)
+		quota.expect(p.SoldQuota.Equal(want), "产品 %d: 已售 %s, 持有中本金 %s", p.ID, p.SoldQuota, want)
+	}
+
+	fmt.Println("对账:")
+	ok := len(sim.runFailures) == 0
+	for _, c := range []*check{frozen, available, snapshot, balance, ledger, expected, quota} {
+		if len(c.failures) == 0 {
+			fmt.Printf("[PASS] %s (%d 项)\n", c.name, c.total)
+			continue
+		}
+		ok = false
+		fmt.Printf("[FAIL] %s (%d/%d 项不符)\n", c.name, len(c.failures), c.total)
+		for i, f := range c.failures {
+			if i == 5 {
+				fmt.Printf("  ... 另有 %d 项\n", len(c.failures)-i)
+				break
+			}
+			fmt.Printf("  - %s\n", f)
+		}
+	}
+	return ok
+}
+
+// sumOf 读取累计值，不存在时为 0
+func sumOf[K comparable](m map[K]money.Decimal, key K) money.Decimal {
+	if v, ok := m[key]; ok {
+		return v
+	}
+	return money.Zero
+}
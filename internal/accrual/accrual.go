@@ -0,0 +1,75 @@
+// internal/accrual/accrual.go
+package accrual
+
+import (
+	"fmt"
+
+	"monera-digital/internal/money"
+)
+
+// DayCount 计息基准：每个自然日计一天，年化天数由基准决定
+type DayCount string
+
+const (
+	DayCountACT365 DayCount = "ACT/365"
+	DayCountACT360 DayCount = "ACT/360"
+)
+
+// ParseDayCount 解析产品配置的计息基准，为空时为 ACT/365
+func ParseDayCount(s string) (DayCount, error) {
+	switch DayCount(s) {
+	case "", DayCountACT365:
+		return DayCountACT365, nil
+	case DayCountACT360:
+		return DayCountACT360, nil
+	}
+	return "", fmt.Errorf("unknown day count convention %q", s)
+}
+
+// DaysPerYear 年化天数；未知或为空的基准按 ACT/365 处理
+func (d DayCount) DaysPerYear() int64 {
+	if d == DayCountACT360 {
+		return 360
+	}
+	return 365
+}
+
+// DailyInterest returns one day of interest on principal at apy (a percentage,
+// e.g. 5.5) under the basis day count, quantized to the currency's smallest unit.
+//
+// Accrual always books this quantized daily amount, so the sum of daily
+// accruals reconciles exactly with ExpectedInterest.
+func DailyInterest(currency string, principal, apy money.Decimal, basis DayCount) money.Decimal {
+	spec := money.SpecFor(currency)
+	return principal.Mul(apy).Div(money.NewFromInt(100*basis.DaysPerYear()), spec.Scale, spec.Rounding)
+}
+
+// ExpectedInterest returns the interest for days full days of accrual
+func ExpectedInterest(currency string, principal, apy money.Decimal, days int, basis DayCount) money.Decimal {
+	return DailyInterest(currency, principal, apy, basis).Mul(money.NewFromInt(int64(days)))
+}
+
+// Tier 分档利率：本金落在 [Floor, 下一档 Floor) 的部分按 APY 计息
+type Tier struct {
+	Floor money.Decimal
+	APY   money.Decimal
+}
+
+// TieredDailyInterest returns one day of interest on principal under tiered
+// rates. Tiers must be sorted by Floor with the first Floor at 0. Each tier
+// applies only to the slice of principal inside it, and the sum is quantized
+// once, so a single tier gives exactly DailyInterest.
+func TieredDailyInterest(currency string, principal money.Decimal, tiers []Tier, basis DayCount) money.Decimal {
+	weighted := money.Zero
+	for i, tier := range tiers {
+		upper := principal
+		if i+1 < len(tiers) {
+			upper = money.Min(principal, tiers[i+1].Floor)
+		}
+		if slice := upper.Sub(tier.Floor); slice.IsPositive() {
+			weighted = weighted.Add(slice.Mul(tier.APY))
+		}
+	}
+	spec := money.SpecFor(currency)
+	return weighted.Div(money.NewFromInt(100*basis.DaysPerYear()), spec.Scale, spec.Rounding)
+}
@@ -0,0 +1,80 @@
+package accrual
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"monera-digital/internal/money"
+)
+
+func TestTieredDailyInterest(t *testing.T) {
+	tiers := []Tier{
+		{Floor: money.Zero, APY: money.MustParse("8")},
+		{Floor: money.MustParse("10000"), APY: money.MustParse("4")},
+		{Floor: money.MustParse("50000"), APY: money.MustParse("1")},
+	}
+
+	tests := []struct {
+		name      string
+		principal string
+		want      string
+	}{
+		{"inside first tier", "4000", "0.876712"},
+		{"at tier boundary", "10000", "2.191780"},
+		{"spans two tiers", "15000", "2.739726"},
+		// (10000*8 + 40000*4 + 10000*1) / 36500
+		{"spans all tiers", "60000", "6.849315"},
+		{"zero principal", "0", "0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := TieredDailyInterest("USDT", money.MustParse(tt.principal), tiers, DayCountACT365)
+			assert.Equal(t, money.MustParse(tt.want), got)
+		})
+	}
+}
+
+func TestTieredDailyInterest_SingleTierMatchesDailyInterest(t *testing.T) {
+	principal := money.MustParse("12345.67")
+	apy := money.MustParse("5.5")
+
+	got := TieredDailyInterest("USDT", principal, []Tier{{Floor: money.Zero, APY: apy}}, DayCountACT365)
+
+	assert.Equal(t, DailyInterest("USDT", principal, apy, DayCountACT365), got)
+}
+
+func TestDailyInterest_DayCount(t *testing.T) {
+	principal := money.MustParse("10000")
+	apy := money.MustParse("5.5")
+
+	tests := []struct {
+		basis DayCount
+		want  string
+	}{
+		// 10000 * 5.5 / 36500
+		{DayCountACT365, "1.506849"},
+		// 10000 * 5.5 / 36000
+		{DayCountACT360, "1.527777"},
+		{"", "1.506849"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.basis), func(t *testing.T) {
+			assert.Equal(t, money.MustParse(tt.want), DailyInterest("USDT", principal, apy, tt.basis))
+		})
+	}
+}
+
+func TestParseDayCount(t *testing.T) {
+	got, err := ParseDayCount("")
+	assert.NoError(t, err)
+	assert.Equal(t, DayCountACT365, got)
+
+	got, err = ParseDayCount("ACT/360")
+	assert.NoError(t, err)
+	assert.Equal(t, DayCountACT360, got)
+
+	_, err = ParseDayCount("30/360")
+	assert.Error(t, err)
+}
@@ -0,0 +1,68 @@
+package accrual
+
+import (
+	"fmt"
+	"time"
+)
+
+// DateLayout 营业日的字符串格式
+const DateLayout = "2006-01-02"
+
+// Calendar 营业日规则：结算时区与日切时间。
+//
+// Business date D covers local wall-clock time [D-1 Cutoff, D Cutoff); with a
+// zero Cutoff it is simply the local calendar date. Cutoff is compared against
+// the wall clock rather than elapsed time, so days that are 23 or 25 hours long
+// around DST changes still close at the same local time.
+//
+// Dates are represented as midnight UTC so date arithmetic with AddDate is
+// unaffected by the settlement time zone.
+type Calendar struct {
+	Location *time.Location
+	// Cutoff 日切时间距当地零点的时长，取值 [0, 24h)
+	Cutoff time.Duration
+}
+
+// ParseCutoff 解析 HH:MM 格式的日切时间，为空时为零点
+func ParseCutoff(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid cutoff time %q, want HH:MM", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// BusinessDate 返回时刻 t 所属的营业日
+func (c Calendar) BusinessDate(t time.Time) time.Time {
+	local := t.In(c.Location)
+	date := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+	wallClock := time.Duration(local.Hour())*time.Hour +
+		time.Duration(local.Minute())*time.Minute +
+		time.Duration(local.Second())*time.Second +
+		time.Duration(local.Nanosecond())
+	if c.Cutoff > 0 && wallClock >= c.Cutoff {
+		date = date.AddDate(0, 0, 1)
+	}
+	return date
+}
+
+// Today 返回时刻 t 所属营业日的字符串形式
+func (c Calendar) Today(t time.Time) string {
+	return c.BusinessDate(t).Format(DateLayout)
+}
+
+// Start 返回营业日 date 开始的时刻，即前一自然日的日切时间（无日切时为当日零点）。
+// A cutoff that falls inside a DST gap is normalized by time.Date, so keep
+// cutoffs outside the 01:00-03:00 window for zones that observe DST.
+func (c Calendar) Start(date time.Time) time.Time {
+	if c.Cutoff == 0 {
+		return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, c.Location)
+	}
+	prev := date.AddDate(0, 0, -1)
+	hour := int(c.Cutoff / time.Hour)
+	minute := int(c.Cutoff % time.Hour / time.Minute)
+	return time.Date(prev.Year(), prev.Month(), prev.Day(), hour, minute, 0, 0, c.Location)
+}
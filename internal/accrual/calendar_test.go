@@ -0,0 +1,130 @@
+package accrual
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustLoad(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	require.NoError(t, err)
+	return loc
+}
+
+func utc(s string) time.Time {
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestParseCutoff(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"", 0, false},
+		{"00:00", 0, false},
+		{"15:00", 15 * time.Hour, false},
+		{"23:59", 23*time.Hour + 59*time.Minute, false},
+		{"24:00", 0, true},
+		{"15:00:00", 0, true},
+		{"3pm", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseCutoff(tt.in)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCalendar_BusinessDate(t *testing.T) {
+	shanghai := mustLoad(t, "Asia/Shanghai")
+	newYork := mustLoad(t, "America/New_York")
+
+	tests := []struct {
+		name   string
+		loc    *time.Location
+		cutoff time.Duration
+		at     string
+		want   string
+	}{
+		{"shanghai last instant of the day", shanghai, 0, "2026-03-01T15:59:59.999999999Z", "2026-03-01"},
+		{"shanghai local midnight", shanghai, 0, "2026-03-01T16:00:00Z", "2026-03-02"},
+		{"shanghai midnight crosses the year", shanghai, 0, "2026-12-31T16:00:00Z", "2027-01-01"},
+		{"shanghai before cutoff", shanghai, 15 * time.Hour, "2026-03-01T06:59:59Z", "2026-03-01"},
+		{"shanghai at cutoff", shanghai, 15 * time.Hour, "2026-03-01T07:00:00Z", "2026-03-02"},
+		{"shanghai cutoff rolls the month", shanghai, 15 * time.Hour, "2026-02-28T07:00:00Z", "2026-03-01"},
+		// 2026-03-08 02:00 EST 拨快至 03:00 EDT，当日只有 23 小时
+		{"new york before spring forward", newYork, 0, "2026-03-08T04:59:59Z", "2026-03-07"},
+		{"new york midnight before spring forward", newYork, 0, "2026-03-08T05:00:00Z", "2026-03-08"},
+		{"new york end of 23-hour day", newYork, 0, "2026-03-09T03:59:59Z", "2026-03-08"},
+		{"new york midnight after spring forward", newYork, 0, "2026-03-09T04:00:00Z", "2026-03-09"},
+		// 2026-11-01 02:00 EDT 拨回至 01:00 EST，当日有 25 小时
+		{"new york midnight before fall back", newYork, 0, "2026-11-01T04:00:00Z", "2026-11-01"},
+		{"new york repeated hour", newYork, 0, "2026-11-01T06:30:00Z", "2026-11-01"},
+		{"new york end of 25-hour day", newYork, 0, "2026-11-02T04:59:59Z", "2026-11-01"},
+		{"new york midnight after fall back", newYork, 0, "2026-11-02T05:00:00Z", "2026-11-02"},
+		{"new york cutoff in EST", newYork, 17 * time.Hour, "2026-03-07T22:00:00Z", "2026-03-08"},
+		{"new york before cutoff in EDT", newYork, 17 * time.Hour, "2026-03-08T20:59:59Z", "2026-03-08"},
+		{"new york cutoff in EDT", newYork, 17 * time.Hour, "2026-03-08T21:00:00Z", "2026-03-09"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := Calendar{Location: tt.loc, Cutoff: tt.cutoff}
+			assert.Equal(t, tt.want, c.Today(utc(tt.at)))
+		})
+	}
+}
+
+func TestCalendar_Start(t *testing.T) {
+	shanghai := mustLoad(t, "Asia/Shanghai")
+	newYork := mustLoad(t, "America/New_York")
+
+	tests := []struct {
+		name   string
+		loc    *time.Location
+		cutoff time.Duration
+		date   string
+		want   string
+	}{
+		{"shanghai midnight", shanghai, 0, "2026-03-02", "2026-03-01T16:00:00Z"},
+		{"shanghai cutoff on previous day", shanghai, 15 * time.Hour, "2026-03-01", "2026-02-28T07:00:00Z"},
+		{"new york spring forward day", newYork, 0, "2026-03-08", "2026-03-08T05:00:00Z"},
+		{"new york day after spring forward", newYork, 0, "2026-03-09", "2026-03-09T04:00:00Z"},
+		{"new york fall back day", newYork, 0, "2026-11-01", "2026-11-01T04:00:00Z"},
+		{"new york day after fall back", newYork, 0, "2026-11-02", "2026-11-02T05:00:00Z"},
+		{"new york cutoff across spring forward", newYork, 17 * time.Hour, "2026-03-09", "2026-03-08T21:00:00Z"},
+		{"new york cutoff before spring forward", newYork, 17 * time.Hour, "2026-03-08", "2026-03-07T22:00:00Z"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := Calendar{Location: tt.loc, Cutoff: tt.cutoff}
+			date, err := time.Parse(DateLayout, tt.date)
+			require.NoError(t, err)
+
+			start := c.Start(date)
+
+			assert.True(t, start.Equal(utc(tt.want)), "got %s", start.UTC())
+			// Start 是该营业日的第一个时刻
+			assert.Equal(t, tt.date, c.Today(start))
+			assert.Equal(t, date.AddDate(0, 0, -1).Format(DateLayout), c.Today(start.Add(-time.Nanosecond)))
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package binance
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"monera-digital/internal/money"
+)
+
+// PriceFeed 币种的 USDT 报价来源。
+// PriceService serves cached Binance prices; tests and the simulator use a
+// StaticPriceFeed so LTV checks run against fixed prices.
+type PriceFeed interface {
+	GetCachedPrice(currency string) (float64, bool)
+	// GetLastUpdateTime 报价最近一次成功刷新的时间，用于判断报价是否过期
+	GetLastUpdateTime() time.Time
+}
+
+var _ PriceFeed = (*PriceService)(nil)
+
+// StaticPriceFeed 手动设置的固定报价，并发安全
+type StaticPriceFeed struct {
+	mu        sync.RWMutex
+	prices    map[string]float64
+	updatedAt time.Time
+}
+
+// NewStaticPriceFeed 创建包含 prices 的报价源，刷新时间为当前时间
+func NewStaticPriceFeed(prices map[string]float64) *StaticPriceFeed {
+	f := &StaticPriceFeed{prices: make(map[string]float64, len(prices)), updatedAt: time.Now()}
+	for currency, price := range prices {
+		f.prices[currency] = price
+	}
+	return f
+}
+
+func (f *StaticPriceFeed) GetCachedPrice(currency string) (float64, bool) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	price, ok := f.prices[currency]
+	return price, ok
+}
+
+func (f *StaticPriceFeed) GetLastUpdateTime() time.Time {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.updatedAt
+}
+
+// Set 设置币种的报价并刷新更新时间
+func (f *StaticPriceFeed) Set(currency string, price float64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.prices[currency] = price
+	f.updatedAt = time.Now()
+}
+
+// SetUpdatedAt 设置报价的刷新时间，用于模拟报价停止刷新
+func (f *StaticPriceFeed) SetUpdatedAt(t time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.updatedAt = t
+}
+
+// PriceOf 以 Decimal 返回币种报价；稳定币固定为 1，没有报价或报价非正时 ok 为 false
+func PriceOf(feed PriceFeed, currency string) (money.Decimal, bool) {
+	switch currency {
+	case "USDT", "USDC", "DAI":
+		return money.NewFromInt(1), true
+	}
+	price, ok := feed.GetCachedPrice(currency)
+	if !ok || price <= 0 {
+		return money.Zero, false
+	}
+	d, err := money.Parse(strconv.FormatFloat(price, 'f', -1, 64))
+	if err != nil {
+		return money.Zero, false
+	}
+	return d, true
+}
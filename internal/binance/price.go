@@ -1,6 +1,7 @@
 package binance
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -26,8 +27,6 @@ type PriceService struct {
 	httpClient *http.Client
 	cache      *PriceCache
 	cacheMu    sync.RWMutex
-	ticker     *time.Ticker
-	stopChan   chan struct{}
 }
 
 var (
@@ -46,39 +45,17 @@ func NewPriceService() *PriceService {
 				Prices:    make(map[string]float64),
 				UpdatedAt: time.Now(),
 			},
-			stopChan: make(chan struct{}),
 		}
-
-		go instance.startBackgroundFetcher()
 	})
 	return instance
 }
 
-func (s *PriceService) startBackgroundFetcher() {
-	fetchInterval := 5 * time.Minute
-	s.ticker = time.NewTicker(fetchInterval)
-
-	s.fetchAllPrices()
-
-	for {
-		select {
-		case <-s.ticker.C:
-			s.fetchAllPrices()
-		case <-s.stopChan:
-			log.Println("[PriceService] Background fetcher stopped")
-			return
-		}
-	}
+// Refresh 拉取最新价格写入缓存，由调度器的价格刷新任务定期调用
+func (s *PriceService) Refresh(ctx context.Context) error {
+	return s.fetchAllPrices(ctx)
 }
 
-func (s *PriceService) Stop() {
-	if s.ticker != nil {
-		s.ticker.Stop()
-	}
-	close(s.stopChan)
-}
-
-func (s *PriceService) fetchAllPrices() {
+func (s *PriceService) fetchAllPrices(ctx context.Context) error {
 	symbols := []string{"BTC", "ETH", "SOL", "ADA", "XRP", "DOGE"}
 
 	symbolStrings := make([]string, len(symbols))
@@ -87,10 +64,14 @@ func (s *PriceService) fetchAllPrices() {
 	}
 	url := s.baseURL + "/api/v3/ticker/price?symbols=[" + strings.Join(symbolStrings, ",") + "]"
 
-	resp, err := s.httpClient.Get(url)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.httpClient.Do(req)
 	if err != nil {
 		log.Printf("[PriceService] Failed to fetch prices: %v", err)
-		return
+		return fmt.Errorf("failed to fetch prices: %v", err)
 	}
 	defer resp.Body.Close()
 
@@ -98,19 +79,19 @@ func (s *PriceService) fetchAllPrices() {
 		log.Printf("[PriceService] HTTP error: %d", resp.StatusCode)
 		body, _ := io.ReadAll(resp.Body)
 		log.Printf("[PriceService] Response body: %s", string(body))
-		return
+		return fmt.Errorf("price API returned HTTP %d", resp.StatusCode)
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		log.Printf("[PriceService] Failed to read response: %v", err)
-		return
+		return fmt.Errorf("failed to read price response: %v", err)
 	}
 
 	var priceList []PriceResponse
 	if err := json.Unmarshal(body, &priceList); err != nil {
 		log.Printf("[PriceService] Failed to parse JSON: %v", err)
-		return
+		return fmt.Errorf("failed to parse price response: %v", err)
 	}
 
 	s.cacheMu.Lock()
@@ -125,6 +106,7 @@ func (s *PriceService) fetchAllPrices() {
 	s.cacheMu.Unlock()
 
 	log.Printf("[PriceService] Prices updated successfully at %s", s.cache.UpdatedAt.Format("2006-01-02 15:04:05"))
+	return nil
 }
 
 func (s *PriceService) GetCachedPrice(currency string) (float64, bool) {
@@ -152,7 +134,7 @@ func (s *PriceService) GetLastUpdateTime() time.Time {
 }
 
 func (s *PriceService) FetchAllPricesForAPI() {
-	s.fetchAllPrices()
+	s.fetchAllPrices(context.Background())
 }
 
 func (s *PriceService) GetUSDValueFromCache(amount float64, currency string) float64 {
@@ -2,36 +2,22 @@
 package cache
 
 import (
-	"context"
 	"sync"
 	"time"
 )
 
 // TokenBlacklist 令牌黑名单
+// 过期令牌由调度器的清理任务定期调用 CleanupExpired 移除
 type TokenBlacklist struct {
 	tokens map[string]time.Time
 	mu     sync.RWMutex
-	ticker *time.Ticker
-	done   chan bool
-	ctx    context.Context
-	cancel context.CancelFunc
 }
 
 // NewTokenBlacklist 创建令牌黑名单
 func NewTokenBlacklist() *TokenBlacklist {
-	ctx, cancel := context.WithCancel(context.Background())
-	tb := &TokenBlacklist{
+	return &TokenBlacklist{
 		tokens: make(map[string]time.Time),
-		ticker: time.NewTicker(1 * time.Hour),
-		done:   make(chan bool, 1),
-		ctx:    ctx,
-		cancel: cancel,
 	}
-
-	// 启动清理过期令牌的后台任务
-	go tb.cleanupExpiredTokens()
-
-	return tb
 }
 
 // Add 添加令牌到黑名单
@@ -60,30 +46,20 @@ func (tb *TokenBlacklist) IsBlacklisted(token string) bool {
 	return true
 }
 
-// cleanupExpiredTokens 清理过期的令牌
-func (tb *TokenBlacklist) cleanupExpiredTokens() {
-	for {
-		select {
-		case <-tb.ticker.C:
-			tb.mu.Lock()
-			now := time.Now()
-			for token, expiry := range tb.tokens {
-				if now.After(expiry) {
-					delete(tb.tokens, token)
-				}
-			}
-			tb.mu.Unlock()
+// CleanupExpired 清理过期的令牌，返回清理数量
+func (tb *TokenBlacklist) CleanupExpired() int {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
 
-		case <-tb.ctx.Done():
-			tb.ticker.Stop()
-			return
+	removed := 0
+	now := time.Now()
+	for token, expiry := range tb.tokens {
+		if now.After(expiry) {
+			delete(tb.tokens, token)
+			removed++
 		}
 	}
-}
-
-// Close 关闭黑名单
-func (tb *TokenBlacklist) Close() {
-	tb.cancel()
+	return removed
 }
 
 // Size 获取黑名单中的令牌数量
@@ -0,0 +1,24 @@
+package clock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSystem_ReturnsWallClock(t *testing.T) {
+	assert.WithinDuration(t, time.Now(), System.Now(), time.Second)
+}
+
+func TestFake_SetAndAdvance(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewFake(start)
+	assert.Equal(t, start, c.Now())
+
+	c.Advance(36 * time.Hour)
+	assert.Equal(t, time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC), c.Now())
+
+	c.Set(start)
+	assert.Equal(t, start, c.Now())
+}
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"strings"
 	"sync"
 	"time"
 
@@ -14,6 +15,20 @@ type Config struct {
 	JWTSecret     string
 	EncryptionKey string
 	TimeZone      string
+	AdminEmails   []string // 可访问 /api/admin 的用户邮箱
+	Safeheron     SafeheronConfig
+}
+
+// SafeheronConfig Safeheron 托管出金接入配置，密钥为 PEM 或 Base64 DER
+type SafeheronConfig struct {
+	BaseURL           string
+	APIKey            string
+	PrivateKey        string
+	PlatformPublicKey string
+	WebhookPublicKey  string // Safeheron 回调签名公钥，为空时使用 PlatformPublicKey
+	WebhookPrivateKey string // 回调解密私钥，为空时使用 PrivateKey
+	AccountKey        string
+	CoinKeys          map[string]string // "USDT/TRC20" -> coinKey
 }
 
 // 全局时区配置
@@ -43,11 +58,45 @@ func Load() *Config {
 		JWTSecret:     viper.GetString("JWT_SECRET"),
 		EncryptionKey: viper.GetString("ENCRYPTION_KEY"),
 		TimeZone:      viper.GetString("TIME_ZONE"),
+		AdminEmails:   parseList(viper.GetString("ADMIN_EMAILS")),
+		Safeheron: SafeheronConfig{
+			BaseURL:           viper.GetString("SAFEHERON_BASE_URL"),
+			APIKey:            viper.GetString("SAFEHERON_API_KEY"),
+			PrivateKey:        viper.GetString("SAFEHERON_PRIVATE_KEY"),
+			PlatformPublicKey: viper.GetString("SAFEHERON_PLATFORM_PUBLIC_KEY"),
+			WebhookPublicKey:  viper.GetString("SAFEHERON_WEBHOOK_PUBLIC_KEY"),
+			WebhookPrivateKey: viper.GetString("SAFEHERON_WEBHOOK_PRIVATE_KEY"),
+			AccountKey:        viper.GetString("SAFEHERON_ACCOUNT_KEY"),
+			CoinKeys:          parseMap(viper.GetString("SAFEHERON_COIN_KEYS")),
+		},
 	}
 
 	return cfg
 }
 
+// parseList splits a comma-separated setting, dropping blanks.
+func parseList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseMap parses a comma-separated list of key=value pairs, dropping malformed entries.
+func parseMap(value string) map[string]string {
+	items := make(map[string]string)
+	for _, item := range parseList(value) {
+		k, v, ok := strings.Cut(item, "=")
+		if k, v = strings.TrimSpace(k), strings.TrimSpace(v); ok && k != "" && v != "" {
+			items[strings.ToUpper(k)] = v
+		}
+	}
+	return items
+}
+
 // GetLocation returns the configured timezone location.
 // Falls back to UTC+8 (Asia/Shanghai) if timezone is invalid or unavailable.
 func GetLocation() *time.Location {
@@ -6,12 +6,15 @@ import (
 	"database/sql"
 	"log"
 	"os"
+	"time"
 
+	"monera-digital/internal/binance"
 	"monera-digital/internal/cache"
 	"monera-digital/internal/coreapi"
 	"monera-digital/internal/middleware"
 	"monera-digital/internal/repository"
 	"monera-digital/internal/repository/postgres"
+	"monera-digital/internal/scheduler"
 	"monera-digital/internal/services"
 )
 
@@ -38,6 +41,25 @@ func WithEncryption(key string) ContainerOption {
 	}
 }
 
+// WithAdminEmails 配置可访问管理接口的用户邮箱
+func WithAdminEmails(emails []string) ContainerOption {
+	return func(c *Container) {
+		c.AdminEmails = emails
+	}
+}
+
+// WithSafeheron 配置 Safeheron 托管出金客户端；配置缺失或无效时提现不可用
+func WithSafeheron(cfg services.SafeheronConfig) ContainerOption {
+	return func(c *Container) {
+		client, err := services.NewSafeheronService(cfg)
+		if err != nil {
+			log.Printf("Warning: Safeheron custody disabled: %v", err)
+			return
+		}
+		c.WithdrawalService.SetCustodyClient(client)
+	}
+}
+
 // WithRedisCache 配置 Redis 缓存服务
 func WithRedisCache(redisCache *cache.RedisCache) ContainerOption {
 	return func(c *Container) {
@@ -60,7 +82,8 @@ type Container struct {
 	DB *sql.DB
 
 	// 配置
-	JWTSecret string
+	JWTSecret   string
+	AdminEmails []string
 
 	// 缓存
 	TokenBlacklist *cache.TokenBlacklist
@@ -76,10 +99,14 @@ type Container struct {
 
 	// 仓储
 	Repository *repository.Repository
+	UnitOfWork repository.UnitOfWork
 
 	// 服务
 	AuthService       *services.AuthService
 	LendingService    *services.LendingService
+	LoanService       *services.LoanService
+	TransferService   *services.AccountTransferService
+	P2PService        *services.P2PTransferService
 	AddressService    *services.AddressService
 	WithdrawalService *services.WithdrawalService
 	DepositService    *services.DepositService
@@ -88,6 +115,12 @@ type Container struct {
 	EncryptionService *services.EncryptionService
 	TwoFAService      *services.TwoFactorService
 
+	// 定时任务
+	InterestScheduler *scheduler.InterestScheduler
+	LendingScheduler  *scheduler.LendingScheduler
+	LoanMonitor       *scheduler.LoanMonitor
+	JobRegistry       *scheduler.Registry
+
 	// 中间件
 	RateLimitMiddleware *middleware.PerEndpointRateLimiter
 }
@@ -112,24 +145,32 @@ func NewContainer(db *sql.DB, jwtSecret string, opts ...ContainerOption) *Contai
 		User:       postgres.NewUserRepository(db),
 		Deposit:    postgres.NewDepositRepository(db),
 		Wallet:     postgres.NewWalletRepository(db),
-		Account:    postgres.NewAccountRepositoryV1(db),
 		AccountV2:  postgres.NewAccountRepository(db),
 		Address:    postgres.NewAddressRepository(db),
 		Withdrawal: postgres.NewWithdrawalRepository(db),
+		Lending:    postgres.NewLendingRepository(db),
+		Loan:       postgres.NewLoanRepository(db),
+		P2P:        postgres.NewP2PTransferRepository(db),
 		Wealth:     postgres.NewWealthRepository(db),
 		Journal:    postgres.NewJournalRepository(db),
+		Scheduler:  postgres.NewSchedulerRunRepository(db),
+		Jobs:       postgres.NewSchedulerJobRepository(db),
 	}
+	c.UnitOfWork = postgres.NewUnitOfWork(db)
 
 	// 初始化核心服务
 	c.AuthService = services.NewAuthService(db, jwtSecret)
 	c.AuthService.SetTokenBlacklist(c.TokenBlacklist)
 
-	c.LendingService = services.NewLendingService(db)
+	c.LendingService = services.NewLendingService(c.Repository.Lending, c.Repository.AccountV2, c.Repository.Journal, c.UnitOfWork)
+	c.LoanService = services.NewLoanService(c.Repository.Loan, c.Repository.AccountV2, c.UnitOfWork, binance.NewPriceService())
+	c.TransferService = services.NewAccountTransferService(c.Repository.AccountV2, c.UnitOfWork)
+	c.P2PService = services.NewP2PTransferService(c.Repository.P2P, c.Repository.User, c.UnitOfWork)
 	c.AddressService = services.NewAddressService(c.Repository.Address)
-	c.WithdrawalService = services.NewWithdrawalService(db, c.Repository, services.NewSafeheronService())
+	c.WithdrawalService = services.NewWithdrawalService(c.Repository, c.UnitOfWork, nil)
 	c.DepositService = services.NewDepositService(c.Repository.Deposit)
 	c.WalletService = services.NewWalletService(c.Repository.Wallet, c.CoreAPIClient)
-	c.WealthService = services.NewWealthService(c.Repository.Wealth, c.Repository.AccountV2, c.Repository.Journal)
+	c.WealthService = services.NewWealthService(c.Repository.Wealth, c.Repository.AccountV2, c.Repository.Journal, c.UnitOfWork)
 
 	// 应用配置选项 (按顺序执行)
 	for _, opt := range opts {
@@ -139,8 +180,16 @@ func NewContainer(db *sql.DB, jwtSecret string, opts ...ContainerOption) *Contai
 	// 注入TwoFactorService依赖（如果在选项函数中已初始化）
 	if c.TwoFAService != nil {
 		c.AuthService.SetTwoFactorService(c.TwoFAService)
+		c.P2PService.SetTwoFactorVerifier(c.TwoFAService)
 	}
 
+	// 初始化定时任务（依赖选项函数中创建的幂等仓储，需在其后注册）
+	c.InterestScheduler = scheduler.NewInterestScheduler(c.Repository.Wealth, c.Repository.AccountV2, c.Repository.Journal, c.Repository.Scheduler, c.UnitOfWork)
+	c.LendingScheduler = scheduler.NewLendingScheduler(c.Repository.Lending, c.Repository.AccountV2, c.UnitOfWork)
+	c.LoanMonitor = scheduler.NewLoanMonitor(c.Repository.Loan, c.Repository.AccountV2, c.UnitOfWork, binance.NewPriceService())
+	c.JobRegistry = scheduler.NewRegistry(c.Repository.Jobs, c.Repository.Scheduler)
+	c.registerJobs()
+
 	// 初始化中间件
 	c.RateLimitMiddleware = middleware.NewPerEndpointRateLimiter()
 	c.RateLimitMiddleware.AddEndpoint("/api/auth/register", 5, 60)
@@ -150,11 +199,67 @@ func NewContainer(db *sql.DB, jwtSecret string, opts ...ContainerOption) *Contai
 	return c
 }
 
+// registerJobs 注册所有定时任务，由 JobRegistry 统一调度
+func (c *Container) registerJobs() {
+	jobs := []scheduler.Job{
+		c.InterestScheduler.Job(),
+		c.LendingScheduler.Job(),
+		c.LoanMonitor.Job(),
+		{
+			Name:       "price_refresh",
+			Schedule:   "*/5 * * * *",
+			Timeout:    30 * time.Second,
+			Retries:    1,
+			RetryDelay: 10 * time.Second,
+			RunOnStart: true,
+			Run:        binance.NewPriceService().Refresh,
+		},
+		{
+			Name:       services.WithdrawalReconcileJobName,
+			Schedule:   "*/5 * * * *",
+			Timeout:    4 * time.Minute,
+			Retries:    1,
+			RetryDelay: 30 * time.Second,
+			Singleton:  true,
+			Run: func(ctx context.Context) error {
+				_, err := c.WithdrawalService.ReconcilePending(ctx)
+				return err
+			},
+		},
+		{
+			Name:     "token_blacklist_cleanup",
+			Schedule: "@hourly",
+			Timeout:  time.Minute,
+			Run: func(ctx context.Context) error {
+				c.TokenBlacklist.CleanupExpired()
+				return nil
+			},
+		},
+	}
+	if c.IdempotencyRepository != nil {
+		jobs = append(jobs, scheduler.Job{
+			Name:       "idempotency_expiry",
+			Schedule:   "30 * * * *",
+			Timeout:    5 * time.Minute,
+			Retries:    2,
+			RetryDelay: 30 * time.Second,
+			Singleton:  true,
+			Run: func(ctx context.Context) error {
+				_, err := c.IdempotencyRepository.DeleteExpired(ctx)
+				return err
+			},
+		})
+	}
+
+	for _, job := range jobs {
+		if err := c.JobRegistry.Register(job); err != nil {
+			log.Printf("Warning: Failed to register job %s: %v", job.Name, err)
+		}
+	}
+}
+
 // Close 关闭容器中的资源
 func (c *Container) Close() error {
-	if c.TokenBlacklist != nil {
-		c.TokenBlacklist.Close()
-	}
 	if c.DB != nil {
 		return c.DB.Close()
 	}
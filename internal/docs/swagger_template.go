@@ -214,6 +214,396 @@ const swaggerTemplate = `{
         }
       }
     },
+    "/lending/rates": {
+      "get": {
+        "summary": "Get lending rates",
+        "description": "Retrieve the lending rate curve currently in effect for each asset. A position uses the APY of the highest bucket whose minDurationDays does not exceed its duration, fixed when the position is opened",
+        "responses": {
+          "200": {
+            "description": "Rate curves by asset"
+          }
+        }
+      }
+    },
+    "/lending/positions/{id}/terminate": {
+      "post": {
+        "summary": "Terminate lending position",
+        "description": "Terminate an active lending position before maturity. The principal is unfrozen and accrued yield is forfeited",
+        "security": [
+          {
+            "Bearer": []
+          }
+        ],
+        "parameters": [
+          {
+            "name": "id",
+            "in": "path",
+            "required": true,
+            "type": "integer"
+          }
+        ],
+        "responses": {
+          "200": {
+            "description": "Lending position terminated",
+            "schema": {
+              "$ref": "#/definitions/LendingPositionResponse"
+            }
+          },
+          "401": {
+            "description": "Unauthorized",
+            "schema": {
+              "$ref": "#/definitions/ErrorResponse"
+            }
+          },
+          "404": {
+            "description": "Position not found",
+            "schema": {
+              "$ref": "#/definitions/ErrorResponse"
+            }
+          },
+          "409": {
+            "description": "Position already closed or matured",
+            "schema": {
+              "$ref": "#/definitions/ErrorResponse"
+            }
+          }
+        }
+      }
+    },
+    "/webhooks/custody/withdrawal": {
+      "post": {
+        "summary": "Custody withdrawal status callback",
+        "description": "Safeheron webhook. The envelope is signed with the Safeheron webhook RSA key (SHA256withRSA over the non-empty envelope fields sorted by name) and bizContent is AES-GCM encrypted with a key wrapped by our webhook public key; timestamps more than 5 minutes off are rejected. TRANSACTION_STATUS_CHANGED events are matched by customerRefId and the transaction status is mapped to PROCESSING, CONFIRMED or FAILED; other events are acknowledged and ignored. A FAILED withdrawal that was already debited is refunded automatically. Repeating a status the order already has is a no-op",
+        "parameters": [
+          {
+            "name": "body",
+            "in": "body",
+            "required": true,
+            "schema": {
+              "type": "object",
+              "properties": {
+                "timestamp": {"type": "string"},
+                "key": {"type": "string"},
+                "bizContent": {"type": "string"},
+                "sig": {"type": "string"},
+                "rsaType": {"type": "string"},
+                "aesType": {"type": "string"}
+              }
+            }
+          }
+        ],
+        "responses": {
+          "200": {
+            "description": "Event acknowledged: {\"code\": \"200\", \"message\": \"SUCCESS\"}"
+          },
+          "401": {
+            "description": "Invalid signature, undecryptable content or stale timestamp",
+            "schema": {
+              "$ref": "#/definitions/ErrorResponse"
+            }
+          },
+          "404": {
+            "description": "Withdrawal not found",
+            "schema": {
+              "$ref": "#/definitions/ErrorResponse"
+            }
+          },
+          "409": {
+            "description": "Status transition not allowed",
+            "schema": {
+              "$ref": "#/definitions/ErrorResponse"
+            }
+          }
+        }
+      }
+    },
+    "/p2p/transfers": {
+      "get": {
+        "summary": "Get peer-to-peer transfers",
+        "description": "List transfers the user has sent or received, newest first. direction is OUT or IN relative to the user",
+        "security": [
+          {
+            "Bearer": []
+          }
+        ],
+        "parameters": [
+          {
+            "name": "limit",
+            "in": "query",
+            "required": false,
+            "type": "integer"
+          },
+          {
+            "name": "offset",
+            "in": "query",
+            "required": false,
+            "type": "integer"
+          }
+        ],
+        "responses": {
+          "200": {
+            "description": "List of transfers"
+          }
+        }
+      },
+      "post": {
+        "summary": "Send to another user",
+        "description": "Transfer USDT or USDC off-chain to another Monera user by email. Requires 2FA and an Idempotency-Key header; retrying with the same key returns the original transfer. Per-transfer and daily limits apply",
+        "security": [
+          {
+            "Bearer": []
+          }
+        ],
+        "parameters": [
+          {
+            "name": "Idempotency-Key",
+            "in": "header",
+            "required": true,
+            "type": "string"
+          },
+          {
+            "name": "body",
+            "in": "body",
+            "required": true,
+            "schema": {
+              "type": "object",
+              "properties": {
+                "toEmail": {"type": "string"},
+                "currency": {"type": "string", "enum": ["USDT", "USDC"]},
+                "amount": {"type": "string"},
+                "twoFactorCode": {"type": "string"}
+              }
+            }
+          }
+        ],
+        "responses": {
+          "200": {
+            "description": "Transfer completed"
+          },
+          "400": {
+            "description": "Invalid transfer, insufficient balance or limit exceeded",
+            "schema": {
+              "$ref": "#/definitions/ErrorResponse"
+            }
+          },
+          "401": {
+            "description": "Invalid 2FA code",
+            "schema": {
+              "$ref": "#/definitions/ErrorResponse"
+            }
+          },
+          "403": {
+            "description": "2FA not enabled",
+            "schema": {
+              "$ref": "#/definitions/ErrorResponse"
+            }
+          },
+          "404": {
+            "description": "Recipient not found",
+            "schema": {
+              "$ref": "#/definitions/ErrorResponse"
+            }
+          },
+          "422": {
+            "description": "Idempotency key reused with different parameters",
+            "schema": {
+              "$ref": "#/definitions/ErrorResponse"
+            }
+          }
+        }
+      }
+    },
+    "/accounts/internal-transfer": {
+      "post": {
+        "summary": "Transfer between own accounts",
+        "description": "Move balance between the user's FUND and WEALTH accounts of the same currency in one transaction. The destination account is opened if missing, and a pair of journal records plus a transfer record are written. Wealth subscriptions still settle against the FUND account, so WEALTH balance must be moved back before subscribing",
+        "security": [
+          {
+            "Bearer": []
+          }
+        ],
+        "parameters": [
+          {
+            "name": "body",
+            "in": "body",
+            "required": true,
+            "schema": {
+              "type": "object",
+              "properties": {
+                "fromType": {"type": "string", "enum": ["FUND", "WEALTH"]},
+                "toType": {"type": "string", "enum": ["FUND", "WEALTH"]},
+                "currency": {"type": "string"},
+                "amount": {"type": "string"}
+              }
+            }
+          }
+        ],
+        "responses": {
+          "200": {
+            "description": "Transfer completed"
+          },
+          "400": {
+            "description": "Invalid transfer or insufficient balance",
+            "schema": {
+              "$ref": "#/definitions/ErrorResponse"
+            }
+          },
+          "409": {
+            "description": "Account modified concurrently, retry the transfer",
+            "schema": {
+              "$ref": "#/definitions/ErrorResponse"
+            }
+          }
+        }
+      }
+    },
+    "/loans": {
+      "get": {
+        "summary": "Get user loans",
+        "description": "Retrieve the user's crypto-backed loans with their LTV at the current collateral price",
+        "security": [
+          {
+            "Bearer": []
+          }
+        ],
+        "responses": {
+          "200": {
+            "description": "List of loans"
+          },
+          "401": {
+            "description": "Unauthorized",
+            "schema": {
+              "$ref": "#/definitions/ErrorResponse"
+            }
+          }
+        }
+      },
+      "post": {
+        "summary": "Borrow stablecoins",
+        "description": "Borrow USDT or USDC against BTC or ETH collateral. The collateral is frozen in the user's account and the loan is disbursed to the stablecoin account. The initial LTV must not exceed the maximum initial LTV",
+        "security": [
+          {
+            "Bearer": []
+          }
+        ],
+        "parameters": [
+          {
+            "name": "body",
+            "in": "body",
+            "required": true,
+            "schema": {
+              "type": "object",
+              "properties": {
+                "collateralAsset": {"type": "string"},
+                "collateralAmount": {"type": "string"},
+                "borrowAsset": {"type": "string"},
+                "borrowAmount": {"type": "string"}
+              }
+            }
+          }
+        ],
+        "responses": {
+          "201": {
+            "description": "Loan created"
+          },
+          "400": {
+            "description": "Invalid request, insufficient collateral or LTV too high",
+            "schema": {
+              "$ref": "#/definitions/ErrorResponse"
+            }
+          },
+          "503": {
+            "description": "Collateral price unavailable",
+            "schema": {
+              "$ref": "#/definitions/ErrorResponse"
+            }
+          }
+        }
+      }
+    },
+    "/loans/{id}/repay": {
+      "post": {
+        "summary": "Repay loan",
+        "description": "Repay the loan principal in full from the stablecoin account and release the collateral",
+        "security": [
+          {
+            "Bearer": []
+          }
+        ],
+        "parameters": [
+          {
+            "name": "id",
+            "in": "path",
+            "required": true,
+            "type": "integer"
+          }
+        ],
+        "responses": {
+          "200": {
+            "description": "Loan repaid"
+          },
+          "404": {
+            "description": "Loan not found",
+            "schema": {
+              "$ref": "#/definitions/ErrorResponse"
+            }
+          },
+          "409": {
+            "description": "Loan already repaid or liquidated",
+            "schema": {
+              "$ref": "#/definitions/ErrorResponse"
+            }
+          }
+        }
+      }
+    },
+    "/loans/{id}/collateral": {
+      "post": {
+        "summary": "Add loan collateral",
+        "description": "Freeze additional collateral against an open loan to lower its LTV",
+        "security": [
+          {
+            "Bearer": []
+          }
+        ],
+        "parameters": [
+          {
+            "name": "id",
+            "in": "path",
+            "required": true,
+            "type": "integer"
+          },
+          {
+            "name": "body",
+            "in": "body",
+            "required": true,
+            "schema": {
+              "type": "object",
+              "properties": {
+                "amount": {"type": "string"}
+              }
+            }
+          }
+        ],
+        "responses": {
+          "200": {
+            "description": "Collateral added"
+          },
+          "404": {
+            "description": "Loan not found",
+            "schema": {
+              "$ref": "#/definitions/ErrorResponse"
+            }
+          },
+          "409": {
+            "description": "Loan already repaid or liquidated",
+            "schema": {
+              "$ref": "#/definitions/ErrorResponse"
+            }
+          }
+        }
+      }
+    },
     "/accounts": {
       "get": {
         "summary": "Get user accounts",
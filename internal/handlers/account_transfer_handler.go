@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"monera-digital/internal/services"
+)
+
+// AccountTransferHandler handles transfers between a user's own accounts
+type AccountTransferHandler struct {
+	base      *BaseHandler
+	transfers *services.AccountTransferService
+}
+
+// NewAccountTransferHandler creates a new account transfer handler
+func NewAccountTransferHandler(transfers *services.AccountTransferService) *AccountTransferHandler {
+	return &AccountTransferHandler{
+		base:      &BaseHandler{},
+		transfers: transfers,
+	}
+}
+
+// Transfer moves balance between the user's FUND and WEALTH accounts
+// POST /api/accounts/internal-transfer
+func (h *AccountTransferHandler) Transfer(c *gin.Context) {
+	userID, ok := h.base.requireUserID(c)
+	if !ok {
+		return
+	}
+
+	var req services.AccountTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.base.errorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+
+	transfer, err := h.transfers.Transfer(c.Request.Context(), userID, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidTransfer):
+			h.base.errorResponse(c, http.StatusBadRequest, "INVALID_TRANSFER", err.Error())
+		case errors.Is(err, services.ErrInsufficientBalance):
+			h.base.errorResponse(c, http.StatusBadRequest, "INSUFFICIENT_BALANCE", err.Error())
+		case errors.Is(err, services.ErrAccountBusy):
+			h.base.errorResponse(c, http.StatusConflict, "ACCOUNT_BUSY", err.Error())
+		default:
+			h.base.errorResponse(c, http.StatusInternalServerError, "TRANSFER_ERROR", err.Error())
+		}
+		return
+	}
+	h.base.successResponse(c, gin.H{"transfer": transfer})
+}
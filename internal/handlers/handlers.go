@@ -1,7 +1,6 @@
 package handlers
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
@@ -279,13 +278,13 @@ func (h *Handler) ApplyForLending(c *gin.Context) {
 		return
 	}
 
-	position, err := h.LendingService.ApplyForLending(userID, models.ApplyLendingRequest{
+	position, err := h.LendingService.ApplyForLending(c.Request.Context(), userID, models.ApplyLendingRequest{
 		Asset:        req.Asset,
 		Amount:       fmt.Sprintf("%.7f", req.Amount),
 		DurationDays: req.DurationDays,
 	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(lendingErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -299,7 +298,7 @@ func (h *Handler) GetUserPositions(c *gin.Context) {
 		return
 	}
 
-	positions, err := h.LendingService.GetUserPositions(userID)
+	positions, err := h.LendingService.GetUserPositions(c.Request.Context(), userID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -322,6 +321,54 @@ func (h *Handler) GetUserPositions(c *gin.Context) {
 	})
 }
 
+// GetLendingRates 返回各资产当前生效的利率曲线
+func (h *Handler) GetLendingRates(c *gin.Context) {
+	curves, err := h.LendingService.GetRates(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"rates": curves})
+}
+
+// TerminateLending 提前终止借贷仓位，本金解冻，已计收益不予派发
+func (h *Handler) TerminateLending(c *gin.Context) {
+	userID, err := h.getUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	positionID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid position ID"})
+		return
+	}
+
+	position, err := h.LendingService.TerminateLending(c.Request.Context(), userID, positionID)
+	if err != nil {
+		c.JSON(lendingErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, toLendingPositionResponse(position))
+}
+
+func lendingErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrLendingPositionNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrLendingPositionClosed), errors.Is(err, services.ErrLendingPositionMatured):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrInvalidLendingRequest), errors.Is(err, services.ErrInsufficientBalance),
+		errors.Is(err, services.ErrLendingRateNotFound):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
 // Address handlers
 
 func (h *Handler) GetAddresses(c *gin.Context) {
@@ -544,13 +591,27 @@ func (h *Handler) CreateWithdrawal(c *gin.Context) {
 
 	order, err := h.WithdrawalService.CreateWithdrawal(c.Request.Context(), userID, req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(withdrawalErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusCreated, gin.H{"message": "Withdrawal created", "order": order})
 }
 
+func withdrawalErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrInvalidWithdrawalAmount), errors.Is(err, services.ErrInsufficientBalance),
+		errors.Is(err, services.ErrWithdrawalAddressNotFound), errors.Is(err, services.ErrWithdrawalAddressNotOwned):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrCustodyInvalidAddress), errors.Is(err, services.ErrCustodyUnsupportedAsset):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrCustodyNotConfigured):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
 func (h *Handler) GetWithdrawalByID(c *gin.Context) {
 	userID, err := h.getUserID(c)
 	if err != nil {
@@ -666,10 +727,9 @@ func (h *Handler) Subscribe(c *gin.Context) {
 	}
 
 	var req struct {
-		ProductID        int64       `json:"productId" binding:"required"`
-		Amount           string      `json:"amount" binding:"required"`
-		AutoRenew        bool        `json:"autoRenew"`
-		InterestExpected interface{} `json:"interest_expected"`
+		QuoteID           string `json:"quoteId" binding:"required"`
+		AutoRenew         bool   `json:"autoRenew"`
+		AutoRenewCompound bool   `json:"autoRenewCompound"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -726,32 +786,10 @@ func (h *Handler) Subscribe(c *gin.Context) {
 		}
 	}()
 
-	// 处理interest_expected字段的类型转换
-	interestExpected := ""
-	if req.InterestExpected != nil {
-		switch v := req.InterestExpected.(type) {
-		case string:
-			interestExpected = v
-		case float64:
-			interestExpected = strconv.FormatFloat(v, 'f', -1, 64)
-		case int:
-			interestExpected = strconv.Itoa(v)
-		case json.Number:
-			interestExpected = v.String()
-		default:
-			interestExpected = fmt.Sprintf("%v", v)
-		}
-	}
-
-	// 验证转换后的值
-	if interestExpected == "" {
-		interestExpected = "0"
-	}
-
-	orderID, err := h.WealthService.Subscribe(c.Request.Context(), userID, req.ProductID, req.Amount, req.AutoRenew, interestExpected)
+	orderID, err := h.WealthService.Subscribe(c.Request.Context(), userID, req.QuoteID, req.AutoRenew, req.AutoRenewCompound)
 	if err != nil {
 		idempotencyErr = err
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(subscribeErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -761,6 +799,46 @@ func (h *Handler) Subscribe(c *gin.Context) {
 	c.JSON(http.StatusCreated, gin.H{"message": "Subscription successful", "orderId": orderID})
 }
 
+// SubscribeQuote 申购试算：返回服务端计算的预期收益、入账计划与到期日，以及下单用的报价 ID
+func (h *Handler) SubscribeQuote(c *gin.Context) {
+	userID, err := h.getUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req struct {
+		ProductID int64  `json:"productId" binding:"required"`
+		Amount    string `json:"amount" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	quote, err := h.WealthService.QuoteSubscription(c.Request.Context(), userID, req.ProductID, req.Amount)
+	if err != nil {
+		c.JSON(subscribeErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, quote)
+}
+
+func subscribeErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrProductNotFound), errors.Is(err, services.ErrQuoteNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrQuoteExpired), errors.Is(err, services.ErrQuotaExceeded):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrProductNotAvailable), errors.Is(err, services.ErrAmountBelowMin),
+		errors.Is(err, services.ErrAmountAboveMax), errors.Is(err, services.ErrInsufficientBalance):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
 func (h *Handler) GetOrders(c *gin.Context) {
 	userID, err := h.getUserID(c)
 	if err != nil {
@@ -780,27 +858,230 @@ func (h *Handler) GetOrders(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"orders": orders, "total": total, "page": page, "pageSize": pageSize})
 }
 
-func (h *Handler) Redeem(c *gin.Context) {
+// GetPortfolio 理财资产总览：按币种汇总的本金与利息、到期日历和美元估值
+func (h *Handler) GetPortfolio(c *gin.Context) {
 	userID, err := h.getUserID(c)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
 	}
 
+	portfolio, err := h.WealthService.GetPortfolio(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, portfolio)
+}
+
+// GetOrderDetail 订单详情：逐日计息记录、续期链与相关资金流水
+func (h *Handler) GetOrderDetail(c *gin.Context) {
+	userID, err := h.getUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	orderID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return
+	}
+
+	detail, err := h.WealthService.GetOrderDetail(c.Request.Context(), userID, orderID)
+	if err != nil {
+		if errors.Is(err, services.ErrOrderNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, detail)
+}
+
+func (h *Handler) GetOrderInterest(c *gin.Context) {
+	userID, err := h.getUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	orderID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return
+	}
+
+	records, err := h.WealthService.GetInterestHistory(c.Request.Context(), userID, orderID)
+	if err != nil {
+		if errors.Is(err, services.ErrOrderNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"orderId": orderID, "records": records})
+}
+
+// SetOrderCompound 切换定期订单续期时是否将利息滚入本金
+func (h *Handler) SetOrderCompound(c *gin.Context) {
+	userID, err := h.getUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	orderID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return
+	}
+
 	var req struct {
-		OrderID        int64  `json:"orderId" binding:"required"`
-		RedemptionType string `json:"redemptionType" binding:"required"`
+		Compound *bool `json:"compound" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.WealthService.SetAutoRenewCompound(c.Request.Context(), userID, orderID, *req.Compound); err != nil {
+		c.JSON(orderChangeErrorStatus(err), gin.H{"error": err.Error()})
+		return
 	}
 
+	c.JSON(http.StatusOK, gin.H{"orderId": orderID, "autoRenewCompound": *req.Compound})
+}
+
+// SetOrderAutoRenew 开启或关闭订单的自动续期，到期前截止时间后不可修改
+func (h *Handler) SetOrderAutoRenew(c *gin.Context) {
+	userID, err := h.getUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	orderID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return
+	}
+
+	var req struct {
+		AutoRenew *bool `json:"autoRenew" binding:"required"`
+	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	if err := h.WealthService.Redeem(c.Request.Context(), userID, req.OrderID, req.RedemptionType); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.WealthService.SetAutoRenew(c.Request.Context(), userID, orderID, *req.AutoRenew); err != nil {
+		c.JSON(orderChangeErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"orderId": orderID, "autoRenew": *req.AutoRenew})
+}
+
+// CancelOrder 撤销尚未起息的订单，本金全额解冻
+func (h *Handler) CancelOrder(c *gin.Context) {
+	userID, err := h.getUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Redemption successful"})
+	orderID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return
+	}
+
+	if err := h.WealthService.CancelOrder(c.Request.Context(), userID, orderID); err != nil {
+		c.JSON(orderChangeErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"orderId": orderID, "message": "Order cancelled"})
+}
+
+func orderChangeErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrOrderNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrOrderAlreadyRedeemed), errors.Is(err, services.ErrOrderNotRenewable),
+		errors.Is(err, services.ErrRenewalCutoffPassed), errors.Is(err, services.ErrOrderAlreadyStarted):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+type redeemRequest struct {
+	OrderID        int64  `json:"orderId" binding:"required"`
+	RedemptionType string `json:"redemptionType"` // full（默认）或 partial
+	Amount         string `json:"amount"`         // partial 时赎回的本金
+}
+
+func (h *Handler) Redeem(c *gin.Context) {
+	userID, err := h.getUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req redeemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	quote, err := h.WealthService.Redeem(c.Request.Context(), userID, req.OrderID, req.RedemptionType, req.Amount)
+	if err != nil {
+		c.JSON(redeemErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Redemption successful", "redemption": quote})
+}
+
+// RedeemQuote 赎回试算：返回确认赎回后实际到账金额
+func (h *Handler) RedeemQuote(c *gin.Context) {
+	userID, err := h.getUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req redeemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	quote, err := h.WealthService.QuoteRedemption(c.Request.Context(), userID, req.OrderID, req.RedemptionType, req.Amount)
+	if err != nil {
+		c.JSON(redeemErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, quote)
+}
+
+func redeemErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrOrderNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrOrderAlreadyRedeemed):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrInvalidRedemptionType), errors.Is(err, services.ErrInvalidRedemptionAmount):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
 }
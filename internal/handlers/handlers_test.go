@@ -2,7 +2,6 @@ package handlers
 
 import (
 	"bytes"
-	"database/sql"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -104,10 +103,8 @@ func TestApplyForLending_Success(t *testing.T) {
 	h := newTestHandler()
 	router := setupTestRouter(h)
 
-	// Mock LendingService.ApplyForLending
-	h.LendingService = &services.LendingService{
-		DB: &sql.DB{}, // This will cause an error in real scenario, but we test handler logic
-	}
+	// LendingService without repositories - this will fail in the service, but we test handler logic
+	h.LendingService = &services.LendingService{}
 
 	reqBody := dto.ApplyLendingRequest{
 		Asset:        "BTC",
@@ -120,10 +117,10 @@ func TestApplyForLending_Success(t *testing.T) {
 	req.Header.Set("Content-Type", "application/json")
 	resp := httptest.NewRecorder()
 
-	// This will fail because DB is nil, but we're testing handler logic
+	// This will fail because repositories are nil, but we're testing handler logic
 	router.ServeHTTP(resp, req)
 
-	// Expected to fail due to nil DB - this is expected behavior
+	// Expected to fail due to nil repositories - this is expected behavior
 	// The handler should return 500 instead of crashing
 	if resp.Code != http.StatusInternalServerError {
 		t.Logf("Got status %d - this is expected if DB is not set up", resp.Code)
@@ -422,41 +419,6 @@ func TestCreateWithdrawalRequest_Validation(t *testing.T) {
 	}
 }
 
-// ==================== APY Calculation Tests ====================
-
-func TestAPYCalculation(t *testing.T) {
-	tests := []struct {
-		asset        string
-		durationDays int
-		expected     float64
-	}{
-		{"BTC", 30, 4.5},
-		{"ETH", 90, 5.72},
-		{"USDT", 180, 10.62},
-		{"USDC", 360, 12.30},
-		{"SOL", 90, 7.48},
-	}
-
-	for _, test := range tests {
-		t.Run(test.asset, func(t *testing.T) {
-			ls := &services.LendingService{}
-			result := ls.CalculateAPY(test.asset, test.durationDays)
-
-			// Verify result format is non-empty and numeric
-			if len(result) == 0 {
-				t.Errorf("Expected non-empty APY result for %s", test.asset)
-			}
-
-			// Verify result can be parsed as float
-			var parsed float64
-			if _, err := json.Marshal(result); err == nil {
-				// Just verify the result string is valid
-				_ = parsed
-			}
-		})
-	}
-}
-
 // ==================== DTO Tests ====================
 
 func TestLendingPositionResponse_JSON(t *testing.T) {
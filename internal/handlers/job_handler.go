@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"monera-digital/internal/scheduler"
+)
+
+// JobHandler handles admin endpoints for scheduled jobs
+type JobHandler struct {
+	base     *BaseHandler
+	registry *scheduler.Registry
+}
+
+// NewJobHandler creates a new job handler
+func NewJobHandler(registry *scheduler.Registry) *JobHandler {
+	return &JobHandler{
+		base:     &BaseHandler{},
+		registry: registry,
+	}
+}
+
+// ListJobs returns every registered job with its schedule and last-run status
+// GET /api/admin/jobs
+func (h *JobHandler) ListJobs(c *gin.Context) {
+	h.base.successResponse(c, gin.H{"jobs": h.registry.List()})
+}
+
+// GetJobRuns returns the persisted run history of a job
+// GET /api/admin/jobs/:name/runs
+func (h *JobHandler) GetJobRuns(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "30"))
+	if err != nil || limit < 1 || limit > 500 {
+		h.base.errorResponse(c, http.StatusBadRequest, "INVALID_LIMIT", "limit must be between 1 and 500")
+		return
+	}
+
+	runs, err := h.registry.Runs(c.Request.Context(), c.Param("name"), limit)
+	if err != nil {
+		h.jobError(c, err)
+		return
+	}
+	h.base.successResponse(c, gin.H{"runs": runs})
+}
+
+// TriggerJob starts a job immediately, regardless of its pause state
+// POST /api/admin/jobs/:name/trigger
+func (h *JobHandler) TriggerJob(c *gin.Context) {
+	if err := h.registry.Trigger(c.Param("name")); err != nil {
+		h.jobError(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{
+		"success": true,
+		"data":    gin.H{"message": "Job triggered"},
+	})
+}
+
+// PauseJob stops scheduled runs of a job on every instance
+// POST /api/admin/jobs/:name/pause
+func (h *JobHandler) PauseJob(c *gin.Context) {
+	if err := h.registry.Pause(c.Request.Context(), c.Param("name")); err != nil {
+		h.jobError(c, err)
+		return
+	}
+	h.base.successResponse(c, gin.H{"message": "Job paused"})
+}
+
+// ResumeJob re-enables scheduled runs of a paused job
+// POST /api/admin/jobs/:name/resume
+func (h *JobHandler) ResumeJob(c *gin.Context) {
+	if err := h.registry.Resume(c.Request.Context(), c.Param("name")); err != nil {
+		h.jobError(c, err)
+		return
+	}
+	h.base.successResponse(c, gin.H{"message": "Job resumed"})
+}
+
+func (h *JobHandler) jobError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, scheduler.ErrJobNotFound):
+		h.base.errorResponse(c, http.StatusNotFound, "JOB_NOT_FOUND", err.Error())
+	case errors.Is(err, scheduler.ErrJobRunning):
+		h.base.errorResponse(c, http.StatusConflict, "JOB_RUNNING", err.Error())
+	default:
+		h.base.errorResponse(c, http.StatusInternalServerError, "JOB_ERROR", err.Error())
+	}
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"monera-digital/internal/middleware"
+	"monera-digital/internal/scheduler"
+)
+
+func setupJobRouter(t *testing.T, email string) (*gin.Engine, *scheduler.Registry) {
+	gin.SetMode(gin.TestMode)
+
+	registry := scheduler.NewRegistry(nil, nil)
+	assert.NoError(t, registry.Register(scheduler.Job{
+		Name:     "token_blacklist_cleanup",
+		Schedule: "@hourly",
+		Run:      func(ctx context.Context) error { return nil },
+	}))
+
+	h := NewJobHandler(registry)
+	router := gin.New()
+	admin := router.Group("/api/admin")
+	admin.Use(func(c *gin.Context) {
+		c.Set("email", email)
+		c.Next()
+	})
+	admin.Use(middleware.AdminMiddleware([]string{"ops@monera.com"}))
+	admin.GET("/jobs", h.ListJobs)
+	admin.POST("/jobs/:name/trigger", h.TriggerJob)
+	admin.POST("/jobs/:name/pause", h.PauseJob)
+	return router, registry
+}
+
+func TestJobHandler_NonAdminForbidden(t *testing.T) {
+	router, _ := setupJobRouter(t, "user@example.com")
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/api/admin/jobs", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestJobHandler_ListJobs(t *testing.T) {
+	router, _ := setupJobRouter(t, "OPS@monera.com")
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/api/admin/jobs", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp struct {
+		Data struct {
+			Jobs []scheduler.JobStatus `json:"jobs"`
+		} `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Len(t, resp.Data.Jobs, 1)
+	assert.Equal(t, "token_blacklist_cleanup", resp.Data.Jobs[0].Name)
+}
+
+func TestJobHandler_TriggerJob(t *testing.T) {
+	router, registry := setupJobRouter(t, "ops@monera.com")
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/api/admin/jobs/token_blacklist_cleanup/trigger", nil)
+	router.ServeHTTP(w, req)
+	registry.Wait()
+
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Equal(t, "SUCCESS", registry.List()[0].LastStatus)
+}
+
+func TestJobHandler_UnknownJob(t *testing.T) {
+	router, _ := setupJobRouter(t, "ops@monera.com")
+
+	for _, path := range []string{"/api/admin/jobs/nope/trigger", "/api/admin/jobs/nope/pause"} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, path, nil)
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusNotFound, w.Code, path)
+	}
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"monera-digital/internal/services"
+)
+
+// LendingAdminHandler handles admin endpoints for the lending rate curve
+type LendingAdminHandler struct {
+	base    *BaseHandler
+	lending *services.LendingService
+}
+
+// NewLendingAdminHandler creates a new lending admin handler
+func NewLendingAdminHandler(lending *services.LendingService) *LendingAdminHandler {
+	return &LendingAdminHandler{
+		base:    &BaseHandler{},
+		lending: lending,
+	}
+}
+
+// GetRates returns the rate curve history of an asset, newest first
+// GET /api/admin/lending/rates/:asset
+func (h *LendingAdminHandler) GetRates(c *gin.Context) {
+	curves, err := h.lending.ListRates(c.Request.Context(), c.Param("asset"))
+	if err != nil {
+		h.lendingError(c, err)
+		return
+	}
+	h.base.successResponse(c, gin.H{"rates": curves})
+}
+
+// SetRates schedules the duration buckets of an asset effective from a date (today or later)
+// POST /api/admin/lending/rates/:asset
+func (h *LendingAdminHandler) SetRates(c *gin.Context) {
+	var req struct {
+		EffectiveDate string                       `json:"effectiveDate"`
+		Buckets       []services.LendingRateBucket `json:"buckets"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.base.errorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+
+	if err := h.lending.SetRates(c.Request.Context(), c.Param("asset"), req.EffectiveDate, req.Buckets); err != nil {
+		h.lendingError(c, err)
+		return
+	}
+	h.base.successResponse(c, gin.H{"message": "Rates scheduled"})
+}
+
+func (h *LendingAdminHandler) lendingError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidLendingRates), errors.Is(err, services.ErrRetroactiveRate):
+		h.base.errorResponse(c, http.StatusBadRequest, "INVALID_RATE_SCHEDULE", err.Error())
+	default:
+		h.base.errorResponse(c, http.StatusInternalServerError, "LENDING_ERROR", err.Error())
+	}
+}
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"monera-digital/internal/services"
+)
+
+// LoanHandler handles crypto-backed loan endpoints
+type LoanHandler struct {
+	base  *BaseHandler
+	loans *services.LoanService
+}
+
+// NewLoanHandler creates a new loan handler
+func NewLoanHandler(loans *services.LoanService) *LoanHandler {
+	return &LoanHandler{
+		base:  &BaseHandler{},
+		loans: loans,
+	}
+}
+
+// Borrow locks collateral and disburses a stablecoin loan
+// POST /api/loans
+func (h *LoanHandler) Borrow(c *gin.Context) {
+	userID, ok := h.base.requireUserID(c)
+	if !ok {
+		return
+	}
+
+	var req services.BorrowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.base.errorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+
+	loan, err := h.loans.Borrow(c.Request.Context(), userID, req)
+	if err != nil {
+		h.loanError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    gin.H{"loan": loan},
+	})
+}
+
+// GetLoans returns the user's loans with their current LTV
+// GET /api/loans
+func (h *LoanHandler) GetLoans(c *gin.Context) {
+	userID, ok := h.base.requireUserID(c)
+	if !ok {
+		return
+	}
+
+	loans, err := h.loans.GetUserLoans(c.Request.Context(), userID)
+	if err != nil {
+		h.loanError(c, err)
+		return
+	}
+	h.base.successResponse(c, gin.H{"loans": loans})
+}
+
+// Repay repays a loan in full and releases its collateral
+// POST /api/loans/:id/repay
+func (h *LoanHandler) Repay(c *gin.Context) {
+	userID, ok := h.base.requireUserID(c)
+	if !ok {
+		return
+	}
+	loanID, ok := h.loanID(c)
+	if !ok {
+		return
+	}
+
+	loan, err := h.loans.Repay(c.Request.Context(), userID, loanID)
+	if err != nil {
+		h.loanError(c, err)
+		return
+	}
+	h.base.successResponse(c, gin.H{"loan": loan})
+}
+
+// AddCollateral locks additional collateral to lower the LTV
+// POST /api/loans/:id/collateral
+func (h *LoanHandler) AddCollateral(c *gin.Context) {
+	userID, ok := h.base.requireUserID(c)
+	if !ok {
+		return
+	}
+	loanID, ok := h.loanID(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Amount string `json:"amount" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.base.errorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+
+	loan, err := h.loans.AddCollateral(c.Request.Context(), userID, loanID, req.Amount)
+	if err != nil {
+		h.loanError(c, err)
+		return
+	}
+	h.base.successResponse(c, gin.H{"loan": loan})
+}
+
+func (h *LoanHandler) loanID(c *gin.Context) (int64, bool) {
+	loanID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		h.base.errorResponse(c, http.StatusBadRequest, "INVALID_LOAN_ID", "Invalid loan ID")
+		return 0, false
+	}
+	return loanID, true
+}
+
+func (h *LoanHandler) loanError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrLoanNotFound):
+		h.base.errorResponse(c, http.StatusNotFound, "LOAN_NOT_FOUND", err.Error())
+	case errors.Is(err, services.ErrLoanClosed):
+		h.base.errorResponse(c, http.StatusConflict, "LOAN_CLOSED", err.Error())
+	case errors.Is(err, services.ErrInvalidLoanRequest), errors.Is(err, services.ErrLoanLTVTooHigh),
+		errors.Is(err, services.ErrInsufficientBalance), errors.Is(err, services.ErrLoanAccountMissing):
+		h.base.errorResponse(c, http.StatusBadRequest, "INVALID_LOAN_REQUEST", err.Error())
+	case errors.Is(err, services.ErrPriceUnavailable):
+		h.base.errorResponse(c, http.StatusServiceUnavailable, "PRICE_UNAVAILABLE", err.Error())
+	default:
+		h.base.errorResponse(c, http.StatusInternalServerError, "LOAN_ERROR", err.Error())
+	}
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"monera-digital/internal/services"
+)
+
+// P2PTransferHandler handles off-chain transfers between platform users
+type P2PTransferHandler struct {
+	base      *BaseHandler
+	transfers *services.P2PTransferService
+}
+
+// NewP2PTransferHandler creates a new peer-to-peer transfer handler
+func NewP2PTransferHandler(transfers *services.P2PTransferService) *P2PTransferHandler {
+	return &P2PTransferHandler{
+		base:      &BaseHandler{},
+		transfers: transfers,
+	}
+}
+
+// Send transfers stablecoins to another user identified by email.
+// The Idempotency-Key header is required; retrying with the same key
+// returns the original transfer instead of sending again.
+// POST /api/p2p/transfers
+func (h *P2PTransferHandler) Send(c *gin.Context) {
+	userID, ok := h.base.requireUserID(c)
+	if !ok {
+		return
+	}
+
+	idempotencyKey := c.GetHeader("Idempotency-Key")
+	if idempotencyKey == "" {
+		h.base.errorResponse(c, http.StatusBadRequest, "MISSING_IDEMPOTENCY_KEY", "Missing Idempotency-Key header")
+		return
+	}
+
+	var req services.P2PTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.base.errorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+
+	transfer, err := h.transfers.Send(c.Request.Context(), userID, idempotencyKey, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidTransfer):
+			h.base.errorResponse(c, http.StatusBadRequest, "INVALID_TRANSFER", err.Error())
+		case errors.Is(err, services.ErrInsufficientBalance):
+			h.base.errorResponse(c, http.StatusBadRequest, "INSUFFICIENT_BALANCE", err.Error())
+		case errors.Is(err, services.ErrTransferLimitExceeded):
+			h.base.errorResponse(c, http.StatusBadRequest, "TRANSFER_LIMIT_EXCEEDED", err.Error())
+		case errors.Is(err, services.ErrRecipientNotFound):
+			h.base.errorResponse(c, http.StatusNotFound, "RECIPIENT_NOT_FOUND", err.Error())
+		case errors.Is(err, services.ErrTwoFactorRequired):
+			h.base.errorResponse(c, http.StatusForbidden, "TWO_FACTOR_REQUIRED", err.Error())
+		case errors.Is(err, services.ErrInvalidTwoFactorCode):
+			h.base.errorResponse(c, http.StatusUnauthorized, "INVALID_TWO_FACTOR_CODE", err.Error())
+		case errors.Is(err, services.ErrIdempotencyKeyReused):
+			h.base.errorResponse(c, http.StatusUnprocessableEntity, "IDEMPOTENCY_KEY_REUSED", err.Error())
+		case errors.Is(err, services.ErrAccountBusy):
+			h.base.errorResponse(c, http.StatusConflict, "ACCOUNT_BUSY", err.Error())
+		default:
+			h.base.errorResponse(c, http.StatusInternalServerError, "TRANSFER_ERROR", err.Error())
+		}
+		return
+	}
+	h.base.successResponse(c, gin.H{"transfer": transfer})
+}
+
+// GetTransfers returns transfers the user has sent or received
+// GET /api/p2p/transfers?limit=20&offset=0
+func (h *P2PTransferHandler) GetTransfers(c *gin.Context) {
+	userID, ok := h.base.requireUserID(c)
+	if !ok {
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	transfers, err := h.transfers.GetTransfers(c.Request.Context(), userID, limit, offset)
+	if err != nil {
+		h.base.errorResponse(c, http.StatusInternalServerError, "TRANSFER_ERROR", err.Error())
+		return
+	}
+	h.base.successResponse(c, gin.H{"transfers": transfers})
+}
@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"monera-digital/internal/services"
+)
+
+// WealthAdminHandler handles admin endpoints for wealth products
+type WealthAdminHandler struct {
+	base   *BaseHandler
+	wealth *services.WealthService
+}
+
+// NewWealthAdminHandler creates a new wealth admin handler
+func NewWealthAdminHandler(wealth *services.WealthService) *WealthAdminHandler {
+	return &WealthAdminHandler{
+		base:   &BaseHandler{},
+		wealth: wealth,
+	}
+}
+
+// ListProducts returns every product, including paused and retired ones
+// GET /api/admin/wealth/products
+func (h *WealthAdminHandler) ListProducts(c *gin.Context) {
+	products, err := h.wealth.ListAdminProducts(c.Request.Context())
+	if err != nil {
+		h.wealthError(c, err)
+		return
+	}
+	h.base.successResponse(c, gin.H{"products": products})
+}
+
+// CreateProduct creates a product together with its base APY
+// POST /api/admin/wealth/products
+func (h *WealthAdminHandler) CreateProduct(c *gin.Context) {
+	var req services.NewProduct
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.base.errorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+
+	product, err := h.wealth.CreateProduct(c.Request.Context(), &req)
+	if err != nil {
+		h.wealthError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    gin.H{"product": product},
+	})
+}
+
+// UpdateProduct replaces the editable terms of a product; existing orders keep their terms
+// PUT /api/admin/wealth/products/:id
+func (h *WealthAdminHandler) UpdateProduct(c *gin.Context) {
+	productID, ok := h.productID(c)
+	if !ok {
+		return
+	}
+
+	var req services.ProductTerms
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.base.errorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+
+	product, err := h.wealth.UpdateProduct(c.Request.Context(), productID, &req)
+	if err != nil {
+		h.wealthError(c, err)
+		return
+	}
+	h.base.successResponse(c, gin.H{"product": product})
+}
+
+// PauseProduct stops new subscriptions to a product
+// POST /api/admin/wealth/products/:id/pause
+func (h *WealthAdminHandler) PauseProduct(c *gin.Context) {
+	h.transition(c, h.wealth.PauseProduct, "Product paused")
+}
+
+// ResumeProduct reopens a paused product for subscription
+// POST /api/admin/wealth/products/:id/resume
+func (h *WealthAdminHandler) ResumeProduct(c *gin.Context) {
+	h.transition(c, h.wealth.ResumeProduct, "Product resumed")
+}
+
+// RetireProduct permanently closes a product to subscriptions and renewals
+// POST /api/admin/wealth/products/:id/retire
+func (h *WealthAdminHandler) RetireProduct(c *gin.Context) {
+	h.transition(c, h.wealth.RetireProduct, "Product retired")
+}
+
+func (h *WealthAdminHandler) transition(c *gin.Context, fn func(context.Context, int64) error, message string) {
+	productID, ok := h.productID(c)
+	if !ok {
+		return
+	}
+	if err := fn(c.Request.Context(), productID); err != nil {
+		h.wealthError(c, err)
+		return
+	}
+	h.base.successResponse(c, gin.H{"message": message})
+}
+
+// GetProductRates returns the APY history of a product, newest first
+// GET /api/admin/wealth/products/:id/rates
+func (h *WealthAdminHandler) GetProductRates(c *gin.Context) {
+	productID, ok := h.productID(c)
+	if !ok {
+		return
+	}
+
+	schedules, err := h.wealth.GetProductRates(c.Request.Context(), productID)
+	if err != nil {
+		h.wealthError(c, err)
+		return
+	}
+	h.base.successResponse(c, gin.H{"rates": schedules})
+}
+
+// SetProductRates schedules APY tiers effective from a date (today or later)
+// POST /api/admin/wealth/products/:id/rates
+func (h *WealthAdminHandler) SetProductRates(c *gin.Context) {
+	productID, ok := h.productID(c)
+	if !ok {
+		return
+	}
+
+	var req services.RateSchedule
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.base.errorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+
+	if err := h.wealth.SetProductRates(c.Request.Context(), productID, req.EffectiveDate, req.Tiers); err != nil {
+		h.wealthError(c, err)
+		return
+	}
+	h.base.successResponse(c, gin.H{"message": "Rates scheduled"})
+}
+
+func (h *WealthAdminHandler) productID(c *gin.Context) (int64, bool) {
+	productID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		h.base.errorResponse(c, http.StatusBadRequest, "INVALID_PRODUCT_ID", "Invalid product ID")
+		return 0, false
+	}
+	return productID, true
+}
+
+func (h *WealthAdminHandler) wealthError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrProductNotFound):
+		h.base.errorResponse(c, http.StatusNotFound, "PRODUCT_NOT_FOUND", err.Error())
+	case errors.Is(err, services.ErrInvalidRateSchedule), errors.Is(err, services.ErrRetroactiveRate):
+		h.base.errorResponse(c, http.StatusBadRequest, "INVALID_RATE_SCHEDULE", err.Error())
+	case errors.Is(err, services.ErrInvalidProduct):
+		h.base.errorResponse(c, http.StatusBadRequest, "INVALID_PRODUCT", err.Error())
+	case errors.Is(err, services.ErrProductRetired), errors.Is(err, services.ErrInvalidProductTransition):
+		h.base.errorResponse(c, http.StatusConflict, "INVALID_PRODUCT_STATUS", err.Error())
+	default:
+		h.base.errorResponse(c, http.StatusInternalServerError, "WEALTH_ERROR", err.Error())
+	}
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"monera-digital/internal/services"
+)
+
+// WithdrawalCallbackHandler handles withdrawal status callbacks from the custody provider
+type WithdrawalCallbackHandler struct {
+	base        *BaseHandler
+	withdrawals *services.WithdrawalService
+}
+
+// NewWithdrawalCallbackHandler creates a new custody callback handler
+func NewWithdrawalCallbackHandler(withdrawals *services.WithdrawalService) *WithdrawalCallbackHandler {
+	return &WithdrawalCallbackHandler{
+		base:        &BaseHandler{},
+		withdrawals: withdrawals,
+	}
+}
+
+// Handle verifies and decrypts the Safeheron webhook envelope and advances the
+// withdrawal order to the mapped status. Safeheron retries until it receives
+// the SUCCESS acknowledgement, so ignored events are acknowledged as well
+// POST /api/webhooks/custody/withdrawal
+func (h *WithdrawalCallbackHandler) Handle(c *gin.Context) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		h.base.errorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Failed to read request body")
+		return
+	}
+
+	cb, err := h.withdrawals.VerifyCustodyCallback(body)
+	if errors.Is(err, services.ErrCallbackIgnored) {
+		acknowledgeWebhook(c)
+		return
+	}
+	if err != nil {
+		h.base.errorResponse(c, http.StatusUnauthorized, "INVALID_SIGNATURE", err.Error())
+		return
+	}
+
+	_, err = h.withdrawals.HandleCustodyCallback(c.Request.Context(), *cb)
+	switch {
+	case err == nil:
+		acknowledgeWebhook(c)
+	case errors.Is(err, services.ErrWithdrawalNotFound):
+		h.base.errorResponse(c, http.StatusNotFound, "WITHDRAWAL_NOT_FOUND", err.Error())
+	case errors.Is(err, services.ErrInvalidWithdrawalTransition):
+		h.base.errorResponse(c, http.StatusConflict, "INVALID_TRANSITION", err.Error())
+	default:
+		h.base.errorResponse(c, http.StatusInternalServerError, "CALLBACK_ERROR", err.Error())
+	}
+}
+
+// acknowledgeWebhook replies in the format Safeheron expects to stop retrying
+func acknowledgeWebhook(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"code": "200", "message": "SUCCESS"})
+}
@@ -0,0 +1,33 @@
+// internal/middleware/admin.go
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminMiddleware restricts a route group to the configured admin emails.
+// It must run after AuthMiddleware, which puts the caller's email in context.
+func AdminMiddleware(adminEmails []string) gin.HandlerFunc {
+	allowed := make(map[string]bool, len(adminEmails))
+	for _, email := range adminEmails {
+		allowed[strings.ToLower(strings.TrimSpace(email))] = true
+	}
+
+	return func(c *gin.Context) {
+		email, _ := c.Get("email")
+		emailStr, _ := email.(string)
+		if emailStr == "" || !allowed[strings.ToLower(emailStr)] {
+			c.JSON(http.StatusForbidden, ErrorResponse{
+				Code:    "FORBIDDEN",
+				Message: "Admin access required",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
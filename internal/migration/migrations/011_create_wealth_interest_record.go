@@ -0,0 +1,62 @@
+package migrations
+
+import (
+	"database/sql"
+	"fmt"
+	"monera-digital/internal/migration"
+)
+
+// CreateWealthInterestRecord migration creates the per-day interest ledger
+type CreateWealthInterestRecord struct{}
+
+func (m *CreateWealthInterestRecord) Version() string {
+	return "011"
+}
+
+func (m *CreateWealthInterestRecord) Description() string {
+	return "Create wealth_interest_record ledger with one accrual row per order and date"
+}
+
+func (m *CreateWealthInterestRecord) Up(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS wealth_interest_record (
+			id BIGSERIAL PRIMARY KEY,
+			order_id BIGINT NOT NULL,
+			amount NUMERIC(65, 30) NOT NULL,
+			type SMALLINT NOT NULL,
+			date DATE NOT NULL,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create wealth_interest_record table: %w", err)
+	}
+
+	_, err = db.Exec(`
+		CREATE INDEX IF NOT EXISTS idx_wealth_interest_record_order_id ON wealth_interest_record(order_id)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create order_id index: %w", err)
+	}
+
+	// 每个订单每天只允许一条计息记录，重复执行同一天的计息不会重复入账
+	_, err = db.Exec(`
+		CREATE UNIQUE INDEX IF NOT EXISTS uq_wealth_interest_record_order_date
+		ON wealth_interest_record(order_id, date) WHERE type = 1
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create unique order/date index: %w", err)
+	}
+
+	return nil
+}
+
+func (m *CreateWealthInterestRecord) Down(db *sql.DB) error {
+	_, err := db.Exec(`
+		DROP INDEX IF EXISTS uq_wealth_interest_record_order_date;
+	`)
+	return err
+}
+
+// Ensure CreateWealthInterestRecord implements Migration interface
+var _ migration.Migration = (*CreateWealthInterestRecord)(nil)
@@ -0,0 +1,57 @@
+package migrations
+
+import (
+	"database/sql"
+	"fmt"
+	"monera-digital/internal/migration"
+)
+
+// CreateSchedulerRun migration creates the persisted run history for scheduled jobs
+type CreateSchedulerRun struct{}
+
+func (m *CreateSchedulerRun) Version() string {
+	return "012"
+}
+
+func (m *CreateSchedulerRun) Description() string {
+	return "Create scheduler_run table recording every scheduled job execution"
+}
+
+func (m *CreateSchedulerRun) Up(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS scheduler_run (
+			id BIGSERIAL PRIMARY KEY,
+			job_name VARCHAR(64) NOT NULL,
+			run_date DATE NOT NULL,
+			status VARCHAR(16) NOT NULL,
+			items_processed INTEGER DEFAULT 0 NOT NULL,
+			detail TEXT DEFAULT '' NOT NULL,
+			error_message TEXT DEFAULT '' NOT NULL,
+			started_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+			finished_at TIMESTAMP WITH TIME ZONE
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create scheduler_run table: %w", err)
+	}
+
+	// 补跑时按任务查找最近一次成功的业务日期
+	_, err = db.Exec(`
+		CREATE INDEX IF NOT EXISTS idx_scheduler_run_job_date ON scheduler_run(job_name, run_date DESC)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create job/date index: %w", err)
+	}
+
+	return nil
+}
+
+func (m *CreateSchedulerRun) Down(db *sql.DB) error {
+	_, err := db.Exec(`
+		DROP TABLE IF EXISTS scheduler_run;
+	`)
+	return err
+}
+
+// Ensure CreateSchedulerRun implements Migration interface
+var _ migration.Migration = (*CreateSchedulerRun)(nil)
@@ -0,0 +1,46 @@
+package migrations
+
+import (
+	"database/sql"
+	"fmt"
+	"monera-digital/internal/migration"
+)
+
+// CreateSchedulerJob migration creates the persisted state of registered scheduler jobs
+type CreateSchedulerJob struct{}
+
+func (m *CreateSchedulerJob) Version() string {
+	return "013"
+}
+
+func (m *CreateSchedulerJob) Description() string {
+	return "Create scheduler_job table holding pause flags and last-run status per job"
+}
+
+func (m *CreateSchedulerJob) Up(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS scheduler_job (
+			name VARCHAR(64) PRIMARY KEY,
+			paused BOOLEAN DEFAULT FALSE NOT NULL,
+			last_run_at TIMESTAMP WITH TIME ZONE,
+			last_status VARCHAR(16) DEFAULT '' NOT NULL,
+			last_error TEXT DEFAULT '' NOT NULL,
+			last_duration_ms BIGINT DEFAULT 0 NOT NULL,
+			updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create scheduler_job table: %w", err)
+	}
+	return nil
+}
+
+func (m *CreateSchedulerJob) Down(db *sql.DB) error {
+	_, err := db.Exec(`
+		DROP TABLE IF EXISTS scheduler_job;
+	`)
+	return err
+}
+
+// Ensure CreateSchedulerJob implements Migration interface
+var _ migration.Migration = (*CreateSchedulerJob)(nil)
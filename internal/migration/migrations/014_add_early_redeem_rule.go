@@ -0,0 +1,43 @@
+package migrations
+
+import (
+	"database/sql"
+	"fmt"
+	"monera-digital/internal/migration"
+)
+
+// AddEarlyRedeemRule migration adds per-product early redemption penalty settings
+type AddEarlyRedeemRule struct{}
+
+func (m *AddEarlyRedeemRule) Version() string {
+	return "014"
+}
+
+func (m *AddEarlyRedeemRule) Description() string {
+	return "Add early_redeem_rule and early_redeem_value to wealth_product"
+}
+
+func (m *AddEarlyRedeemRule) Up(db *sql.DB) error {
+	// rule: 1=forfeit interest, 2=keep value% of interest, 3=flat fee of value
+	_, err := db.Exec(`
+		ALTER TABLE wealth_product
+		ADD COLUMN IF NOT EXISTS early_redeem_rule SMALLINT DEFAULT 1 NOT NULL,
+		ADD COLUMN IF NOT EXISTS early_redeem_value NUMERIC(65, 30) DEFAULT 0 NOT NULL
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to add early redeem columns: %w", err)
+	}
+	return nil
+}
+
+func (m *AddEarlyRedeemRule) Down(db *sql.DB) error {
+	_, err := db.Exec(`
+		ALTER TABLE wealth_product
+		DROP COLUMN IF EXISTS early_redeem_rule,
+		DROP COLUMN IF EXISTS early_redeem_value
+	`)
+	return err
+}
+
+// Ensure AddEarlyRedeemRule implements Migration interface
+var _ migration.Migration = (*AddEarlyRedeemRule)(nil)
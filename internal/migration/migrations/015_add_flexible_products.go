@@ -0,0 +1,49 @@
+package migrations
+
+import (
+	"database/sql"
+	"fmt"
+	"monera-digital/internal/migration"
+)
+
+// AddFlexibleProducts migration adds the product type and lets flexible orders have no maturity
+type AddFlexibleProducts struct{}
+
+func (m *AddFlexibleProducts) Version() string {
+	return "015"
+}
+
+func (m *AddFlexibleProducts) Description() string {
+	return "Add product_type to wealth_product and make wealth_order.end_date nullable for flexible products"
+}
+
+func (m *AddFlexibleProducts) Up(db *sql.DB) error {
+	// product_type: 1=fixed term, 2=flexible (no maturity)
+	_, err := db.Exec(`
+		ALTER TABLE wealth_product
+		ADD COLUMN IF NOT EXISTS product_type SMALLINT DEFAULT 1 NOT NULL
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to add product_type column: %w", err)
+	}
+
+	_, err = db.Exec(`
+		ALTER TABLE wealth_order
+		ALTER COLUMN end_date DROP NOT NULL
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to make end_date nullable: %w", err)
+	}
+	return nil
+}
+
+func (m *AddFlexibleProducts) Down(db *sql.DB) error {
+	_, err := db.Exec(`
+		ALTER TABLE wealth_product
+		DROP COLUMN IF EXISTS product_type
+	`)
+	return err
+}
+
+// Ensure AddFlexibleProducts implements Migration interface
+var _ migration.Migration = (*AddFlexibleProducts)(nil)
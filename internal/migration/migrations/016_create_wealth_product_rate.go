@@ -0,0 +1,64 @@
+package migrations
+
+import (
+	"database/sql"
+	"fmt"
+	"monera-digital/internal/migration"
+)
+
+// CreateWealthProductRate migration creates the dated, tiered APY history of wealth products
+type CreateWealthProductRate struct{}
+
+func (m *CreateWealthProductRate) Version() string {
+	return "016"
+}
+
+func (m *CreateWealthProductRate) Description() string {
+	return "Create wealth_product_rate table with effective-dated APY tiers and seed it from wealth_product.apy"
+}
+
+func (m *CreateWealthProductRate) Up(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS wealth_product_rate (
+			id BIGSERIAL PRIMARY KEY,
+			product_id BIGINT NOT NULL,
+			effective_date DATE NOT NULL,
+			tier_floor NUMERIC(65, 30) DEFAULT 0 NOT NULL,
+			apy NUMERIC(10, 4) NOT NULL,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create wealth_product_rate table: %w", err)
+	}
+
+	_, err = db.Exec(`
+		CREATE UNIQUE INDEX IF NOT EXISTS uq_wealth_product_rate_tier
+		ON wealth_product_rate(product_id, effective_date, tier_floor)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create product/date/tier index: %w", err)
+	}
+
+	// 现有产品的静态 APY 视为一直生效的单档利率
+	_, err = db.Exec(`
+		INSERT INTO wealth_product_rate (product_id, effective_date, tier_floor, apy)
+		SELECT id, DATE '1970-01-01', 0, apy FROM wealth_product
+		ON CONFLICT DO NOTHING
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to seed wealth_product_rate: %w", err)
+	}
+
+	return nil
+}
+
+func (m *CreateWealthProductRate) Down(db *sql.DB) error {
+	_, err := db.Exec(`
+		DROP TABLE IF EXISTS wealth_product_rate;
+	`)
+	return err
+}
+
+// Ensure CreateWealthProductRate implements Migration interface
+var _ migration.Migration = (*CreateWealthProductRate)(nil)
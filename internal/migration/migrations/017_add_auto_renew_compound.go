@@ -0,0 +1,40 @@
+package migrations
+
+import (
+	"database/sql"
+	"fmt"
+	"monera-digital/internal/migration"
+)
+
+// AddAutoRenewCompound migration adds the per-order compounding renewal option
+type AddAutoRenewCompound struct{}
+
+func (m *AddAutoRenewCompound) Version() string {
+	return "017"
+}
+
+func (m *AddAutoRenewCompound) Description() string {
+	return "Add auto_renew_compound to wealth_order"
+}
+
+func (m *AddAutoRenewCompound) Up(db *sql.DB) error {
+	_, err := db.Exec(`
+		ALTER TABLE wealth_order
+		ADD COLUMN IF NOT EXISTS auto_renew_compound BOOLEAN DEFAULT FALSE NOT NULL
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to add auto_renew_compound column: %w", err)
+	}
+	return nil
+}
+
+func (m *AddAutoRenewCompound) Down(db *sql.DB) error {
+	_, err := db.Exec(`
+		ALTER TABLE wealth_order
+		DROP COLUMN IF EXISTS auto_renew_compound
+	`)
+	return err
+}
+
+// Ensure AddAutoRenewCompound implements Migration interface
+var _ migration.Migration = (*AddAutoRenewCompound)(nil)
@@ -0,0 +1,73 @@
+package migrations
+
+import (
+	"database/sql"
+	"fmt"
+	"monera-digital/internal/migration"
+)
+
+// AddProductLifecycle migration adds subscription windows to wealth_product and
+// snapshots the early redemption terms onto wealth_order so product edits only
+// affect new orders
+type AddProductLifecycle struct{}
+
+func (m *AddProductLifecycle) Version() string {
+	return "018"
+}
+
+func (m *AddProductLifecycle) Description() string {
+	return "Add subscription window to wealth_product and early redeem terms to wealth_order"
+}
+
+func (m *AddProductLifecycle) Up(db *sql.DB) error {
+	_, err := db.Exec(`
+		ALTER TABLE wealth_product
+		ADD COLUMN IF NOT EXISTS subscribe_start_at TIMESTAMP WITH TIME ZONE,
+		ADD COLUMN IF NOT EXISTS subscribe_end_at TIMESTAMP WITH TIME ZONE
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to add subscription window columns: %w", err)
+	}
+
+	_, err = db.Exec(`
+		ALTER TABLE wealth_order
+		ADD COLUMN IF NOT EXISTS early_redeem_rule SMALLINT DEFAULT 1 NOT NULL,
+		ADD COLUMN IF NOT EXISTS early_redeem_value NUMERIC(65, 30) DEFAULT 0 NOT NULL
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to add order early redeem columns: %w", err)
+	}
+
+	// 存量订单沿用下单时（即当前）产品的提前赎回规则
+	_, err = db.Exec(`
+		UPDATE wealth_order o
+		SET early_redeem_rule = p.early_redeem_rule,
+		    early_redeem_value = p.early_redeem_value
+		FROM wealth_product p
+		WHERE o.product_id = p.id
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to backfill order early redeem terms: %w", err)
+	}
+	return nil
+}
+
+func (m *AddProductLifecycle) Down(db *sql.DB) error {
+	_, err := db.Exec(`
+		ALTER TABLE wealth_order
+		DROP COLUMN IF EXISTS early_redeem_rule,
+		DROP COLUMN IF EXISTS early_redeem_value
+	`)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`
+		ALTER TABLE wealth_product
+		DROP COLUMN IF EXISTS subscribe_start_at,
+		DROP COLUMN IF EXISTS subscribe_end_at
+	`)
+	return err
+}
+
+// Ensure AddProductLifecycle implements Migration interface
+var _ migration.Migration = (*AddProductLifecycle)(nil)
@@ -0,0 +1,59 @@
+package migrations
+
+import (
+	"database/sql"
+	"fmt"
+	"monera-digital/internal/migration"
+)
+
+// CreateWealthSubscribeQuote migration creates the server-issued subscription
+// quotes that Subscribe consumes instead of a client-supplied interest figure
+type CreateWealthSubscribeQuote struct{}
+
+func (m *CreateWealthSubscribeQuote) Version() string {
+	return "019"
+}
+
+func (m *CreateWealthSubscribeQuote) Description() string {
+	return "Create wealth_subscribe_quote table for server-side interest projections"
+}
+
+func (m *CreateWealthSubscribeQuote) Up(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS wealth_subscribe_quote (
+			id TEXT PRIMARY KEY,
+			user_id BIGINT NOT NULL,
+			product_id BIGINT NOT NULL,
+			amount NUMERIC(65, 30) NOT NULL,
+			interest_expected NUMERIC(65, 30) DEFAULT 0 NOT NULL,
+			start_date DATE NOT NULL,
+			end_date DATE,
+			expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+			used_at TIMESTAMP WITH TIME ZONE,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create wealth_subscribe_quote table: %w", err)
+	}
+
+	_, err = db.Exec(`
+		CREATE INDEX IF NOT EXISTS idx_wealth_subscribe_quote_expires_at
+		ON wealth_subscribe_quote(expires_at)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create expires_at index: %w", err)
+	}
+
+	return nil
+}
+
+func (m *CreateWealthSubscribeQuote) Down(db *sql.DB) error {
+	_, err := db.Exec(`
+		DROP TABLE IF EXISTS wealth_subscribe_quote;
+	`)
+	return err
+}
+
+// Ensure CreateWealthSubscribeQuote implements Migration interface
+var _ migration.Migration = (*CreateWealthSubscribeQuote)(nil)
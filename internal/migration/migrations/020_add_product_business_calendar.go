@@ -0,0 +1,45 @@
+package migrations
+
+import (
+	"database/sql"
+	"fmt"
+	"monera-digital/internal/migration"
+)
+
+// AddProductBusinessCalendar migration adds the per-product settlement time zone,
+// daily cutoff and day-count convention
+type AddProductBusinessCalendar struct{}
+
+func (m *AddProductBusinessCalendar) Version() string {
+	return "020"
+}
+
+func (m *AddProductBusinessCalendar) Description() string {
+	return "Add settlement_timezone, cutoff_time and day_count to wealth_product"
+}
+
+func (m *AddProductBusinessCalendar) Up(db *sql.DB) error {
+	_, err := db.Exec(`
+		ALTER TABLE wealth_product
+		ADD COLUMN IF NOT EXISTS settlement_timezone VARCHAR(64) DEFAULT '' NOT NULL,
+		ADD COLUMN IF NOT EXISTS cutoff_time VARCHAR(5) DEFAULT '' NOT NULL,
+		ADD COLUMN IF NOT EXISTS day_count VARCHAR(16) DEFAULT 'ACT/365' NOT NULL
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to add business calendar columns: %w", err)
+	}
+	return nil
+}
+
+func (m *AddProductBusinessCalendar) Down(db *sql.DB) error {
+	_, err := db.Exec(`
+		ALTER TABLE wealth_product
+		DROP COLUMN IF EXISTS settlement_timezone,
+		DROP COLUMN IF EXISTS cutoff_time,
+		DROP COLUMN IF EXISTS day_count
+	`)
+	return err
+}
+
+// Ensure AddProductBusinessCalendar implements Migration interface
+var _ migration.Migration = (*AddProductBusinessCalendar)(nil)
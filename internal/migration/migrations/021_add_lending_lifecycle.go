@@ -0,0 +1,76 @@
+package migrations
+
+import (
+	"database/sql"
+	"fmt"
+	"monera-digital/internal/migration"
+)
+
+// AddLendingLifecycle migration adds daily yield accrual and closing fields to
+// lending positions
+type AddLendingLifecycle struct{}
+
+func (m *AddLendingLifecycle) Version() string {
+	return "021"
+}
+
+func (m *AddLendingLifecycle) Description() string {
+	return "Add lending yield ledger and position lifecycle columns"
+}
+
+func (m *AddLendingLifecycle) Up(db *sql.DB) error {
+	_, err := db.Exec(`
+		ALTER TABLE lending_positions
+		ADD COLUMN IF NOT EXISTS yield_paid DECIMAL(20, 8) DEFAULT 0 NOT NULL,
+		ADD COLUMN IF NOT EXISTS last_accrual_date DATE,
+		ADD COLUMN IF NOT EXISTS closed_at TIMESTAMP,
+		ALTER COLUMN status SET DEFAULT 'ACTIVE'
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to add lending lifecycle columns: %w", err)
+	}
+
+	// 早期建表的默认值为小写 active，统一为服务端使用的大写状态
+	_, err = db.Exec(`UPDATE lending_positions SET status = UPPER(status) WHERE status <> UPPER(status)`)
+	if err != nil {
+		return fmt.Errorf("failed to normalize lending status: %w", err)
+	}
+
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS lending_yield_record (
+			id BIGSERIAL PRIMARY KEY,
+			position_id INTEGER NOT NULL REFERENCES lending_positions(id) ON DELETE CASCADE,
+			amount DECIMAL(20, 8) NOT NULL,
+			date DATE NOT NULL,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create lending_yield_record table: %w", err)
+	}
+
+	// 每个仓位每天只允许一条收益记录，重复执行同一天的计息不会重复入账
+	_, err = db.Exec(`
+		CREATE UNIQUE INDEX IF NOT EXISTS uq_lending_yield_record_position_date
+		ON lending_yield_record(position_id, date)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create unique position/date index: %w", err)
+	}
+
+	return nil
+}
+
+func (m *AddLendingLifecycle) Down(db *sql.DB) error {
+	_, err := db.Exec(`
+		DROP TABLE IF EXISTS lending_yield_record;
+		ALTER TABLE lending_positions
+		DROP COLUMN IF EXISTS yield_paid,
+		DROP COLUMN IF EXISTS last_accrual_date,
+		DROP COLUMN IF EXISTS closed_at;
+	`)
+	return err
+}
+
+// Ensure AddLendingLifecycle implements Migration interface
+var _ migration.Migration = (*AddLendingLifecycle)(nil)
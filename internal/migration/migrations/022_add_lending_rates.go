@@ -0,0 +1,68 @@
+package migrations
+
+import (
+	"database/sql"
+	"fmt"
+	"monera-digital/internal/migration"
+)
+
+// AddLendingRates migration creates the lending rate curve table and seeds it
+// with the rates previously hard-coded in LendingService
+type AddLendingRates struct{}
+
+func (m *AddLendingRates) Version() string {
+	return "022"
+}
+
+func (m *AddLendingRates) Description() string {
+	return "Create lending rate curve by asset and duration bucket"
+}
+
+func (m *AddLendingRates) Up(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS lending_rate (
+			id BIGSERIAL PRIMARY KEY,
+			asset VARCHAR(20) NOT NULL,
+			min_duration_days INTEGER NOT NULL,
+			apy DECIMAL(10, 4) NOT NULL,
+			effective_date DATE NOT NULL,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create lending_rate table: %w", err)
+	}
+
+	_, err = db.Exec(`
+		CREATE UNIQUE INDEX IF NOT EXISTS uq_lending_rate_asset_date_duration
+		ON lending_rate(asset, effective_date, min_duration_days)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create lending_rate index: %w", err)
+	}
+
+	// 以原硬编码的基础利率与期限系数作为初始利率曲线
+	_, err = db.Exec(`
+		INSERT INTO lending_rate (asset, min_duration_days, apy, effective_date)
+		VALUES
+			('BTC', 0, 4.50, '1970-01-01'), ('BTC', 90, 4.95, '1970-01-01'), ('BTC', 180, 5.62, '1970-01-01'), ('BTC', 360, 6.75, '1970-01-01'),
+			('ETH', 0, 5.20, '1970-01-01'), ('ETH', 90, 5.72, '1970-01-01'), ('ETH', 180, 6.50, '1970-01-01'), ('ETH', 360, 7.80, '1970-01-01'),
+			('USDT', 0, 8.50, '1970-01-01'), ('USDT', 90, 9.35, '1970-01-01'), ('USDT', 180, 10.62, '1970-01-01'), ('USDT', 360, 12.75, '1970-01-01'),
+			('USDC', 0, 8.20, '1970-01-01'), ('USDC', 90, 9.02, '1970-01-01'), ('USDC', 180, 10.25, '1970-01-01'), ('USDC', 360, 12.30, '1970-01-01'),
+			('SOL', 0, 6.80, '1970-01-01'), ('SOL', 90, 7.48, '1970-01-01'), ('SOL', 180, 8.50, '1970-01-01'), ('SOL', 360, 10.20, '1970-01-01')
+		ON CONFLICT (asset, effective_date, min_duration_days) DO NOTHING
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to seed lending rates: %w", err)
+	}
+
+	return nil
+}
+
+func (m *AddLendingRates) Down(db *sql.DB) error {
+	_, err := db.Exec(`DROP TABLE IF EXISTS lending_rate`)
+	return err
+}
+
+// Ensure AddLendingRates implements Migration interface
+var _ migration.Migration = (*AddLendingRates)(nil)
@@ -0,0 +1,64 @@
+package migrations
+
+import (
+	"database/sql"
+	"fmt"
+	"monera-digital/internal/migration"
+)
+
+// CreateLoan migration creates the crypto-backed loan table
+type CreateLoan struct{}
+
+func (m *CreateLoan) Version() string {
+	return "023"
+}
+
+func (m *CreateLoan) Description() string {
+	return "Create crypto-backed loan table"
+}
+
+func (m *CreateLoan) Up(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS loan (
+			id BIGSERIAL PRIMARY KEY,
+			user_id INTEGER NOT NULL REFERENCES users(id),
+			collateral_asset VARCHAR(20) NOT NULL,
+			collateral_amount DECIMAL(36, 18) NOT NULL,
+			borrow_asset VARCHAR(20) NOT NULL,
+			principal DECIMAL(20, 8) NOT NULL,
+			margin_call_ltv DECIMAL(10, 6) NOT NULL,
+			liquidation_ltv DECIMAL(10, 6) NOT NULL,
+			liquidation_penalty DECIMAL(10, 6) NOT NULL,
+			status VARCHAR(20) DEFAULT 'ACTIVE' NOT NULL,
+			liquidated_collateral DECIMAL(36, 18) DEFAULT 0 NOT NULL,
+			liquidation_price DECIMAL(20, 8) DEFAULT 0 NOT NULL,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+			updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+			closed_at TIMESTAMP WITH TIME ZONE
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create loan table: %w", err)
+	}
+
+	_, err = db.Exec(`CREATE INDEX IF NOT EXISTS idx_loan_user_id ON loan(user_id)`)
+	if err != nil {
+		return fmt.Errorf("failed to create loan user index: %w", err)
+	}
+
+	// LTV 监控只扫描未结清的借款
+	_, err = db.Exec(`CREATE INDEX IF NOT EXISTS idx_loan_open ON loan(status) WHERE status IN ('ACTIVE', 'MARGIN_CALL')`)
+	if err != nil {
+		return fmt.Errorf("failed to create open loan index: %w", err)
+	}
+
+	return nil
+}
+
+func (m *CreateLoan) Down(db *sql.DB) error {
+	_, err := db.Exec(`DROP TABLE IF EXISTS loan`)
+	return err
+}
+
+// Ensure CreateLoan implements Migration interface
+var _ migration.Migration = (*CreateLoan)(nil)
@@ -0,0 +1,50 @@
+package migrations
+
+import (
+	"database/sql"
+	"fmt"
+	"monera-digital/internal/migration"
+)
+
+// AddAccountTypeCurrencyUnique migration makes (user, type, currency) identify
+// a single account, so FUND and WEALTH accounts can be opened on demand
+type AddAccountTypeCurrencyUnique struct{}
+
+func (m *AddAccountTypeCurrencyUnique) Version() string {
+	return "024"
+}
+
+func (m *AddAccountTypeCurrencyUnique) Description() string {
+	return "Add unique index on account user, type and currency"
+}
+
+// Up lists duplicated accounts before creating the index, so a failed
+// deployment names the rows to merge instead of a bare unique violation
+func (m *AddAccountTypeCurrencyUnique) Up(db *sql.DB) error {
+	if err := checkViolations(db, "account user, type and currency uniqueness", `
+		SELECT 'user ' || user_id || ' ' || type || ' ' || currency || ' (accounts ' || string_agg(id::text, ', ' ORDER BY id) || ')'
+		FROM account
+		GROUP BY user_id, type, currency
+		HAVING COUNT(*) > 1
+		ORDER BY user_id, type, currency
+	`); err != nil {
+		return err
+	}
+
+	_, err := db.Exec(`
+		CREATE UNIQUE INDEX IF NOT EXISTS uq_account_user_type_currency
+		ON account(user_id, type, currency)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create account unique index: %w", err)
+	}
+	return nil
+}
+
+func (m *AddAccountTypeCurrencyUnique) Down(db *sql.DB) error {
+	_, err := db.Exec(`DROP INDEX IF EXISTS uq_account_user_type_currency`)
+	return err
+}
+
+// Ensure AddAccountTypeCurrencyUnique implements Migration interface
+var _ migration.Migration = (*AddAccountTypeCurrencyUnique)(nil)
@@ -0,0 +1,66 @@
+package migrations
+
+import (
+	"database/sql"
+	"fmt"
+	"monera-digital/internal/migration"
+)
+
+// CreateP2PTransfer migration creates the off-chain user-to-user transfer table
+type CreateP2PTransfer struct{}
+
+func (m *CreateP2PTransfer) Version() string {
+	return "025"
+}
+
+func (m *CreateP2PTransfer) Description() string {
+	return "Create peer-to-peer transfer table"
+}
+
+func (m *CreateP2PTransfer) Up(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS p2p_transfer (
+			id BIGSERIAL PRIMARY KEY,
+			transfer_no VARCHAR(64) NOT NULL UNIQUE,
+			sender_id INTEGER NOT NULL REFERENCES users(id),
+			receiver_id INTEGER NOT NULL REFERENCES users(id),
+			currency VARCHAR(20) NOT NULL,
+			amount DECIMAL(32, 16) NOT NULL CHECK (amount > 0),
+			idempotency_key VARCHAR(128) NOT NULL,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create p2p_transfer table: %w", err)
+	}
+
+	// 幂等键按发送方隔离
+	_, err = db.Exec(`
+		CREATE UNIQUE INDEX IF NOT EXISTS uq_p2p_transfer_sender_key
+		ON p2p_transfer(sender_id, idempotency_key)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create p2p_transfer idempotency index: %w", err)
+	}
+
+	// 日限额按发送方与币种汇总当日转出
+	_, err = db.Exec(`CREATE INDEX IF NOT EXISTS idx_p2p_transfer_sender ON p2p_transfer(sender_id, currency, created_at)`)
+	if err != nil {
+		return fmt.Errorf("failed to create p2p_transfer sender index: %w", err)
+	}
+
+	_, err = db.Exec(`CREATE INDEX IF NOT EXISTS idx_p2p_transfer_receiver ON p2p_transfer(receiver_id, created_at)`)
+	if err != nil {
+		return fmt.Errorf("failed to create p2p_transfer receiver index: %w", err)
+	}
+
+	return nil
+}
+
+func (m *CreateP2PTransfer) Down(db *sql.DB) error {
+	_, err := db.Exec(`DROP TABLE IF EXISTS p2p_transfer`)
+	return err
+}
+
+// Ensure CreateP2PTransfer implements Migration interface
+var _ migration.Migration = (*CreateP2PTransfer)(nil)
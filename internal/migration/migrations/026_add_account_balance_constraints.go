@@ -0,0 +1,92 @@
+package migrations
+
+import (
+	"database/sql"
+	"fmt"
+	"monera-digital/internal/migration"
+	"strings"
+)
+
+// AddAccountBalanceConstraints migration makes negative balances and frozen
+// amounts above the balance impossible at the database level
+type AddAccountBalanceConstraints struct{}
+
+func (m *AddAccountBalanceConstraints) Version() string {
+	return "026"
+}
+
+func (m *AddAccountBalanceConstraints) Description() string {
+	return "Add check constraints on account balance and frozen balance"
+}
+
+// Up adds the constraints NOT VALID so new writes are checked at once, then
+// validates them only if no existing account violates them
+func (m *AddAccountBalanceConstraints) Up(db *sql.DB) error {
+	_, err := db.Exec(`
+		ALTER TABLE account DROP CONSTRAINT IF EXISTS ck_account_balance_non_negative;
+		ALTER TABLE account ADD CONSTRAINT ck_account_balance_non_negative
+			CHECK (balance >= 0) NOT VALID;
+		ALTER TABLE account DROP CONSTRAINT IF EXISTS ck_account_frozen_within_balance;
+		ALTER TABLE account ADD CONSTRAINT ck_account_frozen_within_balance
+			CHECK (frozen_balance >= 0 AND frozen_balance <= balance) NOT VALID;
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to add account balance constraints: %w", err)
+	}
+
+	if err := checkViolations(db, "account balance constraints", `
+		SELECT 'account ' || id || ' (balance ' || balance || ', frozen ' || frozen_balance || ')'
+		FROM account
+		WHERE balance < 0 OR frozen_balance < 0 OR frozen_balance > balance
+		ORDER BY id
+	`); err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+		ALTER TABLE account VALIDATE CONSTRAINT ck_account_balance_non_negative;
+		ALTER TABLE account VALIDATE CONSTRAINT ck_account_frozen_within_balance;
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to validate account balance constraints: %w", err)
+	}
+	return nil
+}
+
+func (m *AddAccountBalanceConstraints) Down(db *sql.DB) error {
+	_, err := db.Exec(`
+		ALTER TABLE account DROP CONSTRAINT IF EXISTS ck_account_frozen_within_balance;
+		ALTER TABLE account DROP CONSTRAINT IF EXISTS ck_account_balance_non_negative;
+	`)
+	return err
+}
+
+// checkViolations runs a query returning one description per offending row and
+// fails with the full list, so the rows can be repaired before re-running
+func checkViolations(db *sql.DB, constraint, query string) error {
+	rows, err := db.Query(query)
+	if err != nil {
+		return fmt.Errorf("failed to check %s: %w", constraint, err)
+	}
+	defer rows.Close()
+
+	var offending []string
+	for rows.Next() {
+		var row string
+		if err := rows.Scan(&row); err != nil {
+			return fmt.Errorf("failed to check %s: %w", constraint, err)
+		}
+		offending = append(offending, row)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to check %s: %w", constraint, err)
+	}
+	if len(offending) > 0 {
+		return fmt.Errorf("%d rows violate %s, repair them and re-run the migration: %s",
+			len(offending), constraint, strings.Join(offending, "; "))
+	}
+	return nil
+}
+
+// Ensure AddAccountBalanceConstraints implements Migration interface
+var _ migration.Migration = (*AddAccountBalanceConstraints)(nil)
@@ -0,0 +1,68 @@
+package migrations
+
+import (
+	"database/sql"
+	"fmt"
+	"monera-digital/internal/migration"
+)
+
+// AddWithdrawalLifecycle migration records the custody request ID and failure
+// reason on withdrawal orders and restricts status to the state machine
+type AddWithdrawalLifecycle struct{}
+
+func (m *AddWithdrawalLifecycle) Version() string {
+	return "027"
+}
+
+func (m *AddWithdrawalLifecycle) Description() string {
+	return "Add request ID, failure reason and status check to withdrawal orders"
+}
+
+// Up normalises legacy statuses, adds the status check NOT VALID and
+// validates it only once no order is left outside the state machine
+func (m *AddWithdrawalLifecycle) Up(db *sql.DB) error {
+	_, err := db.Exec(`
+		ALTER TABLE withdrawal_order ADD COLUMN IF NOT EXISTS request_id VARCHAR(64);
+		ALTER TABLE withdrawal_order ADD COLUMN IF NOT EXISTS failure_reason TEXT;
+		CREATE UNIQUE INDEX IF NOT EXISTS uq_withdrawal_order_request_id
+			ON withdrawal_order(request_id) WHERE request_id IS NOT NULL;
+
+		-- 统一大小写与空白；旧订单在提交托管后直接写为 SENT，对应状态机中的 PROCESSING
+		UPDATE withdrawal_order SET status = UPPER(TRIM(status)) WHERE status <> UPPER(TRIM(status));
+		UPDATE withdrawal_order SET status = 'PROCESSING' WHERE status = 'SENT';
+
+		ALTER TABLE withdrawal_order DROP CONSTRAINT IF EXISTS ck_withdrawal_order_status;
+		ALTER TABLE withdrawal_order ADD CONSTRAINT ck_withdrawal_order_status
+			CHECK (status IN ('PENDING', 'PROCESSING', 'CONFIRMED', 'COMPLETED', 'FAILED')) NOT VALID;
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to add withdrawal lifecycle columns: %w", err)
+	}
+
+	if err := checkViolations(db, "withdrawal order status check", `
+		SELECT 'withdrawal_order ' || id || ' (status ' || status || ')'
+		FROM withdrawal_order
+		WHERE status NOT IN ('PENDING', 'PROCESSING', 'CONFIRMED', 'COMPLETED', 'FAILED')
+		ORDER BY id
+	`); err != nil {
+		return err
+	}
+
+	if _, err := db.Exec(`ALTER TABLE withdrawal_order VALIDATE CONSTRAINT ck_withdrawal_order_status`); err != nil {
+		return fmt.Errorf("failed to validate withdrawal order status check: %w", err)
+	}
+	return nil
+}
+
+func (m *AddWithdrawalLifecycle) Down(db *sql.DB) error {
+	_, err := db.Exec(`
+		ALTER TABLE withdrawal_order DROP CONSTRAINT IF EXISTS ck_withdrawal_order_status;
+		DROP INDEX IF EXISTS uq_withdrawal_order_request_id;
+		ALTER TABLE withdrawal_order DROP COLUMN IF EXISTS failure_reason;
+		ALTER TABLE withdrawal_order DROP COLUMN IF EXISTS request_id;
+	`)
+	return err
+}
+
+// Ensure AddWithdrawalLifecycle implements Migration interface
+var _ migration.Migration = (*AddWithdrawalLifecycle)(nil)
@@ -0,0 +1,61 @@
+package migrations
+
+import (
+	"database/sql"
+	"fmt"
+	"monera-digital/internal/migration"
+)
+
+// WidenAmountColumns migration widens money columns created with a scale
+// below money.Spec (ETH keeps 18 decimals) to the NUMERIC(65, 30) used by the
+// wealth tables, so Postgres no longer rounds amounts on write
+type WidenAmountColumns struct{}
+
+// widenedAmountColumns 需加宽的金额列及其原始类型，Down 时恢复
+var widenedAmountColumns = []struct {
+	table, column, original string
+}{
+	{"account", "balance", "DECIMAL(32, 16)"},
+	{"account", "frozen_balance", "DECIMAL(32, 16)"},
+	{"withdrawal_order", "amount", "DECIMAL(32, 16)"},
+	{"withdrawal_order", "network_fee", "DECIMAL(32, 16)"},
+	{"withdrawal_order", "platform_fee", "DECIMAL(32, 16)"},
+	{"withdrawal_order", "actual_amount", "DECIMAL(32, 16)"},
+	{"withdrawal_freeze_log", "amount", "DECIMAL(32, 16)"},
+	{"p2p_transfer", "amount", "DECIMAL(32, 16)"},
+	{"lending_positions", "amount", "DECIMAL(20, 8)"},
+	{"lending_positions", "accrued_yield", "DECIMAL(20, 8)"},
+	{"lending_positions", "yield_paid", "DECIMAL(20, 8)"},
+	{"lending_yield_record", "amount", "DECIMAL(20, 8)"},
+}
+
+func (m *WidenAmountColumns) Version() string {
+	return "028"
+}
+
+func (m *WidenAmountColumns) Description() string {
+	return "Widen account, withdrawal, transfer and lending amounts to NUMERIC(65, 30)"
+}
+
+func (m *WidenAmountColumns) Up(db *sql.DB) error {
+	for _, c := range widenedAmountColumns {
+		// 加宽精度不会改变已有数值
+		if _, err := db.Exec(fmt.Sprintf(`ALTER TABLE %s ALTER COLUMN %s TYPE NUMERIC(65, 30)`, c.table, c.column)); err != nil {
+			return fmt.Errorf("failed to widen %s.%s: %w", c.table, c.column, err)
+		}
+	}
+	return nil
+}
+
+func (m *WidenAmountColumns) Down(db *sql.DB) error {
+	for i := len(widenedAmountColumns) - 1; i >= 0; i-- {
+		c := widenedAmountColumns[i]
+		if _, err := db.Exec(fmt.Sprintf(`ALTER TABLE %s ALTER COLUMN %s TYPE %s`, c.table, c.column, c.original)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Ensure WidenAmountColumns implements Migration interface
+var _ migration.Migration = (*WidenAmountColumns)(nil)
@@ -0,0 +1,55 @@
+package migrations
+
+import (
+	"database/sql"
+	"fmt"
+	"monera-digital/internal/migration"
+)
+
+// CreateAuditTrail migration makes sure the audit_trail table from the base
+// schema exists, so order term changes can be audited inside their transaction
+type CreateAuditTrail struct{}
+
+func (m *CreateAuditTrail) Version() string {
+	return "029"
+}
+
+func (m *CreateAuditTrail) Description() string {
+	return "Create audit trail table and index audits by target"
+}
+
+func (m *CreateAuditTrail) Up(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS audit_trail (
+			id BIGSERIAL PRIMARY KEY,
+			operator_id TEXT NOT NULL,
+			operator_role TEXT NOT NULL,
+			action TEXT NOT NULL,
+			target_id BIGINT,
+			target_type TEXT,
+			old_value JSONB,
+			new_value JSONB,
+			reason TEXT,
+			ip_address TEXT,
+			status TEXT,
+			error_message TEXT,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS idx_audit_trail_operator ON audit_trail(operator_id, created_at);
+		CREATE INDEX IF NOT EXISTS idx_audit_trail_action ON audit_trail(action, created_at);
+		CREATE INDEX IF NOT EXISTS idx_audit_trail_target ON audit_trail(target_type, target_id, created_at);
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create audit_trail table: %w", err)
+	}
+	return nil
+}
+
+// Down 只删除本迁移新增的索引，audit_trail 属于基础表结构
+func (m *CreateAuditTrail) Down(db *sql.DB) error {
+	_, err := db.Exec(`DROP INDEX IF EXISTS idx_audit_trail_target`)
+	return err
+}
+
+// Ensure CreateAuditTrail implements Migration interface
+var _ migration.Migration = (*CreateAuditTrail)(nil)
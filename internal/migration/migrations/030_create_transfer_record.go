@@ -0,0 +1,50 @@
+package migrations
+
+import (
+	"database/sql"
+	"fmt"
+	"monera-digital/internal/migration"
+)
+
+// CreateTransferRecord migration makes sure the transfer_record table from the
+// base schema exists, so internal account transfers are recorded alongside
+// their journal pair
+type CreateTransferRecord struct{}
+
+func (m *CreateTransferRecord) Version() string {
+	return "030"
+}
+
+func (m *CreateTransferRecord) Description() string {
+	return "Create transfer record table for internal account transfers"
+}
+
+func (m *CreateTransferRecord) Up(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS transfer_record (
+			id BIGSERIAL PRIMARY KEY,
+			user_id BIGINT NOT NULL,
+			transfer_id TEXT NOT NULL,
+			from_account_id BIGINT NOT NULL,
+			to_account_id BIGINT NOT NULL,
+			amount NUMERIC(65, 30) NOT NULL,
+			status TEXT DEFAULT 'PENDING' NOT NULL,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+			completed_at TIMESTAMP WITH TIME ZONE
+		);
+		CREATE INDEX IF NOT EXISTS idx_transfer_record_user_id ON transfer_record(user_id);
+		CREATE UNIQUE INDEX IF NOT EXISTS uk_transfer_record_transfer_id ON transfer_record(transfer_id);
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create transfer_record table: %w", err)
+	}
+	return nil
+}
+
+// Down 不做任何变更，transfer_record 及其索引均属于基础表结构
+func (m *CreateTransferRecord) Down(db *sql.DB) error {
+	return nil
+}
+
+// Ensure CreateTransferRecord implements Migration interface
+var _ migration.Migration = (*CreateTransferRecord)(nil)
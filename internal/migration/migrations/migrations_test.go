@@ -1,8 +1,10 @@
 package migrations
 
 import (
+	"strings"
 	"testing"
 
+	"github.com/DATA-DOG/go-sqlmock"
 	"monera-digital/internal/migration"
 )
 
@@ -48,6 +50,194 @@ func TestAddTwoFactorTimestampMigration_Description(t *testing.T) {
 	}
 }
 
+// TestCreateWealthInterestRecord_Version verifies version
+func TestCreateWealthInterestRecord_Version(t *testing.T) {
+	m := &CreateWealthInterestRecord{}
+	if m.Version() != "011" {
+		t.Errorf("Expected version '011', got '%s'", m.Version())
+	}
+}
+
+// TestCreateSchedulerRun_Version verifies version
+func TestCreateSchedulerRun_Version(t *testing.T) {
+	m := &CreateSchedulerRun{}
+	if m.Version() != "012" {
+		t.Errorf("Expected version '012', got '%s'", m.Version())
+	}
+}
+
+// TestCreateSchedulerJob_Version verifies version
+func TestCreateSchedulerJob_Version(t *testing.T) {
+	m := &CreateSchedulerJob{}
+	if m.Version() != "013" {
+		t.Errorf("Expected version '013', got '%s'", m.Version())
+	}
+}
+
+// TestAddEarlyRedeemRule_Version verifies version
+func TestAddEarlyRedeemRule_Version(t *testing.T) {
+	m := &AddEarlyRedeemRule{}
+	if m.Version() != "014" {
+		t.Errorf("Expected version '014', got '%s'", m.Version())
+	}
+}
+
+// TestAddFlexibleProducts_Version verifies version
+func TestAddFlexibleProducts_Version(t *testing.T) {
+	m := &AddFlexibleProducts{}
+	if m.Version() != "015" {
+		t.Errorf("Expected version '015', got '%s'", m.Version())
+	}
+}
+
+// TestCreateWealthProductRate_Version verifies version
+func TestCreateWealthProductRate_Version(t *testing.T) {
+	m := &CreateWealthProductRate{}
+	if m.Version() != "016" {
+		t.Errorf("Expected version '016', got '%s'", m.Version())
+	}
+}
+
+// TestAddAutoRenewCompound_Version verifies version
+func TestAddAutoRenewCompound_Version(t *testing.T) {
+	m := &AddAutoRenewCompound{}
+	if m.Version() != "017" {
+		t.Errorf("Expected version '017', got '%s'", m.Version())
+	}
+}
+
+// TestAddProductLifecycle_Version verifies version
+func TestAddProductLifecycle_Version(t *testing.T) {
+	m := &AddProductLifecycle{}
+	if m.Version() != "018" {
+		t.Errorf("Expected version '018', got '%s'", m.Version())
+	}
+}
+
+// TestCreateWealthSubscribeQuote_Version verifies version
+func TestCreateWealthSubscribeQuote_Version(t *testing.T) {
+	m := &CreateWealthSubscribeQuote{}
+	if m.Version() != "019" {
+		t.Errorf("Expected version '019', got '%s'", m.Version())
+	}
+}
+
+// TestAddProductBusinessCalendar_Version verifies version
+func TestAddProductBusinessCalendar_Version(t *testing.T) {
+	m := &AddProductBusinessCalendar{}
+	if m.Version() != "020" {
+		t.Errorf("Expected version '020', got '%s'", m.Version())
+	}
+}
+
+// TestAddLendingLifecycle_Version verifies version
+func TestAddLendingLifecycle_Version(t *testing.T) {
+	m := &AddLendingLifecycle{}
+	if m.Version() != "021" {
+		t.Errorf("Expected version '021', got '%s'", m.Version())
+	}
+}
+
+// TestAddLendingRates_Version verifies version
+func TestAddLendingRates_Version(t *testing.T) {
+	m := &AddLendingRates{}
+	if m.Version() != "022" {
+		t.Errorf("Expected version '022', got '%s'", m.Version())
+	}
+}
+
+// TestCreateLoan_Version verifies version
+func TestCreateLoan_Version(t *testing.T) {
+	m := &CreateLoan{}
+	if m.Version() != "023" {
+		t.Errorf("Expected version '023', got '%s'", m.Version())
+	}
+}
+
+// TestAddAccountTypeCurrencyUnique_Version verifies version
+func TestAddAccountTypeCurrencyUnique_Version(t *testing.T) {
+	m := &AddAccountTypeCurrencyUnique{}
+	if m.Version() != "024" {
+		t.Errorf("Expected version '024', got '%s'", m.Version())
+	}
+}
+
+// TestCreateP2PTransfer_Version verifies version
+func TestCreateP2PTransfer_Version(t *testing.T) {
+	m := &CreateP2PTransfer{}
+	if m.Version() != "025" {
+		t.Errorf("Expected version '025', got '%s'", m.Version())
+	}
+}
+
+// TestAddAccountBalanceConstraints_Version verifies version
+func TestAddAccountBalanceConstraints_Version(t *testing.T) {
+	m := &AddAccountBalanceConstraints{}
+	if m.Version() != "026" {
+		t.Errorf("Expected version '026', got '%s'", m.Version())
+	}
+}
+
+// TestAddWithdrawalLifecycle_Version verifies version
+func TestAddWithdrawalLifecycle_Version(t *testing.T) {
+	m := &AddWithdrawalLifecycle{}
+	if m.Version() != "027" {
+		t.Errorf("Expected version '027', got '%s'", m.Version())
+	}
+}
+
+// TestWidenAmountColumns_Version verifies version
+func TestWidenAmountColumns_Version(t *testing.T) {
+	m := &WidenAmountColumns{}
+	if m.Version() != "028" {
+		t.Errorf("Expected version '028', got '%s'", m.Version())
+	}
+}
+
+// TestWidenAmountColumns_Up_CoversETHScale verifies every widened column holds 18 decimals
+func TestWidenAmountColumns_Up_CoversETHScale(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	widened := make(map[string]bool)
+	for _, c := range widenedAmountColumns {
+		widened[c.table+"."+c.column] = true
+		mock.ExpectExec("ALTER TABLE " + c.table + " ALTER COLUMN " + c.column + " TYPE NUMERIC\\(65, 30\\)").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+	}
+	for _, col := range []string{"account.balance", "account.frozen_balance", "withdrawal_order.amount", "p2p_transfer.amount"} {
+		if !widened[col] {
+			t.Errorf("Expected %s to be widened", col)
+		}
+	}
+
+	if err := (&WidenAmountColumns{}).Up(db); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+// TestCreateAuditTrail_Version verifies version
+func TestCreateAuditTrail_Version(t *testing.T) {
+	m := &CreateAuditTrail{}
+	if m.Version() != "029" {
+		t.Errorf("Expected version '029', got '%s'", m.Version())
+	}
+}
+
+// TestCreateTransferRecord_Version verifies version
+func TestCreateTransferRecord_Version(t *testing.T) {
+	m := &CreateTransferRecord{}
+	if m.Version() != "030" {
+		t.Errorf("Expected version '030', got '%s'", m.Version())
+	}
+}
+
 // TestMigrationOrder verifies all migrations are properly ordered
 func TestMigrationOrder(t *testing.T) {
 	migrations := []struct {
@@ -60,6 +250,26 @@ func TestMigrationOrder(t *testing.T) {
 		{"AddTwoFactorColumnsMigration", "004"},
 		{"AddTwoFactorTimestampMigration", "005"},
 		{"UpdateWalletRequestsTable", "007"},
+		{"CreateWealthInterestRecord", "011"},
+		{"CreateSchedulerRun", "012"},
+		{"CreateSchedulerJob", "013"},
+		{"AddEarlyRedeemRule", "014"},
+		{"AddFlexibleProducts", "015"},
+		{"CreateWealthProductRate", "016"},
+		{"AddAutoRenewCompound", "017"},
+		{"AddProductLifecycle", "018"},
+		{"CreateWealthSubscribeQuote", "019"},
+		{"AddProductBusinessCalendar", "020"},
+		{"AddLendingLifecycle", "021"},
+		{"AddLendingRates", "022"},
+		{"CreateLoan", "023"},
+		{"AddAccountTypeCurrencyUnique", "024"},
+		{"CreateP2PTransfer", "025"},
+		{"AddAccountBalanceConstraints", "026"},
+		{"AddWithdrawalLifecycle", "027"},
+		{"WidenAmountColumns", "028"},
+		{"CreateAuditTrail", "029"},
+		{"CreateTransferRecord", "030"},
 	}
 
 	for i, m := range migrations {
@@ -80,3 +290,130 @@ func TestMigrationOrder(t *testing.T) {
 		})
 	}
 }
+
+// TestAddAccountBalanceConstraints_Up_ValidatesCleanTable verifies constraints are validated when no row violates them
+func TestAddAccountBalanceConstraints_Up_ValidatesCleanTable(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec("ADD CONSTRAINT ck_account_balance_non_negative").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("FROM account").WillReturnRows(sqlmock.NewRows([]string{"row"}))
+	mock.ExpectExec("VALIDATE CONSTRAINT ck_account_balance_non_negative").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	if err := (&AddAccountBalanceConstraints{}).Up(db); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+// TestAddAccountBalanceConstraints_Up_ReportsOffendingAccounts verifies the migration lists violating accounts instead of validating
+func TestAddAccountBalanceConstraints_Up_ReportsOffendingAccounts(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec("ADD CONSTRAINT ck_account_balance_non_negative").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("FROM account").WillReturnRows(sqlmock.NewRows([]string{"row"}).
+		AddRow("account 3 (balance -5, frozen 0)").
+		AddRow("account 9 (balance 10, frozen 20)"))
+
+	err = (&AddAccountBalanceConstraints{}).Up(db)
+	if err == nil {
+		t.Fatal("Expected error for offending accounts")
+	}
+	for _, want := range []string{"2 rows", "account 3", "account 9"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error to mention %q, got %v", want, err)
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+// TestAddWithdrawalLifecycle_Up_ReportsUnknownStatuses verifies orders outside the state machine are listed instead of validated
+func TestAddWithdrawalLifecycle_Up_ReportsUnknownStatuses(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec("UPDATE withdrawal_order SET status = 'PROCESSING' WHERE status = 'SENT'").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("FROM withdrawal_order").WillReturnRows(sqlmock.NewRows([]string{"row"}).
+		AddRow("withdrawal_order 12 (status CANCELLED)"))
+
+	err = (&AddWithdrawalLifecycle{}).Up(db)
+	if err == nil || !strings.Contains(err.Error(), "withdrawal_order 12 (status CANCELLED)") {
+		t.Errorf("Expected error listing order 12, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+// TestAddWithdrawalLifecycle_Up_ValidatesStatusCheck verifies the status check is validated when all orders are known
+func TestAddWithdrawalLifecycle_Up_ValidatesStatusCheck(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec("NOT VALID").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("FROM withdrawal_order").WillReturnRows(sqlmock.NewRows([]string{"row"}))
+	mock.ExpectExec("VALIDATE CONSTRAINT ck_withdrawal_order_status").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	if err := (&AddWithdrawalLifecycle{}).Up(db); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+// TestAddAccountTypeCurrencyUnique_Up_ReportsDuplicates verifies duplicated accounts are listed before the index is created
+func TestAddAccountTypeCurrencyUnique_Up_ReportsDuplicates(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("HAVING COUNT").WillReturnRows(sqlmock.NewRows([]string{"row"}).
+		AddRow("user 5 FUND USDT (accounts 11, 14)"))
+
+	err = (&AddAccountTypeCurrencyUnique{}).Up(db)
+	if err == nil || !strings.Contains(err.Error(), "user 5 FUND USDT (accounts 11, 14)") {
+		t.Errorf("Expected error listing duplicated accounts, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+// TestAddAccountTypeCurrencyUnique_Up_CreatesIndex verifies the index is created when no duplicates exist
+func TestAddAccountTypeCurrencyUnique_Up_CreatesIndex(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("HAVING COUNT").WillReturnRows(sqlmock.NewRows([]string{"row"}))
+	mock.ExpectExec("CREATE UNIQUE INDEX IF NOT EXISTS uq_account_user_type_currency").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	if err := (&AddAccountTypeCurrencyUnique{}).Up(db); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -28,10 +28,30 @@ type WithdrawalStatus string
 const (
 	WithdrawalStatusPending    WithdrawalStatus = "PENDING"
 	WithdrawalStatusProcessing WithdrawalStatus = "PROCESSING"
+	WithdrawalStatusConfirmed  WithdrawalStatus = "CONFIRMED"
 	WithdrawalStatusCompleted  WithdrawalStatus = "COMPLETED"
 	WithdrawalStatusFailed     WithdrawalStatus = "FAILED"
 )
 
+// withdrawalTransitions 提现订单允许的状态流转：
+// PENDING 已冻结待发送，PROCESSING 已提交托管并扣款，CONFIRMED 链上已确认；
+// COMPLETED 与 FAILED 为终态，链上确认后不再允许失败
+var withdrawalTransitions = map[WithdrawalStatus][]WithdrawalStatus{
+	WithdrawalStatusPending:    {WithdrawalStatusProcessing, WithdrawalStatusFailed},
+	WithdrawalStatusProcessing: {WithdrawalStatusConfirmed, WithdrawalStatusCompleted, WithdrawalStatusFailed},
+	WithdrawalStatusConfirmed:  {WithdrawalStatusCompleted},
+}
+
+// CanTransitionTo reports whether an order in status s may move to next
+func (s WithdrawalStatus) CanTransitionTo(next WithdrawalStatus) bool {
+	for _, allowed := range withdrawalTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
 type DepositStatus string
 
 const (
@@ -68,19 +88,6 @@ type User struct {
 	CreatedAt            time.Time      `json:"createdAt" db:"created_at"`
 }
 
-// Account model (New)
-type Account struct {
-	ID            int       `json:"id" db:"id"`
-	UserID        int       `json:"user_id" db:"user_id"`
-	Type          string    `json:"type" db:"type"` // WEALTH, FUND
-	Currency      string    `json:"currency" db:"currency"`
-	Balance       float64   `json:"balance" db:"balance"`
-	FrozenBalance float64   `json:"frozen_balance" db:"frozen_balance"`
-	Version       int64     `json:"version" db:"version"`
-	CreatedAt     time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
-}
-
 // Deposit model
 type Deposit struct {
 	ID          int            `json:"id" db:"id"`
@@ -182,7 +189,9 @@ type WithdrawalOrder struct {
 	ToAddress        string         `json:"to_address" db:"to_address"`
 	SafeheronOrderID sql.NullString `json:"safeheron_order_id" db:"safeheron_order_id"`
 	TransactionHash  sql.NullString `json:"transaction_hash" db:"transaction_hash"`
+	RequestID        string         `json:"request_id" db:"request_id"`
 	Status           string         `json:"status" db:"status"`
+	FailureReason    sql.NullString `json:"failure_reason" db:"failure_reason"`
 	CreatedAt        time.Time      `json:"created_at" db:"created_at"`
 	SentAt           sql.NullTime   `json:"sent_at" db:"sent_at"`
 	ConfirmedAt      sql.NullTime   `json:"confirmed_at" db:"confirmed_at"`
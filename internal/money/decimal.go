@@ -0,0 +1,383 @@
+// internal/money/decimal.go
+package money
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidDecimal = errors.New("invalid decimal")
+
+// RoundingMode 舍入模式
+type RoundingMode int
+
+const (
+	// RoundDown truncates toward zero
+	RoundDown RoundingMode = iota
+	// RoundUp rounds away from zero
+	RoundUp
+	// RoundHalfUp rounds to nearest, ties away from zero
+	RoundHalfUp
+	// RoundHalfEven rounds to nearest, ties to even (banker's rounding)
+	RoundHalfEven
+)
+
+var (
+	bigOne = big.NewInt(1)
+	bigTen = big.NewInt(10)
+)
+
+// Decimal is an immutable fixed-point decimal number: coef * 10^-scale.
+//
+// Values are kept in canonical form (no trailing zeros in the fraction and a
+// nil coefficient for zero) so two equal amounts are also reflect.DeepEqual,
+// which keeps them usable as testify mock arguments. The zero value is 0.
+type Decimal struct {
+	coef  *big.Int
+	scale int32
+}
+
+// Zero is the decimal 0
+var Zero = Decimal{}
+
+// New returns coef * 10^-scale
+func New(coef int64, scale int32) Decimal {
+	return newDecimal(big.NewInt(coef), scale)
+}
+
+// NewFromInt returns the decimal for an integer
+func NewFromInt(i int64) Decimal {
+	return New(i, 0)
+}
+
+// newDecimal normalizes coef/scale into canonical form. It takes ownership of coef.
+func newDecimal(coef *big.Int, scale int32) Decimal {
+	if coef == nil || coef.Sign() == 0 {
+		return Decimal{}
+	}
+	for scale < 0 {
+		coef.Mul(coef, bigTen)
+		scale++
+	}
+	if scale > 0 {
+		q, r := new(big.Int), new(big.Int)
+		for scale > 0 {
+			q.QuoRem(coef, bigTen, r)
+			if r.Sign() != 0 {
+				break
+			}
+			coef.Set(q)
+			scale--
+		}
+	}
+	return Decimal{coef: coef, scale: scale}
+}
+
+// Parse parses a plain decimal string such as "-12.3400".
+// Exponent notation is not accepted; amounts are always stored in plain form.
+func Parse(s string) (Decimal, error) {
+	str := strings.TrimSpace(s)
+	if str == "" {
+		return Decimal{}, fmt.Errorf("%w: empty string", ErrInvalidDecimal)
+	}
+
+	neg := false
+	switch str[0] {
+	case '-':
+		neg = true
+		str = str[1:]
+	case '+':
+		str = str[1:]
+	}
+
+	intPart, fracPart := str, ""
+	if i := strings.IndexByte(str, '.'); i >= 0 {
+		intPart, fracPart = str[:i], str[i+1:]
+	}
+	if intPart == "" && fracPart == "" {
+		return Decimal{}, fmt.Errorf("%w: %q", ErrInvalidDecimal, s)
+	}
+	for _, part := range []string{intPart, fracPart} {
+		for i := 0; i < len(part); i++ {
+			if part[i] < '0' || part[i] > '9' {
+				return Decimal{}, fmt.Errorf("%w: %q", ErrInvalidDecimal, s)
+			}
+		}
+	}
+
+	coef, ok := new(big.Int).SetString(intPart+fracPart, 10)
+	if !ok {
+		return Decimal{}, fmt.Errorf("%w: %q", ErrInvalidDecimal, s)
+	}
+	if neg {
+		coef.Neg(coef)
+	}
+	return newDecimal(coef, int32(len(fracPart))), nil
+}
+
+// MustParse is like Parse but panics on invalid input. Intended for constants and tests.
+func MustParse(s string) Decimal {
+	d, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// ParseOrZero parses s and returns Zero for empty or invalid input
+func ParseOrZero(s string) Decimal {
+	d, err := Parse(s)
+	if err != nil {
+		return Zero
+	}
+	return d
+}
+
+func (d Decimal) bigCoef() *big.Int {
+	if d.coef == nil {
+		return new(big.Int)
+	}
+	return new(big.Int).Set(d.coef)
+}
+
+// rescale returns the coefficient of d expressed at the given (larger or equal) scale
+func (d Decimal) rescale(scale int32) *big.Int {
+	c := d.bigCoef()
+	if scale > d.scale {
+		c.Mul(c, pow10(scale-d.scale))
+	}
+	return c
+}
+
+func pow10(n int32) *big.Int {
+	return new(big.Int).Exp(bigTen, big.NewInt(int64(n)), nil)
+}
+
+func maxScale(a, b Decimal) int32 {
+	if a.scale > b.scale {
+		return a.scale
+	}
+	return b.scale
+}
+
+// Add returns d + o
+func (d Decimal) Add(o Decimal) Decimal {
+	s := maxScale(d, o)
+	return newDecimal(new(big.Int).Add(d.rescale(s), o.rescale(s)), s)
+}
+
+// Sub returns d - o
+func (d Decimal) Sub(o Decimal) Decimal {
+	s := maxScale(d, o)
+	return newDecimal(new(big.Int).Sub(d.rescale(s), o.rescale(s)), s)
+}
+
+// Mul returns d * o exactly
+func (d Decimal) Mul(o Decimal) Decimal {
+	return newDecimal(new(big.Int).Mul(d.bigCoef(), o.bigCoef()), d.scale+o.scale)
+}
+
+// Div returns d / o rounded to scale decimal places. It panics if o is zero.
+func (d Decimal) Div(o Decimal, scale int32, mode RoundingMode) Decimal {
+	if o.IsZero() {
+		panic("money: division by zero")
+	}
+	// d/o = (dc * 10^-ds) / (oc * 10^-os); unscaled result at target scale is
+	// dc * 10^(scale + os - ds) / oc
+	num, den := d.bigCoef(), o.bigCoef()
+	shift := scale + o.scale - d.scale
+	if shift >= 0 {
+		num.Mul(num, pow10(shift))
+	} else {
+		den.Mul(den, pow10(-shift))
+	}
+	return newDecimal(roundQuo(num, den, mode), scale)
+}
+
+// Round returns d rounded to scale decimal places using mode
+func (d Decimal) Round(scale int32, mode RoundingMode) Decimal {
+	if d.scale <= scale {
+		return d
+	}
+	return newDecimal(roundQuo(d.bigCoef(), pow10(d.scale-scale), mode), scale)
+}
+
+// roundQuo returns num/den rounded to an integer using mode
+func roundQuo(num, den *big.Int, mode RoundingMode) *big.Int {
+	q, r := new(big.Int).QuoRem(num, den, new(big.Int))
+	if r.Sign() == 0 {
+		return q
+	}
+
+	// sign of the exact quotient
+	sign := num.Sign() * den.Sign()
+	away := false
+	switch mode {
+	case RoundDown:
+		away = false
+	case RoundUp:
+		away = true
+	case RoundHalfUp, RoundHalfEven:
+		twiceR := new(big.Int).Abs(r)
+		twiceR.Lsh(twiceR, 1)
+		switch twiceR.Cmp(new(big.Int).Abs(den)) {
+		case 1:
+			away = true
+		case 0:
+			away = mode == RoundHalfUp || q.Bit(0) == 1
+		}
+	}
+	if away {
+		if sign < 0 {
+			q.Sub(q, bigOne)
+		} else {
+			q.Add(q, bigOne)
+		}
+	}
+	return q
+}
+
+// Neg returns -d
+func (d Decimal) Neg() Decimal {
+	return newDecimal(d.bigCoef().Neg(d.bigCoef()), d.scale)
+}
+
+// Abs returns |d|
+func (d Decimal) Abs() Decimal {
+	if d.Sign() < 0 {
+		return d.Neg()
+	}
+	return d
+}
+
+// Cmp compares d and o and returns -1, 0 or +1
+func (d Decimal) Cmp(o Decimal) int {
+	s := maxScale(d, o)
+	return d.rescale(s).Cmp(o.rescale(s))
+}
+
+// Sign returns -1, 0 or +1
+func (d Decimal) Sign() int {
+	if d.coef == nil {
+		return 0
+	}
+	return d.coef.Sign()
+}
+
+func (d Decimal) IsZero() bool               { return d.Sign() == 0 }
+func (d Decimal) IsPositive() bool           { return d.Sign() > 0 }
+func (d Decimal) IsNegative() bool           { return d.Sign() < 0 }
+func (d Decimal) Equal(o Decimal) bool       { return d.Cmp(o) == 0 }
+func (d Decimal) GreaterThan(o Decimal) bool { return d.Cmp(o) > 0 }
+func (d Decimal) LessThan(o Decimal) bool    { return d.Cmp(o) < 0 }
+
+// Scale returns the number of significant fractional digits
+func (d Decimal) Scale() int32 {
+	return d.scale
+}
+
+// Min returns the smaller of a and b
+func Min(a, b Decimal) Decimal {
+	if a.Cmp(b) <= 0 {
+		return a
+	}
+	return b
+}
+
+// Max returns the larger of a and b
+func Max(a, b Decimal) Decimal {
+	if a.Cmp(b) >= 0 {
+		return a
+	}
+	return b
+}
+
+// String returns the plain representation without trailing zeros, e.g. "1.5"
+func (d Decimal) String() string {
+	return d.format(d.scale)
+}
+
+// StringFixed returns d rounded half-up and padded to exactly places decimals
+func (d Decimal) StringFixed(places int32) string {
+	return d.Round(places, RoundHalfUp).format(places)
+}
+
+func (d Decimal) format(places int32) string {
+	c := d.bigCoef()
+	if places > d.scale {
+		c.Mul(c, pow10(places-d.scale))
+	}
+	neg := c.Sign() < 0
+	digits := c.Abs(c).String()
+
+	if places > 0 {
+		if len(digits) <= int(places) {
+			digits = strings.Repeat("0", int(places)-len(digits)+1) + digits
+		}
+		i := len(digits) - int(places)
+		digits = digits[:i] + "." + digits[i:]
+	}
+	if neg {
+		return "-" + digits
+	}
+	return digits
+}
+
+// Float64 returns the nearest float64. Only for display and metrics, never for arithmetic.
+func (d Decimal) Float64() float64 {
+	f, _ := strconv.ParseFloat(d.String(), 64)
+	return f
+}
+
+// Scan implements sql.Scanner for NUMERIC/TEXT columns
+func (d *Decimal) Scan(src interface{}) error {
+	var err error
+	switch v := src.(type) {
+	case nil:
+		*d = Zero
+	case string:
+		*d, err = Parse(v)
+	case []byte:
+		*d, err = Parse(string(v))
+	case int64:
+		*d = NewFromInt(v)
+	case float64:
+		*d, err = Parse(strconv.FormatFloat(v, 'f', -1, 64))
+	default:
+		err = fmt.Errorf("%w: cannot scan %T", ErrInvalidDecimal, src)
+	}
+	return err
+}
+
+// Value implements driver.Valuer; amounts are sent as plain strings so
+// CAST($n AS NUMERIC) keeps full precision
+func (d Decimal) Value() (driver.Value, error) {
+	return d.String(), nil
+}
+
+// MarshalJSON encodes the decimal as a JSON string
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(d.String())), nil
+}
+
+// UnmarshalJSON accepts either a JSON string or a JSON number
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	s := strings.TrimSpace(string(data))
+	if s == "null" {
+		*d = Zero
+		return nil
+	}
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+	parsed, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
@@ -0,0 +1,136 @@
+package money
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"0", "0", false},
+		{"100", "100", false},
+		{"100.000000", "100", false},
+		{"-12.3400", "-12.34", false},
+		{"+0.5", "0.5", false},
+		{".25", "0.25", false},
+		{"0.000000000000000000000000000001", "0.000000000000000000000000000001", false},
+		{"", "", true},
+		{"abc", "", true},
+		{"1e5", "", true},
+		{"1.2.3", "", true},
+		{"-", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			d, err := Parse(tt.in)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidDecimal)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, d.String())
+		})
+	}
+}
+
+func TestDecimal_CanonicalEquality(t *testing.T) {
+	// 值相等的金额必须 DeepEqual，mock 参数匹配依赖这一点
+	assert.Equal(t, MustParse("5000"), MustParse("5000.000"))
+	assert.Equal(t, Zero, MustParse("0.00"))
+	assert.Equal(t, Zero, MustParse("1.5").Sub(MustParse("1.50")))
+	assert.Equal(t, MustParse("18.21"), MustParse("10").Add(MustParse("8.21")))
+}
+
+func TestDecimal_Arithmetic(t *testing.T) {
+	a := MustParse("0.1")
+	b := MustParse("0.2")
+	assert.Equal(t, "0.3", a.Add(b).String())
+	assert.Equal(t, "-0.1", a.Sub(b).String())
+	assert.Equal(t, "0.02", a.Mul(b).String())
+	assert.Equal(t, 1, b.Cmp(a))
+	assert.True(t, a.Sub(b).IsNegative())
+	assert.Equal(t, "0.1", a.Sub(b).Abs().String())
+}
+
+func TestDecimal_Div(t *testing.T) {
+	// 10000 * 5.5% / 365
+	daily := MustParse("10000").Mul(MustParse("5.5")).Div(NewFromInt(36500), 6, RoundDown)
+	assert.Equal(t, "1.506849", daily.String())
+
+	assert.Equal(t, "0.333333", NewFromInt(1).Div(NewFromInt(3), 6, RoundHalfEven).String())
+	assert.Equal(t, "0.666667", NewFromInt(2).Div(NewFromInt(3), 6, RoundHalfEven).String())
+	assert.Equal(t, "-0.666666", NewFromInt(-2).Div(NewFromInt(3), 6, RoundDown).String())
+	assert.Panics(t, func() { NewFromInt(1).Div(Zero, 2, RoundDown) })
+}
+
+func TestDecimal_Round(t *testing.T) {
+	tests := []struct {
+		in   string
+		mode RoundingMode
+		want string
+	}{
+		{"1.005", RoundDown, "1"},
+		{"1.005", RoundUp, "1.01"},
+		{"1.005", RoundHalfUp, "1.01"},
+		{"1.005", RoundHalfEven, "1"},
+		{"1.015", RoundHalfEven, "1.02"},
+		{"-1.005", RoundHalfUp, "-1.01"},
+		{"-1.005", RoundDown, "-1"},
+		{"-1.001", RoundUp, "-1.01"},
+		{"1.2", RoundUp, "1.2"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, MustParse(tt.in).Round(2, tt.mode).String(), "%s mode=%d", tt.in, tt.mode)
+	}
+}
+
+func TestDecimal_StringFixed(t *testing.T) {
+	assert.Equal(t, "100000.0000000", NewFromInt(100000).StringFixed(7))
+	assert.Equal(t, "0.0000001", MustParse("0.00000005").StringFixed(7))
+	assert.Equal(t, "-0.50", MustParse("-0.5").StringFixed(2))
+}
+
+func TestDecimal_ScanValueJSON(t *testing.T) {
+	var d Decimal
+	require.NoError(t, d.Scan([]byte("12.500000000000000000000000000000")))
+	assert.Equal(t, "12.5", d.String())
+	require.NoError(t, d.Scan(int64(7)))
+	assert.Equal(t, "7", d.String())
+	require.NoError(t, d.Scan(nil))
+	assert.True(t, d.IsZero())
+	assert.Error(t, d.Scan(true))
+
+	v, err := MustParse("3.14").Value()
+	require.NoError(t, err)
+	assert.Equal(t, "3.14", v)
+
+	out, err := json.Marshal(struct {
+		Amount Decimal `json:"amount"`
+	}{MustParse("1.5")})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"amount":"1.5"}`, string(out))
+
+	var in struct {
+		A Decimal `json:"a"`
+		B Decimal `json:"b"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(`{"a":"2.25","b":3.5}`), &in))
+	assert.Equal(t, "2.25", in.A.String())
+	assert.Equal(t, "3.5", in.B.String())
+}
+
+func TestSpecFor(t *testing.T) {
+	assert.Equal(t, int32(6), SpecFor("USDT").Scale)
+	assert.Equal(t, int32(6), SpecFor("USDT_ERC20").Scale)
+	assert.Equal(t, int32(8), SpecFor("BTC").Scale)
+	assert.Equal(t, DefaultSpec, SpecFor("UNKNOWN"))
+	assert.Equal(t, "1.506849", Quantize("USDT", MustParse("1.50684931506")).String())
+}
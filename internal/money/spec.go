@@ -0,0 +1,67 @@
+// internal/money/spec.go
+package money
+
+import (
+	"strings"
+	"sync"
+)
+
+// Spec 币种精度与舍入规则
+// Every stored amount (balance, frozen balance, interest, journal snapshot)
+// is quantized to Scale decimals with Rounding before it is persisted.
+type Spec struct {
+	Scale    int32
+	Rounding RoundingMode
+}
+
+// DefaultSpec is used for currencies without an explicit entry
+var DefaultSpec = Spec{Scale: 8, Rounding: RoundDown}
+
+var (
+	specsMu sync.RWMutex
+	specs   = map[string]Spec{
+		// 稳定币按链上最小单位 6 位小数，利息一律向下取整，避免超额发放
+		"USDT": {Scale: 6, Rounding: RoundDown},
+		"USDC": {Scale: 6, Rounding: RoundDown},
+		"DAI":  {Scale: 6, Rounding: RoundDown},
+		"BTC":  {Scale: 8, Rounding: RoundDown},
+		"ETH":  {Scale: 18, Rounding: RoundDown},
+		"SOL":  {Scale: 9, Rounding: RoundDown},
+	}
+)
+
+// RegisterSpec sets or overrides the spec of a currency
+func RegisterSpec(currency string, spec Spec) {
+	specsMu.Lock()
+	defer specsMu.Unlock()
+	specs[baseCurrency(currency)] = spec
+}
+
+// SpecFor returns the spec for a currency. Network-qualified codes such as
+// USDT_ERC20 resolve to their token (USDT).
+func SpecFor(currency string) Spec {
+	specsMu.RLock()
+	defer specsMu.RUnlock()
+	if spec, ok := specs[baseCurrency(currency)]; ok {
+		return spec
+	}
+	return DefaultSpec
+}
+
+// Quantize rounds d to the currency's smallest unit
+func Quantize(currency string, d Decimal) Decimal {
+	return SpecFor(currency).Quantize(d)
+}
+
+// Quantize rounds d to the spec's scale using its rounding mode
+func (s Spec) Quantize(d Decimal) Decimal {
+	return d.Round(s.Scale, s.Rounding)
+}
+
+func baseCurrency(currency string) string {
+	c := strings.ToUpper(strings.TrimSpace(currency))
+	if i := strings.IndexByte(c, '_'); i > 0 {
+		c = c[:i]
+	}
+	return c
+}
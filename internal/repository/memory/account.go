@@ -0,0 +1,201 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sort"
+
+	"monera-digital/internal/money"
+	"monera-digital/internal/repository"
+)
+
+type AccountRepository struct {
+	store *Store
+}
+
+func NewAccountRepository(store *Store) *AccountRepository {
+	return &AccountRepository{store: store}
+}
+
+var _ repository.AccountV2 = (*AccountRepository)(nil)
+
+// CreateAccount 开立账户，供模拟器与测试准备初始余额
+func (r *AccountRepository) CreateAccount(ctx context.Context, account *repository.AccountModel) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, a := range s.data.accounts {
+		if a.UserID == account.UserID && a.Currency == account.Currency && a.Type == account.Type {
+			return repository.ErrAlreadyExists
+		}
+	}
+	account.ID = s.nextID()
+	if account.Type == "" {
+		account.Type = repository.AccountTypeFund
+	}
+	account.CreatedAt = s.timestamp()
+	account.UpdatedAt = account.CreatedAt
+	s.data.accounts[account.ID] = *account
+	return nil
+}
+
+func (r *AccountRepository) GetAccountByUserIDAndCurrency(ctx context.Context, userID int64, currency string) (*repository.AccountModel, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	for _, a := range r.store.data.accounts {
+		if a.UserID == userID && a.Currency == currency && a.Type == repository.AccountTypeFund {
+			return &a, nil
+		}
+	}
+	return nil, errAccountNotFound(userID, currency)
+}
+
+func (r *AccountRepository) GetAccountByType(ctx context.Context, userID int64, accountType, currency string) (*repository.AccountModel, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	for _, a := range r.store.data.accounts {
+		if a.UserID == userID && a.Type == accountType && a.Currency == currency {
+			return &a, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *AccountRepository) GetAccountsByUserID(ctx context.Context, userID int64) ([]*repository.AccountModel, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	var accounts []*repository.AccountModel
+	for _, a := range r.store.data.accounts {
+		if a.UserID == userID {
+			a := a
+			accounts = append(accounts, &a)
+		}
+	}
+	sort.Slice(accounts, func(i, j int) bool {
+		if accounts[i].Currency != accounts[j].Currency {
+			return accounts[i].Currency < accounts[j].Currency
+		}
+		return accounts[i].Type < accounts[j].Type
+	})
+	return accounts, nil
+}
+
+func (r *AccountRepository) CreateTransferRecord(ctx context.Context, record *repository.TransferRecordModel) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, t := range s.data.transfers {
+		if t.TransferID == record.TransferID {
+			return repository.ErrAlreadyExists
+		}
+	}
+	record.ID = s.nextID()
+	s.data.transfers = append(s.data.transfers, *record)
+	return nil
+}
+
+func (r *AccountRepository) FreezeBalance(ctx context.Context, accountID int64, amount money.Decimal) error {
+	return r.update(accountID, amount, func(a *repository.AccountModel) {
+		a.FrozenBalance = a.FrozenBalance.Add(amount)
+	})
+}
+
+func (r *AccountRepository) UnfreezeBalance(ctx context.Context, accountID int64, amount money.Decimal) error {
+	return r.update(accountID, amount, func(a *repository.AccountModel) {
+		a.FrozenBalance = a.FrozenBalance.Sub(amount)
+	})
+}
+
+func (r *AccountRepository) DeductBalance(ctx context.Context, accountID int64, amount money.Decimal) error {
+	return r.update(accountID, amount, func(a *repository.AccountModel) {
+		a.Balance = a.Balance.Sub(amount)
+	})
+}
+
+func (r *AccountRepository) AddBalance(ctx context.Context, accountID int64, amount money.Decimal) error {
+	return r.update(accountID, amount, func(a *repository.AccountModel) {
+		a.Balance = a.Balance.Add(amount)
+	})
+}
+
+// UpdateBalance 版本号不一致时返回 ErrVersionConflict
+func (r *AccountRepository) UpdateBalance(ctx context.Context, accountID int64, version int64, delta money.Decimal) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a, ok := s.data.accounts[accountID]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	if a.Version != version {
+		return repository.ErrVersionConflict
+	}
+	a.Balance = a.Balance.Add(delta)
+	a.Version++
+	a.UpdatedAt = s.timestamp()
+	s.data.accounts[accountID] = a
+	return nil
+}
+
+// update 与 postgres 实现保持相同的约束：金额不得为负，更新后冻结额须在 0 与余额之间
+func (r *AccountRepository) update(accountID int64, amount money.Decimal, fn func(a *repository.AccountModel)) error {
+	if amount.IsNegative() {
+		return repository.ErrInvalidInput
+	}
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a, ok := s.data.accounts[accountID]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	fn(&a)
+	if a.FrozenBalance.IsNegative() || a.FrozenBalance.GreaterThan(a.Balance) {
+		return repository.ErrInsufficientBalance
+	}
+	a.Version++
+	a.UpdatedAt = s.timestamp()
+	s.data.accounts[accountID] = a
+	return nil
+}
+
+type JournalRepository struct {
+	store *Store
+}
+
+func NewJournalRepository(store *Store) *JournalRepository {
+	return &JournalRepository{store: store}
+}
+
+var _ repository.Journal = (*JournalRepository)(nil)
+
+// CreateJournalRecord 流水号唯一，重复写入返回 ErrAlreadyExists
+func (r *JournalRepository) CreateJournalRecord(ctx context.Context, record *repository.JournalModel) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, j := range s.data.journals {
+		if j.SerialNo == record.SerialNo {
+			return repository.ErrAlreadyExists
+		}
+	}
+	record.ID = s.nextID()
+	if record.CreatedAt == "" {
+		record.CreatedAt = s.timestamp()
+	}
+	s.data.journals = append(s.data.journals, *record)
+	return nil
+}
+
+func (r *JournalRepository) GetJournalRecordsByRef(ctx context.Context, userID, refID int64, bizTypes []string) ([]*repository.JournalModel, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	var records []*repository.JournalModel
+	for _, j := range r.store.data.journals {
+		if j.UserID == userID && j.RefID != nil && *j.RefID == refID && slices.Contains(bizTypes, j.BizType) {
+			j := j
+			records = append(records, &j)
+		}
+	}
+	return records, nil
+}
@@ -0,0 +1,92 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"monera-digital/internal/repository"
+)
+
+type SchedulerRunRepository struct {
+	store *Store
+}
+
+func NewSchedulerRunRepository(store *Store) *SchedulerRunRepository {
+	return &SchedulerRunRepository{store: store}
+}
+
+var _ repository.SchedulerRun = (*SchedulerRunRepository)(nil)
+
+// TryLock 进程内的任务锁，语义与 advisory lock 相同
+func (r *SchedulerRunRepository) TryLock(ctx context.Context, jobName string) (func(), bool, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.locks[jobName] {
+		return nil, false, nil
+	}
+	s.locks[jobName] = true
+	unlock := func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		delete(s.locks, jobName)
+	}
+	return unlock, true, nil
+}
+
+func (r *SchedulerRunRepository) GetLastSuccessDate(ctx context.Context, jobName string) (string, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	last := ""
+	for _, run := range r.store.data.runs {
+		if run.JobName == jobName && run.Status == repository.SchedulerRunStatusSuccess && run.RunDate > last {
+			last = run.RunDate
+		}
+	}
+	return last, nil
+}
+
+func (r *SchedulerRunRepository) CreateRun(ctx context.Context, run *repository.SchedulerRunModel) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	run.ID = s.nextID()
+	run.StartedAt = s.timestamp()
+	s.data.runs = append(s.data.runs, *run)
+	return nil
+}
+
+func (r *SchedulerRunRepository) FinishRun(ctx context.Context, run *repository.SchedulerRunModel) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.data.runs {
+		if s.data.runs[i].ID == run.ID {
+			run.FinishedAt = s.timestamp()
+			s.data.runs[i].Status = run.Status
+			s.data.runs[i].ItemsProcessed = run.ItemsProcessed
+			s.data.runs[i].Detail = run.Detail
+			s.data.runs[i].ErrorMessage = run.ErrorMessage
+			s.data.runs[i].FinishedAt = run.FinishedAt
+			return nil
+		}
+	}
+	return repository.ErrNotFound
+}
+
+func (r *SchedulerRunRepository) ListRuns(ctx context.Context, jobName string, limit int) ([]*repository.SchedulerRunModel, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	var runs []*repository.SchedulerRunModel
+	for _, run := range r.store.data.runs {
+		if run.JobName == jobName {
+			run := run
+			runs = append(runs, &run)
+		}
+	}
+	sort.Slice(runs, func(i, j int) bool { return runs[i].ID > runs[j].ID })
+	if len(runs) > limit {
+		runs = runs[:limit]
+	}
+	return runs, nil
+}
@@ -0,0 +1,194 @@
+// Package memory 仓储接口的内存实现。
+// It lets the simulator and tests drive the full subscribe/accrue/settle/renew
+// flow through the real services without a database. Transactions are
+// serialized and rolled back by restoring a snapshot of the store.
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"monera-digital/internal/clock"
+	"monera-digital/internal/repository"
+)
+
+// Store 所有内存仓储共享的数据
+type Store struct {
+	mu    sync.Mutex
+	txMu  sync.Mutex
+	clock clock.Clock
+	data  *state
+	locks map[string]bool
+}
+
+type state struct {
+	nextID    int64
+	products  map[int64]repository.WealthProductModel
+	orders    map[int64]repository.WealthOrderModel
+	interest  []repository.InterestRecordModel
+	rates     []repository.WealthRateModel
+	quotes    map[string]repository.WealthQuoteModel
+	accounts  map[int64]repository.AccountModel
+	journals  []repository.JournalModel
+	audits    []repository.WealthOrderAuditModel
+	transfers []repository.TransferRecordModel
+	runs      []repository.SchedulerRunModel
+}
+
+// NewStore 创建空的内存库，时间戳与报价过期判断取自 c
+func NewStore(c clock.Clock) *Store {
+	return &Store{
+		clock: c,
+		data: &state{
+			products: make(map[int64]repository.WealthProductModel),
+			orders:   make(map[int64]repository.WealthOrderModel),
+			quotes:   make(map[string]repository.WealthQuoteModel),
+			accounts: make(map[int64]repository.AccountModel),
+		},
+		locks: make(map[string]bool),
+	}
+}
+
+func (st *state) clone() *state {
+	c := &state{
+		nextID:    st.nextID,
+		products:  make(map[int64]repository.WealthProductModel, len(st.products)),
+		orders:    make(map[int64]repository.WealthOrderModel, len(st.orders)),
+		interest:  append([]repository.InterestRecordModel(nil), st.interest...),
+		rates:     append([]repository.WealthRateModel(nil), st.rates...),
+		quotes:    make(map[string]repository.WealthQuoteModel, len(st.quotes)),
+		accounts:  make(map[int64]repository.AccountModel, len(st.accounts)),
+		journals:  append([]repository.JournalModel(nil), st.journals...),
+		audits:    append([]repository.WealthOrderAuditModel(nil), st.audits...),
+		transfers: append([]repository.TransferRecordModel(nil), st.transfers...),
+		runs:      append([]repository.SchedulerRunModel(nil), st.runs...),
+	}
+	for k, v := range st.products {
+		c.products[k] = v
+	}
+	for k, v := range st.orders {
+		c.orders[k] = v
+	}
+	for k, v := range st.quotes {
+		c.quotes[k] = v
+	}
+	for k, v := range st.accounts {
+		c.accounts[k] = v
+	}
+	return c
+}
+
+// nextID 分配自增主键，调用方需持有 mu
+func (s *Store) nextID() int64 {
+	s.data.nextID++
+	return s.data.nextID
+}
+
+// timestamp 当前时钟的 RFC3339 时间
+func (s *Store) timestamp() string {
+	return s.clock.Now().Format(time.RFC3339)
+}
+
+// Accounts 返回全部账户，按 ID 排列
+func (s *Store) Accounts() []repository.AccountModel {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	accounts := make([]repository.AccountModel, 0, len(s.data.accounts))
+	for _, a := range s.data.accounts {
+		accounts = append(accounts, a)
+	}
+	sort.Slice(accounts, func(i, j int) bool { return accounts[i].ID < accounts[j].ID })
+	return accounts
+}
+
+// Orders 返回全部理财订单，按 ID 排列
+func (s *Store) Orders() []repository.WealthOrderModel {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	orders := make([]repository.WealthOrderModel, 0, len(s.data.orders))
+	for _, o := range s.data.orders {
+		orders = append(orders, s.withProduct(o))
+	}
+	sort.Slice(orders, func(i, j int) bool { return orders[i].ID < orders[j].ID })
+	return orders
+}
+
+// Journals 返回全部资金流水，按写入顺序排列
+func (s *Store) Journals() []repository.JournalModel {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]repository.JournalModel(nil), s.data.journals...)
+}
+
+// OrderAudits 返回全部订单条款变更记录，按写入顺序排列
+func (s *Store) OrderAudits() []repository.WealthOrderAuditModel {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]repository.WealthOrderAuditModel(nil), s.data.audits...)
+}
+
+// TransferRecords 返回全部账户间划转记录，按写入顺序排列
+func (s *Store) TransferRecords() []repository.TransferRecordModel {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]repository.TransferRecordModel(nil), s.data.transfers...)
+}
+
+// InterestRecords 返回全部计息记录，按写入顺序排列
+func (s *Store) InterestRecords() []repository.InterestRecordModel {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]repository.InterestRecordModel(nil), s.data.interest...)
+}
+
+// UnitOfWork 串行执行事务，出错或 panic 时恢复到事务开始前的快照
+type UnitOfWork struct {
+	store *Store
+}
+
+func NewUnitOfWork(store *Store) *UnitOfWork {
+	return &UnitOfWork{store: store}
+}
+
+func (u *UnitOfWork) Do(ctx context.Context, fn func(tx *repository.TxRepository) error) error {
+	s := u.store
+	s.txMu.Lock()
+	defer s.txMu.Unlock()
+
+	s.mu.Lock()
+	snapshot := s.data.clone()
+	s.mu.Unlock()
+
+	rollback := func() {
+		s.mu.Lock()
+		s.data = snapshot
+		s.mu.Unlock()
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(&repository.TxRepository{
+		Wealth:  NewWealthRepository(s),
+		Account: NewAccountRepository(s),
+		Journal: NewJournalRepository(s),
+	}); err != nil {
+		rollback()
+		return err
+	}
+	return nil
+}
+
+var _ repository.UnitOfWork = (*UnitOfWork)(nil)
+
+// errAccountNotFound 与 postgres 实现的提示保持一致
+func errAccountNotFound(userID int64, currency string) error {
+	return fmt.Errorf("account not found for user %d and currency %s", userID, currency)
+}
@@ -0,0 +1,116 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"monera-digital/internal/clock"
+	"monera-digital/internal/money"
+	"monera-digital/internal/repository"
+)
+
+func newTestStore() (*Store, *clock.Fake) {
+	fake := clock.NewFake(time.Date(2026, 1, 1, 1, 0, 0, 0, time.UTC))
+	return NewStore(fake), fake
+}
+
+func TestUnitOfWork_RollsBackOnError(t *testing.T) {
+	store, _ := newTestStore()
+	ctx := context.Background()
+	accounts := NewAccountRepository(store)
+	account := &repository.AccountModel{UserID: 1, Currency: "USDT", Balance: money.MustParse("100"), FrozenBalance: money.Zero}
+	assert.NoError(t, accounts.CreateAccount(ctx, account))
+
+	err := NewUnitOfWork(store).Do(ctx, func(tx *repository.TxRepository) error {
+		if err := tx.Account.FreezeBalance(ctx, account.ID, money.MustParse("40")); err != nil {
+			return err
+		}
+		return errors.New("boom")
+	})
+
+	assert.EqualError(t, err, "boom")
+	got, _ := accounts.GetAccountByUserIDAndCurrency(ctx, 1, "USDT")
+	assert.True(t, got.FrozenBalance.IsZero())
+}
+
+func TestWealthRepository_AccrueInterest_OncePerDate(t *testing.T) {
+	store, _ := newTestStore()
+	ctx := context.Background()
+	repo := NewWealthRepository(store)
+	product := &repository.WealthProductModel{Currency: "USDT", TotalQuota: money.MustParse("1000")}
+	assert.NoError(t, repo.CreateProduct(ctx, product))
+	order := &repository.WealthOrderModel{ProductID: product.ID, Amount: money.MustParse("100"), StartDate: "2026-01-02", EndDate: "2026-01-09"}
+	assert.NoError(t, repo.CreateOrder(ctx, order))
+
+	assert.NoError(t, repo.AccrueInterest(ctx, order.ID, money.MustParse("0.01"), "2026-01-03"))
+	assert.ErrorIs(t, repo.AccrueInterest(ctx, order.ID, money.MustParse("0.01"), "2026-01-03"), repository.ErrAlreadyExists)
+
+	got, _ := repo.GetOrderByID(ctx, order.ID)
+	assert.Equal(t, "0.01", got.InterestAccrued.String())
+	assert.Equal(t, "USDT", got.Currency)
+
+	expired, _ := repo.GetExpiredOrders(ctx, "2026-01-08")
+	assert.Empty(t, expired)
+	expired, _ = repo.GetExpiredOrders(ctx, "2026-01-09")
+	assert.Len(t, expired, 1)
+}
+
+func TestWealthRepository_UseQuote_FollowsClock(t *testing.T) {
+	store, fake := newTestStore()
+	ctx := context.Background()
+	repo := NewWealthRepository(store)
+	assert.NoError(t, repo.CreateQuote(ctx, &repository.WealthQuoteModel{ID: "q-1", UserID: 1, ExpiresAt: fake.Now().Add(time.Minute)}))
+	assert.NoError(t, repo.CreateQuote(ctx, &repository.WealthQuoteModel{ID: "q-2", UserID: 1, ExpiresAt: fake.Now().Add(time.Minute)}))
+
+	assert.ErrorIs(t, repo.UseQuote(ctx, "q-1", 2), repository.ErrNotFound)
+	assert.NoError(t, repo.UseQuote(ctx, "q-1", 1))
+	assert.ErrorIs(t, repo.UseQuote(ctx, "q-1", 1), repository.ErrNotFound)
+
+	fake.Advance(time.Minute)
+	assert.ErrorIs(t, repo.UseQuote(ctx, "q-2", 1), repository.ErrNotFound)
+}
+
+func TestAccountRepository_BalanceGuards(t *testing.T) {
+	store, _ := newTestStore()
+	ctx := context.Background()
+	accounts := NewAccountRepository(store)
+	account := &repository.AccountModel{UserID: 1, Currency: "USDT", Balance: money.MustParse("100"), FrozenBalance: money.Zero}
+	assert.NoError(t, accounts.CreateAccount(ctx, account))
+	assert.NoError(t, accounts.FreezeBalance(ctx, account.ID, money.MustParse("60")))
+
+	assert.ErrorIs(t, accounts.FreezeBalance(ctx, account.ID, money.MustParse("41")), repository.ErrInsufficientBalance)
+	assert.ErrorIs(t, accounts.UnfreezeBalance(ctx, account.ID, money.MustParse("61")), repository.ErrInsufficientBalance)
+	assert.ErrorIs(t, accounts.DeductBalance(ctx, account.ID, money.MustParse("41")), repository.ErrInsufficientBalance)
+	assert.ErrorIs(t, accounts.AddBalance(ctx, account.ID, money.MustParse("-1")), repository.ErrInvalidInput)
+
+	got, _ := accounts.GetAccountByUserIDAndCurrency(ctx, 1, "USDT")
+	assert.Equal(t, "100", got.Balance.String())
+	assert.Equal(t, "60", got.FrozenBalance.String())
+	assert.Equal(t, account.Version+1, got.Version)
+}
+
+func TestJournalRepository_GetJournalRecordsByRef_FiltersBizType(t *testing.T) {
+	store, _ := newTestStore()
+	ctx := context.Background()
+	journals := NewJournalRepository(store)
+
+	// 理财订单与借贷持仓编号相同，流水的 ref_id 相撞
+	refID := int64(7)
+	for _, j := range []repository.JournalModel{
+		{SerialNo: "SUBSCRIBE-7", UserID: 1, BizType: "SUBSCRIBE_FREEZE", RefID: &refID},
+		{SerialNo: "LENDING-YIELD-7", UserID: 1, BizType: "LENDING_YIELD", RefID: &refID},
+	} {
+		j := j
+		assert.NoError(t, journals.CreateJournalRecord(ctx, &j))
+	}
+
+	records, err := journals.GetJournalRecordsByRef(ctx, 1, refID, []string{"SUBSCRIBE_FREEZE", "INTEREST_PAYOUT"})
+
+	assert.NoError(t, err)
+	if assert.Len(t, records, 1) {
+		assert.Equal(t, "SUBSCRIBE-7", records[0].SerialNo)
+	}
+}
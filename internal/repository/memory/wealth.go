@@ -0,0 +1,469 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"monera-digital/internal/money"
+	"monera-digital/internal/repository"
+)
+
+type WealthRepository struct {
+	store *Store
+}
+
+func NewWealthRepository(store *Store) *WealthRepository {
+	return &WealthRepository{store: store}
+}
+
+var _ repository.Wealth = (*WealthRepository)(nil)
+
+// withProduct 补齐 postgres 实现中由 JOIN wealth_product 得到的字段，调用方需持有 mu
+func (s *Store) withProduct(o repository.WealthOrderModel) repository.WealthOrderModel {
+	if p, ok := s.data.products[o.ProductID]; ok {
+		o.Currency = p.Currency
+		o.ProductType = p.ProductType
+		o.SettlementTimezone = p.SettlementTimezone
+		o.CutoffTime = p.CutoffTime
+		o.DayCount = p.DayCount
+		if o.ProductTitle == "" {
+			o.ProductTitle = p.Title
+		}
+	}
+	if o.EndDate != "" {
+		start, _ := time.Parse("2006-01-02", o.StartDate)
+		end, _ := time.Parse("2006-01-02", o.EndDate)
+		o.Duration = int64(end.Sub(start).Hours() / 24)
+	}
+	return o
+}
+
+// selectOrders 按创建时间倒序返回满足 match 的订单，调用方需持有 mu
+func (s *Store) selectOrders(match func(o repository.WealthOrderModel) bool) []*repository.WealthOrderModel {
+	var orders []*repository.WealthOrderModel
+	for _, o := range s.data.orders {
+		if match(o) {
+			o = s.withProduct(o)
+			orders = append(orders, &o)
+		}
+	}
+	sort.Slice(orders, func(i, j int) bool { return orders[i].ID > orders[j].ID })
+	return orders
+}
+
+func (r *WealthRepository) GetActiveProducts(ctx context.Context) ([]*repository.WealthProductModel, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.clock.Now()
+	return r.selectProducts(func(p repository.WealthProductModel) bool {
+		return p.Status == repository.WealthProductStatusActive && (p.SubscribeEndAt == nil || p.SubscribeEndAt.After(now))
+	}), nil
+}
+
+func (r *WealthRepository) ListProducts(ctx context.Context) ([]*repository.WealthProductModel, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	return r.selectProducts(func(repository.WealthProductModel) bool { return true }), nil
+}
+
+func (r *WealthRepository) selectProducts(match func(p repository.WealthProductModel) bool) []*repository.WealthProductModel {
+	var products []*repository.WealthProductModel
+	for _, p := range r.store.data.products {
+		if match(p) {
+			p := p
+			products = append(products, &p)
+		}
+	}
+	sort.Slice(products, func(i, j int) bool { return products[i].ID > products[j].ID })
+	return products
+}
+
+func (r *WealthRepository) GetProductByID(ctx context.Context, id int64) (*repository.WealthProductModel, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	p, ok := r.store.data.products[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &p, nil
+}
+
+func (r *WealthRepository) CreateProduct(ctx context.Context, product *repository.WealthProductModel) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	product.ID = s.nextID()
+	product.SoldQuota = money.Zero
+	product.CreatedAt = s.timestamp()
+	product.UpdatedAt = product.CreatedAt
+	s.data.products[product.ID] = *product
+	return nil
+}
+
+func (r *WealthRepository) UpdateProduct(ctx context.Context, product *repository.WealthProductModel) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.data.products[product.ID]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	if p.SoldQuota.GreaterThan(product.TotalQuota) {
+		return repository.ErrQuotaExceeded
+	}
+	p.Title = product.Title
+	p.Duration = product.Duration
+	p.MinAmount = product.MinAmount
+	p.MaxAmount = product.MaxAmount
+	p.TotalQuota = product.TotalQuota
+	p.AutoRenewAllowed = product.AutoRenewAllowed
+	p.EarlyRedeemRule = product.EarlyRedeemRule
+	p.EarlyRedeemValue = product.EarlyRedeemValue
+	p.SubscribeStartAt = product.SubscribeStartAt
+	p.SubscribeEndAt = product.SubscribeEndAt
+	p.UpdatedAt = s.timestamp()
+	s.data.products[p.ID] = p
+	return nil
+}
+
+func (r *WealthRepository) UpdateProductStatus(ctx context.Context, id int64, status int) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.data.products[id]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	p.Status = status
+	p.UpdatedAt = s.timestamp()
+	s.data.products[id] = p
+	return nil
+}
+
+func (r *WealthRepository) CreateOrder(ctx context.Context, order *repository.WealthOrderModel) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	order.ID = s.nextID()
+	stored := *order
+	stored.PrincipalRedeemed = money.Zero
+	stored.InterestPaid = money.Zero
+	stored.InterestAccrued = money.Zero
+	stored.Status = repository.WealthOrderStatusActive
+	stored.CreatedAt = s.timestamp()
+	stored.UpdatedAt = stored.CreatedAt
+	s.data.orders[stored.ID] = stored
+	return nil
+}
+
+func (r *WealthRepository) GetOrdersByUserID(ctx context.Context, userID int64) ([]*repository.WealthOrderModel, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	return r.store.selectOrders(func(o repository.WealthOrderModel) bool { return o.UserID == userID }), nil
+}
+
+func (r *WealthRepository) GetOrderByID(ctx context.Context, id int64) (*repository.WealthOrderModel, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	o, ok := s.data.orders[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	o = s.withProduct(o)
+	return &o, nil
+}
+
+// GetOrderForUpdate 与 GetOrderByID 相同；内存事务本身串行执行
+func (r *WealthRepository) GetOrderForUpdate(ctx context.Context, id int64) (*repository.WealthOrderModel, error) {
+	return r.GetOrderByID(ctx, id)
+}
+
+func (r *WealthRepository) UpdateOrder(ctx context.Context, order *repository.WealthOrderModel) error {
+	return r.store.updateOrder(order.ID, false, func(o *repository.WealthOrderModel) {
+		o.InterestPaid = order.InterestPaid
+		o.InterestAccrued = order.InterestAccrued
+		o.Status = order.Status
+		o.RedemptionAmount = order.RedemptionAmount
+		o.RedemptionType = order.RedemptionType
+		o.RedeemedAt = order.RedeemedAt
+		o.PrincipalRedeemed = order.PrincipalRedeemed
+		o.InterestExpected = order.InterestExpected
+	})
+}
+
+// updateOrder 修改一笔订单；activeOnly 时只修改持有中的订单，否则返回 ErrNotFound
+func (s *Store) updateOrder(id int64, activeOnly bool, fn func(o *repository.WealthOrderModel)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	o, ok := s.data.orders[id]
+	if !ok || (activeOnly && o.Status != repository.WealthOrderStatusActive) {
+		return repository.ErrNotFound
+	}
+	fn(&o)
+	o.UpdatedAt = s.timestamp()
+	s.data.orders[id] = o
+	return nil
+}
+
+func (r *WealthRepository) ReserveProductQuota(ctx context.Context, id int64, amount money.Decimal) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.data.products[id]
+	if !ok || p.SoldQuota.Add(amount).GreaterThan(p.TotalQuota) {
+		return repository.ErrQuotaExceeded
+	}
+	p.SoldQuota = p.SoldQuota.Add(amount)
+	s.data.products[id] = p
+	return nil
+}
+
+func (r *WealthRepository) ReleaseProductQuota(ctx context.Context, id int64, amount money.Decimal) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.data.products[id]
+	if !ok {
+		return nil
+	}
+	p.SoldQuota = money.Max(p.SoldQuota.Sub(amount), money.Zero)
+	s.data.products[id] = p
+	return nil
+}
+
+func (r *WealthRepository) AddPrincipal(ctx context.Context, orderID int64, amount money.Decimal) error {
+	return r.store.updateOrder(orderID, true, func(o *repository.WealthOrderModel) {
+		o.Amount = o.Amount.Add(amount)
+	})
+}
+
+func (r *WealthRepository) UpdateRenewalOptions(ctx context.Context, orderID int64, autoRenew, compound bool) error {
+	return r.store.updateOrder(orderID, true, func(o *repository.WealthOrderModel) {
+		o.AutoRenew = autoRenew
+		o.AutoRenewCompound = compound
+	})
+}
+
+func (r *WealthRepository) CreateOrderAudit(ctx context.Context, audit *repository.WealthOrderAuditModel) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	audit.ID = s.nextID()
+	s.data.audits = append(s.data.audits, *audit)
+	return nil
+}
+
+func (r *WealthRepository) CancelOrder(ctx context.Context, orderID int64) error {
+	now := r.store.timestamp()
+	return r.store.updateOrder(orderID, true, func(o *repository.WealthOrderModel) {
+		o.Status = repository.WealthOrderStatusCancelled
+		o.RedeemedAt = now
+	})
+}
+
+func (r *WealthRepository) GetActiveOrders(ctx context.Context) ([]*repository.WealthOrderModel, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	return r.store.selectOrders(func(o repository.WealthOrderModel) bool {
+		return o.Status == repository.WealthOrderStatusActive
+	}), nil
+}
+
+func (r *WealthRepository) GetExpiredOrders(ctx context.Context, asOf string) ([]*repository.WealthOrderModel, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	return r.store.selectOrders(func(o repository.WealthOrderModel) bool {
+		return o.Status == repository.WealthOrderStatusActive && o.EndDate != "" && o.EndDate <= asOf
+	}), nil
+}
+
+func (r *WealthRepository) AccrueInterest(ctx context.Context, orderID int64, amount money.Decimal, date string) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	o, ok := s.data.orders[orderID]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	for _, rec := range s.data.interest {
+		if rec.OrderID == orderID && rec.Date == date && rec.Type == repository.InterestRecordTypeAccrual {
+			return repository.ErrAlreadyExists
+		}
+	}
+
+	now := s.timestamp()
+	s.data.interest = append(s.data.interest, repository.InterestRecordModel{
+		ID:        s.nextID(),
+		OrderID:   orderID,
+		Amount:    amount,
+		Type:      repository.InterestRecordTypeAccrual,
+		Date:      date,
+		CreatedAt: now,
+	})
+	o.InterestAccrued = o.InterestAccrued.Add(amount)
+	o.LastInterestDate = date
+	o.UpdatedAt = now
+	s.data.orders[orderID] = o
+	return nil
+}
+
+func (r *WealthRepository) GetInterestRecords(ctx context.Context, orderID int64) ([]*repository.InterestRecordModel, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	var records []*repository.InterestRecordModel
+	for _, rec := range r.store.data.interest {
+		if rec.OrderID == orderID {
+			rec := rec
+			records = append(records, &rec)
+		}
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		if records[i].Date != records[j].Date {
+			return records[i].Date < records[j].Date
+		}
+		return records[i].ID < records[j].ID
+	})
+	return records, nil
+}
+
+func (r *WealthRepository) SettleOrder(ctx context.Context, orderID int64, interestPaid money.Decimal) error {
+	now := r.store.timestamp()
+	return r.store.updateOrder(orderID, true, func(o *repository.WealthOrderModel) {
+		o.InterestPaid = o.InterestPaid.Add(interestPaid)
+		o.InterestAccrued = money.Zero
+		o.Status = repository.WealthOrderStatusSettled
+		o.RedeemedAt = now
+	})
+}
+
+func (r *WealthRepository) RenewOrder(ctx context.Context, order *repository.WealthOrderModel, product *repository.WealthProductModel, principal, interestExpected money.Decimal, startDate, endDate string, renewedAt time.Time) (*repository.WealthOrderModel, error) {
+	newOrder := &repository.WealthOrderModel{
+		UserID:             order.UserID,
+		ProductID:          product.ID,
+		ProductTitle:       product.Title,
+		Currency:           product.Currency,
+		Amount:             principal,
+		AutoRenew:          order.AutoRenew,
+		AutoRenewCompound:  order.AutoRenewCompound,
+		EarlyRedeemRule:    product.EarlyRedeemRule,
+		EarlyRedeemValue:   product.EarlyRedeemValue,
+		StartDate:          startDate,
+		EndDate:            endDate,
+		InterestExpected:   interestExpected,
+		RenewedFromOrderID: &order.ID,
+	}
+	if err := r.CreateOrder(ctx, newOrder); err != nil {
+		return nil, err
+	}
+
+	newID := newOrder.ID
+	if err := r.store.updateOrder(order.ID, false, func(o *repository.WealthOrderModel) {
+		o.RenewedToOrderID = &newID
+	}); err != nil {
+		return nil, err
+	}
+	return r.GetOrderByID(ctx, newID)
+}
+
+func (r *WealthRepository) GetProductRates(ctx context.Context, productID int64, date string) ([]*repository.WealthRateModel, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	effective := ""
+	for _, rate := range r.store.data.rates {
+		if rate.ProductID == productID && rate.EffectiveDate <= date && rate.EffectiveDate > effective {
+			effective = rate.EffectiveDate
+		}
+	}
+	rates := r.selectRates(func(rate repository.WealthRateModel) bool {
+		return rate.ProductID == productID && rate.EffectiveDate == effective
+	})
+	return rates, nil
+}
+
+func (r *WealthRepository) ListProductRates(ctx context.Context, productID int64) ([]*repository.WealthRateModel, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	return r.selectRates(func(rate repository.WealthRateModel) bool { return rate.ProductID == productID }), nil
+}
+
+// selectRates 按生效日期倒序、档位下限升序返回利率，调用方需持有 mu
+func (r *WealthRepository) selectRates(match func(rate repository.WealthRateModel) bool) []*repository.WealthRateModel {
+	rates := []*repository.WealthRateModel{}
+	for _, rate := range r.store.data.rates {
+		if match(rate) {
+			rate := rate
+			rates = append(rates, &rate)
+		}
+	}
+	sort.SliceStable(rates, func(i, j int) bool {
+		if rates[i].EffectiveDate != rates[j].EffectiveDate {
+			return rates[i].EffectiveDate > rates[j].EffectiveDate
+		}
+		return rates[i].TierFloor.LessThan(rates[j].TierFloor)
+	})
+	return rates
+}
+
+func (r *WealthRepository) SaveProductRates(ctx context.Context, productID int64, effectiveDate string, rates []*repository.WealthRateModel) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	kept := s.data.rates[:0:0]
+	for _, rate := range s.data.rates {
+		if rate.ProductID != productID || rate.EffectiveDate != effectiveDate {
+			kept = append(kept, rate)
+		}
+	}
+	for _, rate := range rates {
+		kept = append(kept, repository.WealthRateModel{
+			ID:            s.nextID(),
+			ProductID:     productID,
+			EffectiveDate: effectiveDate,
+			TierFloor:     rate.TierFloor,
+			APY:           rate.APY,
+			CreatedAt:     s.timestamp(),
+		})
+	}
+	s.data.rates = kept
+	return nil
+}
+
+func (r *WealthRepository) CreateQuote(ctx context.Context, quote *repository.WealthQuoteModel) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.data.quotes[quote.ID]; ok {
+		return repository.ErrAlreadyExists
+	}
+	stored := *quote
+	stored.CreatedAt = s.timestamp()
+	s.data.quotes[quote.ID] = stored
+	return nil
+}
+
+func (r *WealthRepository) GetQuote(ctx context.Context, id string) (*repository.WealthQuoteModel, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	q, ok := r.store.data.quotes[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &q, nil
+}
+
+func (r *WealthRepository) UseQuote(ctx context.Context, id string, userID int64) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.clock.Now()
+	q, ok := s.data.quotes[id]
+	if !ok || q.UserID != userID || q.UsedAt != nil || !q.ExpiresAt.After(now) {
+		return repository.ErrNotFound
+	}
+	q.UsedAt = &now
+	s.data.quotes[id] = q
+	return nil
+}
@@ -0,0 +1,202 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"monera-digital/internal/models"
+	"monera-digital/internal/money"
+	"monera-digital/internal/repository"
+)
+
+type LendingRepository struct {
+	db querier
+}
+
+func NewLendingRepository(db *sql.DB) *LendingRepository {
+	return &LendingRepository{db: db}
+}
+
+var _ repository.Lending = (*LendingRepository)(nil)
+
+const positionColumns = `
+	id, user_id, asset, amount, duration_days, apy, status, accrued_yield, yield_paid,
+	COALESCE(last_accrual_date::text, ''), start_date, end_date, closed_at`
+
+func scanPosition(row rowScanner) (*repository.LendingPositionModel, error) {
+	var p repository.LendingPositionModel
+	var closedAt sql.NullTime
+	err := row.Scan(
+		&p.ID, &p.UserID, &p.Asset, &p.Amount, &p.DurationDays, &p.APY, &p.Status, &p.AccruedYield, &p.YieldPaid,
+		&p.LastAccrualDate, &p.StartDate, &p.EndDate, &closedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if closedAt.Valid {
+		p.ClosedAt = &closedAt.Time
+	}
+	return &p, nil
+}
+
+func (r *LendingRepository) CreatePosition(ctx context.Context, position *repository.LendingPositionModel) error {
+	// start_date/end_date 为不带时区的 TIMESTAMP，统一以 UTC 写入
+	query := `
+		INSERT INTO lending_positions (user_id, asset, amount, duration_days, apy, status,
+			accrued_yield, yield_paid, start_date, end_date, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, 0, 0, $7, $8, NOW(), NOW())
+		RETURNING id
+	`
+	return r.db.QueryRowContext(ctx, query,
+		position.UserID, position.Asset, position.Amount, position.DurationDays, position.APY, position.Status,
+		position.StartDate.UTC(), position.EndDate.UTC(),
+	).Scan(&position.ID)
+}
+
+func (r *LendingRepository) GetPositionsByUserID(ctx context.Context, userID int64) ([]*repository.LendingPositionModel, error) {
+	return r.queryPositions(ctx, `SELECT `+positionColumns+` FROM lending_positions WHERE user_id = $1 ORDER BY start_date`, userID)
+}
+
+func (r *LendingRepository) GetPositionByID(ctx context.Context, id int64) (*repository.LendingPositionModel, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+positionColumns+` FROM lending_positions WHERE id = $1`, id)
+	p, err := scanPosition(row)
+	if err == sql.ErrNoRows {
+		return nil, repository.ErrNotFound
+	}
+	return p, err
+}
+
+func (r *LendingRepository) GetActivePositions(ctx context.Context) ([]*repository.LendingPositionModel, error) {
+	return r.queryPositions(ctx, `SELECT `+positionColumns+` FROM lending_positions WHERE status = $1 ORDER BY id`, models.LendingStatusActive)
+}
+
+func (r *LendingRepository) queryPositions(ctx context.Context, query string, args ...interface{}) ([]*repository.LendingPositionModel, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var positions []*repository.LendingPositionModel
+	for rows.Next() {
+		p, err := scanPosition(rows)
+		if err != nil {
+			return nil, err
+		}
+		positions = append(positions, p)
+	}
+	return positions, rows.Err()
+}
+
+func (r *LendingRepository) AccrueYield(ctx context.Context, positionID int64, amount money.Decimal, date string) error {
+	// 收益记录与仓位累计在同一条语句内完成；同一天已入账或仓位已结束时不写入任何记录
+	query := `
+		WITH ins AS (
+			INSERT INTO lending_yield_record (position_id, amount, date, created_at)
+			SELECT id, $2, $3, NOW() FROM lending_positions WHERE id = $1 AND status = $4
+			ON CONFLICT (position_id, date) DO NOTHING
+			RETURNING position_id
+		)
+		UPDATE lending_positions SET
+			accrued_yield = accrued_yield + CAST($2 AS NUMERIC),
+			last_accrual_date = $3,
+			updated_at = NOW()
+		WHERE id IN (SELECT position_id FROM ins)
+	`
+	result, err := r.db.ExecContext(ctx, query, positionID, amount, date, models.LendingStatusActive)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return repository.ErrAlreadyExists
+	}
+	return nil
+}
+
+func (r *LendingRepository) ClosePosition(ctx context.Context, positionID int64, status models.LendingStatus, yieldPaid money.Decimal) error {
+	query := `
+		UPDATE lending_positions SET
+			status = $1,
+			yield_paid = $2,
+			closed_at = NOW(),
+			updated_at = NOW()
+		WHERE id = $3 AND status = $4
+	`
+	result, err := r.db.ExecContext(ctx, query, status, yieldPaid, positionID, models.LendingStatusActive)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+func (r *LendingRepository) GetRates(ctx context.Context, date string) ([]*repository.LendingRateModel, error) {
+	query := `
+		SELECT r.id, r.asset, r.min_duration_days, r.apy, r.effective_date::text, r.created_at
+		FROM lending_rate r
+		WHERE r.effective_date = (
+			SELECT MAX(effective_date) FROM lending_rate
+			WHERE asset = r.asset AND effective_date <= $1
+		)
+		ORDER BY r.asset ASC, r.min_duration_days ASC
+	`
+	return r.queryRates(ctx, query, date)
+}
+
+func (r *LendingRepository) ListRates(ctx context.Context, asset string) ([]*repository.LendingRateModel, error) {
+	query := `
+		SELECT id, asset, min_duration_days, apy, effective_date::text, created_at
+		FROM lending_rate
+		WHERE asset = $1
+		ORDER BY effective_date DESC, min_duration_days ASC
+	`
+	return r.queryRates(ctx, query, asset)
+}
+
+func (r *LendingRepository) queryRates(ctx context.Context, query string, args ...interface{}) ([]*repository.LendingRateModel, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rates := []*repository.LendingRateModel{}
+	for rows.Next() {
+		var rate repository.LendingRateModel
+		if err := rows.Scan(&rate.ID, &rate.Asset, &rate.MinDurationDays, &rate.APY, &rate.EffectiveDate, &rate.CreatedAt); err != nil {
+			return nil, err
+		}
+		rates = append(rates, &rate)
+	}
+	return rates, rows.Err()
+}
+
+func (r *LendingRepository) SaveRates(ctx context.Context, asset string, effectiveDate string, rates []*repository.LendingRateModel) error {
+	_, err := r.db.ExecContext(ctx, `
+		DELETE FROM lending_rate WHERE asset = $1 AND effective_date = $2
+	`, asset, effectiveDate)
+	if err != nil {
+		return err
+	}
+
+	for _, rate := range rates {
+		_, err := r.db.ExecContext(ctx, `
+			INSERT INTO lending_rate (asset, min_duration_days, apy, effective_date, created_at)
+			VALUES ($1, $2, $3, $4, NOW())
+		`, asset, rate.MinDurationDays, rate.APY, effectiveDate)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
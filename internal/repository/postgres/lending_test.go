@@ -0,0 +1,124 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"monera-digital/internal/models"
+	"monera-digital/internal/money"
+	"monera-digital/internal/repository"
+)
+
+func TestLendingRepository_AccrueYield(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewLendingRepository(db)
+
+	mock.ExpectExec("INSERT INTO lending_yield_record").
+		WithArgs(int64(1), money.MustParse("0.116438"), "2026-03-11", models.LendingStatusActive).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO lending_yield_record").
+		WithArgs(int64(1), money.MustParse("0.116438"), "2026-03-11", models.LendingStatusActive).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	assert.NoError(t, repo.AccrueYield(context.Background(), 1, money.MustParse("0.116438"), "2026-03-11"))
+	assert.ErrorIs(t, repo.AccrueYield(context.Background(), 1, money.MustParse("0.116438"), "2026-03-11"), repository.ErrAlreadyExists)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestLendingRepository_ClosePosition_NotActive(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewLendingRepository(db)
+
+	mock.ExpectExec("UPDATE lending_positions SET").
+		WithArgs(models.LendingStatusCompleted, money.MustParse("1.2"), int64(1), models.LendingStatusActive).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err = repo.ClosePosition(context.Background(), 1, models.LendingStatusCompleted, money.MustParse("1.2"))
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestLendingRepository_GetPositionByID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewLendingRepository(db)
+
+	start := time.Date(2026, 3, 10, 2, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("SELECT (.+) FROM lending_positions WHERE id = \\$1").
+		WithArgs(int64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "user_id", "asset", "amount", "duration_days", "apy", "status", "accrued_yield", "yield_paid",
+			"last_accrual_date", "start_date", "end_date", "closed_at",
+		}).AddRow(1, 2, "USDT", "500", 30, "8.5", "ACTIVE", "0.116438", "0", "2026-03-11", start, start.AddDate(0, 0, 30), nil))
+	mock.ExpectQuery("SELECT (.+) FROM lending_positions WHERE id = \\$1").
+		WithArgs(int64(2)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	position, err := repo.GetPositionByID(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), position.UserID)
+	assert.Equal(t, models.LendingStatusActive, position.Status)
+	assert.Equal(t, "0.116438", position.AccruedYield.String())
+	assert.Equal(t, "2026-03-11", position.LastAccrualDate)
+	assert.Nil(t, position.ClosedAt)
+
+	_, err = repo.GetPositionByID(context.Background(), 2)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestLendingRepository_SaveRates(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewLendingRepository(db)
+
+	mock.ExpectExec("DELETE FROM lending_rate").
+		WithArgs("BTC", "2026-04-01").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec("INSERT INTO lending_rate").
+		WithArgs("BTC", 0, money.MustParse("4"), "2026-04-01").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO lending_rate").
+		WithArgs("BTC", 90, money.MustParse("4.5"), "2026-04-01").
+		WillReturnResult(sqlmock.NewResult(2, 1))
+
+	err = repo.SaveRates(context.Background(), "BTC", "2026-04-01", []*repository.LendingRateModel{
+		{MinDurationDays: 0, APY: money.MustParse("4")},
+		{MinDurationDays: 90, APY: money.MustParse("4.5")},
+	})
+	assert.NoError(t, err)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
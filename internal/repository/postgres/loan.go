@@ -0,0 +1,138 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"monera-digital/internal/money"
+	"monera-digital/internal/repository"
+)
+
+type LoanRepository struct {
+	db querier
+}
+
+func NewLoanRepository(db *sql.DB) *LoanRepository {
+	return &LoanRepository{db: db}
+}
+
+var _ repository.Loan = (*LoanRepository)(nil)
+
+const loanColumns = `
+	id, user_id, collateral_asset, collateral_amount, borrow_asset, principal,
+	margin_call_ltv, liquidation_ltv, liquidation_penalty, status,
+	liquidated_collateral, liquidation_price, created_at, closed_at`
+
+func scanLoan(row rowScanner) (*repository.LoanModel, error) {
+	var l repository.LoanModel
+	var closedAt sql.NullTime
+	err := row.Scan(
+		&l.ID, &l.UserID, &l.CollateralAsset, &l.CollateralAmount, &l.BorrowAsset, &l.Principal,
+		&l.MarginCallLTV, &l.LiquidationLTV, &l.LiquidationPenalty, &l.Status,
+		&l.LiquidatedCollateral, &l.LiquidationPrice, &l.CreatedAt, &closedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if closedAt.Valid {
+		l.ClosedAt = &closedAt.Time
+	}
+	return &l, nil
+}
+
+func (r *LoanRepository) CreateLoan(ctx context.Context, loan *repository.LoanModel) error {
+	query := `
+		INSERT INTO loan (user_id, collateral_asset, collateral_amount, borrow_asset, principal,
+			margin_call_ltv, liquidation_ltv, liquidation_penalty, status,
+			liquidated_collateral, liquidation_price, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, 0, 0, NOW(), NOW())
+		RETURNING id, created_at
+	`
+	return r.db.QueryRowContext(ctx, query,
+		loan.UserID, loan.CollateralAsset, loan.CollateralAmount, loan.BorrowAsset, loan.Principal,
+		loan.MarginCallLTV, loan.LiquidationLTV, loan.LiquidationPenalty, loan.Status,
+	).Scan(&loan.ID, &loan.CreatedAt)
+}
+
+func (r *LoanRepository) GetLoanByID(ctx context.Context, id int64) (*repository.LoanModel, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+loanColumns+` FROM loan WHERE id = $1`, id)
+	l, err := scanLoan(row)
+	if err == sql.ErrNoRows {
+		return nil, repository.ErrNotFound
+	}
+	return l, err
+}
+
+func (r *LoanRepository) GetLoansByUserID(ctx context.Context, userID int64) ([]*repository.LoanModel, error) {
+	return r.queryLoans(ctx, `SELECT `+loanColumns+` FROM loan WHERE user_id = $1 ORDER BY id DESC`, userID)
+}
+
+func (r *LoanRepository) GetOpenLoans(ctx context.Context) ([]*repository.LoanModel, error) {
+	return r.queryLoans(ctx, `SELECT `+loanColumns+` FROM loan WHERE status IN ($1, $2) ORDER BY id`,
+		repository.LoanStatusActive, repository.LoanStatusMarginCall)
+}
+
+func (r *LoanRepository) queryLoans(ctx context.Context, query string, args ...interface{}) ([]*repository.LoanModel, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var loans []*repository.LoanModel
+	for rows.Next() {
+		l, err := scanLoan(rows)
+		if err != nil {
+			return nil, err
+		}
+		loans = append(loans, l)
+	}
+	return loans, rows.Err()
+}
+
+func (r *LoanRepository) SetLoanStatus(ctx context.Context, id int64, from, to repository.LoanStatus) error {
+	query := `UPDATE loan SET status = $1, updated_at = NOW() WHERE id = $2 AND status = $3`
+	return expectOneRow(r.db.ExecContext(ctx, query, to, id, from))
+}
+
+func (r *LoanRepository) AddCollateral(ctx context.Context, id int64, amount money.Decimal) error {
+	query := `
+		UPDATE loan SET
+			collateral_amount = collateral_amount + CAST($1 AS NUMERIC),
+			updated_at = NOW()
+		WHERE id = $2 AND status IN ($3, $4)
+	`
+	return expectOneRow(r.db.ExecContext(ctx, query, amount, id, repository.LoanStatusActive, repository.LoanStatusMarginCall))
+}
+
+func (r *LoanRepository) CloseLoan(ctx context.Context, loan *repository.LoanModel, status repository.LoanStatus) error {
+	// 以抵押物数量作为条件，避免按过期的抵押物数量结清
+	query := `
+		UPDATE loan SET
+			status = $1,
+			liquidated_collateral = $2,
+			liquidation_price = $3,
+			closed_at = NOW(),
+			updated_at = NOW()
+		WHERE id = $4 AND status IN ($5, $6) AND collateral_amount = $7
+	`
+	return expectOneRow(r.db.ExecContext(ctx, query,
+		status, loan.LiquidatedCollateral, loan.LiquidationPrice,
+		loan.ID, repository.LoanStatusActive, repository.LoanStatusMarginCall, loan.CollateralAmount,
+	))
+}
+
+// expectOneRow 将未命中任何行的条件更新转为 ErrNotFound
+func expectOneRow(result sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
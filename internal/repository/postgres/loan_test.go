@@ -0,0 +1,59 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"monera-digital/internal/money"
+	"monera-digital/internal/repository"
+)
+
+func TestLoanRepository_CloseLoan_CollateralChanged(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewLoanRepository(db)
+	loan := &repository.LoanModel{
+		ID:                   9,
+		CollateralAmount:     money.MustParse("0.1"),
+		LiquidatedCollateral: money.MustParse("0.08513514"),
+		LiquidationPrice:     money.MustParse("37000"),
+	}
+
+	mock.ExpectExec("UPDATE loan SET").
+		WithArgs(repository.LoanStatusLiquidated, loan.LiquidatedCollateral, loan.LiquidationPrice,
+			int64(9), repository.LoanStatusActive, repository.LoanStatusMarginCall, loan.CollateralAmount).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err = repo.CloseLoan(context.Background(), loan, repository.LoanStatusLiquidated)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestLoanRepository_SetLoanStatus(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewLoanRepository(db)
+
+	mock.ExpectExec("UPDATE loan SET status").
+		WithArgs(repository.LoanStatusMarginCall, int64(9), repository.LoanStatusActive).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	assert.NoError(t, repo.SetLoanStatus(context.Background(), 9, repository.LoanStatusActive, repository.LoanStatusMarginCall))
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,90 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"monera-digital/internal/money"
+	"monera-digital/internal/repository"
+)
+
+type P2PTransferRepository struct {
+	db querier
+}
+
+func NewP2PTransferRepository(db *sql.DB) *P2PTransferRepository {
+	return &P2PTransferRepository{db: db}
+}
+
+var _ repository.P2PTransfer = (*P2PTransferRepository)(nil)
+
+const p2pTransferColumns = `id, transfer_no, sender_id, receiver_id, currency, amount, idempotency_key, created_at`
+
+func scanP2PTransfer(row rowScanner) (*repository.P2PTransferModel, error) {
+	var t repository.P2PTransferModel
+	err := row.Scan(&t.ID, &t.TransferNo, &t.SenderID, &t.ReceiverID, &t.Currency, &t.Amount, &t.IdempotencyKey, &t.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *P2PTransferRepository) CreateTransfer(ctx context.Context, transfer *repository.P2PTransferModel) error {
+	query := `
+		INSERT INTO p2p_transfer (transfer_no, sender_id, receiver_id, currency, amount, idempotency_key, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW())
+		ON CONFLICT (sender_id, idempotency_key) DO NOTHING
+		RETURNING id, created_at
+	`
+	err := r.db.QueryRowContext(ctx, query,
+		transfer.TransferNo, transfer.SenderID, transfer.ReceiverID, transfer.Currency, transfer.Amount, transfer.IdempotencyKey,
+	).Scan(&transfer.ID, &transfer.CreatedAt)
+	if err == sql.ErrNoRows {
+		return repository.ErrAlreadyExists
+	}
+	return err
+}
+
+func (r *P2PTransferRepository) GetTransferByKey(ctx context.Context, senderID int64, idempotencyKey string) (*repository.P2PTransferModel, error) {
+	row := r.db.QueryRowContext(ctx,
+		`SELECT `+p2pTransferColumns+` FROM p2p_transfer WHERE sender_id = $1 AND idempotency_key = $2`,
+		senderID, idempotencyKey)
+	t, err := scanP2PTransfer(row)
+	if err == sql.ErrNoRows {
+		return nil, repository.ErrNotFound
+	}
+	return t, err
+}
+
+func (r *P2PTransferRepository) GetTransfersByUserID(ctx context.Context, userID int64, limit, offset int) ([]*repository.P2PTransferModel, error) {
+	query := `SELECT ` + p2pTransferColumns + ` FROM p2p_transfer
+		WHERE sender_id = $1 OR receiver_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2 OFFSET $3`
+	rows, err := r.db.QueryContext(ctx, query, userID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var transfers []*repository.P2PTransferModel
+	for rows.Next() {
+		t, err := scanP2PTransfer(rows)
+		if err != nil {
+			return nil, err
+		}
+		transfers = append(transfers, t)
+	}
+	return transfers, rows.Err()
+}
+
+func (r *P2PTransferRepository) SumSentSince(ctx context.Context, senderID int64, currency string, since time.Time) (money.Decimal, error) {
+	query := `
+		SELECT COALESCE(SUM(amount), 0) FROM p2p_transfer
+		WHERE sender_id = $1 AND currency = $2 AND created_at >= $3
+	`
+	var total money.Decimal
+	err := r.db.QueryRowContext(ctx, query, senderID, currency, since).Scan(&total)
+	return total, err
+}
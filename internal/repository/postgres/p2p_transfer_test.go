@@ -0,0 +1,36 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"monera-digital/internal/money"
+	"monera-digital/internal/repository"
+)
+
+func TestP2PTransferRepository_CreateTransfer_DuplicateKey(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewP2PTransferRepository(db)
+	transfer := &repository.P2PTransferModel{
+		TransferNo: "P2P-1", SenderID: 1, ReceiverID: 2, Currency: "USDT",
+		Amount: money.MustParse("1000"), IdempotencyKey: "key-1",
+	}
+
+	mock.ExpectQuery("INSERT INTO p2p_transfer").
+		WithArgs("P2P-1", int64(1), int64(2), "USDT", money.MustParse("1000"), "key-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}))
+
+	err = repo.CreateTransfer(context.Background(), transfer)
+	assert.ErrorIs(t, err, repository.ErrAlreadyExists)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
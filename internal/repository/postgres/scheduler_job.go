@@ -0,0 +1,72 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"monera-digital/internal/repository"
+)
+
+type SchedulerJobRepository struct {
+	db *sql.DB
+}
+
+func NewSchedulerJobRepository(db *sql.DB) *SchedulerJobRepository {
+	return &SchedulerJobRepository{db: db}
+}
+
+func (r *SchedulerJobRepository) GetJob(ctx context.Context, name string) (*repository.SchedulerJobModel, error) {
+	query := `
+		SELECT name, paused, last_run_at, last_status, last_error, last_duration_ms, updated_at
+		FROM scheduler_job
+		WHERE name = $1
+	`
+	var job repository.SchedulerJobModel
+	var lastRunAt sql.NullTime
+	var updatedAt time.Time
+	err := r.db.QueryRowContext(ctx, query, name).Scan(
+		&job.Name, &job.Paused, &lastRunAt, &job.LastStatus, &job.LastError, &job.LastDurationMs, &updatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if lastRunAt.Valid {
+		job.LastRunAt = lastRunAt.Time.Format(time.RFC3339)
+	}
+	job.UpdatedAt = updatedAt.Format(time.RFC3339)
+	return &job, nil
+}
+
+// SaveLastRun 写入最近一次运行结果，不改变暂停开关
+func (r *SchedulerJobRepository) SaveLastRun(ctx context.Context, job *repository.SchedulerJobModel) error {
+	query := `
+		INSERT INTO scheduler_job (name, last_run_at, last_status, last_error, last_duration_ms, updated_at)
+		VALUES ($1, $2, $3, $4, $5, NOW())
+		ON CONFLICT (name) DO UPDATE SET
+			last_run_at = EXCLUDED.last_run_at,
+			last_status = EXCLUDED.last_status,
+			last_error = EXCLUDED.last_error,
+			last_duration_ms = EXCLUDED.last_duration_ms,
+			updated_at = NOW()
+	`
+	_, err := r.db.ExecContext(ctx, query, job.Name, job.LastRunAt, job.LastStatus, job.LastError, job.LastDurationMs)
+	return err
+}
+
+func (r *SchedulerJobRepository) SetPaused(ctx context.Context, name string, paused bool) error {
+	query := `
+		INSERT INTO scheduler_job (name, paused, updated_at)
+		VALUES ($1, $2, NOW())
+		ON CONFLICT (name) DO UPDATE SET
+			paused = EXCLUDED.paused,
+			updated_at = NOW()
+	`
+	_, err := r.db.ExecContext(ctx, query, name, paused)
+	return err
+}
+
+var _ repository.SchedulerJob = (*SchedulerJobRepository)(nil)
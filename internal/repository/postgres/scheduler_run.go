@@ -0,0 +1,133 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"monera-digital/internal/repository"
+)
+
+type SchedulerRunRepository struct {
+	db *sql.DB
+}
+
+func NewSchedulerRunRepository(db *sql.DB) *SchedulerRunRepository {
+	return &SchedulerRunRepository{db: db}
+}
+
+// TryLock 基于 Postgres 会话级 advisory lock 实现
+// The lock lives on a dedicated pooled connection, so it is released either by
+// unlock or automatically when that connection dies with the holding process.
+func (r *SchedulerRunRepository) TryLock(ctx context.Context, jobName string) (func(), bool, error) {
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var acquired bool
+	err = conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock(hashtext($1))`, jobName).Scan(&acquired)
+	if err != nil {
+		conn.Close()
+		return nil, false, err
+	}
+	if !acquired {
+		conn.Close()
+		return nil, false, nil
+	}
+
+	unlock := func() {
+		conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock(hashtext($1))`, jobName)
+		conn.Close()
+	}
+	return unlock, true, nil
+}
+
+func (r *SchedulerRunRepository) GetLastSuccessDate(ctx context.Context, jobName string) (string, error) {
+	query := `
+		SELECT COALESCE(MAX(run_date)::text, '')
+		FROM scheduler_run
+		WHERE job_name = $1 AND status = $2
+	`
+	var date string
+	err := r.db.QueryRowContext(ctx, query, jobName, repository.SchedulerRunStatusSuccess).Scan(&date)
+	if err != nil {
+		return "", err
+	}
+	return date, nil
+}
+
+func (r *SchedulerRunRepository) CreateRun(ctx context.Context, run *repository.SchedulerRunModel) error {
+	query := `
+		INSERT INTO scheduler_run (job_name, run_date, status, started_at)
+		VALUES ($1, $2, $3, NOW())
+		RETURNING id, started_at
+	`
+	var startedAt time.Time
+	err := r.db.QueryRowContext(ctx, query, run.JobName, run.RunDate, run.Status).Scan(&run.ID, &startedAt)
+	if err != nil {
+		return err
+	}
+	run.StartedAt = startedAt.Format(time.RFC3339)
+	return nil
+}
+
+func (r *SchedulerRunRepository) FinishRun(ctx context.Context, run *repository.SchedulerRunModel) error {
+	query := `
+		UPDATE scheduler_run SET
+			status = $1,
+			items_processed = $2,
+			detail = $3,
+			error_message = $4,
+			finished_at = NOW()
+		WHERE id = $5
+		RETURNING finished_at
+	`
+	var finishedAt time.Time
+	err := r.db.QueryRowContext(ctx, query, run.Status, run.ItemsProcessed, run.Detail, run.ErrorMessage, run.ID).Scan(&finishedAt)
+	if err == sql.ErrNoRows {
+		return repository.ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	run.FinishedAt = finishedAt.Format(time.RFC3339)
+	return nil
+}
+
+func (r *SchedulerRunRepository) ListRuns(ctx context.Context, jobName string, limit int) ([]*repository.SchedulerRunModel, error) {
+	query := `
+		SELECT id, job_name, run_date::text, status, items_processed, detail, error_message, started_at, finished_at
+		FROM scheduler_run
+		WHERE job_name = $1
+		ORDER BY started_at DESC, id DESC
+		LIMIT $2
+	`
+	rows, err := r.db.QueryContext(ctx, query, jobName, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var runs []*repository.SchedulerRunModel
+	for rows.Next() {
+		var run repository.SchedulerRunModel
+		var startedAt time.Time
+		var finishedAt sql.NullTime
+		err := rows.Scan(
+			&run.ID, &run.JobName, &run.RunDate, &run.Status, &run.ItemsProcessed,
+			&run.Detail, &run.ErrorMessage, &startedAt, &finishedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		run.StartedAt = startedAt.Format(time.RFC3339)
+		if finishedAt.Valid {
+			run.FinishedAt = finishedAt.Time.Format(time.RFC3339)
+		}
+		runs = append(runs, &run)
+	}
+	return runs, rows.Err()
+}
+
+var _ repository.SchedulerRun = (*SchedulerRunRepository)(nil)
@@ -0,0 +1,80 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchedulerRunRepository_TryLock(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewSchedulerRunRepository(db)
+
+	mock.ExpectQuery("SELECT pg_try_advisory_lock").
+		WithArgs("wealth_interest").
+		WillReturnRows(sqlmock.NewRows([]string{"pg_try_advisory_lock"}).AddRow(true))
+	mock.ExpectExec("SELECT pg_advisory_unlock").
+		WithArgs("wealth_interest").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	unlock, acquired, err := repo.TryLock(context.Background(), "wealth_interest")
+	assert.NoError(t, err)
+	assert.True(t, acquired)
+	unlock()
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestSchedulerRunRepository_TryLock_HeldElsewhere(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewSchedulerRunRepository(db)
+
+	mock.ExpectQuery("SELECT pg_try_advisory_lock").
+		WithArgs("wealth_interest").
+		WillReturnRows(sqlmock.NewRows([]string{"pg_try_advisory_lock"}).AddRow(false))
+
+	unlock, acquired, err := repo.TryLock(context.Background(), "wealth_interest")
+	assert.NoError(t, err)
+	assert.False(t, acquired)
+	assert.Nil(t, unlock)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestSchedulerRunRepository_GetLastSuccessDate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewSchedulerRunRepository(db)
+
+	mock.ExpectQuery("SELECT COALESCE\\(MAX\\(run_date\\)::text, ''\\)").
+		WithArgs("wealth_interest", "SUCCESS").
+		WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow("2026-01-10"))
+
+	date, err := repo.GetLastSuccessDate(context.Background(), "wealth_interest")
+	assert.NoError(t, err)
+	assert.Equal(t, "2026-01-10", date)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,62 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"monera-digital/internal/repository"
+)
+
+// querier is satisfied by both *sql.DB and *sql.Tx, so the same repository
+// code runs inside or outside a transaction.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+type UnitOfWork struct {
+	db *sql.DB
+}
+
+func NewUnitOfWork(db *sql.DB) *UnitOfWork {
+	return &UnitOfWork{db: db}
+}
+
+// Do 在单个事务中执行 fn，fn 返回错误或 panic 时整体回滚
+func (u *UnitOfWork) Do(ctx context.Context, fn func(tx *repository.TxRepository) error) (err error) {
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err = fn(&repository.TxRepository{
+		Wealth:     &WealthRepository{db: tx},
+		Lending:    &LendingRepository{db: tx},
+		Loan:       &LoanRepository{db: tx},
+		P2P:        &P2PTransferRepository{db: tx},
+		Withdrawal: &WithdrawalRepository{db: tx},
+		Account:    &AccountRepository{db: tx},
+		Journal:    &JournalRepository{db: tx},
+	}); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return fmt.Errorf("%w (rollback failed: %v)", err, rbErr)
+		}
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+var _ repository.UnitOfWork = (*UnitOfWork)(nil)
@@ -0,0 +1,116 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"monera-digital/internal/money"
+	"monera-digital/internal/repository"
+)
+
+func TestUnitOfWork_CommitsOnSuccess(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT balance, frozen_balance, version FROM account").
+		WithArgs(int64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"balance", "frozen_balance", "version"}).AddRow("500", "0", 1))
+	mock.ExpectExec("UPDATE account SET").
+		WithArgs(money.MustParse("500"), money.MustParse("100"), int64(1), int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO account_journal").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	uow := NewUnitOfWork(db)
+	err = uow.Do(context.Background(), func(tx *repository.TxRepository) error {
+		if err := tx.Account.FreezeBalance(context.Background(), 1, money.MustParse("100")); err != nil {
+			return err
+		}
+		return tx.Journal.CreateJournalRecord(context.Background(), &repository.JournalModel{SerialNo: "S-1"})
+	})
+	assert.NoError(t, err)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestUnitOfWork_RollsBackOnError(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT balance, frozen_balance, version FROM account").
+		WithArgs(int64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"balance", "frozen_balance", "version"}).AddRow("500", "0", 1))
+	mock.ExpectExec("UPDATE account SET").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO account_journal").
+		WillReturnError(errors.New("journal insert failed"))
+	mock.ExpectRollback()
+
+	uow := NewUnitOfWork(db)
+	err = uow.Do(context.Background(), func(tx *repository.TxRepository) error {
+		if err := tx.Account.FreezeBalance(context.Background(), 1, money.MustParse("100")); err != nil {
+			return err
+		}
+		return tx.Journal.CreateJournalRecord(context.Background(), &repository.JournalModel{SerialNo: "S-1"})
+	})
+	assert.EqualError(t, err, "journal insert failed")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestUnitOfWork_RollsBackOnPanic(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	uow := NewUnitOfWork(db)
+	assert.Panics(t, func() {
+		uow.Do(context.Background(), func(tx *repository.TxRepository) error {
+			panic("boom")
+		})
+	})
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestUnitOfWork_BeginFailure(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin().WillReturnError(errors.New("connection refused"))
+
+	called := false
+	uow := NewUnitOfWork(db)
+	err = uow.Do(context.Background(), func(tx *repository.TxRepository) error {
+		called = true
+		return nil
+	})
+	assert.Error(t, err)
+	assert.False(t, called)
+}
@@ -4,28 +4,57 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"github.com/lib/pq"
+	"monera-digital/internal/money"
 	"monera-digital/internal/repository"
 	"strconv"
 	"time"
 )
 
 type WealthRepository struct {
-	db *sql.DB
+	db querier
 }
 
 func NewWealthRepository(db *sql.DB) *WealthRepository {
 	return &WealthRepository{db: db}
 }
 
-func (r *WealthRepository) GetActiveProducts(ctx context.Context) ([]*repository.WealthProductModel, error) {
-	query := `
-		SELECT id, title, currency, apy, duration, min_amount, max_amount,
-		       total_quota, sold_quota, status, auto_renew_allowed, created_at, updated_at
-		FROM wealth_product
-		WHERE status = 1
-		ORDER BY created_at DESC
-	`
-	rows, err := r.db.QueryContext(ctx, query)
+const productColumns = `
+	id, title, currency, apy, duration, product_type, min_amount, max_amount,
+	total_quota, sold_quota, status, auto_renew_allowed,
+	early_redeem_rule, early_redeem_value, subscribe_start_at, subscribe_end_at,
+	settlement_timezone, cutoff_time, day_count,
+	created_at, updated_at`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanProduct(row rowScanner) (*repository.WealthProductModel, error) {
+	var p repository.WealthProductModel
+	var startAt, endAt sql.NullTime
+	err := row.Scan(
+		&p.ID, &p.Title, &p.Currency, &p.APY, &p.Duration, &p.ProductType,
+		&p.MinAmount, &p.MaxAmount, &p.TotalQuota, &p.SoldQuota,
+		&p.Status, &p.AutoRenewAllowed,
+		&p.EarlyRedeemRule, &p.EarlyRedeemValue, &startAt, &endAt,
+		&p.SettlementTimezone, &p.CutoffTime, &p.DayCount,
+		&p.CreatedAt, &p.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if startAt.Valid {
+		p.SubscribeStartAt = &startAt.Time
+	}
+	if endAt.Valid {
+		p.SubscribeEndAt = &endAt.Time
+	}
+	return &p, nil
+}
+
+func (r *WealthRepository) queryProducts(ctx context.Context, query string, args ...interface{}) ([]*repository.WealthProductModel, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -33,53 +62,128 @@ func (r *WealthRepository) GetActiveProducts(ctx context.Context) ([]*repository
 
 	var products []*repository.WealthProductModel
 	for rows.Next() {
-		var p repository.WealthProductModel
-		err := rows.Scan(
-			&p.ID, &p.Title, &p.Currency, &p.APY, &p.Duration,
-			&p.MinAmount, &p.MaxAmount, &p.TotalQuota, &p.SoldQuota,
-			&p.Status, &p.AutoRenewAllowed, &p.CreatedAt, &p.UpdatedAt,
-		)
+		p, err := scanProduct(rows)
 		if err != nil {
 			return nil, err
 		}
-		products = append(products, &p)
+		products = append(products, p)
 	}
 	return products, rows.Err()
 }
 
+func (r *WealthRepository) GetActiveProducts(ctx context.Context) ([]*repository.WealthProductModel, error) {
+	query := `SELECT` + productColumns + `
+		FROM wealth_product
+		WHERE status = 1 AND (subscribe_end_at IS NULL OR subscribe_end_at > NOW())
+		ORDER BY created_at DESC
+	`
+	return r.queryProducts(ctx, query)
+}
+
+func (r *WealthRepository) ListProducts(ctx context.Context) ([]*repository.WealthProductModel, error) {
+	query := `SELECT` + productColumns + `
+		FROM wealth_product
+		ORDER BY created_at DESC
+	`
+	return r.queryProducts(ctx, query)
+}
+
 func (r *WealthRepository) GetProductByID(ctx context.Context, id int64) (*repository.WealthProductModel, error) {
-	query := `
-		SELECT id, title, currency, apy, duration, min_amount, max_amount,
-		       total_quota, sold_quota, status, auto_renew_allowed, created_at, updated_at
+	query := `SELECT` + productColumns + `
 		FROM wealth_product
 		WHERE id = $1
 	`
-	var p repository.WealthProductModel
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&p.ID, &p.Title, &p.Currency, &p.APY, &p.Duration,
-		&p.MinAmount, &p.MaxAmount, &p.TotalQuota, &p.SoldQuota,
-		&p.Status, &p.AutoRenewAllowed, &p.CreatedAt, &p.UpdatedAt,
-	)
+	p, err := scanProduct(r.db.QueryRowContext(ctx, query, id))
 	if err == sql.ErrNoRows {
 		return nil, repository.ErrNotFound
 	}
 	if err != nil {
 		return nil, err
 	}
-	return &p, nil
+	return p, nil
+}
+
+func (r *WealthRepository) CreateProduct(ctx context.Context, product *repository.WealthProductModel) error {
+	query := `
+		INSERT INTO wealth_product (title, currency, apy, duration, product_type, min_amount, max_amount,
+			total_quota, sold_quota, status, auto_renew_allowed, early_redeem_rule, early_redeem_value,
+			subscribe_start_at, subscribe_end_at, settlement_timezone, cutoff_time, day_count, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, '0', $9, $10, $11, $12, $13, $14, $15, $16, $17, NOW(), NOW())
+		RETURNING id
+	`
+	return r.db.QueryRowContext(ctx, query,
+		product.Title, product.Currency, product.APY, product.Duration, product.ProductType,
+		product.MinAmount, product.MaxAmount, product.TotalQuota, product.Status, product.AutoRenewAllowed,
+		product.EarlyRedeemRule, product.EarlyRedeemValue, product.SubscribeStartAt, product.SubscribeEndAt,
+		product.SettlementTimezone, product.CutoffTime, product.DayCount,
+	).Scan(&product.ID)
+}
+
+func (r *WealthRepository) UpdateProduct(ctx context.Context, product *repository.WealthProductModel) error {
+	query := `
+		UPDATE wealth_product SET
+			title = $1, duration = $2, min_amount = $3, max_amount = $4, total_quota = $5,
+			auto_renew_allowed = $6, early_redeem_rule = $7, early_redeem_value = $8,
+			subscribe_start_at = $9, subscribe_end_at = $10,
+			updated_at = NOW()
+		WHERE id = $11 AND sold_quota <= $5
+	`
+	result, err := r.db.ExecContext(ctx, query,
+		product.Title, product.Duration, product.MinAmount, product.MaxAmount, product.TotalQuota,
+		product.AutoRenewAllowed, product.EarlyRedeemRule, product.EarlyRedeemValue,
+		product.SubscribeStartAt, product.SubscribeEndAt, product.ID,
+	)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		// 区分产品不存在与并发申购使已售额度超过新的总额度
+		var exists bool
+		if err := r.db.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM wealth_product WHERE id = $1)`, product.ID).Scan(&exists); err != nil {
+			return err
+		}
+		if exists {
+			return repository.ErrQuotaExceeded
+		}
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+func (r *WealthRepository) UpdateProductStatus(ctx context.Context, id int64, status int) error {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE wealth_product SET status = $1, updated_at = NOW() WHERE id = $2
+	`, status, id)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
 }
 
 func (r *WealthRepository) CreateOrder(ctx context.Context, order *repository.WealthOrderModel) error {
 	query := `
 		INSERT INTO wealth_order (user_id, product_id, product_title, currency, amount,
 			principal_redeemed, interest_expected, interest_paid, interest_accrued,
-			start_date, end_date, auto_renew, status, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, '0', $6, '0', '0', $7, $8, $9, 1, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+			start_date, end_date, auto_renew, auto_renew_compound, early_redeem_rule, early_redeem_value,
+			status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, '0', $6, '0', '0', $7, NULLIF($8, '')::date, $9, $10, $11, $12, 1, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
 		RETURNING id
 	`
 	err := r.db.QueryRowContext(ctx, query,
 		order.UserID, order.ProductID, order.ProductTitle, order.Currency, order.Amount,
-		order.InterestExpected, order.StartDate, order.EndDate, order.AutoRenew,
+		order.InterestExpected, order.StartDate, order.EndDate, order.AutoRenew, order.AutoRenewCompound,
+		order.EarlyRedeemRule, order.EarlyRedeemValue,
 	).Scan(&order.ID)
 	if err != nil {
 		fmt.Printf("[DEBUG] CreateOrder - error: %v\n", err)
@@ -89,11 +193,12 @@ func (r *WealthRepository) CreateOrder(ctx context.Context, order *repository.We
 
 func (r *WealthRepository) GetOrdersByUserID(ctx context.Context, userID int64) ([]*repository.WealthOrderModel, error) {
 	query := `
-		SELECT o.id, o.user_id, o.product_id, p.title as product_title, p.currency,
-			o.amount, p.duration,
-			o.interest_expected, o.interest_paid, o.interest_accrued, o.start_date, o.end_date,
-			o.auto_renew, o.status, o.renewed_from_order_id, o.renewed_to_order_id,
-			o.redemption_amount, o.redemption_type, o.redeemed_at, o.created_at, o.updated_at
+		SELECT o.id, o.user_id, o.product_id, COALESCE(o.product_title, p.title) as product_title, p.currency, p.product_type,
+			o.amount, o.principal_redeemed, COALESCE(o.end_date - o.start_date, 0),
+			o.interest_expected, o.interest_paid, o.interest_accrued, o.start_date, COALESCE(o.end_date::text, ''), COALESCE(o.last_interest_date::text, ''),
+			o.auto_renew, o.auto_renew_compound, o.early_redeem_rule, o.early_redeem_value, o.status, o.renewed_from_order_id, o.renewed_to_order_id,
+			o.redemption_amount, o.redemption_type, o.redeemed_at, p.settlement_timezone, p.cutoff_time, p.day_count,
+			o.created_at, o.updated_at
 		FROM wealth_order o
 		JOIN wealth_product p ON o.product_id = p.id
 		WHERE o.user_id = $1
@@ -108,22 +213,20 @@ func (r *WealthRepository) GetOrdersByUserID(ctx context.Context, userID int64)
 	var orders []*repository.WealthOrderModel
 	for rows.Next() {
 		var o repository.WealthOrderModel
-		var redemptionAmount, redeemedAt sql.NullString
-		var redemptionType sql.NullString
+		var redeemedAt sql.NullString
 		err := rows.Scan(
-			&o.ID, &o.UserID, &o.ProductID, &o.ProductTitle, &o.Currency,
-			&o.Amount, &o.Duration,
+			&o.ID, &o.UserID, &o.ProductID, &o.ProductTitle, &o.Currency, &o.ProductType,
+			&o.Amount, &o.PrincipalRedeemed, &o.Duration,
 			&o.InterestExpected, &o.InterestPaid, &o.InterestAccrued,
-			&o.StartDate, &o.EndDate, &o.AutoRenew, &o.Status,
+			&o.StartDate, &o.EndDate, &o.LastInterestDate, &o.AutoRenew, &o.AutoRenewCompound, &o.EarlyRedeemRule, &o.EarlyRedeemValue, &o.Status,
 			&o.RenewedFromOrderID, &o.RenewedToOrderID,
-			&redemptionAmount, &redemptionType, &redeemedAt,
+			&o.RedemptionAmount, &o.RedemptionType, &redeemedAt,
+			&o.SettlementTimezone, &o.CutoffTime, &o.DayCount,
 			&o.CreatedAt, &o.UpdatedAt,
 		)
 		if err != nil {
 			return nil, err
 		}
-		o.RedemptionAmount = redemptionAmount.String
-		o.RedeemedAt = redeemedAt.String
 		o.RedeemedAt = redeemedAt.String
 		orders = append(orders, &o)
 	}
@@ -131,24 +234,34 @@ func (r *WealthRepository) GetOrdersByUserID(ctx context.Context, userID int64)
 }
 
 func (r *WealthRepository) GetOrderByID(ctx context.Context, id int64) (*repository.WealthOrderModel, error) {
+	return r.getOrder(ctx, id, "")
+}
+
+// GetOrderForUpdate 读取订单并锁定该行直至事务结束
+func (r *WealthRepository) GetOrderForUpdate(ctx context.Context, id int64) (*repository.WealthOrderModel, error) {
+	return r.getOrder(ctx, id, "FOR UPDATE OF o")
+}
+
+func (r *WealthRepository) getOrder(ctx context.Context, id int64, lock string) (*repository.WealthOrderModel, error) {
 	query := `
-		SELECT o.id, o.user_id, o.product_id, p.title as product_title, p.currency, o.amount,
-			o.interest_expected, o.interest_paid, o.interest_accrued, o.start_date, o.end_date,
-			o.auto_renew, o.status, o.renewed_from_order_id, o.renewed_to_order_id,
-			o.redemption_amount, o.redemption_type, o.redeemed_at, o.created_at, o.updated_at
+		SELECT o.id, o.user_id, o.product_id, COALESCE(o.product_title, p.title) as product_title, p.currency, p.product_type, o.amount, o.principal_redeemed,
+			o.interest_expected, o.interest_paid, o.interest_accrued, o.start_date, COALESCE(o.end_date::text, ''), COALESCE(o.last_interest_date::text, ''),
+			o.auto_renew, o.auto_renew_compound, o.early_redeem_rule, o.early_redeem_value, o.status, o.renewed_from_order_id, o.renewed_to_order_id,
+			o.redemption_amount, o.redemption_type, o.redeemed_at, p.settlement_timezone, p.cutoff_time, p.day_count,
+			o.created_at, o.updated_at
 		FROM wealth_order o
 		JOIN wealth_product p ON o.product_id = p.id
 		WHERE o.id = $1
-	`
+	` + lock
 	var o repository.WealthOrderModel
-	var redemptionAmount, redeemedAt sql.NullString
-	var redemptionType sql.NullString
+	var redeemedAt sql.NullString
 	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&o.ID, &o.UserID, &o.ProductID, &o.ProductTitle, &o.Currency, &o.Amount,
+		&o.ID, &o.UserID, &o.ProductID, &o.ProductTitle, &o.Currency, &o.ProductType, &o.Amount, &o.PrincipalRedeemed,
 		&o.InterestExpected, &o.InterestPaid, &o.InterestAccrued,
-		&o.StartDate, &o.EndDate, &o.AutoRenew, &o.Status,
+		&o.StartDate, &o.EndDate, &o.LastInterestDate, &o.AutoRenew, &o.AutoRenewCompound, &o.EarlyRedeemRule, &o.EarlyRedeemValue, &o.Status,
 		&o.RenewedFromOrderID, &o.RenewedToOrderID,
-		&redemptionAmount, &redemptionType, &redeemedAt,
+		&o.RedemptionAmount, &o.RedemptionType, &redeemedAt,
+		&o.SettlementTimezone, &o.CutoffTime, &o.DayCount,
 		&o.CreatedAt, &o.UpdatedAt,
 	)
 	if err == sql.ErrNoRows {
@@ -157,7 +270,6 @@ func (r *WealthRepository) GetOrderByID(ctx context.Context, id int64) (*reposit
 	if err != nil {
 		return nil, err
 	}
-	o.RedemptionAmount = redemptionAmount.String
 	o.RedeemedAt = redeemedAt.String
 	return &o, nil
 }
@@ -166,22 +278,45 @@ func (r *WealthRepository) UpdateOrder(ctx context.Context, order *repository.We
 	query := `
 		UPDATE wealth_order SET
 			interest_paid = $1, interest_accrued = $2, status = $3,
-			redemption_amount = $4, redemption_type = $5, redeemed_at = $6,
-			updated_at = $7
-		WHERE id = $8
+			redemption_amount = $4, redemption_type = $5, redeemed_at = NULLIF($6, '')::timestamptz,
+			principal_redeemed = $7, interest_expected = $8,
+			updated_at = NOW()
+		WHERE id = $9
 	`
 	_, err := r.db.ExecContext(ctx, query,
 		order.InterestPaid, order.InterestAccrued, order.Status,
 		order.RedemptionAmount, order.RedemptionType, order.RedeemedAt,
-		order.UpdatedAt, order.ID,
+		order.PrincipalRedeemed, order.InterestExpected, order.ID,
 	)
 	return err
 }
 
-func (r *WealthRepository) UpdateProductSoldQuota(ctx context.Context, id int64, amount string) error {
+func (r *WealthRepository) ReserveProductQuota(ctx context.Context, id int64, amount money.Decimal) error {
+	// 条件更新在行锁下重新求值，并发申购不会突破总额度
 	query := `
 		UPDATE wealth_product SET
-			sold_quota = CAST(sold_quota AS NUMERIC) + CAST($1 AS NUMERIC),
+			sold_quota = sold_quota + CAST($1 AS NUMERIC),
+			updated_at = NOW()
+		WHERE id = $2 AND sold_quota + CAST($1 AS NUMERIC) <= total_quota
+	`
+	result, err := r.db.ExecContext(ctx, query, amount, id)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return repository.ErrQuotaExceeded
+	}
+	return nil
+}
+
+func (r *WealthRepository) ReleaseProductQuota(ctx context.Context, id int64, amount money.Decimal) error {
+	query := `
+		UPDATE wealth_product SET
+			sold_quota = GREATEST(sold_quota - CAST($1 AS NUMERIC), 0),
 			updated_at = NOW()
 		WHERE id = $2
 	`
@@ -190,7 +325,7 @@ func (r *WealthRepository) UpdateProductSoldQuota(ctx context.Context, id int64,
 }
 
 type AccountRepository struct {
-	db *sql.DB
+	db querier
 }
 
 func NewAccountRepository(db *sql.DB) repository.AccountV2 {
@@ -201,7 +336,7 @@ func (r *AccountRepository) GetAccountByUserIDAndCurrency(ctx context.Context, u
 	query := `
 		SELECT id, user_id, type, currency, balance, frozen_balance, version, created_at, updated_at
 		FROM account
-		WHERE user_id = $1 AND currency = $2
+		WHERE user_id = $1 AND currency = $2 AND type = 'FUND'
 	`
 	var a repository.AccountModel
 	err := r.db.QueryRowContext(ctx, query, userID, currency).Scan(
@@ -222,8 +357,8 @@ func (r *AccountRepository) GetAccountsByUserID(ctx context.Context, userID int6
 	query := `
 		SELECT id, user_id, type, currency, balance, frozen_balance, version, created_at, updated_at
 		FROM account
-		WHERE user_id = $1 AND type = 'FUND'
-		ORDER BY currency
+		WHERE user_id = $1
+		ORDER BY currency, type
 	`
 	rows, err := r.db.QueryContext(ctx, query, userID)
 	if err != nil {
@@ -247,60 +382,253 @@ func (r *AccountRepository) GetAccountsByUserID(ctx context.Context, userID int6
 	return accounts, rows.Err()
 }
 
-func (r *AccountRepository) FreezeBalance(ctx context.Context, accountID int64, amount string) error {
+func (r *AccountRepository) GetAccountByType(ctx context.Context, userID int64, accountType, currency string) (*repository.AccountModel, error) {
 	query := `
-		UPDATE account SET
-			frozen_balance = frozen_balance + CAST($1 AS NUMERIC),
-			version = version + 1,
-			updated_at = NOW()
-		WHERE id = $2
+		SELECT id, user_id, type, currency, balance, frozen_balance, version, created_at, updated_at
+		FROM account
+		WHERE user_id = $1 AND type = $2 AND currency = $3
+	`
+	var a repository.AccountModel
+	err := r.db.QueryRowContext(ctx, query, userID, accountType, currency).Scan(
+		&a.ID, &a.UserID, &a.Type, &a.Currency,
+		&a.Balance, &a.FrozenBalance, &a.Version,
+		&a.CreatedAt, &a.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (r *AccountRepository) CreateAccount(ctx context.Context, account *repository.AccountModel) error {
+	query := `
+		INSERT INTO account (user_id, type, currency, balance, frozen_balance, version, created_at, updated_at)
+		VALUES ($1, $2, $3, 0, 0, 1, NOW(), NOW())
+		ON CONFLICT (user_id, type, currency) DO NOTHING
+		RETURNING id, balance, frozen_balance, version, created_at, updated_at
 	`
-	_, err := r.db.ExecContext(ctx, query, amount, accountID)
+	err := r.db.QueryRowContext(ctx, query, account.UserID, account.Type, account.Currency).Scan(
+		&account.ID, &account.Balance, &account.FrozenBalance, &account.Version,
+		&account.CreatedAt, &account.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return repository.ErrAlreadyExists
+	}
 	return err
 }
 
-func (r *AccountRepository) UnfreezeBalance(ctx context.Context, accountID int64, amount string) error {
+func (r *AccountRepository) UpdateBalance(ctx context.Context, accountID int64, version int64, delta money.Decimal) error {
 	query := `
 		UPDATE account SET
-			frozen_balance = frozen_balance - CAST($1 AS NUMERIC),
+			balance = balance + CAST($1 AS NUMERIC),
 			version = version + 1,
 			updated_at = NOW()
-		WHERE id = $2
+		WHERE id = $2 AND version = $3
 	`
-	_, err := r.db.ExecContext(ctx, query, amount, accountID)
+	result, err := r.db.ExecContext(ctx, query, delta, accountID, version)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return repository.ErrVersionConflict
+	}
+	return nil
+}
+
+// maxBalanceRetries 余额更新遇到版本冲突时重读重试的次数上限
+const maxBalanceRetries = 8
+
+// casBalance 读取账户余额与版本号，由 apply 校验并计算新的余额与冻结额后按版本号写回。
+// 期间被并发修改时重读重试，重试耗尽返回 ErrVersionConflict；
+// 结果会出现负数或冻结额超过余额时返回 ErrInsufficientBalance，不做任何更新。
+func (r *AccountRepository) casBalance(ctx context.Context, accountID int64, apply func(balance, frozen money.Decimal) (money.Decimal, money.Decimal)) error {
+	for attempt := 0; attempt < maxBalanceRetries; attempt++ {
+		var balance, frozen money.Decimal
+		var version int64
+		err := r.db.QueryRowContext(ctx,
+			`SELECT balance, frozen_balance, version FROM account WHERE id = $1`,
+			accountID).Scan(&balance, &frozen, &version)
+		if err == sql.ErrNoRows {
+			return repository.ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		newBalance, newFrozen := apply(balance, frozen)
+		if newFrozen.IsNegative() || newFrozen.GreaterThan(newBalance) {
+			return repository.ErrInsufficientBalance
+		}
+
+		query := `
+			UPDATE account SET
+				balance = $1,
+				frozen_balance = $2,
+				version = version + 1,
+				updated_at = NOW()
+			WHERE id = $3 AND version = $4
+		`
+		result, err := r.db.ExecContext(ctx, query, newBalance, newFrozen, accountID, version)
+		if err != nil {
+			return err
+		}
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rows == 1 {
+			return nil
+		}
+	}
+	return repository.ErrVersionConflict
+}
+
+func (r *AccountRepository) FreezeBalance(ctx context.Context, accountID int64, amount money.Decimal) error {
+	if amount.IsNegative() {
+		return repository.ErrInvalidInput
+	}
+	return r.casBalance(ctx, accountID, func(balance, frozen money.Decimal) (money.Decimal, money.Decimal) {
+		return balance, frozen.Add(amount)
+	})
+}
+
+func (r *AccountRepository) UnfreezeBalance(ctx context.Context, accountID int64, amount money.Decimal) error {
+	if amount.IsNegative() {
+		return repository.ErrInvalidInput
+	}
+	return r.casBalance(ctx, accountID, func(balance, frozen money.Decimal) (money.Decimal, money.Decimal) {
+		return balance, frozen.Sub(amount)
+	})
+}
+
+// DeductBalance 从可用余额中扣减，不动用冻结部分
+func (r *AccountRepository) DeductBalance(ctx context.Context, accountID int64, amount money.Decimal) error {
+	if amount.IsNegative() {
+		return repository.ErrInvalidInput
+	}
+	return r.casBalance(ctx, accountID, func(balance, frozen money.Decimal) (money.Decimal, money.Decimal) {
+		return balance.Sub(amount), frozen
+	})
+}
+
+func (r *AccountRepository) AddBalance(ctx context.Context, accountID int64, amount money.Decimal) error {
+	if amount.IsNegative() {
+		return repository.ErrInvalidInput
+	}
+	return r.casBalance(ctx, accountID, func(balance, frozen money.Decimal) (money.Decimal, money.Decimal) {
+		return balance.Add(amount), frozen
+	})
+}
+
+func (r *AccountRepository) CreateTransferRecord(ctx context.Context, record *repository.TransferRecordModel) error {
+	query := `
+		INSERT INTO transfer_record (user_id, transfer_id, from_account_id, to_account_id, amount, status, created_at, completed_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, '')::timestamptz)
+		ON CONFLICT (transfer_id) DO NOTHING
+		RETURNING id
+	`
+	err := r.db.QueryRowContext(ctx, query,
+		record.UserID, record.TransferID, record.FromAccountID, record.ToAccountID,
+		record.Amount, record.Status, record.CreatedAt, record.CompletedAt,
+	).Scan(&record.ID)
+	if err == sql.ErrNoRows {
+		return repository.ErrAlreadyExists
+	}
 	return err
 }
 
-func (r *AccountRepository) DeductBalance(ctx context.Context, accountID int64, amount string) error {
+func (r *WealthRepository) AddPrincipal(ctx context.Context, orderID int64, amount money.Decimal) error {
 	query := `
-		UPDATE account SET
-			balance = CAST(balance AS NUMERIC) - CAST($1 AS NUMERIC),
-			version = version + 1,
+		UPDATE wealth_order SET
+			amount = amount + CAST($1 AS NUMERIC),
 			updated_at = NOW()
-		WHERE id = $2
+		WHERE id = $2 AND status = 1
 	`
-	_, err := r.db.ExecContext(ctx, query, amount, accountID)
-	return err
+	result, err := r.db.ExecContext(ctx, query, amount, orderID)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
 }
 
-func (r *AccountRepository) AddBalance(ctx context.Context, accountID int64, amount string) error {
+func (r *WealthRepository) UpdateRenewalOptions(ctx context.Context, orderID int64, autoRenew, compound bool) error {
 	query := `
-		UPDATE account SET
-			balance = balance + CAST($1 AS NUMERIC),
-			version = version + 1,
+		UPDATE wealth_order SET
+			auto_renew = $1,
+			auto_renew_compound = $2,
 			updated_at = NOW()
-		WHERE id = $2
+		WHERE id = $3 AND status = 1
 	`
-	_, err := r.db.ExecContext(ctx, query, amount, accountID)
-	return err
+	result, err := r.db.ExecContext(ctx, query, autoRenew, compound, orderID)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+func (r *WealthRepository) CreateOrderAudit(ctx context.Context, audit *repository.WealthOrderAuditModel) error {
+	query := `
+		INSERT INTO audit_trail (operator_id, operator_role, action, target_id, target_type,
+			old_value, new_value, status, created_at)
+		VALUES ($1, 'USER', $2, $3, 'wealth_order', $4::jsonb, $5::jsonb, 'SUCCESS', $6)
+		RETURNING id
+	`
+	return r.db.QueryRowContext(ctx, query,
+		strconv.FormatInt(audit.UserID, 10), audit.Action, audit.OrderID,
+		audit.OldValue, audit.NewValue, audit.CreatedAt,
+	).Scan(&audit.ID)
+}
+
+func (r *WealthRepository) CancelOrder(ctx context.Context, orderID int64) error {
+	query := `
+		UPDATE wealth_order SET
+			status = $1,
+			redeemed_at = NOW(),
+			updated_at = NOW()
+		WHERE id = $2 AND status = $3
+	`
+	result, err := r.db.ExecContext(ctx, query, repository.WealthOrderStatusCancelled, orderID, repository.WealthOrderStatusActive)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
 }
 
 func (r *WealthRepository) GetActiveOrders(ctx context.Context) ([]*repository.WealthOrderModel, error) {
 	query := `
-		SELECT o.id, o.user_id, o.product_id, p.title as product_title, p.currency, o.amount,
-			o.interest_expected, o.interest_paid, o.interest_accrued, o.start_date, o.end_date,
-			o.auto_renew, o.status, o.renewed_from_order_id, o.renewed_to_order_id,
-			o.redemption_amount, o.redemption_type, o.redeemed_at, o.created_at, o.updated_at
+		SELECT o.id, o.user_id, o.product_id, COALESCE(o.product_title, p.title) as product_title, p.currency, p.product_type, o.amount, o.principal_redeemed,
+			o.interest_expected, o.interest_paid, o.interest_accrued, o.start_date, COALESCE(o.end_date::text, ''), COALESCE(o.last_interest_date::text, ''),
+			o.auto_renew, o.auto_renew_compound, o.early_redeem_rule, o.early_redeem_value, o.status, o.renewed_from_order_id, o.renewed_to_order_id,
+			o.redemption_amount, o.redemption_type, o.redeemed_at, p.settlement_timezone, p.cutoff_time, p.day_count,
+			o.created_at, o.updated_at
 		FROM wealth_order o
 		JOIN wealth_product p ON o.product_id = p.id
 		WHERE o.status = 1
@@ -315,38 +643,38 @@ func (r *WealthRepository) GetActiveOrders(ctx context.Context) ([]*repository.W
 	var orders []*repository.WealthOrderModel
 	for rows.Next() {
 		var o repository.WealthOrderModel
-		var redemptionAmount, redeemedAt sql.NullString
-		var redemptionType sql.NullString
+		var redeemedAt sql.NullString
 		err := rows.Scan(
-			&o.ID, &o.UserID, &o.ProductID, &o.ProductTitle, &o.Currency, &o.Amount,
+			&o.ID, &o.UserID, &o.ProductID, &o.ProductTitle, &o.Currency, &o.ProductType, &o.Amount, &o.PrincipalRedeemed,
 			&o.InterestExpected, &o.InterestPaid, &o.InterestAccrued,
-			&o.StartDate, &o.EndDate, &o.AutoRenew, &o.Status,
+			&o.StartDate, &o.EndDate, &o.LastInterestDate, &o.AutoRenew, &o.AutoRenewCompound, &o.EarlyRedeemRule, &o.EarlyRedeemValue, &o.Status,
 			&o.RenewedFromOrderID, &o.RenewedToOrderID,
-			&redemptionAmount, &redemptionType, &redeemedAt,
+			&o.RedemptionAmount, &o.RedemptionType, &redeemedAt,
+			&o.SettlementTimezone, &o.CutoffTime, &o.DayCount,
 			&o.CreatedAt, &o.UpdatedAt,
 		)
 		if err != nil {
 			return nil, err
 		}
-		o.RedemptionAmount = redemptionAmount.String
 		o.RedeemedAt = redeemedAt.String
 		orders = append(orders, &o)
 	}
 	return orders, rows.Err()
 }
 
-func (r *WealthRepository) GetExpiredOrders(ctx context.Context) ([]*repository.WealthOrderModel, error) {
+func (r *WealthRepository) GetExpiredOrders(ctx context.Context, asOf string) ([]*repository.WealthOrderModel, error) {
 	query := `
-		SELECT o.id, o.user_id, o.product_id, p.title as product_title, p.currency, o.amount,
-			o.interest_expected, o.interest_paid, o.interest_accrued, o.start_date, o.end_date,
-			o.auto_renew, o.status, o.renewed_from_order_id, o.renewed_to_order_id,
-			o.redemption_amount, o.redemption_type, o.redeemed_at, o.created_at, o.updated_at
+		SELECT o.id, o.user_id, o.product_id, COALESCE(o.product_title, p.title) as product_title, p.currency, p.product_type, o.amount, o.principal_redeemed,
+			o.interest_expected, o.interest_paid, o.interest_accrued, o.start_date, COALESCE(o.end_date::text, ''), COALESCE(o.last_interest_date::text, ''),
+			o.auto_renew, o.auto_renew_compound, o.early_redeem_rule, o.early_redeem_value, o.status, o.renewed_from_order_id, o.renewed_to_order_id,
+			o.redemption_amount, o.redemption_type, o.redeemed_at, p.settlement_timezone, p.cutoff_time, p.day_count,
+			o.created_at, o.updated_at
 		FROM wealth_order o
 		JOIN wealth_product p ON o.product_id = p.id
-		WHERE o.status = 1 AND o.end_date <= CURRENT_DATE
+		WHERE o.status = 1 AND o.end_date <= $1
 		ORDER BY o.created_at DESC
 	`
-	rows, err := r.db.QueryContext(ctx, query)
+	rows, err := r.db.QueryContext(ctx, query, asOf)
 	if err != nil {
 		return nil, err
 	}
@@ -355,50 +683,79 @@ func (r *WealthRepository) GetExpiredOrders(ctx context.Context) ([]*repository.
 	var orders []*repository.WealthOrderModel
 	for rows.Next() {
 		var o repository.WealthOrderModel
-		var redemptionAmount, redeemedAt sql.NullString
-		var redemptionType sql.NullString
+		var redeemedAt sql.NullString
 		err := rows.Scan(
-			&o.ID, &o.UserID, &o.ProductID, &o.ProductTitle, &o.Currency, &o.Amount,
+			&o.ID, &o.UserID, &o.ProductID, &o.ProductTitle, &o.Currency, &o.ProductType, &o.Amount, &o.PrincipalRedeemed,
 			&o.InterestExpected, &o.InterestPaid, &o.InterestAccrued,
-			&o.StartDate, &o.EndDate, &o.AutoRenew, &o.Status,
+			&o.StartDate, &o.EndDate, &o.LastInterestDate, &o.AutoRenew, &o.AutoRenewCompound, &o.EarlyRedeemRule, &o.EarlyRedeemValue, &o.Status,
 			&o.RenewedFromOrderID, &o.RenewedToOrderID,
-			&redemptionAmount, &redemptionType, &redeemedAt,
+			&o.RedemptionAmount, &o.RedemptionType, &redeemedAt,
+			&o.SettlementTimezone, &o.CutoffTime, &o.DayCount,
 			&o.CreatedAt, &o.UpdatedAt,
 		)
 		if err != nil {
 			return nil, err
 		}
-		o.RedemptionAmount = redemptionAmount.String
 		o.RedeemedAt = redeemedAt.String
 		orders = append(orders, &o)
 	}
 	return orders, rows.Err()
 }
 
-func (r *WealthRepository) UpdateInterestAccrued(ctx context.Context, orderID int64, interestAccrued string) error {
+func (r *WealthRepository) AccrueInterest(ctx context.Context, orderID int64, amount money.Decimal, date string) error {
+	// 流水写入与订单累计在同一条语句内完成；同一天已入账时 INSERT 被唯一索引拦截，订单不会被更新
 	query := `
+		WITH ins AS (
+			INSERT INTO wealth_interest_record (order_id, amount, type, date, created_at)
+			VALUES ($1, $2, 1, $3, NOW())
+			ON CONFLICT (order_id, date) WHERE type = 1 DO NOTHING
+			RETURNING order_id
+		)
 		UPDATE wealth_order SET
-			interest_accrued = CAST($1 AS NUMERIC),
+			interest_accrued = interest_accrued + CAST($2 AS NUMERIC),
+			last_interest_date = $3,
 			updated_at = NOW()
-		WHERE id = $2
+		WHERE id IN (SELECT order_id FROM ins)
 	`
-	_, err := r.db.ExecContext(ctx, query, interestAccrued, orderID)
-	return err
+	result, err := r.db.ExecContext(ctx, query, orderID, amount, date)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return repository.ErrAlreadyExists
+	}
+	return nil
 }
 
-func (r *WealthRepository) AccrueInterest(ctx context.Context, orderID int64, amount string, date string) error {
+func (r *WealthRepository) GetInterestRecords(ctx context.Context, orderID int64) ([]*repository.InterestRecordModel, error) {
 	query := `
-		UPDATE wealth_order SET
-			interest_accrued = interest_accrued + CAST($1 AS NUMERIC),
-			last_interest_date = $2,
-			updated_at = NOW()
-		WHERE id = $4
+		SELECT id, order_id, amount, type, date::text, created_at
+		FROM wealth_interest_record
+		WHERE order_id = $1
+		ORDER BY date ASC, id ASC
 	`
-	_, err := r.db.ExecContext(ctx, query, amount, date, "now()", orderID)
-	return err
+	rows, err := r.db.QueryContext(ctx, query, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []*repository.InterestRecordModel
+	for rows.Next() {
+		var rec repository.InterestRecordModel
+		if err := rows.Scan(&rec.ID, &rec.OrderID, &rec.Amount, &rec.Type, &rec.Date, &rec.CreatedAt); err != nil {
+			return nil, err
+		}
+		records = append(records, &rec)
+	}
+	return records, rows.Err()
 }
 
-func (r *WealthRepository) SettleOrder(ctx context.Context, orderID int64, interestPaid string) error {
+func (r *WealthRepository) SettleOrder(ctx context.Context, orderID int64, interestPaid money.Decimal) error {
 	query := `
 		UPDATE wealth_order SET
 			interest_paid = CAST(interest_paid AS NUMERIC) + CAST($1 AS NUMERIC),
@@ -406,52 +763,61 @@ func (r *WealthRepository) SettleOrder(ctx context.Context, orderID int64, inter
 			status = 3,
 			redeemed_at = $2,
 			updated_at = $3
-		WHERE id = $4
+		WHERE id = $4 AND status = $5
 	`
-	_, err := r.db.ExecContext(ctx, query, interestPaid, "now()", "now()", orderID)
-	return err
+	result, err := r.db.ExecContext(ctx, query, interestPaid, "now()", "now()", orderID, repository.WealthOrderStatusActive)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
 }
 
-func (r *WealthRepository) RenewOrder(ctx context.Context, order *repository.WealthOrderModel, product *repository.WealthProductModel, startDate string, endDate string) (*repository.WealthOrderModel, error) {
-	now := time.Now()
-
-	apy, _ := strconv.ParseFloat(product.APY, 64)
-	amountFloat, _ := strconv.ParseFloat(order.Amount, 64)
-	dailyInterest := amountFloat * (apy / 100) / 365
-	interestExpected := strconv.FormatFloat(dailyInterest*float64(product.Duration), 'f', -1, 64)
+func (r *WealthRepository) RenewOrder(ctx context.Context, order *repository.WealthOrderModel, product *repository.WealthProductModel, principal, interestExpected money.Decimal, startDate, endDate string, renewedAt time.Time) (*repository.WealthOrderModel, error) {
+	now := renewedAt.Format(time.RFC3339)
 
 	newOrder := &repository.WealthOrderModel{
 		UserID:             order.UserID,
 		ProductID:          product.ID,
 		ProductTitle:       product.Title,
 		Currency:           product.Currency,
-		Amount:             order.Amount,
+		Amount:             principal,
 		AutoRenew:          order.AutoRenew,
+		AutoRenewCompound:  order.AutoRenewCompound,
+		EarlyRedeemRule:    product.EarlyRedeemRule,
+		EarlyRedeemValue:   product.EarlyRedeemValue,
 		Status:             1,
 		StartDate:          startDate,
 		EndDate:            endDate,
-		PrincipalRedeemed:  "0",
+		PrincipalRedeemed:  money.Zero,
 		InterestExpected:   interestExpected,
-		InterestPaid:       "0",
-		InterestAccrued:    "0",
+		InterestPaid:       money.Zero,
+		InterestAccrued:    money.Zero,
 		LastInterestDate:   "",
 		RenewedFromOrderID: &order.ID,
-		CreatedAt:          now.Format(time.RFC3339),
-		UpdatedAt:          now.Format(time.RFC3339),
+		CreatedAt:          now,
+		UpdatedAt:          now,
 	}
 
 	query := `
 		INSERT INTO wealth_order (user_id, product_id, product_title, currency, amount,
-			auto_renew, status, start_date, end_date,
+			auto_renew, auto_renew_compound, early_redeem_rule, early_redeem_value, status, start_date, end_date,
 			principal_redeemed, interest_expected, interest_paid, interest_accrued,
 			renewed_from_order_id, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, 1, $7, $8, '0', $9, '0', '0', $10, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, 1, $10, $11, '0', $12, '0', '0', $13, $14, $14)
 		RETURNING id
 	`
 	err := r.db.QueryRowContext(ctx, query,
 		newOrder.UserID, newOrder.ProductID, newOrder.ProductTitle, newOrder.Currency, newOrder.Amount,
-		newOrder.AutoRenew, newOrder.StartDate, newOrder.EndDate,
-		newOrder.InterestExpected, newOrder.RenewedFromOrderID,
+		newOrder.AutoRenew, newOrder.AutoRenewCompound, newOrder.EarlyRedeemRule, newOrder.EarlyRedeemValue,
+		newOrder.StartDate, newOrder.EndDate,
+		newOrder.InterestExpected, newOrder.RenewedFromOrderID, now,
 	).Scan(&newOrder.ID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create renewed order: %v", err)
@@ -460,22 +826,22 @@ func (r *WealthRepository) RenewOrder(ctx context.Context, order *repository.Wea
 	updateQuery := `
 		UPDATE wealth_order SET
 			renewed_to_order_id = $1,
-			updated_at = NOW()
-		WHERE id = $2
+			updated_at = $2
+		WHERE id = $3
 	`
-	_, err = r.db.ExecContext(ctx, updateQuery, newOrder.ID, order.ID)
+	_, err = r.db.ExecContext(ctx, updateQuery, newOrder.ID, now, order.ID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to update original order: %v", err)
 	}
 
 	fmt.Printf("[RenewOrder] Order renewed successfully: old_order_id=%d, new_order_id=%d, user_id=%d, amount=%s, start_date=%s, end_date=%s\n",
-		order.ID, newOrder.ID, order.UserID, order.Amount, startDate, endDate)
+		order.ID, newOrder.ID, order.UserID, principal, startDate, endDate)
 
 	return newOrder, nil
 }
 
 type JournalRepository struct {
-	db *sql.DB
+	db querier
 }
 
 func NewJournalRepository(db *sql.DB) *JournalRepository {
@@ -499,3 +865,144 @@ func (r *JournalRepository) CreateJournalRecord(ctx context.Context, record *rep
 	)
 	return err
 }
+
+func (r *JournalRepository) GetJournalRecordsByRef(ctx context.Context, userID, refID int64, bizTypes []string) ([]*repository.JournalModel, error) {
+	query := `
+		SELECT id, serial_no, user_id, account_id, amount, balance_snapshot, biz_type, ref_id, created_at
+		FROM account_journal
+		WHERE user_id = $1 AND ref_id = $2 AND biz_type = ANY($3)
+		ORDER BY created_at ASC, id ASC
+	`
+	rows, err := r.db.QueryContext(ctx, query, userID, refID, pq.Array(bizTypes))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []*repository.JournalModel
+	for rows.Next() {
+		var j repository.JournalModel
+		if err := rows.Scan(&j.ID, &j.SerialNo, &j.UserID, &j.AccountID, &j.Amount, &j.BalanceSnapshot, &j.BizType, &j.RefID, &j.CreatedAt); err != nil {
+			return nil, err
+		}
+		records = append(records, &j)
+	}
+	return records, rows.Err()
+}
+
+func (r *WealthRepository) GetProductRates(ctx context.Context, productID int64, date string) ([]*repository.WealthRateModel, error) {
+	query := `
+		SELECT id, product_id, effective_date::text, tier_floor, apy, created_at
+		FROM wealth_product_rate
+		WHERE product_id = $1 AND effective_date = (
+			SELECT MAX(effective_date) FROM wealth_product_rate
+			WHERE product_id = $1 AND effective_date <= $2
+		)
+		ORDER BY tier_floor ASC
+	`
+	return r.queryRates(ctx, query, productID, date)
+}
+
+func (r *WealthRepository) ListProductRates(ctx context.Context, productID int64) ([]*repository.WealthRateModel, error) {
+	query := `
+		SELECT id, product_id, effective_date::text, tier_floor, apy, created_at
+		FROM wealth_product_rate
+		WHERE product_id = $1
+		ORDER BY effective_date DESC, tier_floor ASC
+	`
+	return r.queryRates(ctx, query, productID)
+}
+
+func (r *WealthRepository) queryRates(ctx context.Context, query string, args ...interface{}) ([]*repository.WealthRateModel, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rates := []*repository.WealthRateModel{}
+	for rows.Next() {
+		var rate repository.WealthRateModel
+		if err := rows.Scan(&rate.ID, &rate.ProductID, &rate.EffectiveDate, &rate.TierFloor, &rate.APY, &rate.CreatedAt); err != nil {
+			return nil, err
+		}
+		rates = append(rates, &rate)
+	}
+	return rates, rows.Err()
+}
+
+func (r *WealthRepository) SaveProductRates(ctx context.Context, productID int64, effectiveDate string, rates []*repository.WealthRateModel) error {
+	_, err := r.db.ExecContext(ctx, `
+		DELETE FROM wealth_product_rate WHERE product_id = $1 AND effective_date = $2
+	`, productID, effectiveDate)
+	if err != nil {
+		return err
+	}
+
+	for _, rate := range rates {
+		_, err := r.db.ExecContext(ctx, `
+			INSERT INTO wealth_product_rate (product_id, effective_date, tier_floor, apy, created_at)
+			VALUES ($1, $2, $3, $4, NOW())
+		`, productID, effectiveDate, rate.TierFloor, rate.APY)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *WealthRepository) CreateQuote(ctx context.Context, quote *repository.WealthQuoteModel) error {
+	query := `
+		INSERT INTO wealth_subscribe_quote (id, user_id, product_id, amount, interest_expected, start_date, end_date, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, '')::date, $8, NOW())
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		quote.ID, quote.UserID, quote.ProductID, quote.Amount, quote.InterestExpected,
+		quote.StartDate, quote.EndDate, quote.ExpiresAt,
+	)
+	return err
+}
+
+func (r *WealthRepository) GetQuote(ctx context.Context, id string) (*repository.WealthQuoteModel, error) {
+	query := `
+		SELECT id, user_id, product_id, amount, interest_expected, start_date::text, COALESCE(end_date::text, ''),
+			expires_at, used_at, created_at
+		FROM wealth_subscribe_quote
+		WHERE id = $1
+	`
+	var q repository.WealthQuoteModel
+	var usedAt sql.NullTime
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&q.ID, &q.UserID, &q.ProductID, &q.Amount, &q.InterestExpected, &q.StartDate, &q.EndDate,
+		&q.ExpiresAt, &usedAt, &q.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if usedAt.Valid {
+		q.UsedAt = &usedAt.Time
+	}
+	return &q, nil
+}
+
+func (r *WealthRepository) UseQuote(ctx context.Context, id string, userID int64) error {
+	query := `
+		UPDATE wealth_subscribe_quote SET used_at = NOW()
+		WHERE id = $1 AND user_id = $2 AND used_at IS NULL AND expires_at > NOW()
+	`
+	result, err := r.db.ExecContext(ctx, query, id, userID)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
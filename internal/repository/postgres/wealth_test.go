@@ -0,0 +1,600 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"monera-digital/internal/accrual"
+	"monera-digital/internal/money"
+	"monera-digital/internal/repository"
+)
+
+func TestWealthRepository_AccrueInterest(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewWealthRepository(db)
+
+	mock.ExpectExec("INSERT INTO wealth_interest_record").
+		WithArgs(int64(1), money.MustParse("1.506849"), "2026-01-11").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = repo.AccrueInterest(context.Background(), 1, money.MustParse("1.506849"), "2026-01-11")
+	assert.NoError(t, err)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestWealthRepository_AccrueInterest_SameDateTwice(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewWealthRepository(db)
+
+	// 唯一索引拦截插入后，订单更新影响 0 行
+	mock.ExpectExec("INSERT INTO wealth_interest_record").
+		WithArgs(int64(1), money.MustParse("1.506849"), "2026-01-11").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err = repo.AccrueInterest(context.Background(), 1, money.MustParse("1.506849"), "2026-01-11")
+	assert.ErrorIs(t, err, repository.ErrAlreadyExists)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestWealthRepository_GetInterestRecords(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewWealthRepository(db)
+
+	mock.ExpectQuery("SELECT id, order_id, amount, type, date::text, created_at").
+		WithArgs(int64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "order_id", "amount", "type", "date", "created_at"}).
+			AddRow(1, 1, "1.506849", 1, "2026-01-11", "2026-01-11T00:00:05Z").
+			AddRow(2, 1, "1.506849", 1, "2026-01-12", "2026-01-12T00:00:05Z"))
+
+	records, err := repo.GetInterestRecords(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Len(t, records, 2)
+	assert.Equal(t, "2026-01-12", records[1].Date)
+	assert.True(t, records[0].Amount.Equal(money.MustParse("1.506849")))
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestWealthRepository_UpdateOrder_PersistsPartialRedemption(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewWealthRepository(db)
+
+	order := &repository.WealthOrderModel{
+		ID:                7,
+		Status:            1,
+		PrincipalRedeemed: money.MustParse("2500"),
+		InterestExpected:  money.MustParse("45"),
+		InterestPaid:      money.MustParse("2.5"),
+		InterestAccrued:   money.MustParse("15"),
+		RedemptionAmount:  money.MustParse("2502.5"),
+		RedemptionType:    sql.NullString{String: "partial", Valid: true},
+	}
+
+	mock.ExpectExec("UPDATE wealth_order SET").
+		WithArgs(order.InterestPaid, order.InterestAccrued, 1,
+			order.RedemptionAmount, order.RedemptionType, "",
+			order.PrincipalRedeemed, order.InterestExpected, int64(7)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = repo.UpdateOrder(context.Background(), order)
+	assert.NoError(t, err)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestWealthRepository_GetOrderForUpdate_LocksRow(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewWealthRepository(db)
+
+	mock.ExpectQuery("FROM wealth_order o(.+)WHERE o.id = \\$1\\s+FOR UPDATE OF o").
+		WithArgs(int64(7)).
+		WillReturnError(sql.ErrNoRows)
+
+	_, err = repo.GetOrderForUpdate(context.Background(), 7)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestWealthRepository_AddPrincipal(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewWealthRepository(db)
+
+	mock.ExpectExec("UPDATE wealth_order SET").
+		WithArgs(money.MustParse("500"), int64(7)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE wealth_order SET").
+		WithArgs(money.MustParse("500"), int64(8)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	assert.NoError(t, repo.AddPrincipal(context.Background(), 7, money.MustParse("500")))
+	// 订单已结清时不能再追加
+	assert.ErrorIs(t, repo.AddPrincipal(context.Background(), 8, money.MustParse("500")), repository.ErrNotFound)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestWealthRepository_GetProductRates(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewWealthRepository(db)
+
+	rows := sqlmock.NewRows([]string{"id", "product_id", "effective_date", "tier_floor", "apy", "created_at"}).
+		AddRow(int64(3), int64(1), "2026-03-01", "0", "8", "2026-02-20T00:00:00Z").
+		AddRow(int64(4), int64(1), "2026-03-01", "10000", "4", "2026-02-20T00:00:00Z")
+	mock.ExpectQuery("SELECT (.+) FROM wealth_product_rate").
+		WithArgs(int64(1), "2026-03-09").
+		WillReturnRows(rows)
+
+	rates, err := repo.GetProductRates(context.Background(), 1, "2026-03-09")
+	assert.NoError(t, err)
+	if assert.Len(t, rates, 2) {
+		assert.Equal(t, money.MustParse("10000"), rates[1].TierFloor)
+		assert.Equal(t, money.MustParse("4"), rates[1].APY)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestWealthRepository_SaveProductRates_ReplacesScheduledDate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewWealthRepository(db)
+
+	mock.ExpectExec("DELETE FROM wealth_product_rate").
+		WithArgs(int64(1), "2026-04-01").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec("INSERT INTO wealth_product_rate").
+		WithArgs(int64(1), "2026-04-01", money.Zero, money.MustParse("7")).
+		WillReturnResult(sqlmock.NewResult(5, 1))
+
+	err = repo.SaveProductRates(context.Background(), 1, "2026-04-01", []*repository.WealthRateModel{
+		{TierFloor: money.Zero, APY: money.MustParse("7")},
+	})
+	assert.NoError(t, err)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestWealthRepository_GetProductByID_SubscribeWindow(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewWealthRepository(db)
+
+	opensAt := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	rows := sqlmock.NewRows([]string{
+		"id", "title", "currency", "apy", "duration", "product_type", "min_amount", "max_amount",
+		"total_quota", "sold_quota", "status", "auto_renew_allowed",
+		"early_redeem_rule", "early_redeem_value", "subscribe_start_at", "subscribe_end_at",
+		"settlement_timezone", "cutoff_time", "day_count",
+		"created_at", "updated_at",
+	}).AddRow(int64(1), "USDT 30日", "USDT", "6", 30, 1, "100", "50000",
+		"1000000", "0", 1, true,
+		2, "50", opensAt, nil,
+		"America/New_York", "15:00", "ACT/360",
+		"2026-04-01T00:00:00Z", "2026-04-01T00:00:00Z")
+	mock.ExpectQuery("SELECT (.+) FROM wealth_product").
+		WithArgs(int64(1)).
+		WillReturnRows(rows)
+
+	product, err := repo.GetProductByID(context.Background(), 1)
+	assert.NoError(t, err)
+	if assert.NotNil(t, product.SubscribeStartAt) {
+		assert.True(t, product.SubscribeStartAt.Equal(opensAt))
+	}
+	assert.Nil(t, product.SubscribeEndAt)
+	assert.False(t, product.InSubscribeWindow(opensAt.Add(-time.Minute)))
+	assert.True(t, product.InSubscribeWindow(opensAt))
+	assert.Equal(t, accrual.DayCountACT360, product.Basis())
+	assert.Equal(t, 15*time.Hour, product.Calendar().Cutoff)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestWealthRepository_UpdateProduct_GuardsSoldQuota(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewWealthRepository(db)
+	product := &repository.WealthProductModel{ID: 3, Title: "Fixed 30D", TotalQuota: money.MustParse("25000")}
+
+	mock.ExpectExec("UPDATE wealth_product SET(.+)WHERE id = \\$11 AND sold_quota <= \\$5").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT EXISTS").WithArgs(int64(3)).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectExec("UPDATE wealth_product SET").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT EXISTS").WithArgs(int64(3)).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+
+	// 已售额度超过新的总额度
+	assert.ErrorIs(t, repo.UpdateProduct(context.Background(), product), repository.ErrQuotaExceeded)
+	assert.ErrorIs(t, repo.UpdateProduct(context.Background(), product), repository.ErrNotFound)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestWealthRepository_UpdateProductStatus_NotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewWealthRepository(db)
+
+	mock.ExpectExec("UPDATE wealth_product SET status").
+		WithArgs(repository.WealthProductStatusPaused, int64(99)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err = repo.UpdateProductStatus(context.Background(), 99, repository.WealthProductStatusPaused)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestWealthRepository_ReserveProductQuota(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewWealthRepository(db)
+
+	mock.ExpectExec("UPDATE wealth_product SET(.+)sold_quota \\+ CAST\\(\\$1 AS NUMERIC\\) <= total_quota").
+		WithArgs(money.MustParse("5000"), int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE wealth_product SET").
+		WithArgs(money.MustParse("5000"), int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	assert.NoError(t, repo.ReserveProductQuota(context.Background(), 1, money.MustParse("5000")))
+	// 余量不足时条件更新不命中任何行
+	assert.ErrorIs(t, repo.ReserveProductQuota(context.Background(), 1, money.MustParse("5000")), repository.ErrQuotaExceeded)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestWealthRepository_UseQuote(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewWealthRepository(db)
+
+	mock.ExpectExec("UPDATE wealth_subscribe_quote SET used_at = NOW\\(\\)(.+)used_at IS NULL AND expires_at > NOW\\(\\)").
+		WithArgs("q-1", int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE wealth_subscribe_quote SET used_at").
+		WithArgs("q-1", int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	assert.NoError(t, repo.UseQuote(context.Background(), "q-1", 1))
+	// 已使用或已过期的报价不会被再次核销
+	assert.ErrorIs(t, repo.UseQuote(context.Background(), "q-1", 1), repository.ErrNotFound)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestWealthRepository_CancelOrder(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewWealthRepository(db)
+
+	mock.ExpectExec("UPDATE wealth_order SET(.+)WHERE id = \\$2 AND status = \\$3").
+		WithArgs(repository.WealthOrderStatusCancelled, int64(5), repository.WealthOrderStatusActive).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE wealth_order SET").
+		WithArgs(repository.WealthOrderStatusCancelled, int64(5), repository.WealthOrderStatusActive).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	assert.NoError(t, repo.CancelOrder(context.Background(), 5))
+	// 订单已撤销或已赎回时条件更新不命中任何行
+	assert.ErrorIs(t, repo.CancelOrder(context.Background(), 5), repository.ErrNotFound)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestWealthRepository_SettleOrder_OnlyActive(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewWealthRepository(db)
+
+	mock.ExpectExec("UPDATE wealth_order SET(.+)WHERE id = \\$4 AND status = \\$5").
+		WithArgs(money.MustParse("15.5"), sqlmock.AnyArg(), sqlmock.AnyArg(), int64(5), repository.WealthOrderStatusActive).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE wealth_order SET").
+		WithArgs(money.MustParse("15.5"), sqlmock.AnyArg(), sqlmock.AnyArg(), int64(5), repository.WealthOrderStatusActive).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	assert.NoError(t, repo.SettleOrder(context.Background(), 5, money.MustParse("15.5")))
+	// 订单已被赎回或撤单时不再结算
+	assert.ErrorIs(t, repo.SettleOrder(context.Background(), 5, money.MustParse("15.5")), repository.ErrNotFound)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestWealthRepository_RenewOrder_UsesCallerPricingAndTime(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewWealthRepository(db)
+	renewedAt := time.Date(2026, 3, 10, 1, 0, 0, 0, time.UTC)
+	order := &repository.WealthOrderModel{ID: 1, UserID: 7, AutoRenew: true}
+	product := &repository.WealthProductModel{ID: 3, Title: "USDT 7日", Currency: "USDT", APY: money.MustParse("5.50"), Duration: 7}
+
+	mock.ExpectQuery("INSERT INTO wealth_order").
+		WithArgs(int64(7), int64(3), "USDT 7日", "USDT", money.MustParse("10000"), true, false, 0, money.Decimal{},
+			"2026-03-10", "2026-03-17", money.MustParse("11"), &order.ID, "2026-03-10T01:00:00Z").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+	mock.ExpectExec("UPDATE wealth_order SET").
+		WithArgs(int64(2), "2026-03-10T01:00:00Z", int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	renewed, err := repo.RenewOrder(context.Background(), order, product, money.MustParse("10000"), money.MustParse("11"), "2026-03-10", "2026-03-17", renewedAt)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), renewed.ID)
+	assert.Equal(t, money.MustParse("11"), renewed.InterestExpected)
+	assert.Equal(t, "2026-03-10T01:00:00Z", renewed.CreatedAt)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestWealthRepository_CreateOrderAudit(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewWealthRepository(db)
+	audit := &repository.WealthOrderAuditModel{
+		OrderID:   5,
+		UserID:    1,
+		Action:    repository.WealthAuditAutoRenew,
+		OldValue:  `{"autoRenew":false,"compound":false}`,
+		NewValue:  `{"autoRenew":true,"compound":false}`,
+		CreatedAt: "2026-03-10T02:00:00Z",
+	}
+
+	mock.ExpectQuery("INSERT INTO audit_trail").
+		WithArgs("1", repository.WealthAuditAutoRenew, int64(5), audit.OldValue, audit.NewValue, audit.CreatedAt).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(42))
+
+	assert.NoError(t, repo.CreateOrderAudit(context.Background(), audit))
+	assert.Equal(t, int64(42), audit.ID)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestJournalRepository_GetJournalRecordsByRef(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewJournalRepository(db)
+
+	mock.ExpectQuery("SELECT (.+) FROM account_journal WHERE user_id = \\$1 AND ref_id = \\$2 AND biz_type = ANY\\(\\$3\\)").
+		WithArgs(int64(1), int64(7), pq.Array([]string{"SUBSCRIBE_FREEZE", "REDEEM_UNFREEZE"})).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "serial_no", "user_id", "account_id", "amount", "balance_snapshot", "biz_type", "ref_id", "created_at"}).
+			AddRow(1, "SUBSCRIBE-20260110-7", 1, 3, "-5000", "1000", "SUBSCRIBE_FREEZE", 7, "2026-01-10T08:00:00Z").
+			AddRow(2, "REDEEM-PRINCIPAL-20260118-7", 1, 3, "5000", "6000", "REDEEM_UNFREEZE", 7, "2026-01-18T08:00:00Z"))
+
+	records, err := repo.GetJournalRecordsByRef(context.Background(), 1, 7, []string{"SUBSCRIBE_FREEZE", "REDEEM_UNFREEZE"})
+	assert.NoError(t, err)
+	if assert.Len(t, records, 2) {
+		assert.Equal(t, "SUBSCRIBE_FREEZE", records[0].BizType)
+		assert.True(t, records[0].Amount.Equal(money.MustParse("-5000")))
+		assert.Equal(t, int64(7), *records[1].RefID)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestAccountRepository_UpdateBalance_VersionConflict(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewAccountRepository(db)
+
+	mock.ExpectExec("UPDATE account SET").
+		WithArgs(money.MustParse("-300"), int64(1), int64(7)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE account SET").
+		WithArgs(money.MustParse("-300"), int64(1), int64(7)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	assert.NoError(t, repo.UpdateBalance(context.Background(), 1, 7, money.MustParse("-300")))
+	assert.ErrorIs(t, repo.UpdateBalance(context.Background(), 1, 7, money.MustParse("-300")), repository.ErrVersionConflict)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestAccountRepository_FreezeBalance_RetriesOnConflict(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewAccountRepository(db)
+
+	// 第一次写回时账户已被并发修改，重读后按新版本写回
+	mock.ExpectQuery("SELECT balance, frozen_balance, version FROM account").
+		WithArgs(int64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"balance", "frozen_balance", "version"}).AddRow("1000", "0", 3))
+	mock.ExpectExec("UPDATE account SET").
+		WithArgs(money.MustParse("1000"), money.MustParse("400"), int64(1), int64(3)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT balance, frozen_balance, version FROM account").
+		WithArgs(int64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"balance", "frozen_balance", "version"}).AddRow("1000", "500", 4))
+	mock.ExpectExec("UPDATE account SET").
+		WithArgs(money.MustParse("1000"), money.MustParse("900"), int64(1), int64(4)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	assert.NoError(t, repo.FreezeBalance(context.Background(), 1, money.MustParse("400")))
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestAccountRepository_BalanceGuards(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewAccountRepository(db)
+	ctx := context.Background()
+	rows := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"balance", "frozen_balance", "version"}).AddRow("1000", "600", 2)
+	}
+
+	// 冻结超过可用、解冻超过冻结、扣减动用冻结部分都不写回
+	mock.ExpectQuery("SELECT balance, frozen_balance, version FROM account").WillReturnRows(rows())
+	mock.ExpectQuery("SELECT balance, frozen_balance, version FROM account").WillReturnRows(rows())
+	mock.ExpectQuery("SELECT balance, frozen_balance, version FROM account").WillReturnRows(rows())
+
+	assert.ErrorIs(t, repo.FreezeBalance(ctx, 1, money.MustParse("400.01")), repository.ErrInsufficientBalance)
+	assert.ErrorIs(t, repo.UnfreezeBalance(ctx, 1, money.MustParse("600.01")), repository.ErrInsufficientBalance)
+	assert.ErrorIs(t, repo.DeductBalance(ctx, 1, money.MustParse("400.01")), repository.ErrInsufficientBalance)
+	assert.ErrorIs(t, repo.AddBalance(ctx, 1, money.MustParse("-1")), repository.ErrInvalidInput)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestAccountRepository_AddBalance_GivesUpAfterRetries(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewAccountRepository(db)
+
+	for i := 0; i < maxBalanceRetries; i++ {
+		mock.ExpectQuery("SELECT balance, frozen_balance, version FROM account").
+			WillReturnRows(sqlmock.NewRows([]string{"balance", "frozen_balance", "version"}).AddRow("10", "0", i))
+		mock.ExpectExec("UPDATE account SET").WillReturnResult(sqlmock.NewResult(0, 0))
+	}
+
+	assert.ErrorIs(t, repo.AddBalance(context.Background(), 1, money.MustParse("5")), repository.ErrVersionConflict)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
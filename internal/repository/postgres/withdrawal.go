@@ -10,7 +10,7 @@ import (
 )
 
 type WithdrawalRepository struct {
-	db *sql.DB
+	db querier
 }
 
 func NewWithdrawalRepository(db *sql.DB) repository.Withdrawal {
@@ -22,12 +22,12 @@ func (r *WithdrawalRepository) CreateOrder(ctx context.Context, order *models.Wi
 		`INSERT INTO withdrawal_order (
 			user_id, amount, network_fee, platform_fee, actual_amount,
 			chain_type, coin_type, to_address, safeheron_order_id, transaction_hash,
-			status, created_at, updated_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+			request_id, status, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
 		RETURNING id, created_at`,
 		order.UserID, order.Amount, order.NetworkFee, order.PlatformFee, order.ActualAmount,
 		order.ChainType, order.CoinType, order.ToAddress, order.SafeheronOrderID, order.TransactionHash,
-		order.Status, time.Now(), time.Now(),
+		order.RequestID, order.Status, time.Now(), time.Now(),
 	).Scan(&order.ID, &order.CreatedAt)
 	if err != nil {
 		return nil, err
@@ -35,11 +35,28 @@ func (r *WithdrawalRepository) CreateOrder(ctx context.Context, order *models.Wi
 	return order, nil
 }
 
+const withdrawalOrderColumns = `id, user_id, amount, network_fee, platform_fee, actual_amount,
+	chain_type, coin_type, to_address, safeheron_order_id, transaction_hash,
+	COALESCE(request_id, ''), status, failure_reason,
+	created_at, sent_at, confirmed_at, completed_at, updated_at`
+
+func scanWithdrawalOrder(row rowScanner) (*models.WithdrawalOrder, error) {
+	var o models.WithdrawalOrder
+	err := row.Scan(
+		&o.ID, &o.UserID, &o.Amount, &o.NetworkFee, &o.PlatformFee, &o.ActualAmount,
+		&o.ChainType, &o.CoinType, &o.ToAddress, &o.SafeheronOrderID, &o.TransactionHash,
+		&o.RequestID, &o.Status, &o.FailureReason,
+		&o.CreatedAt, &o.SentAt, &o.ConfirmedAt, &o.CompletedAt, &o.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
 func (r *WithdrawalRepository) GetOrdersByUserID(ctx context.Context, userID int) ([]*models.WithdrawalOrder, error) {
 	rows, err := r.db.QueryContext(ctx,
-		`SELECT id, user_id, amount, network_fee, platform_fee, actual_amount,
-			chain_type, coin_type, to_address, safeheron_order_id, transaction_hash,
-			status, created_at, sent_at, confirmed_at, completed_at, updated_at
+		`SELECT `+withdrawalOrderColumns+`
 		FROM withdrawal_order WHERE user_id = $1 ORDER BY created_at DESC`,
 		userID)
 	if err != nil {
@@ -49,49 +66,74 @@ func (r *WithdrawalRepository) GetOrdersByUserID(ctx context.Context, userID int
 
 	orders := make([]*models.WithdrawalOrder, 0, 50)
 	for rows.Next() {
-		var o models.WithdrawalOrder
-		if err := rows.Scan(
-			&o.ID, &o.UserID, &o.Amount, &o.NetworkFee, &o.PlatformFee, &o.ActualAmount,
-			&o.ChainType, &o.CoinType, &o.ToAddress, &o.SafeheronOrderID, &o.TransactionHash,
-			&o.Status, &o.CreatedAt, &o.SentAt, &o.ConfirmedAt, &o.CompletedAt, &o.UpdatedAt,
-		); err != nil {
+		o, err := scanWithdrawalOrder(rows)
+		if err != nil {
 			return nil, err
 		}
-		orders = append(orders, &o)
+		orders = append(orders, o)
 	}
-	return orders, nil
+	return orders, rows.Err()
+}
+
+func (r *WithdrawalRepository) GetPendingOrdersBefore(ctx context.Context, before time.Time) ([]*models.WithdrawalOrder, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+withdrawalOrderColumns+`
+		FROM withdrawal_order WHERE status = $1 AND created_at < $2 ORDER BY id`,
+		string(models.WithdrawalStatusPending), before)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var orders []*models.WithdrawalOrder
+	for rows.Next() {
+		o, err := scanWithdrawalOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, o)
+	}
+	return orders, rows.Err()
 }
 
 func (r *WithdrawalRepository) GetOrderByID(ctx context.Context, id int) (*models.WithdrawalOrder, error) {
-	var o models.WithdrawalOrder
-	err := r.db.QueryRowContext(ctx,
-		`SELECT id, user_id, amount, network_fee, platform_fee, actual_amount,
-			chain_type, coin_type, to_address, safeheron_order_id, transaction_hash,
-			status, created_at, sent_at, confirmed_at, completed_at, updated_at
-		FROM withdrawal_order WHERE id = $1`,
-		id).Scan(
-		&o.ID, &o.UserID, &o.Amount, &o.NetworkFee, &o.PlatformFee, &o.ActualAmount,
-		&o.ChainType, &o.CoinType, &o.ToAddress, &o.SafeheronOrderID, &o.TransactionHash,
-		&o.Status, &o.CreatedAt, &o.SentAt, &o.ConfirmedAt, &o.CompletedAt, &o.UpdatedAt,
-	)
+	o, err := scanWithdrawalOrder(r.db.QueryRowContext(ctx,
+		`SELECT `+withdrawalOrderColumns+` FROM withdrawal_order WHERE id = $1`, id))
 	if err == sql.ErrNoRows {
 		return nil, repository.ErrNotFound
 	}
-	if err != nil {
-		return nil, err
+	return o, err
+}
+
+func (r *WithdrawalRepository) GetOrderByRequestID(ctx context.Context, requestID string) (*models.WithdrawalOrder, error) {
+	o, err := scanWithdrawalOrder(r.db.QueryRowContext(ctx,
+		`SELECT `+withdrawalOrderColumns+` FROM withdrawal_order WHERE request_id = $1`, requestID))
+	if err == sql.ErrNoRows {
+		return nil, repository.ErrNotFound
 	}
-	return &o, nil
+	return o, err
 }
 
-func (r *WithdrawalRepository) UpdateOrder(ctx context.Context, order *models.WithdrawalOrder) error {
-	_, err := r.db.ExecContext(ctx,
+func (r *WithdrawalRepository) TransitionOrder(ctx context.Context, order *models.WithdrawalOrder, from models.WithdrawalStatus) error {
+	result, err := r.db.ExecContext(ctx,
 		`UPDATE withdrawal_order SET
-			status = $1, transaction_hash = $2, sent_at = $3,
-			confirmed_at = $4, completed_at = $5, updated_at = $6
-		WHERE id = $7`,
-		order.Status, order.TransactionHash, order.SentAt,
-		order.ConfirmedAt, order.CompletedAt, time.Now(), order.ID)
-	return err
+			status = $1, network_fee = $2, safeheron_order_id = $3, transaction_hash = $4,
+			failure_reason = $5, sent_at = $6, confirmed_at = $7, completed_at = $8, updated_at = NOW()
+		WHERE id = $9 AND status = $10`,
+		order.Status, order.NetworkFee, order.SafeheronOrderID, order.TransactionHash,
+		order.FailureReason, order.SentAt, order.ConfirmedAt, order.CompletedAt,
+		order.ID, string(from))
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
 }
 
 func (r *WithdrawalRepository) CreateRequest(ctx context.Context, req *models.WithdrawalRequest) error {
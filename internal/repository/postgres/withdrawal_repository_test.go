@@ -8,6 +8,7 @@ import (
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/assert"
 	"monera-digital/internal/models"
+	"monera-digital/internal/repository"
 )
 
 func TestWithdrawalRepository_CreateOrder(t *testing.T) {
@@ -34,7 +35,7 @@ func TestWithdrawalRepository_CreateOrder(t *testing.T) {
 	mock.ExpectQuery("INSERT INTO withdrawal_order").
 		WithArgs(order.UserID, order.Amount, order.NetworkFee, order.PlatformFee, order.ActualAmount,
 			order.ChainType, order.CoinType, order.ToAddress, order.SafeheronOrderID, order.TransactionHash,
-			order.Status, sqlmock.AnyArg(), sqlmock.AnyArg()).
+			order.RequestID, order.Status, sqlmock.AnyArg(), sqlmock.AnyArg()).
 		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(1, time.Now()))
 
 	createdOrder, err := repo.CreateOrder(context.Background(), order)
@@ -47,25 +48,70 @@ func TestWithdrawalRepository_CreateOrder(t *testing.T) {
 	}
 }
 
-func TestAccountRepository_UpdateFrozenBalance(t *testing.T) {
+func TestWithdrawalRepository_TransitionOrder(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
 		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
 	}
 	defer db.Close()
 
-	repo := NewAccountRepositoryV1(db)
-
-	userID := 1
-	amount := 100.0
+	repo := NewWithdrawalRepository(db)
+	order := &models.WithdrawalOrder{ID: 7, Status: string(models.WithdrawalStatusCompleted), NetworkFee: "1.0"}
 
-	// Test case: Success
-	mock.ExpectExec("UPDATE account SET frozen_balance = frozen_balance \\+ \\$1, version = version \\+ 1, updated_at = \\$3 WHERE user_id = \\$2").
-		WithArgs(amount, userID, sqlmock.AnyArg()).
+	mock.ExpectExec("UPDATE withdrawal_order SET").
+		WithArgs(order.Status, order.NetworkFee, order.SafeheronOrderID, order.TransactionHash,
+			order.FailureReason, order.SentAt, order.ConfirmedAt, order.CompletedAt, 7, "PROCESSING").
 		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE withdrawal_order SET").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	assert.NoError(t, repo.TransitionOrder(context.Background(), order, models.WithdrawalStatusProcessing))
+	// 状态已被其他回调推进
+	assert.ErrorIs(t, repo.TransitionOrder(context.Background(), order, models.WithdrawalStatusProcessing), repository.ErrNotFound)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestWithdrawalRepository_GetOrderByRequestID_NotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewWithdrawalRepository(db)
+
+	mock.ExpectQuery("SELECT (.+) FROM withdrawal_order WHERE request_id = \\$1").
+		WithArgs("req-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	_, err = repo.GetOrderByRequestID(context.Background(), "req-1")
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestWithdrawalRepository_GetPendingOrdersBefore(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewWithdrawalRepository(db)
+	before := time.Date(2026, 3, 10, 1, 50, 0, 0, time.UTC)
+
+	mock.ExpectQuery("SELECT (.+) FROM withdrawal_order WHERE status = \\$1 AND created_at < \\$2").
+		WithArgs("PENDING", before).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
 
-	err = repo.UpdateFrozenBalance(context.Background(), userID, amount)
+	orders, err := repo.GetPendingOrdersBefore(context.Background(), before)
 	assert.NoError(t, err)
+	assert.Empty(t, orders)
 
 	if err := mock.ExpectationsWereMet(); err != nil {
 		t.Errorf("there were unfulfilled expectations: %s", err)
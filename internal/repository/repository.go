@@ -5,7 +5,11 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"monera-digital/internal/accrual"
+	"monera-digital/internal/config"
 	"monera-digital/internal/models"
+	"monera-digital/internal/money"
+	"time"
 )
 
 // User 用户仓储接口
@@ -17,21 +21,155 @@ type User interface {
 	Delete(ctx context.Context, id int) error
 }
 
-// Account 账户仓储接口
-type Account interface {
-	GetByUserIDAndType(ctx context.Context, userID int, accountType string) (*models.Account, error)
-	Create(ctx context.Context, account *models.Account) error
-	UpdateFrozenBalance(ctx context.Context, userID int, amount float64) error  // Add to frozen
-	ReleaseFrozenBalance(ctx context.Context, userID int, amount float64) error // Remove from frozen
-	DeductBalance(ctx context.Context, userID int, amount float64) error        // Deduct from balance and frozen
-}
-
 // Lending 借贷仓储接口
 type Lending interface {
-	CreatePosition(ctx context.Context, position *models.LendingPosition) (*models.LendingPosition, error)
-	GetPositionsByUserID(ctx context.Context, userID int) ([]*models.LendingPosition, error)
-	GetPositionByID(ctx context.Context, id int) (*models.LendingPosition, error)
-	UpdatePosition(ctx context.Context, position *models.LendingPosition) error
+	CreatePosition(ctx context.Context, position *LendingPositionModel) error
+	GetPositionsByUserID(ctx context.Context, userID int64) ([]*LendingPositionModel, error)
+	GetPositionByID(ctx context.Context, id int64) (*LendingPositionModel, error)
+	GetActivePositions(ctx context.Context) ([]*LendingPositionModel, error)
+	// AccrueYield 记入 date 当日的收益；已入账或仓位已不在持有中时返回 ErrAlreadyExists
+	AccrueYield(ctx context.Context, positionID int64, amount money.Decimal, date string) error
+	// ClosePosition 将持有中的仓位置为 status 并记录实付收益；仓位已不在持有中时返回 ErrNotFound
+	ClosePosition(ctx context.Context, positionID int64, status models.LendingStatus, yieldPaid money.Decimal) error
+	// GetRates returns, for every asset, the duration buckets in effect on date
+	// (the latest effective_date not after it), ordered by asset and bucket.
+	GetRates(ctx context.Context, date string) ([]*LendingRateModel, error)
+	ListRates(ctx context.Context, asset string) ([]*LendingRateModel, error)
+	// SaveRates replaces the buckets scheduled for asset on effectiveDate
+	SaveRates(ctx context.Context, asset string, effectiveDate string, rates []*LendingRateModel) error
+}
+
+// LendingPositionModel 借贷仓位
+type LendingPositionModel struct {
+	ID              int64
+	UserID          int64
+	Asset           string
+	Amount          money.Decimal
+	DurationDays    int
+	APY             money.Decimal
+	Status          models.LendingStatus
+	AccruedYield    money.Decimal
+	YieldPaid       money.Decimal
+	LastAccrualDate string
+	StartDate       time.Time
+	EndDate         time.Time
+	ClosedAt        *time.Time
+}
+
+// Calendar 借贷仓位按系统默认时区的自然日计息
+func (p *LendingPositionModel) Calendar() accrual.Calendar {
+	return LendingCalendar()
+}
+
+// LendingCalendar 借贷业务的营业日规则：系统默认时区，零点切日
+func LendingCalendar() accrual.Calendar {
+	return businessCalendar("", "")
+}
+
+// PlatformCalendar 平台级限额（如转账日限额）的营业日规则：系统默认时区，零点切日
+func PlatformCalendar() accrual.Calendar {
+	return businessCalendar("", "")
+}
+
+// LendingRateModel 借贷利率曲线的一个期限档，按生效日期保留历史。
+// 仓位开立时锁定当时的 APY，之后的调价不影响已有仓位。
+type LendingRateModel struct {
+	ID              int64
+	Asset           string
+	MinDurationDays int // 本档适用期限的下限（天）
+	APY             money.Decimal
+	EffectiveDate   string
+	CreatedAt       string
+}
+
+// Term 返回计息的起止营业日：收益在 (start, end] 内的每个营业日入账，共 DurationDays 天
+func (p *LendingPositionModel) Term() (start, end time.Time) {
+	start = p.Calendar().BusinessDate(p.StartDate)
+	return start, start.AddDate(0, 0, p.DurationDays)
+}
+
+// Loan 抵押借贷仓储接口
+type Loan interface {
+	CreateLoan(ctx context.Context, loan *LoanModel) error
+	GetLoanByID(ctx context.Context, id int64) (*LoanModel, error)
+	GetLoansByUserID(ctx context.Context, userID int64) ([]*LoanModel, error)
+	// GetOpenLoans 返回未结清（ACTIVE 与 MARGIN_CALL）的借款
+	GetOpenLoans(ctx context.Context) ([]*LoanModel, error)
+	// SetLoanStatus 将状态为 from 的借款置为 to；状态已变化时返回 ErrNotFound
+	SetLoanStatus(ctx context.Context, id int64, from, to LoanStatus) error
+	// AddCollateral 追加未结清借款的抵押物；借款已结清时返回 ErrNotFound
+	AddCollateral(ctx context.Context, id int64, amount money.Decimal) error
+	// CloseLoan 以 status 结清借款并记录清算数据。借款已结清或抵押物数量
+	// 与 loan.CollateralAmount 不一致（期间被追加）时返回 ErrNotFound。
+	CloseLoan(ctx context.Context, loan *LoanModel, status LoanStatus) error
+}
+
+// LoanStatus 借款状态
+type LoanStatus string
+
+const (
+	LoanStatusActive     LoanStatus = "ACTIVE"
+	LoanStatusMarginCall LoanStatus = "MARGIN_CALL"
+	LoanStatusRepaid     LoanStatus = "REPAID"
+	LoanStatusLiquidated LoanStatus = "LIQUIDATED"
+)
+
+// LoanModel 以加密资产抵押借入稳定币的借款。
+// LTV thresholds are ratios (0.8 = 80%) fixed when the loan is opened.
+type LoanModel struct {
+	ID                 int64
+	UserID             int64
+	CollateralAsset    string
+	CollateralAmount   money.Decimal
+	BorrowAsset        string
+	Principal          money.Decimal
+	MarginCallLTV      money.Decimal
+	LiquidationLTV     money.Decimal
+	LiquidationPenalty money.Decimal
+	Status             LoanStatus
+	// LiquidatedCollateral/LiquidationPrice 清算时扣划的抵押物数量与使用的价格
+	LiquidatedCollateral money.Decimal
+	LiquidationPrice     money.Decimal
+	CreatedAt            time.Time
+	ClosedAt             *time.Time
+}
+
+// LTV 返回按抵押物价格 price 计算的借款价值比，向上取 6 位小数；抵押物价值非正时 ok 为 false
+func (l *LoanModel) LTV(price money.Decimal) (ltv money.Decimal, ok bool) {
+	value := l.CollateralAmount.Mul(price)
+	if !value.IsPositive() {
+		return money.Zero, false
+	}
+	return l.Principal.Div(value, 6, money.RoundUp), true
+}
+
+// IsOpen 借款是否尚未结清
+func (l *LoanModel) IsOpen() bool {
+	return l.Status == LoanStatusActive || l.Status == LoanStatusMarginCall
+}
+
+// P2PTransfer 用户间站内转账仓储接口
+type P2PTransfer interface {
+	// CreateTransfer 记录转账；同一发送方的幂等键已使用时返回 ErrAlreadyExists
+	CreateTransfer(ctx context.Context, transfer *P2PTransferModel) error
+	// GetTransferByKey 按发送方与幂等键查找转账；不存在时返回 ErrNotFound
+	GetTransferByKey(ctx context.Context, senderID int64, idempotencyKey string) (*P2PTransferModel, error)
+	// GetTransfersByUserID 返回用户发出或收到的转账，按时间倒序
+	GetTransfersByUserID(ctx context.Context, userID int64, limit, offset int) ([]*P2PTransferModel, error)
+	// SumSentSince 返回发送方自 since 起已转出的该币种总额
+	SumSentSince(ctx context.Context, senderID int64, currency string, since time.Time) (money.Decimal, error)
+}
+
+// P2PTransferModel 站内转账记录，资金在同一事务内完成划转
+type P2PTransferModel struct {
+	ID             int64
+	TransferNo     string
+	SenderID       int64
+	ReceiverID     int64
+	Currency       string
+	Amount         money.Decimal
+	IdempotencyKey string
+	CreatedAt      time.Time
 }
 
 // Address 地址仓储接口
@@ -50,7 +188,13 @@ type Withdrawal interface {
 	CreateOrder(ctx context.Context, order *models.WithdrawalOrder) (*models.WithdrawalOrder, error)
 	GetOrdersByUserID(ctx context.Context, userID int) ([]*models.WithdrawalOrder, error)
 	GetOrderByID(ctx context.Context, id int) (*models.WithdrawalOrder, error)
-	UpdateOrder(ctx context.Context, order *models.WithdrawalOrder) error
+	// GetOrderByRequestID 按提交托管时的请求号查找订单；不存在时返回 ErrNotFound
+	GetOrderByRequestID(ctx context.Context, requestID string) (*models.WithdrawalOrder, error)
+	// GetPendingOrdersBefore 返回创建时间早于 before 仍为 PENDING 的订单，供对账任务按 RequestID 查询托管方
+	GetPendingOrdersBefore(ctx context.Context, before time.Time) ([]*models.WithdrawalOrder, error)
+	// TransitionOrder 在订单状态仍为 from 时写入 order 的状态、托管信息与时间戳；
+	// 状态已被修改时返回 ErrNotFound
+	TransitionOrder(ctx context.Context, order *models.WithdrawalOrder, from models.WithdrawalStatus) error
 	CreateRequest(ctx context.Context, request *models.WithdrawalRequest) error
 	GetRequestByID(ctx context.Context, requestID string) (*models.WithdrawalRequest, error)
 }
@@ -84,19 +228,65 @@ type Wallet interface {
 
 // Wealth 理财仓储接口
 type Wealth interface {
+	// GetActiveProducts 返回在售且申购窗口未结束的产品
 	GetActiveProducts(ctx context.Context) ([]*WealthProductModel, error)
+	// ListProducts 返回全部产品（含暂停、下架），供后台管理
+	ListProducts(ctx context.Context) ([]*WealthProductModel, error)
 	GetProductByID(ctx context.Context, id int64) (*WealthProductModel, error)
+	CreateProduct(ctx context.Context, product *WealthProductModel) error
+	// UpdateProduct 修改产品的可编辑条款；币种、类型、已售额度不在此修改。
+	// 写入时已售额度超过新的总额度返回 ErrQuotaExceeded
+	UpdateProduct(ctx context.Context, product *WealthProductModel) error
+	UpdateProductStatus(ctx context.Context, id int64, status int) error
 	CreateOrder(ctx context.Context, order *WealthOrderModel) error
 	GetOrdersByUserID(ctx context.Context, userID int64) ([]*WealthOrderModel, error)
 	GetOrderByID(ctx context.Context, id int64) (*WealthOrderModel, error)
+	// GetOrderForUpdate reads an order and locks it until the surrounding
+	// transaction ends; use it inside a UnitOfWork before read-modify-write.
+	GetOrderForUpdate(ctx context.Context, id int64) (*WealthOrderModel, error)
 	UpdateOrder(ctx context.Context, order *WealthOrderModel) error
-	UpdateProductSoldQuota(ctx context.Context, id int64, amount string) error
+	// ReserveProductQuota atomically adds amount to the sold quota, or returns
+	// ErrQuotaExceeded when that would exceed the total quota
+	ReserveProductQuota(ctx context.Context, id int64, amount money.Decimal) error
+	// ReleaseProductQuota gives amount of sold quota back to the product
+	ReleaseProductQuota(ctx context.Context, id int64, amount money.Decimal) error
+	// AddPrincipal 活期追加本金，当日生效
+	AddPrincipal(ctx context.Context, orderID int64, amount money.Decimal) error
+	// UpdateRenewalOptions 修改未结清订单的续期设置
+	UpdateRenewalOptions(ctx context.Context, orderID int64, autoRenew, compound bool) error
+	// CreateOrderAudit 记录订单条款变更，与变更在同一事务内写入
+	CreateOrderAudit(ctx context.Context, audit *WealthOrderAuditModel) error
+	// CancelOrder 将持有中的订单标记为已撤单；订单已不在持有中时返回 ErrNotFound
+	CancelOrder(ctx context.Context, orderID int64) error
 	GetActiveOrders(ctx context.Context) ([]*WealthOrderModel, error)
-	GetExpiredOrders(ctx context.Context) ([]*WealthOrderModel, error)
-	UpdateInterestAccrued(ctx context.Context, orderID int64, interestAccrued string) error
-	AccrueInterest(ctx context.Context, orderID int64, amount string, date string) error
-	SettleOrder(ctx context.Context, orderID int64, interestPaid string) error
-	RenewOrder(ctx context.Context, order *WealthOrderModel, product *WealthProductModel, startDate string, endDate string) (*WealthOrderModel, error)
+	// GetExpiredOrders 返回到期日不晚于 asOf (YYYY-MM-DD) 的持有中订单
+	GetExpiredOrders(ctx context.Context, asOf string) ([]*WealthOrderModel, error)
+	// AccrueInterest books one day of interest for an order: it inserts the
+	// ledger row and advances interest_accrued and last_interest_date.
+	// Returns ErrAlreadyExists when the date has already been accrued.
+	AccrueInterest(ctx context.Context, orderID int64, amount money.Decimal, date string) error
+	GetInterestRecords(ctx context.Context, orderID int64) ([]*InterestRecordModel, error)
+	// SettleOrder marks an active order settled. Returns ErrNotFound when the
+	// order is no longer active (redeemed, cancelled or settled concurrently).
+	SettleOrder(ctx context.Context, orderID int64, interestPaid money.Decimal) error
+	// RenewOrder creates the follow-up order for principal (the remaining
+	// principal, plus the accrued interest when compounding) and links both orders.
+	// interestExpected is priced by the caller from the effective-dated rate
+	// history; renewedAt stamps the new order and the link on the old one.
+	RenewOrder(ctx context.Context, order *WealthOrderModel, product *WealthProductModel, principal, interestExpected money.Decimal, startDate, endDate string, renewedAt time.Time) (*WealthOrderModel, error)
+	// GetProductRates returns the APY tiers in effect on date (the latest
+	// effective_date not after it), ordered by tier floor. Empty when none.
+	GetProductRates(ctx context.Context, productID int64, date string) ([]*WealthRateModel, error)
+	ListProductRates(ctx context.Context, productID int64) ([]*WealthRateModel, error)
+	// SaveProductRates replaces the tiers scheduled for effectiveDate
+	SaveProductRates(ctx context.Context, productID int64, effectiveDate string, rates []*WealthRateModel) error
+	// CreateQuote 保存服务端签发的申购报价
+	CreateQuote(ctx context.Context, quote *WealthQuoteModel) error
+	GetQuote(ctx context.Context, id string) (*WealthQuoteModel, error)
+	// UseQuote atomically marks the user's quote as used. Returns ErrNotFound
+	// when the quote does not exist, belongs to someone else, has already been
+	// used or has expired.
+	UseQuote(ctx context.Context, id string, userID int64) error
 }
 
 // WealthProductModel 理财产品模型
@@ -104,71 +294,301 @@ type WealthProductModel struct {
 	ID               int64
 	Title            string
 	Currency         string
-	APY              string
-	Duration         int
-	MinAmount        string
-	MaxAmount        string
-	TotalQuota       string
-	SoldQuota        string
+	APY              money.Decimal
+	Duration         int // 活期产品为 0
+	ProductType      int
+	MinAmount        money.Decimal
+	MaxAmount        money.Decimal
+	TotalQuota       money.Decimal
+	SoldQuota        money.Decimal
 	Status           int
 	AutoRenewAllowed bool
-	CreatedAt        string
-	UpdatedAt        string
+	// EarlyRedeemRule 提前赎回规则，EarlyRedeemValue 含义随规则而定
+	EarlyRedeemRule  int
+	EarlyRedeemValue money.Decimal
+	// SubscribeStartAt/SubscribeEndAt 申购窗口，nil 表示不限
+	SubscribeStartAt *time.Time
+	SubscribeEndAt   *time.Time
+	// SettlementTimezone/CutoffTime/DayCount 营业日规则与计息基准，创建后不可修改
+	SettlementTimezone string // IANA 时区名，为空时使用系统默认时区
+	CutoffTime         string // 日切时间 HH:MM，为空表示当地零点
+	DayCount           string // 见 accrual.DayCount，为空时为 ACT/365
+	CreatedAt          string
+	UpdatedAt          string
+}
+
+// Calendar 产品的营业日规则
+func (p *WealthProductModel) Calendar() accrual.Calendar {
+	return businessCalendar(p.SettlementTimezone, p.CutoffTime)
+}
+
+// Basis 产品的计息基准
+func (p *WealthProductModel) Basis() accrual.DayCount {
+	return accrual.DayCount(p.DayCount)
 }
 
+// TiersOn 从按生效日期倒序的利率历史中取出 date 生效的档位，
+// 与计息任务的取数规则一致；没有利率历史时使用产品的静态 APY
+func (p *WealthProductModel) TiersOn(rates []*WealthRateModel, date string) []accrual.Tier {
+	var tiers []accrual.Tier
+	effective := ""
+	for _, rate := range rates {
+		if rate.EffectiveDate > date {
+			continue
+		}
+		if effective == "" {
+			effective = rate.EffectiveDate
+		}
+		if rate.EffectiveDate != effective {
+			break
+		}
+		tiers = append(tiers, accrual.Tier{Floor: rate.TierFloor, APY: rate.APY})
+	}
+	if len(tiers) == 0 {
+		return []accrual.Tier{{Floor: money.Zero, APY: p.APY}}
+	}
+	return tiers
+}
+
+// TermInterest 自 start 起逐日按当日生效的分档利率试算 principal 一个期限的利息，
+// 计息日为 [start, start+Duration)
+func (p *WealthProductModel) TermInterest(principal money.Decimal, rates []*WealthRateModel, start time.Time) money.Decimal {
+	total := money.Zero
+	for i := 0; i < p.Duration; i++ {
+		day := start.AddDate(0, 0, i).Format(accrual.DateLayout)
+		total = total.Add(accrual.TieredDailyInterest(p.Currency, principal, p.TiersOn(rates, day), p.Basis()))
+	}
+	return total
+}
+
+// businessCalendar 构造营业日规则；配置在创建产品时已校验，解析失败时按零点日切处理
+func businessCalendar(timezone, cutoff string) accrual.Calendar {
+	c, _ := accrual.ParseCutoff(cutoff)
+	return accrual.Calendar{Location: config.GetLocationWithTimezone(timezone), Cutoff: c}
+}
+
+// InSubscribeWindow 判断 t 是否处于申购窗口内
+func (p *WealthProductModel) InSubscribeWindow(t time.Time) bool {
+	if p.SubscribeStartAt != nil && t.Before(*p.SubscribeStartAt) {
+		return false
+	}
+	if p.SubscribeEndAt != nil && !t.Before(*p.SubscribeEndAt) {
+		return false
+	}
+	return true
+}
+
+// 理财产品状态
+const (
+	WealthProductStatusActive  = 1 // 在售
+	WealthProductStatusPaused  = 2 // 暂停申购，存量订单照常计息、到期
+	WealthProductStatusRetired = 3 // 下架，不再申购和续期
+)
+
+// 理财产品类型
+const (
+	WealthProductTypeFixed    = 1 // 定期：次日起息，到期结算
+	WealthProductTypeFlexible = 2 // 活期：无到期日，当日起息，随时存取
+)
+
+// 提前赎回规则
+const (
+	EarlyRedeemForfeitInterest = 1 // 没收全部已计利息
+	EarlyRedeemKeepPercent     = 2 // 保留已计利息的 EarlyRedeemValue%
+	EarlyRedeemFlatFee         = 3 // 利息照付，扣除固定手续费 EarlyRedeemValue
+)
+
+// 理财订单状态
+const (
+	WealthOrderStatusActive    = 1 // 持有中
+	WealthOrderStatusSettled   = 3 // 到期结算或到期后赎回
+	WealthOrderStatusRedeemed  = 4 // 提前赎回
+	WealthOrderStatusCancelled = 5 // 起息前撤单，本金全额退回
+)
+
 // WealthOrderModel 理财订单模型
 type WealthOrderModel struct {
-	ID                 int64
-	UserID             int64
-	ProductID          int64
-	ProductTitle       string
-	Currency           string
-	Amount             string
-	Duration           int64
-	PrincipalRedeemed  string
-	InterestExpected   string
-	InterestPaid       string
-	InterestAccrued    string
-	StartDate          string
-	EndDate            string
-	LastInterestDate   string
-	AutoRenew          bool
+	ID                int64
+	UserID            int64
+	ProductID         int64
+	ProductTitle      string
+	Currency          string
+	ProductType       int
+	Amount            money.Decimal
+	Duration          int64
+	PrincipalRedeemed money.Decimal
+	InterestExpected  money.Decimal
+	InterestPaid      money.Decimal
+	InterestAccrued   money.Decimal
+	StartDate         string
+	EndDate           string // 活期订单为空
+	LastInterestDate  string
+	AutoRenew         bool
+	AutoRenewCompound bool // 续期时利息滚入新订单本金
+	// EarlyRedeemRule/EarlyRedeemValue 下单时产品的提前赎回条款快照
+	EarlyRedeemRule    int
+	EarlyRedeemValue   money.Decimal
 	Status             int
 	RenewedFromOrderID *int64
 	RenewedToOrderID   *int64
 	RedeemedAt         string
-	RedemptionAmount   string
+	RedemptionAmount   money.Decimal
 	RedemptionType     sql.NullString
+	// SettlementTimezone/CutoffTime/DayCount 取自所属产品
+	SettlementTimezone string
+	CutoffTime         string
+	DayCount           string
 	CreatedAt          string
 	UpdatedAt          string
 }
 
+// Calendar 订单所属产品的营业日规则
+func (o *WealthOrderModel) Calendar() accrual.Calendar {
+	return businessCalendar(o.SettlementTimezone, o.CutoffTime)
+}
+
+// Basis 订单所属产品的计息基准
+func (o *WealthOrderModel) Basis() accrual.DayCount {
+	return accrual.DayCount(o.DayCount)
+}
+
+// RemainingPrincipal 部分赎回后仍在计息的本金
+func (o *WealthOrderModel) RemainingPrincipal() money.Decimal {
+	return o.Amount.Sub(o.PrincipalRedeemed)
+}
+
+// IsFlexible 活期订单没有到期日
+func (o *WealthOrderModel) IsFlexible() bool {
+	return o.ProductType == WealthProductTypeFlexible
+}
+
+// 利息记录类型
+const (
+	InterestRecordTypeAccrual = 1 // 每日计息
+)
+
+// InterestRecordModel 每日利息流水
+type InterestRecordModel struct {
+	ID        int64
+	OrderID   int64
+	Amount    money.Decimal
+	Type      int
+	Date      string
+	CreatedAt string
+}
+
+// WealthRateModel 理财产品利率档位，按生效日期保留历史
+type WealthRateModel struct {
+	ID            int64
+	ProductID     int64
+	EffectiveDate string
+	TierFloor     money.Decimal // 本档适用本金的下限，首档为 0
+	APY           money.Decimal
+	CreatedAt     string
+}
+
+// WealthOrderAuditModel 订单条款变更的审计记录，写入 audit_trail。
+// OldValue/NewValue 为变更前后条款的 JSON
+type WealthOrderAuditModel struct {
+	ID        int64
+	OrderID   int64
+	UserID    int64
+	Action    string
+	OldValue  string
+	NewValue  string
+	CreatedAt string
+}
+
+// 订单条款变更的审计动作
+const (
+	WealthAuditAutoRenew         = "WEALTH_ORDER_AUTO_RENEW"
+	WealthAuditAutoRenewCompound = "WEALTH_ORDER_AUTO_RENEW_COMPOUND"
+)
+
+// WealthQuoteModel 申购报价：服务端按计息规则算出的预期收益，短时有效且只能使用一次
+type WealthQuoteModel struct {
+	ID               string
+	UserID           int64
+	ProductID        int64
+	Amount           money.Decimal
+	InterestExpected money.Decimal
+	StartDate        string
+	EndDate          string // 活期产品为空
+	ExpiresAt        time.Time
+	UsedAt           *time.Time
+	CreatedAt        string
+}
+
+// 账户类型：资金账户用于充提与交易，理财账户用于投资
+const (
+	AccountTypeFund   = "FUND"
+	AccountTypeWealth = "WEALTH"
+)
+
 // AccountV2 账户仓储接口 (详细版本)
 type AccountV2 interface {
+	// GetAccountByUserIDAndCurrency 返回用户该币种的资金账户
 	GetAccountByUserIDAndCurrency(ctx context.Context, userID int64, currency string) (*AccountModel, error)
+	// GetAccountsByUserID 返回用户全部类型的账户，按币种、类型排列
 	GetAccountsByUserID(ctx context.Context, userID int64) ([]*AccountModel, error)
-	FreezeBalance(ctx context.Context, accountID int64, amount string) error
-	UnfreezeBalance(ctx context.Context, accountID int64, amount string) error
-	DeductBalance(ctx context.Context, accountID int64, amount string) error
-	AddBalance(ctx context.Context, accountID int64, amount string) error
+	// GetAccountByType 返回用户指定类型与币种的账户；不存在时返回 ErrNotFound
+	GetAccountByType(ctx context.Context, userID int64, accountType, currency string) (*AccountModel, error)
+	// CreateAccount 开立零余额账户；同类型同币种账户已存在时返回 ErrAlreadyExists
+	CreateAccount(ctx context.Context, account *AccountModel) error
+	// UpdateBalance 在版本号仍为 version 时将余额变动 delta；
+	// 账户已被并发修改时返回 ErrVersionConflict
+	UpdateBalance(ctx context.Context, accountID int64, version int64, delta money.Decimal) error
+	// 以下四个方法按版本号比较并更新，遇并发修改自动重读重试，重试耗尽返回 ErrVersionConflict；
+	// 结果会使余额为负或冻结额超过余额时返回 ErrInsufficientBalance，账户保持不变
+	FreezeBalance(ctx context.Context, accountID int64, amount money.Decimal) error
+	UnfreezeBalance(ctx context.Context, accountID int64, amount money.Decimal) error
+	DeductBalance(ctx context.Context, accountID int64, amount money.Decimal) error
+	AddBalance(ctx context.Context, accountID int64, amount money.Decimal) error
+	// CreateTransferRecord 写入账户间划转记录；TransferID 重复时返回 ErrAlreadyExists
+	CreateTransferRecord(ctx context.Context, record *TransferRecordModel) error
 }
 
+// TransferRecordModel 同一用户账户间的划转记录，对应 transfer_record 表。
+// 余额变动仍以成对流水为准，本记录用于按划转单号查询
+type TransferRecordModel struct {
+	ID            int64
+	UserID        int64
+	TransferID    string
+	FromAccountID int64
+	ToAccountID   int64
+	Amount        money.Decimal
+	Status        string
+	CreatedAt     string
+	CompletedAt   string
+}
+
+// 划转记录状态；同步划转在事务内一次完成
+const TransferStatusCompleted = "COMPLETED"
+
 // AccountModel 账户模型
 type AccountModel struct {
 	ID            int64
 	UserID        int64
 	Type          string
 	Currency      string
-	Balance       string
-	FrozenBalance string
+	Balance       money.Decimal
+	FrozenBalance money.Decimal
 	Version       int64
 	CreatedAt     string
 	UpdatedAt     string
 }
 
+// Available returns balance minus frozen balance, never below zero
+func (a *AccountModel) Available() money.Decimal {
+	return money.Max(a.Balance.Sub(a.FrozenBalance), money.Zero)
+}
+
 // Journal 资金流水仓储接口
 type Journal interface {
 	CreateJournalRecord(ctx context.Context, record *JournalModel) error
+	// GetJournalRecordsByRef 返回用户关联到某笔业务单（如理财订单）且属于 bizTypes 的流水，按时间先后排列。
+	// ref_id 在理财、借贷、借款、转账、提现之间共用编号空间，需按业务类型区分
+	GetJournalRecordsByRef(ctx context.Context, userID, refID int64, bizTypes []string) ([]*JournalModel, error)
 }
 
 // JournalModel 资金流水模型
@@ -177,25 +597,96 @@ type JournalModel struct {
 	SerialNo        string
 	UserID          int64
 	AccountID       int64
-	Amount          string
-	BalanceSnapshot string
+	Amount          money.Decimal
+	BalanceSnapshot money.Decimal // 变动后的可用余额
 	BizType         string
 	RefID           *int64
 	CreatedAt       string
 }
 
+// SchedulerRun 调度任务运行记录与跨实例锁
+type SchedulerRun interface {
+	// TryLock 尝试获取任务级的跨实例锁；被其他实例持有时 acquired 为 false
+	TryLock(ctx context.Context, jobName string) (unlock func(), acquired bool, err error)
+	// GetLastSuccessDate 返回任务最近一次成功的业务日期，从未成功时返回空字符串
+	GetLastSuccessDate(ctx context.Context, jobName string) (string, error)
+	CreateRun(ctx context.Context, run *SchedulerRunModel) error
+	FinishRun(ctx context.Context, run *SchedulerRunModel) error
+	ListRuns(ctx context.Context, jobName string, limit int) ([]*SchedulerRunModel, error)
+}
+
+// 调度任务运行状态
+const (
+	SchedulerRunStatusRunning = "RUNNING"
+	SchedulerRunStatusSuccess = "SUCCESS"
+	SchedulerRunStatusFailed  = "FAILED"
+)
+
+// SchedulerRunModel 调度任务运行记录
+type SchedulerRunModel struct {
+	ID             int64
+	JobName        string
+	RunDate        string
+	Status         string
+	ItemsProcessed int
+	Detail         string
+	ErrorMessage   string
+	StartedAt      string
+	FinishedAt     string
+}
+
+// SchedulerJob 调度任务的持久化状态（暂停开关与最近一次运行结果）
+type SchedulerJob interface {
+	// GetJob 任务从未持久化过时返回 ErrNotFound
+	GetJob(ctx context.Context, name string) (*SchedulerJobModel, error)
+	SaveLastRun(ctx context.Context, job *SchedulerJobModel) error
+	SetPaused(ctx context.Context, name string, paused bool) error
+}
+
+// SchedulerJobModel 调度任务状态
+type SchedulerJobModel struct {
+	Name           string
+	Paused         bool
+	LastRunAt      string
+	LastStatus     string
+	LastError      string
+	LastDurationMs int64
+	UpdatedAt      string
+}
+
 // Repository 仓储容器
 type Repository struct {
 	User       User
-	Account    Account   // Legacy account interface
-	AccountV2  AccountV2 // New detailed account interface
+	AccountV2  AccountV2
 	Lending    Lending
+	Loan       Loan
+	P2P        P2PTransfer
 	Address    Address
 	Withdrawal Withdrawal
 	Deposit    Deposit
 	Wallet     Wallet
 	Wealth     Wealth
 	Journal    Journal
+	Scheduler  SchedulerRun
+	Jobs       SchedulerJob
+}
+
+// TxRepository 事务内可用的仓储集合，所有调用共享同一个数据库事务
+type TxRepository struct {
+	Wealth     Wealth
+	Lending    Lending
+	Loan       Loan
+	P2P        P2PTransfer
+	Withdrawal Withdrawal
+	Account    AccountV2
+	Journal    Journal
+}
+
+// UnitOfWork 工作单元
+// Do runs fn inside one transaction: it commits when fn returns nil and rolls
+// back when fn returns an error or panics.
+type UnitOfWork interface {
+	Do(ctx context.Context, fn func(tx *TxRepository) error) error
 }
 
 // Common errors
@@ -204,4 +695,6 @@ var (
 	ErrAlreadyExists       = errors.New("record already exists")
 	ErrInvalidInput        = errors.New("invalid input")
 	ErrInsufficientBalance = errors.New("insufficient balance")
+	ErrQuotaExceeded       = errors.New("product quota exceeded")
+	ErrVersionConflict     = errors.New("record modified concurrently")
 )
@@ -38,6 +38,27 @@ func SetupRoutes(router *gin.Engine, cont *container.Container) {
 	// Create 2FA handler
 	twofaHandler := handlers.NewTwoFAHandler(cont.TwoFAService)
 
+	// Create scheduled job handler
+	jobHandler := handlers.NewJobHandler(cont.JobRegistry)
+
+	// Create wealth admin handler
+	wealthAdminHandler := handlers.NewWealthAdminHandler(cont.WealthService)
+
+	// Create lending admin handler
+	lendingAdminHandler := handlers.NewLendingAdminHandler(cont.LendingService)
+
+	// Create crypto-backed loan handler
+	loanHandler := handlers.NewLoanHandler(cont.LoanService)
+
+	// Create account transfer handler
+	transferHandler := handlers.NewAccountTransferHandler(cont.TransferService)
+
+	// Create peer-to-peer transfer handler
+	p2pHandler := handlers.NewP2PTransferHandler(cont.P2PService)
+
+	// Create custody withdrawal callback handler
+	withdrawalCallbackHandler := handlers.NewWithdrawalCallbackHandler(cont.WithdrawalService)
+
 	// Root health check endpoint (backup)
 	router.GET("/health", func(c *gin.Context) {
 		c.JSON(200, gin.H{"status": "ok"})
@@ -68,10 +89,14 @@ func SetupRoutes(router *gin.Engine, cont *container.Container) {
 			auth.POST("/2fa/skip", h.Skip2FALogin)
 		}
 
+		// Lending rate curve (public, shown before login)
+		public.GET("/lending/rates", h.GetLendingRates)
+
 		// Webhook routes (public)
 		webhooks := public.Group("/webhooks")
 		{
 			webhooks.POST("/core/deposit", h.HandleDepositWebhook)
+			webhooks.POST("/custody/withdrawal", withdrawalCallbackHandler.Handle)
 		}
 	}
 
@@ -107,6 +132,29 @@ func SetupRoutes(router *gin.Engine, cont *container.Container) {
 		{
 			lending.POST("/apply", h.ApplyForLending)
 			lending.GET("/positions", h.GetUserPositions)
+			lending.POST("/positions/:id/terminate", h.TerminateLending)
+		}
+
+		// Internal account transfer routes
+		accounts := protected.Group("/accounts")
+		{
+			accounts.POST("/internal-transfer", transferHandler.Transfer)
+		}
+
+		// Peer-to-peer transfer routes
+		p2p := protected.Group("/p2p")
+		{
+			p2p.POST("/transfers", p2pHandler.Send)
+			p2p.GET("/transfers", p2pHandler.GetTransfers)
+		}
+
+		// Crypto-backed loan routes
+		loans := protected.Group("/loans")
+		{
+			loans.POST("", loanHandler.Borrow)
+			loans.GET("", loanHandler.GetLoans)
+			loans.POST("/:id/repay", loanHandler.Repay)
+			loans.POST("/:id/collateral", loanHandler.AddCollateral)
 		}
 
 		// Wallet routes
@@ -148,9 +196,49 @@ func SetupRoutes(router *gin.Engine, cont *container.Container) {
 		wealth := protected.Group("/wealth")
 		{
 			wealth.GET("/products", h.GetProducts)
+			wealth.POST("/subscribe/quote", h.SubscribeQuote)
 			wealth.POST("/subscribe", h.Subscribe)
+			wealth.GET("/portfolio", h.GetPortfolio)
 			wealth.GET("/orders", h.GetOrders)
+			wealth.GET("/orders/:id", h.GetOrderDetail)
+			wealth.GET("/orders/:id/interest", h.GetOrderInterest)
+			wealth.POST("/orders/:id/compound", h.SetOrderCompound)
+			wealth.POST("/orders/:id/auto-renew", h.SetOrderAutoRenew)
+			wealth.POST("/orders/:id/cancel", h.CancelOrder)
 			wealth.POST("/redeem", h.Redeem)
+			wealth.POST("/redeem/quote", h.RedeemQuote)
+		}
+
+		// Admin routes (configured admin emails only)
+		admin := protected.Group("/admin")
+		admin.Use(middleware.AdminMiddleware(cont.AdminEmails))
+		{
+			jobs := admin.Group("/jobs")
+			{
+				jobs.GET("", jobHandler.ListJobs)
+				jobs.GET("/:name/runs", jobHandler.GetJobRuns)
+				jobs.POST("/:name/trigger", jobHandler.TriggerJob)
+				jobs.POST("/:name/pause", jobHandler.PauseJob)
+				jobs.POST("/:name/resume", jobHandler.ResumeJob)
+			}
+
+			wealthAdmin := admin.Group("/wealth")
+			{
+				wealthAdmin.GET("/products", wealthAdminHandler.ListProducts)
+				wealthAdmin.POST("/products", wealthAdminHandler.CreateProduct)
+				wealthAdmin.PUT("/products/:id", wealthAdminHandler.UpdateProduct)
+				wealthAdmin.POST("/products/:id/pause", wealthAdminHandler.PauseProduct)
+				wealthAdmin.POST("/products/:id/resume", wealthAdminHandler.ResumeProduct)
+				wealthAdmin.POST("/products/:id/retire", wealthAdminHandler.RetireProduct)
+				wealthAdmin.GET("/products/:id/rates", wealthAdminHandler.GetProductRates)
+				wealthAdmin.POST("/products/:id/rates", wealthAdminHandler.SetProductRates)
+			}
+
+			lendingAdmin := admin.Group("/lending")
+			{
+				lendingAdmin.GET("/rates/:asset", lendingAdminHandler.GetRates)
+				lendingAdmin.POST("/rates/:asset", lendingAdminHandler.SetRates)
+			}
 		}
 	}
 }
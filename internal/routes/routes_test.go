@@ -0,0 +1,96 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"monera-digital/internal/clock"
+	"monera-digital/internal/container"
+	"monera-digital/internal/middleware"
+	"monera-digital/internal/repository/memory"
+	"monera-digital/internal/services"
+	"monera-digital/internal/utils"
+)
+
+const testJWTSecret = "routes-test-secret"
+
+var (
+	testRouter     *gin.Engine
+	testRouterOnce sync.Once
+)
+
+// newTestRouter 构造一次完整路由，swagger 文档只能全局注册一次
+func newTestRouter(t *testing.T) *gin.Engine {
+	testRouterOnce.Do(func() { testRouter = buildTestRouter() })
+	return testRouter
+}
+
+func buildTestRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	store := memory.NewStore(clock.System)
+	cont := &container.Container{
+		JWTSecret:   testJWTSecret,
+		RateLimiter: middleware.NewRateLimiter(1000, time.Minute),
+		AdminEmails: []string{"admin@example.com"},
+		WealthService: services.NewWealthService(
+			memory.NewWealthRepository(store),
+			memory.NewAccountRepository(store),
+			memory.NewJournalRepository(store),
+			memory.NewUnitOfWork(store),
+		),
+	}
+	router := gin.New()
+	SetupRoutes(router, cont)
+	return router
+}
+
+func authorized(t *testing.T, method, path, email string) *http.Request {
+	token, err := utils.GenerateJWT(1, email, testJWTSecret)
+	require.NoError(t, err)
+	req := httptest.NewRequest(method, path, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	return req
+}
+
+func TestSetupRoutes_WealthAdminProductLifecycle(t *testing.T) {
+	router := newTestRouter(t)
+
+	registered := make(map[string]bool)
+	for _, r := range router.Routes() {
+		registered[r.Method+" "+r.Path] = true
+	}
+	for _, route := range []string{
+		"GET /api/admin/wealth/products",
+		"POST /api/admin/wealth/products",
+		"PUT /api/admin/wealth/products/:id",
+		"POST /api/admin/wealth/products/:id/pause",
+		"POST /api/admin/wealth/products/:id/resume",
+		"POST /api/admin/wealth/products/:id/retire",
+	} {
+		assert.True(t, registered[route], "route %s not registered", route)
+	}
+}
+
+func TestSetupRoutes_WealthAdminProductsRequireAdmin(t *testing.T) {
+	router := newTestRouter(t)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, authorized(t, http.MethodGet, "/api/admin/wealth/products", "admin@example.com"))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "products")
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, authorized(t, http.MethodGet, "/api/admin/wealth/products", "user@example.com"))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, authorized(t, http.MethodPost, "/api/admin/wealth/products/999/pause", "admin@example.com"))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
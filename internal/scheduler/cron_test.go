@@ -0,0 +1,60 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCron_Invalid(t *testing.T) {
+	cases := []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+	}
+	for _, expr := range cases {
+		_, err := parseCron(expr)
+		assert.Error(t, err, expr)
+	}
+}
+
+func TestCronSchedule_Next(t *testing.T) {
+	from := time.Date(2026, 1, 10, 13, 32, 45, 0, time.UTC)
+
+	cases := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2026, 1, 10, 13, 33, 0, 0, time.UTC)},
+		{"*/5 * * * *", time.Date(2026, 1, 10, 13, 35, 0, 0, time.UTC)},
+		{"30 * * * *", time.Date(2026, 1, 10, 14, 30, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2026, 1, 10, 14, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2026, 1, 11, 0, 0, 0, 0, time.UTC)},
+		{"0 9-17/4 * * *", time.Date(2026, 1, 10, 17, 0, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)},
+		// 2026-01-10 是周六，下一个周一是 01-12
+		{"0 8 * * 1", time.Date(2026, 1, 12, 8, 0, 0, 0, time.UTC)},
+		// 日与周同时限定时任一匹配即触发
+		{"0 0 15 * 1", time.Date(2026, 1, 12, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.expr, func(t *testing.T) {
+			s, err := parseCron(tc.expr)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, s.Next(from))
+		})
+	}
+}
+
+func TestCronSchedule_Next_NeverFires(t *testing.T) {
+	s, err := parseCron("0 0 31 2 *")
+	assert.NoError(t, err)
+	assert.True(t, s.Next(time.Now()).IsZero())
+}
@@ -2,143 +2,277 @@ package scheduler
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"strconv"
 	"time"
 
+	"monera-digital/internal/accrual"
 	"monera-digital/internal/binance"
-	"monera-digital/internal/config"
+	"monera-digital/internal/clock"
 	"monera-digital/internal/logger"
+	"monera-digital/internal/money"
 	"monera-digital/internal/repository"
 )
 
+// InterestJobName 利息任务在运行记录与 advisory lock 中使用的名称
+const InterestJobName = "wealth_interest"
+
+var (
+	// ErrOrderNotActive 订单已被赎回、撤单或结算，本次结算跳过
+	ErrOrderNotActive = errors.New("order status is not active")
+	// ErrOrderChanged 订单在读取后被部分赎回或计息，本次结算跳过，下次运行重新读取
+	ErrOrderChanged = errors.New("order changed during settlement")
+)
+
 type InterestScheduler struct {
 	repo         repository.Wealth
 	accountRepo  repository.AccountV2
 	journalRepo  repository.Journal
+	runRepo      repository.SchedulerRun
+	uow          repository.UnitOfWork
 	priceService *binance.PriceService
 	metrics      *SchedulerMetrics
+	clock        clock.Clock
 }
 
-func NewInterestScheduler(wealthRepo repository.Wealth, accountRepo repository.AccountV2, journalRepo repository.Journal) *InterestScheduler {
+func NewInterestScheduler(wealthRepo repository.Wealth, accountRepo repository.AccountV2, journalRepo repository.Journal, runRepo repository.SchedulerRun, uow repository.UnitOfWork) *InterestScheduler {
 	return &InterestScheduler{
 		repo:         wealthRepo,
 		accountRepo:  accountRepo,
 		journalRepo:  journalRepo,
+		runRepo:      runRepo,
+		uow:          uow,
 		priceService: binance.NewPriceService(),
 		metrics:      NewSchedulerMetrics(),
+		clock:        clock.System,
 	}
 }
 
-func (s *InterestScheduler) Start() {
-	// 使用 UTC 时区
-	loc := time.UTC
-	timeZoneName := "UTC"
+// SetClock 替换业务日期的时间来源，供测试与模拟器拨动时间
+func (s *InterestScheduler) SetClock(c clock.Clock) {
+	s.clock = c
+}
+
+// runCalendar 运行日期所用的营业日规则：系统默认时区，零点切日。
+// Each order is credited against its own product calendar, capped at the run date.
+func (s *InterestScheduler) runCalendar() accrual.Calendar {
+	return accrual.Calendar{Location: GetShanghaiLocation()}
+}
 
-	nextMidnight := time.Now().In(loc)
-	nextMidnight = time.Date(nextMidnight.Year(), nextMidnight.Month(), nextMidnight.Day(), 0, 0, 5, 0, loc)
-	duration := nextMidnight.Sub(time.Now().In(loc))
+// Job 利息任务的注册信息：启动时补跑遗漏的日期，之后每天 UTC 00:00 执行一次
+// RunOnce takes its own advisory lock, so the job is not marked Singleton.
+func (s *InterestScheduler) Job() Job {
+	return Job{
+		Name:       InterestJobName,
+		Schedule:   "0 0 * * *",
+		Timeout:    30 * time.Minute,
+		Retries:    2,
+		RetryDelay: time.Minute,
+		RunOnStart: true,
+		Run:        s.RunOnce,
+	}
+}
 
-	logger.Info("[InterestScheduler] First run scheduled",
-		"scheduled_time", nextMidnight.Format("2006-01-02 15:04:05"),
-		"delay_seconds", duration.Seconds(),
-		"timezone", timeZoneName)
+// RunOnce 在持有跨实例锁的前提下，按顺序处理自上次成功运行以来的每个业务日期
+// Expired orders are settled once, after the last pending date has accrued.
+// Processing stops at the first failed date so the next run resumes there.
+func (s *InterestScheduler) RunOnce(ctx context.Context) error {
+	unlock, acquired, err := s.runRepo.TryLock(ctx, InterestJobName)
+	if err != nil {
+		return fmt.Errorf("failed to acquire job lock: %v", err)
+	}
+	if !acquired {
+		logger.Info("[InterestScheduler] Job is running on another instance, skipping", "job", InterestJobName)
+		return nil
+	}
+	defer unlock()
+
+	today := s.runCalendar().BusinessDate(s.clock.Now())
+	dates, err := s.pendingRunDates(ctx, today)
+	if err != nil {
+		return err
+	}
+	if len(dates) == 0 {
+		logger.Info("[InterestScheduler] Already ran today, nothing to do", "date", today.Format("2006-01-02"))
+		return nil
+	}
+	if len(dates) > 1 {
+		logger.Warn("[InterestScheduler] Catching up missed run dates",
+			"from", dates[0].Format("2006-01-02"),
+			"to", dates[len(dates)-1].Format("2006-01-02"),
+			"days", len(dates))
+	}
+
+	for i, date := range dates {
+		if err := s.runForDate(ctx, date, i == len(dates)-1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-	time.Sleep(duration)
+// pendingRunDates 返回 (上次成功日期, today] 内的所有日期；从未运行过时只处理 today
+func (s *InterestScheduler) pendingRunDates(ctx context.Context, today time.Time) ([]time.Time, error) {
+	last, err := s.runRepo.GetLastSuccessDate(ctx, InterestJobName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last successful run: %v", err)
+	}
+	if last == "" {
+		return []time.Time{today}, nil
+	}
 
-	logger.Info("[InterestScheduler] Started - running daily at 00:00:05 UTC")
+	lastDate, err := time.Parse("2006-01-02", last)
+	if err != nil {
+		return nil, fmt.Errorf("invalid last run date %q: %v", last, err)
+	}
 
-	for {
-		ctx := context.Background()
-		now := time.Now().In(loc)
-		logger.Info("[InterestScheduler] Execution started", "timestamp", now.Format("2006-01-02 15:04:05"))
+	var dates []time.Time
+	for d := lastDate.AddDate(0, 0, 1); !d.After(today); d = d.AddDate(0, 0, 1) {
+		dates = append(dates, d)
+	}
+	return dates, nil
+}
 
-		// Step 1: Calculate daily interest
-		ordersProcessed, interestAccrued, err := s.CalculateDailyInterest(ctx)
+// runForDate 执行单个业务日期并持久化运行记录
+func (s *InterestScheduler) runForDate(ctx context.Context, date time.Time, settle bool) error {
+	run := &repository.SchedulerRunModel{
+		JobName: InterestJobName,
+		RunDate: date.Format("2006-01-02"),
+		Status:  repository.SchedulerRunStatusRunning,
+	}
+	if err := s.runRepo.CreateRun(ctx, run); err != nil {
+		return fmt.Errorf("failed to record run start: %v", err)
+	}
 
-		// Step 2: Settle expired orders
-		settledCount, settleErr := s.SettleExpiredOrders(ctx)
+	logger.Info("[InterestScheduler] Execution started", "run_date", run.RunDate)
 
-		success := err == nil && settleErr == nil
-		errorMsg := ""
-		if err != nil {
-			errorMsg = err.Error()
-		}
-		if settleErr != nil {
-			if errorMsg != "" {
-				errorMsg += "; "
-			}
-			errorMsg += fmt.Sprintf("settle error: %v", settleErr)
-		}
+	// Step 1: Accrue interest for the run date
+	ordersProcessed, interestAccrued, err := s.AccrueForDate(ctx, date)
 
-		s.metrics.RecordInterestRun(success, ordersProcessed, interestAccrued, errorMsg)
+	// Step 2: Settle expired orders
+	settledCount := 0
+	var settleErr error
+	if err == nil && settle {
+		settledCount, settleErr = s.SettleExpiredOrders(ctx)
+	}
 
-		if !success {
-			logger.Error("[InterestScheduler] Execution failed", "error", errorMsg)
-		} else {
-			logger.Info("[InterestScheduler] Execution completed",
-				"orders_processed", ordersProcessed,
-				"interest_accrued", interestAccrued,
-				"orders_settled", settledCount)
+	success := err == nil && settleErr == nil
+	errorMsg := ""
+	if err != nil {
+		errorMsg = err.Error()
+	}
+	if settleErr != nil {
+		if errorMsg != "" {
+			errorMsg += "; "
 		}
+		errorMsg += fmt.Sprintf("settle error: %v", settleErr)
+	}
+
+	s.metrics.RecordInterestRun(success, ordersProcessed, interestAccrued.Float64(), errorMsg)
 
-		// Calculate wait time until next run at UTC 00:00:05
-		nextRun := time.Now().In(loc)
-		nextRun = time.Date(nextRun.Year(), nextRun.Month(), nextRun.Day(), 0, 0, 5, 0, loc)
-		nextRun = nextRun.AddDate(0, 0, 1)
-		waitDuration := nextRun.Sub(time.Now().In(loc))
+	run.Status = repository.SchedulerRunStatusSuccess
+	if !success {
+		run.Status = repository.SchedulerRunStatusFailed
+	}
+	run.ItemsProcessed = ordersProcessed
+	run.Detail = fmt.Sprintf("interest_accrued=%s orders_settled=%d", interestAccrued.String(), settledCount)
+	run.ErrorMessage = errorMsg
+	if finishErr := s.runRepo.FinishRun(ctx, run); finishErr != nil {
+		logger.Error("[InterestScheduler] Failed to record run result",
+			"run_id", run.ID, "run_date", run.RunDate, "error", finishErr.Error())
+		if success {
+			return fmt.Errorf("failed to record run result: %v", finishErr)
+		}
+	}
 
-		logger.Debug("[InterestScheduler] Waiting until next run", "next_run", nextRun.Format("2006-01-02 15:04:05"))
-		time.Sleep(waitDuration)
+	if !success {
+		return fmt.Errorf("run %s failed: %s", run.RunDate, errorMsg)
 	}
+
+	logger.Info("[InterestScheduler] Execution completed",
+		"run_date", run.RunDate,
+		"orders_processed", ordersProcessed,
+		"interest_accrued", interestAccrued.String(),
+		"orders_settled", settledCount)
+	return nil
 }
 
-func (s *InterestScheduler) CalculateDailyInterest(ctx context.Context) (int, float64, error) {
-	logger.Info("[InterestScheduler] Calculating daily interest...")
+// CalculateDailyInterest 为所有活跃订单记入今日利息
+func (s *InterestScheduler) CalculateDailyInterest(ctx context.Context) (int, money.Decimal, error) {
+	return s.AccrueForDate(ctx, s.runCalendar().BusinessDate(s.clock.Now()))
+}
 
-	today := time.Now().UTC()
+// AccrueForDate 为所有活跃订单记入指定运行日期的利息
+// Each run date books exactly one day of interest per order into the interest
+// ledger. The ledger is unique per (order, date), so re-running the same date
+// is a no-op. An order whose settlement time zone has not reached the run date
+// yet is credited for its own business date; the remaining day is picked up by
+// the next run. If any order fails to accrue, an error is returned after the
+// remaining orders are processed so the run date is retried.
+func (s *InterestScheduler) AccrueForDate(ctx context.Context, today time.Time) (int, money.Decimal, error) {
+	logger.Info("[InterestScheduler] Calculating daily interest...", "run_date", today.Format(accrual.DateLayout))
 
 	orders, err := s.repo.GetActiveOrders(ctx)
 	if err != nil {
-		return 0, 0, fmt.Errorf("failed to get active orders: %v", err)
+		return 0, money.Zero, fmt.Errorf("failed to get active orders: %v", err)
 	}
 
 	logger.Info("[InterestScheduler] Found active orders", "count", len(orders))
 
+	type rateKey struct {
+		productID int64
+		date      string
+	}
+	tiersByProduct := make(map[rateKey][]accrual.Tier)
+
+	now := s.clock.Now()
 	ordersProcessed := 0
-	totalInterestAccrued := 0.0
+	totalInterestAccrued := money.Zero
+	var failedOrders []int64
 
 	for _, order := range orders {
-		startDate, err := time.Parse("2006-01-02", order.StartDate)
-		if err != nil {
-			logger.Error("[InterestScheduler] Failed to parse start date",
-				"order_id", order.ID, "start_date", order.StartDate, "error", err.Error())
-			continue
+		creditDate := today
+		if orderToday := order.Calendar().BusinessDate(now); orderToday.Before(creditDate) {
+			creditDate = orderToday
 		}
+		runDate := creditDate.Format(accrual.DateLayout)
+		// 入账日记入的是前一营业日的利息，按该日生效的利率计算；调价不追溯已计利息
+		rateDate := creditDate.AddDate(0, 0, -1).Format(accrual.DateLayout)
 
-		endDate, err := time.Parse("2006-01-02", order.EndDate)
+		startDate, err := time.Parse(accrual.DateLayout, order.StartDate)
 		if err != nil {
-			logger.Error("[InterestScheduler] Failed to parse end date",
-				"order_id", order.ID, "end_date", order.EndDate, "error", err.Error())
+			logger.Error("[InterestScheduler] Failed to parse start date",
+				"order_id", order.ID, "start_date", order.StartDate, "error", err.Error())
+			failedOrders = append(failedOrders, order.ID)
 			continue
 		}
 
-		daysSinceStart := int(today.Sub(startDate).Hours() / 24)
-		if daysSinceStart < 1 {
+		// 计息日为 [start_date, end_date)，在次日入账，因此入账日期范围为 (start_date, end_date]；
+		// 活期订单没有 end_date，持续计息直至全部赎回
+		if !creditDate.After(startDate) {
 			logger.Debug("[InterestScheduler] Order skipped - started today or not yet",
-				"order_id", order.ID, "start_date", order.StartDate, "days_since_start", daysSinceStart)
+				"order_id", order.ID, "start_date", order.StartDate)
 			continue
 		}
 
-		if daysSinceStart >= int(order.Duration) {
-			logger.Debug("[InterestScheduler] Order skipped - duration exceeded or expired",
-				"order_id", order.ID, "start_date", order.StartDate, "duration", order.Duration, "days_since_start", daysSinceStart)
-			continue
+		if !order.IsFlexible() {
+			endDate, err := time.Parse(accrual.DateLayout, order.EndDate)
+			if err != nil {
+				logger.Error("[InterestScheduler] Failed to parse end date",
+					"order_id", order.ID, "end_date", order.EndDate, "error", err.Error())
+				failedOrders = append(failedOrders, order.ID)
+				continue
+			}
+			if creditDate.After(endDate) {
+				logger.Debug("[InterestScheduler] Order skipped - already expired",
+					"order_id", order.ID, "end_date", order.EndDate)
+				continue
+			}
 		}
 
-		if today.After(endDate) || today.Equal(endDate) {
-			logger.Debug("[InterestScheduler] Order skipped - already expired",
-				"order_id", order.ID, "end_date", order.EndDate)
+		if order.LastInterestDate != "" && order.LastInterestDate >= runDate {
+			logger.Debug("[InterestScheduler] Order skipped - already accrued",
+				"order_id", order.ID, "last_interest_date", order.LastInterestDate)
 			continue
 		}
 
@@ -146,41 +280,81 @@ func (s *InterestScheduler) CalculateDailyInterest(ctx context.Context) (int, fl
 		if err != nil {
 			logger.Error("[InterestScheduler] Failed to get product",
 				"order_id", order.ID, "error", err.Error())
+			failedOrders = append(failedOrders, order.ID)
 			continue
 		}
 
-		apy, _ := strconv.ParseFloat(product.APY, 64)
-		amount, _ := strconv.ParseFloat(order.Amount, 64)
+		key := rateKey{productID: product.ID, date: rateDate}
+		tiers, ok := tiersByProduct[key]
+		if !ok {
+			tiers, err = s.rateTiers(ctx, product, rateDate)
+			if err != nil {
+				logger.Error("[InterestScheduler] Failed to get product rates",
+					"order_id", order.ID, "product_id", product.ID, "error", err.Error())
+				failedOrders = append(failedOrders, order.ID)
+				continue
+			}
+			tiersByProduct[key] = tiers
+		}
 
-		dailyInterest := amount * (apy / 100) / 365
-		interestAccrued := dailyInterest / float64(order.Duration) * float64(daysSinceStart)
+		// 部分赎回后按剩余本金计息
+		principal := order.RemainingPrincipal()
+		dailyInterest := accrual.TieredDailyInterest(order.Currency, principal, tiers, order.Basis())
 
-		err = s.repo.UpdateInterestAccrued(ctx, order.ID, strconv.FormatFloat(interestAccrued, 'f', -1, 64))
+		err = s.repo.AccrueInterest(ctx, order.ID, dailyInterest, runDate)
+		if errors.Is(err, repository.ErrAlreadyExists) {
+			logger.Debug("[InterestScheduler] Order skipped - already accrued",
+				"order_id", order.ID, "date", runDate)
+			continue
+		}
 		if err != nil {
-			logger.Error("[InterestScheduler] Failed to update interest accrued",
-				"order_id", order.ID, "error", err.Error())
+			logger.Error("[InterestScheduler] Failed to accrue interest",
+				"order_id", order.ID, "date", runDate, "error", err.Error())
+			failedOrders = append(failedOrders, order.ID)
 			continue
 		}
 
 		ordersProcessed++
-		totalInterestAccrued += interestAccrued
+		totalInterestAccrued = totalInterestAccrued.Add(dailyInterest)
 
 		logger.Info("[InterestScheduler] Interest accrued",
 			"order_id", order.ID,
-			"interest_accrued", interestAccrued,
-			"days_subscribed", daysSinceStart,
+			"date", runDate,
+			"daily_interest", dailyInterest.String(),
+			"interest_accrued", order.InterestAccrued.Add(dailyInterest).String(),
 			"currency", order.Currency,
-			"apy", apy,
-			"amount", amount)
+			"rate_date", rateDate,
+			"amount", principal.String())
 	}
 
 	logger.Info("[InterestScheduler] Daily interest calculation completed",
 		"orders_processed", ordersProcessed,
-		"total_interest", totalInterestAccrued)
+		"orders_failed", len(failedOrders),
+		"total_interest", totalInterestAccrued.String())
 
+	// 有订单计息失败时整个日期视为失败，下次运行重跑该日期；已入账的订单按 (order, date) 幂等跳过
+	if len(failedOrders) > 0 {
+		return ordersProcessed, totalInterestAccrued, fmt.Errorf("failed to accrue interest for %d orders: %v", len(failedOrders), failedOrders)
+	}
 	return ordersProcessed, totalInterestAccrued, nil
 }
 
+// rateTiers 返回产品在 date 生效的利率档位；没有利率历史时使用产品的静态 APY
+func (s *InterestScheduler) rateTiers(ctx context.Context, product *repository.WealthProductModel, date string) ([]accrual.Tier, error) {
+	rates, err := s.repo.GetProductRates(ctx, product.ID, date)
+	if err != nil {
+		return nil, err
+	}
+	if len(rates) == 0 {
+		return []accrual.Tier{{Floor: money.Zero, APY: product.APY}}, nil
+	}
+	tiers := make([]accrual.Tier, 0, len(rates))
+	for _, rate := range rates {
+		tiers = append(tiers, accrual.Tier{Floor: rate.TierFloor, APY: rate.APY})
+	}
+	return tiers, nil
+}
+
 // SettleOrder Settle a single order
 func (s *InterestScheduler) SettleOrder(ctx context.Context, orderID int64) error {
 	logger.Info("[InterestScheduler] Settling order", "order_id", orderID)
@@ -190,8 +364,8 @@ func (s *InterestScheduler) SettleOrder(ctx context.Context, orderID int64) erro
 		return fmt.Errorf("failed to get order: %v", err)
 	}
 
-	if order.Status != 1 {
-		return fmt.Errorf("order status is not active: %d", order.Status)
+	if order.Status != repository.WealthOrderStatusActive {
+		return fmt.Errorf("%w: %d", ErrOrderNotActive, order.Status)
 	}
 
 	account, err := s.accountRepo.GetAccountByUserIDAndCurrency(ctx, order.UserID, order.Currency)
@@ -199,84 +373,111 @@ func (s *InterestScheduler) SettleOrder(ctx context.Context, orderID int64) erro
 		return fmt.Errorf("failed to get account: %v", err)
 	}
 
-	now := time.Now()
-
-	// Step 1: Unfreeze principal
-	err = s.accountRepo.UnfreezeBalance(ctx, account.ID, order.Amount)
-	if err != nil {
-		return fmt.Errorf("failed to unfreeze balance: %v", err)
-	}
-
-	// Generate journal record for principal unfreeze
-	balance, _ := strconv.ParseFloat(account.Balance, 64)
-	principalAmt, _ := strconv.ParseFloat(order.Amount, 64)
-	newBalance := balance + principalAmt
+	now := s.clock.Now()
+	principal := order.RemainingPrincipal()
+	interestPaid := order.InterestAccrued
 
-	principalJournal := &repository.JournalModel{
-		SerialNo:        fmt.Sprintf("SETTLE-PRINCIPAL-%s-%d", now.Format("20060102150405"), order.ID),
-		UserID:          order.UserID,
-		AccountID:       account.ID,
-		Amount:          order.Amount,
-		BalanceSnapshot: strconv.FormatFloat(newBalance, 'f', -1, 64),
-		BizType:         "REDEEM_UNFREEZE",
-		RefID:           &order.ID,
-		CreatedAt:       now.Format(time.RFC3339),
-	}
-	err = s.journalRepo.CreateJournalRecord(ctx, principalJournal)
-	if err != nil {
-		logger.Error("[InterestScheduler] Failed to create principal journal record",
-			"order_id", orderID, "error", err.Error())
-	}
+	err = s.uow.Do(ctx, func(tx *repository.TxRepository) error {
+		if err := lockUnchangedOrder(ctx, tx, order); err != nil {
+			return err
+		}
 
-	// Step 2: Pay interest if accrued
-	interestPaid, _ := strconv.ParseFloat(order.InterestAccrued, 64)
-	if interestPaid > 0 {
-		err = s.accountRepo.AddBalance(ctx, account.ID, strconv.FormatFloat(interestPaid, 'f', -1, 64))
-		if err != nil {
-			return fmt.Errorf("failed to add interest to balance: %v", err)
+		// Step 1: Unfreeze principal
+		if err := tx.Account.UnfreezeBalance(ctx, account.ID, principal); err != nil {
+			return fmt.Errorf("failed to unfreeze balance: %v", err)
 		}
 
-		// Generate journal record for interest payout
-		balanceAfterInterest := newBalance + interestPaid
-		interestJournal := &repository.JournalModel{
-			SerialNo:        fmt.Sprintf("SETTLE-INTEREST-%s-%d", now.Format("20060102150405"), order.ID),
+		// Generate journal record for principal unfreeze
+		availableAfter := account.Available().Add(principal)
+
+		principalJournal := &repository.JournalModel{
+			SerialNo:        fmt.Sprintf("SETTLE-PRINCIPAL-%s-%d", now.Format("20060102150405"), order.ID),
 			UserID:          order.UserID,
 			AccountID:       account.ID,
-			Amount:          strconv.FormatFloat(interestPaid, 'f', -1, 64),
-			BalanceSnapshot: strconv.FormatFloat(balanceAfterInterest, 'f', -1, 64),
-			BizType:         "INTEREST_PAYOUT",
+			Amount:          principal,
+			BalanceSnapshot: availableAfter,
+			BizType:         "REDEEM_UNFREEZE",
 			RefID:           &order.ID,
 			CreatedAt:       now.Format(time.RFC3339),
 		}
-		err = s.journalRepo.CreateJournalRecord(ctx, interestJournal)
-		if err != nil {
-			logger.Error("[InterestScheduler] Failed to create interest journal record",
-				"order_id", orderID, "error", err.Error())
+		if err := tx.Journal.CreateJournalRecord(ctx, principalJournal); err != nil {
+			return fmt.Errorf("failed to create principal journal record: %v", err)
+		}
+
+		// Step 2: Pay interest if accrued
+		if interestPaid.IsPositive() {
+			if err := tx.Account.AddBalance(ctx, account.ID, interestPaid); err != nil {
+				return fmt.Errorf("failed to add interest to balance: %v", err)
+			}
+
+			// Generate journal record for interest payout
+			interestJournal := &repository.JournalModel{
+				SerialNo:        fmt.Sprintf("SETTLE-INTEREST-%s-%d", now.Format("20060102150405"), order.ID),
+				UserID:          order.UserID,
+				AccountID:       account.ID,
+				Amount:          interestPaid,
+				BalanceSnapshot: availableAfter.Add(interestPaid),
+				BizType:         "INTEREST_PAYOUT",
+				RefID:           &order.ID,
+				CreatedAt:       now.Format(time.RFC3339),
+			}
+			if err := tx.Journal.CreateJournalRecord(ctx, interestJournal); err != nil {
+				return fmt.Errorf("failed to create interest journal record: %v", err)
+			}
 		}
-	}
 
-	// Step 3: Update order status
-	err = s.repo.SettleOrder(ctx, orderID, strconv.FormatFloat(interestPaid, 'f', -1, 64))
+		// Step 3: Update order status; 订单已被并发赎回或撤单时整体回滚
+		if err := tx.Wealth.SettleOrder(ctx, orderID, interestPaid); err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrOrderNotActive
+			}
+			return fmt.Errorf("failed to settle order: %v", err)
+		}
+
+		// Step 4: Give the principal's quota back to the product
+		if err := tx.Wealth.ReleaseProductQuota(ctx, order.ProductID, principal); err != nil {
+			return fmt.Errorf("failed to release product quota: %v", err)
+		}
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("failed to settle order: %v", err)
+		return err
 	}
 
 	logger.Info("[InterestScheduler] Order settled",
 		"order_id", orderID,
-		"amount_unfrozen", order.Amount,
+		"amount_unfrozen", principal,
 		"currency", order.Currency,
-		"interest_paid", interestPaid)
+		"interest_paid", interestPaid.String())
 
 	return nil
 }
 
+// lockUnchangedOrder 在事务内锁定订单，确认读取之后没有并发的赎回、撤单或计息改动它
+func lockUnchangedOrder(ctx context.Context, tx *repository.TxRepository, order *repository.WealthOrderModel) error {
+	locked, err := tx.Wealth.GetOrderForUpdate(ctx, order.ID)
+	if err != nil {
+		return fmt.Errorf("failed to lock order: %v", err)
+	}
+	if locked.Status != repository.WealthOrderStatusActive {
+		return ErrOrderNotActive
+	}
+	if !locked.PrincipalRedeemed.Equal(order.PrincipalRedeemed) || !locked.InterestAccrued.Equal(order.InterestAccrued) {
+		return ErrOrderChanged
+	}
+	return nil
+}
+
 // SettleExpiredOrders Find and settle all orders that have expired
+// An order matures once its own settlement calendar reaches the end date, so
+// orders in time zones behind the run date wait for the next run.
 func (s *InterestScheduler) SettleExpiredOrders(ctx context.Context) (int, error) {
-	today := time.Now().UTC().Format("2006-01-02")
+	now := s.clock.Now()
+	today := s.runCalendar().Today(now)
 
 	logger.Info("[InterestScheduler] Settling expired orders", "date", today)
 
-	orders, err := s.repo.GetExpiredOrders(ctx)
+	orders, err := s.repo.GetExpiredOrders(ctx, today)
 	if err != nil {
 		return 0, fmt.Errorf("failed to get expired orders: %v", err)
 	}
@@ -285,8 +486,18 @@ func (s *InterestScheduler) SettleExpiredOrders(ctx context.Context) (int, error
 	renewedCount := 0
 
 	for _, order := range orders {
+		if order.Calendar().Today(now) < order.EndDate {
+			logger.Debug("[InterestScheduler] Order skipped - not yet matured in its settlement time zone",
+				"order_id", order.ID, "end_date", order.EndDate)
+			continue
+		}
 		if order.AutoRenew {
 			err = s.RenewOrder(ctx, order)
+			if errors.Is(err, ErrOrderNotActive) || errors.Is(err, ErrOrderChanged) {
+				logger.Info("[InterestScheduler] Order skipped - changed concurrently",
+					"order_id", order.ID, "reason", err.Error())
+				continue
+			}
 			if err != nil {
 				logger.Error("[InterestScheduler] Failed to renew order",
 					"order_id", order.ID, "error", err.Error())
@@ -300,6 +511,11 @@ func (s *InterestScheduler) SettleExpiredOrders(ctx context.Context) (int, error
 				"currency", order.Currency)
 		} else {
 			err = s.SettleOrder(ctx, order.ID)
+			if errors.Is(err, ErrOrderNotActive) || errors.Is(err, ErrOrderChanged) {
+				logger.Info("[InterestScheduler] Order skipped - changed concurrently",
+					"order_id", order.ID, "reason", err.Error())
+				continue
+			}
 			if err != nil {
 				logger.Error("[InterestScheduler] Failed to settle order",
 					"order_id", order.ID, "error", err.Error())
@@ -351,101 +567,159 @@ func (s *InterestScheduler) RenewOrder(ctx context.Context, order *repository.We
 	}
 
 	// Check if user has sufficient available balance for principal freeze
-	balance, _ := strconv.ParseFloat(account.Balance, 64)
-	frozen, _ := strconv.ParseFloat(account.FrozenBalance, 64)
-	availableBalance := balance - frozen
-	orderAmount, _ := strconv.ParseFloat(order.Amount, 64)
-	if availableBalance < orderAmount {
+	principal := order.RemainingPrincipal()
+	availableBalance := account.Available()
+	if availableBalance.LessThan(principal) {
 		logger.Error("[InterestScheduler] Insufficient balance for renewal",
 			"order_id", order.ID, "user_id", order.UserID,
-			"available", availableBalance, "required", orderAmount)
-		return fmt.Errorf("insufficient balance for renewal: available %.2f, required %.2f", availableBalance, orderAmount)
+			"available", availableBalance.String(), "required", principal.String())
+		return fmt.Errorf("insufficient balance for renewal: available %s, required %s", availableBalance, principal)
 	}
 
-	now := time.Now()
-	loc := config.GetLocation()
-	nowInLoc := now.In(loc)
+	now := s.clock.Now()
 
-	// Calculate dates - same as subscription: start tomorrow, end tomorrow + duration
-	today := nowInLoc.Format("2006-01-02")
-	todayDate, _ := time.Parse("2006-01-02", today)
-	startDate := todayDate.AddDate(0, 0, 1).Format("2006-01-02")
-	endDate := todayDate.AddDate(0, 0, 1+product.Duration).Format("2006-01-02")
+	// 新订单自原到期日起息，计息日与原订单首尾相接；结算延误时从当前营业日起息，不补计延误期间
+	start, err := time.Parse(accrual.DateLayout, order.EndDate)
+	if err != nil {
+		return fmt.Errorf("invalid end date %q: %v", order.EndDate, err)
+	}
+	if today := order.Calendar().BusinessDate(now); start.Before(today) {
+		start = today
+	}
+	startDate := start.Format(accrual.DateLayout)
+	endDate := start.AddDate(0, 0, product.Duration).Format(accrual.DateLayout)
 
 	logger.Info("[InterestScheduler] Renewal dates calculated",
 		"order_id", order.ID,
 		"start_date", startDate,
 		"end_date", endDate)
 
-	// Step 1: Pay interest from old order
-	interestPaid, _ := strconv.ParseFloat(order.InterestAccrued, 64)
-	if interestPaid > 0 {
-		err = s.accountRepo.AddBalance(ctx, account.ID, strconv.FormatFloat(interestPaid, 'f', -1, 64))
+	interestPaid := order.InterestAccrued
+	availableAfterInterest := availableBalance.Add(interestPaid)
+
+	// 复利续期：利息先派发再冻结，滚入新订单本金
+	newPrincipal := principal
+	compounded := money.Zero
+	if order.AutoRenewCompound && interestPaid.IsPositive() {
+		compounded = interestPaid
+		newPrincipal = principal.Add(compounded)
+	}
+
+	// 与申购报价相同，按新期限内每日生效的分档利率试算预期收益
+	rates, err := s.repo.ListProductRates(ctx, product.ID)
+	if err != nil {
+		return fmt.Errorf("failed to list product rates: %v", err)
+	}
+	interestExpected := product.TermInterest(newPrincipal, rates, start)
+
+	var newOrder *repository.WealthOrderModel
+	err = s.uow.Do(ctx, func(tx *repository.TxRepository) error {
+		if err := lockUnchangedOrder(ctx, tx, order); err != nil {
+			return err
+		}
+
+		// Step 0: 原本金的额度直接转给新订单，复利部分需额外占用额度
+		if compounded.IsPositive() {
+			if err := tx.Wealth.ReserveProductQuota(ctx, product.ID, compounded); err != nil {
+				return err
+			}
+		}
+
+		// Step 1: Pay interest from old order
+		if interestPaid.IsPositive() {
+			if err := tx.Account.AddBalance(ctx, account.ID, interestPaid); err != nil {
+				return fmt.Errorf("failed to add interest: %v", err)
+			}
+
+			// Generate journal record for interest payout
+			interestJournal := &repository.JournalModel{
+				SerialNo:        fmt.Sprintf("RENEW-INTEREST-%s-%d", now.Format("20060102150405"), order.ID),
+				UserID:          order.UserID,
+				AccountID:       account.ID,
+				Amount:          interestPaid,
+				BalanceSnapshot: availableAfterInterest,
+				BizType:         "INTEREST_PAYOUT",
+				RefID:           &order.ID,
+				CreatedAt:       now.Format(time.RFC3339),
+			}
+			if err := tx.Journal.CreateJournalRecord(ctx, interestJournal); err != nil {
+				return fmt.Errorf("failed to create interest journal record: %v", err)
+			}
+		}
+
+		// Step 2: Create new order (principal stays frozen)
+		renewed, err := tx.Wealth.RenewOrder(ctx, order, product, newPrincipal, interestExpected, startDate, endDate, now)
 		if err != nil {
-			return fmt.Errorf("failed to add interest: %v", err)
+			return fmt.Errorf("failed to create renewed order: %v", err)
 		}
 
-		// Generate journal record for interest payout
-		balanceAfterInterest := balance + interestPaid
-		interestJournal := &repository.JournalModel{
-			SerialNo:        fmt.Sprintf("RENEW-INTEREST-%s-%d", now.Format("20060102150405"), order.ID),
+		// Step 2b: Freeze compounded interest into the new order
+		if compounded.IsPositive() {
+			if err := tx.Account.FreezeBalance(ctx, account.ID, compounded); err != nil {
+				return fmt.Errorf("failed to freeze compounded interest: %v", err)
+			}
+
+			compoundJournal := &repository.JournalModel{
+				SerialNo:        fmt.Sprintf("RENEW-COMPOUND-%s-%d", now.Format("20060102150405"), renewed.ID),
+				UserID:          order.UserID,
+				AccountID:       account.ID,
+				Amount:          compounded.Neg(),
+				BalanceSnapshot: availableAfterInterest.Sub(compounded),
+				BizType:         "COMPOUND_FREEZE",
+				RefID:           &renewed.ID,
+				CreatedAt:       now.Format(time.RFC3339),
+			}
+			if err := tx.Journal.CreateJournalRecord(ctx, compoundJournal); err != nil {
+				return fmt.Errorf("failed to create compound journal record: %v", err)
+			}
+		}
+
+		// Generate journal record for new subscription
+		// Balance after interest payout, then principal stays frozen
+		subscribeJournal := &repository.JournalModel{
+			SerialNo:        fmt.Sprintf("RENEW-SUBSCRIBE-%s-%d", now.Format("20060102150405"), renewed.ID),
 			UserID:          order.UserID,
 			AccountID:       account.ID,
-			Amount:          strconv.FormatFloat(interestPaid, 'f', -1, 64),
-			BalanceSnapshot: strconv.FormatFloat(balanceAfterInterest, 'f', -1, 64),
-			BizType:         "INTEREST_PAYOUT",
-			RefID:           &order.ID,
+			Amount:          principal.Neg(),
+			BalanceSnapshot: availableAfterInterest.Sub(compounded).Sub(principal),
+			BizType:         "SUBSCRIBE_FREEZE",
+			RefID:           &renewed.ID,
 			CreatedAt:       now.Format(time.RFC3339),
 		}
-		err = s.journalRepo.CreateJournalRecord(ctx, interestJournal)
-		if err != nil {
-			logger.Error("[InterestScheduler] Failed to create interest journal record",
-				"order_id", order.ID, "error", err.Error())
+		if err := tx.Journal.CreateJournalRecord(ctx, subscribeJournal); err != nil {
+			return fmt.Errorf("failed to create subscription journal record: %v", err)
 		}
-	}
 
-	// Step 2: Create new order (principal stays frozen)
-	newOrder, err := s.repo.RenewOrder(ctx, order, product, startDate, endDate)
-	if err != nil {
-		return fmt.Errorf("failed to create renewed order: %v", err)
-	}
-
-	// Generate journal record for new subscription
-	// Balance after interest payout, then principal stays frozen
-	balanceAfterInterest, _ := strconv.ParseFloat(account.Balance, 64)
-	balanceAfterInterest += interestPaid
-	balanceAfterFreeze := balanceAfterInterest - orderAmount
-	subscribeJournal := &repository.JournalModel{
-		SerialNo:        fmt.Sprintf("RENEW-SUBSCRIBE-%s-%d", now.Format("20060102150405"), newOrder.ID),
-		UserID:          order.UserID,
-		AccountID:       account.ID,
-		Amount:          "-" + order.Amount,
-		BalanceSnapshot: strconv.FormatFloat(balanceAfterFreeze, 'f', -1, 64),
-		BizType:         "SUBSCRIBE_FREEZE",
-		RefID:           &newOrder.ID,
-		CreatedAt:       now.Format(time.RFC3339),
-	}
-	err = s.journalRepo.CreateJournalRecord(ctx, subscribeJournal)
-	if err != nil {
-		logger.Error("[InterestScheduler] Failed to create subscription journal record",
-			"order_id", newOrder.ID, "error", err.Error())
-	}
+		// Step 3: Update old order status; 订单已被并发赎回时整体回滚
+		if err := tx.Wealth.SettleOrder(ctx, order.ID, interestPaid); err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrOrderNotActive
+			}
+			return fmt.Errorf("failed to update old order status: %v", err)
+		}
 
-	// Step 3: Update old order status
-	err = s.repo.SettleOrder(ctx, order.ID, strconv.FormatFloat(interestPaid, 'f', -1, 64))
+		newOrder = renewed
+		return nil
+	})
+	if errors.Is(err, repository.ErrQuotaExceeded) {
+		// 额度不足以容纳续期后的本金，正常结算
+		logger.Warn("[InterestScheduler] Product quota exceeded for renewal, settling normally",
+			"order_id", order.ID, "product_id", product.ID, "principal", newPrincipal.String())
+		return s.SettleOrder(ctx, order.ID)
+	}
 	if err != nil {
-		logger.Error("[InterestScheduler] Failed to update old order status",
-			"order_id", order.ID, "error", err.Error())
+		return err
 	}
 
 	logger.Info("[InterestScheduler] Order renewed successfully",
 		"old_order_id", order.ID,
 		"new_order_id", newOrder.ID,
-		"amount", order.Amount,
+		"amount", newPrincipal,
+		"compounded", compounded.String(),
 		"currency", order.Currency,
 		"start_date", startDate,
 		"end_date", endDate,
-		"interest_paid", interestPaid)
+		"interest_paid", interestPaid.String())
 
 	return nil
 }
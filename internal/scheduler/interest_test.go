@@ -2,13 +2,16 @@ package scheduler
 
 import (
 	"context"
+	"errors"
 	"os"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"monera-digital/internal/clock"
 	"monera-digital/internal/logger"
+	"monera-digital/internal/money"
 	"monera-digital/internal/repository"
 )
 
@@ -25,10 +28,12 @@ func TestInterestScheduler_CalculateDailyInterest_Success(t *testing.T) {
 		repo:        mockWealthRepo,
 		accountRepo: mockAccountRepo,
 		journalRepo: mockJournalRepo,
+		uow:         NewMockUnitOfWork(mockWealthRepo, mockAccountRepo, mockJournalRepo),
 		metrics:     NewSchedulerMetrics(),
+		clock:       clock.System,
 	}
 
-	yesterday := time.Now().AddDate(0, 0, -1).Format("2006-01-02")
+	yesterday := NowInShanghai().AddDate(0, 0, -1).Format("2006-01-02")
 
 	mockWealthRepo.On("GetActiveOrders", mock.Anything).Return([]*repository.WealthOrderModel{
 		{
@@ -36,10 +41,10 @@ func TestInterestScheduler_CalculateDailyInterest_Success(t *testing.T) {
 			UserID:           1,
 			ProductID:        1,
 			ProductTitle:     "USDT 7日增值",
-			Amount:           "10000",
-			InterestAccrued:  "0",
+			Amount:           money.MustParse("10000"),
+			InterestAccrued:  money.MustParse("0"),
 			StartDate:        yesterday,
-			EndDate:          "2026-02-15",
+			EndDate:          time.Now().AddDate(0, 0, 30).Format("2006-01-02"),
 			LastInterestDate: "",
 			Duration:         7,
 			Currency:         "USDT",
@@ -49,17 +54,114 @@ func TestInterestScheduler_CalculateDailyInterest_Success(t *testing.T) {
 	mockWealthRepo.On("GetProductByID", mock.Anything, int64(1)).Return(&repository.WealthProductModel{
 		ID:       1,
 		Title:    "USDT 7日增值",
-		APY:      "5.50",
+		APY:      money.MustParse("5.50"),
 		Currency: "USDT",
 	}, nil)
+	mockWealthRepo.On("GetProductRates", mock.Anything, int64(1), mock.Anything).Return([]*repository.WealthRateModel{}, nil)
 
-	mockWealthRepo.On("UpdateInterestAccrued", mock.Anything, int64(1), mock.AnythingOfType("string")).Return(nil)
+	today := NowInShanghai().Format("2006-01-02")
+	// 10000 * 5.50 / 36500 = 1.506849 (USDT 6 位小数，向下取整)
+	mockWealthRepo.On("AccrueInterest", mock.Anything, int64(1), money.MustParse("1.506849"), today).Return(nil)
 
 	ordersProcessed, totalInterest, err := scheduler.CalculateDailyInterest(context.Background())
 
 	assert.NoError(t, err)
 	assert.Equal(t, 1, ordersProcessed)
-	assert.True(t, totalInterest > 0)
+	assert.True(t, totalInterest.Equal(money.MustParse("1.506849")))
+	mockWealthRepo.AssertExpectations(t)
+}
+
+func TestInterestScheduler_CalculateDailyInterest_FlexibleOrder(t *testing.T) {
+	mockWealthRepo := new(MockWealthRepository)
+	mockAccountRepo := new(MockAccountRepositoryV2)
+	mockJournalRepo := new(MockJournalRepository)
+
+	scheduler := &InterestScheduler{
+		repo:        mockWealthRepo,
+		accountRepo: mockAccountRepo,
+		journalRepo: mockJournalRepo,
+		uow:         NewMockUnitOfWork(mockWealthRepo, mockAccountRepo, mockJournalRepo),
+		metrics:     NewSchedulerMetrics(),
+		clock:       clock.System,
+	}
+
+	// 活期订单没有到期日，按赎回后的剩余本金计息
+	mockWealthRepo.On("GetActiveOrders", mock.Anything).Return([]*repository.WealthOrderModel{
+		{
+			ID:                5,
+			UserID:            1,
+			ProductID:         2,
+			ProductType:       repository.WealthProductTypeFlexible,
+			Amount:            money.MustParse("12000"),
+			PrincipalRedeemed: money.MustParse("2000"),
+			InterestAccrued:   money.MustParse("0"),
+			StartDate:         NowInShanghai().AddDate(0, -3, 0).Format("2006-01-02"),
+			EndDate:           "",
+			Currency:          "USDT",
+		},
+	}, nil)
+
+	mockWealthRepo.On("GetProductByID", mock.Anything, int64(2)).Return(&repository.WealthProductModel{
+		ID:          2,
+		Title:       "USDT 活期",
+		APY:         money.MustParse("5.50"),
+		Currency:    "USDT",
+		ProductType: repository.WealthProductTypeFlexible,
+	}, nil)
+	mockWealthRepo.On("GetProductRates", mock.Anything, int64(2), mock.Anything).Return([]*repository.WealthRateModel{}, nil)
+
+	today := NowInShanghai().Format("2006-01-02")
+	mockWealthRepo.On("AccrueInterest", mock.Anything, int64(5), money.MustParse("1.506849"), today).Return(nil)
+
+	ordersProcessed, _, err := scheduler.CalculateDailyInterest(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, ordersProcessed)
+	mockWealthRepo.AssertExpectations(t)
+}
+
+func TestInterestScheduler_CalculateDailyInterest_TieredRateOfAccrualDay(t *testing.T) {
+	mockWealthRepo := new(MockWealthRepository)
+	mockAccountRepo := new(MockAccountRepositoryV2)
+	mockJournalRepo := new(MockJournalRepository)
+
+	scheduler := &InterestScheduler{
+		repo:        mockWealthRepo,
+		accountRepo: mockAccountRepo,
+		journalRepo: mockJournalRepo,
+		uow:         NewMockUnitOfWork(mockWealthRepo, mockAccountRepo, mockJournalRepo),
+		metrics:     NewSchedulerMetrics(),
+		clock:       clock.System,
+	}
+
+	today := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+	orders := []*repository.WealthOrderModel{
+		{ID: 1, ProductID: 1, Currency: "USDT", Amount: money.MustParse("15000"), StartDate: "2026-03-01", EndDate: "2026-04-01", Status: 1},
+		{ID: 2, ProductID: 1, Currency: "USDT", Amount: money.MustParse("4000"), StartDate: "2026-03-01", EndDate: "2026-04-01", Status: 1},
+	}
+	mockWealthRepo.On("GetActiveOrders", mock.Anything).Return(orders, nil)
+	// 产品当前 APY 已调整，但 3 月 9 日的利息仍按当日生效的档位计算
+	mockWealthRepo.On("GetProductByID", mock.Anything, int64(1)).Return(&repository.WealthProductModel{
+		ID:       1,
+		APY:      money.MustParse("2"),
+		Currency: "USDT",
+	}, nil)
+	mockWealthRepo.On("GetProductRates", mock.Anything, int64(1), "2026-03-09").Return([]*repository.WealthRateModel{
+		{ProductID: 1, EffectiveDate: "2026-03-01", TierFloor: money.Zero, APY: money.MustParse("8")},
+		{ProductID: 1, EffectiveDate: "2026-03-01", TierFloor: money.MustParse("10000"), APY: money.MustParse("4")},
+	}, nil).Once()
+
+	// (10000 * 8 + 5000 * 4) / 36500 = 2.739726
+	mockWealthRepo.On("AccrueInterest", mock.Anything, int64(1), money.MustParse("2.739726"), "2026-03-10").Return(nil)
+	// 4000 * 8 / 36500 = 0.876712
+	mockWealthRepo.On("AccrueInterest", mock.Anything, int64(2), money.MustParse("0.876712"), "2026-03-10").Return(nil)
+
+	ordersProcessed, totalInterest, err := scheduler.AccrueForDate(context.Background(), today)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, ordersProcessed)
+	assert.Equal(t, money.MustParse("3.616438"), totalInterest)
+	// 同一产品的档位在一次运行内只查询一次
 	mockWealthRepo.AssertExpectations(t)
 }
 
@@ -72,7 +174,9 @@ func TestInterestScheduler_CalculateDailyInterest_NoActiveOrders(t *testing.T) {
 		repo:        mockWealthRepo,
 		accountRepo: mockAccountRepo,
 		journalRepo: mockJournalRepo,
+		uow:         NewMockUnitOfWork(mockWealthRepo, mockAccountRepo, mockJournalRepo),
 		metrics:     NewSchedulerMetrics(),
+		clock:       clock.System,
 	}
 
 	mockWealthRepo.On("GetActiveOrders", mock.Anything).Return([]*repository.WealthOrderModel{}, nil)
@@ -81,7 +185,7 @@ func TestInterestScheduler_CalculateDailyInterest_NoActiveOrders(t *testing.T) {
 
 	assert.NoError(t, err)
 	assert.Equal(t, 0, ordersProcessed)
-	assert.Equal(t, 0.0, totalInterest)
+	assert.True(t, totalInterest.IsZero())
 	mockWealthRepo.AssertExpectations(t)
 }
 
@@ -94,20 +198,22 @@ func TestInterestScheduler_CalculateDailyInterest_SkipStartDate(t *testing.T) {
 		repo:        mockWealthRepo,
 		accountRepo: mockAccountRepo,
 		journalRepo: mockJournalRepo,
+		uow:         NewMockUnitOfWork(mockWealthRepo, mockAccountRepo, mockJournalRepo),
 		metrics:     NewSchedulerMetrics(),
+		clock:       clock.System,
 	}
 
-	today := time.Now().Format("2006-01-02")
+	today := NowInShanghai().Format("2006-01-02")
 
 	mockWealthRepo.On("GetActiveOrders", mock.Anything).Return([]*repository.WealthOrderModel{
 		{
 			ID:               1,
 			UserID:           1,
 			ProductID:        1,
-			Amount:           "10000",
-			InterestAccrued:  "0",
+			Amount:           money.MustParse("10000"),
+			InterestAccrued:  money.MustParse("0"),
 			StartDate:        today,
-			EndDate:          "2026-02-15",
+			EndDate:          time.Now().AddDate(0, 0, 30).Format("2006-01-02"),
 			LastInterestDate: "",
 			Currency:         "USDT",
 		},
@@ -117,7 +223,7 @@ func TestInterestScheduler_CalculateDailyInterest_SkipStartDate(t *testing.T) {
 
 	assert.NoError(t, err)
 	assert.Equal(t, 0, ordersProcessed)
-	assert.Equal(t, 0.0, totalInterest)
+	assert.True(t, totalInterest.IsZero())
 	mockWealthRepo.AssertNotCalled(t, "AccrueInterest")
 }
 
@@ -130,21 +236,23 @@ func TestInterestScheduler_CalculateDailyInterest_SkipAlreadyAccrued(t *testing.
 		repo:        mockWealthRepo,
 		accountRepo: mockAccountRepo,
 		journalRepo: mockJournalRepo,
+		uow:         NewMockUnitOfWork(mockWealthRepo, mockAccountRepo, mockJournalRepo),
 		metrics:     NewSchedulerMetrics(),
+		clock:       clock.System,
 	}
 
-	today := time.Now().Format("2006-01-02")
-	yesterday := time.Now().AddDate(0, 0, -1).Format("2006-01-02")
+	today := NowInShanghai().Format("2006-01-02")
+	yesterday := NowInShanghai().AddDate(0, 0, -1).Format("2006-01-02")
 
 	mockWealthRepo.On("GetActiveOrders", mock.Anything).Return([]*repository.WealthOrderModel{
 		{
 			ID:               1,
 			UserID:           1,
 			ProductID:        1,
-			Amount:           "10000",
-			InterestAccrued:  "0",
+			Amount:           money.MustParse("10000"),
+			InterestAccrued:  money.MustParse("0"),
 			StartDate:        yesterday,
-			EndDate:          "2026-02-15",
+			EndDate:          time.Now().AddDate(0, 0, 30).Format("2006-01-02"),
 			LastInterestDate: today,
 			Currency:         "USDT",
 		},
@@ -154,7 +262,7 @@ func TestInterestScheduler_CalculateDailyInterest_SkipAlreadyAccrued(t *testing.
 
 	assert.NoError(t, err)
 	assert.Equal(t, 0, ordersProcessed)
-	assert.Equal(t, 0.0, totalInterest)
+	assert.True(t, totalInterest.IsZero())
 	mockWealthRepo.AssertNotCalled(t, "AccrueInterest")
 }
 
@@ -167,7 +275,9 @@ func TestInterestScheduler_CalculateDailyInterest_DatabaseError(t *testing.T) {
 		repo:        mockWealthRepo,
 		accountRepo: mockAccountRepo,
 		journalRepo: mockJournalRepo,
+		uow:         NewMockUnitOfWork(mockWealthRepo, mockAccountRepo, mockJournalRepo),
 		metrics:     NewSchedulerMetrics(),
+		clock:       clock.System,
 	}
 
 	mockWealthRepo.On("GetActiveOrders", mock.Anything).Return(nil, assert.AnError)
@@ -176,7 +286,7 @@ func TestInterestScheduler_CalculateDailyInterest_DatabaseError(t *testing.T) {
 
 	assert.Error(t, err)
 	assert.Equal(t, 0, ordersProcessed)
-	assert.Equal(t, 0.0, totalInterest)
+	assert.True(t, totalInterest.IsZero())
 	assert.Contains(t, err.Error(), "failed to get active orders")
 }
 
@@ -189,21 +299,72 @@ func TestInterestScheduler_CalculateDailyInterest_AccrueError(t *testing.T) {
 		repo:        mockWealthRepo,
 		accountRepo: mockAccountRepo,
 		journalRepo: mockJournalRepo,
+		uow:         NewMockUnitOfWork(mockWealthRepo, mockAccountRepo, mockJournalRepo),
+		metrics:     NewSchedulerMetrics(),
+		clock:       clock.System,
+	}
+
+	yesterday := NowInShanghai().AddDate(0, 0, -1).Format("2006-01-02")
+	today := NowInShanghai().Format("2006-01-02")
+
+	mockWealthRepo.On("GetActiveOrders", mock.Anything).Return([]*repository.WealthOrderModel{
+		{
+			ID:               1,
+			UserID:           1,
+			ProductID:        1,
+			Amount:           money.MustParse("10000"),
+			InterestAccrued:  money.MustParse("0"),
+			StartDate:        yesterday,
+			EndDate:          time.Now().AddDate(0, 0, 30).Format("2006-01-02"),
+			LastInterestDate: "",
+			Currency:         "USDT",
+		},
+	}, nil)
+
+	mockWealthRepo.On("GetProductByID", mock.Anything, int64(1)).Return(&repository.WealthProductModel{
+		ID:       1,
+		APY:      money.MustParse("5.50"),
+		Currency: "USDT",
+	}, nil)
+	mockWealthRepo.On("GetProductRates", mock.Anything, int64(1), mock.Anything).Return([]*repository.WealthRateModel{}, nil)
+
+	mockWealthRepo.On("AccrueInterest", mock.Anything, int64(1), mock.AnythingOfType("money.Decimal"), today).Return(assert.AnError)
+
+	ordersProcessed, totalInterest, err := scheduler.CalculateDailyInterest(context.Background())
+
+	// 计息失败需上报，运行日期才会被重跑
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to accrue interest for 1 orders")
+	assert.Equal(t, 0, ordersProcessed)
+	assert.True(t, totalInterest.IsZero())
+}
+
+func TestInterestScheduler_CalculateDailyInterest_LedgerAlreadyHasDate(t *testing.T) {
+	mockWealthRepo := new(MockWealthRepository)
+	mockAccountRepo := new(MockAccountRepositoryV2)
+	mockJournalRepo := new(MockJournalRepository)
+
+	scheduler := &InterestScheduler{
+		repo:        mockWealthRepo,
+		accountRepo: mockAccountRepo,
+		journalRepo: mockJournalRepo,
+		uow:         NewMockUnitOfWork(mockWealthRepo, mockAccountRepo, mockJournalRepo),
 		metrics:     NewSchedulerMetrics(),
+		clock:       clock.System,
 	}
 
-	yesterday := time.Now().AddDate(0, 0, -1).Format("2006-01-02")
-	today := time.Now().Format("2006-01-02")
+	yesterday := NowInShanghai().AddDate(0, 0, -1).Format("2006-01-02")
+	today := NowInShanghai().Format("2006-01-02")
 
 	mockWealthRepo.On("GetActiveOrders", mock.Anything).Return([]*repository.WealthOrderModel{
 		{
 			ID:               1,
 			UserID:           1,
 			ProductID:        1,
-			Amount:           "10000",
-			InterestAccrued:  "0",
+			Amount:           money.MustParse("10000"),
+			InterestAccrued:  money.MustParse("0"),
 			StartDate:        yesterday,
-			EndDate:          "2026-02-15",
+			EndDate:          time.Now().AddDate(0, 0, 30).Format("2006-01-02"),
 			LastInterestDate: "",
 			Currency:         "USDT",
 		},
@@ -211,17 +372,20 @@ func TestInterestScheduler_CalculateDailyInterest_AccrueError(t *testing.T) {
 
 	mockWealthRepo.On("GetProductByID", mock.Anything, int64(1)).Return(&repository.WealthProductModel{
 		ID:       1,
-		APY:      "5.50",
+		APY:      money.MustParse("5.50"),
 		Currency: "USDT",
 	}, nil)
+	mockWealthRepo.On("GetProductRates", mock.Anything, int64(1), mock.Anything).Return([]*repository.WealthRateModel{}, nil)
 
-	mockWealthRepo.On("AccrueInterest", mock.Anything, int64(1), mock.AnythingOfType("string"), today).Return(assert.AnError)
+	// 并发运行的另一实例已写入当日账本记录
+	mockWealthRepo.On("AccrueInterest", mock.Anything, int64(1), mock.AnythingOfType("money.Decimal"), today).Return(repository.ErrAlreadyExists)
 
 	ordersProcessed, totalInterest, err := scheduler.CalculateDailyInterest(context.Background())
 
 	assert.NoError(t, err)
 	assert.Equal(t, 0, ordersProcessed)
-	assert.Equal(t, 0.0, totalInterest)
+	assert.True(t, totalInterest.IsZero())
+	mockWealthRepo.AssertExpectations(t)
 }
 
 func TestSchedulerMetrics_RecordInterestRun_Success(t *testing.T) {
@@ -275,15 +439,18 @@ func TestInterestScheduler_SettleOrder_Success(t *testing.T) {
 		repo:        mockWealthRepo,
 		accountRepo: mockAccountRepo,
 		journalRepo: mockJournalRepo,
+		uow:         NewMockUnitOfWork(mockWealthRepo, mockAccountRepo, mockJournalRepo),
 		metrics:     NewSchedulerMetrics(),
+		clock:       clock.System,
 	}
 
 	mockWealthRepo.On("GetOrderByID", mock.Anything, int64(1)).Return(&repository.WealthOrderModel{
 		ID:              1,
 		UserID:          1,
+		ProductID:       1,
 		Currency:        "USDT",
-		Amount:          "10000",
-		InterestAccrued: "15.50",
+		Amount:          money.MustParse("10000"),
+		InterestAccrued: money.MustParse("15.50"),
 		Status:          1,
 	}, nil)
 
@@ -291,14 +458,16 @@ func TestInterestScheduler_SettleOrder_Success(t *testing.T) {
 		ID:       1,
 		UserID:   1,
 		Currency: "USDT",
-		Balance:  "100000",
+		Balance:  money.MustParse("100000"),
 	}, nil)
 
-	mockAccountRepo.On("UnfreezeBalance", mock.Anything, int64(1), "10000").Return(nil)
-	mockAccountRepo.On("AddBalance", mock.Anything, int64(1), mock.AnythingOfType("string")).Return(nil)
-	mockWealthRepo.On("SettleOrder", mock.Anything, int64(1), mock.AnythingOfType("string")).Return(nil)
+	mockAccountRepo.On("UnfreezeBalance", mock.Anything, int64(1), money.MustParse("10000")).Return(nil)
+	mockAccountRepo.On("AddBalance", mock.Anything, int64(1), mock.AnythingOfType("money.Decimal")).Return(nil)
+	mockWealthRepo.On("SettleOrder", mock.Anything, int64(1), mock.AnythingOfType("money.Decimal")).Return(nil)
+	mockWealthRepo.On("ReleaseProductQuota", mock.Anything, int64(1), money.MustParse("10000")).Return(nil)
 	mockJournalRepo.On("CreateJournalRecord", mock.Anything, mock.AnythingOfType("*repository.JournalModel")).Return(nil)
 
+	expectLockedReread(mockWealthRepo, int64(1))
 	err := scheduler.SettleOrder(context.Background(), 1)
 
 	assert.NoError(t, err)
@@ -307,6 +476,94 @@ func TestInterestScheduler_SettleOrder_Success(t *testing.T) {
 	mockJournalRepo.AssertExpectations(t)
 }
 
+func TestInterestScheduler_SettleOrder_ConcurrentlyRedeemedRollsBack(t *testing.T) {
+	mockWealthRepo := new(MockWealthRepository)
+	mockAccountRepo := new(MockAccountRepositoryV2)
+	mockJournalRepo := new(MockJournalRepository)
+	uow := NewMockUnitOfWork(mockWealthRepo, mockAccountRepo, mockJournalRepo)
+
+	scheduler := &InterestScheduler{
+		repo:        mockWealthRepo,
+		accountRepo: mockAccountRepo,
+		journalRepo: mockJournalRepo,
+		uow:         uow,
+		metrics:     NewSchedulerMetrics(),
+		clock:       clock.System,
+	}
+
+	mockWealthRepo.On("GetOrderByID", mock.Anything, int64(1)).Return(&repository.WealthOrderModel{
+		ID:              1,
+		UserID:          1,
+		ProductID:       1,
+		Currency:        "USDT",
+		Amount:          money.MustParse("10000"),
+		InterestAccrued: money.MustParse("15.50"),
+		Status:          1,
+	}, nil)
+	mockAccountRepo.On("GetAccountByUserIDAndCurrency", mock.Anything, int64(1), "USDT").Return(&repository.AccountModel{
+		ID:       1,
+		UserID:   1,
+		Currency: "USDT",
+		Balance:  money.MustParse("100000"),
+	}, nil)
+	mockAccountRepo.On("UnfreezeBalance", mock.Anything, int64(1), money.MustParse("10000")).Return(nil)
+	mockAccountRepo.On("AddBalance", mock.Anything, int64(1), mock.AnythingOfType("money.Decimal")).Return(nil)
+	mockJournalRepo.On("CreateJournalRecord", mock.Anything, mock.AnythingOfType("*repository.JournalModel")).Return(nil)
+	// 用户在读取订单之后抢先赎回，条件更新不命中
+	mockWealthRepo.On("SettleOrder", mock.Anything, int64(1), mock.AnythingOfType("money.Decimal")).Return(repository.ErrNotFound)
+
+	expectLockedReread(mockWealthRepo, int64(1))
+	err := scheduler.SettleOrder(context.Background(), 1)
+
+	assert.ErrorIs(t, err, ErrOrderNotActive)
+	assert.Equal(t, 1, uow.Rollbacks)
+	assert.Equal(t, 0, uow.Commits)
+	assert.False(t, wasCalled(&mockWealthRepo.Mock, "ReleaseProductQuota"))
+}
+
+func TestInterestScheduler_SettleOrder_PartiallyRedeemedSinceRead(t *testing.T) {
+	mockWealthRepo := new(MockWealthRepository)
+	mockAccountRepo := new(MockAccountRepositoryV2)
+	mockJournalRepo := new(MockJournalRepository)
+	uow := NewMockUnitOfWork(mockWealthRepo, mockAccountRepo, mockJournalRepo)
+
+	scheduler := &InterestScheduler{
+		repo:        mockWealthRepo,
+		accountRepo: mockAccountRepo,
+		journalRepo: mockJournalRepo,
+		uow:         uow,
+		metrics:     NewSchedulerMetrics(),
+		clock:       clock.System,
+	}
+
+	order := repository.WealthOrderModel{
+		ID:              1,
+		UserID:          1,
+		ProductID:       1,
+		Currency:        "USDT",
+		Amount:          money.MustParse("10000"),
+		InterestAccrued: money.MustParse("15.50"),
+		Status:          1,
+	}
+	// 用户在结算读取之后部分赎回了 2000
+	locked := order
+	locked.PrincipalRedeemed = money.MustParse("2000")
+	mockWealthRepo.On("GetOrderByID", mock.Anything, int64(1)).Return(&order, nil)
+	mockWealthRepo.On("GetOrderForUpdate", mock.Anything, int64(1)).Return(&locked, nil)
+	mockAccountRepo.On("GetAccountByUserIDAndCurrency", mock.Anything, int64(1), "USDT").Return(&repository.AccountModel{
+		ID:       1,
+		UserID:   1,
+		Currency: "USDT",
+		Balance:  money.MustParse("100000"),
+	}, nil)
+
+	err := scheduler.SettleOrder(context.Background(), 1)
+
+	assert.ErrorIs(t, err, ErrOrderChanged)
+	assert.Equal(t, 1, uow.Rollbacks)
+	assert.False(t, wasCalled(&mockAccountRepo.Mock, "UnfreezeBalance"))
+}
+
 func TestInterestScheduler_SettleOrder_AlreadySettled(t *testing.T) {
 	mockWealthRepo := new(MockWealthRepository)
 	mockAccountRepo := new(MockAccountRepositoryV2)
@@ -316,7 +573,9 @@ func TestInterestScheduler_SettleOrder_AlreadySettled(t *testing.T) {
 		repo:        mockWealthRepo,
 		accountRepo: mockAccountRepo,
 		journalRepo: mockJournalRepo,
+		uow:         NewMockUnitOfWork(mockWealthRepo, mockAccountRepo, mockJournalRepo),
 		metrics:     NewSchedulerMetrics(),
+		clock:       clock.System,
 	}
 
 	mockWealthRepo.On("GetOrderByID", mock.Anything, int64(1)).Return(&repository.WealthOrderModel{
@@ -339,19 +598,21 @@ func TestInterestScheduler_SettleExpiredOrders_AutoRenew(t *testing.T) {
 		repo:        mockWealthRepo,
 		accountRepo: mockAccountRepo,
 		journalRepo: mockJournalRepo,
+		uow:         NewMockUnitOfWork(mockWealthRepo, mockAccountRepo, mockJournalRepo),
 		metrics:     NewSchedulerMetrics(),
+		clock:       clock.System,
 	}
 
-	today := time.Now().Format("2006-01-02")
-	yesterday := time.Now().AddDate(0, 0, -1).Format("2006-01-02")
+	today := NowInShanghai().Format("2006-01-02")
+	yesterday := NowInShanghai().AddDate(0, 0, -1).Format("2006-01-02")
 
 	expiredOrder := &repository.WealthOrderModel{
 		ID:              1,
 		UserID:          1,
 		ProductID:       1,
 		ProductTitle:    "USDT 7日增值",
-		Amount:          "10000",
-		InterestAccrued: "15.50",
+		Amount:          money.MustParse("10000"),
+		InterestAccrued: money.MustParse("15.50"),
 		StartDate:       yesterday,
 		EndDate:         yesterday,
 		Status:          1,
@@ -359,13 +620,14 @@ func TestInterestScheduler_SettleExpiredOrders_AutoRenew(t *testing.T) {
 		Currency:        "USDT",
 	}
 
-	mockWealthRepo.On("GetExpiredOrders", mock.Anything).Return([]*repository.WealthOrderModel{expiredOrder}, nil)
+	mockWealthRepo.On("GetExpiredOrders", mock.Anything, mock.Anything).Return([]*repository.WealthOrderModel{expiredOrder}, nil)
 	mockWealthRepo.On("GetProductByID", mock.Anything, int64(1)).Return(&repository.WealthProductModel{
 		ID:               1,
 		Title:            "USDT 7日增值",
-		APY:              "5.50",
+		APY:              money.MustParse("5.50"),
 		Duration:         7,
 		Currency:         "USDT",
+		TotalQuota:       money.MustParse("1000000"),
 		Status:           1,
 		AutoRenewAllowed: true,
 	}, nil)
@@ -374,26 +636,31 @@ func TestInterestScheduler_SettleExpiredOrders_AutoRenew(t *testing.T) {
 		ID:       1,
 		UserID:   1,
 		Currency: "USDT",
-		Balance:  "100000",
+		Balance:  money.MustParse("100000"),
 	}, nil)
 
 	newOrder := &repository.WealthOrderModel{
 		ID:        2,
 		UserID:    1,
 		ProductID: 1,
-		Amount:    "10000",
+		Amount:    money.MustParse("10000"),
 		StartDate: today,
 		AutoRenew: true,
 	}
-	mockWealthRepo.On("RenewOrder", mock.Anything, expiredOrder, mock.Anything, mock.Anything, mock.Anything).Return(newOrder, nil)
-	mockAccountRepo.On("AddBalance", mock.Anything, int64(1), mock.AnythingOfType("string")).Return(nil)
-	mockWealthRepo.On("SettleOrder", mock.Anything, int64(1), mock.AnythingOfType("string")).Return(nil)
+	mockWealthRepo.On("ListProductRates", mock.Anything, int64(1)).Return([]*repository.WealthRateModel{}, nil)
+	mockWealthRepo.On("RenewOrder", mock.Anything, expiredOrder, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(newOrder, nil)
+	mockAccountRepo.On("AddBalance", mock.Anything, int64(1), mock.AnythingOfType("money.Decimal")).Return(nil)
+	mockWealthRepo.On("SettleOrder", mock.Anything, int64(1), mock.AnythingOfType("money.Decimal")).Return(nil)
 	mockJournalRepo.On("CreateJournalRecord", mock.Anything, mock.AnythingOfType("*repository.JournalModel")).Return(nil)
 
+	mockWealthRepo.On("GetOrderForUpdate", mock.Anything, int64(1)).Return(expiredOrder, nil)
+
 	settledCount, err := scheduler.SettleExpiredOrders(context.Background())
 
 	assert.NoError(t, err)
 	assert.Equal(t, 1, settledCount)
+	// 续期的本金额度直接转给新订单，不归还
+	assert.False(t, wasCalled(&mockWealthRepo.Mock, "ReleaseProductQuota"))
 	mockWealthRepo.AssertExpectations(t)
 	mockAccountRepo.AssertExpectations(t)
 	mockJournalRepo.AssertExpectations(t)
@@ -408,36 +675,41 @@ func TestInterestScheduler_SettleExpiredOrders_NoAutoRenew(t *testing.T) {
 		repo:        mockWealthRepo,
 		accountRepo: mockAccountRepo,
 		journalRepo: mockJournalRepo,
+		uow:         NewMockUnitOfWork(mockWealthRepo, mockAccountRepo, mockJournalRepo),
 		metrics:     NewSchedulerMetrics(),
+		clock:       clock.System,
 	}
 
-	yesterday := time.Now().AddDate(0, 0, -1).Format("2006-01-02")
+	yesterday := NowInShanghai().AddDate(0, 0, -1).Format("2006-01-02")
 
 	expiredOrder := &repository.WealthOrderModel{
 		ID:              1,
 		UserID:          1,
 		ProductID:       1,
-		Amount:          "10000",
-		InterestAccrued: "15.50",
+		Amount:          money.MustParse("10000"),
+		InterestAccrued: money.MustParse("15.50"),
 		EndDate:         yesterday,
 		Status:          1,
 		AutoRenew:       false,
 		Currency:        "USDT",
 	}
 
-	mockWealthRepo.On("GetExpiredOrders", mock.Anything).Return([]*repository.WealthOrderModel{expiredOrder}, nil)
+	mockWealthRepo.On("GetExpiredOrders", mock.Anything, mock.Anything).Return([]*repository.WealthOrderModel{expiredOrder}, nil)
 	mockWealthRepo.On("GetOrderByID", mock.Anything, int64(1)).Return(expiredOrder, nil)
 	mockAccountRepo.On("GetAccountByUserIDAndCurrency", mock.Anything, int64(1), "USDT").Return(&repository.AccountModel{
 		ID:       1,
 		UserID:   1,
 		Currency: "USDT",
-		Balance:  "100000",
+		Balance:  money.MustParse("100000"),
 	}, nil)
-	mockAccountRepo.On("UnfreezeBalance", mock.Anything, int64(1), "10000").Return(nil)
-	mockAccountRepo.On("AddBalance", mock.Anything, int64(1), mock.AnythingOfType("string")).Return(nil)
-	mockWealthRepo.On("SettleOrder", mock.Anything, int64(1), mock.AnythingOfType("string")).Return(nil)
+	mockAccountRepo.On("UnfreezeBalance", mock.Anything, int64(1), money.MustParse("10000")).Return(nil)
+	mockAccountRepo.On("AddBalance", mock.Anything, int64(1), mock.AnythingOfType("money.Decimal")).Return(nil)
+	mockWealthRepo.On("SettleOrder", mock.Anything, int64(1), mock.AnythingOfType("money.Decimal")).Return(nil)
+	mockWealthRepo.On("ReleaseProductQuota", mock.Anything, int64(1), money.MustParse("10000")).Return(nil)
 	mockJournalRepo.On("CreateJournalRecord", mock.Anything, mock.AnythingOfType("*repository.JournalModel")).Return(nil)
 
+	mockWealthRepo.On("GetOrderForUpdate", mock.Anything, int64(1)).Return(expiredOrder, nil)
+
 	settledCount, err := scheduler.SettleExpiredOrders(context.Background())
 
 	assert.NoError(t, err)
@@ -451,3 +723,574 @@ func TestMain(m *testing.M) {
 	_ = logger.Init("test")
 	os.Exit(m.Run())
 }
+
+func TestInterestScheduler_SettleOrder_RollsBackOnStepFailure(t *testing.T) {
+	errBoom := errors.New("boom")
+	steps := []string{"UnfreezeBalance", "PrincipalJournal", "AddBalance", "InterestJournal", "SettleOrder", "ReleaseProductQuota"}
+
+	for failAt, step := range steps {
+		t.Run(step, func(t *testing.T) {
+			mockWealthRepo := new(MockWealthRepository)
+			mockAccountRepo := new(MockAccountRepositoryV2)
+			mockJournalRepo := new(MockJournalRepository)
+			uow := NewMockUnitOfWork(mockWealthRepo, mockAccountRepo, mockJournalRepo)
+
+			scheduler := &InterestScheduler{
+				repo:        mockWealthRepo,
+				accountRepo: mockAccountRepo,
+				journalRepo: mockJournalRepo,
+				uow:         uow,
+				metrics:     NewSchedulerMetrics(),
+				clock:       clock.System,
+			}
+
+			mockWealthRepo.On("GetOrderByID", mock.Anything, int64(1)).Return(&repository.WealthOrderModel{
+				ID:              1,
+				UserID:          1,
+				ProductID:       1,
+				Currency:        "USDT",
+				Amount:          money.MustParse("10000"),
+				InterestAccrued: money.MustParse("15.50"),
+				Status:          1,
+			}, nil)
+			mockAccountRepo.On("GetAccountByUserIDAndCurrency", mock.Anything, int64(1), "USDT").Return(&repository.AccountModel{
+				ID:            1,
+				UserID:        1,
+				Currency:      "USDT",
+				Balance:       money.MustParse("100000"),
+				FrozenBalance: money.MustParse("10000"),
+			}, nil)
+
+			stepErr := func(i int) error {
+				if i == failAt {
+					return errBoom
+				}
+				return nil
+			}
+			mockAccountRepo.On("UnfreezeBalance", mock.Anything, int64(1), money.MustParse("10000")).Return(stepErr(0))
+			mockJournalRepo.On("CreateJournalRecord", mock.Anything, mock.AnythingOfType("*repository.JournalModel")).Return(stepErr(1)).Once()
+			mockAccountRepo.On("AddBalance", mock.Anything, int64(1), money.MustParse("15.5")).Return(stepErr(2))
+			mockJournalRepo.On("CreateJournalRecord", mock.Anything, mock.AnythingOfType("*repository.JournalModel")).Return(stepErr(3)).Once()
+			mockWealthRepo.On("SettleOrder", mock.Anything, int64(1), money.MustParse("15.5")).Return(stepErr(4))
+			mockWealthRepo.On("ReleaseProductQuota", mock.Anything, int64(1), money.MustParse("10000")).Return(stepErr(5))
+
+			expectLockedReread(mockWealthRepo, int64(1))
+			err := scheduler.SettleOrder(context.Background(), 1)
+
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), errBoom.Error())
+			assert.Equal(t, 0, uow.Commits)
+			assert.Equal(t, 1, uow.Rollbacks)
+			assert.Equal(t, failAt >= 2, wasCalled(&mockAccountRepo.Mock, "AddBalance"))
+			assert.Equal(t, failAt >= 4, wasCalled(&mockWealthRepo.Mock, "SettleOrder"))
+			assert.Equal(t, failAt == 5, wasCalled(&mockWealthRepo.Mock, "ReleaseProductQuota"))
+		})
+	}
+}
+
+func TestInterestScheduler_RenewOrder_RollsBackOnStepFailure(t *testing.T) {
+	errBoom := errors.New("boom")
+	steps := []string{"AddBalance", "InterestJournal", "RenewOrder", "SubscribeJournal", "SettleOrder"}
+
+	for failAt, step := range steps {
+		t.Run(step, func(t *testing.T) {
+			mockWealthRepo := new(MockWealthRepository)
+			mockAccountRepo := new(MockAccountRepositoryV2)
+			mockJournalRepo := new(MockJournalRepository)
+			uow := NewMockUnitOfWork(mockWealthRepo, mockAccountRepo, mockJournalRepo)
+
+			scheduler := &InterestScheduler{
+				repo:        mockWealthRepo,
+				accountRepo: mockAccountRepo,
+				journalRepo: mockJournalRepo,
+				uow:         uow,
+				metrics:     NewSchedulerMetrics(),
+				clock:       clock.System,
+			}
+
+			order := &repository.WealthOrderModel{
+				ID:              1,
+				UserID:          1,
+				ProductID:       1,
+				Currency:        "USDT",
+				Amount:          money.MustParse("10000"),
+				InterestAccrued: money.MustParse("15.50"),
+				EndDate:         TodayInShanghai(),
+				Status:          1,
+				AutoRenew:       true,
+			}
+			mockWealthRepo.On("GetProductByID", mock.Anything, int64(1)).Return(&repository.WealthProductModel{
+				ID:               1,
+				APY:              money.MustParse("5.50"),
+				Duration:         7,
+				Currency:         "USDT",
+				TotalQuota:       money.MustParse("1000000"),
+				Status:           1,
+				AutoRenewAllowed: true,
+			}, nil)
+			mockAccountRepo.On("GetAccountByUserIDAndCurrency", mock.Anything, int64(1), "USDT").Return(&repository.AccountModel{
+				ID:       1,
+				UserID:   1,
+				Currency: "USDT",
+				Balance:  money.MustParse("100000"),
+			}, nil)
+
+			stepErr := func(i int) error {
+				if i == failAt {
+					return errBoom
+				}
+				return nil
+			}
+			mockAccountRepo.On("AddBalance", mock.Anything, int64(1), money.MustParse("15.5")).Return(stepErr(0))
+			mockJournalRepo.On("CreateJournalRecord", mock.Anything, mock.AnythingOfType("*repository.JournalModel")).Return(stepErr(1)).Once()
+			mockWealthRepo.On("ListProductRates", mock.Anything, int64(1)).Return([]*repository.WealthRateModel{}, nil)
+			if failAt == 2 {
+				mockWealthRepo.On("RenewOrder", mock.Anything, order, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil, errBoom)
+			} else {
+				mockWealthRepo.On("RenewOrder", mock.Anything, order, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(&repository.WealthOrderModel{ID: 2}, nil)
+			}
+			mockJournalRepo.On("CreateJournalRecord", mock.Anything, mock.AnythingOfType("*repository.JournalModel")).Return(stepErr(3)).Once()
+			mockWealthRepo.On("SettleOrder", mock.Anything, int64(1), money.MustParse("15.5")).Return(stepErr(4))
+
+			mockWealthRepo.On("GetOrderForUpdate", mock.Anything, int64(1)).Return(order, nil)
+
+			err := scheduler.RenewOrder(context.Background(), order)
+
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), errBoom.Error())
+			assert.Equal(t, 0, uow.Commits)
+			assert.Equal(t, 1, uow.Rollbacks)
+			assert.Equal(t, failAt >= 2, wasCalled(&mockWealthRepo.Mock, "RenewOrder"))
+			assert.Equal(t, failAt == 4, wasCalled(&mockWealthRepo.Mock, "SettleOrder"))
+		})
+	}
+}
+
+func TestInterestScheduler_RenewOrder_CompoundsInterest(t *testing.T) {
+	mockWealthRepo := new(MockWealthRepository)
+	mockAccountRepo := new(MockAccountRepositoryV2)
+	mockJournalRepo := new(MockJournalRepository)
+
+	scheduler := &InterestScheduler{
+		repo:        mockWealthRepo,
+		accountRepo: mockAccountRepo,
+		journalRepo: mockJournalRepo,
+		uow:         NewMockUnitOfWork(mockWealthRepo, mockAccountRepo, mockJournalRepo),
+		metrics:     NewSchedulerMetrics(),
+		clock:       clock.NewFake(time.Date(2026, 3, 10, 1, 0, 0, 0, time.UTC)),
+	}
+
+	order := &repository.WealthOrderModel{
+		ID:                1,
+		UserID:            1,
+		ProductID:         1,
+		Currency:          "USDT",
+		Amount:            money.MustParse("10000"),
+		InterestAccrued:   money.MustParse("15.5"),
+		EndDate:           "2026-03-10",
+		Status:            1,
+		AutoRenew:         true,
+		AutoRenewCompound: true,
+	}
+	mockWealthRepo.On("GetProductByID", mock.Anything, int64(1)).Return(&repository.WealthProductModel{
+		ID:               1,
+		Duration:         7,
+		Currency:         "USDT",
+		TotalQuota:       money.MustParse("1000000"),
+		Status:           1,
+		AutoRenewAllowed: true,
+	}, nil)
+	mockAccountRepo.On("GetAccountByUserIDAndCurrency", mock.Anything, int64(1), "USDT").Return(&repository.AccountModel{
+		ID:            1,
+		UserID:        1,
+		Currency:      "USDT",
+		Balance:       money.MustParse("30000"),
+		FrozenBalance: money.MustParse("10000"),
+	}, nil)
+
+	var journals []*repository.JournalModel
+	mockAccountRepo.On("AddBalance", mock.Anything, int64(1), money.MustParse("15.5")).Return(nil)
+	mockAccountRepo.On("FreezeBalance", mock.Anything, int64(1), money.MustParse("15.5")).Return(nil)
+	// 原本金的额度转给新订单，只需额外占用复利部分
+	mockWealthRepo.On("ReserveProductQuota", mock.Anything, int64(1), money.MustParse("15.5")).Return(nil)
+	// 新订单自原到期日起息，与原订单的计息日首尾相接
+	mockWealthRepo.On("ListProductRates", mock.Anything, int64(1)).Return([]*repository.WealthRateModel{}, nil)
+	mockWealthRepo.On("RenewOrder", mock.Anything, order, mock.Anything, money.MustParse("10015.5"), mock.Anything, "2026-03-10", "2026-03-17", mock.Anything).
+		Return(&repository.WealthOrderModel{ID: 2, Amount: money.MustParse("10015.5")}, nil)
+	mockWealthRepo.On("SettleOrder", mock.Anything, int64(1), money.MustParse("15.5")).Return(nil)
+	mockJournalRepo.On("CreateJournalRecord", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		journals = append(journals, args.Get(1).(*repository.JournalModel))
+	}).Return(nil)
+
+	mockWealthRepo.On("GetOrderForUpdate", mock.Anything, int64(1)).Return(order, nil)
+
+	err := scheduler.RenewOrder(context.Background(), order)
+
+	assert.NoError(t, err)
+	mockAccountRepo.AssertExpectations(t)
+	mockWealthRepo.AssertExpectations(t)
+
+	if assert.Len(t, journals, 3) {
+		assert.Equal(t, "INTEREST_PAYOUT", journals[0].BizType)
+		assert.Equal(t, money.MustParse("20015.5"), journals[0].BalanceSnapshot)
+		// 派发的利息随即冻结进新订单，可用余额回到续期前
+		assert.Equal(t, "COMPOUND_FREEZE", journals[1].BizType)
+		assert.Equal(t, money.MustParse("-15.5"), journals[1].Amount)
+		assert.Equal(t, money.MustParse("20000"), journals[1].BalanceSnapshot)
+		assert.Equal(t, int64(2), *journals[1].RefID)
+		assert.Equal(t, "SUBSCRIBE_FREEZE", journals[2].BizType)
+	}
+}
+
+func TestInterestScheduler_RenewOrder_PricesFromRateHistory(t *testing.T) {
+	mockWealthRepo := new(MockWealthRepository)
+	mockAccountRepo := new(MockAccountRepositoryV2)
+	mockJournalRepo := new(MockJournalRepository)
+
+	now := time.Date(2026, 3, 10, 1, 0, 0, 0, time.UTC)
+	scheduler := &InterestScheduler{
+		repo:        mockWealthRepo,
+		accountRepo: mockAccountRepo,
+		journalRepo: mockJournalRepo,
+		uow:         NewMockUnitOfWork(mockWealthRepo, mockAccountRepo, mockJournalRepo),
+		metrics:     NewSchedulerMetrics(),
+		clock:       clock.NewFake(now),
+	}
+
+	order := &repository.WealthOrderModel{
+		ID:        1,
+		UserID:    1,
+		ProductID: 1,
+		Currency:  "USDT",
+		Amount:    money.MustParse("10000"),
+		EndDate:   "2026-03-10",
+		Status:    1,
+		AutoRenew: true,
+	}
+	mockWealthRepo.On("GetProductByID", mock.Anything, int64(1)).Return(&repository.WealthProductModel{
+		ID:               1,
+		APY:              money.MustParse("5.50"),
+		Duration:         7,
+		Currency:         "USDT",
+		TotalQuota:       money.MustParse("1000000"),
+		Status:           1,
+		AutoRenewAllowed: true,
+	}, nil)
+	// 新期限内利率自 3-13 起调整，静态 APY 不再适用
+	mockWealthRepo.On("ListProductRates", mock.Anything, int64(1)).Return([]*repository.WealthRateModel{
+		{ProductID: 1, EffectiveDate: "2026-03-13", TierFloor: money.Zero, APY: money.MustParse("7.30")},
+		{ProductID: 1, EffectiveDate: "2026-03-01", TierFloor: money.Zero, APY: money.MustParse("3.65")},
+	}, nil)
+	mockAccountRepo.On("GetAccountByUserIDAndCurrency", mock.Anything, int64(1), "USDT").Return(&repository.AccountModel{
+		ID:            1,
+		UserID:        1,
+		Currency:      "USDT",
+		Balance:       money.MustParse("30000"),
+		FrozenBalance: money.MustParse("10000"),
+	}, nil)
+	mockWealthRepo.On("GetOrderForUpdate", mock.Anything, int64(1)).Return(order, nil)
+	// 3-10 至 3-12 每日 1，3-13 至 3-16 每日 2
+	mockWealthRepo.On("RenewOrder", mock.Anything, order, mock.Anything, money.MustParse("10000"), money.MustParse("11"), "2026-03-10", "2026-03-17", now).
+		Return(&repository.WealthOrderModel{ID: 2, Amount: money.MustParse("10000")}, nil)
+	mockJournalRepo.On("CreateJournalRecord", mock.Anything, mock.Anything).Return(nil)
+	mockWealthRepo.On("SettleOrder", mock.Anything, int64(1), money.Zero).Return(nil)
+
+	err := scheduler.RenewOrder(context.Background(), order)
+
+	assert.NoError(t, err)
+	mockWealthRepo.AssertExpectations(t)
+}
+
+func TestInterestScheduler_RenewOrder_CompoundedPrincipalOverQuotaSettles(t *testing.T) {
+	mockWealthRepo := new(MockWealthRepository)
+	mockAccountRepo := new(MockAccountRepositoryV2)
+	mockJournalRepo := new(MockJournalRepository)
+
+	scheduler := &InterestScheduler{
+		repo:        mockWealthRepo,
+		accountRepo: mockAccountRepo,
+		journalRepo: mockJournalRepo,
+		uow:         NewMockUnitOfWork(mockWealthRepo, mockAccountRepo, mockJournalRepo),
+		metrics:     NewSchedulerMetrics(),
+		clock:       clock.System,
+	}
+
+	order := &repository.WealthOrderModel{
+		ID:                1,
+		UserID:            1,
+		ProductID:         1,
+		Currency:          "USDT",
+		Amount:            money.MustParse("10000"),
+		InterestAccrued:   money.MustParse("15.5"),
+		EndDate:           TodayInShanghai(),
+		Status:            1,
+		AutoRenew:         true,
+		AutoRenewCompound: true,
+	}
+	mockWealthRepo.On("GetProductByID", mock.Anything, int64(1)).Return(&repository.WealthProductModel{
+		ID:               1,
+		Duration:         7,
+		Currency:         "USDT",
+		TotalQuota:       money.MustParse("100000"),
+		SoldQuota:        money.MustParse("100000"),
+		Status:           1,
+		AutoRenewAllowed: true,
+	}, nil)
+	mockWealthRepo.On("ListProductRates", mock.Anything, int64(1)).Return([]*repository.WealthRateModel{}, nil)
+	// 额度已满：续期本金本身已占额度，复利部分占不到
+	mockWealthRepo.On("ReserveProductQuota", mock.Anything, int64(1), money.MustParse("15.5")).Return(repository.ErrQuotaExceeded)
+	mockWealthRepo.On("GetOrderByID", mock.Anything, int64(1)).Return(order, nil)
+	mockAccountRepo.On("GetAccountByUserIDAndCurrency", mock.Anything, int64(1), "USDT").Return(&repository.AccountModel{
+		ID:            1,
+		UserID:        1,
+		Currency:      "USDT",
+		Balance:       money.MustParse("30000"),
+		FrozenBalance: money.MustParse("10000"),
+	}, nil)
+	mockAccountRepo.On("UnfreezeBalance", mock.Anything, int64(1), money.MustParse("10000")).Return(nil)
+	mockAccountRepo.On("AddBalance", mock.Anything, int64(1), money.MustParse("15.5")).Return(nil)
+	mockJournalRepo.On("CreateJournalRecord", mock.Anything, mock.Anything).Return(nil)
+	mockWealthRepo.On("SettleOrder", mock.Anything, int64(1), money.MustParse("15.5")).Return(nil)
+	mockWealthRepo.On("ReleaseProductQuota", mock.Anything, int64(1), money.MustParse("10000")).Return(nil)
+
+	mockWealthRepo.On("GetOrderForUpdate", mock.Anything, int64(1)).Return(order, nil)
+
+	err := scheduler.RenewOrder(context.Background(), order)
+
+	assert.NoError(t, err)
+	assert.False(t, wasCalled(&mockWealthRepo.Mock, "RenewOrder"))
+	assert.True(t, wasCalled(&mockAccountRepo.Mock, "UnfreezeBalance"))
+}
+
+func newRunOnceScheduler() (*InterestScheduler, *MockWealthRepository, *MockSchedulerRunRepository) {
+	mockWealthRepo := new(MockWealthRepository)
+	mockAccountRepo := new(MockAccountRepositoryV2)
+	mockJournalRepo := new(MockJournalRepository)
+	mockRunRepo := new(MockSchedulerRunRepository)
+
+	scheduler := &InterestScheduler{
+		repo:        mockWealthRepo,
+		accountRepo: mockAccountRepo,
+		journalRepo: mockJournalRepo,
+		runRepo:     mockRunRepo,
+		uow:         NewMockUnitOfWork(mockWealthRepo, mockAccountRepo, mockJournalRepo),
+		metrics:     NewSchedulerMetrics(),
+		clock:       clock.System,
+	}
+	return scheduler, mockWealthRepo, mockRunRepo
+}
+
+func TestInterestScheduler_RunOnce_LockHeldByAnotherInstance(t *testing.T) {
+	scheduler, mockWealthRepo, mockRunRepo := newRunOnceScheduler()
+
+	mockRunRepo.On("TryLock", mock.Anything, InterestJobName).Return(nil, false, nil)
+
+	err := scheduler.RunOnce(context.Background())
+
+	assert.NoError(t, err)
+	assert.False(t, wasCalled(&mockRunRepo.Mock, "GetLastSuccessDate"))
+	assert.False(t, wasCalled(&mockWealthRepo.Mock, "GetActiveOrders"))
+}
+
+func TestInterestScheduler_RunOnce_CatchesUpMissedDates(t *testing.T) {
+	scheduler, mockWealthRepo, mockRunRepo := newRunOnceScheduler()
+
+	today, _ := time.Parse("2006-01-02", TodayInShanghai())
+	unlocked := false
+
+	mockRunRepo.On("TryLock", mock.Anything, InterestJobName).Return(func() { unlocked = true }, true, nil)
+	mockRunRepo.On("GetLastSuccessDate", mock.Anything, InterestJobName).Return(today.AddDate(0, 0, -3).Format("2006-01-02"), nil)
+
+	var runDates []string
+	mockRunRepo.On("CreateRun", mock.Anything, mock.AnythingOfType("*repository.SchedulerRunModel")).
+		Run(func(args mock.Arguments) {
+			runDates = append(runDates, args.Get(1).(*repository.SchedulerRunModel).RunDate)
+		}).Return(nil)
+	mockRunRepo.On("FinishRun", mock.Anything, mock.MatchedBy(func(run *repository.SchedulerRunModel) bool {
+		return run.Status == repository.SchedulerRunStatusSuccess
+	})).Return(nil)
+
+	mockWealthRepo.On("GetActiveOrders", mock.Anything).Return([]*repository.WealthOrderModel{}, nil)
+	mockWealthRepo.On("GetExpiredOrders", mock.Anything, mock.Anything).Return([]*repository.WealthOrderModel{}, nil)
+
+	err := scheduler.RunOnce(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		today.AddDate(0, 0, -2).Format("2006-01-02"),
+		today.AddDate(0, 0, -1).Format("2006-01-02"),
+		today.Format("2006-01-02"),
+	}, runDates)
+	// 到期结算只在最后一个日期执行一次
+	mockWealthRepo.AssertNumberOfCalls(t, "GetExpiredOrders", 1)
+	mockRunRepo.AssertNumberOfCalls(t, "FinishRun", 3)
+	assert.True(t, unlocked)
+}
+
+func TestInterestScheduler_RunOnce_StopsAtFailedDate(t *testing.T) {
+	scheduler, mockWealthRepo, mockRunRepo := newRunOnceScheduler()
+
+	today, _ := time.Parse("2006-01-02", TodayInShanghai())
+
+	mockRunRepo.On("TryLock", mock.Anything, InterestJobName).Return(func() {}, true, nil)
+	mockRunRepo.On("GetLastSuccessDate", mock.Anything, InterestJobName).Return(today.AddDate(0, 0, -3).Format("2006-01-02"), nil)
+	mockRunRepo.On("CreateRun", mock.Anything, mock.AnythingOfType("*repository.SchedulerRunModel")).Return(nil)
+	mockRunRepo.On("FinishRun", mock.Anything, mock.MatchedBy(func(run *repository.SchedulerRunModel) bool {
+		return run.Status == repository.SchedulerRunStatusFailed && run.ErrorMessage != ""
+	})).Return(nil)
+
+	mockWealthRepo.On("GetActiveOrders", mock.Anything).Return(nil, errors.New("connection reset"))
+
+	err := scheduler.RunOnce(context.Background())
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "connection reset")
+	mockRunRepo.AssertNumberOfCalls(t, "CreateRun", 1)
+	assert.False(t, wasCalled(&mockWealthRepo.Mock, "GetExpiredOrders"))
+}
+
+func TestInterestScheduler_RunOnce_OrderAccrualFailureFailsDate(t *testing.T) {
+	scheduler, mockWealthRepo, mockRunRepo := newRunOnceScheduler()
+
+	today := TodayInShanghai()
+	yesterday := NowInShanghai().AddDate(0, 0, -1).Format("2006-01-02")
+	endDate := NowInShanghai().AddDate(0, 0, 30).Format("2006-01-02")
+
+	mockRunRepo.On("TryLock", mock.Anything, InterestJobName).Return(func() {}, true, nil)
+	mockRunRepo.On("GetLastSuccessDate", mock.Anything, InterestJobName).Return(yesterday, nil)
+	mockRunRepo.On("CreateRun", mock.Anything, mock.AnythingOfType("*repository.SchedulerRunModel")).Return(nil)
+	mockRunRepo.On("FinishRun", mock.Anything, mock.MatchedBy(func(run *repository.SchedulerRunModel) bool {
+		return run.RunDate == today && run.Status == repository.SchedulerRunStatusFailed && run.ItemsProcessed == 1
+	})).Return(nil)
+
+	mockWealthRepo.On("GetActiveOrders", mock.Anything).Return([]*repository.WealthOrderModel{
+		{ID: 1, UserID: 1, ProductID: 1, Amount: money.MustParse("10000"), StartDate: yesterday, EndDate: endDate, Currency: "USDT"},
+		{ID: 2, UserID: 2, ProductID: 1, Amount: money.MustParse("10000"), StartDate: yesterday, EndDate: endDate, Currency: "USDT"},
+	}, nil)
+	mockWealthRepo.On("GetProductByID", mock.Anything, int64(1)).Return(&repository.WealthProductModel{
+		ID:       1,
+		APY:      money.MustParse("5.50"),
+		Currency: "USDT",
+	}, nil)
+	mockWealthRepo.On("GetProductRates", mock.Anything, int64(1), mock.Anything).Return([]*repository.WealthRateModel{}, nil)
+	mockWealthRepo.On("AccrueInterest", mock.Anything, int64(1), mock.AnythingOfType("money.Decimal"), today).Return(errors.New("deadlock detected"))
+	mockWealthRepo.On("AccrueInterest", mock.Anything, int64(2), mock.AnythingOfType("money.Decimal"), today).Return(nil)
+
+	err := scheduler.RunOnce(context.Background())
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "[1]")
+	// 其余订单照常计息，但该日期不记为成功，到期结算也推迟到重跑成功之后
+	mockWealthRepo.AssertCalled(t, "AccrueInterest", mock.Anything, int64(2), mock.AnythingOfType("money.Decimal"), today)
+	mockRunRepo.AssertExpectations(t)
+	assert.False(t, wasCalled(&mockWealthRepo.Mock, "GetExpiredOrders"))
+}
+
+func TestInterestScheduler_RunOnce_AlreadyRanToday(t *testing.T) {
+	scheduler, mockWealthRepo, mockRunRepo := newRunOnceScheduler()
+
+	today := NowInShanghai().Format("2006-01-02")
+
+	mockRunRepo.On("TryLock", mock.Anything, InterestJobName).Return(func() {}, true, nil)
+	mockRunRepo.On("GetLastSuccessDate", mock.Anything, InterestJobName).Return(today, nil)
+
+	err := scheduler.RunOnce(context.Background())
+
+	assert.NoError(t, err)
+	assert.False(t, wasCalled(&mockRunRepo.Mock, "CreateRun"))
+	assert.False(t, wasCalled(&mockWealthRepo.Mock, "GetActiveOrders"))
+}
+
+func TestInterestScheduler_RunOnce_FirstRunOnlyProcessesToday(t *testing.T) {
+	scheduler, mockWealthRepo, mockRunRepo := newRunOnceScheduler()
+
+	mockRunRepo.On("TryLock", mock.Anything, InterestJobName).Return(func() {}, true, nil)
+	mockRunRepo.On("GetLastSuccessDate", mock.Anything, InterestJobName).Return("", nil)
+	mockRunRepo.On("CreateRun", mock.Anything, mock.MatchedBy(func(run *repository.SchedulerRunModel) bool {
+		return run.RunDate == NowInShanghai().Format("2006-01-02")
+	})).Return(nil)
+	mockRunRepo.On("FinishRun", mock.Anything, mock.AnythingOfType("*repository.SchedulerRunModel")).Return(nil)
+
+	mockWealthRepo.On("GetActiveOrders", mock.Anything).Return([]*repository.WealthOrderModel{}, nil)
+	mockWealthRepo.On("GetExpiredOrders", mock.Anything, mock.Anything).Return([]*repository.WealthOrderModel{}, nil)
+
+	err := scheduler.RunOnce(context.Background())
+
+	assert.NoError(t, err)
+	mockRunRepo.AssertNumberOfCalls(t, "CreateRun", 1)
+}
+
+func TestInterestScheduler_RunOnce_FollowsInjectedClock(t *testing.T) {
+	scheduler, mockWealthRepo, mockRunRepo := newRunOnceScheduler()
+	fake := clock.NewFake(time.Date(2026, 3, 1, 0, 5, 0, 0, time.UTC))
+	scheduler.SetClock(fake)
+
+	mockRunRepo.On("TryLock", mock.Anything, InterestJobName).Return(func() {}, true, nil)
+	mockRunRepo.On("GetLastSuccessDate", mock.Anything, InterestJobName).Return("2026-02-27", nil)
+	var runDates []string
+	mockRunRepo.On("CreateRun", mock.Anything, mock.AnythingOfType("*repository.SchedulerRunModel")).
+		Run(func(args mock.Arguments) {
+			runDates = append(runDates, args.Get(1).(*repository.SchedulerRunModel).RunDate)
+		}).Return(nil)
+	mockRunRepo.On("FinishRun", mock.Anything, mock.AnythingOfType("*repository.SchedulerRunModel")).Return(nil)
+
+	mockWealthRepo.On("GetActiveOrders", mock.Anything).Return([]*repository.WealthOrderModel{}, nil)
+	mockWealthRepo.On("GetExpiredOrders", mock.Anything, "2026-03-01").Return([]*repository.WealthOrderModel{}, nil)
+
+	err := scheduler.RunOnce(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"2026-02-28", "2026-03-01"}, runDates)
+	mockWealthRepo.AssertCalled(t, "GetExpiredOrders", mock.Anything, "2026-03-01")
+}
+
+func TestInterestScheduler_AccrueForDate_UsesOrderCalendar(t *testing.T) {
+	mockWealthRepo := new(MockWealthRepository)
+	// UTC 3 月 10 日 01:00：运行日期（上海）为 3 月 10 日，纽约仍是 3 月 9 日
+	fake := clock.NewFake(time.Date(2026, 3, 10, 1, 0, 0, 0, time.UTC))
+	scheduler := &InterestScheduler{
+		repo:    mockWealthRepo,
+		metrics: NewSchedulerMetrics(),
+		clock:   fake,
+	}
+
+	mockWealthRepo.On("GetActiveOrders", mock.Anything).Return([]*repository.WealthOrderModel{
+		{ID: 1, ProductID: 1, Currency: "USDT", Amount: money.MustParse("10000"), StartDate: "2026-03-01", EndDate: "2026-03-31", Status: 1},
+		{ID: 2, ProductID: 2, Currency: "USDT", Amount: money.MustParse("10000"), StartDate: "2026-03-01", EndDate: "2026-03-31", Status: 1,
+			SettlementTimezone: "America/New_York", DayCount: "ACT/360"},
+	}, nil)
+	mockWealthRepo.On("GetProductByID", mock.Anything, int64(1)).Return(&repository.WealthProductModel{ID: 1, APY: money.MustParse("5.5")}, nil)
+	mockWealthRepo.On("GetProductByID", mock.Anything, int64(2)).Return(&repository.WealthProductModel{ID: 2, APY: money.MustParse("5.5")}, nil)
+	mockWealthRepo.On("GetProductRates", mock.Anything, int64(1), "2026-03-09").Return([]*repository.WealthRateModel{}, nil)
+	mockWealthRepo.On("GetProductRates", mock.Anything, int64(2), "2026-03-08").Return([]*repository.WealthRateModel{}, nil)
+	// 10000 * 5.5 / 36500 与 10000 * 5.5 / 36000
+	mockWealthRepo.On("AccrueInterest", mock.Anything, int64(1), money.MustParse("1.506849"), "2026-03-10").Return(nil)
+	mockWealthRepo.On("AccrueInterest", mock.Anything, int64(2), money.MustParse("1.527777"), "2026-03-09").Return(nil)
+
+	runDate, _ := time.Parse("2006-01-02", "2026-03-10")
+	ordersProcessed, _, err := scheduler.AccrueForDate(context.Background(), runDate)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, ordersProcessed)
+	mockWealthRepo.AssertExpectations(t)
+}
+
+func TestInterestScheduler_SettleExpiredOrders_WaitsForSettlementZone(t *testing.T) {
+	mockWealthRepo := new(MockWealthRepository)
+	scheduler := &InterestScheduler{
+		repo:    mockWealthRepo,
+		metrics: NewSchedulerMetrics(),
+		clock:   clock.NewFake(time.Date(2026, 3, 10, 1, 0, 0, 0, time.UTC)),
+	}
+
+	mockWealthRepo.On("GetExpiredOrders", mock.Anything, "2026-03-10").Return([]*repository.WealthOrderModel{
+		{ID: 1, ProductID: 1, Currency: "USDT", Amount: money.MustParse("10000"), EndDate: "2026-03-10", Status: 1,
+			SettlementTimezone: "America/New_York"},
+	}, nil)
+
+	settled, err := scheduler.SettleExpiredOrders(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, settled)
+	assert.False(t, wasCalled(&mockWealthRepo.Mock, "GetOrderByID"))
+}
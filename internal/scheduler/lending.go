@@ -0,0 +1,179 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"monera-digital/internal/accrual"
+	"monera-digital/internal/clock"
+	"monera-digital/internal/logger"
+	"monera-digital/internal/models"
+	"monera-digital/internal/repository"
+)
+
+// LendingJobName 借贷收益任务的名称
+const LendingJobName = "lending_yield"
+
+type LendingScheduler struct {
+	repo        repository.Lending
+	accountRepo repository.AccountV2
+	uow         repository.UnitOfWork
+	clock       clock.Clock
+}
+
+func NewLendingScheduler(lendingRepo repository.Lending, accountRepo repository.AccountV2, uow repository.UnitOfWork) *LendingScheduler {
+	return &LendingScheduler{
+		repo:        lendingRepo,
+		accountRepo: accountRepo,
+		uow:         uow,
+		clock:       clock.System,
+	}
+}
+
+// SetClock 替换业务日期的时间来源，供测试拨动时间
+func (s *LendingScheduler) SetClock(c clock.Clock) {
+	s.clock = c
+}
+
+// Job 借贷收益任务的注册信息：启动时补记遗漏的收益，之后每天 UTC 00:00 执行一次
+func (s *LendingScheduler) Job() Job {
+	return Job{
+		Name:       LendingJobName,
+		Schedule:   "0 0 * * *",
+		Timeout:    30 * time.Minute,
+		Retries:    2,
+		RetryDelay: time.Minute,
+		RunOnStart: true,
+		Singleton:  true,
+		Run:        s.RunOnce,
+	}
+}
+
+// RunOnce 为每个持有中的仓位补记截至今日的收益，并结清已到期的仓位
+// Each position catches up from its own last accrual date, so a missed run is
+// recovered by the next one. A position is completed only after every date in
+// its term has been credited.
+func (s *LendingScheduler) RunOnce(ctx context.Context) error {
+	positions, err := s.repo.GetActivePositions(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get active positions: %v", err)
+	}
+
+	now := s.clock.Now()
+	var failed int
+	for _, position := range positions {
+		if err := s.processPosition(ctx, position, now); err != nil {
+			failed++
+			logger.Error("[LendingScheduler] Failed to process position",
+				"position_id", position.ID, "error", err.Error())
+		}
+	}
+
+	logger.Info("[LendingScheduler] Run completed", "positions", len(positions), "failed", failed)
+	if failed > 0 {
+		return fmt.Errorf("%d of %d lending positions failed", failed, len(positions))
+	}
+	return nil
+}
+
+func (s *LendingScheduler) processPosition(ctx context.Context, position *repository.LendingPositionModel, now time.Time) error {
+	start, end := position.Term()
+	today := position.Calendar().BusinessDate(now)
+
+	from := start
+	if position.LastAccrualDate != "" {
+		last, err := time.Parse(accrual.DateLayout, position.LastAccrualDate)
+		if err != nil {
+			return fmt.Errorf("invalid last accrual date %q: %v", position.LastAccrualDate, err)
+		}
+		if last.After(from) {
+			from = last
+		}
+	}
+
+	// 收益在 (start, end] 内的每个营业日入账，每日金额相同
+	dailyYield := accrual.DailyInterest(position.Asset, position.Amount, position.APY, accrual.DayCountACT365)
+	for d := from.AddDate(0, 0, 1); !d.After(today) && !d.After(end); d = d.AddDate(0, 0, 1) {
+		date := d.Format(accrual.DateLayout)
+		err := s.repo.AccrueYield(ctx, position.ID, dailyYield, date)
+		if errors.Is(err, repository.ErrAlreadyExists) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to accrue yield for %s: %v", date, err)
+		}
+		position.AccruedYield = position.AccruedYield.Add(dailyYield)
+		position.LastAccrualDate = date
+	}
+
+	if today.Before(end) {
+		return nil
+	}
+	return s.CompletePosition(ctx, position)
+}
+
+// CompletePosition 到期结清：解冻本金并派发累计收益
+func (s *LendingScheduler) CompletePosition(ctx context.Context, position *repository.LendingPositionModel) error {
+	account, err := s.accountRepo.GetAccountByUserIDAndCurrency(ctx, position.UserID, position.Asset)
+	if err != nil {
+		return fmt.Errorf("failed to get account: %v", err)
+	}
+
+	now := s.clock.Now()
+	yield := position.AccruedYield
+
+	err = s.uow.Do(ctx, func(tx *repository.TxRepository) error {
+		// 条件更新保证与提前终止互斥，重复执行时不会二次派发
+		if err := tx.Lending.ClosePosition(ctx, position.ID, models.LendingStatusCompleted, yield); err != nil {
+			return fmt.Errorf("failed to close position: %v", err)
+		}
+
+		if err := tx.Account.UnfreezeBalance(ctx, account.ID, position.Amount); err != nil {
+			return fmt.Errorf("failed to unfreeze balance: %v", err)
+		}
+		availableAfter := account.Available().Add(position.Amount)
+		if err := tx.Journal.CreateJournalRecord(ctx, &repository.JournalModel{
+			SerialNo:        fmt.Sprintf("LENDING-PRINCIPAL-%s-%d", now.Format("20060102150405"), position.ID),
+			UserID:          position.UserID,
+			AccountID:       account.ID,
+			Amount:          position.Amount,
+			BalanceSnapshot: availableAfter,
+			BizType:         "LENDING_UNFREEZE",
+			RefID:           &position.ID,
+			CreatedAt:       now.Format(time.RFC3339),
+		}); err != nil {
+			return fmt.Errorf("failed to create principal journal record: %v", err)
+		}
+
+		if !yield.IsPositive() {
+			return nil
+		}
+		if err := tx.Account.AddBalance(ctx, account.ID, yield); err != nil {
+			return fmt.Errorf("failed to add yield to balance: %v", err)
+		}
+		if err := tx.Journal.CreateJournalRecord(ctx, &repository.JournalModel{
+			SerialNo:        fmt.Sprintf("LENDING-YIELD-%s-%d", now.Format("20060102150405"), position.ID),
+			UserID:          position.UserID,
+			AccountID:       account.ID,
+			Amount:          yield,
+			BalanceSnapshot: availableAfter.Add(yield),
+			BizType:         "LENDING_YIELD",
+			RefID:           &position.ID,
+			CreatedAt:       now.Format(time.RFC3339),
+		}); err != nil {
+			return fmt.Errorf("failed to create yield journal record: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.Info("[LendingScheduler] Position completed",
+		"position_id", position.ID,
+		"principal", position.Amount.String(),
+		"yield_paid", yield.String())
+	return nil
+}
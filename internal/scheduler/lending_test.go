@@ -0,0 +1,110 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"monera-digital/internal/accrual"
+	"monera-digital/internal/clock"
+	"monera-digital/internal/models"
+	"monera-digital/internal/money"
+	"monera-digital/internal/repository"
+)
+
+func newTestLendingScheduler(now time.Time) (*LendingScheduler, *MockLendingRepository, *MockAccountRepositoryV2, *MockJournalRepository, *MockUnitOfWork) {
+	lendingRepo := new(MockLendingRepository)
+	accountRepo := new(MockAccountRepositoryV2)
+	journalRepo := new(MockJournalRepository)
+	uow := NewMockUnitOfWork(nil, accountRepo, journalRepo)
+	uow.Repos.Lending = lendingRepo
+	s := NewLendingScheduler(lendingRepo, accountRepo, uow)
+	s.SetClock(clock.NewFake(now))
+	return s, lendingRepo, accountRepo, journalRepo, uow
+}
+
+func TestLendingScheduler_RunOnce_CatchesUpMissedDates(t *testing.T) {
+	// 2026-03-15 10:00 北京时间，上次入账为 03-12
+	s, lendingRepo, _, _, uow := newTestLendingScheduler(time.Date(2026, 3, 15, 2, 0, 0, 0, time.UTC))
+
+	position := &repository.LendingPositionModel{
+		ID: 1, UserID: 1, Asset: "USDT", Amount: money.MustParse("500"), DurationDays: 30,
+		APY: money.MustParse("8.5"), AccruedYield: money.Zero, Status: models.LendingStatusActive,
+		LastAccrualDate: "2026-03-12",
+		StartDate:       time.Date(2026, 3, 10, 2, 0, 0, 0, time.UTC),
+	}
+	daily := accrual.DailyInterest("USDT", position.Amount, position.APY, accrual.DayCountACT365)
+
+	lendingRepo.On("GetActivePositions", mock.Anything).Return([]*repository.LendingPositionModel{position}, nil)
+	lendingRepo.On("AccrueYield", mock.Anything, int64(1), daily, "2026-03-13").Return(nil)
+	lendingRepo.On("AccrueYield", mock.Anything, int64(1), daily, "2026-03-14").Return(repository.ErrAlreadyExists)
+	lendingRepo.On("AccrueYield", mock.Anything, int64(1), daily, "2026-03-15").Return(nil)
+
+	err := s.RunOnce(context.Background())
+
+	assert.NoError(t, err)
+	lendingRepo.AssertExpectations(t)
+	lendingRepo.AssertNumberOfCalls(t, "AccrueYield", 3)
+	assert.False(t, wasCalled(&lendingRepo.Mock, "ClosePosition"))
+	assert.Equal(t, 0, uow.Commits)
+}
+
+func TestLendingScheduler_RunOnce_CompletesMaturedPosition(t *testing.T) {
+	// 7 天期仓位 03-10 起息，03-17 到期；03-18 运行时补记最后一天并结清
+	s, lendingRepo, accountRepo, journalRepo, uow := newTestLendingScheduler(time.Date(2026, 3, 18, 2, 0, 0, 0, time.UTC))
+
+	position := &repository.LendingPositionModel{
+		ID: 1, UserID: 1, Asset: "USDT", Amount: money.MustParse("500"), DurationDays: 7,
+		APY: money.MustParse("8.5"), AccruedYield: money.MustParse("0.6"), Status: models.LendingStatusActive,
+		LastAccrualDate: "2026-03-16",
+		StartDate:       time.Date(2026, 3, 10, 2, 0, 0, 0, time.UTC),
+	}
+	daily := accrual.DailyInterest("USDT", position.Amount, position.APY, accrual.DayCountACT365)
+	yield := money.MustParse("0.6").Add(daily)
+	account := &repository.AccountModel{ID: 7, UserID: 1, Currency: "USDT", Balance: money.MustParse("1000"), FrozenBalance: money.MustParse("500")}
+
+	lendingRepo.On("GetActivePositions", mock.Anything).Return([]*repository.LendingPositionModel{position}, nil)
+	lendingRepo.On("AccrueYield", mock.Anything, int64(1), daily, "2026-03-17").Return(nil)
+	accountRepo.On("GetAccountByUserIDAndCurrency", mock.Anything, int64(1), "USDT").Return(account, nil)
+	lendingRepo.On("ClosePosition", mock.Anything, int64(1), models.LendingStatusCompleted, yield).Return(nil)
+	accountRepo.On("UnfreezeBalance", mock.Anything, int64(7), money.MustParse("500")).Return(nil)
+	accountRepo.On("AddBalance", mock.Anything, int64(7), yield).Return(nil)
+	journalRepo.On("CreateJournalRecord", mock.Anything, mock.MatchedBy(func(j *repository.JournalModel) bool {
+		return j.BizType == "LENDING_UNFREEZE" && j.Amount.String() == "500" && j.BalanceSnapshot.String() == "1000"
+	})).Return(nil).Once()
+	journalRepo.On("CreateJournalRecord", mock.Anything, mock.MatchedBy(func(j *repository.JournalModel) bool {
+		return j.BizType == "LENDING_YIELD" && j.Amount.Equal(yield) && j.BalanceSnapshot.Equal(money.MustParse("1000").Add(yield))
+	})).Return(nil).Once()
+
+	err := s.RunOnce(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, uow.Commits)
+	lendingRepo.AssertExpectations(t)
+	accountRepo.AssertExpectations(t)
+	journalRepo.AssertExpectations(t)
+}
+
+func TestLendingScheduler_RunOnce_ReportsFailedPositions(t *testing.T) {
+	s, lendingRepo, accountRepo, _, uow := newTestLendingScheduler(time.Date(2026, 3, 18, 2, 0, 0, 0, time.UTC))
+
+	position := &repository.LendingPositionModel{
+		ID: 1, UserID: 1, Asset: "USDT", Amount: money.MustParse("500"), DurationDays: 7,
+		APY: money.MustParse("8.5"), AccruedYield: money.MustParse("0.7"), Status: models.LendingStatusActive,
+		LastAccrualDate: "2026-03-17",
+		StartDate:       time.Date(2026, 3, 10, 2, 0, 0, 0, time.UTC),
+	}
+
+	lendingRepo.On("GetActivePositions", mock.Anything).Return([]*repository.LendingPositionModel{position}, nil)
+	accountRepo.On("GetAccountByUserIDAndCurrency", mock.Anything, int64(1), "USDT").Return(&repository.AccountModel{ID: 7}, nil)
+	lendingRepo.On("ClosePosition", mock.Anything, int64(1), models.LendingStatusCompleted, mock.Anything).Return(repository.ErrNotFound)
+
+	err := s.RunOnce(context.Background())
+
+	assert.Error(t, err)
+	assert.Equal(t, 1, uow.Rollbacks)
+	assert.False(t, wasCalled(&lendingRepo.Mock, "AccrueYield"))
+	assert.False(t, wasCalled(&accountRepo.Mock, "UnfreezeBalance"))
+}
@@ -0,0 +1,228 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"monera-digital/internal/binance"
+	"monera-digital/internal/clock"
+	"monera-digital/internal/logger"
+	"monera-digital/internal/money"
+	"monera-digital/internal/repository"
+)
+
+// LoanMonitorJobName LTV 监控任务的名称
+const LoanMonitorJobName = "loan_ltv_monitor"
+
+// MaxPriceAge 报价的最大可用时长，为价格刷新间隔（5 分钟）的两倍；
+// 超过后不再按报价追保或清算
+const MaxPriceAge = 10 * time.Minute
+
+// ErrStalePrices 报价超过 MaxPriceAge 未刷新
+var ErrStalePrices = errors.New("price feed is stale")
+
+type LoanMonitor struct {
+	repo        repository.Loan
+	accountRepo repository.AccountV2
+	uow         repository.UnitOfWork
+	prices      binance.PriceFeed
+	clock       clock.Clock
+}
+
+func NewLoanMonitor(loanRepo repository.Loan, accountRepo repository.AccountV2, uow repository.UnitOfWork, prices binance.PriceFeed) *LoanMonitor {
+	return &LoanMonitor{
+		repo:        loanRepo,
+		accountRepo: accountRepo,
+		uow:         uow,
+		prices:      prices,
+		clock:       clock.System,
+	}
+}
+
+// SetClock 替换流水时间的来源，供测试与模拟器拨动时间
+func (m *LoanMonitor) SetClock(c clock.Clock) {
+	m.clock = c
+}
+
+// Job LTV 监控任务的注册信息：价格每 5 分钟刷新一次，监控在每次刷新后 1 分钟执行
+func (m *LoanMonitor) Job() Job {
+	return Job{
+		Name:       LoanMonitorJobName,
+		Schedule:   "1-59/5 * * * *",
+		Timeout:    4 * time.Minute,
+		Retries:    1,
+		RetryDelay: 30 * time.Second,
+		Singleton:  true,
+		Run:        m.RunOnce,
+	}
+}
+
+// LoanCheckResult 单次监控的处理结果
+type LoanCheckResult struct {
+	Checked     int
+	MarginCalls int
+	Cleared     int
+	Liquidated  int
+	Skipped     int // 没有可用报价
+}
+
+// RunOnce 按当前价格检查全部未结清借款
+func (m *LoanMonitor) RunOnce(ctx context.Context) error {
+	_, err := m.CheckLoans(ctx)
+	return err
+}
+
+// CheckLoans 按当前价格重算每笔未结清借款的 LTV：
+// at or above LiquidationLTV the loan is liquidated, at or above MarginCallLTV
+// it is flagged MARGIN_CALL, and a flagged loan back below MarginCallLTV is
+// cleared. Loans whose collateral has no price are skipped, never liquidated;
+// a feed older than MaxPriceAge skips every loan and fails the run.
+func (m *LoanMonitor) CheckLoans(ctx context.Context) (*LoanCheckResult, error) {
+	loans, err := m.repo.GetOpenLoans(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get open loans: %v", err)
+	}
+
+	result := &LoanCheckResult{}
+	if age := m.clock.Now().Sub(m.prices.GetLastUpdateTime()); age > MaxPriceAge {
+		// 价格刷新任务长时间失败时，旧报价既可能误清算也可能漏掉真实穿仓
+		result.Checked = len(loans)
+		result.Skipped = len(loans)
+		logger.Error("[LoanMonitor] Price feed is stale, skipping all loans",
+			"age", age.String(), "loans", len(loans))
+		return result, fmt.Errorf("%w: last update %s ago", ErrStalePrices, age.Round(time.Second))
+	}
+
+	var failed int
+	for _, loan := range loans {
+		result.Checked++
+		price, ok := binance.PriceOf(m.prices, loan.CollateralAsset)
+		if !ok {
+			result.Skipped++
+			logger.Warn("[LoanMonitor] No price for collateral, skipping",
+				"loan_id", loan.ID, "asset", loan.CollateralAsset)
+			continue
+		}
+		ltv, ok := loan.LTV(price)
+		if !ok {
+			result.Skipped++
+			continue
+		}
+
+		switch {
+		case !ltv.LessThan(loan.LiquidationLTV):
+			err = m.Liquidate(ctx, loan, price)
+			if err == nil {
+				result.Liquidated++
+			}
+		case !ltv.LessThan(loan.MarginCallLTV) && loan.Status == repository.LoanStatusActive:
+			err = m.setStatus(ctx, loan, repository.LoanStatusMarginCall)
+			if err == nil {
+				result.MarginCalls++
+				logger.Warn("[LoanMonitor] Margin call",
+					"loan_id", loan.ID, "user_id", loan.UserID, "ltv", ltv.String(), "price", price.String())
+			}
+		case ltv.LessThan(loan.MarginCallLTV) && loan.Status == repository.LoanStatusMarginCall:
+			err = m.setStatus(ctx, loan, repository.LoanStatusActive)
+			if err == nil {
+				result.Cleared++
+			}
+		default:
+			err = nil
+		}
+		if err != nil {
+			failed++
+			logger.Error("[LoanMonitor] Failed to process loan",
+				"loan_id", loan.ID, "ltv", ltv.String(), "error", err.Error())
+		}
+	}
+
+	logger.Info("[LoanMonitor] Check completed",
+		"checked", result.Checked,
+		"margin_calls", result.MarginCalls,
+		"cleared", result.Cleared,
+		"liquidated", result.Liquidated,
+		"skipped", result.Skipped)
+	if failed > 0 {
+		return result, fmt.Errorf("%d of %d loans failed", failed, len(loans))
+	}
+	return result, nil
+}
+
+// setStatus 切换追保状态；借款已被并发结清或变更时忽略
+func (m *LoanMonitor) setStatus(ctx context.Context, loan *repository.LoanModel, to repository.LoanStatus) error {
+	err := m.repo.SetLoanStatus(ctx, loan.ID, loan.Status, to)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil
+	}
+	return err
+}
+
+// Liquidate 强制清算：按 price 扣划覆盖本金与罚金所需的抵押物，剩余抵押物解冻返还
+func (m *LoanMonitor) Liquidate(ctx context.Context, loan *repository.LoanModel, price money.Decimal) error {
+	account, err := m.accountRepo.GetAccountByUserIDAndCurrency(ctx, loan.UserID, loan.CollateralAsset)
+	if err != nil {
+		return fmt.Errorf("failed to get collateral account: %v", err)
+	}
+
+	spec := money.SpecFor(loan.CollateralAsset)
+	debt := loan.Principal.Mul(money.NewFromInt(1).Add(loan.LiquidationPenalty))
+	seized := money.Min(debt.Div(price, spec.Scale, money.RoundUp), loan.CollateralAmount)
+	loan.LiquidatedCollateral = seized
+	loan.LiquidationPrice = price
+
+	now := m.clock.Now()
+	err = m.uow.Do(ctx, func(tx *repository.TxRepository) error {
+		if err := tx.Loan.CloseLoan(ctx, loan, repository.LoanStatusLiquidated); err != nil {
+			return fmt.Errorf("failed to close loan: %v", err)
+		}
+
+		if err := tx.Account.UnfreezeBalance(ctx, account.ID, loan.CollateralAmount); err != nil {
+			return fmt.Errorf("failed to unfreeze collateral: %v", err)
+		}
+		availableAfter := account.Available().Add(loan.CollateralAmount)
+		if err := tx.Journal.CreateJournalRecord(ctx, &repository.JournalModel{
+			SerialNo:        fmt.Sprintf("LOAN-RELEASE-%s-%d", now.Format("20060102150405"), loan.ID),
+			UserID:          loan.UserID,
+			AccountID:       account.ID,
+			Amount:          loan.CollateralAmount,
+			BalanceSnapshot: availableAfter,
+			BizType:         "LOAN_COLLATERAL_RELEASE",
+			RefID:           &loan.ID,
+			CreatedAt:       now.Format(time.RFC3339),
+		}); err != nil {
+			return fmt.Errorf("failed to create release journal record: %v", err)
+		}
+
+		if err := tx.Account.DeductBalance(ctx, account.ID, seized); err != nil {
+			return fmt.Errorf("failed to deduct seized collateral: %v", err)
+		}
+		if err := tx.Journal.CreateJournalRecord(ctx, &repository.JournalModel{
+			SerialNo:        fmt.Sprintf("LOAN-LIQUIDATION-%s-%d", now.Format("20060102150405"), loan.ID),
+			UserID:          loan.UserID,
+			AccountID:       account.ID,
+			Amount:          seized.Neg(),
+			BalanceSnapshot: availableAfter.Sub(seized),
+			BizType:         "LOAN_LIQUIDATION",
+			RefID:           &loan.ID,
+			CreatedAt:       now.Format(time.RFC3339),
+		}); err != nil {
+			return fmt.Errorf("failed to create liquidation journal record: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.Warn("[LoanMonitor] Loan liquidated",
+		"loan_id", loan.ID,
+		"user_id", loan.UserID,
+		"price", price.String(),
+		"principal", loan.Principal.String(),
+		"collateral", loan.CollateralAmount.String(),
+		"seized", seized.String())
+	return nil
+}
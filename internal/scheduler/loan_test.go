@@ -0,0 +1,157 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"monera-digital/internal/binance"
+	"monera-digital/internal/clock"
+	"monera-digital/internal/money"
+	"monera-digital/internal/repository"
+)
+
+func newTestLoanMonitor(prices map[string]float64) (*LoanMonitor, *MockLoanRepository, *MockAccountRepositoryV2, *MockJournalRepository, *MockUnitOfWork) {
+	loanRepo := new(MockLoanRepository)
+	accountRepo := new(MockAccountRepositoryV2)
+	journalRepo := new(MockJournalRepository)
+	uow := NewMockUnitOfWork(nil, accountRepo, journalRepo)
+	uow.Repos.Loan = loanRepo
+	now := time.Date(2026, 3, 10, 2, 0, 0, 0, time.UTC)
+	feed := binance.NewStaticPriceFeed(prices)
+	feed.SetUpdatedAt(now.Add(-time.Minute))
+	m := NewLoanMonitor(loanRepo, accountRepo, uow, feed)
+	m.SetClock(clock.NewFake(now))
+	return m, loanRepo, accountRepo, journalRepo, uow
+}
+
+func btcLoan(status repository.LoanStatus) *repository.LoanModel {
+	return &repository.LoanModel{
+		ID: 9, UserID: 1, CollateralAsset: "BTC", CollateralAmount: money.MustParse("0.1"),
+		BorrowAsset: "USDT", Principal: money.MustParse("3000"),
+		MarginCallLTV: money.MustParse("0.7"), LiquidationLTV: money.MustParse("0.8"),
+		LiquidationPenalty: money.MustParse("0.05"), Status: status,
+	}
+}
+
+func TestLoanMonitor_CheckLoans_MarginCallThresholds(t *testing.T) {
+	tests := []struct {
+		name       string
+		price      float64
+		status     repository.LoanStatus
+		wantTo     repository.LoanStatus // 为空表示不切换状态
+		wantResult LoanCheckResult
+	}{
+		{name: "healthy", price: 60000, status: repository.LoanStatusActive, wantResult: LoanCheckResult{Checked: 1}},
+		{name: "margin call", price: 42000, status: repository.LoanStatusActive, wantTo: repository.LoanStatusMarginCall, wantResult: LoanCheckResult{Checked: 1, MarginCalls: 1}},
+		{name: "already flagged", price: 42000, status: repository.LoanStatusMarginCall, wantResult: LoanCheckResult{Checked: 1}},
+		{name: "recovered", price: 60000, status: repository.LoanStatusMarginCall, wantTo: repository.LoanStatusActive, wantResult: LoanCheckResult{Checked: 1, Cleared: 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, loanRepo, accountRepo, _, _ := newTestLoanMonitor(map[string]float64{"BTC": tt.price})
+			loanRepo.On("GetOpenLoans", mock.Anything).Return([]*repository.LoanModel{btcLoan(tt.status)}, nil)
+			if tt.wantTo != "" {
+				loanRepo.On("SetLoanStatus", mock.Anything, int64(9), tt.status, tt.wantTo).Return(nil)
+			}
+
+			result, err := m.CheckLoans(context.Background())
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantResult, *result)
+			assert.Equal(t, tt.wantTo != "", wasCalled(&loanRepo.Mock, "SetLoanStatus"))
+			assert.False(t, wasCalled(&accountRepo.Mock, "DeductBalance"))
+		})
+	}
+}
+
+func TestLoanMonitor_CheckLoans_Liquidates(t *testing.T) {
+	tests := []struct {
+		name   string
+		price  float64
+		seized string
+	}{
+		// 3000 × 1.05 / 37000 = 0.085135135…，向上取整到 BTC 最小单位
+		{name: "penalty covered by collateral", price: 37000, seized: "0.08513514"},
+		// 抵押物不足以覆盖本金与罚金时全部扣划
+		{name: "underwater", price: 30000, seized: "0.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, loanRepo, accountRepo, journalRepo, uow := newTestLoanMonitor(map[string]float64{"BTC": tt.price})
+			seized := money.MustParse(tt.seized)
+			account := &repository.AccountModel{ID: 1, UserID: 1, Currency: "BTC", Balance: money.MustParse("0.5"), FrozenBalance: money.MustParse("0.1")}
+
+			loanRepo.On("GetOpenLoans", mock.Anything).Return([]*repository.LoanModel{btcLoan(repository.LoanStatusMarginCall)}, nil)
+			accountRepo.On("GetAccountByUserIDAndCurrency", mock.Anything, int64(1), "BTC").Return(account, nil)
+			loanRepo.On("CloseLoan", mock.Anything, mock.MatchedBy(func(l *repository.LoanModel) bool {
+				return l.LiquidatedCollateral.Equal(seized) && l.CollateralAmount.Equal(money.MustParse("0.1"))
+			}), repository.LoanStatusLiquidated).Return(nil)
+			accountRepo.On("UnfreezeBalance", mock.Anything, int64(1), money.MustParse("0.1")).Return(nil)
+			accountRepo.On("DeductBalance", mock.Anything, int64(1), seized).Return(nil)
+			journalRepo.On("CreateJournalRecord", mock.Anything, mock.MatchedBy(func(j *repository.JournalModel) bool {
+				return j.BizType == "LOAN_COLLATERAL_RELEASE" && j.Amount.String() == "0.1" && j.BalanceSnapshot.String() == "0.5"
+			})).Return(nil).Once()
+			journalRepo.On("CreateJournalRecord", mock.Anything, mock.MatchedBy(func(j *repository.JournalModel) bool {
+				return j.BizType == "LOAN_LIQUIDATION" && j.Amount.Equal(seized.Neg()) &&
+					j.BalanceSnapshot.Equal(money.MustParse("0.5").Sub(seized))
+			})).Return(nil).Once()
+
+			result, err := m.CheckLoans(context.Background())
+
+			assert.NoError(t, err)
+			assert.Equal(t, 1, result.Liquidated)
+			assert.Equal(t, 1, uow.Commits)
+			loanRepo.AssertExpectations(t)
+			accountRepo.AssertExpectations(t)
+			journalRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestLoanMonitor_CheckLoans_StalePricesSkipAndFail(t *testing.T) {
+	// 报价远低于清算线，但价格刷新已停止 30 分钟
+	m, loanRepo, accountRepo, _, uow := newTestLoanMonitor(map[string]float64{"BTC": 20000})
+	m.prices.(*binance.StaticPriceFeed).SetUpdatedAt(time.Date(2026, 3, 10, 1, 30, 0, 0, time.UTC))
+	loanRepo.On("GetOpenLoans", mock.Anything).Return([]*repository.LoanModel{
+		btcLoan(repository.LoanStatusActive), btcLoan(repository.LoanStatusMarginCall),
+	}, nil)
+
+	result, err := m.CheckLoans(context.Background())
+
+	assert.ErrorIs(t, err, ErrStalePrices)
+	assert.Equal(t, LoanCheckResult{Checked: 2, Skipped: 2}, *result)
+	assert.Equal(t, 0, uow.Commits+uow.Rollbacks)
+	assert.False(t, wasCalled(&loanRepo.Mock, "SetLoanStatus"))
+	assert.False(t, wasCalled(&accountRepo.Mock, "GetAccountByUserIDAndCurrency"))
+}
+
+func TestLoanMonitor_CheckLoans_SkipsWithoutPrice(t *testing.T) {
+	m, loanRepo, _, _, uow := newTestLoanMonitor(map[string]float64{"BTC": 1000})
+	loan := btcLoan(repository.LoanStatusActive)
+	loan.CollateralAsset = "ETH"
+	loanRepo.On("GetOpenLoans", mock.Anything).Return([]*repository.LoanModel{loan}, nil)
+
+	result, err := m.CheckLoans(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, LoanCheckResult{Checked: 1, Skipped: 1}, *result)
+	assert.Equal(t, 0, uow.Commits+uow.Rollbacks)
+}
+
+func TestLoanMonitor_CheckLoans_CollateralAddedDuringLiquidation(t *testing.T) {
+	m, loanRepo, accountRepo, _, uow := newTestLoanMonitor(map[string]float64{"BTC": 30000})
+	loanRepo.On("GetOpenLoans", mock.Anything).Return([]*repository.LoanModel{btcLoan(repository.LoanStatusActive)}, nil)
+	accountRepo.On("GetAccountByUserIDAndCurrency", mock.Anything, int64(1), "BTC").Return(&repository.AccountModel{ID: 1}, nil)
+	loanRepo.On("CloseLoan", mock.Anything, mock.Anything, repository.LoanStatusLiquidated).Return(repository.ErrNotFound)
+
+	_, err := m.CheckLoans(context.Background())
+
+	assert.Error(t, err)
+	assert.Equal(t, 1, uow.Rollbacks)
+	assert.False(t, wasCalled(&accountRepo.Mock, "UnfreezeBalance"))
+}
@@ -2,7 +2,10 @@ package scheduler
 
 import (
 	"context"
+	"monera-digital/internal/models"
+	"monera-digital/internal/money"
 	"monera-digital/internal/repository"
+	"time"
 
 	"github.com/stretchr/testify/mock"
 )
@@ -48,16 +51,72 @@ func (m *MockWealthRepository) GetOrderByID(ctx context.Context, id int64) (*rep
 	return args.Get(0).(*repository.WealthOrderModel), args.Error(1)
 }
 
+func (m *MockWealthRepository) GetOrderForUpdate(ctx context.Context, id int64) (*repository.WealthOrderModel, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.WealthOrderModel), args.Error(1)
+}
+
 func (m *MockWealthRepository) UpdateOrder(ctx context.Context, order *repository.WealthOrderModel) error {
 	args := m.Called(ctx, order)
 	return args.Error(0)
 }
 
-func (m *MockWealthRepository) UpdateProductSoldQuota(ctx context.Context, id int64, amount string) error {
+func (m *MockWealthRepository) ReserveProductQuota(ctx context.Context, id int64, amount money.Decimal) error {
 	args := m.Called(ctx, id, amount)
 	return args.Error(0)
 }
 
+func (m *MockWealthRepository) ReleaseProductQuota(ctx context.Context, id int64, amount money.Decimal) error {
+	args := m.Called(ctx, id, amount)
+	return args.Error(0)
+}
+
+func (m *MockWealthRepository) AddPrincipal(ctx context.Context, orderID int64, amount money.Decimal) error {
+	args := m.Called(ctx, orderID, amount)
+	return args.Error(0)
+}
+
+func (m *MockWealthRepository) ListProducts(ctx context.Context) ([]*repository.WealthProductModel, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*repository.WealthProductModel), args.Error(1)
+}
+
+func (m *MockWealthRepository) CreateProduct(ctx context.Context, product *repository.WealthProductModel) error {
+	args := m.Called(ctx, product)
+	return args.Error(0)
+}
+
+func (m *MockWealthRepository) UpdateProduct(ctx context.Context, product *repository.WealthProductModel) error {
+	args := m.Called(ctx, product)
+	return args.Error(0)
+}
+
+func (m *MockWealthRepository) UpdateProductStatus(ctx context.Context, id int64, status int) error {
+	args := m.Called(ctx, id, status)
+	return args.Error(0)
+}
+
+func (m *MockWealthRepository) CreateOrderAudit(ctx context.Context, audit *repository.WealthOrderAuditModel) error {
+	args := m.Called(ctx, audit)
+	return args.Error(0)
+}
+
+func (m *MockWealthRepository) UpdateRenewalOptions(ctx context.Context, orderID int64, autoRenew, compound bool) error {
+	args := m.Called(ctx, orderID, autoRenew, compound)
+	return args.Error(0)
+}
+
+func (m *MockWealthRepository) CancelOrder(ctx context.Context, orderID int64) error {
+	args := m.Called(ctx, orderID)
+	return args.Error(0)
+}
+
 func (m *MockWealthRepository) GetActiveOrders(ctx context.Context) ([]*repository.WealthOrderModel, error) {
 	args := m.Called(ctx)
 	if args.Get(0) == nil {
@@ -66,31 +125,34 @@ func (m *MockWealthRepository) GetActiveOrders(ctx context.Context) ([]*reposito
 	return args.Get(0).([]*repository.WealthOrderModel), args.Error(1)
 }
 
-func (m *MockWealthRepository) GetExpiredOrders(ctx context.Context) ([]*repository.WealthOrderModel, error) {
-	args := m.Called(ctx)
+func (m *MockWealthRepository) GetExpiredOrders(ctx context.Context, asOf string) ([]*repository.WealthOrderModel, error) {
+	args := m.Called(ctx, asOf)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).([]*repository.WealthOrderModel), args.Error(1)
 }
 
-func (m *MockWealthRepository) AccrueInterest(ctx context.Context, orderID int64, amount string, date string) error {
+func (m *MockWealthRepository) AccrueInterest(ctx context.Context, orderID int64, amount money.Decimal, date string) error {
 	args := m.Called(ctx, orderID, amount, date)
 	return args.Error(0)
 }
 
-func (m *MockWealthRepository) UpdateInterestAccrued(ctx context.Context, orderID int64, interestAccrued string) error {
-	args := m.Called(ctx, orderID, interestAccrued)
-	return args.Error(0)
+func (m *MockWealthRepository) GetInterestRecords(ctx context.Context, orderID int64) ([]*repository.InterestRecordModel, error) {
+	args := m.Called(ctx, orderID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*repository.InterestRecordModel), args.Error(1)
 }
 
-func (m *MockWealthRepository) SettleOrder(ctx context.Context, orderID int64, interestPaid string) error {
+func (m *MockWealthRepository) SettleOrder(ctx context.Context, orderID int64, interestPaid money.Decimal) error {
 	args := m.Called(ctx, orderID, interestPaid)
 	return args.Error(0)
 }
 
-func (m *MockWealthRepository) RenewOrder(ctx context.Context, order *repository.WealthOrderModel, product *repository.WealthProductModel, startDate string, endDate string) (*repository.WealthOrderModel, error) {
-	args := m.Called(ctx, order, product, startDate, endDate)
+func (m *MockWealthRepository) RenewOrder(ctx context.Context, order *repository.WealthOrderModel, product *repository.WealthProductModel, principal, interestExpected money.Decimal, startDate, endDate string, renewedAt time.Time) (*repository.WealthOrderModel, error) {
+	args := m.Called(ctx, order, product, principal, interestExpected, startDate, endDate, renewedAt)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -118,26 +180,163 @@ func (m *MockAccountRepositoryV2) GetAccountsByUserID(ctx context.Context, userI
 	return args.Get(0).([]*repository.AccountModel), args.Error(1)
 }
 
-func (m *MockAccountRepositoryV2) FreezeBalance(ctx context.Context, accountID int64, amount string) error {
+func (m *MockAccountRepositoryV2) FreezeBalance(ctx context.Context, accountID int64, amount money.Decimal) error {
 	args := m.Called(ctx, accountID, amount)
 	return args.Error(0)
 }
 
-func (m *MockAccountRepositoryV2) UnfreezeBalance(ctx context.Context, accountID int64, amount string) error {
+func (m *MockAccountRepositoryV2) UnfreezeBalance(ctx context.Context, accountID int64, amount money.Decimal) error {
 	args := m.Called(ctx, accountID, amount)
 	return args.Error(0)
 }
 
-func (m *MockAccountRepositoryV2) DeductBalance(ctx context.Context, accountID int64, amount string) error {
+func (m *MockAccountRepositoryV2) DeductBalance(ctx context.Context, accountID int64, amount money.Decimal) error {
 	args := m.Called(ctx, accountID, amount)
 	return args.Error(0)
 }
 
-func (m *MockAccountRepositoryV2) AddBalance(ctx context.Context, accountID int64, amount string) error {
+func (m *MockAccountRepositoryV2) AddBalance(ctx context.Context, accountID int64, amount money.Decimal) error {
 	args := m.Called(ctx, accountID, amount)
 	return args.Error(0)
 }
 
+func (m *MockAccountRepositoryV2) CreateTransferRecord(ctx context.Context, record *repository.TransferRecordModel) error {
+	args := m.Called(ctx, record)
+	return args.Error(0)
+}
+
+func (m *MockAccountRepositoryV2) GetAccountByType(ctx context.Context, userID int64, accountType, currency string) (*repository.AccountModel, error) {
+	args := m.Called(ctx, userID, accountType, currency)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.AccountModel), args.Error(1)
+}
+
+func (m *MockAccountRepositoryV2) CreateAccount(ctx context.Context, account *repository.AccountModel) error {
+	args := m.Called(ctx, account)
+	return args.Error(0)
+}
+
+func (m *MockAccountRepositoryV2) UpdateBalance(ctx context.Context, accountID int64, version int64, delta money.Decimal) error {
+	args := m.Called(ctx, accountID, version, delta)
+	return args.Error(0)
+}
+
+// MockLendingRepository implements repository.Lending interface for testing
+type MockLendingRepository struct {
+	mock.Mock
+}
+
+func (m *MockLendingRepository) CreatePosition(ctx context.Context, position *repository.LendingPositionModel) error {
+	args := m.Called(ctx, position)
+	return args.Error(0)
+}
+
+func (m *MockLendingRepository) GetPositionsByUserID(ctx context.Context, userID int64) ([]*repository.LendingPositionModel, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*repository.LendingPositionModel), args.Error(1)
+}
+
+func (m *MockLendingRepository) GetPositionByID(ctx context.Context, id int64) (*repository.LendingPositionModel, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.LendingPositionModel), args.Error(1)
+}
+
+func (m *MockLendingRepository) GetActivePositions(ctx context.Context) ([]*repository.LendingPositionModel, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*repository.LendingPositionModel), args.Error(1)
+}
+
+func (m *MockLendingRepository) AccrueYield(ctx context.Context, positionID int64, amount money.Decimal, date string) error {
+	args := m.Called(ctx, positionID, amount, date)
+	return args.Error(0)
+}
+
+func (m *MockLendingRepository) ClosePosition(ctx context.Context, positionID int64, status models.LendingStatus, yieldPaid money.Decimal) error {
+	args := m.Called(ctx, positionID, status, yieldPaid)
+	return args.Error(0)
+}
+
+func (m *MockLendingRepository) GetRates(ctx context.Context, date string) ([]*repository.LendingRateModel, error) {
+	args := m.Called(ctx, date)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*repository.LendingRateModel), args.Error(1)
+}
+
+func (m *MockLendingRepository) ListRates(ctx context.Context, asset string) ([]*repository.LendingRateModel, error) {
+	args := m.Called(ctx, asset)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*repository.LendingRateModel), args.Error(1)
+}
+
+func (m *MockLendingRepository) SaveRates(ctx context.Context, asset string, effectiveDate string, rates []*repository.LendingRateModel) error {
+	args := m.Called(ctx, asset, effectiveDate, rates)
+	return args.Error(0)
+}
+
+// MockLoanRepository implements repository.Loan interface for testing
+type MockLoanRepository struct {
+	mock.Mock
+}
+
+func (m *MockLoanRepository) CreateLoan(ctx context.Context, loan *repository.LoanModel) error {
+	args := m.Called(ctx, loan)
+	return args.Error(0)
+}
+
+func (m *MockLoanRepository) GetLoanByID(ctx context.Context, id int64) (*repository.LoanModel, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.LoanModel), args.Error(1)
+}
+
+func (m *MockLoanRepository) GetLoansByUserID(ctx context.Context, userID int64) ([]*repository.LoanModel, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*repository.LoanModel), args.Error(1)
+}
+
+func (m *MockLoanRepository) GetOpenLoans(ctx context.Context) ([]*repository.LoanModel, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*repository.LoanModel), args.Error(1)
+}
+
+func (m *MockLoanRepository) SetLoanStatus(ctx context.Context, id int64, from, to repository.LoanStatus) error {
+	args := m.Called(ctx, id, from, to)
+	return args.Error(0)
+}
+
+func (m *MockLoanRepository) AddCollateral(ctx context.Context, id int64, amount money.Decimal) error {
+	args := m.Called(ctx, id, amount)
+	return args.Error(0)
+}
+
+func (m *MockLoanRepository) CloseLoan(ctx context.Context, loan *repository.LoanModel, status repository.LoanStatus) error {
+	args := m.Called(ctx, loan, status)
+	return args.Error(0)
+}
+
 type MockJournalRepository struct {
 	mock.Mock
 }
@@ -146,3 +345,151 @@ func (m *MockJournalRepository) CreateJournalRecord(ctx context.Context, record
 	args := m.Called(ctx, record)
 	return args.Error(0)
 }
+
+func (m *MockJournalRepository) GetJournalRecordsByRef(ctx context.Context, userID, refID int64, bizTypes []string) ([]*repository.JournalModel, error) {
+	args := m.Called(ctx, userID, refID, bizTypes)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*repository.JournalModel), args.Error(1)
+}
+
+type MockSchedulerRunRepository struct {
+	mock.Mock
+}
+
+func (m *MockSchedulerRunRepository) TryLock(ctx context.Context, jobName string) (func(), bool, error) {
+	args := m.Called(ctx, jobName)
+	if args.Get(0) == nil {
+		return nil, args.Bool(1), args.Error(2)
+	}
+	return args.Get(0).(func()), args.Bool(1), args.Error(2)
+}
+
+func (m *MockSchedulerRunRepository) GetLastSuccessDate(ctx context.Context, jobName string) (string, error) {
+	args := m.Called(ctx, jobName)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockSchedulerRunRepository) CreateRun(ctx context.Context, run *repository.SchedulerRunModel) error {
+	args := m.Called(ctx, run)
+	return args.Error(0)
+}
+
+func (m *MockSchedulerRunRepository) FinishRun(ctx context.Context, run *repository.SchedulerRunModel) error {
+	args := m.Called(ctx, run)
+	return args.Error(0)
+}
+
+func (m *MockSchedulerRunRepository) ListRuns(ctx context.Context, jobName string, limit int) ([]*repository.SchedulerRunModel, error) {
+	args := m.Called(ctx, jobName, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*repository.SchedulerRunModel), args.Error(1)
+}
+
+type MockSchedulerJobRepository struct {
+	mock.Mock
+}
+
+func (m *MockSchedulerJobRepository) GetJob(ctx context.Context, name string) (*repository.SchedulerJobModel, error) {
+	args := m.Called(ctx, name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.SchedulerJobModel), args.Error(1)
+}
+
+func (m *MockSchedulerJobRepository) SaveLastRun(ctx context.Context, job *repository.SchedulerJobModel) error {
+	args := m.Called(ctx, job)
+	return args.Error(0)
+}
+
+func (m *MockSchedulerJobRepository) SetPaused(ctx context.Context, name string, paused bool) error {
+	args := m.Called(ctx, name, paused)
+	return args.Error(0)
+}
+
+// MockUnitOfWork 直接在 mock 仓储上执行事务函数，并记录提交与回滚次数
+type MockUnitOfWork struct {
+	Repos     *repository.TxRepository
+	Commits   int
+	Rollbacks int
+}
+
+func NewMockUnitOfWork(wealthRepo repository.Wealth, accountRepo repository.AccountV2, journalRepo repository.Journal) *MockUnitOfWork {
+	return &MockUnitOfWork{Repos: &repository.TxRepository{
+		Wealth:  wealthRepo,
+		Account: accountRepo,
+		Journal: journalRepo,
+	}}
+}
+
+func (u *MockUnitOfWork) Do(ctx context.Context, fn func(tx *repository.TxRepository) error) error {
+	if err := fn(u.Repos); err != nil {
+		u.Rollbacks++
+		return err
+	}
+	u.Commits++
+	return nil
+}
+
+// wasCalled reports whether method was invoked on m at least once
+func wasCalled(m *mock.Mock, method string) bool {
+	for _, call := range m.Calls {
+		if call.Method == method {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *MockWealthRepository) GetProductRates(ctx context.Context, productID int64, date string) ([]*repository.WealthRateModel, error) {
+	args := m.Called(ctx, productID, date)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*repository.WealthRateModel), args.Error(1)
+}
+
+func (m *MockWealthRepository) ListProductRates(ctx context.Context, productID int64) ([]*repository.WealthRateModel, error) {
+	args := m.Called(ctx, productID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*repository.WealthRateModel), args.Error(1)
+}
+
+func (m *MockWealthRepository) SaveProductRates(ctx context.Context, productID int64, effectiveDate string, rates []*repository.WealthRateModel) error {
+	args := m.Called(ctx, productID, effectiveDate, rates)
+	return args.Error(0)
+}
+
+func (m *MockWealthRepository) CreateQuote(ctx context.Context, quote *repository.WealthQuoteModel) error {
+	args := m.Called(ctx, quote)
+	return args.Error(0)
+}
+
+func (m *MockWealthRepository) GetQuote(ctx context.Context, id string) (*repository.WealthQuoteModel, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.WealthQuoteModel), args.Error(1)
+}
+
+func (m *MockWealthRepository) UseQuote(ctx context.Context, id string, userID int64) error {
+	args := m.Called(ctx, id, userID)
+	return args.Error(0)
+}
+
+// expectLockedReread 让事务内的 GetOrderForUpdate 返回已登记的 GetOrderByID 结果
+func expectLockedReread(m *MockWealthRepository, id int64) {
+	for _, call := range m.ExpectedCalls {
+		if call.Method == "GetOrderByID" && call.Arguments.Get(1) == id {
+			m.On("GetOrderForUpdate", mock.Anything, id).Return(call.ReturnArguments...)
+			return
+		}
+	}
+}
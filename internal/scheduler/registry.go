@@ -0,0 +1,398 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"monera-digital/internal/logger"
+	"monera-digital/internal/repository"
+)
+
+var (
+	ErrJobNotFound      = errors.New("job not found")
+	ErrJobAlreadyExists = errors.New("job already registered")
+	ErrJobRunning       = errors.New("job is already running")
+)
+
+// Job 注册到 Registry 的定时任务
+type Job struct {
+	Name string
+	// Schedule 5 段 cron 表达式（UTC），如 "*/5 * * * *"
+	Schedule string
+	// Timeout 单次尝试的超时时间，0 表示不限制
+	Timeout time.Duration
+	// Retries 失败后的重试次数
+	Retries    int
+	RetryDelay time.Duration
+	// RunOnStart 启动时立即执行一次（用于补跑与预热缓存）
+	RunOnStart bool
+	// Singleton 多实例部署时通过 advisory lock 保证同一时刻只有一个实例执行；
+	// 仅作用于本进程内存的任务（如缓存刷新）不应设置
+	Singleton bool
+	Run       func(ctx context.Context) error
+}
+
+// JobStatus 任务当前状态，供管理接口展示
+type JobStatus struct {
+	Name           string `json:"name"`
+	Schedule       string `json:"schedule"`
+	Paused         bool   `json:"paused"`
+	Running        bool   `json:"running"`
+	NextRunAt      string `json:"nextRunAt,omitempty"`
+	LastRunAt      string `json:"lastRunAt,omitempty"`
+	LastStatus     string `json:"lastStatus,omitempty"`
+	LastError      string `json:"lastError,omitempty"`
+	LastDurationMs int64  `json:"lastDurationMs"`
+}
+
+type jobEntry struct {
+	job      Job
+	schedule *cronSchedule
+	running  atomic.Bool
+
+	mu      sync.Mutex
+	paused  bool
+	nextRun time.Time
+	last    repository.SchedulerJobModel
+}
+
+// Registry 统一管理所有定时任务：调度、超时、重试、防重入与状态持久化
+type Registry struct {
+	store  repository.SchedulerJob
+	locker repository.SchedulerRun
+
+	mu      sync.RWMutex
+	jobs    map[string]*jobEntry
+	names   []string
+	baseCtx context.Context
+	wg      sync.WaitGroup
+}
+
+func NewRegistry(store repository.SchedulerJob, locker repository.SchedulerRun) *Registry {
+	return &Registry{
+		store:   store,
+		locker:  locker,
+		jobs:    make(map[string]*jobEntry),
+		baseCtx: context.Background(),
+	}
+}
+
+// Register 注册任务，必须在 Start 之前调用
+func (r *Registry) Register(job Job) error {
+	if job.Name == "" || job.Run == nil {
+		return fmt.Errorf("job name and run function are required")
+	}
+	schedule, err := parseCron(job.Schedule)
+	if err != nil {
+		return fmt.Errorf("job %s: invalid schedule: %v", job.Name, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.jobs[job.Name]; exists {
+		return ErrJobAlreadyExists
+	}
+	r.jobs[job.Name] = &jobEntry{job: job, schedule: schedule}
+	r.names = append(r.names, job.Name)
+	return nil
+}
+
+// Start 为每个任务启动调度协程，ctx 取消后全部退出
+func (r *Registry) Start(ctx context.Context) {
+	r.mu.Lock()
+	r.baseCtx = ctx
+	entries := make([]*jobEntry, 0, len(r.names))
+	for _, name := range r.names {
+		entries = append(entries, r.jobs[name])
+	}
+	r.mu.Unlock()
+
+	for _, e := range entries {
+		r.loadState(ctx, e)
+		r.wg.Add(1)
+		go r.loop(ctx, e)
+	}
+	logger.Info("[Scheduler] Job registry started", "jobs", len(entries))
+}
+
+// Wait 等待所有调度协程及手动触发的执行结束
+func (r *Registry) Wait() {
+	r.wg.Wait()
+}
+
+func (r *Registry) loop(ctx context.Context, e *jobEntry) {
+	defer r.wg.Done()
+
+	if e.job.RunOnStart && !r.isPaused(ctx, e) {
+		r.runIfIdle(ctx, e)
+	}
+
+	for {
+		next := e.schedule.Next(time.Now())
+		if next.IsZero() {
+			logger.Warn("[Scheduler] Job schedule never fires, stopping", "job", e.job.Name)
+			return
+		}
+		e.mu.Lock()
+		e.nextRun = next
+		e.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Until(next)):
+		}
+
+		if r.isPaused(ctx, e) {
+			logger.Info("[Scheduler] Job is paused, skipping", "job", e.job.Name)
+			continue
+		}
+		r.runIfIdle(ctx, e)
+	}
+}
+
+func (r *Registry) runIfIdle(ctx context.Context, e *jobEntry) {
+	if !e.running.CompareAndSwap(false, true) {
+		logger.Warn("[Scheduler] Previous run still in progress, skipping", "job", e.job.Name)
+		return
+	}
+	defer e.running.Store(false)
+	r.execute(ctx, e)
+}
+
+// Trigger 立即异步执行一次任务，忽略暂停状态
+func (r *Registry) Trigger(name string) error {
+	e, err := r.lookup(name)
+	if err != nil {
+		return err
+	}
+	if !e.running.CompareAndSwap(false, true) {
+		return ErrJobRunning
+	}
+
+	r.mu.RLock()
+	ctx := r.baseCtx
+	r.mu.RUnlock()
+
+	logger.Info("[Scheduler] Job triggered manually", "job", name)
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		defer e.running.Store(false)
+		r.execute(ctx, e)
+	}()
+	return nil
+}
+
+// Pause 暂停任务的定时执行；状态持久化，对所有实例生效
+func (r *Registry) Pause(ctx context.Context, name string) error {
+	return r.setPaused(ctx, name, true)
+}
+
+// Resume 恢复任务的定时执行
+func (r *Registry) Resume(ctx context.Context, name string) error {
+	return r.setPaused(ctx, name, false)
+}
+
+func (r *Registry) setPaused(ctx context.Context, name string, paused bool) error {
+	e, err := r.lookup(name)
+	if err != nil {
+		return err
+	}
+	if r.store != nil {
+		if err := r.store.SetPaused(ctx, name, paused); err != nil {
+			return fmt.Errorf("failed to persist pause state: %v", err)
+		}
+	}
+	e.mu.Lock()
+	e.paused = paused
+	e.mu.Unlock()
+	logger.Info("[Scheduler] Job pause state changed", "job", name, "paused", paused)
+	return nil
+}
+
+// List 按注册顺序返回所有任务状态
+func (r *Registry) List() []JobStatus {
+	r.mu.RLock()
+	entries := make([]*jobEntry, 0, len(r.names))
+	for _, name := range r.names {
+		entries = append(entries, r.jobs[name])
+	}
+	r.mu.RUnlock()
+
+	result := make([]JobStatus, 0, len(entries))
+	for _, e := range entries {
+		result = append(result, e.status())
+	}
+	return result
+}
+
+// Runs 返回任务按业务日期持久化的运行记录（目前仅利息任务写入）
+func (r *Registry) Runs(ctx context.Context, name string, limit int) ([]*repository.SchedulerRunModel, error) {
+	if _, err := r.lookup(name); err != nil {
+		return nil, err
+	}
+	if r.locker == nil {
+		return []*repository.SchedulerRunModel{}, nil
+	}
+	return r.locker.ListRuns(ctx, name, limit)
+}
+
+func (r *Registry) lookup(name string) (*jobEntry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	e, ok := r.jobs[name]
+	if !ok {
+		return nil, ErrJobNotFound
+	}
+	return e, nil
+}
+
+// loadState 从数据库恢复暂停开关与最近一次运行结果
+func (r *Registry) loadState(ctx context.Context, e *jobEntry) {
+	if r.store == nil {
+		return
+	}
+	saved, err := r.store.GetJob(ctx, e.job.Name)
+	if err != nil {
+		if !errors.Is(err, repository.ErrNotFound) {
+			logger.Warn("[Scheduler] Failed to load job state", "job", e.job.Name, "error", err.Error())
+		}
+		return
+	}
+	e.mu.Lock()
+	e.paused = saved.Paused
+	e.last = *saved
+	e.mu.Unlock()
+}
+
+// isPaused 以数据库为准，其他实例的暂停操作也能生效；读取失败时使用本地状态
+func (r *Registry) isPaused(ctx context.Context, e *jobEntry) bool {
+	if r.store != nil {
+		saved, err := r.store.GetJob(ctx, e.job.Name)
+		switch {
+		case err == nil:
+			e.mu.Lock()
+			e.paused = saved.Paused
+			e.mu.Unlock()
+		case errors.Is(err, repository.ErrNotFound):
+			e.mu.Lock()
+			e.paused = false
+			e.mu.Unlock()
+		default:
+			logger.Warn("[Scheduler] Failed to read pause state, using local state", "job", e.job.Name, "error", err.Error())
+		}
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.paused
+}
+
+func (r *Registry) execute(ctx context.Context, e *jobEntry) {
+	name := e.job.Name
+
+	if e.job.Singleton && r.locker != nil {
+		unlock, acquired, err := r.locker.TryLock(ctx, "job:"+name)
+		if err != nil {
+			logger.Error("[Scheduler] Failed to acquire job lock", "job", name, "error", err.Error())
+			return
+		}
+		if !acquired {
+			logger.Info("[Scheduler] Job is running on another instance, skipping", "job", name)
+			return
+		}
+		defer unlock()
+	}
+
+	started := time.Now()
+	var err error
+	for attempt := 0; attempt <= e.job.Retries; attempt++ {
+		if attempt > 0 {
+			logger.Warn("[Scheduler] Retrying job", "job", name, "attempt", attempt+1, "error", err.Error())
+			select {
+			case <-ctx.Done():
+			case <-time.After(e.job.RetryDelay):
+			}
+		}
+		if ctx.Err() != nil {
+			err = ctx.Err()
+			break
+		}
+		if err = r.attempt(ctx, e); err == nil {
+			break
+		}
+	}
+
+	r.recordResult(e, started, err)
+}
+
+// attempt 执行一次任务，带超时并把 panic 转为错误
+func (r *Registry) attempt(ctx context.Context, e *jobEntry) (err error) {
+	if e.job.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.job.Timeout)
+		defer cancel()
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("job panicked: %v", p)
+		}
+	}()
+	return e.job.Run(ctx)
+}
+
+func (r *Registry) recordResult(e *jobEntry, started time.Time, err error) {
+	duration := time.Since(started)
+	result := repository.SchedulerJobModel{
+		Name:           e.job.Name,
+		LastRunAt:      started.UTC().Format(time.RFC3339),
+		LastStatus:     repository.SchedulerRunStatusSuccess,
+		LastDurationMs: duration.Milliseconds(),
+	}
+	if err != nil {
+		result.LastStatus = repository.SchedulerRunStatusFailed
+		result.LastError = err.Error()
+		logger.Error("[Scheduler] Job failed", "job", e.job.Name, "duration_ms", result.LastDurationMs, "error", err.Error())
+	} else {
+		logger.Info("[Scheduler] Job completed", "job", e.job.Name, "duration_ms", result.LastDurationMs)
+	}
+
+	e.mu.Lock()
+	result.Paused = e.paused
+	e.last = result
+	e.mu.Unlock()
+
+	if r.store == nil {
+		return
+	}
+	// 任务可能因 ctx 取消而结束，结果仍需落库
+	saveCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := r.store.SaveLastRun(saveCtx, &result); err != nil {
+		logger.Error("[Scheduler] Failed to persist job status", "job", e.job.Name, "error", err.Error())
+	}
+}
+
+func (e *jobEntry) status() JobStatus {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	status := JobStatus{
+		Name:           e.job.Name,
+		Schedule:       e.job.Schedule,
+		Paused:         e.paused,
+		Running:        e.running.Load(),
+		LastRunAt:      e.last.LastRunAt,
+		LastStatus:     e.last.LastStatus,
+		LastError:      e.last.LastError,
+		LastDurationMs: e.last.LastDurationMs,
+	}
+	if !e.nextRun.IsZero() {
+		status.NextRunAt = e.nextRun.Format(time.RFC3339)
+	}
+	return status
+}
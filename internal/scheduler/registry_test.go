@@ -0,0 +1,178 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"monera-digital/internal/repository"
+)
+
+func TestRegistry_Register_Validation(t *testing.T) {
+	r := NewRegistry(nil, nil)
+	noop := func(ctx context.Context) error { return nil }
+
+	assert.NoError(t, r.Register(Job{Name: "a", Schedule: "@hourly", Run: noop}))
+	assert.ErrorIs(t, r.Register(Job{Name: "a", Schedule: "@hourly", Run: noop}), ErrJobAlreadyExists)
+	assert.Error(t, r.Register(Job{Name: "b", Schedule: "every hour", Run: noop}))
+	assert.Error(t, r.Register(Job{Name: "c", Schedule: "@hourly"}))
+}
+
+func TestRegistry_Trigger_UnknownJob(t *testing.T) {
+	r := NewRegistry(nil, nil)
+	assert.ErrorIs(t, r.Trigger("missing"), ErrJobNotFound)
+}
+
+func TestRegistry_Trigger_PersistsSuccess(t *testing.T) {
+	store := new(MockSchedulerJobRepository)
+	store.On("SaveLastRun", mock.Anything, mock.MatchedBy(func(job *repository.SchedulerJobModel) bool {
+		return job.Name == "cleanup" && job.LastStatus == repository.SchedulerRunStatusSuccess && job.LastRunAt != ""
+	})).Return(nil)
+
+	var calls int32
+	r := NewRegistry(store, nil)
+	assert.NoError(t, r.Register(Job{Name: "cleanup", Schedule: "@hourly", Run: func(ctx context.Context) error {
+		atomic.AddInt32(&calls, 1)
+		return nil
+	}}))
+
+	assert.NoError(t, r.Trigger("cleanup"))
+	r.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	assert.Equal(t, repository.SchedulerRunStatusSuccess, r.List()[0].LastStatus)
+	store.AssertExpectations(t)
+}
+
+func TestRegistry_RetriesUntilSuccess(t *testing.T) {
+	var calls int32
+	r := NewRegistry(nil, nil)
+	assert.NoError(t, r.Register(Job{Name: "flaky", Schedule: "@hourly", Retries: 2, Run: func(ctx context.Context) error {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			return errors.New("temporary")
+		}
+		return nil
+	}}))
+
+	assert.NoError(t, r.Trigger("flaky"))
+	r.Wait()
+
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+	assert.Equal(t, repository.SchedulerRunStatusSuccess, r.List()[0].LastStatus)
+}
+
+func TestRegistry_TimeoutAndPanicFailRun(t *testing.T) {
+	r := NewRegistry(nil, nil)
+	assert.NoError(t, r.Register(Job{Name: "slow", Schedule: "@hourly", Timeout: 20 * time.Millisecond, Run: func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}}))
+	assert.NoError(t, r.Register(Job{Name: "broken", Schedule: "@hourly", Run: func(ctx context.Context) error {
+		panic("nil map")
+	}}))
+
+	assert.NoError(t, r.Trigger("slow"))
+	assert.NoError(t, r.Trigger("broken"))
+	r.Wait()
+
+	statuses := r.List()
+	assert.Equal(t, repository.SchedulerRunStatusFailed, statuses[0].LastStatus)
+	assert.Contains(t, statuses[0].LastError, "deadline exceeded")
+	assert.Equal(t, repository.SchedulerRunStatusFailed, statuses[1].LastStatus)
+	assert.Contains(t, statuses[1].LastError, "nil map")
+}
+
+func TestRegistry_Trigger_RejectsOverlap(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{})
+	r := NewRegistry(nil, nil)
+	assert.NoError(t, r.Register(Job{Name: "long", Schedule: "@hourly", Run: func(ctx context.Context) error {
+		close(started)
+		<-release
+		return nil
+	}}))
+
+	assert.NoError(t, r.Trigger("long"))
+	<-started
+	assert.True(t, r.List()[0].Running)
+	assert.ErrorIs(t, r.Trigger("long"), ErrJobRunning)
+
+	close(release)
+	r.Wait()
+	assert.False(t, r.List()[0].Running)
+}
+
+func TestRegistry_PauseAndResume(t *testing.T) {
+	store := new(MockSchedulerJobRepository)
+	store.On("SetPaused", mock.Anything, "cleanup", true).Return(nil)
+	store.On("SetPaused", mock.Anything, "cleanup", false).Return(nil)
+
+	r := NewRegistry(store, nil)
+	assert.NoError(t, r.Register(Job{Name: "cleanup", Schedule: "@hourly", Run: func(ctx context.Context) error { return nil }}))
+
+	assert.NoError(t, r.Pause(context.Background(), "cleanup"))
+	assert.True(t, r.List()[0].Paused)
+
+	assert.NoError(t, r.Resume(context.Background(), "cleanup"))
+	assert.False(t, r.List()[0].Paused)
+
+	assert.ErrorIs(t, r.Pause(context.Background(), "missing"), ErrJobNotFound)
+	store.AssertExpectations(t)
+}
+
+func TestRegistry_IsPaused_ReadsPersistedState(t *testing.T) {
+	store := new(MockSchedulerJobRepository)
+	// 另一个实例已暂停该任务
+	store.On("GetJob", mock.Anything, "cleanup").Return(&repository.SchedulerJobModel{Name: "cleanup", Paused: true}, nil)
+
+	r := NewRegistry(store, nil)
+	assert.NoError(t, r.Register(Job{Name: "cleanup", Schedule: "@hourly", Run: func(ctx context.Context) error { return nil }}))
+
+	e, err := r.lookup("cleanup")
+	assert.NoError(t, err)
+	assert.True(t, r.isPaused(context.Background(), e))
+}
+
+func TestRegistry_Singleton_SkipsWhenLockHeld(t *testing.T) {
+	locker := new(MockSchedulerRunRepository)
+	locker.On("TryLock", mock.Anything, "job:expiry").Return(nil, false, nil)
+
+	var calls int32
+	r := NewRegistry(nil, locker)
+	assert.NoError(t, r.Register(Job{Name: "expiry", Schedule: "@hourly", Singleton: true, Run: func(ctx context.Context) error {
+		atomic.AddInt32(&calls, 1)
+		return nil
+	}}))
+
+	assert.NoError(t, r.Trigger("expiry"))
+	r.Wait()
+
+	assert.Equal(t, int32(0), atomic.LoadInt32(&calls))
+	assert.Empty(t, r.List()[0].LastStatus)
+}
+
+func TestRegistry_Start_RunOnStartAndStop(t *testing.T) {
+	ran := make(chan struct{}, 1)
+	r := NewRegistry(nil, nil)
+	assert.NoError(t, r.Register(Job{Name: "warmup", Schedule: "@daily", RunOnStart: true, Run: func(ctx context.Context) error {
+		ran <- struct{}{}
+		return nil
+	}}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r.Start(ctx)
+
+	select {
+	case <-ran:
+	case <-time.After(time.Second):
+		t.Fatal("job was not run on start")
+	}
+
+	cancel()
+	r.Wait()
+	assert.NotEmpty(t, r.List()[0].NextRunAt)
+}
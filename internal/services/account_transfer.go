@@ -0,0 +1,204 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"monera-digital/internal/clock"
+	"monera-digital/internal/money"
+	"monera-digital/internal/repository"
+)
+
+var (
+	ErrInvalidTransfer = errors.New("invalid transfer request")
+	ErrAccountBusy     = errors.New("account was modified concurrently, please retry")
+)
+
+// 账户间划转的流水类型，转出与转入成对记录
+const (
+	TransferBizOut = "ACCOUNT_TRANSFER_OUT"
+	TransferBizIn  = "ACCOUNT_TRANSFER_IN"
+)
+
+var transferAccountTypes = map[string]bool{
+	repository.AccountTypeFund:   true,
+	repository.AccountTypeWealth: true,
+}
+
+// AccountTransferRequest 用户自有账户之间的划转申请
+type AccountTransferRequest struct {
+	FromType string `json:"fromType" binding:"required"`
+	ToType   string `json:"toType" binding:"required"`
+	Currency string `json:"currency" binding:"required"`
+	Amount   string `json:"amount" binding:"required"`
+}
+
+// AccountTransfer 划转结果，余额为划转后的可用余额
+type AccountTransfer struct {
+	TransferNo    string    `json:"transferNo"`
+	FromType      string    `json:"fromType"`
+	ToType        string    `json:"toType"`
+	Currency      string    `json:"currency"`
+	Amount        string    `json:"amount"`
+	FromAvailable string    `json:"fromAvailable"`
+	ToAvailable   string    `json:"toAvailable"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+// AccountTransferService 在同一用户的资金账户与理财账户之间划转余额。
+// 理财账户目前只用于存放与展示划出的余额：理财申购、赎回与结算仍在资金账户上冻结与解冻，
+// 需先划回资金账户才能申购
+type AccountTransferService struct {
+	accountRepo repository.AccountV2
+	uow         repository.UnitOfWork
+	clock       clock.Clock
+}
+
+func NewAccountTransferService(accountRepo repository.AccountV2, uow repository.UnitOfWork) *AccountTransferService {
+	return &AccountTransferService{
+		accountRepo: accountRepo,
+		uow:         uow,
+		clock:       clock.System,
+	}
+}
+
+// SetClock 替换流水时间的来源，供测试与模拟器拨动时间
+func (s *AccountTransferService) SetClock(c clock.Clock) {
+	s.clock = c
+}
+
+// Transfer 在一个事务内从 FromType 账户扣减、向 ToType 账户增加余额，写入成对流水与划转记录。
+// 两个账户均按读取时的版本号更新，期间被并发修改时整体回滚并返回 ErrAccountBusy。
+// 目标账户不存在时自动开立。
+func (s *AccountTransferService) Transfer(ctx context.Context, userID int, req AccountTransferRequest) (*AccountTransfer, error) {
+	fromType := strings.ToUpper(strings.TrimSpace(req.FromType))
+	toType := strings.ToUpper(strings.TrimSpace(req.ToType))
+	currency := strings.ToUpper(strings.TrimSpace(req.Currency))
+	if !transferAccountTypes[fromType] || !transferAccountTypes[toType] || fromType == toType || currency == "" {
+		return nil, ErrInvalidTransfer
+	}
+	amount, err := money.Parse(req.Amount)
+	if err != nil || !amount.IsPositive() || !money.Quantize(currency, amount).Equal(amount) {
+		return nil, ErrInvalidTransfer
+	}
+
+	now := s.clock.Now()
+	transferNo := "TRANSFER-" + uuid.NewString()
+	var from, to *repository.AccountModel
+	err = s.uow.Do(ctx, func(tx *repository.TxRepository) error {
+		from, err = tx.Account.GetAccountByType(ctx, int64(userID), fromType, currency)
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrInsufficientBalance
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get source account: %v", err)
+		}
+		if from.Available().LessThan(amount) {
+			return ErrInsufficientBalance
+		}
+
+		to, err = openAccount(ctx, tx.Account, int64(userID), toType, currency)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Account.UpdateBalance(ctx, from.ID, from.Version, amount.Neg()); err != nil {
+			return versionError(err)
+		}
+		if err := tx.Account.UpdateBalance(ctx, to.ID, to.Version, amount); err != nil {
+			return versionError(err)
+		}
+
+		if err := tx.Journal.CreateJournalRecord(ctx, &repository.JournalModel{
+			SerialNo:        transferNo + "-OUT",
+			UserID:          int64(userID),
+			AccountID:       from.ID,
+			Amount:          amount.Neg(),
+			BalanceSnapshot: from.Available().Sub(amount),
+			BizType:         TransferBizOut,
+			CreatedAt:       now.Format(time.RFC3339),
+		}); err != nil {
+			return ErrJournalCreateFailed
+		}
+		if err := tx.Journal.CreateJournalRecord(ctx, &repository.JournalModel{
+			SerialNo:        transferNo + "-IN",
+			UserID:          int64(userID),
+			AccountID:       to.ID,
+			Amount:          amount,
+			BalanceSnapshot: to.Available().Add(amount),
+			BizType:         TransferBizIn,
+			CreatedAt:       now.Format(time.RFC3339),
+		}); err != nil {
+			return ErrJournalCreateFailed
+		}
+
+		// 划转单沿用基础表结构中的 transfer_record，按划转单号可查到两端账户
+		if err := tx.Account.CreateTransferRecord(ctx, &repository.TransferRecordModel{
+			UserID:        int64(userID),
+			TransferID:    transferNo,
+			FromAccountID: from.ID,
+			ToAccountID:   to.ID,
+			Amount:        amount,
+			Status:        repository.TransferStatusCompleted,
+			CreatedAt:     now.Format(time.RFC3339),
+			CompletedAt:   now.Format(time.RFC3339),
+		}); err != nil {
+			return fmt.Errorf("failed to create transfer record: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &AccountTransfer{
+		TransferNo:    transferNo,
+		FromType:      fromType,
+		ToType:        toType,
+		Currency:      currency,
+		Amount:        amount.String(),
+		FromAvailable: from.Available().Sub(amount).String(),
+		ToAvailable:   to.Available().Add(amount).String(),
+		CreatedAt:     now,
+	}, nil
+}
+
+// openAccount 返回用户指定类型与币种的账户，不存在时开立零余额账户
+func openAccount(ctx context.Context, accounts repository.AccountV2, userID int64, accountType, currency string) (*repository.AccountModel, error) {
+	account, err := accounts.GetAccountByType(ctx, userID, accountType, currency)
+	if err == nil {
+		return account, nil
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		return nil, fmt.Errorf("failed to get %s account: %v", accountType, err)
+	}
+
+	account = &repository.AccountModel{
+		UserID:        userID,
+		Type:          accountType,
+		Currency:      currency,
+		Balance:       money.Zero,
+		FrozenBalance: money.Zero,
+	}
+	err = accounts.CreateAccount(ctx, account)
+	if errors.Is(err, repository.ErrAlreadyExists) {
+		// 并发开户，改用已开立的账户
+		return accounts.GetAccountByType(ctx, userID, accountType, currency)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s account: %v", accountType, err)
+	}
+	return account, nil
+}
+
+func versionError(err error) error {
+	if errors.Is(err, repository.ErrVersionConflict) {
+		return ErrAccountBusy
+	}
+	return fmt.Errorf("failed to update balance: %v", err)
+}
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"monera-digital/internal/clock"
+	"monera-digital/internal/money"
+	"monera-digital/internal/repository"
+)
+
+func newTestTransferService() (*AccountTransferService, *MockAccountRepository, *MockJournalRepository, *MockUnitOfWork) {
+	accountRepo := new(MockAccountRepository)
+	journalRepo := new(MockJournalRepository)
+	uow := NewMockUnitOfWork(nil, accountRepo, journalRepo)
+	service := NewAccountTransferService(accountRepo, uow)
+	service.SetClock(clock.NewFake(time.Date(2026, 3, 10, 2, 0, 0, 0, time.UTC)))
+	return service, accountRepo, journalRepo, uow
+}
+
+func TestAccountTransferService_Transfer_FundToWealth(t *testing.T) {
+	service, accountRepo, journalRepo, uow := newTestTransferService()
+
+	fund := &repository.AccountModel{ID: 1, UserID: 1, Type: "FUND", Currency: "USDT", Balance: money.MustParse("1000"), FrozenBalance: money.MustParse("200"), Version: 7}
+	wealth := &repository.AccountModel{ID: 2, UserID: 1, Type: "WEALTH", Currency: "USDT", Balance: money.MustParse("50"), FrozenBalance: money.Zero, Version: 3}
+	accountRepo.On("GetAccountByType", mock.Anything, int64(1), "FUND", "USDT").Return(fund, nil)
+	accountRepo.On("GetAccountByType", mock.Anything, int64(1), "WEALTH", "USDT").Return(wealth, nil)
+	accountRepo.On("UpdateBalance", mock.Anything, int64(1), int64(7), money.MustParse("-300")).Return(nil)
+	accountRepo.On("UpdateBalance", mock.Anything, int64(2), int64(3), money.MustParse("300")).Return(nil)
+
+	var out, in *repository.JournalModel
+	journalRepo.On("CreateJournalRecord", mock.Anything, mock.MatchedBy(func(j *repository.JournalModel) bool {
+		return j.BizType == TransferBizOut
+	})).Run(func(args mock.Arguments) { out = args.Get(1).(*repository.JournalModel) }).Return(nil).Once()
+	journalRepo.On("CreateJournalRecord", mock.Anything, mock.MatchedBy(func(j *repository.JournalModel) bool {
+		return j.BizType == TransferBizIn
+	})).Run(func(args mock.Arguments) { in = args.Get(1).(*repository.JournalModel) }).Return(nil).Once()
+	var record *repository.TransferRecordModel
+	accountRepo.On("CreateTransferRecord", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) { record = args.Get(1).(*repository.TransferRecordModel) }).Return(nil)
+
+	transfer, err := service.Transfer(context.Background(), 1, AccountTransferRequest{
+		FromType: "fund", ToType: "WEALTH", Currency: "usdt", Amount: "300",
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "500", transfer.FromAvailable)
+	assert.Equal(t, "350", transfer.ToAvailable)
+	assert.Equal(t, 1, uow.Commits)
+	// 成对流水金额相反、共享划转单号
+	assert.Equal(t, "-300", out.Amount.String())
+	assert.Equal(t, "500", out.BalanceSnapshot.String())
+	assert.Equal(t, "300", in.Amount.String())
+	assert.Equal(t, "350", in.BalanceSnapshot.String())
+	assert.Equal(t, transfer.TransferNo+"-OUT", out.SerialNo)
+	assert.Equal(t, transfer.TransferNo+"-IN", in.SerialNo)
+	// 划转记录与流水同一事务写入
+	assert.Equal(t, transfer.TransferNo, record.TransferID)
+	assert.Equal(t, int64(1), record.FromAccountID)
+	assert.Equal(t, int64(2), record.ToAccountID)
+	assert.Equal(t, "300", record.Amount.String())
+	assert.Equal(t, repository.TransferStatusCompleted, record.Status)
+	accountRepo.AssertExpectations(t)
+}
+
+func TestAccountTransferService_Transfer_OpensDestinationAccount(t *testing.T) {
+	service, accountRepo, journalRepo, _ := newTestTransferService()
+
+	wealth := &repository.AccountModel{ID: 2, UserID: 1, Type: "WEALTH", Currency: "BTC", Balance: money.MustParse("0.5"), FrozenBalance: money.Zero, Version: 1}
+	accountRepo.On("GetAccountByType", mock.Anything, int64(1), "WEALTH", "BTC").Return(wealth, nil)
+	accountRepo.On("GetAccountByType", mock.Anything, int64(1), "FUND", "BTC").Return(nil, repository.ErrNotFound)
+	accountRepo.On("CreateAccount", mock.Anything, mock.MatchedBy(func(a *repository.AccountModel) bool {
+		return a.Type == "FUND" && a.Currency == "BTC" && a.Balance.IsZero()
+	})).Run(func(args mock.Arguments) {
+		a := args.Get(1).(*repository.AccountModel)
+		a.ID, a.Version = 5, 1
+	}).Return(nil)
+	accountRepo.On("UpdateBalance", mock.Anything, int64(2), int64(1), money.MustParse("-0.1")).Return(nil)
+	accountRepo.On("UpdateBalance", mock.Anything, int64(5), int64(1), money.MustParse("0.1")).Return(nil)
+	accountRepo.On("CreateTransferRecord", mock.Anything, mock.Anything).Return(nil)
+	journalRepo.On("CreateJournalRecord", mock.Anything, mock.Anything).Return(nil)
+
+	transfer, err := service.Transfer(context.Background(), 1, AccountTransferRequest{
+		FromType: "WEALTH", ToType: "FUND", Currency: "BTC", Amount: "0.1",
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "0.1", transfer.ToAvailable)
+	accountRepo.AssertExpectations(t)
+}
+
+func TestAccountTransferService_Transfer_Rejects(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     AccountTransferRequest
+		wantErr error
+	}{
+		{"same account type", AccountTransferRequest{FromType: "FUND", ToType: "FUND", Currency: "USDT", Amount: "1"}, ErrInvalidTransfer},
+		{"unknown account type", AccountTransferRequest{FromType: "FUND", ToType: "LOAN", Currency: "USDT", Amount: "1"}, ErrInvalidTransfer},
+		{"non-positive amount", AccountTransferRequest{FromType: "FUND", ToType: "WEALTH", Currency: "USDT", Amount: "-1"}, ErrInvalidTransfer},
+		{"below currency precision", AccountTransferRequest{FromType: "FUND", ToType: "WEALTH", Currency: "USDT", Amount: "0.0000001"}, ErrInvalidTransfer},
+		{"exceeds available", AccountTransferRequest{FromType: "FUND", ToType: "WEALTH", Currency: "USDT", Amount: "800.01"}, ErrInsufficientBalance},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, accountRepo, _, _ := newTestTransferService()
+			accountRepo.On("GetAccountByType", mock.Anything, int64(1), "FUND", "USDT").Return(
+				&repository.AccountModel{ID: 1, Balance: money.MustParse("1000"), FrozenBalance: money.MustParse("200")}, nil)
+
+			_, err := service.Transfer(context.Background(), 1, tt.req)
+
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.False(t, wasCalled(&accountRepo.Mock, "UpdateBalance"))
+		})
+	}
+}
+
+func TestAccountTransferService_Transfer_VersionConflict(t *testing.T) {
+	service, accountRepo, journalRepo, uow := newTestTransferService()
+
+	accountRepo.On("GetAccountByType", mock.Anything, int64(1), "FUND", "USDT").Return(
+		&repository.AccountModel{ID: 1, Balance: money.MustParse("1000"), FrozenBalance: money.Zero, Version: 7}, nil)
+	accountRepo.On("GetAccountByType", mock.Anything, int64(1), "WEALTH", "USDT").Return(
+		&repository.AccountModel{ID: 2, Balance: money.Zero, FrozenBalance: money.Zero, Version: 3}, nil)
+	accountRepo.On("UpdateBalance", mock.Anything, int64(1), int64(7), mock.Anything).Return(nil)
+	accountRepo.On("UpdateBalance", mock.Anything, int64(2), int64(3), mock.Anything).Return(repository.ErrVersionConflict)
+
+	_, err := service.Transfer(context.Background(), 1, AccountTransferRequest{
+		FromType: "FUND", ToType: "WEALTH", Currency: "USDT", Amount: "100",
+	})
+
+	assert.ErrorIs(t, err, ErrAccountBusy)
+	assert.Equal(t, 1, uow.Rollbacks)
+	assert.False(t, wasCalled(&journalRepo.Mock, "CreateJournalRecord"))
+}
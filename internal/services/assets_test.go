@@ -6,13 +6,14 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"monera-digital/internal/money"
 	"monera-digital/internal/repository"
 )
 
 func TestWealthService_GetAssets(t *testing.T) {
 	mockAccountRepo := new(MockAccountRepository)
 	mockJournalRepo := new(MockJournalRepository)
-	service := NewWealthService(nil, mockAccountRepo, mockJournalRepo)
+	service := NewWealthService(nil, mockAccountRepo, mockJournalRepo, NewMockUnitOfWork(nil, mockAccountRepo, mockJournalRepo))
 
 	mockAccountRepo.On("GetAccountsByUserID", mock.Anything, int64(1)).Return([]*repository.AccountModel{
 		{
@@ -20,25 +21,37 @@ func TestWealthService_GetAssets(t *testing.T) {
 			UserID:        1,
 			Type:          "FUND",
 			Currency:      "USDT",
-			Balance:       "100000",
-			FrozenBalance: "5000",
+			Balance:       money.MustParse("100000"),
+			FrozenBalance: money.MustParse("5000"),
 		},
 		{
 			ID:            2,
 			UserID:        1,
 			Type:          "FUND",
 			Currency:      "USDC",
-			Balance:       "50000",
-			FrozenBalance: "0",
+			Balance:       money.MustParse("50000"),
+			FrozenBalance: money.MustParse("0"),
+		},
+		{
+			ID:            3,
+			UserID:        1,
+			Type:          "WEALTH",
+			Currency:      "USDT",
+			Balance:       money.MustParse("300"),
+			FrozenBalance: money.MustParse("0"),
 		},
 	}, nil)
 
 	assets, err := service.GetAssets(context.Background(), 1)
 
 	assert.NoError(t, err)
-	assert.Len(t, assets, 2)
+	assert.Len(t, assets, 3)
 	assert.Equal(t, "USDT", assets[0].Currency)
+	assert.Equal(t, "FUND", assets[0].Type)
 	assert.Equal(t, "100000", assets[0].Total)
 	assert.Equal(t, "95000", assets[0].Available)
+	// 划入理财账户的余额单独列出
+	assert.Equal(t, "WEALTH", assets[2].Type)
+	assert.Equal(t, "300", assets[2].Available)
 	mockAccountRepo.AssertExpectations(t)
 }
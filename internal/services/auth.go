@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"monera-digital/internal/cache"
+	"monera-digital/internal/clock"
 	"monera-digital/internal/config"
 	"monera-digital/internal/models"
 	"monera-digital/internal/utils"
@@ -22,6 +23,7 @@ type AuthService struct {
 	jwtSecret        string
 	tokenBlacklist   *cache.TokenBlacklist
 	twoFactorService *TwoFactorService
+	clock            clock.Clock
 }
 
 // NewAuthService creates a new AuthService instance
@@ -29,6 +31,7 @@ func NewAuthService(db *sql.DB, jwtSecret string) *AuthService {
 	return &AuthService{
 		DB:        db,
 		jwtSecret: jwtSecret,
+		clock:     clock.System,
 	}
 }
 
@@ -42,6 +45,11 @@ func (s *AuthService) SetTokenBlacklist(tb *cache.TokenBlacklist) {
 	s.tokenBlacklist = tb
 }
 
+// SetClock replaces the time source used for token expiry
+func (s *AuthService) SetClock(c clock.Clock) {
+	s.clock = c
+}
+
 // LoginResponse represents the login API response
 type LoginResponse struct {
 	User         *models.User `json:"user,omitempty"`
@@ -166,7 +174,7 @@ func (s *AuthService) Login(req models.LoginRequest) (*LoginResponse, error) {
 		return nil, err
 	}
 
-	expiresAt := time.Now().Add(24 * time.Hour)
+	expiresAt := s.clock.Now().Add(24 * time.Hour)
 
 	return &LoginResponse{
 		User:        &user,
@@ -201,7 +209,7 @@ func (s *AuthService) Verify2FAAndLogin(userID int, token string) (*LoginRespons
 		return nil, fmt.Errorf("failed to generate token: %w", err)
 	}
 
-	expiresAt := time.Now().Add(24 * time.Hour)
+	expiresAt := s.clock.Now().Add(24 * time.Hour)
 
 	return &LoginResponse{
 		User:        user,
@@ -232,7 +240,7 @@ func (s *AuthService) Skip2FAAndLogin(userID int) (*LoginResponse, error) {
 		return nil, fmt.Errorf("failed to generate token: %w", err)
 	}
 
-	expiresAt := time.Now().Add(24 * time.Hour)
+	expiresAt := s.clock.Now().Add(24 * time.Hour)
 
 	return &LoginResponse{
 		User:        user,
@@ -53,13 +53,13 @@ func (s *AuthService) RefreshToken(refreshToken string) (*models.TokenPair, erro
 		RefreshToken: newRefreshToken,
 		TokenType:    "Bearer",
 		ExpiresIn:    900, // 15 分钟
-		ExpiresAt:    time.Now().Add(15 * time.Minute),
+		ExpiresAt:    s.clock.Now().Add(15 * time.Minute),
 	}, nil
 }
 
 // generateAccessToken 生成访问令牌（15 分钟过期）
 func (s *AuthService) generateAccessToken(userID int, email string) (string, error) {
-	now := time.Now()
+	now := s.clock.Now()
 	expiresAt := now.Add(15 * time.Minute)
 
 	claims := &models.TokenClaims{
@@ -76,7 +76,7 @@ func (s *AuthService) generateAccessToken(userID int, email string) (string, err
 
 // generateRefreshToken 生成刷新令牌（7 天过期）
 func (s *AuthService) generateRefreshToken(userID int, email string) (string, error) {
-	now := time.Now()
+	now := s.clock.Now()
 	expiresAt := now.Add(7 * 24 * time.Hour)
 
 	claims := &models.TokenClaims{
@@ -105,7 +105,7 @@ func (s *AuthService) Logout(token string) error {
 
 	if err != nil {
 		// 即使令牌无效，也将其加入黑名单
-		s.tokenBlacklist.Add(token, time.Now().Add(24*time.Hour))
+		s.tokenBlacklist.Add(token, s.clock.Now().Add(24*time.Hour))
 		return nil
 	}
 
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"monera-digital/internal/cache"
+	"monera-digital/internal/clock"
 	"monera-digital/internal/repository/postgres"
 )
 
@@ -46,15 +47,22 @@ var (
 type IdempotencyService struct {
 	redisCache *cache.RedisCache
 	dbRepo     *postgres.IdempotencyRepository
+	clock      clock.Clock
 }
 
 func NewIdempotencyService(redisCache *cache.RedisCache, dbRepo *postgres.IdempotencyRepository) *IdempotencyService {
 	return &IdempotencyService{
 		redisCache: redisCache,
 		dbRepo:     dbRepo,
+		clock:      clock.System,
 	}
 }
 
+// SetClock 替换判断记录过期所用的时间来源
+func (s *IdempotencyService) SetClock(c clock.Clock) {
+	s.clock = c
+}
+
 // CheckOrCreate 检查或创建幂等性记录
 // 优先使用 Redis，如果 Redis 不可用则回退到数据库
 func (s *IdempotencyService) CheckOrCreate(ctx context.Context, key string) (*IdempotencyRecord, bool, error) {
@@ -80,8 +88,8 @@ func (s *IdempotencyService) checkOrCreateFromRedis(ctx context.Context, key str
 		record := &IdempotencyRecord{
 			Key:       key,
 			Status:    IdempotencyStatusProcessing,
-			CreatedAt: time.Now(),
-			ExpiresAt: time.Now().Add(24 * time.Hour),
+			CreatedAt: s.clock.Now(),
+			ExpiresAt: s.clock.Now().Add(24 * time.Hour),
 		}
 
 		jsonData, _ := json.Marshal(record)
@@ -101,10 +109,10 @@ func (s *IdempotencyService) checkOrCreateFromRedis(ctx context.Context, key str
 	}
 
 	// 检查是否过期
-	if time.Now().After(record.ExpiresAt) {
+	if s.clock.Now().After(record.ExpiresAt) {
 		record.Status = IdempotencyStatusProcessing
-		record.CreatedAt = time.Now()
-		record.ExpiresAt = time.Now().Add(24 * time.Hour)
+		record.CreatedAt = s.clock.Now()
+		record.ExpiresAt = s.clock.Now().Add(24 * time.Hour)
 
 		jsonData, _ := json.Marshal(record)
 		s.redisCache.Set(ctx, "idempotency:"+key, string(jsonData), 24*time.Hour)
@@ -155,7 +163,7 @@ func (s *IdempotencyService) checkOrCreateFromDB(ctx context.Context, key string
 	}
 
 	// 创建新记录
-	now := time.Now()
+	now := s.clock.Now()
 	newRecord := &postgres.IdempotencyRecordModel{
 		RequestID:   key,
 		BizType:     "WEALTH_SUBSCRIBE",
@@ -1,102 +1,314 @@
 package services
 
 import (
-	"database/sql"
+	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
+	"monera-digital/internal/accrual"
+	"monera-digital/internal/clock"
 	"monera-digital/internal/models"
+	"monera-digital/internal/money"
+	"monera-digital/internal/repository"
 )
 
+var (
+	ErrInvalidLendingRequest   = errors.New("invalid lending request")
+	ErrLendingPositionNotFound = errors.New("lending position not found")
+	ErrLendingPositionClosed   = errors.New("lending position already closed")
+	ErrLendingPositionMatured  = errors.New("lending position has matured")
+	ErrLendingRateNotFound     = errors.New("no lending rate for asset and duration")
+	ErrInvalidLendingRates     = errors.New("invalid lending rate curve")
+)
+
+// 借贷资金变动的流水类型
+const (
+	LendingBizFreeze   = "LENDING_FREEZE"
+	LendingBizUnfreeze = "LENDING_UNFREEZE"
+	LendingBizYield    = "LENDING_YIELD"
+)
+
+// LendingRateBucket 利率曲线的一个期限档：期限不少于 MinDurationDays 天的仓位适用该 APY
+type LendingRateBucket struct {
+	MinDurationDays int    `json:"minDurationDays"`
+	APY             string `json:"apy"`
+}
+
+// LendingRateCurve 某资产自某一生效日期起的利率曲线
+type LendingRateCurve struct {
+	Asset         string              `json:"asset"`
+	EffectiveDate string              `json:"effectiveDate"`
+	Buckets       []LendingRateBucket `json:"buckets"`
+}
+
 type LendingService struct {
-	DB *sql.DB
+	repo        repository.Lending
+	accountRepo repository.AccountV2
+	journalRepo repository.Journal
+	uow         repository.UnitOfWork
+	clock       clock.Clock
 }
 
-func NewLendingService(db *sql.DB) *LendingService {
-	return &LendingService{DB: db}
+func NewLendingService(repo repository.Lending, accountRepo repository.AccountV2, journalRepo repository.Journal, uow repository.UnitOfWork) *LendingService {
+	return &LendingService{
+		repo:        repo,
+		accountRepo: accountRepo,
+		journalRepo: journalRepo,
+		uow:         uow,
+		clock:       clock.System,
+	}
 }
 
-func (s *LendingService) CalculateAPY(asset string, durationDays int) string {
-	baseRates := map[string]float64{
-		"BTC":  4.5,
-		"ETH":  5.2,
-		"USDT": 8.5,
-		"USDC": 8.2,
-		"SOL":  6.8,
+// SetClock 替换时间来源，供测试拨动时间
+func (s *LendingService) SetClock(c clock.Clock) {
+	s.clock = c
+}
+
+// ApplyForLending 冻结出借本金并开立仓位。收益自次一营业日起按日入账，
+// 到期后由借贷任务解冻本金并派发收益。
+func (s *LendingService) ApplyForLending(ctx context.Context, userID int, req models.ApplyLendingRequest) (*models.LendingPosition, error) {
+	asset := strings.ToUpper(strings.TrimSpace(req.Asset))
+	amount, err := money.Parse(req.Amount)
+	if err != nil {
+		return nil, fmt.Errorf("%w: amount must be a decimal", ErrInvalidLendingRequest)
+	}
+	amount = money.Quantize(asset, amount)
+	if !amount.IsPositive() {
+		return nil, fmt.Errorf("%w: amount must be positive", ErrInvalidLendingRequest)
+	}
+	if req.DurationDays <= 0 {
+		return nil, fmt.Errorf("%w: durationDays must be positive", ErrInvalidLendingRequest)
+	}
+
+	now := s.clock.Now()
+	// 开仓时锁定当日生效的利率，之后的调价不影响该仓位
+	apy, err := s.rateFor(ctx, asset, req.DurationDays, repository.LendingCalendar().Today(now))
+	if err != nil {
+		return nil, err
+	}
+
+	account, err := s.accountRepo.GetAccountByUserIDAndCurrency(ctx, int64(userID), asset)
+	if err != nil {
+		return nil, ErrInsufficientBalance
+	}
+	if account.Available().LessThan(amount) {
+		return nil, ErrInsufficientBalance
 	}
 
-	baseRate, exists := baseRates[asset]
-	if !exists {
-		baseRate = 5.0
+	position := &repository.LendingPositionModel{
+		UserID:       int64(userID),
+		Asset:        asset,
+		Amount:       amount,
+		DurationDays: req.DurationDays,
+		APY:          apy,
+		Status:       models.LendingStatusActive,
+		AccruedYield: money.Zero,
+		YieldPaid:    money.Zero,
+		StartDate:    now,
 	}
+	// 到期时刻为最后一个计息营业日开始的时刻
+	_, end := position.Term()
+	position.EndDate = position.Calendar().Start(end)
 
-	multiplier := 1.0
-	if durationDays >= 360 {
-		multiplier = 1.5
-	} else if durationDays >= 180 {
-		multiplier = 1.25
-	} else if durationDays >= 90 {
-		multiplier = 1.1
+	err = s.uow.Do(ctx, func(tx *repository.TxRepository) error {
+		if err := tx.Lending.CreatePosition(ctx, position); err != nil {
+			return err
+		}
+		if err := tx.Account.FreezeBalance(ctx, account.ID, amount); err != nil {
+			return err
+		}
+		return tx.Journal.CreateJournalRecord(ctx, &repository.JournalModel{
+			SerialNo:        fmt.Sprintf("LENDING-APPLY-%s-%d", now.Format("20060102150405"), position.ID),
+			UserID:          position.UserID,
+			AccountID:       account.ID,
+			Amount:          amount.Neg(),
+			BalanceSnapshot: account.Available().Sub(amount),
+			BizType:         LendingBizFreeze,
+			RefID:           &position.ID,
+			CreatedAt:       now.Format(time.RFC3339),
+		})
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	apy := baseRate * multiplier
-	return fmt.Sprintf("%.2f", apy)
+	result := toLendingPosition(position)
+	return &result, nil
 }
 
-func (s *LendingService) ApplyForLending(userID int, req models.ApplyLendingRequest) (*models.LendingPosition, error) {
-	apy := s.CalculateAPY(req.Asset, req.DurationDays)
-	startDate := time.Now()
-	endDate := startDate.AddDate(0, 0, req.DurationDays)
+func (s *LendingService) GetUserPositions(ctx context.Context, userID int) ([]models.LendingPosition, error) {
+	positions, err := s.repo.GetPositionsByUserID(ctx, int64(userID))
+	if err != nil {
+		return nil, err
+	}
+	result := make([]models.LendingPosition, 0, len(positions))
+	for _, p := range positions {
+		result = append(result, toLendingPosition(p))
+	}
+	return result, nil
+}
+
+// TerminateLending 提前终止持有中的仓位：本金全额解冻，已计收益不予派发。
+// 已到期的仓位由借贷任务正常结清，不可再提前终止。
+func (s *LendingService) TerminateLending(ctx context.Context, userID int, positionID int64) (*models.LendingPosition, error) {
+	position, err := s.repo.GetPositionByID(ctx, positionID)
+	if err != nil || position.UserID != int64(userID) {
+		return nil, ErrLendingPositionNotFound
+	}
+	if position.Status != models.LendingStatusActive {
+		return nil, ErrLendingPositionClosed
+	}
 
-	query := `
-		INSERT INTO lending_positions (user_id, asset, amount, duration_days, apy, status, end_date)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
-		RETURNING id, user_id, asset, amount, duration_days, apy, status, accrued_yield, start_date, end_date
-	`
+	now := s.clock.Now()
+	if _, end := position.Term(); !position.Calendar().BusinessDate(now).Before(end) {
+		return nil, ErrLendingPositionMatured
+	}
 
-	var position models.LendingPosition
-	err := s.DB.QueryRow(query, userID, req.Asset, req.Amount, req.DurationDays, apy, "ACTIVE", endDate).Scan(
-		&position.ID, &position.UserID, &position.Asset, &position.Amount,
-		&position.DurationDays, &position.Apy, &position.Status, &position.AccruedYield,
-		&position.StartDate, &position.EndDate,
-	)
+	account, err := s.accountRepo.GetAccountByUserIDAndCurrency(ctx, position.UserID, position.Asset)
+	if err != nil {
+		return nil, err
+	}
 
+	err = s.uow.Do(ctx, func(tx *repository.TxRepository) error {
+		// 先以条件更新锁定仓位状态，并发的终止与到期结清只有一笔能成功
+		if err := tx.Lending.ClosePosition(ctx, position.ID, models.LendingStatusTerminated, money.Zero); err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrLendingPositionClosed
+			}
+			return err
+		}
+		if err := tx.Account.UnfreezeBalance(ctx, account.ID, position.Amount); err != nil {
+			return err
+		}
+		return tx.Journal.CreateJournalRecord(ctx, &repository.JournalModel{
+			SerialNo:        fmt.Sprintf("LENDING-TERMINATE-%s-%d", now.Format("20060102150405"), position.ID),
+			UserID:          position.UserID,
+			AccountID:       account.ID,
+			Amount:          position.Amount,
+			BalanceSnapshot: account.Available().Add(position.Amount),
+			BizType:         LendingBizUnfreeze,
+			RefID:           &position.ID,
+			CreatedAt:       now.Format(time.RFC3339),
+		})
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	return &position, nil
+	position.Status = models.LendingStatusTerminated
+	position.YieldPaid = money.Zero
+	result := toLendingPosition(position)
+	return &result, nil
 }
 
-func (s *LendingService) GetUserPositions(userID int) ([]models.LendingPosition, error) {
-	query := `
-		SELECT id, user_id, asset, amount, duration_days, apy, status, accrued_yield, start_date, end_date
-		FROM lending_positions
-		WHERE user_id = $1
-		ORDER BY start_date
-	`
+// rateFor 返回 date 生效的利率曲线中适用于 durationDays 的 APY：取下限不超过期限的最高一档
+func (s *LendingService) rateFor(ctx context.Context, asset string, durationDays int, date string) (money.Decimal, error) {
+	rates, err := s.repo.GetRates(ctx, date)
+	if err != nil {
+		return money.Zero, err
+	}
+	var apy *money.Decimal
+	for _, rate := range rates {
+		if rate.Asset == asset && rate.MinDurationDays <= durationDays {
+			apy = &rate.APY
+		}
+	}
+	if apy == nil {
+		return money.Zero, ErrLendingRateNotFound
+	}
+	return *apy, nil
+}
+
+// GetRates 返回各资产当前生效的利率曲线，供前端展示
+func (s *LendingService) GetRates(ctx context.Context) ([]*LendingRateCurve, error) {
+	rates, err := s.repo.GetRates(ctx, repository.LendingCalendar().Today(s.clock.Now()))
+	if err != nil {
+		return nil, err
+	}
+	return groupLendingRates(rates, func(r *repository.LendingRateModel) string { return r.Asset }), nil
+}
 
-	rows, err := s.DB.Query(query, userID)
+// ListRates 返回某资产的利率曲线历史，按生效日期从新到旧
+func (s *LendingService) ListRates(ctx context.Context, asset string) ([]*LendingRateCurve, error) {
+	rates, err := s.repo.ListRates(ctx, strings.ToUpper(asset))
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-
-	var positions []models.LendingPosition
-	for rows.Next() {
-		var position models.LendingPosition
-		err := rows.Scan(
-			&position.ID, &position.UserID, &position.Asset, &position.Amount,
-			&position.DurationDays, &position.Apy, &position.Status, &position.AccruedYield,
-			&position.StartDate, &position.EndDate,
-		)
-		if err != nil {
-			return nil, err
+	return groupLendingRates(rates, func(r *repository.LendingRateModel) string { return r.EffectiveDate }), nil
+}
+
+// SetRates 设置资产自 effectiveDate 起生效的利率曲线，覆盖同一日期已排期的曲线。
+// 生效日期不能早于当前营业日；已开立的仓位保留开仓时的利率。
+func (s *LendingService) SetRates(ctx context.Context, asset string, effectiveDate string, buckets []LendingRateBucket) error {
+	asset = strings.ToUpper(strings.TrimSpace(asset))
+	if asset == "" {
+		return ErrInvalidLendingRates
+	}
+
+	date, err := time.Parse(accrual.DateLayout, effectiveDate)
+	if err != nil {
+		return ErrInvalidLendingRates
+	}
+	if date.Before(repository.LendingCalendar().BusinessDate(s.clock.Now())) {
+		return ErrRetroactiveRate
+	}
+
+	if len(buckets) == 0 {
+		return ErrInvalidLendingRates
+	}
+	rates := make([]*repository.LendingRateModel, 0, len(buckets))
+	for i, bucket := range buckets {
+		apy, err := money.Parse(bucket.APY)
+		if err != nil || apy.IsNegative() {
+			return ErrInvalidLendingRates
 		}
-		positions = append(positions, position)
+		// 期限下限非负且严格递增
+		if bucket.MinDurationDays < 0 || (i > 0 && bucket.MinDurationDays <= rates[i-1].MinDurationDays) {
+			return ErrInvalidLendingRates
+		}
+		rates = append(rates, &repository.LendingRateModel{
+			Asset:           asset,
+			MinDurationDays: bucket.MinDurationDays,
+			APY:             apy,
+			EffectiveDate:   effectiveDate,
+		})
+	}
+
+	return s.uow.Do(ctx, func(tx *repository.TxRepository) error {
+		return tx.Lending.SaveRates(ctx, asset, effectiveDate, rates)
+	})
+}
+
+// groupLendingRates 将按 key 连续排列的期限档合并为曲线
+func groupLendingRates(rates []*repository.LendingRateModel, key func(*repository.LendingRateModel) string) []*LendingRateCurve {
+	result := []*LendingRateCurve{}
+	var lastKey string
+	for _, rate := range rates {
+		if len(result) == 0 || key(rate) != lastKey {
+			result = append(result, &LendingRateCurve{Asset: rate.Asset, EffectiveDate: rate.EffectiveDate})
+			lastKey = key(rate)
+		}
+		last := result[len(result)-1]
+		last.Buckets = append(last.Buckets, LendingRateBucket{MinDurationDays: rate.MinDurationDays, APY: rate.APY.String()})
 	}
+	return result
+}
 
-	return positions, nil
+func toLendingPosition(p *repository.LendingPositionModel) models.LendingPosition {
+	return models.LendingPosition{
+		ID:           int(p.ID),
+		UserID:       int(p.UserID),
+		Asset:        p.Asset,
+		Amount:       p.Amount.String(),
+		DurationDays: p.DurationDays,
+		Apy:          p.APY.String(),
+		Status:       p.Status,
+		AccruedYield: p.AccruedYield.String(),
+		StartDate:    p.StartDate,
+		EndDate:      p.EndDate,
+	}
 }
 
 func (s *LendingService) CalculateEstimatedYield(amount, apy float64, durationDays int) float64 {
@@ -1,30 +1,119 @@
 package services
 
 import (
+	"context"
 	"math"
 	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"monera-digital/internal/clock"
+	"monera-digital/internal/config"
+	"monera-digital/internal/models"
+	"monera-digital/internal/money"
+	"monera-digital/internal/repository"
 )
 
-func TestLendingService_CalculateAPY(t *testing.T) {
-	ls := &LendingService{}
+// seedLendingRates 与迁移 022 写入的初始利率曲线一致
+func seedLendingRates(asset string, apys ...string) []*repository.LendingRateModel {
+	buckets := []int{0, 90, 180, 360}
+	rates := make([]*repository.LendingRateModel, 0, len(apys))
+	for i, apy := range apys {
+		rates = append(rates, &repository.LendingRateModel{Asset: asset, MinDurationDays: buckets[i], APY: money.MustParse(apy), EffectiveDate: "1970-01-01"})
+	}
+	return rates
+}
+
+func TestLendingService_RateFor(t *testing.T) {
+	var curve []*repository.LendingRateModel
+	curve = append(curve, seedLendingRates("BTC", "4.50", "4.95", "5.62", "6.75")...)
+	curve = append(curve, seedLendingRates("ETH", "5.20", "5.72", "6.50", "7.80")...)
+	curve = append(curve, seedLendingRates("USDT", "8.50", "9.35", "10.62", "12.75")...)
+	curve = append(curve, seedLendingRates("USDC", "8.20", "9.02", "10.25", "12.30")...)
+
+	lendingRepo := new(MockLendingRepository)
+	lendingRepo.On("GetRates", mock.Anything, "2026-03-10").Return(curve, nil)
+	ls := NewLendingService(lendingRepo, nil, nil, nil)
 
 	tests := []struct {
 		asset        string
 		durationDays int
 		expected     string
+		wantErr      error
 	}{
-		{"BTC", 30, "4.50"},
-		{"ETH", 90, "5.72"},
-		{"USDT", 180, "10.62"},
-		{"USDC", 360, "12.30"},
-		{"UNKNOWN", 30, "5.00"},
+		{"BTC", 30, "4.5", nil},
+		{"ETH", 90, "5.72", nil},
+		{"USDT", 180, "10.62", nil},
+		{"USDC", 360, "12.3", nil},
+		{"USDC", 359, "10.25", nil},
+		{"SOL", 30, "", ErrLendingRateNotFound},
 	}
 
 	for _, test := range tests {
-		result := ls.CalculateAPY(test.asset, test.durationDays)
-		if result != test.expected {
-			t.Errorf("CalculateAPY(%s, %d) = %s; expected %s", test.asset, test.durationDays, result, test.expected)
+		result, err := ls.rateFor(context.Background(), test.asset, test.durationDays, "2026-03-10")
+		if test.wantErr != nil {
+			assert.ErrorIs(t, err, test.wantErr)
+			continue
 		}
+		assert.NoError(t, err)
+		assert.Equal(t, test.expected, result.String(), "%s %d days", test.asset, test.durationDays)
+	}
+}
+
+func TestLendingService_GetRates(t *testing.T) {
+	// 2026-03-10 07:00 北京时间
+	service, lendingRepo, _, _, _ := newTestLendingService(time.Date(2026, 3, 9, 23, 0, 0, 0, time.UTC))
+	lendingRepo.On("GetRates", mock.Anything, "2026-03-10").Return([]*repository.LendingRateModel{
+		{Asset: "BTC", MinDurationDays: 0, APY: money.MustParse("4.5"), EffectiveDate: "1970-01-01"},
+		{Asset: "BTC", MinDurationDays: 90, APY: money.MustParse("5"), EffectiveDate: "1970-01-01"},
+		{Asset: "USDT", MinDurationDays: 0, APY: money.MustParse("9"), EffectiveDate: "2026-03-01"},
+	}, nil)
+
+	curves, err := service.GetRates(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, []*LendingRateCurve{
+		{Asset: "BTC", EffectiveDate: "1970-01-01", Buckets: []LendingRateBucket{{0, "4.5"}, {90, "5"}}},
+		{Asset: "USDT", EffectiveDate: "2026-03-01", Buckets: []LendingRateBucket{{0, "9"}}},
+	}, curves)
+}
+
+func TestLendingService_SetRates(t *testing.T) {
+	now := time.Date(2026, 3, 10, 2, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		date    string
+		buckets []LendingRateBucket
+		wantErr error
+	}{
+		{name: "today", date: "2026-03-10", buckets: []LendingRateBucket{{0, "4"}, {90, "4.5"}}},
+		{name: "future", date: "2026-04-01", buckets: []LendingRateBucket{{30, "4"}}},
+		{name: "retroactive", date: "2026-03-09", buckets: []LendingRateBucket{{0, "4"}}, wantErr: ErrRetroactiveRate},
+		{name: "bad date", date: "10/03/2026", buckets: []LendingRateBucket{{0, "4"}}, wantErr: ErrInvalidLendingRates},
+		{name: "empty", date: "2026-03-10", wantErr: ErrInvalidLendingRates},
+		{name: "not increasing", date: "2026-03-10", buckets: []LendingRateBucket{{90, "4"}, {90, "5"}}, wantErr: ErrInvalidLendingRates},
+		{name: "negative apy", date: "2026-03-10", buckets: []LendingRateBucket{{0, "-1"}}, wantErr: ErrInvalidLendingRates},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, lendingRepo, _, _, uow := newTestLendingService(now)
+			lendingRepo.On("SaveRates", mock.Anything, "BTC", tt.date, mock.Anything).Return(nil)
+
+			err := service.SetRates(context.Background(), "btc", tt.date, tt.buckets)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.False(t, wasCalled(&lendingRepo.Mock, "SaveRates"))
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, 1, uow.Commits)
+			saved := lendingRepo.Calls[0].Arguments.Get(3).([]*repository.LendingRateModel)
+			assert.Len(t, saved, len(tt.buckets))
+		})
 	}
 }
 
@@ -49,3 +138,137 @@ func TestLendingService_CalculateEstimatedYield(t *testing.T) {
 		}
 	}
 }
+
+func newTestLendingService(now time.Time) (*LendingService, *MockLendingRepository, *MockAccountRepository, *MockJournalRepository, *MockUnitOfWork) {
+	lendingRepo := new(MockLendingRepository)
+	accountRepo := new(MockAccountRepository)
+	journalRepo := new(MockJournalRepository)
+	uow := NewMockUnitOfWork(nil, accountRepo, journalRepo)
+	uow.Repos.Lending = lendingRepo
+	service := NewLendingService(lendingRepo, accountRepo, journalRepo, uow)
+	service.SetClock(clock.NewFake(now))
+	return service, lendingRepo, accountRepo, journalRepo, uow
+}
+
+func TestLendingService_ApplyForLending_FreezesBalance(t *testing.T) {
+	// 2026-03-10 10:00 北京时间
+	now := time.Date(2026, 3, 10, 2, 0, 0, 0, time.UTC)
+	service, lendingRepo, accountRepo, journalRepo, uow := newTestLendingService(now)
+
+	account := &repository.AccountModel{ID: 7, UserID: 1, Currency: "USDT", Balance: money.MustParse("1000"), FrozenBalance: money.MustParse("200")}
+	lendingRepo.On("GetRates", mock.Anything, "2026-03-10").Return(seedLendingRates("USDT", "8.50", "9.35", "10.62", "12.75"), nil)
+	accountRepo.On("GetAccountByUserIDAndCurrency", mock.Anything, int64(1), "USDT").Return(account, nil)
+	lendingRepo.On("CreatePosition", mock.Anything, mock.MatchedBy(func(p *repository.LendingPositionModel) bool {
+		return p.Amount.Equal(money.MustParse("500")) && p.Status == models.LendingStatusActive
+	})).Run(func(args mock.Arguments) {
+		args.Get(1).(*repository.LendingPositionModel).ID = 42
+	}).Return(nil)
+	accountRepo.On("FreezeBalance", mock.Anything, int64(7), money.MustParse("500")).Return(nil)
+	journalRepo.On("CreateJournalRecord", mock.Anything, mock.MatchedBy(func(j *repository.JournalModel) bool {
+		return j.BizType == LendingBizFreeze && j.Amount.String() == "-500" &&
+			j.BalanceSnapshot.String() == "300" && *j.RefID == 42
+	})).Return(nil)
+
+	position, err := service.ApplyForLending(context.Background(), 1, models.ApplyLendingRequest{
+		Asset: "USDT", Amount: "500.0000000", DurationDays: 30,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 42, position.ID)
+	assert.Equal(t, "8.5", position.Apy)
+	assert.Equal(t, "2026-03-10", position.StartDate.In(config.GetLocation()).Format("2006-01-02"))
+	assert.Equal(t, "2026-04-09", position.EndDate.In(config.GetLocation()).Format("2006-01-02"))
+	assert.Equal(t, 1, uow.Commits)
+	lendingRepo.AssertExpectations(t)
+	accountRepo.AssertExpectations(t)
+	journalRepo.AssertExpectations(t)
+}
+
+func TestLendingService_ApplyForLending_InsufficientBalance(t *testing.T) {
+	service, lendingRepo, accountRepo, _, _ := newTestLendingService(time.Now())
+
+	account := &repository.AccountModel{ID: 7, UserID: 1, Currency: "BTC", Balance: money.MustParse("1"), FrozenBalance: money.MustParse("0.8")}
+	lendingRepo.On("GetRates", mock.Anything, mock.Anything).Return(seedLendingRates("BTC", "4.50"), nil)
+	accountRepo.On("GetAccountByUserIDAndCurrency", mock.Anything, int64(1), "BTC").Return(account, nil)
+
+	_, err := service.ApplyForLending(context.Background(), 1, models.ApplyLendingRequest{
+		Asset: "BTC", Amount: "0.5", DurationDays: 30,
+	})
+
+	assert.ErrorIs(t, err, ErrInsufficientBalance)
+	assert.False(t, wasCalled(&lendingRepo.Mock, "CreatePosition"))
+	assert.False(t, wasCalled(&accountRepo.Mock, "FreezeBalance"))
+}
+
+func TestLendingService_TerminateLending(t *testing.T) {
+	now := time.Date(2026, 3, 20, 2, 0, 0, 0, time.UTC)
+	active := func() *repository.LendingPositionModel {
+		return &repository.LendingPositionModel{
+			ID: 42, UserID: 1, Asset: "USDT", Amount: money.MustParse("500"), DurationDays: 30,
+			APY: money.MustParse("8.5"), AccruedYield: money.MustParse("1.05"), Status: models.LendingStatusActive,
+			StartDate: time.Date(2026, 3, 10, 2, 0, 0, 0, time.UTC),
+		}
+	}
+
+	tests := []struct {
+		name     string
+		userID   int
+		now      time.Time
+		position func() *repository.LendingPositionModel
+		wantErr  error
+	}{
+		{name: "other user", userID: 2, now: now, position: active, wantErr: ErrLendingPositionNotFound},
+		{name: "already closed", userID: 1, now: now, position: func() *repository.LendingPositionModel {
+			p := active()
+			p.Status = models.LendingStatusTerminated
+			return p
+		}, wantErr: ErrLendingPositionClosed},
+		{name: "matured", userID: 1, now: time.Date(2026, 4, 9, 2, 0, 0, 0, time.UTC), position: active, wantErr: ErrLendingPositionMatured},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, lendingRepo, accountRepo, _, _ := newTestLendingService(tt.now)
+			lendingRepo.On("GetPositionByID", mock.Anything, int64(42)).Return(tt.position(), nil)
+
+			_, err := service.TerminateLending(context.Background(), tt.userID, 42)
+
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.False(t, wasCalled(&accountRepo.Mock, "UnfreezeBalance"))
+		})
+	}
+
+	t.Run("unfreezes principal and forfeits yield", func(t *testing.T) {
+		service, lendingRepo, accountRepo, journalRepo, uow := newTestLendingService(now)
+		account := &repository.AccountModel{ID: 7, UserID: 1, Currency: "USDT", Balance: money.MustParse("1000"), FrozenBalance: money.MustParse("500")}
+		lendingRepo.On("GetPositionByID", mock.Anything, int64(42)).Return(active(), nil)
+		accountRepo.On("GetAccountByUserIDAndCurrency", mock.Anything, int64(1), "USDT").Return(account, nil)
+		lendingRepo.On("ClosePosition", mock.Anything, int64(42), models.LendingStatusTerminated, money.Zero).Return(nil)
+		accountRepo.On("UnfreezeBalance", mock.Anything, int64(7), money.MustParse("500")).Return(nil)
+		journalRepo.On("CreateJournalRecord", mock.Anything, mock.MatchedBy(func(j *repository.JournalModel) bool {
+			return j.BizType == LendingBizUnfreeze && j.Amount.String() == "500" && j.BalanceSnapshot.String() == "1000"
+		})).Return(nil)
+
+		position, err := service.TerminateLending(context.Background(), 1, 42)
+
+		assert.NoError(t, err)
+		assert.Equal(t, models.LendingStatusTerminated, position.Status)
+		assert.Equal(t, 1, uow.Commits)
+		lendingRepo.AssertExpectations(t)
+		accountRepo.AssertExpectations(t)
+		journalRepo.AssertExpectations(t)
+	})
+
+	t.Run("concurrent close is reported as closed", func(t *testing.T) {
+		service, lendingRepo, accountRepo, _, uow := newTestLendingService(now)
+		lendingRepo.On("GetPositionByID", mock.Anything, int64(42)).Return(active(), nil)
+		accountRepo.On("GetAccountByUserIDAndCurrency", mock.Anything, int64(1), "USDT").Return(&repository.AccountModel{ID: 7}, nil)
+		lendingRepo.On("ClosePosition", mock.Anything, int64(42), models.LendingStatusTerminated, money.Zero).Return(repository.ErrNotFound)
+
+		_, err := service.TerminateLending(context.Background(), 1, 42)
+
+		assert.ErrorIs(t, err, ErrLendingPositionClosed)
+		assert.Equal(t, 1, uow.Rollbacks)
+		assert.False(t, wasCalled(&accountRepo.Mock, "UnfreezeBalance"))
+	})
+}
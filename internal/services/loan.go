@@ -0,0 +1,366 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"monera-digital/internal/binance"
+	"monera-digital/internal/clock"
+	"monera-digital/internal/money"
+	"monera-digital/internal/repository"
+)
+
+var (
+	ErrInvalidLoanRequest = errors.New("invalid loan request")
+	ErrLoanNotFound       = errors.New("loan not found")
+	ErrLoanClosed         = errors.New("loan already closed")
+	ErrLoanLTVTooHigh     = errors.New("loan-to-value exceeds the initial limit")
+	ErrPriceUnavailable   = errors.New("collateral price unavailable")
+	ErrLoanAccountMissing = errors.New("no account for loan asset")
+)
+
+// 抵押借贷资金变动的流水类型
+const (
+	LoanBizCollateralLock    = "LOAN_COLLATERAL_LOCK"
+	LoanBizCollateralRelease = "LOAN_COLLATERAL_RELEASE"
+	LoanBizDisburse          = "LOAN_DISBURSE"
+	LoanBizRepay             = "LOAN_REPAY"
+	LoanBizLiquidation       = "LOAN_LIQUIDATION"
+)
+
+// LoanTerms 抵押借贷参数，LTV 与罚金均为比例（0.5 即 50%）
+type LoanTerms struct {
+	MaxInitialLTV      money.Decimal
+	MarginCallLTV      money.Decimal
+	LiquidationLTV     money.Decimal
+	LiquidationPenalty money.Decimal
+}
+
+// DefaultLoanTerms 开仓不超过 50%，70% 追加保证金，80% 强制清算并收取 5% 罚金
+var DefaultLoanTerms = LoanTerms{
+	MaxInitialLTV:      money.MustParse("0.5"),
+	MarginCallLTV:      money.MustParse("0.7"),
+	LiquidationLTV:     money.MustParse("0.8"),
+	LiquidationPenalty: money.MustParse("0.05"),
+}
+
+var (
+	loanCollateralAssets = map[string]bool{"BTC": true, "ETH": true}
+	loanBorrowAssets     = map[string]bool{"USDT": true, "USDC": true}
+)
+
+// BorrowRequest 抵押借款申请
+type BorrowRequest struct {
+	CollateralAsset  string `json:"collateralAsset" binding:"required"`
+	CollateralAmount string `json:"collateralAmount" binding:"required"`
+	BorrowAsset      string `json:"borrowAsset" binding:"required"`
+	BorrowAmount     string `json:"borrowAmount" binding:"required"`
+}
+
+// Loan 借款详情
+type Loan struct {
+	ID                   int64      `json:"id"`
+	CollateralAsset      string     `json:"collateralAsset"`
+	CollateralAmount     string     `json:"collateralAmount"`
+	BorrowAsset          string     `json:"borrowAsset"`
+	Principal            string     `json:"principal"`
+	LTV                  string     `json:"ltv,omitempty"` // 按当前价格计算，无报价或已结清时为空
+	MarginCallLTV        string     `json:"marginCallLtv"`
+	LiquidationLTV       string     `json:"liquidationLtv"`
+	Status               string     `json:"status"`
+	LiquidatedCollateral string     `json:"liquidatedCollateral,omitempty"`
+	LiquidationPrice     string     `json:"liquidationPrice,omitempty"`
+	CreatedAt            time.Time  `json:"createdAt"`
+	ClosedAt             *time.Time `json:"closedAt,omitempty"`
+}
+
+type LoanService struct {
+	repo        repository.Loan
+	accountRepo repository.AccountV2
+	uow         repository.UnitOfWork
+	prices      binance.PriceFeed
+	terms       LoanTerms
+	clock       clock.Clock
+}
+
+func NewLoanService(repo repository.Loan, accountRepo repository.AccountV2, uow repository.UnitOfWork, prices binance.PriceFeed) *LoanService {
+	return &LoanService{
+		repo:        repo,
+		accountRepo: accountRepo,
+		uow:         uow,
+		prices:      prices,
+		terms:       DefaultLoanTerms,
+		clock:       clock.System,
+	}
+}
+
+// SetTerms 替换新开借款使用的 LTV 参数；已开立的借款保留开仓时的参数
+func (s *LoanService) SetTerms(terms LoanTerms) {
+	s.terms = terms
+}
+
+// SetClock 替换时间来源，供测试拨动时间
+func (s *LoanService) SetClock(c clock.Clock) {
+	s.clock = c
+}
+
+// Borrow 冻结抵押物并发放稳定币借款，开仓 LTV 不得超过 MaxInitialLTV
+func (s *LoanService) Borrow(ctx context.Context, userID int, req BorrowRequest) (*Loan, error) {
+	collateralAsset := strings.ToUpper(strings.TrimSpace(req.CollateralAsset))
+	borrowAsset := strings.ToUpper(strings.TrimSpace(req.BorrowAsset))
+	if !loanCollateralAssets[collateralAsset] || !loanBorrowAssets[borrowAsset] {
+		return nil, fmt.Errorf("%w: unsupported asset pair %s/%s", ErrInvalidLoanRequest, collateralAsset, borrowAsset)
+	}
+	collateral, err := money.Parse(req.CollateralAmount)
+	if err != nil {
+		return nil, fmt.Errorf("%w: collateralAmount must be a decimal", ErrInvalidLoanRequest)
+	}
+	collateral = money.Quantize(collateralAsset, collateral)
+	principal, err := money.Parse(req.BorrowAmount)
+	if err != nil {
+		return nil, fmt.Errorf("%w: borrowAmount must be a decimal", ErrInvalidLoanRequest)
+	}
+	principal = money.Quantize(borrowAsset, principal)
+	if !collateral.IsPositive() || !principal.IsPositive() {
+		return nil, fmt.Errorf("%w: amounts must be positive", ErrInvalidLoanRequest)
+	}
+
+	price, ok := binance.PriceOf(s.prices, collateralAsset)
+	if !ok {
+		return nil, ErrPriceUnavailable
+	}
+	loan := &repository.LoanModel{
+		UserID:               int64(userID),
+		CollateralAsset:      collateralAsset,
+		CollateralAmount:     collateral,
+		BorrowAsset:          borrowAsset,
+		Principal:            principal,
+		MarginCallLTV:        s.terms.MarginCallLTV,
+		LiquidationLTV:       s.terms.LiquidationLTV,
+		LiquidationPenalty:   s.terms.LiquidationPenalty,
+		Status:               repository.LoanStatusActive,
+		LiquidatedCollateral: money.Zero,
+		LiquidationPrice:     money.Zero,
+	}
+	if ltv, _ := loan.LTV(price); ltv.GreaterThan(s.terms.MaxInitialLTV) {
+		return nil, ErrLoanLTVTooHigh
+	}
+
+	collateralAccount, err := s.accountRepo.GetAccountByUserIDAndCurrency(ctx, int64(userID), collateralAsset)
+	if err != nil || collateralAccount.Available().LessThan(collateral) {
+		return nil, ErrInsufficientBalance
+	}
+	borrowAccount, err := s.accountRepo.GetAccountByUserIDAndCurrency(ctx, int64(userID), borrowAsset)
+	if err != nil {
+		return nil, ErrLoanAccountMissing
+	}
+
+	now := s.clock.Now()
+	err = s.uow.Do(ctx, func(tx *repository.TxRepository) error {
+		if err := tx.Loan.CreateLoan(ctx, loan); err != nil {
+			return err
+		}
+		if err := tx.Account.FreezeBalance(ctx, collateralAccount.ID, collateral); err != nil {
+			return err
+		}
+		if err := tx.Journal.CreateJournalRecord(ctx, &repository.JournalModel{
+			SerialNo:        fmt.Sprintf("LOAN-COLLATERAL-%s-%d", now.Format("20060102150405"), loan.ID),
+			UserID:          loan.UserID,
+			AccountID:       collateralAccount.ID,
+			Amount:          collateral.Neg(),
+			BalanceSnapshot: collateralAccount.Available().Sub(collateral),
+			BizType:         LoanBizCollateralLock,
+			RefID:           &loan.ID,
+			CreatedAt:       now.Format(time.RFC3339),
+		}); err != nil {
+			return err
+		}
+		if err := tx.Account.AddBalance(ctx, borrowAccount.ID, principal); err != nil {
+			return err
+		}
+		return tx.Journal.CreateJournalRecord(ctx, &repository.JournalModel{
+			SerialNo:        fmt.Sprintf("LOAN-DISBURSE-%s-%d", now.Format("20060102150405"), loan.ID),
+			UserID:          loan.UserID,
+			AccountID:       borrowAccount.ID,
+			Amount:          principal,
+			BalanceSnapshot: borrowAccount.Available().Add(principal),
+			BizType:         LoanBizDisburse,
+			RefID:           &loan.ID,
+			CreatedAt:       now.Format(time.RFC3339),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.toLoan(loan), nil
+}
+
+// Repay 全额归还借款并解冻抵押物
+func (s *LoanService) Repay(ctx context.Context, userID int, loanID int64) (*Loan, error) {
+	loan, err := s.userLoan(ctx, userID, loanID)
+	if err != nil {
+		return nil, err
+	}
+
+	borrowAccount, err := s.accountRepo.GetAccountByUserIDAndCurrency(ctx, loan.UserID, loan.BorrowAsset)
+	if err != nil || borrowAccount.Available().LessThan(loan.Principal) {
+		return nil, ErrInsufficientBalance
+	}
+	collateralAccount, err := s.accountRepo.GetAccountByUserIDAndCurrency(ctx, loan.UserID, loan.CollateralAsset)
+	if err != nil {
+		return nil, ErrLoanAccountMissing
+	}
+
+	now := s.clock.Now()
+	loan.LiquidatedCollateral = money.Zero
+	loan.LiquidationPrice = money.Zero
+	err = s.uow.Do(ctx, func(tx *repository.TxRepository) error {
+		// 条件更新与清算互斥，只有一方能结清借款
+		if err := tx.Loan.CloseLoan(ctx, loan, repository.LoanStatusRepaid); err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrLoanClosed
+			}
+			return err
+		}
+		if err := tx.Account.DeductBalance(ctx, borrowAccount.ID, loan.Principal); err != nil {
+			return err
+		}
+		if err := tx.Journal.CreateJournalRecord(ctx, &repository.JournalModel{
+			SerialNo:        fmt.Sprintf("LOAN-REPAY-%s-%d", now.Format("20060102150405"), loan.ID),
+			UserID:          loan.UserID,
+			AccountID:       borrowAccount.ID,
+			Amount:          loan.Principal.Neg(),
+			BalanceSnapshot: borrowAccount.Available().Sub(loan.Principal),
+			BizType:         LoanBizRepay,
+			RefID:           &loan.ID,
+			CreatedAt:       now.Format(time.RFC3339),
+		}); err != nil {
+			return err
+		}
+		if err := tx.Account.UnfreezeBalance(ctx, collateralAccount.ID, loan.CollateralAmount); err != nil {
+			return err
+		}
+		return tx.Journal.CreateJournalRecord(ctx, &repository.JournalModel{
+			SerialNo:        fmt.Sprintf("LOAN-RELEASE-%s-%d", now.Format("20060102150405"), loan.ID),
+			UserID:          loan.UserID,
+			AccountID:       collateralAccount.ID,
+			Amount:          loan.CollateralAmount,
+			BalanceSnapshot: collateralAccount.Available().Add(loan.CollateralAmount),
+			BizType:         LoanBizCollateralRelease,
+			RefID:           &loan.ID,
+			CreatedAt:       now.Format(time.RFC3339),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	loan.Status = repository.LoanStatusRepaid
+	loan.ClosedAt = &now
+	return s.toLoan(loan), nil
+}
+
+// AddCollateral 追加抵押物以降低 LTV；追加后回到安全线以下的借款由监控任务解除追保状态
+func (s *LoanService) AddCollateral(ctx context.Context, userID int, loanID int64, amount string) (*Loan, error) {
+	loan, err := s.userLoan(ctx, userID, loanID)
+	if err != nil {
+		return nil, err
+	}
+	added, err := money.Parse(amount)
+	if err != nil {
+		return nil, fmt.Errorf("%w: amount must be a decimal", ErrInvalidLoanRequest)
+	}
+	added = money.Quantize(loan.CollateralAsset, added)
+	if !added.IsPositive() {
+		return nil, fmt.Errorf("%w: amount must be positive", ErrInvalidLoanRequest)
+	}
+
+	account, err := s.accountRepo.GetAccountByUserIDAndCurrency(ctx, loan.UserID, loan.CollateralAsset)
+	if err != nil || account.Available().LessThan(added) {
+		return nil, ErrInsufficientBalance
+	}
+
+	now := s.clock.Now()
+	err = s.uow.Do(ctx, func(tx *repository.TxRepository) error {
+		if err := tx.Loan.AddCollateral(ctx, loan.ID, added); err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrLoanClosed
+			}
+			return err
+		}
+		if err := tx.Account.FreezeBalance(ctx, account.ID, added); err != nil {
+			return err
+		}
+		return tx.Journal.CreateJournalRecord(ctx, &repository.JournalModel{
+			SerialNo:        fmt.Sprintf("LOAN-COLLATERAL-%s-%d", now.Format("20060102150405"), loan.ID),
+			UserID:          loan.UserID,
+			AccountID:       account.ID,
+			Amount:          added.Neg(),
+			BalanceSnapshot: account.Available().Sub(added),
+			BizType:         LoanBizCollateralLock,
+			RefID:           &loan.ID,
+			CreatedAt:       now.Format(time.RFC3339),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	loan.CollateralAmount = loan.CollateralAmount.Add(added)
+	return s.toLoan(loan), nil
+}
+
+func (s *LoanService) GetUserLoans(ctx context.Context, userID int) ([]*Loan, error) {
+	loans, err := s.repo.GetLoansByUserID(ctx, int64(userID))
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*Loan, 0, len(loans))
+	for _, loan := range loans {
+		result = append(result, s.toLoan(loan))
+	}
+	return result, nil
+}
+
+// userLoan 返回用户本人未结清的借款
+func (s *LoanService) userLoan(ctx context.Context, userID int, loanID int64) (*repository.LoanModel, error) {
+	loan, err := s.repo.GetLoanByID(ctx, loanID)
+	if err != nil || loan.UserID != int64(userID) {
+		return nil, ErrLoanNotFound
+	}
+	if !loan.IsOpen() {
+		return nil, ErrLoanClosed
+	}
+	return loan, nil
+}
+
+func (s *LoanService) toLoan(l *repository.LoanModel) *Loan {
+	loan := &Loan{
+		ID:               l.ID,
+		CollateralAsset:  l.CollateralAsset,
+		CollateralAmount: l.CollateralAmount.String(),
+		BorrowAsset:      l.BorrowAsset,
+		Principal:        l.Principal.String(),
+		MarginCallLTV:    l.MarginCallLTV.String(),
+		LiquidationLTV:   l.LiquidationLTV.String(),
+		Status:           string(l.Status),
+		CreatedAt:        l.CreatedAt,
+		ClosedAt:         l.ClosedAt,
+	}
+	if l.IsOpen() {
+		if price, ok := binance.PriceOf(s.prices, l.CollateralAsset); ok {
+			if ltv, ok := l.LTV(price); ok {
+				loan.LTV = ltv.String()
+			}
+		}
+	}
+	if l.Status == repository.LoanStatusLiquidated {
+		loan.LiquidatedCollateral = l.LiquidatedCollateral.String()
+		loan.LiquidationPrice = l.LiquidationPrice.String()
+	}
+	return loan
+}
@@ -0,0 +1,179 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"monera-digital/internal/binance"
+	"monera-digital/internal/clock"
+	"monera-digital/internal/money"
+	"monera-digital/internal/repository"
+)
+
+func newTestLoanService() (*LoanService, *MockLoanRepository, *MockAccountRepository, *MockJournalRepository, *MockUnitOfWork) {
+	loanRepo := new(MockLoanRepository)
+	accountRepo := new(MockAccountRepository)
+	journalRepo := new(MockJournalRepository)
+	uow := NewMockUnitOfWork(nil, accountRepo, journalRepo)
+	uow.Repos.Loan = loanRepo
+	service := NewLoanService(loanRepo, accountRepo, uow, binance.NewStaticPriceFeed(map[string]float64{"BTC": 60000}))
+	service.SetClock(clock.NewFake(time.Date(2026, 3, 10, 2, 0, 0, 0, time.UTC)))
+	return service, loanRepo, accountRepo, journalRepo, uow
+}
+
+func TestLoanService_Borrow_LocksCollateralAndDisburses(t *testing.T) {
+	service, loanRepo, accountRepo, journalRepo, uow := newTestLoanService()
+
+	btc := &repository.AccountModel{ID: 1, UserID: 1, Currency: "BTC", Balance: money.MustParse("0.5"), FrozenBalance: money.Zero}
+	usdt := &repository.AccountModel{ID: 2, UserID: 1, Currency: "USDT", Balance: money.MustParse("100"), FrozenBalance: money.Zero}
+	accountRepo.On("GetAccountByUserIDAndCurrency", mock.Anything, int64(1), "BTC").Return(btc, nil)
+	accountRepo.On("GetAccountByUserIDAndCurrency", mock.Anything, int64(1), "USDT").Return(usdt, nil)
+	loanRepo.On("CreateLoan", mock.Anything, mock.MatchedBy(func(l *repository.LoanModel) bool {
+		return l.Status == repository.LoanStatusActive && l.LiquidationLTV.Equal(money.MustParse("0.8"))
+	})).Run(func(args mock.Arguments) {
+		args.Get(1).(*repository.LoanModel).ID = 9
+	}).Return(nil)
+	accountRepo.On("FreezeBalance", mock.Anything, int64(1), money.MustParse("0.1")).Return(nil)
+	accountRepo.On("AddBalance", mock.Anything, int64(2), money.MustParse("3000")).Return(nil)
+	journalRepo.On("CreateJournalRecord", mock.Anything, mock.MatchedBy(func(j *repository.JournalModel) bool {
+		return j.BizType == LoanBizCollateralLock && j.Amount.String() == "-0.1" && j.BalanceSnapshot.String() == "0.4" && *j.RefID == 9
+	})).Return(nil).Once()
+	journalRepo.On("CreateJournalRecord", mock.Anything, mock.MatchedBy(func(j *repository.JournalModel) bool {
+		return j.BizType == LoanBizDisburse && j.Amount.String() == "3000" && j.BalanceSnapshot.String() == "3100" && *j.RefID == 9
+	})).Return(nil).Once()
+
+	loan, err := service.Borrow(context.Background(), 1, BorrowRequest{
+		CollateralAsset: "btc", CollateralAmount: "0.1", BorrowAsset: "USDT", BorrowAmount: "3000",
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(9), loan.ID)
+	assert.Equal(t, "0.5", loan.LTV)
+	assert.Equal(t, 1, uow.Commits)
+	loanRepo.AssertExpectations(t)
+	accountRepo.AssertExpectations(t)
+	journalRepo.AssertExpectations(t)
+}
+
+func TestLoanService_Borrow_Rejects(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     BorrowRequest
+		wantErr error
+	}{
+		{"ltv above initial limit", BorrowRequest{CollateralAsset: "BTC", CollateralAmount: "0.1", BorrowAsset: "USDT", BorrowAmount: "3000.01"}, ErrLoanLTVTooHigh},
+		{"unsupported collateral", BorrowRequest{CollateralAsset: "SOL", CollateralAmount: "10", BorrowAsset: "USDT", BorrowAmount: "100"}, ErrInvalidLoanRequest},
+		{"borrow non-stablecoin", BorrowRequest{CollateralAsset: "BTC", CollateralAmount: "1", BorrowAsset: "ETH", BorrowAmount: "1"}, ErrInvalidLoanRequest},
+		{"no price", BorrowRequest{CollateralAsset: "ETH", CollateralAmount: "1", BorrowAsset: "USDT", BorrowAmount: "100"}, ErrPriceUnavailable},
+		{"zero amount", BorrowRequest{CollateralAsset: "BTC", CollateralAmount: "0", BorrowAsset: "USDT", BorrowAmount: "100"}, ErrInvalidLoanRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, loanRepo, _, _, _ := newTestLoanService()
+
+			_, err := service.Borrow(context.Background(), 1, tt.req)
+
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.False(t, wasCalled(&loanRepo.Mock, "CreateLoan"))
+		})
+	}
+}
+
+func TestLoanService_Repay(t *testing.T) {
+	openLoan := func() *repository.LoanModel {
+		return &repository.LoanModel{
+			ID: 9, UserID: 1, CollateralAsset: "BTC", CollateralAmount: money.MustParse("0.1"),
+			BorrowAsset: "USDT", Principal: money.MustParse("3000"), Status: repository.LoanStatusMarginCall,
+			MarginCallLTV: money.MustParse("0.7"), LiquidationLTV: money.MustParse("0.8"),
+		}
+	}
+
+	t.Run("repays principal and releases collateral", func(t *testing.T) {
+		service, loanRepo, accountRepo, journalRepo, uow := newTestLoanService()
+		btc := &repository.AccountModel{ID: 1, UserID: 1, Currency: "BTC", Balance: money.MustParse("0.5"), FrozenBalance: money.MustParse("0.1")}
+		usdt := &repository.AccountModel{ID: 2, UserID: 1, Currency: "USDT", Balance: money.MustParse("3500"), FrozenBalance: money.Zero}
+		loanRepo.On("GetLoanByID", mock.Anything, int64(9)).Return(openLoan(), nil)
+		accountRepo.On("GetAccountByUserIDAndCurrency", mock.Anything, int64(1), "USDT").Return(usdt, nil)
+		accountRepo.On("GetAccountByUserIDAndCurrency", mock.Anything, int64(1), "BTC").Return(btc, nil)
+		loanRepo.On("CloseLoan", mock.Anything, mock.Anything, repository.LoanStatusRepaid).Return(nil)
+		accountRepo.On("DeductBalance", mock.Anything, int64(2), money.MustParse("3000")).Return(nil)
+		accountRepo.On("UnfreezeBalance", mock.Anything, int64(1), money.MustParse("0.1")).Return(nil)
+		journalRepo.On("CreateJournalRecord", mock.Anything, mock.MatchedBy(func(j *repository.JournalModel) bool {
+			return j.BizType == LoanBizRepay && j.Amount.String() == "-3000" && j.BalanceSnapshot.String() == "500"
+		})).Return(nil).Once()
+		journalRepo.On("CreateJournalRecord", mock.Anything, mock.MatchedBy(func(j *repository.JournalModel) bool {
+			return j.BizType == LoanBizCollateralRelease && j.Amount.String() == "0.1" && j.BalanceSnapshot.String() == "0.5"
+		})).Return(nil).Once()
+
+		loan, err := service.Repay(context.Background(), 1, 9)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "REPAID", loan.Status)
+		assert.Empty(t, loan.LTV)
+		assert.Equal(t, 1, uow.Commits)
+		accountRepo.AssertExpectations(t)
+		journalRepo.AssertExpectations(t)
+	})
+
+	t.Run("insufficient stablecoin balance", func(t *testing.T) {
+		service, loanRepo, accountRepo, _, _ := newTestLoanService()
+		loanRepo.On("GetLoanByID", mock.Anything, int64(9)).Return(openLoan(), nil)
+		accountRepo.On("GetAccountByUserIDAndCurrency", mock.Anything, int64(1), "USDT").Return(
+			&repository.AccountModel{ID: 2, Balance: money.MustParse("3000"), FrozenBalance: money.MustParse("1")}, nil)
+
+		_, err := service.Repay(context.Background(), 1, 9)
+
+		assert.ErrorIs(t, err, ErrInsufficientBalance)
+		assert.False(t, wasCalled(&loanRepo.Mock, "CloseLoan"))
+	})
+
+	t.Run("liquidated concurrently", func(t *testing.T) {
+		service, loanRepo, accountRepo, _, uow := newTestLoanService()
+		loanRepo.On("GetLoanByID", mock.Anything, int64(9)).Return(openLoan(), nil)
+		accountRepo.On("GetAccountByUserIDAndCurrency", mock.Anything, int64(1), mock.Anything).Return(
+			&repository.AccountModel{ID: 2, Balance: money.MustParse("5000"), FrozenBalance: money.Zero}, nil)
+		loanRepo.On("CloseLoan", mock.Anything, mock.Anything, repository.LoanStatusRepaid).Return(repository.ErrNotFound)
+
+		_, err := service.Repay(context.Background(), 1, 9)
+
+		assert.ErrorIs(t, err, ErrLoanClosed)
+		assert.Equal(t, 1, uow.Rollbacks)
+		assert.False(t, wasCalled(&accountRepo.Mock, "DeductBalance"))
+	})
+
+	t.Run("other user", func(t *testing.T) {
+		service, loanRepo, _, _, _ := newTestLoanService()
+		loanRepo.On("GetLoanByID", mock.Anything, int64(9)).Return(openLoan(), nil)
+
+		_, err := service.Repay(context.Background(), 2, 9)
+
+		assert.ErrorIs(t, err, ErrLoanNotFound)
+	})
+}
+
+func TestLoanService_AddCollateral(t *testing.T) {
+	service, loanRepo, accountRepo, journalRepo, _ := newTestLoanService()
+	loanRepo.On("GetLoanByID", mock.Anything, int64(9)).Return(&repository.LoanModel{
+		ID: 9, UserID: 1, CollateralAsset: "BTC", CollateralAmount: money.MustParse("0.1"),
+		BorrowAsset: "USDT", Principal: money.MustParse("4500"), Status: repository.LoanStatusMarginCall,
+	}, nil)
+	accountRepo.On("GetAccountByUserIDAndCurrency", mock.Anything, int64(1), "BTC").Return(
+		&repository.AccountModel{ID: 1, Balance: money.MustParse("0.5"), FrozenBalance: money.MustParse("0.1")}, nil)
+	loanRepo.On("AddCollateral", mock.Anything, int64(9), money.MustParse("0.05")).Return(nil)
+	accountRepo.On("FreezeBalance", mock.Anything, int64(1), money.MustParse("0.05")).Return(nil)
+	journalRepo.On("CreateJournalRecord", mock.Anything, mock.MatchedBy(func(j *repository.JournalModel) bool {
+		return j.BizType == LoanBizCollateralLock && j.Amount.String() == "-0.05" && j.BalanceSnapshot.String() == "0.35"
+	})).Return(nil)
+
+	loan, err := service.AddCollateral(context.Background(), 1, 9, "0.05")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "0.15", loan.CollateralAmount)
+	assert.Equal(t, "0.5", loan.LTV)
+	loanRepo.AssertExpectations(t)
+	journalRepo.AssertExpectations(t)
+}
@@ -0,0 +1,311 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"monera-digital/internal/clock"
+	"monera-digital/internal/money"
+	"monera-digital/internal/repository"
+)
+
+var (
+	ErrRecipientNotFound     = errors.New("recipient not found")
+	ErrTwoFactorRequired     = errors.New("two-factor authentication must be enabled to transfer")
+	ErrInvalidTwoFactorCode  = errors.New("invalid two-factor code")
+	ErrTransferLimitExceeded = errors.New("transfer exceeds limit")
+	ErrIdempotencyKeyReused  = errors.New("idempotency key already used for a different transfer")
+)
+
+// 站内转账的流水类型，转出与转入成对记录
+const (
+	P2PBizOut = "P2P_TRANSFER_OUT"
+	P2PBizIn  = "P2P_TRANSFER_IN"
+)
+
+// 转账方向，相对于查询的用户
+const (
+	P2PDirectionOut = "OUT"
+	P2PDirectionIn  = "IN"
+)
+
+var p2pAssets = map[string]bool{"USDT": true, "USDC": true}
+
+// TwoFactorVerifier 转账前的二次验证，由 TwoFactorService 实现
+type TwoFactorVerifier interface {
+	IsEnabled(userID int) (bool, error)
+	Verify(userID int, token string) (bool, error)
+}
+
+// P2PTransferLimits 每个发送方按币种适用的转账限额，日限额按平台营业日累计
+type P2PTransferLimits struct {
+	MinAmount      money.Decimal
+	MaxPerTransfer money.Decimal
+	DailyLimit     money.Decimal
+}
+
+// DefaultP2PTransferLimits 单笔 1 至 50,000，每日累计不超过 100,000
+var DefaultP2PTransferLimits = P2PTransferLimits{
+	MinAmount:      money.MustParse("1"),
+	MaxPerTransfer: money.MustParse("50000"),
+	DailyLimit:     money.MustParse("100000"),
+}
+
+// P2PTransferRequest 向其他平台用户转账的申请
+type P2PTransferRequest struct {
+	ToEmail       string `json:"toEmail" binding:"required"`
+	Currency      string `json:"currency" binding:"required"`
+	Amount        string `json:"amount" binding:"required"`
+	TwoFactorCode string `json:"twoFactorCode" binding:"required"`
+}
+
+// P2PTransfer 站内转账记录，Direction 与 CounterpartyEmail 相对于查询的用户
+type P2PTransfer struct {
+	TransferNo        string    `json:"transferNo"`
+	Direction         string    `json:"direction"`
+	CounterpartyEmail string    `json:"counterpartyEmail"`
+	Currency          string    `json:"currency"`
+	Amount            string    `json:"amount"`
+	CreatedAt         time.Time `json:"createdAt"`
+}
+
+type P2PTransferService struct {
+	repo      repository.P2PTransfer
+	users     repository.User
+	uow       repository.UnitOfWork
+	twoFactor TwoFactorVerifier
+	limits    P2PTransferLimits
+	clock     clock.Clock
+}
+
+func NewP2PTransferService(repo repository.P2PTransfer, users repository.User, uow repository.UnitOfWork) *P2PTransferService {
+	return &P2PTransferService{
+		repo:   repo,
+		users:  users,
+		uow:    uow,
+		limits: DefaultP2PTransferLimits,
+		clock:  clock.System,
+	}
+}
+
+// SetTwoFactorVerifier 注入二次验证服务；未注入时拒绝所有转账
+func (s *P2PTransferService) SetTwoFactorVerifier(twoFactor TwoFactorVerifier) {
+	s.twoFactor = twoFactor
+}
+
+// SetLimits 替换转账限额
+func (s *P2PTransferService) SetLimits(limits P2PTransferLimits) {
+	s.limits = limits
+}
+
+// SetClock 替换日限额与流水时间的来源，供测试与模拟器拨动时间
+func (s *P2PTransferService) SetClock(c clock.Clock) {
+	s.clock = c
+}
+
+// Send 从发送方资金账户向收款方资金账户转账，扣款、入账、转账记录与成对流水在同一事务内完成。
+// 同一发送方重复使用幂等键时返回首次的转账结果，参数不一致时返回 ErrIdempotencyKeyReused。
+func (s *P2PTransferService) Send(ctx context.Context, senderID int, idempotencyKey string, req P2PTransferRequest) (*P2PTransfer, error) {
+	key := strings.TrimSpace(idempotencyKey)
+	email := strings.TrimSpace(req.ToEmail)
+	currency := strings.ToUpper(strings.TrimSpace(req.Currency))
+	if key == "" || len(key) > 128 || email == "" || !p2pAssets[currency] {
+		return nil, ErrInvalidTransfer
+	}
+	amount, err := money.Parse(req.Amount)
+	if err != nil || !amount.IsPositive() || !money.Quantize(currency, amount).Equal(amount) {
+		return nil, ErrInvalidTransfer
+	}
+
+	existing, err := s.repo.GetTransferByKey(ctx, int64(senderID), key)
+	if err == nil {
+		return s.replay(ctx, existing, email, currency, amount)
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		return nil, fmt.Errorf("failed to check idempotency key: %v", err)
+	}
+
+	if amount.LessThan(s.limits.MinAmount) || amount.GreaterThan(s.limits.MaxPerTransfer) {
+		return nil, ErrTransferLimitExceeded
+	}
+
+	// 先完成二次验证再查找收款方，避免未验证的请求探测邮箱是否注册
+	if s.twoFactor == nil {
+		return nil, ErrTwoFactorRequired
+	}
+	enabled, err := s.twoFactor.IsEnabled(senderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check 2FA status: %v", err)
+	}
+	if !enabled {
+		return nil, ErrTwoFactorRequired
+	}
+	valid, err := s.twoFactor.Verify(senderID, strings.TrimSpace(req.TwoFactorCode))
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify 2FA code: %v", err)
+	}
+	if !valid {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	receiver, err := s.users.GetByEmail(ctx, email)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrRecipientNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recipient: %v", err)
+	}
+	if receiver.ID == senderID {
+		return nil, ErrInvalidTransfer
+	}
+
+	now := s.clock.Now()
+	cal := repository.PlatformCalendar()
+	dayStart := cal.Start(cal.BusinessDate(now))
+	transfer := &repository.P2PTransferModel{
+		TransferNo:     "P2P-" + uuid.NewString(),
+		SenderID:       int64(senderID),
+		ReceiverID:     int64(receiver.ID),
+		Currency:       currency,
+		Amount:         amount,
+		IdempotencyKey: key,
+	}
+
+	// 日限额在事务内汇总：同一发送方的并发转账会在扣款的版本检查处冲突，不会同时通过限额校验
+	err = s.uow.Do(ctx, func(tx *repository.TxRepository) error {
+		sent, err := tx.P2P.SumSentSince(ctx, transfer.SenderID, currency, dayStart)
+		if err != nil {
+			return fmt.Errorf("failed to sum daily transfers: %v", err)
+		}
+		if sent.Add(amount).GreaterThan(s.limits.DailyLimit) {
+			return ErrTransferLimitExceeded
+		}
+
+		from, err := tx.Account.GetAccountByType(ctx, transfer.SenderID, repository.AccountTypeFund, currency)
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrInsufficientBalance
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get sender account: %v", err)
+		}
+		if from.Available().LessThan(amount) {
+			return ErrInsufficientBalance
+		}
+		to, err := openAccount(ctx, tx.Account, transfer.ReceiverID, repository.AccountTypeFund, currency)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.P2P.CreateTransfer(ctx, transfer); err != nil {
+			return err
+		}
+		if err := tx.Account.UpdateBalance(ctx, from.ID, from.Version, amount.Neg()); err != nil {
+			return versionError(err)
+		}
+		if err := tx.Account.UpdateBalance(ctx, to.ID, to.Version, amount); err != nil {
+			return versionError(err)
+		}
+
+		if err := tx.Journal.CreateJournalRecord(ctx, &repository.JournalModel{
+			SerialNo:        transfer.TransferNo + "-OUT",
+			UserID:          transfer.SenderID,
+			AccountID:       from.ID,
+			Amount:          amount.Neg(),
+			BalanceSnapshot: from.Available().Sub(amount),
+			BizType:         P2PBizOut,
+			RefID:           &transfer.ID,
+			CreatedAt:       now.Format(time.RFC3339),
+		}); err != nil {
+			return ErrJournalCreateFailed
+		}
+		if err := tx.Journal.CreateJournalRecord(ctx, &repository.JournalModel{
+			SerialNo:        transfer.TransferNo + "-IN",
+			UserID:          transfer.ReceiverID,
+			AccountID:       to.ID,
+			Amount:          amount,
+			BalanceSnapshot: to.Available().Add(amount),
+			BizType:         P2PBizIn,
+			RefID:           &transfer.ID,
+			CreatedAt:       now.Format(time.RFC3339),
+		}); err != nil {
+			return ErrJournalCreateFailed
+		}
+		return nil
+	})
+	if errors.Is(err, repository.ErrAlreadyExists) {
+		// 同一幂等键的并发请求已先完成
+		existing, err := s.repo.GetTransferByKey(ctx, int64(senderID), key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get existing transfer: %v", err)
+		}
+		return s.replay(ctx, existing, email, currency, amount)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return toP2PTransfer(transfer, P2PDirectionOut, receiver.Email), nil
+}
+
+// replay 校验重放请求与首次请求一致后返回首次的转账结果
+func (s *P2PTransferService) replay(ctx context.Context, t *repository.P2PTransferModel, email, currency string, amount money.Decimal) (*P2PTransfer, error) {
+	receiver, err := s.users.GetByID(ctx, int(t.ReceiverID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recipient: %v", err)
+	}
+	if receiver.Email != email || t.Currency != currency || !t.Amount.Equal(amount) {
+		return nil, ErrIdempotencyKeyReused
+	}
+	return toP2PTransfer(t, P2PDirectionOut, receiver.Email), nil
+}
+
+// GetTransfers 返回用户发出与收到的转账，按时间倒序分页
+func (s *P2PTransferService) GetTransfers(ctx context.Context, userID int, limit, offset int) ([]*P2PTransfer, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	transfers, err := s.repo.GetTransfersByUserID(ctx, int64(userID), limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	emails := make(map[int64]string)
+	result := make([]*P2PTransfer, 0, len(transfers))
+	for _, t := range transfers {
+		direction, counterparty := P2PDirectionOut, t.ReceiverID
+		if t.ReceiverID == int64(userID) {
+			direction, counterparty = P2PDirectionIn, t.SenderID
+		}
+		email, ok := emails[counterparty]
+		if !ok {
+			user, err := s.users.GetByID(ctx, int(counterparty))
+			if err != nil {
+				return nil, fmt.Errorf("failed to get counterparty: %v", err)
+			}
+			email = user.Email
+			emails[counterparty] = email
+		}
+		result = append(result, toP2PTransfer(t, direction, email))
+	}
+	return result, nil
+}
+
+func toP2PTransfer(t *repository.P2PTransferModel, direction, counterpartyEmail string) *P2PTransfer {
+	return &P2PTransfer{
+		TransferNo:        t.TransferNo,
+		Direction:         direction,
+		CounterpartyEmail: counterpartyEmail,
+		Currency:          t.Currency,
+		Amount:            t.Amount.String(),
+		CreatedAt:         t.CreatedAt,
+	}
+}
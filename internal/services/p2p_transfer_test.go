@@ -0,0 +1,239 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"monera-digital/internal/clock"
+	"monera-digital/internal/models"
+	"monera-digital/internal/money"
+	"monera-digital/internal/repository"
+)
+
+type p2pTestDeps struct {
+	repo      *MockP2PTransferRepository
+	users     *MockUserRepository
+	twoFactor *MockTwoFactorVerifier
+	accounts  *MockAccountRepository
+	journals  *MockJournalRepository
+	uow       *MockUnitOfWork
+}
+
+var p2pTestNow = time.Date(2026, 3, 10, 2, 0, 0, 0, time.UTC)
+
+func newTestP2PService() (*P2PTransferService, *p2pTestDeps) {
+	d := &p2pTestDeps{
+		repo:      new(MockP2PTransferRepository),
+		users:     new(MockUserRepository),
+		twoFactor: new(MockTwoFactorVerifier),
+		accounts:  new(MockAccountRepository),
+		journals:  new(MockJournalRepository),
+	}
+	d.uow = NewMockUnitOfWork(nil, d.accounts, d.journals)
+	d.uow.Repos.P2P = d.repo
+	service := NewP2PTransferService(d.repo, d.users, d.uow)
+	service.SetTwoFactorVerifier(d.twoFactor)
+	service.SetClock(clock.NewFake(p2pTestNow))
+	return service, d
+}
+
+func p2pRequest(amount string) P2PTransferRequest {
+	return P2PTransferRequest{ToEmail: "bob@example.com", Currency: "usdt", Amount: amount, TwoFactorCode: "123456"}
+}
+
+func TestP2PTransferService_Send(t *testing.T) {
+	service, d := newTestP2PService()
+	cal := repository.PlatformCalendar()
+	dayStart := cal.Start(cal.BusinessDate(p2pTestNow))
+
+	d.repo.On("GetTransferByKey", mock.Anything, int64(1), "key-1").Return(nil, repository.ErrNotFound)
+	d.twoFactor.On("IsEnabled", 1).Return(true, nil)
+	d.twoFactor.On("Verify", 1, "123456").Return(true, nil)
+	d.users.On("GetByEmail", mock.Anything, "bob@example.com").Return(&models.User{ID: 2, Email: "bob@example.com"}, nil)
+	d.repo.On("SumSentSince", mock.Anything, int64(1), "USDT", dayStart).Return(money.MustParse("99000"), nil)
+	d.accounts.On("GetAccountByType", mock.Anything, int64(1), "FUND", "USDT").Return(
+		&repository.AccountModel{ID: 10, Balance: money.MustParse("5000"), FrozenBalance: money.MustParse("1000"), Version: 4}, nil)
+	d.accounts.On("GetAccountByType", mock.Anything, int64(2), "FUND", "USDT").Return(
+		&repository.AccountModel{ID: 20, Balance: money.MustParse("10"), FrozenBalance: money.Zero, Version: 9}, nil)
+	d.repo.On("CreateTransfer", mock.Anything, mock.MatchedBy(func(tr *repository.P2PTransferModel) bool {
+		return tr.SenderID == 1 && tr.ReceiverID == 2 && tr.IdempotencyKey == "key-1" && tr.Amount.String() == "1000"
+	})).Run(func(args mock.Arguments) {
+		tr := args.Get(1).(*repository.P2PTransferModel)
+		tr.ID, tr.CreatedAt = 7, p2pTestNow
+	}).Return(nil)
+	d.accounts.On("UpdateBalance", mock.Anything, int64(10), int64(4), money.MustParse("-1000")).Return(nil)
+	d.accounts.On("UpdateBalance", mock.Anything, int64(20), int64(9), money.MustParse("1000")).Return(nil)
+	d.journals.On("CreateJournalRecord", mock.Anything, mock.MatchedBy(func(j *repository.JournalModel) bool {
+		return j.BizType == P2PBizOut && j.UserID == 1 && j.Amount.String() == "-1000" && j.BalanceSnapshot.String() == "3000" && *j.RefID == 7
+	})).Return(nil).Once()
+	d.journals.On("CreateJournalRecord", mock.Anything, mock.MatchedBy(func(j *repository.JournalModel) bool {
+		return j.BizType == P2PBizIn && j.UserID == 2 && j.Amount.String() == "1000" && j.BalanceSnapshot.String() == "1010" && *j.RefID == 7
+	})).Return(nil).Once()
+
+	transfer, err := service.Send(context.Background(), 1, "key-1", p2pRequest("1000"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, P2PDirectionOut, transfer.Direction)
+	assert.Equal(t, "bob@example.com", transfer.CounterpartyEmail)
+	assert.Equal(t, "USDT", transfer.Currency)
+	assert.Equal(t, 1, d.uow.Commits)
+	d.accounts.AssertExpectations(t)
+	d.journals.AssertExpectations(t)
+}
+
+func TestP2PTransferService_Send_Rejects(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     P2PTransferRequest
+		setup   func(d *p2pTestDeps)
+		wantErr error
+	}{
+		{
+			name:    "non-stablecoin",
+			req:     P2PTransferRequest{ToEmail: "bob@example.com", Currency: "BTC", Amount: "1", TwoFactorCode: "123456"},
+			wantErr: ErrInvalidTransfer,
+		},
+		{
+			name:    "above per-transfer limit",
+			req:     p2pRequest("50000.01"),
+			wantErr: ErrTransferLimitExceeded,
+		},
+		{
+			name: "2FA not enabled",
+			req:  p2pRequest("100"),
+			setup: func(d *p2pTestDeps) {
+				d.twoFactor.On("IsEnabled", 1).Return(false, nil)
+			},
+			wantErr: ErrTwoFactorRequired,
+		},
+		{
+			name: "wrong 2FA code",
+			req:  p2pRequest("100"),
+			setup: func(d *p2pTestDeps) {
+				d.twoFactor.On("IsEnabled", 1).Return(true, nil)
+				d.twoFactor.On("Verify", 1, "123456").Return(false, nil)
+			},
+			wantErr: ErrInvalidTwoFactorCode,
+		},
+		{
+			name: "unknown recipient",
+			req:  p2pRequest("100"),
+			setup: func(d *p2pTestDeps) {
+				d.twoFactor.On("IsEnabled", 1).Return(true, nil)
+				d.twoFactor.On("Verify", 1, "123456").Return(true, nil)
+				d.users.On("GetByEmail", mock.Anything, "bob@example.com").Return(nil, repository.ErrNotFound)
+			},
+			wantErr: ErrRecipientNotFound,
+		},
+		{
+			name: "self transfer",
+			req:  p2pRequest("100"),
+			setup: func(d *p2pTestDeps) {
+				d.twoFactor.On("IsEnabled", 1).Return(true, nil)
+				d.twoFactor.On("Verify", 1, "123456").Return(true, nil)
+				d.users.On("GetByEmail", mock.Anything, "bob@example.com").Return(&models.User{ID: 1}, nil)
+			},
+			wantErr: ErrInvalidTransfer,
+		},
+		{
+			name: "daily limit",
+			req:  p2pRequest("1000.01"),
+			setup: func(d *p2pTestDeps) {
+				d.twoFactor.On("IsEnabled", 1).Return(true, nil)
+				d.twoFactor.On("Verify", 1, "123456").Return(true, nil)
+				d.users.On("GetByEmail", mock.Anything, "bob@example.com").Return(&models.User{ID: 2}, nil)
+				d.repo.On("SumSentSince", mock.Anything, int64(1), "USDT", mock.Anything).Return(money.MustParse("99000"), nil)
+			},
+			wantErr: ErrTransferLimitExceeded,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, d := newTestP2PService()
+			d.repo.On("GetTransferByKey", mock.Anything, int64(1), "key-1").Return(nil, repository.ErrNotFound)
+			if tt.setup != nil {
+				tt.setup(d)
+			}
+
+			_, err := service.Send(context.Background(), 1, "key-1", tt.req)
+
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.False(t, wasCalled(&d.repo.Mock, "CreateTransfer"))
+			assert.False(t, wasCalled(&d.accounts.Mock, "UpdateBalance"))
+		})
+	}
+}
+
+func TestP2PTransferService_Send_IdempotentReplay(t *testing.T) {
+	existing := &repository.P2PTransferModel{
+		ID: 7, TransferNo: "P2P-1", SenderID: 1, ReceiverID: 2, Currency: "USDT",
+		Amount: money.MustParse("1000"), IdempotencyKey: "key-1", CreatedAt: p2pTestNow,
+	}
+
+	t.Run("same request returns original transfer", func(t *testing.T) {
+		service, d := newTestP2PService()
+		d.repo.On("GetTransferByKey", mock.Anything, int64(1), "key-1").Return(existing, nil)
+		d.users.On("GetByID", mock.Anything, 2).Return(&models.User{ID: 2, Email: "bob@example.com"}, nil)
+
+		transfer, err := service.Send(context.Background(), 1, "key-1", p2pRequest("1000"))
+
+		assert.NoError(t, err)
+		assert.Equal(t, "P2P-1", transfer.TransferNo)
+		assert.False(t, wasCalled(&d.twoFactor.Mock, "Verify"))
+		assert.Equal(t, 0, d.uow.Commits)
+	})
+
+	t.Run("different amount with same key", func(t *testing.T) {
+		service, d := newTestP2PService()
+		d.repo.On("GetTransferByKey", mock.Anything, int64(1), "key-1").Return(existing, nil)
+		d.users.On("GetByID", mock.Anything, 2).Return(&models.User{ID: 2, Email: "bob@example.com"}, nil)
+
+		_, err := service.Send(context.Background(), 1, "key-1", p2pRequest("999"))
+
+		assert.ErrorIs(t, err, ErrIdempotencyKeyReused)
+	})
+
+	t.Run("concurrent request with same key won", func(t *testing.T) {
+		service, d := newTestP2PService()
+		d.repo.On("GetTransferByKey", mock.Anything, int64(1), "key-1").Return(nil, repository.ErrNotFound).Once()
+		d.repo.On("GetTransferByKey", mock.Anything, int64(1), "key-1").Return(existing, nil).Once()
+		d.twoFactor.On("IsEnabled", 1).Return(true, nil)
+		d.twoFactor.On("Verify", 1, "123456").Return(true, nil)
+		d.users.On("GetByEmail", mock.Anything, "bob@example.com").Return(&models.User{ID: 2, Email: "bob@example.com"}, nil)
+		d.users.On("GetByID", mock.Anything, 2).Return(&models.User{ID: 2, Email: "bob@example.com"}, nil)
+		d.repo.On("SumSentSince", mock.Anything, int64(1), "USDT", mock.Anything).Return(money.Zero, nil)
+		d.accounts.On("GetAccountByType", mock.Anything, mock.Anything, "FUND", "USDT").Return(
+			&repository.AccountModel{ID: 10, Balance: money.MustParse("5000"), FrozenBalance: money.Zero}, nil)
+		d.repo.On("CreateTransfer", mock.Anything, mock.Anything).Return(repository.ErrAlreadyExists)
+
+		transfer, err := service.Send(context.Background(), 1, "key-1", p2pRequest("1000"))
+
+		assert.NoError(t, err)
+		assert.Equal(t, "P2P-1", transfer.TransferNo)
+		assert.Equal(t, 1, d.uow.Rollbacks)
+		assert.False(t, wasCalled(&d.accounts.Mock, "UpdateBalance"))
+	})
+}
+
+func TestP2PTransferService_GetTransfers(t *testing.T) {
+	service, d := newTestP2PService()
+	d.repo.On("GetTransfersByUserID", mock.Anything, int64(2), 20, 0).Return([]*repository.P2PTransferModel{
+		{TransferNo: "P2P-2", SenderID: 2, ReceiverID: 3, Currency: "USDC", Amount: money.MustParse("5")},
+		{TransferNo: "P2P-1", SenderID: 1, ReceiverID: 2, Currency: "USDT", Amount: money.MustParse("1000")},
+	}, nil)
+	d.users.On("GetByID", mock.Anything, 3).Return(&models.User{ID: 3, Email: "carol@example.com"}, nil)
+	d.users.On("GetByID", mock.Anything, 1).Return(&models.User{ID: 1, Email: "alice@example.com"}, nil)
+
+	transfers, err := service.GetTransfers(context.Background(), 2, 0, 0)
+
+	assert.NoError(t, err)
+	assert.Len(t, transfers, 2)
+	assert.Equal(t, P2PDirectionOut, transfers[0].Direction)
+	assert.Equal(t, "carol@example.com", transfers[0].CounterpartyEmail)
+	assert.Equal(t, P2PDirectionIn, transfers[1].Direction)
+	assert.Equal(t, "alice@example.com", transfers[1].CounterpartyEmail)
+}
@@ -0,0 +1,202 @@
+package services
+
+import (
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Safeheron 报文的加密方式：key 以 RSA/ECB/OAEP(SHA-256) 加密，bizContent 以 AES-256-GCM 加密
+const (
+	safeheronRSAType = "ECB_OAEP"
+	safeheronAESType = "GCM_NOPADDING"
+
+	safeheronAESKeySize = 32
+	safeheronAESIVSize  = 16
+)
+
+// safeheronEnvelope Safeheron 请求、响应与回调共用的报文。
+// 发送方为每个报文生成随机 AES 密钥与 IV，以之加密业务 JSON 得到 bizContent，
+// 以接收方 RSA 公钥加密“密钥+IV”得到 key，再以己方 RSA 私钥对参与签名的参数做 SHA256withRSA 签名得到 sig。
+// 请求签名 apiKey、bizContent、key、timestamp；响应另含 code、message；回调不含 apiKey。
+type safeheronEnvelope struct {
+	APIKey     string `json:"apiKey,omitempty"`
+	Code       *int   `json:"code,omitempty"`
+	Message    string `json:"message,omitempty"`
+	Timestamp  string `json:"timestamp"`
+	Key        string `json:"key,omitempty"`
+	BizContent string `json:"bizContent,omitempty"`
+	Sig        string `json:"sig"`
+	RSAType    string `json:"rsaType,omitempty"`
+	AESType    string `json:"aesType,omitempty"`
+}
+
+// signedContent 报文中参与签名的参数，按参数名排序后以 & 拼接
+func (e *safeheronEnvelope) signedContent() string {
+	params := map[string]string{
+		"apiKey":     e.APIKey,
+		"bizContent": e.BizContent,
+		"key":        e.Key,
+		"timestamp":  e.Timestamp,
+	}
+	if e.Code != nil {
+		params["code"] = strconv.Itoa(*e.Code)
+		params["message"] = e.Message
+	}
+	return signContent(params)
+}
+
+// seal 加密 biz 写入 bizContent 与 key，并以 signer 签名；biz 为 nil 时不携带业务内容
+func (e *safeheronEnvelope) seal(biz interface{}, receiver *rsa.PublicKey, signer *rsa.PrivateKey) error {
+	secret := make([]byte, safeheronAESKeySize+safeheronAESIVSize)
+	if _, err := rand.Read(secret); err != nil {
+		return fmt.Errorf("failed to generate aes key: %w", err)
+	}
+	if biz != nil {
+		plain, err := json.Marshal(biz)
+		if err != nil {
+			return fmt.Errorf("failed to marshal biz content: %w", err)
+		}
+		gcm, err := safeheronGCM(secret)
+		if err != nil {
+			return err
+		}
+		e.BizContent = base64.StdEncoding.EncodeToString(gcm.Seal(nil, secret[safeheronAESKeySize:], plain, nil))
+	}
+	key, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, receiver, secret, nil)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt aes key: %w", err)
+	}
+	e.Key = base64.StdEncoding.EncodeToString(key)
+
+	digest := sha256.Sum256([]byte(e.signedContent()))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, signer, crypto.SHA256, digest[:])
+	if err != nil {
+		return fmt.Errorf("failed to sign: %w", err)
+	}
+	e.Sig = base64.StdEncoding.EncodeToString(sig)
+	e.RSAType = safeheronRSAType
+	e.AESType = safeheronAESType
+	return nil
+}
+
+// open 以 sender 公钥验证签名，再以 receiver 私钥解出 AES 密钥并解密 bizContent；
+// 签名无效返回 ErrCustodyBadSignature，没有业务内容时返回 nil
+func (e *safeheronEnvelope) open(sender *rsa.PublicKey, receiver *rsa.PrivateKey) ([]byte, error) {
+	sig, err := base64.StdEncoding.DecodeString(e.Sig)
+	if err != nil {
+		return nil, ErrCustodyBadSignature
+	}
+	digest := sha256.Sum256([]byte(e.signedContent()))
+	if err := rsa.VerifyPKCS1v15(sender, crypto.SHA256, digest[:], sig); err != nil {
+		return nil, ErrCustodyBadSignature
+	}
+	if e.BizContent == "" {
+		return nil, nil
+	}
+	if e.RSAType != safeheronRSAType || e.AESType != safeheronAESType {
+		return nil, fmt.Errorf("unsupported safeheron encryption %s/%s", e.RSAType, e.AESType)
+	}
+
+	key, err := base64.StdEncoding.DecodeString(e.Key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid safeheron key: %w", err)
+	}
+	secret, err := rsa.DecryptOAEP(sha256.New(), nil, receiver, key, nil)
+	if err != nil || len(secret) != safeheronAESKeySize+safeheronAESIVSize {
+		return nil, errors.New("failed to decrypt safeheron aes key")
+	}
+	content, err := base64.StdEncoding.DecodeString(e.BizContent)
+	if err != nil {
+		return nil, fmt.Errorf("invalid safeheron biz content: %w", err)
+	}
+	gcm, err := safeheronGCM(secret)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := gcm.Open(nil, secret[safeheronAESKeySize:], content, nil)
+	if err != nil {
+		return nil, errors.New("failed to decrypt safeheron biz content")
+	}
+	return plain, nil
+}
+
+// safeheronGCM 以“密钥+IV”的前 32 字节为 AES-256 密钥，IV 长度 16 字节
+func safeheronGCM(secret []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(secret[:safeheronAESKeySize])
+	if err != nil {
+		return nil, fmt.Errorf("failed to create aes cipher: %w", err)
+	}
+	return cipher.NewGCMWithNonceSize(block, safeheronAESIVSize)
+}
+
+// signContent 按参数名排序后以 & 拼接 key=value，空值参数不参与签名
+func signContent(params map[string]string) string {
+	keys := make([]string, 0, len(params))
+	for k, v := range params {
+		if v != "" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = k + "=" + params[k]
+	}
+	return strings.Join(pairs, "&")
+}
+
+func decodeKey(key string) ([]byte, error) {
+	key = strings.TrimSpace(key)
+	if block, _ := pem.Decode([]byte(key)); block != nil {
+		return block.Bytes, nil
+	}
+	return base64.StdEncoding.DecodeString(key)
+}
+
+func parseRSAPrivateKey(key string) (*rsa.PrivateKey, error) {
+	der, err := decodeKey(key)
+	if err != nil {
+		return nil, err
+	}
+	if k, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return k, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, err
+	}
+	k, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("not an RSA private key")
+	}
+	return k, nil
+}
+
+func parseRSAPublicKey(key string) (*rsa.PublicKey, error) {
+	der, err := decodeKey(key)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, err
+	}
+	k, ok := parsed.(*rsa.PublicKey)
+	if !ok {
+		return nil, errors.New("not an RSA public key")
+	}
+	return k, nil
+}
@@ -1,37 +1,348 @@
 package services
 
 import (
+	"bytes"
 	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"monera-digital/internal/models"
 )
 
-type SafeheronService struct {
-	// config...
+var (
+	ErrCustodyNotConfigured     = errors.New("custody client is not configured")
+	ErrCustodyUnsupportedAsset  = errors.New("asset is not supported by custody")
+	ErrCustodyInvalidAddress    = errors.New("custody rejected destination address")
+	ErrCustodyInsufficientFunds = errors.New("custody wallet has insufficient funds")
+	ErrCustodyRejected          = errors.New("custody rejected request")
+	ErrCustodyNotFound          = errors.New("custody transaction not found")
+	ErrCustodyBadSignature      = errors.New("custody response signature invalid")
+	// ErrCustodyOutcomeUnknown 请求可能已被托管方受理（超时、5xx、响应无法验证），
+	// 且按 RequestID 也未能确认结果；调用方不得当作失败处理
+	ErrCustodyOutcomeUnknown = errors.New("custody request outcome unknown")
+)
+
+// Safeheron 业务错误码
+const (
+	safeheronCodeSuccess             = 200
+	safeheronCodeDuplicateRequest    = 9001
+	safeheronCodeInsufficientBalance = 9002
+	safeheronCodeInvalidAddress      = 9003
+	safeheronCodeTransactionNotFound = 9004
+)
+
+const (
+	safeheronCreatePath = "/v2/transactions/create"
+	safeheronQueryPath  = "/v1/transactions/one"
+)
+
+// Safeheron 交易状态中的终态；其余状态（SUBMITTED、SIGNING、BROADCASTING、CONFIRMING 等）均为在途
+const (
+	safeheronStatusCompleted = "COMPLETED"
+	safeheronStatusFailed    = "FAILED"
+	safeheronStatusRejected  = "REJECTED"
+	safeheronStatusCancelled = "CANCELLED"
+)
+
+// custodyWithdrawalStatus 将 Safeheron 交易状态映射为提现订单状态：
+// 失败、拒绝、取消为 FAILED，已完成为 CONFIRMED，其余在途状态为 PROCESSING
+func custodyWithdrawalStatus(transactionStatus string) models.WithdrawalStatus {
+	switch strings.ToUpper(transactionStatus) {
+	case safeheronStatusFailed, safeheronStatusRejected, safeheronStatusCancelled:
+		return models.WithdrawalStatusFailed
+	case safeheronStatusCompleted:
+		return models.WithdrawalStatusConfirmed
+	default:
+		return models.WithdrawalStatusProcessing
+	}
 }
 
-func NewSafeheronService() *SafeheronService {
-	return &SafeheronService{}
+type ISafeheronService interface {
+	Withdraw(ctx context.Context, req SafeheronWithdrawalRequest) (*SafeheronWithdrawalResponse, error)
+	// GetWithdrawal 按提交时的 RequestID 查询交易及其映射后的订单状态；不存在时返回 ErrCustodyNotFound
+	GetWithdrawal(ctx context.Context, requestID string) (*SafeheronWithdrawalResponse, error)
+	// OpenWebhook 验证回调报文的签名并解密；签名无效时返回 ErrCustodyBadSignature
+	OpenWebhook(body []byte) (*SafeheronWebhookEvent, error)
+}
+
+type SafeheronWithdrawalRequest struct {
+	CoinType  string
+	ChainType string
+	ToAddress string
+	Amount    string
+	RequestID string
 }
 
 type SafeheronWithdrawalResponse struct {
 	TxHash           string
 	SafeheronOrderID string
 	NetworkFee       string
+	// Status 按交易状态映射的订单状态，TransactionStatus 为托管方原始状态
+	Status            models.WithdrawalStatus
+	TransactionStatus string
 }
 
-func (s *SafeheronService) Withdraw(ctx context.Context, req SafeheronWithdrawalRequest) (*SafeheronWithdrawalResponse, error) {
-	// Stub implementation
-	// In real life, call Safeheron API
-	return &SafeheronWithdrawalResponse{
-		TxHash:           "0xmocktxhash",
-		SafeheronOrderID: "mock-sh-id",
-		NetworkFee:       "1.0",
+// SafeheronConfig Safeheron Open API 的接入配置。密钥支持 PEM 或不带头尾的 Base64 DER
+type SafeheronConfig struct {
+	BaseURL           string
+	APIKey            string
+	PrivateKey        string            // 我方 RSA 私钥，用于请求签名与解密响应
+	PlatformPublicKey string            // Safeheron 平台 RSA 公钥，用于加密请求与验证响应签名
+	WebhookPublicKey  string            // Safeheron 回调 RSA 公钥，用于验证回调签名；为空时使用 PlatformPublicKey
+	WebhookPrivateKey string            // 我方回调 RSA 私钥，用于解密回调；为空时使用 PrivateKey
+	AccountKey        string            // 出金的钱包账户
+	CoinKeys          map[string]string // "USDT/TRC20" -> Safeheron coinKey
+	Timeout           time.Duration
+}
+
+// SafeheronService Safeheron 托管出金客户端。
+// 请求以平台公钥加密、我方私钥签名，响应以平台公钥验签、我方私钥解密，报文格式见 safeheronEnvelope。
+type SafeheronService struct {
+	cfg            SafeheronConfig
+	privateKey     *rsa.PrivateKey
+	platform       *rsa.PublicKey
+	webhookPrivate *rsa.PrivateKey
+	webhookPublic  *rsa.PublicKey
+	httpClient     *http.Client
+}
+
+func NewSafeheronService(cfg SafeheronConfig) (*SafeheronService, error) {
+	if cfg.BaseURL == "" || cfg.APIKey == "" || cfg.AccountKey == "" {
+		return nil, ErrCustodyNotConfigured
+	}
+	privateKey, err := parseRSAPrivateKey(cfg.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid safeheron private key: %w", err)
+	}
+	platform, err := parseRSAPublicKey(cfg.PlatformPublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid safeheron platform public key: %w", err)
+	}
+	webhookPrivate, webhookPublic := privateKey, platform
+	if cfg.WebhookPrivateKey != "" {
+		if webhookPrivate, err = parseRSAPrivateKey(cfg.WebhookPrivateKey); err != nil {
+			return nil, fmt.Errorf("invalid safeheron webhook private key: %w", err)
+		}
+	}
+	if cfg.WebhookPublicKey != "" {
+		if webhookPublic, err = parseRSAPublicKey(cfg.WebhookPublicKey); err != nil {
+			return nil, fmt.Errorf("invalid safeheron webhook public key: %w", err)
+		}
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 20 * time.Second
+	}
+	cfg.BaseURL = strings.TrimRight(cfg.BaseURL, "/")
+	return &SafeheronService{
+		cfg:            cfg,
+		privateKey:     privateKey,
+		platform:       platform,
+		webhookPrivate: webhookPrivate,
+		webhookPublic:  webhookPublic,
+		httpClient:     &http.Client{Timeout: cfg.Timeout},
 	}, nil
 }
 
-type SafeheronWithdrawalRequest struct {
-	CoinType  string
-	ChainType string
-	ToAddress string
-	Amount    string
-	RequestID string
+type safeheronCreateRequest struct {
+	CustomerRefID          string `json:"customerRefId"`
+	SourceAccountKey       string `json:"sourceAccountKey"`
+	SourceAccountType      string `json:"sourceAccountType"`
+	DestinationAccountType string `json:"destinationAccountType"`
+	DestinationAddress     string `json:"destinationAddress"`
+	CoinKey                string `json:"coinKey"`
+	TxAmount               string `json:"txAmount"`
+	TxFeeLevel             string `json:"txFeeLevel"`
+}
+
+type safeheronTransaction struct {
+	TxKey                string `json:"txKey"`
+	TxHash               string `json:"txHash"`
+	TxFee                string `json:"txFee"`
+	CustomerRefID        string `json:"customerRefId"`
+	TransactionStatus    string `json:"transactionStatus"`
+	TransactionSubStatus string `json:"transactionSubStatus"`
+}
+
+// withdrawalResponse 按交易详情构造出金结果
+func (tx safeheronTransaction) withdrawalResponse() *SafeheronWithdrawalResponse {
+	return &SafeheronWithdrawalResponse{
+		TxHash:            tx.TxHash,
+		SafeheronOrderID:  tx.TxKey,
+		NetworkFee:        tx.TxFee,
+		Status:            custodyWithdrawalStatus(tx.TransactionStatus),
+		TransactionStatus: tx.TransactionStatus,
+	}
+}
+
+// Withdraw 以 RequestID 作为 customerRefId 创建出金交易。
+// 请求结果不确定（超时、5xx、响应签名无效）或托管方报告 customerRefId 重复时，
+// 不重发请求，而是按 RequestID 查询交易是否已创建。
+func (s *SafeheronService) Withdraw(ctx context.Context, req SafeheronWithdrawalRequest) (*SafeheronWithdrawalResponse, error) {
+	coinKey, ok := s.cfg.CoinKeys[strings.ToUpper(req.CoinType+"/"+req.ChainType)]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s/%s", ErrCustodyUnsupportedAsset, req.CoinType, req.ChainType)
+	}
+
+	var created safeheronTransaction
+	err := s.call(ctx, safeheronCreatePath, safeheronCreateRequest{
+		CustomerRefID:          req.RequestID,
+		SourceAccountKey:       s.cfg.AccountKey,
+		SourceAccountType:      "VAULT_ACCOUNT",
+		DestinationAccountType: "ONE_TIME_ADDRESS",
+		DestinationAddress:     req.ToAddress,
+		CoinKey:                coinKey,
+		TxAmount:               req.Amount,
+		TxFeeLevel:             "MIDDLE",
+	}, &created)
+	if err == nil {
+		// 新建的交易尚未上链
+		return &SafeheronWithdrawalResponse{SafeheronOrderID: created.TxKey, Status: models.WithdrawalStatusProcessing}, nil
+	}
+	if !errors.Is(err, ErrCustodyOutcomeUnknown) && !errors.Is(err, errSafeheronDuplicate) {
+		return nil, err
+	}
+
+	// 原请求的 ctx 可能已超时，查询使用独立的超时
+	lookupCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), s.cfg.Timeout)
+	defer cancel()
+	resp, lookupErr := s.GetWithdrawal(lookupCtx, req.RequestID)
+	if lookupErr == nil {
+		return resp, nil
+	}
+	return nil, fmt.Errorf("%w: %v (lookup: %v)", ErrCustodyOutcomeUnknown, err, lookupErr)
+}
+
+// GetWithdrawal 按 customerRefId 查询交易
+func (s *SafeheronService) GetWithdrawal(ctx context.Context, requestID string) (*SafeheronWithdrawalResponse, error) {
+	var tx safeheronTransaction
+	if err := s.call(ctx, safeheronQueryPath, map[string]string{"customerRefId": requestID}, &tx); err != nil {
+		return nil, err
+	}
+	return tx.withdrawalResponse(), nil
+}
+
+// safeheronEventTransactionStatusChanged 交易状态变更的回调事件类型
+const safeheronEventTransactionStatusChanged = "TRANSACTION_STATUS_CHANGED"
+
+// SafeheronWebhookEvent 验签并解密后的回调事件；仅交易状态变更事件带有 RequestID 与 Withdrawal
+type SafeheronWebhookEvent struct {
+	EventType  string
+	Timestamp  time.Time
+	RequestID  string
+	Withdrawal *SafeheronWithdrawalResponse
+}
+
+// OpenWebhook 以回调公钥验证签名、以我方回调私钥解密 bizContent，报文格式与 API 响应相同但不含 code、message
+func (s *SafeheronService) OpenWebhook(body []byte) (*SafeheronWebhookEvent, error) {
+	var env safeheronEnvelope
+	if err := json.Unmarshal(body, &env); err != nil {
+		return nil, fmt.Errorf("malformed webhook: %w", err)
+	}
+	ms, err := strconv.ParseInt(env.Timestamp, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("malformed webhook timestamp %q", env.Timestamp)
+	}
+	content, err := env.open(s.webhookPublic, s.webhookPrivate)
+	if err != nil {
+		return nil, err
+	}
+
+	var payload struct {
+		EventType   string          `json:"eventType"`
+		EventDetail json.RawMessage `json:"eventDetail"`
+	}
+	if err := json.Unmarshal(content, &payload); err != nil {
+		return nil, fmt.Errorf("malformed webhook content: %w", err)
+	}
+	event := &SafeheronWebhookEvent{EventType: payload.EventType, Timestamp: time.UnixMilli(ms)}
+	if payload.EventType == safeheronEventTransactionStatusChanged {
+		var tx safeheronTransaction
+		if err := json.Unmarshal(payload.EventDetail, &tx); err != nil {
+			return nil, fmt.Errorf("malformed webhook transaction: %w", err)
+		}
+		event.RequestID = tx.CustomerRefID
+		event.Withdrawal = tx.withdrawalResponse()
+	}
+	return event, nil
+}
+
+// errSafeheronDuplicate customerRefId 已被使用，说明之前的请求已创建交易
+var errSafeheronDuplicate = errors.New("safeheron duplicate customerRefId")
+
+// call 加密并签名请求，验证响应签名并解密后将业务内容解析到 out
+func (s *SafeheronService) call(ctx context.Context, path string, biz interface{}, out interface{}) error {
+	req := safeheronEnvelope{
+		APIKey:    s.cfg.APIKey,
+		Timestamp: strconv.FormatInt(time.Now().UnixMilli(), 10),
+	}
+	if err := req.seal(biz, s.platform, s.privateKey); err != nil {
+		return fmt.Errorf("failed to seal request: %w", err)
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, s.cfg.BaseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	httpResp, err := s.httpClient.Do(httpReq)
+	if err != nil {
+		// 请求可能已到达托管方
+		return fmt.Errorf("%w: %v", ErrCustodyOutcomeUnknown, err)
+	}
+	defer httpResp.Body.Close()
+	respBody, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return fmt.Errorf("%w: failed to read response: %v", ErrCustodyOutcomeUnknown, err)
+	}
+	if httpResp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("%w: HTTP %d", ErrCustodyOutcomeUnknown, httpResp.StatusCode)
+	}
+
+	var resp safeheronEnvelope
+	if err := json.Unmarshal(respBody, &resp); err != nil || resp.Code == nil {
+		return fmt.Errorf("%w: malformed response (HTTP %d)", ErrCustodyOutcomeUnknown, httpResp.StatusCode)
+	}
+	content, err := resp.open(s.platform, s.privateKey)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrCustodyOutcomeUnknown, err)
+	}
+	if *resp.Code != safeheronCodeSuccess {
+		return safeheronError(*resp.Code, resp.Message)
+	}
+	if out != nil && content != nil {
+		if err := json.Unmarshal(content, out); err != nil {
+			return fmt.Errorf("failed to unmarshal response: %w", err)
+		}
+	}
+	return nil
+}
+
+// safeheronError 将 Safeheron 业务错误码映射为本地错误，保留原始错误码与信息
+func safeheronError(code int, message string) error {
+	var base error
+	switch code {
+	case safeheronCodeDuplicateRequest:
+		base = errSafeheronDuplicate
+	case safeheronCodeInsufficientBalance:
+		base = ErrCustodyInsufficientFunds
+	case safeheronCodeInvalidAddress:
+		base = ErrCustodyInvalidAddress
+	case safeheronCodeTransactionNotFound:
+		base = ErrCustodyNotFound
+	default:
+		base = ErrCustodyRejected
+	}
+	return fmt.Errorf("%w: %d %s", base, code, message)
 }
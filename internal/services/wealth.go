@@ -2,79 +2,68 @@ package services
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"strconv"
-	"sync"
 	"time"
 
+	"monera-digital/internal/accrual"
 	"monera-digital/internal/binance"
-	"monera-digital/internal/config"
+	"monera-digital/internal/clock"
+	"monera-digital/internal/money"
 	"monera-digital/internal/repository"
 )
 
 var (
-	ErrInsufficientBalance   = errors.New("insufficient balance")
-	ErrProductNotFound       = errors.New("product not found")
-	ErrOrderNotFound         = errors.New("order not found")
-	ErrProductNotAvailable   = errors.New("product not available")
-	ErrAmountBelowMin        = errors.New("amount below minimum")
-	ErrAmountAboveMax        = errors.New("amount above maximum")
-	ErrQuotaExceeded         = errors.New("quota exceeded")
-	ErrOrderAlreadyRedeemed  = errors.New("order already redeemed")
-	ErrInvalidRedemptionType = errors.New("invalid redemption type")
-	ErrPriceFetchFailed      = errors.New("failed to fetch price")
-	ErrJournalCreateFailed   = errors.New("failed to create journal record")
-	ErrDuplicateRequest      = errors.New("duplicate request, please try again later")
+	ErrInsufficientBalance     = repository.ErrInsufficientBalance
+	ErrProductNotFound         = errors.New("product not found")
+	ErrOrderNotFound           = errors.New("order not found")
+	ErrProductNotAvailable     = errors.New("product not available")
+	ErrAmountBelowMin          = errors.New("amount below minimum")
+	ErrAmountAboveMax          = errors.New("amount above maximum")
+	ErrQuotaExceeded           = errors.New("quota exceeded")
+	ErrOrderAlreadyRedeemed    = errors.New("order already redeemed")
+	ErrInvalidRedemptionType   = errors.New("invalid redemption type")
+	ErrInvalidRedemptionAmount = errors.New("invalid redemption amount")
+	ErrPriceFetchFailed        = errors.New("failed to fetch price")
+	ErrJournalCreateFailed     = errors.New("failed to create journal record")
+	ErrInvalidRateSchedule     = errors.New("invalid rate schedule")
+	ErrRetroactiveRate         = errors.New("rate effective date must not be in the past")
+	ErrOrderNotRenewable       = errors.New("order does not support auto-renew")
+	ErrRenewalCutoffPassed     = errors.New("auto-renew can no longer be changed before maturity")
+	ErrOrderAlreadyStarted     = errors.New("order has already started accruing interest")
+	ErrQuoteNotFound           = errors.New("quote not found")
+	ErrQuoteExpired            = errors.New("quote expired or already used")
 )
 
 type WealthService struct {
 	repo        repository.Wealth
 	accountRepo repository.AccountV2
 	journalRepo repository.Journal
-	lockMap     map[string]bool
-	mu          map[string]*sync.Mutex
+	uow         repository.UnitOfWork
+	clock       clock.Clock
 }
 
-func NewWealthService(wealthRepo repository.Wealth, accountRepo repository.AccountV2, journalRepo repository.Journal) *WealthService {
+func NewWealthService(wealthRepo repository.Wealth, accountRepo repository.AccountV2, journalRepo repository.Journal, uow repository.UnitOfWork) *WealthService {
 	return &WealthService{
 		repo:        wealthRepo,
 		accountRepo: accountRepo,
 		journalRepo: journalRepo,
-		lockMap:     make(map[string]bool),
-		mu:          make(map[string]*sync.Mutex),
+		uow:         uow,
+		clock:       clock.System,
 	}
 }
 
-// getLock returns a mutex for the given key
-func (s *WealthService) getLock(key string) *sync.Mutex {
-	if s.mu[key] == nil {
-		s.mu[key] = &sync.Mutex{}
-	}
-	return s.mu[key]
-}
-
-// generateIdempotencyKey generates a unique key for idempotency check
-func (s *WealthService) generateIdempotencyKey(userID int, productID int64, amount string) string {
-	return fmt.Sprintf("subscribe:%d:%d:%s", userID, productID, amount)
-}
-
-// isDuplicateCheck checks if this is a duplicate request
-func (s *WealthService) isDuplicateCheck(key string) bool {
-	if s.lockMap[key] {
-		return true
-	}
-	s.lockMap[key] = true
-	return false
-}
-
-// clearLock clears the lock for a key
-func (s *WealthService) clearLock(key string) {
-	delete(s.lockMap, key)
+// SetClock 替换时间来源，供测试与模拟器拨动时间
+func (s *WealthService) SetClock(c clock.Clock) {
+	s.clock = c
 }
 
 type Asset struct {
 	Currency      string  `json:"currency"`
+	Type          string  `json:"type"`
 	Total         string  `json:"total"`
 	Available     string  `json:"available"`
 	FrozenBalance string  `json:"frozenBalance"`
@@ -97,82 +86,86 @@ func (s *WealthService) GetAssets(ctx context.Context, userID int) ([]*Asset, er
 
 	var result []*Asset
 	for _, a := range accounts {
-		available := subtractStrings(a.Balance, a.FrozenBalance)
-		availableFloat, _ := strconv.ParseFloat(available, 64)
-		usdValue := availableFloat
+		available := a.Available()
+		usdValue := available.Float64()
 
 		if a.Currency == "USDT" || a.Currency == "USDC" || a.Currency == "DAI" {
-			usdValue = availableFloat
+			usdValue = available.Float64()
 		} else if price, ok := prices[a.Currency]; ok {
-			usdValue = availableFloat * price
+			usdValue = available.Float64() * price
 		}
 
 		result = append(result, &Asset{
 			Currency:      a.Currency,
-			Total:         formatTo7Decimal(a.Balance),
-			Available:     available,
-			FrozenBalance: formatTo7Decimal(a.FrozenBalance),
+			Type:          a.Type,
+			Total:         a.Balance.String(),
+			Available:     available.String(),
+			FrozenBalance: a.FrozenBalance.String(),
 			UsdValue:      usdValue,
 		})
 	}
 	return result, nil
 }
 
-func subtractStrings(a, b string) string {
-	aFloat, errA := strconv.ParseFloat(a, 64)
-	bFloat, errB := strconv.ParseFloat(b, 64)
-
-	if errA != nil {
-		return a
-	}
-	if errB != nil {
-		return "0"
-	}
-
-	diff := aFloat - bFloat
-	if diff < 0 {
-		diff = 0
-	}
-
-	return strconv.FormatFloat(diff, 'f', 7, 64)
-}
-
-// formatTo7Decimal formats a string number to 7 decimal places
-func formatTo7Decimal(s string) string {
-	f, err := strconv.ParseFloat(s, 64)
-	if err != nil {
-		return s
-	}
-	return strconv.FormatFloat(f, 'f', 7, 64)
-}
-
 type Product struct {
 	ID               int64   `json:"id"`
 	Title            string  `json:"title"`
 	Currency         string  `json:"currency"`
 	APY              float64 `json:"apy"`
 	Duration         int     `json:"duration"`
+	ProductType      int     `json:"productType"`
 	MinAmount        string  `json:"minAmount"`
 	MaxAmount        string  `json:"maxAmount"`
 	RemainingQuota   string  `json:"remainingQuota"`
 	AutoRenewAllowed bool    `json:"autoRenewAllowed"`
+	// APYTiers 当前生效的分档利率；APY 为首档利率
+	APYTiers         []RateTier `json:"apyTiers,omitempty"`
+	EarlyRedeemRule  int        `json:"earlyRedeemRule"`
+	EarlyRedeemValue string     `json:"earlyRedeemValue"`
+	// SubscribeStartAt/SubscribeEndAt 申购窗口，未开放的产品可提前展示
+	SubscribeStartAt *time.Time `json:"subscribeStartAt,omitempty"`
+	SubscribeEndAt   *time.Time `json:"subscribeEndAt,omitempty"`
+}
+
+// RateTier 利率档位：本金超过 Floor 的部分按 APY 计息
+type RateTier struct {
+	Floor string `json:"floor"`
+	APY   string `json:"apy"`
+}
+
+// RateSchedule 某一生效日期的全部利率档位
+type RateSchedule struct {
+	EffectiveDate string     `json:"effectiveDate"`
+	Tiers         []RateTier `json:"tiers"`
 }
 
 type Order struct {
-	ID               int64  `json:"id"`
-	ProductTitle     string `json:"productTitle"`
-	Currency         string `json:"currency"`
-	Amount           string `json:"amount"`
-	InterestExpected string `json:"interestExpected"`
-	InterestPaid     string `json:"interestPaid"`
-	InterestAccrued  string `json:"interestAccrued"`
-	StartDate        string `json:"startDate"`
-	EndDate          string `json:"endDate"`
-	Duration         int64  `json:"duration"`
-	AutoRenew        bool   `json:"autoRenew"`
-	Status           int    `json:"status"`
-	RedemptionAmount string `json:"redemptionAmount,omitempty"`
-	CreatedAt        string `json:"createdAt"`
+	ID                int64  `json:"id"`
+	ProductTitle      string `json:"productTitle"`
+	Currency          string `json:"currency"`
+	ProductType       int    `json:"productType"`
+	Amount            string `json:"amount"`
+	PrincipalRedeemed string `json:"principalRedeemed"`
+	InterestExpected  string `json:"interestExpected"`
+	InterestPaid      string `json:"interestPaid"`
+	InterestAccrued   string `json:"interestAccrued"`
+	StartDate         string `json:"startDate"`
+	EndDate           string `json:"endDate"`
+	Duration          int64  `json:"duration"`
+	AutoRenew         bool   `json:"autoRenew"`
+	AutoRenewCompound bool   `json:"autoRenewCompound"`
+	Status            int    `json:"status"`
+	RedemptionAmount  string `json:"redemptionAmount,omitempty"`
+	LastInterestDate  string `json:"lastInterestDate,omitempty"`
+	CreatedAt         string `json:"createdAt"`
+}
+
+// InterestRecord 订单某一计息日的利息记录
+type InterestRecord struct {
+	Date      string `json:"date"`
+	Amount    string `json:"amount"`
+	Type      int    `json:"type"`
+	CreatedAt string `json:"createdAt"`
 }
 
 func (s *WealthService) GetProducts(ctx context.Context, page, pageSize int) ([]*Product, int64, error) {
@@ -199,156 +192,347 @@ func (s *WealthService) GetProducts(ctx context.Context, page, pageSize int) ([]
 		end = len(products)
 	}
 
+	now := s.clock.Now()
+
 	var result []*Product
 	for _, p := range products[start:end] {
-		apy, _ := strconv.ParseFloat(p.APY, 64)
+		apy := p.APY
+		rates, err := s.repo.GetProductRates(ctx, p.ID, p.Calendar().Today(now))
+		if err != nil {
+			return nil, 0, err
+		}
+		tiers := make([]RateTier, 0, len(rates))
+		for _, rate := range rates {
+			tiers = append(tiers, RateTier{Floor: rate.TierFloor.String(), APY: rate.APY.String()})
+		}
+		if len(rates) > 0 {
+			apy = rates[0].APY
+		}
+
 		result = append(result, &Product{
 			ID:               p.ID,
 			Title:            p.Title,
 			Currency:         p.Currency,
-			APY:              apy,
+			APY:              apy.Float64(),
+			APYTiers:         tiers,
 			Duration:         p.Duration,
-			MinAmount:        p.MinAmount,
-			MaxAmount:        p.MaxAmount,
-			RemainingQuota:   p.TotalQuota,
+			ProductType:      p.ProductType,
+			MinAmount:        p.MinAmount.String(),
+			MaxAmount:        p.MaxAmount.String(),
+			RemainingQuota:   p.TotalQuota.String(),
 			AutoRenewAllowed: p.AutoRenewAllowed,
+			EarlyRedeemRule:  p.EarlyRedeemRule,
+			EarlyRedeemValue: p.EarlyRedeemValue.String(),
+			SubscribeStartAt: p.SubscribeStartAt,
+			SubscribeEndAt:   p.SubscribeEndAt,
 		})
 	}
 	return result, total, nil
 }
 
-func (s *WealthService) Subscribe(ctx context.Context, userID int, productID int64, amount string, autoRenew bool, interestExpected string) (string, error) {
-	idempotencyKey := s.generateIdempotencyKey(userID, productID, amount)
-	mu := s.getLock(idempotencyKey)
+// GetProductRates 返回产品的利率历史，按生效日期倒序
+func (s *WealthService) GetProductRates(ctx context.Context, productID int64) ([]*RateSchedule, error) {
+	if _, err := s.repo.GetProductByID(ctx, productID); err != nil {
+		return nil, ErrProductNotFound
+	}
 
-	mu.Lock()
-	defer func() {
-		mu.Unlock()
-		s.clearLock(idempotencyKey)
-	}()
+	rates, err := s.repo.ListProductRates(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
 
-	if s.isDuplicateCheck(idempotencyKey) {
-		return "", ErrDuplicateRequest
+	result := []*RateSchedule{}
+	for _, rate := range rates {
+		if len(result) == 0 || result[len(result)-1].EffectiveDate != rate.EffectiveDate {
+			result = append(result, &RateSchedule{EffectiveDate: rate.EffectiveDate})
+		}
+		last := result[len(result)-1]
+		last.Tiers = append(last.Tiers, RateTier{Floor: rate.TierFloor.String(), APY: rate.APY.String()})
 	}
+	return result, nil
+}
 
+// SetProductRates 设置自 effectiveDate 起生效的分档利率，覆盖同一日期已排期的档位。
+// 生效日期不能早于产品当前营业日，已入账的利息不会被重新定价。
+func (s *WealthService) SetProductRates(ctx context.Context, productID int64, effectiveDate string, tiers []RateTier) error {
 	product, err := s.repo.GetProductByID(ctx, productID)
 	if err != nil {
-		return "", ErrProductNotFound
+		return ErrProductNotFound
 	}
 
-	if product.Status != 1 {
-		return "", ErrProductNotFound
+	date, err := time.Parse(accrual.DateLayout, effectiveDate)
+	if err != nil {
+		return ErrInvalidRateSchedule
+	}
+	if date.Before(product.Calendar().BusinessDate(s.clock.Now())) {
+		return ErrRetroactiveRate
 	}
 
-	available, _ := strconv.ParseFloat(amount, 64)
-	if available <= 0 {
-		return "", ErrAmountBelowMin
+	if len(tiers) == 0 {
+		return ErrInvalidRateSchedule
 	}
+	rates := make([]*repository.WealthRateModel, 0, len(tiers))
+	for i, tier := range tiers {
+		floor, err := money.Parse(tier.Floor)
+		if err != nil || floor.IsNegative() {
+			return ErrInvalidRateSchedule
+		}
+		apy, err := money.Parse(tier.APY)
+		if err != nil || apy.IsNegative() {
+			return ErrInvalidRateSchedule
+		}
+		// 首档从 0 开始，档位下限严格递增
+		if i == 0 && !floor.IsZero() {
+			return ErrInvalidRateSchedule
+		}
+		if i > 0 && !floor.GreaterThan(rates[i-1].TierFloor) {
+			return ErrInvalidRateSchedule
+		}
+		rates = append(rates, &repository.WealthRateModel{
+			ProductID:     productID,
+			EffectiveDate: effectiveDate,
+			TierFloor:     floor,
+			APY:           apy,
+		})
+	}
+
+	return s.uow.Do(ctx, func(tx *repository.TxRepository) error {
+		return tx.Wealth.SaveProductRates(ctx, productID, effectiveDate, rates)
+	})
+}
 
-	minAmount, _ := strconv.ParseFloat(product.MinAmount, 64)
-	if available < minAmount {
-		return "", ErrAmountBelowMin
+// checkSubscription 校验产品状态、申购窗口、金额上下限与剩余额度。
+// 活期产品返回用户已有的持仓订单（没有时为 nil），上限按持仓合计校验。
+func (s *WealthService) checkSubscription(ctx context.Context, userID int, productID int64, principal money.Decimal) (*repository.WealthProductModel, *repository.WealthOrderModel, error) {
+	product, err := s.repo.GetProductByID(ctx, productID)
+	if err != nil {
+		return nil, nil, ErrProductNotFound
 	}
 
-	maxAmount, _ := strconv.ParseFloat(product.MaxAmount, 64)
-	if available > maxAmount {
-		return "", ErrAmountAboveMax
+	if product.Status != repository.WealthProductStatusActive {
+		return nil, nil, ErrProductNotFound
+	}
+	if !product.InSubscribeWindow(s.clock.Now()) {
+		return nil, nil, ErrProductNotAvailable
 	}
 
-	soldQuota, _ := strconv.ParseFloat(product.SoldQuota, 64)
-	totalQuota, _ := strconv.ParseFloat(product.TotalQuota, 64)
-	if soldQuota+available > totalQuota {
-		return "", ErrQuotaExceeded
+	if !principal.IsPositive() || principal.LessThan(product.MinAmount) {
+		return nil, nil, ErrAmountBelowMin
 	}
 
-	account, err := s.accountRepo.GetAccountByUserIDAndCurrency(ctx, int64(userID), product.Currency)
-	if err != nil {
-		return "", ErrInsufficientBalance
+	// 活期产品每个用户只保留一笔持仓订单，再次申购即追加本金
+	var holding *repository.WealthOrderModel
+	held := money.Zero
+	if product.ProductType == repository.WealthProductTypeFlexible {
+		holding, err = s.findFlexibleHolding(ctx, userID, productID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if holding != nil {
+			held = holding.RemainingPrincipal()
+		}
 	}
 
-	balance, _ := strconv.ParseFloat(account.Balance, 64)
-	frozen, _ := strconv.ParseFloat(account.FrozenBalance, 64)
-	availableBalance := balance - frozen
-	if available > availableBalance {
-		return "", ErrInsufficientBalance
+	if held.Add(principal).GreaterThan(product.MaxAmount) {
+		return nil, nil, ErrAmountAboveMax
+	}
+
+	if product.SoldQuota.Add(principal).GreaterThan(product.TotalQuota) {
+		return nil, nil, ErrQuotaExceeded
+	}
+
+	return product, holding, nil
+}
+
+// Subscribe 按服务端报价申购产品，订单金额、起止日期与预期收益均取自报价。
+// autoRenewCompound 仅在 autoRenew 开启时生效：续期时利息滚入新订单本金
+func (s *WealthService) Subscribe(ctx context.Context, userID int, quoteID string, autoRenew, autoRenewCompound bool) (string, error) {
+	quote, err := s.repo.GetQuote(ctx, quoteID)
+	if err != nil || quote.UserID != int64(userID) {
+		return "", ErrQuoteNotFound
+	}
+	if quote.UsedAt != nil || !s.clock.Now().Before(quote.ExpiresAt) {
+		return "", ErrQuoteExpired
 	}
 
-	err = s.accountRepo.FreezeBalance(ctx, account.ID, amount)
+	principal := quote.Amount
+	product, holding, err := s.checkSubscription(ctx, userID, quote.ProductID, principal)
 	if err != nil {
 		return "", err
 	}
 
-	now := time.Now()
-	loc := config.GetLocation()
-	nowInLoc := now.In(loc)
+	account, err := s.accountRepo.GetAccountByUserIDAndCurrency(ctx, int64(userID), product.Currency)
+	if err != nil {
+		return "", ErrInsufficientBalance
+	}
+
+	if principal.GreaterThan(account.Available()) {
+		return "", ErrInsufficientBalance
+	}
 
-	// 计算新加坡时区(UTC+8)的日期
-	today := nowInLoc.Format("2006-01-02")
-	todayDate, _ := time.Parse("2006-01-02", today)
-	startDate := todayDate.AddDate(0, 0, 1).Format("2006-01-02")
-	endDate := todayDate.AddDate(0, 0, 1+product.Duration).Format("2006-01-02")
+	now := s.clock.Now()
 
-	// 使用前端计算的利息，如果前端没有提供则使用后端计算作为后备
-	var finalInterestExpected string
-	if interestExpected != "" {
-		finalInterestExpected = interestExpected
-		fmt.Printf("[INFO] Using frontend calculated interest: %s\n", interestExpected)
-	} else {
-		// 后备计算：后端自己计算利息
-		apy, _ := strconv.ParseFloat(product.APY, 64)
-		amountFloat, _ := strconv.ParseFloat(amount, 64)
-		dailyInterest := amountFloat * (apy / 100) / 365
-		finalInterestExpected = strconv.FormatFloat(dailyInterest*float64(product.Duration), 'f', -1, 64)
-		fmt.Printf("[INFO] Using backend calculated interest: %s\n", finalInterestExpected)
+	if product.ProductType == repository.WealthProductTypeFlexible {
+		return s.subscribeFlexible(ctx, userID, product, account, holding, quote, now)
 	}
 
 	order := &repository.WealthOrderModel{
 		UserID:            int64(userID),
-		ProductID:         productID,
+		ProductID:         product.ID,
 		ProductTitle:      product.Title,
 		Currency:          product.Currency,
-		Amount:            amount,
+		Amount:            principal,
 		AutoRenew:         autoRenew,
+		AutoRenewCompound: autoRenew && autoRenewCompound,
+		EarlyRedeemRule:   product.EarlyRedeemRule,
+		EarlyRedeemValue:  product.EarlyRedeemValue,
 		Status:            1,
-		StartDate:         startDate,
-		EndDate:           endDate,
-		PrincipalRedeemed: "0",
-		InterestExpected:  finalInterestExpected,
-		InterestPaid:      "0",
-		InterestAccrued:   "0",
+		StartDate:         quote.StartDate,
+		EndDate:           quote.EndDate,
+		PrincipalRedeemed: money.Zero,
+		InterestExpected:  quote.InterestExpected,
+		InterestPaid:      money.Zero,
+		InterestAccrued:   money.Zero,
 		LastInterestDate:  "",
 		CreatedAt:         now.Format(time.RFC3339),
 		UpdatedAt:         now.Format(time.RFC3339),
 	}
 
-	err = s.repo.CreateOrder(ctx, order)
+	// 核销报价、占用额度、冻结、建单、记账在同一事务内完成，任一步失败整体回滚。
+	// 额度在数据库中原子占用，上面的校验只用于提前拒绝明显超额的请求。
+	err = s.uow.Do(ctx, func(tx *repository.TxRepository) error {
+		if err := tx.Wealth.UseQuote(ctx, quote.ID, int64(userID)); err != nil {
+			return err
+		}
+
+		if err := tx.Wealth.ReserveProductQuota(ctx, product.ID, principal); err != nil {
+			return err
+		}
+
+		if err := tx.Account.FreezeBalance(ctx, account.ID, principal); err != nil {
+			return err
+		}
+
+		if err := tx.Wealth.CreateOrder(ctx, order); err != nil {
+			return err
+		}
+
+		serialNo := fmt.Sprintf("SUBSCRIBE-%s-%d", now.Format("20060102150405"), order.ID)
+		// 快照记录冻结后的可用余额
+		journalRecord := &repository.JournalModel{
+			SerialNo:        serialNo,
+			UserID:          int64(userID),
+			AccountID:       account.ID,
+			Amount:          principal.Neg(),
+			BalanceSnapshot: account.Available().Sub(principal),
+			BizType:         "SUBSCRIBE_FREEZE",
+			RefID:           &order.ID,
+			CreatedAt:       now.Format(time.RFC3339),
+		}
+		if err := tx.Journal.CreateJournalRecord(ctx, journalRecord); err != nil {
+			fmt.Printf("[ERROR] Failed to create journal record: %v\n", err)
+			return ErrJournalCreateFailed
+		}
+		return nil
+	})
 	if err != nil {
-		s.accountRepo.UnfreezeBalance(ctx, account.ID, amount)
-		return "", err
+		return "", subscribeTxError(err)
+	}
+
+	return strconv.FormatInt(order.ID, 10), nil
+}
+
+// subscribeTxError 将申购事务中的仓储错误映射为业务错误
+func subscribeTxError(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrQuotaExceeded):
+		return ErrQuotaExceeded
+	case errors.Is(err, repository.ErrNotFound):
+		// 报价在校验后被并发核销或恰好过期
+		return ErrQuoteExpired
+	default:
+		return err
 	}
+}
 
-	err = s.repo.UpdateProductSoldQuota(ctx, productID, amount)
+// findFlexibleHolding 返回用户在活期产品下的持仓订单，没有时返回 nil
+func (s *WealthService) findFlexibleHolding(ctx context.Context, userID int, productID int64) (*repository.WealthOrderModel, error) {
+	orders, err := s.repo.GetOrdersByUserID(ctx, int64(userID))
 	if err != nil {
-		fmt.Printf("[WARNING] Failed to update product sold quota: %v\n", err)
+		return nil, err
 	}
+	for _, o := range orders {
+		if o.ProductID == productID && o.Status == 1 {
+			return o, nil
+		}
+	}
+	return nil, nil
+}
 
-	serialNo := fmt.Sprintf("SUBSCRIBE-%s-%d", now.Format("20060102150405"), order.ID)
-	balanceAfterFreeze := balance - available
-	journalRecord := &repository.JournalModel{
-		SerialNo:        serialNo,
-		UserID:          int64(userID),
-		AccountID:       account.ID,
-		Amount:          "-" + amount,
-		BalanceSnapshot: strconv.FormatFloat(balanceAfterFreeze, 'f', -1, 64),
-		BizType:         "SUBSCRIBE_FREEZE",
-		RefID:           &order.ID,
-		CreatedAt:       now.Format(time.RFC3339),
+// subscribeFlexible 活期申购：当日起息，无到期日；已有持仓时追加本金
+func (s *WealthService) subscribeFlexible(ctx context.Context, userID int, product *repository.WealthProductModel, account *repository.AccountModel, holding *repository.WealthOrderModel, quote *repository.WealthQuoteModel, now time.Time) (string, error) {
+	principal := quote.Amount
+	order := holding
+	if order == nil {
+		order = &repository.WealthOrderModel{
+			UserID:            int64(userID),
+			ProductID:         product.ID,
+			ProductTitle:      product.Title,
+			Currency:          product.Currency,
+			ProductType:       product.ProductType,
+			Amount:            principal,
+			Status:            1,
+			StartDate:         quote.StartDate,
+			PrincipalRedeemed: money.Zero,
+			InterestExpected:  money.Zero,
+			InterestPaid:      money.Zero,
+			InterestAccrued:   money.Zero,
+			CreatedAt:         now.Format(time.RFC3339),
+			UpdatedAt:         now.Format(time.RFC3339),
+		}
 	}
 
-	err = s.journalRepo.CreateJournalRecord(ctx, journalRecord)
+	err := s.uow.Do(ctx, func(tx *repository.TxRepository) error {
+		if err := tx.Wealth.UseQuote(ctx, quote.ID, int64(userID)); err != nil {
+			return err
+		}
+
+		if err := tx.Wealth.ReserveProductQuota(ctx, product.ID, principal); err != nil {
+			return err
+		}
+
+		if err := tx.Account.FreezeBalance(ctx, account.ID, principal); err != nil {
+			return err
+		}
+
+		serialPrefix := "SUBSCRIBE"
+		if holding != nil {
+			serialPrefix = "TOPUP"
+			if err := tx.Wealth.AddPrincipal(ctx, order.ID, principal); err != nil {
+				return err
+			}
+		} else if err := tx.Wealth.CreateOrder(ctx, order); err != nil {
+			return err
+		}
+
+		journalRecord := &repository.JournalModel{
+			SerialNo:        fmt.Sprintf("%s-%s-%d", serialPrefix, now.Format("20060102150405"), order.ID),
+			UserID:          int64(userID),
+			AccountID:       account.ID,
+			Amount:          principal.Neg(),
+			BalanceSnapshot: account.Available().Sub(principal),
+			BizType:         "SUBSCRIBE_FREEZE",
+			RefID:           &order.ID,
+			CreatedAt:       now.Format(time.RFC3339),
+		}
+		if err := tx.Journal.CreateJournalRecord(ctx, journalRecord); err != nil {
+			fmt.Printf("[ERROR] Failed to create journal record: %v\n", err)
+			return ErrJournalCreateFailed
+		}
+		return nil
+	})
 	if err != nil {
-		fmt.Printf("[ERROR] Failed to create journal record: %v\n", err)
-		return "", ErrJournalCreateFailed
+		return "", subscribeTxError(err)
 	}
 
 	return strconv.FormatInt(order.ID, 10), nil
@@ -380,52 +564,144 @@ func (s *WealthService) GetOrders(ctx context.Context, userID int, page, pageSiz
 
 	var result []*Order
 	for _, o := range orders[start:end] {
-		result = append(result, &Order{
-			ID:               o.ID,
-			ProductTitle:     o.ProductTitle,
-			Currency:         o.Currency,
-			Amount:           o.Amount,
-			InterestExpected: o.InterestExpected,
-			InterestPaid:     o.InterestPaid,
-			InterestAccrued:  o.InterestAccrued,
-			StartDate:        o.StartDate,
-			EndDate:          o.EndDate,
-			Duration:         o.Duration,
-			AutoRenew:        o.AutoRenew,
-			Status:           o.Status,
-			CreatedAt:        o.CreatedAt,
-		})
+		result = append(result, toOrder(o))
 	}
 	return result, total, nil
 }
 
-func (s *WealthService) Redeem(ctx context.Context, userID int, orderID int64, redemptionType string) error {
-	fmt.Printf("[DEBUG] Redeem - userID: %d, orderID: %d, redemptionType: %s\n", userID, orderID, redemptionType)
-	order, err := s.repo.GetOrderByID(ctx, orderID)
+func toOrder(o *repository.WealthOrderModel) *Order {
+	return &Order{
+		ID:                o.ID,
+		ProductTitle:      o.ProductTitle,
+		Currency:          o.Currency,
+		ProductType:       o.ProductType,
+		Amount:            o.Amount.String(),
+		PrincipalRedeemed: o.PrincipalRedeemed.String(),
+		InterestExpected:  o.InterestExpected.String(),
+		InterestPaid:      o.InterestPaid.String(),
+		InterestAccrued:   o.InterestAccrued.String(),
+		StartDate:         o.StartDate,
+		EndDate:           o.EndDate,
+		Duration:          o.Duration,
+		AutoRenew:         o.AutoRenew,
+		AutoRenewCompound: o.AutoRenewCompound,
+		Status:            o.Status,
+		LastInterestDate:  o.LastInterestDate,
+		CreatedAt:         o.CreatedAt,
+	}
+}
+
+// AutoRenewChangeCutoff 到期日零点前这段时间内不再接受续期设置的修改，
+// 避免与到期结算、续期任务交错
+const AutoRenewChangeCutoff = 24 * time.Hour
+
+// loadRenewableOrder 校验订单为持有中的定期订单，且尚未过续期设置的修改截止时间
+func (s *WealthService) loadRenewableOrder(ctx context.Context, userID int, orderID int64) (*repository.WealthOrderModel, error) {
+	order, err := s.loadRedeemableOrder(ctx, userID, orderID)
 	if err != nil {
-		fmt.Printf("[DEBUG] Redeem - GetOrderByID error: %v\n", err)
-		return ErrOrderNotFound
+		return nil, err
+	}
+	if order.IsFlexible() {
+		return nil, ErrOrderNotRenewable
 	}
-	fmt.Printf("[DEBUG] Redeem - order found: ID=%d, UserID=%d, Status=%d, Amount=%s\n", order.ID, order.UserID, order.Status, order.Amount)
 
-	if order.UserID != int64(userID) {
-		return ErrOrderNotFound
+	// 到期日营业日开始的时刻即订单到期的时刻
+	endDate, err := time.Parse(accrual.DateLayout, order.EndDate)
+	if err != nil {
+		return nil, ErrOrderNotRenewable
+	}
+	maturity := order.Calendar().Start(endDate)
+	if !s.clock.Now().Before(maturity.Add(-AutoRenewChangeCutoff)) {
+		return nil, ErrRenewalCutoffPassed
+	}
+	return order, nil
+}
+
+// SetAutoRenew 开启或关闭进行中定期订单的自动续期，须在到期前 AutoRenewChangeCutoff 之前修改。
+// 关闭续期会同时关闭复利；产品不再允许续期时仍可关闭。
+func (s *WealthService) SetAutoRenew(ctx context.Context, userID int, orderID int64, autoRenew bool) error {
+	order, err := s.loadRenewableOrder(ctx, userID, orderID)
+	if err != nil {
+		return err
+	}
+
+	if autoRenew {
+		product, err := s.repo.GetProductByID(ctx, order.ProductID)
+		if err != nil {
+			return ErrProductNotFound
+		}
+		if !product.AutoRenewAllowed {
+			return ErrOrderNotRenewable
+		}
+	}
+
+	compound := autoRenew && order.AutoRenewCompound
+	return s.updateRenewalOptions(ctx, order, repository.WealthAuditAutoRenew, autoRenew, compound)
+}
+
+// SetAutoRenewCompound 修改进行中定期订单的续期方式：compound 为 true 时利息滚入续期本金，
+// 否则续期只续本金、利息到账。开启复利会同时开启自动续期。
+func (s *WealthService) SetAutoRenewCompound(ctx context.Context, userID int, orderID int64, compound bool) error {
+	order, err := s.loadRenewableOrder(ctx, userID, orderID)
+	if err != nil {
+		return err
+	}
+
+	product, err := s.repo.GetProductByID(ctx, order.ProductID)
+	if err != nil {
+		return ErrProductNotFound
 	}
+	if !product.AutoRenewAllowed {
+		return ErrOrderNotRenewable
+	}
+
+	autoRenew := order.AutoRenew || compound
+	return s.updateRenewalOptions(ctx, order, repository.WealthAuditAutoRenewCompound, autoRenew, compound)
+}
+
+// renewalOptions 续期设置在审计记录中的 JSON 形式
+type renewalOptions struct {
+	AutoRenew bool `json:"autoRenew"`
+	Compound  bool `json:"compound"`
+}
+
+// updateRenewalOptions 修改续期设置，并在同一事务内记录变更前后的设置
+func (s *WealthService) updateRenewalOptions(ctx context.Context, order *repository.WealthOrderModel, action string, autoRenew, compound bool) error {
+	oldValue, _ := json.Marshal(renewalOptions{AutoRenew: order.AutoRenew, Compound: order.AutoRenewCompound})
+	newValue, _ := json.Marshal(renewalOptions{AutoRenew: autoRenew, Compound: compound})
+	now := s.clock.Now()
 
-	if order.Status == 3 {
+	err := s.uow.Do(ctx, func(tx *repository.TxRepository) error {
+		if err := tx.Wealth.UpdateRenewalOptions(ctx, order.ID, autoRenew, compound); err != nil {
+			return err
+		}
+		return tx.Wealth.CreateOrderAudit(ctx, &repository.WealthOrderAuditModel{
+			OrderID:   order.ID,
+			UserID:    order.UserID,
+			Action:    action,
+			OldValue:  string(oldValue),
+			NewValue:  string(newValue),
+			CreatedAt: now.Format(time.RFC3339),
+		})
+	})
+	if errors.Is(err, repository.ErrNotFound) {
 		return ErrOrderAlreadyRedeemed
 	}
+	return err
+}
 
-	now := time.Now()
-	endDate, _ := time.Parse("2006-01-02", order.EndDate)
-	isExpired := now.After(endDate) || now.Equal(endDate)
+// CancelOrder 撤销尚未起息的定期订单：全额解冻本金、归还产品额度，不收取任何费用
+func (s *WealthService) CancelOrder(ctx context.Context, userID int, orderID int64) error {
+	order, err := s.loadRedeemableOrder(ctx, userID, orderID)
+	if err != nil {
+		return err
+	}
 
-	if isExpired {
-		fmt.Printf("[DEBUG] Order %d is expired - full redemption with interest\n", order.ID)
-		order.Status = 3
-	} else {
-		fmt.Printf("[DEBUG] Order %d is not expired yet - early redemption, principal only\n", order.ID)
-		order.Status = 4
+	now := s.clock.Now()
+	today := order.Calendar().BusinessDate(now)
+	startDate, err := time.Parse(accrual.DateLayout, order.StartDate)
+	if order.IsFlexible() || err != nil || !startDate.After(today) {
+		return ErrOrderAlreadyStarted
 	}
 
 	account, err := s.accountRepo.GetAccountByUserIDAndCurrency(ctx, int64(userID), order.Currency)
@@ -433,67 +709,345 @@ func (s *WealthService) Redeem(ctx context.Context, userID int, orderID int64, r
 		return err
 	}
 
-	err = s.accountRepo.UnfreezeBalance(ctx, account.ID, order.Amount)
+	principal := order.RemainingPrincipal()
+	err = s.uow.Do(ctx, func(tx *repository.TxRepository) error {
+		// 先以条件更新锁定订单状态，并发的撤单、赎回只有一笔能成功
+		if err := tx.Wealth.CancelOrder(ctx, order.ID); err != nil {
+			return err
+		}
+
+		if err := tx.Account.UnfreezeBalance(ctx, account.ID, principal); err != nil {
+			return err
+		}
+
+		journalRecord := &repository.JournalModel{
+			SerialNo:        fmt.Sprintf("CANCEL-%s-%d", now.Format("20060102150405"), order.ID),
+			UserID:          int64(userID),
+			AccountID:       account.ID,
+			Amount:          principal,
+			BalanceSnapshot: account.Available().Add(principal),
+			BizType:         "CANCEL_UNFREEZE",
+			RefID:           &order.ID,
+			CreatedAt:       now.Format(time.RFC3339),
+		}
+		if err := tx.Journal.CreateJournalRecord(ctx, journalRecord); err != nil {
+			fmt.Printf("[ERROR] Failed to create cancel journal record: %v\n", err)
+			return ErrJournalCreateFailed
+		}
+
+		return tx.Wealth.ReleaseProductQuota(ctx, order.ProductID, principal)
+	})
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrOrderAlreadyRedeemed
+	}
+	return err
+}
+
+// GetInterestHistory 返回订单按日记录的利息明细，仅订单所有者可查看
+func (s *WealthService) GetInterestHistory(ctx context.Context, userID int, orderID int64) ([]*InterestRecord, error) {
+	order, err := s.repo.GetOrderByID(ctx, orderID)
 	if err != nil {
-		return err
+		return nil, ErrOrderNotFound
+	}
+	if order.UserID != int64(userID) {
+		return nil, ErrOrderNotFound
+	}
+
+	return s.interestRecords(ctx, orderID)
+}
+
+func (s *WealthService) interestRecords(ctx context.Context, orderID int64) ([]*InterestRecord, error) {
+	records, err := s.repo.GetInterestRecords(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*InterestRecord, 0, len(records))
+	for _, r := range records {
+		result = append(result, &InterestRecord{
+			Date:      r.Date,
+			Amount:    r.Amount.String(),
+			Type:      r.Type,
+			CreatedAt: r.CreatedAt,
+		})
+	}
+	return result, nil
+}
+
+// 赎回方式
+const (
+	RedemptionTypeFull    = "full"
+	RedemptionTypePartial = "partial"
+)
+
+// RedemptionQuote 赎回试算结果，确认赎回时按同一规则执行
+type RedemptionQuote struct {
+	OrderID            int64  `json:"orderId"`
+	Currency           string `json:"currency"`
+	RedemptionType     string `json:"redemptionType"`
+	IsEarly            bool   `json:"isEarly"`
+	EarlyRedeemRule    int    `json:"earlyRedeemRule,omitempty"`
+	Principal          string `json:"principal"`
+	InterestAccrued    string `json:"interestAccrued"`
+	InterestPaid       string `json:"interestPaid"`
+	InterestForfeited  string `json:"interestForfeited"`
+	Fee                string `json:"fee"`
+	Payout             string `json:"payout"`
+	RemainingPrincipal string `json:"remainingPrincipal"`
+}
+
+// redemption 赎回计算的中间结果
+type redemption struct {
+	redemptionType string
+	isEarly        bool
+	rule           int
+	principal      money.Decimal // 本次赎回本金
+	interestShare  money.Decimal // 本次赎回本金对应的已计利息
+	interestPaid   money.Decimal // 实际派发利息
+	fee            money.Decimal
+	remaining      money.Decimal // 赎回后剩余本金
+}
+
+func (r *redemption) payout() money.Decimal {
+	return r.principal.Add(r.interestPaid).Sub(r.fee)
+}
+
+func (r *redemption) quote(order *repository.WealthOrderModel) *RedemptionQuote {
+	return &RedemptionQuote{
+		OrderID:            order.ID,
+		Currency:           order.Currency,
+		RedemptionType:     r.redemptionType,
+		IsEarly:            r.isEarly,
+		EarlyRedeemRule:    r.rule,
+		Principal:          r.principal.String(),
+		InterestAccrued:    r.interestShare.String(),
+		InterestPaid:       r.interestPaid.String(),
+		InterestForfeited:  r.interestShare.Sub(r.interestPaid).String(),
+		Fee:                r.fee.String(),
+		Payout:             r.payout().String(),
+		RemainingPrincipal: r.remaining.String(),
+	}
+}
+
+// loadRedeemableOrder 校验订单归属与状态
+func (s *WealthService) loadRedeemableOrder(ctx context.Context, userID int, orderID int64) (*repository.WealthOrderModel, error) {
+	order, err := s.repo.GetOrderByID(ctx, orderID)
+	if err != nil {
+		return nil, ErrOrderNotFound
+	}
+	return checkRedeemable(order, userID)
+}
+
+// checkRedeemable 校验订单归属且仍可赎回
+func checkRedeemable(order *repository.WealthOrderModel, userID int) (*repository.WealthOrderModel, error) {
+	if order.UserID != int64(userID) {
+		return nil, ErrOrderNotFound
+	}
+	switch order.Status {
+	case repository.WealthOrderStatusSettled, repository.WealthOrderStatusRedeemed, repository.WealthOrderStatusCancelled:
+		return nil, ErrOrderAlreadyRedeemed
+	}
+	return order, nil
+}
+
+// calculateRedemption 计算本次赎回的本金、利息与手续费。
+// 到期（含到期日当天）赎回全额派息；提前赎回按订单的提前赎回条款处理已计利息。
+// 部分赎回时利息按赎回本金占剩余本金的比例分摊。
+func (s *WealthService) calculateRedemption(order *repository.WealthOrderModel, redemptionType, amount string, now time.Time) (*redemption, error) {
+	spec := money.SpecFor(order.Currency)
+	remaining := order.RemainingPrincipal()
+
+	r := &redemption{redemptionType: redemptionType}
+	switch redemptionType {
+	case "", RedemptionTypeFull:
+		r.redemptionType = RedemptionTypeFull
+		r.principal = remaining
+	case RedemptionTypePartial:
+		principal, err := money.Parse(amount)
+		if err != nil {
+			return nil, ErrInvalidRedemptionAmount
+		}
+		principal = spec.Quantize(principal)
+		if !principal.IsPositive() || principal.GreaterThan(remaining) {
+			return nil, ErrInvalidRedemptionAmount
+		}
+		r.principal = principal
+	default:
+		return nil, ErrInvalidRedemptionType
+	}
+	r.remaining = remaining.Sub(r.principal)
+
+	r.interestShare = order.InterestAccrued
+	if r.remaining.IsPositive() {
+		r.interestShare = spec.Quantize(order.InterestAccrued.Mul(r.principal).Div(remaining, spec.Scale+4, money.RoundDown))
+	}
+
+	// 活期没有到期日，任何时候赎回都不算提前
+	if !order.IsFlexible() {
+		r.isEarly = order.Calendar().Today(now) < order.EndDate
+	}
+	r.interestPaid = r.interestShare
+	r.fee = money.Zero
+	if !r.isEarly {
+		return r, nil
+	}
+
+	// 按下单时的条款快照处理，产品后续修改不影响存量订单
+	r.rule = order.EarlyRedeemRule
+	switch order.EarlyRedeemRule {
+	case repository.EarlyRedeemKeepPercent:
+		pct := money.Min(money.Max(order.EarlyRedeemValue, money.Zero), money.NewFromInt(100))
+		r.interestPaid = spec.Quantize(r.interestShare.Mul(pct).Div(money.NewFromInt(100), spec.Scale+4, money.RoundDown))
+	case repository.EarlyRedeemFlatFee:
+		// 手续费不超过本次可得金额
+		r.fee = money.Min(spec.Quantize(order.EarlyRedeemValue), r.principal.Add(r.interestShare))
+	default:
+		r.rule = repository.EarlyRedeemForfeitInterest
+		r.interestPaid = money.Zero
+	}
+	return r, nil
+}
+
+// QuoteRedemption 试算赎回金额，不修改任何数据
+func (s *WealthService) QuoteRedemption(ctx context.Context, userID int, orderID int64, redemptionType, amount string) (*RedemptionQuote, error) {
+	order, err := s.loadRedeemableOrder(ctx, userID, orderID)
+	if err != nil {
+		return nil, err
+	}
+	r, err := s.calculateRedemption(order, redemptionType, amount, s.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+	return r.quote(order), nil
+}
+
+// Redeem 赎回订单：full 赎回全部剩余本金，partial 赎回 amount 指定的本金
+func (s *WealthService) Redeem(ctx context.Context, userID int, orderID int64, redemptionType, amount string) (*RedemptionQuote, error) {
+	fmt.Printf("[DEBUG] Redeem - userID: %d, orderID: %d, redemptionType: %s, amount: %s\n", userID, orderID, redemptionType, amount)
+	order, err := s.loadRedeemableOrder(ctx, userID, orderID)
+	if err != nil {
+		return nil, err
 	}
 
-	order.RedemptionAmount = order.Amount
-	order.RedeemedAt = now.Format(time.RFC3339)
+	now := s.clock.Now()
+	if _, err := s.calculateRedemption(order, redemptionType, amount, now); err != nil {
+		return nil, err
+	}
 
-	if isExpired {
-		interestAccrued, _ := strconv.ParseFloat(order.InterestAccrued, 64)
-		if interestAccrued > 0 {
-			err = s.accountRepo.AddBalance(ctx, account.ID, order.InterestAccrued)
-			if err != nil {
+	account, err := s.accountRepo.GetAccountByUserIDAndCurrency(ctx, int64(userID), order.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	// 订单在事务内加锁重读后再计算：并发的赎回或到期结算先提交时，这里读到终态并返回 ErrOrderAlreadyRedeemed
+	var quote *RedemptionQuote
+	err = s.uow.Do(ctx, func(tx *repository.TxRepository) error {
+		order, err := tx.Wealth.GetOrderForUpdate(ctx, orderID)
+		if err != nil {
+			return ErrOrderNotFound
+		}
+		if _, err := checkRedeemable(order, userID); err != nil {
+			return err
+		}
+		r, err := s.calculateRedemption(order, redemptionType, amount, now)
+		if err != nil {
+			return err
+		}
+		quote = r.quote(order)
+		fmt.Printf("[DEBUG] Redeem - order %d: principal=%s interest=%s fee=%s early=%t\n", order.ID, r.principal, r.interestPaid, r.fee, r.isEarly)
+
+		order.PrincipalRedeemed = order.PrincipalRedeemed.Add(r.principal)
+		order.InterestAccrued = order.InterestAccrued.Sub(r.interestShare)
+		order.InterestPaid = order.InterestPaid.Add(r.interestPaid)
+		order.RedemptionAmount = order.RedemptionAmount.Add(r.payout())
+		order.RedemptionType = sql.NullString{String: r.redemptionType, Valid: true}
+		if remaining := order.Amount.Sub(order.PrincipalRedeemed); remaining.IsPositive() {
+			// 预期利息按剩余本金等比缩减
+			order.InterestExpected = money.Quantize(order.Currency, order.InterestExpected.Mul(r.remaining).Div(r.remaining.Add(r.principal), money.SpecFor(order.Currency).Scale+4, money.RoundDown))
+		} else if r.isEarly {
+			order.Status = 4
+			order.RedeemedAt = now.Format(time.RFC3339)
+		} else {
+			order.Status = 3
+			order.RedeemedAt = now.Format(time.RFC3339)
+		}
+
+		// 解冻本金、派息、扣费、记账、更新订单在同一事务内完成
+		if err := tx.Account.UnfreezeBalance(ctx, account.ID, r.principal); err != nil {
+			return err
+		}
+
+		// 可用余额快照：解冻本金后再叠加利息、扣除手续费
+		availableAfter := account.Available().Add(r.principal)
+
+		principalJournal := &repository.JournalModel{
+			SerialNo:        fmt.Sprintf("REDEEM-PRINCIPAL-%s-%d", now.Format("20060102150405"), order.ID),
+			UserID:          int64(userID),
+			AccountID:       account.ID,
+			Amount:          r.principal,
+			BalanceSnapshot: availableAfter,
+			BizType:         "REDEEM_UNFREEZE",
+			RefID:           &order.ID,
+			CreatedAt:       now.Format(time.RFC3339),
+		}
+		if err := tx.Journal.CreateJournalRecord(ctx, principalJournal); err != nil {
+			fmt.Printf("[ERROR] Failed to create principal journal record: %v\n", err)
+			return ErrJournalCreateFailed
+		}
+
+		if r.interestPaid.IsPositive() {
+			if err := tx.Account.AddBalance(ctx, account.ID, r.interestPaid); err != nil {
 				return err
 			}
 
-			balance, _ := strconv.ParseFloat(account.Balance, 64)
-			newBalance := balance + interestAccrued
-
+			availableAfter = availableAfter.Add(r.interestPaid)
 			interestJournalRecord := &repository.JournalModel{
 				SerialNo:        fmt.Sprintf("REDEEM-INTEREST-%s-%d", now.Format("20060102150405"), order.ID),
 				UserID:          int64(userID),
 				AccountID:       account.ID,
-				Amount:          order.InterestAccrued,
-				BalanceSnapshot: strconv.FormatFloat(newBalance+interestAccrued, 'f', -1, 64),
+				Amount:          r.interestPaid,
+				BalanceSnapshot: availableAfter,
 				BizType:         "INTEREST_PAYOUT",
 				RefID:           &order.ID,
 				CreatedAt:       now.Format(time.RFC3339),
 			}
-			err = s.journalRepo.CreateJournalRecord(ctx, interestJournalRecord)
-			if err != nil {
+			if err := tx.Journal.CreateJournalRecord(ctx, interestJournalRecord); err != nil {
 				fmt.Printf("[ERROR] Failed to create interest journal record: %v\n", err)
+				return ErrJournalCreateFailed
+			}
+		}
+
+		if r.fee.IsPositive() {
+			if err := tx.Account.DeductBalance(ctx, account.ID, r.fee); err != nil {
+				return err
 			}
 
-			order.InterestPaid = order.InterestAccrued
-			order.InterestAccrued = "0"
-			fmt.Printf("[DEBUG] Paid interest %.8f %s for order %d\n", interestAccrued, order.Currency, order.ID)
+			availableAfter = availableAfter.Sub(r.fee)
+			feeJournal := &repository.JournalModel{
+				SerialNo:        fmt.Sprintf("REDEEM-FEE-%s-%d", now.Format("20060102150405"), order.ID),
+				UserID:          int64(userID),
+				AccountID:       account.ID,
+				Amount:          r.fee.Neg(),
+				BalanceSnapshot: availableAfter,
+				BizType:         "REDEEM_FEE",
+				RefID:           &order.ID,
+				CreatedAt:       now.Format(time.RFC3339),
+			}
+			if err := tx.Journal.CreateJournalRecord(ctx, feeJournal); err != nil {
+				fmt.Printf("[ERROR] Failed to create fee journal record: %v\n", err)
+				return ErrJournalCreateFailed
+			}
 		}
-	} else {
-		order.InterestAccrued = "0"
-		fmt.Printf("[DEBUG] Early redemption - cleared accrued interest for order %d\n", order.ID)
-	}
 
-	balance, _ := strconv.ParseFloat(account.Balance, 64)
-	principalAmt, _ := strconv.ParseFloat(order.Amount, 64)
-	newBalance := balance + principalAmt
+		// 赎回的本金归还产品额度
+		if err := tx.Wealth.ReleaseProductQuota(ctx, order.ProductID, r.principal); err != nil {
+			return err
+		}
 
-	principalJournal := &repository.JournalModel{
-		SerialNo:        fmt.Sprintf("REDEEM-PRINCIPAL-%s-%d", now.Format("20060102150405"), order.ID),
-		UserID:          int64(userID),
-		AccountID:       account.ID,
-		Amount:          order.Amount,
-		BalanceSnapshot: strconv.FormatFloat(newBalance, 'f', -1, 64),
-		BizType:         "REDEEM_UNFREEZE",
-		RefID:           &order.ID,
-		CreatedAt:       now.Format(time.RFC3339),
-	}
-	err = s.journalRepo.CreateJournalRecord(ctx, principalJournal)
+		return tx.Wealth.UpdateOrder(ctx, order)
+	})
 	if err != nil {
-		fmt.Printf("[ERROR] Failed to create principal journal record: %v\n", err)
+		return nil, err
 	}
-
-	return s.repo.UpdateOrder(ctx, order)
+	return quote, nil
 }
@@ -0,0 +1,232 @@
+package services
+
+import (
+	"context"
+	"sort"
+
+	"monera-digital/internal/binance"
+	"monera-digital/internal/money"
+	"monera-digital/internal/repository"
+)
+
+// maxRenewalChain 续期链的最大追溯长度，防止异常数据形成环
+const maxRenewalChain = 100
+
+// wealthJournalBizTypes 理财订单（申购、撤单、赎回、派息、续期复投）写入的流水类型
+var wealthJournalBizTypes = []string{
+	"SUBSCRIBE_FREEZE", "CANCEL_UNFREEZE", "REDEEM_UNFREEZE", "REDEEM_FEE", "INTEREST_PAYOUT", "COMPOUND_FREEZE",
+}
+
+// PortfolioPosition 某一币种的理财持仓汇总
+type PortfolioPosition struct {
+	Currency string `json:"currency"`
+	// Principal 持有中订单的剩余本金
+	Principal       string `json:"principal"`
+	InterestAccrued string `json:"interestAccrued"`
+	// InterestPaid 该币种历史累计已派发利息
+	InterestPaid string  `json:"interestPaid"`
+	ActiveOrders int     `json:"activeOrders"`
+	UsdValue     float64 `json:"usdValue"` // 本金与已计未付利息的美元估值
+}
+
+// MaturityEntry 到期日历中的一笔定期订单
+type MaturityEntry struct {
+	OrderID          int64  `json:"orderId"`
+	ProductTitle     string `json:"productTitle"`
+	Currency         string `json:"currency"`
+	EndDate          string `json:"endDate"`
+	Principal        string `json:"principal"`
+	InterestExpected string `json:"interestExpected"`
+	AutoRenew        bool   `json:"autoRenew"`
+}
+
+// Portfolio 用户理财资产总览
+type Portfolio struct {
+	Positions     []*PortfolioPosition `json:"positions"`
+	TotalUsdValue float64              `json:"totalUsdValue"`
+	// Maturities 持有中定期订单按到期日升序排列
+	Maturities []*MaturityEntry `json:"maturities"`
+}
+
+// RenewalLink 续期链上的一笔订单
+type RenewalLink struct {
+	OrderID   int64  `json:"orderId"`
+	Amount    string `json:"amount"`
+	StartDate string `json:"startDate"`
+	EndDate   string `json:"endDate"`
+	Status    int    `json:"status"`
+}
+
+// JournalEntry 与订单相关的资金流水
+type JournalEntry struct {
+	SerialNo        string `json:"serialNo"`
+	BizType         string `json:"bizType"`
+	Amount          string `json:"amount"`
+	BalanceSnapshot string `json:"balanceSnapshot"`
+	CreatedAt       string `json:"createdAt"`
+}
+
+// OrderDetail 订单详情：逐日计息记录、续期链与资金流水
+type OrderDetail struct {
+	Order
+	RenewedFromOrderID *int64 `json:"renewedFromOrderId,omitempty"`
+	RenewedToOrderID   *int64 `json:"renewedToOrderId,omitempty"`
+	// RenewalChain 从最早的原始订单到最新续期订单，包含本订单；未续期时只有本订单
+	RenewalChain []*RenewalLink    `json:"renewalChain"`
+	Interest     []*InterestRecord `json:"interest"`
+	Journals     []*JournalEntry   `json:"journals"`
+}
+
+// GetPortfolio 汇总用户的理财持仓：按币种统计本金与利息、列出到期日历并按缓存价格估值
+func (s *WealthService) GetPortfolio(ctx context.Context, userID int) (*Portfolio, error) {
+	orders, err := s.repo.GetOrdersByUserID(ctx, int64(userID))
+	if err != nil {
+		return nil, err
+	}
+
+	type totals struct {
+		principal, accrued, paid money.Decimal
+		active                   int
+	}
+	byCurrency := make(map[string]*totals)
+	maturities := []*MaturityEntry{}
+	for _, o := range orders {
+		t := byCurrency[o.Currency]
+		if t == nil {
+			t = &totals{principal: money.Zero, accrued: money.Zero, paid: money.Zero}
+			byCurrency[o.Currency] = t
+		}
+		t.paid = t.paid.Add(o.InterestPaid)
+		if o.Status != repository.WealthOrderStatusActive {
+			continue
+		}
+
+		t.principal = t.principal.Add(o.RemainingPrincipal())
+		t.accrued = t.accrued.Add(o.InterestAccrued)
+		t.active++
+		if !o.IsFlexible() {
+			maturities = append(maturities, &MaturityEntry{
+				OrderID:          o.ID,
+				ProductTitle:     o.ProductTitle,
+				Currency:         o.Currency,
+				EndDate:          o.EndDate,
+				Principal:        o.RemainingPrincipal().String(),
+				InterestExpected: o.InterestExpected.String(),
+				AutoRenew:        o.AutoRenew,
+			})
+		}
+	}
+
+	prices := binance.NewPriceService()
+	portfolio := &Portfolio{Positions: []*PortfolioPosition{}, Maturities: maturities}
+	for currency, t := range byCurrency {
+		usdValue := prices.GetUSDValueFromCache(t.principal.Add(t.accrued).Float64(), currency)
+		portfolio.Positions = append(portfolio.Positions, &PortfolioPosition{
+			Currency:        currency,
+			Principal:       t.principal.String(),
+			InterestAccrued: t.accrued.String(),
+			InterestPaid:    t.paid.String(),
+			ActiveOrders:    t.active,
+			UsdValue:        usdValue,
+		})
+		portfolio.TotalUsdValue += usdValue
+	}
+
+	sort.Slice(portfolio.Positions, func(i, j int) bool {
+		return portfolio.Positions[i].Currency < portfolio.Positions[j].Currency
+	})
+	sort.SliceStable(portfolio.Maturities, func(i, j int) bool {
+		if portfolio.Maturities[i].EndDate != portfolio.Maturities[j].EndDate {
+			return portfolio.Maturities[i].EndDate < portfolio.Maturities[j].EndDate
+		}
+		return portfolio.Maturities[i].OrderID < portfolio.Maturities[j].OrderID
+	})
+	return portfolio, nil
+}
+
+// GetOrderDetail 返回订单详情，仅订单所有者可查看
+func (s *WealthService) GetOrderDetail(ctx context.Context, userID int, orderID int64) (*OrderDetail, error) {
+	order, err := s.repo.GetOrderByID(ctx, orderID)
+	if err != nil || order.UserID != int64(userID) {
+		return nil, ErrOrderNotFound
+	}
+
+	interest, err := s.interestRecords(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	chain, err := s.renewalChain(ctx, order)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := s.journalRepo.GetJournalRecordsByRef(ctx, int64(userID), orderID, wealthJournalBizTypes)
+	if err != nil {
+		return nil, err
+	}
+	journals := make([]*JournalEntry, 0, len(records))
+	for _, r := range records {
+		journals = append(journals, &JournalEntry{
+			SerialNo:        r.SerialNo,
+			BizType:         r.BizType,
+			Amount:          r.Amount.String(),
+			BalanceSnapshot: r.BalanceSnapshot.String(),
+			CreatedAt:       r.CreatedAt,
+		})
+	}
+
+	return &OrderDetail{
+		Order:              *toOrder(order),
+		RenewedFromOrderID: order.RenewedFromOrderID,
+		RenewedToOrderID:   order.RenewedToOrderID,
+		RenewalChain:       chain,
+		Interest:           interest,
+		Journals:           journals,
+	}, nil
+}
+
+// renewalChain 沿 RenewedFromOrderID/RenewedToOrderID 向前后追溯，返回按时间先后排列的续期链
+func (s *WealthService) renewalChain(ctx context.Context, order *repository.WealthOrderModel) ([]*RenewalLink, error) {
+	link := func(o *repository.WealthOrderModel) *RenewalLink {
+		return &RenewalLink{
+			OrderID:   o.ID,
+			Amount:    o.Amount.String(),
+			StartDate: o.StartDate,
+			EndDate:   o.EndDate,
+			Status:    o.Status,
+		}
+	}
+
+	var earlier []*RenewalLink
+	for cur := order; cur.RenewedFromOrderID != nil && len(earlier) < maxRenewalChain; {
+		prev, err := s.repo.GetOrderByID(ctx, *cur.RenewedFromOrderID)
+		if err != nil {
+			return nil, err
+		}
+		if prev.UserID != order.UserID {
+			break
+		}
+		earlier = append(earlier, link(prev))
+		cur = prev
+	}
+
+	chain := make([]*RenewalLink, 0, len(earlier)+1)
+	for i := len(earlier) - 1; i >= 0; i-- {
+		chain = append(chain, earlier[i])
+	}
+	chain = append(chain, link(order))
+
+	for cur, n := order, 0; cur.RenewedToOrderID != nil && n < maxRenewalChain; n++ {
+		next, err := s.repo.GetOrderByID(ctx, *cur.RenewedToOrderID)
+		if err != nil {
+			return nil, err
+		}
+		if next.UserID != order.UserID {
+			break
+		}
+		chain = append(chain, link(next))
+		cur = next
+	}
+	return chain, nil
+}
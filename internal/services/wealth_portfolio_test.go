@@ -0,0 +1,96 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"monera-digital/internal/money"
+	"monera-digital/internal/repository"
+)
+
+func TestWealthService_GetPortfolio(t *testing.T) {
+	mockRepo := new(MockWealthRepository)
+	service := NewWealthService(mockRepo, nil, nil, nil)
+
+	mockRepo.On("GetOrdersByUserID", mock.Anything, int64(1)).Return([]*repository.WealthOrderModel{
+		{ID: 1, Currency: "USDT", Amount: money.MustParse("5000"), PrincipalRedeemed: money.MustParse("1000"), InterestAccrued: money.MustParse("3"), InterestPaid: money.MustParse("2"), EndDate: "2026-11-20", Status: 1, AutoRenew: true},
+		{ID: 2, Currency: "USDT", Amount: money.MustParse("2000"), InterestAccrued: money.MustParse("1"), EndDate: "2026-11-01", Status: 1},
+		{ID: 3, Currency: "USDT", Amount: money.MustParse("9000"), PrincipalRedeemed: money.MustParse("9000"), InterestPaid: money.MustParse("40"), EndDate: "2026-09-01", Status: 3},
+		{ID: 4, Currency: "USDC", Amount: money.MustParse("300"), InterestAccrued: money.MustParse("0.5"), ProductType: repository.WealthProductTypeFlexible, Status: 1},
+	}, nil)
+
+	portfolio, err := service.GetPortfolio(context.Background(), 1)
+
+	assert.NoError(t, err)
+	if assert.Len(t, portfolio.Positions, 2) {
+		usdc, usdt := portfolio.Positions[0], portfolio.Positions[1]
+		assert.Equal(t, "USDC", usdc.Currency)
+		assert.Equal(t, "300", usdc.Principal)
+		assert.Equal(t, 300.5, usdc.UsdValue)
+
+		assert.Equal(t, "USDT", usdt.Currency)
+		assert.Equal(t, "6000", usdt.Principal)
+		assert.Equal(t, "4", usdt.InterestAccrued)
+		// 已结清订单的利息计入累计已派发
+		assert.Equal(t, "42", usdt.InterestPaid)
+		assert.Equal(t, 2, usdt.ActiveOrders)
+		assert.Equal(t, 6004.0, usdt.UsdValue)
+	}
+	assert.Equal(t, 6304.5, portfolio.TotalUsdValue)
+
+	// 到期日历只含持有中的定期订单，按到期日排列
+	if assert.Len(t, portfolio.Maturities, 2) {
+		assert.Equal(t, int64(2), portfolio.Maturities[0].OrderID)
+		assert.Equal(t, int64(1), portfolio.Maturities[1].OrderID)
+		assert.Equal(t, "4000", portfolio.Maturities[1].Principal)
+		assert.True(t, portfolio.Maturities[1].AutoRenew)
+	}
+}
+
+func TestWealthService_GetOrderDetail(t *testing.T) {
+	mockRepo := new(MockWealthRepository)
+	mockJournalRepo := new(MockJournalRepository)
+	service := NewWealthService(mockRepo, nil, mockJournalRepo, nil)
+
+	first, middle, last := int64(3), int64(5), int64(8)
+	mockRepo.On("GetOrderByID", mock.Anything, first).Return(&repository.WealthOrderModel{ID: first, UserID: 1, Amount: money.MustParse("1000"), Status: 3, RenewedToOrderID: &middle}, nil)
+	mockRepo.On("GetOrderByID", mock.Anything, middle).Return(&repository.WealthOrderModel{ID: middle, UserID: 1, Amount: money.MustParse("1010"), Status: 3, RenewedFromOrderID: &first, RenewedToOrderID: &last}, nil)
+	mockRepo.On("GetOrderByID", mock.Anything, last).Return(&repository.WealthOrderModel{ID: last, UserID: 1, Amount: money.MustParse("1020"), Status: 1, RenewedFromOrderID: &middle}, nil)
+	mockRepo.On("GetInterestRecords", mock.Anything, middle).Return([]*repository.InterestRecordModel{
+		{OrderID: middle, Amount: money.MustParse("1.4"), Type: repository.InterestRecordTypeAccrual, Date: "2026-10-02"},
+	}, nil)
+	mockJournalRepo.On("GetJournalRecordsByRef", mock.Anything, int64(1), middle, wealthJournalBizTypes).Return([]*repository.JournalModel{
+		{SerialNo: "RENEW-INTEREST-1", BizType: "INTEREST_PAYOUT", Amount: money.MustParse("10"), BalanceSnapshot: money.MustParse("10")},
+	}, nil)
+
+	detail, err := service.GetOrderDetail(context.Background(), 1, middle)
+
+	assert.NoError(t, err)
+	assert.Equal(t, middle, detail.ID)
+	assert.Equal(t, &first, detail.RenewedFromOrderID)
+	assert.Equal(t, &last, detail.RenewedToOrderID)
+	if assert.Len(t, detail.RenewalChain, 3) {
+		assert.Equal(t, first, detail.RenewalChain[0].OrderID)
+		assert.Equal(t, middle, detail.RenewalChain[1].OrderID)
+		assert.Equal(t, last, detail.RenewalChain[2].OrderID)
+	}
+	assert.Len(t, detail.Interest, 1)
+	if assert.Len(t, detail.Journals, 1) {
+		assert.Equal(t, "INTEREST_PAYOUT", detail.Journals[0].BizType)
+	}
+}
+
+func TestWealthService_GetOrderDetail_OtherUser(t *testing.T) {
+	mockRepo := new(MockWealthRepository)
+	mockJournalRepo := new(MockJournalRepository)
+	service := NewWealthService(mockRepo, nil, mockJournalRepo, nil)
+
+	mockRepo.On("GetOrderByID", mock.Anything, int64(5)).Return(&repository.WealthOrderModel{ID: 5, UserID: 2}, nil)
+
+	_, err := service.GetOrderDetail(context.Background(), 1, 5)
+
+	assert.ErrorIs(t, err, ErrOrderNotFound)
+	assert.False(t, wasCalled(&mockJournalRepo.Mock, "GetJournalRecordsByRef"))
+}
@@ -0,0 +1,322 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"monera-digital/internal/accrual"
+	"monera-digital/internal/money"
+	"monera-digital/internal/repository"
+)
+
+var (
+	ErrInvalidProduct           = errors.New("invalid product")
+	ErrProductRetired           = errors.New("product retired")
+	ErrInvalidProductTransition = errors.New("invalid product status transition")
+)
+
+// baseRateDate 产品初始利率的生效日期，与利率表的历史种子一致
+const baseRateDate = "1970-01-01"
+
+// ProductTerms 产品可编辑的条款。修改只影响之后的申购：
+// 存量订单的期限、到期日与提前赎回规则在下单时已固定。
+type ProductTerms struct {
+	Title            string `json:"title"`
+	Duration         int    `json:"duration"`
+	MinAmount        string `json:"minAmount"`
+	MaxAmount        string `json:"maxAmount"`
+	TotalQuota       string `json:"totalQuota"`
+	AutoRenewAllowed bool   `json:"autoRenewAllowed"`
+	EarlyRedeemRule  int    `json:"earlyRedeemRule"`
+	EarlyRedeemValue string `json:"earlyRedeemValue"`
+	// SubscribeStartAt/SubscribeEndAt 申购窗口，为空表示不限
+	SubscribeStartAt *time.Time `json:"subscribeStartAt"`
+	SubscribeEndAt   *time.Time `json:"subscribeEndAt"`
+}
+
+// NewProduct 创建产品的参数。币种、产品类型与营业日规则创建后不可修改，
+// 利率之后通过利率排期接口调整。
+type NewProduct struct {
+	ProductTerms
+	Currency    string `json:"currency"`
+	ProductType int    `json:"productType"`
+	APY         string `json:"apy"`
+	// SettlementTimezone 结算时区（IANA 名称），为空时使用系统默认时区
+	SettlementTimezone string `json:"settlementTimezone"`
+	// CutoffTime 日切时间 HH:MM，为空时以当地零点切日
+	CutoffTime string `json:"cutoffTime"`
+	// DayCount 计息基准 ACT/365 或 ACT/360，为空时为 ACT/365
+	DayCount string `json:"dayCount"`
+	// Paused 为 true 时产品创建后暂不开放申购
+	Paused bool `json:"paused"`
+}
+
+// AdminProduct 后台视角的产品信息，包含状态与已售额度
+type AdminProduct struct {
+	ID               int64      `json:"id"`
+	Title            string     `json:"title"`
+	Currency         string     `json:"currency"`
+	ProductType      int        `json:"productType"`
+	APY              string     `json:"apy"`
+	Duration         int        `json:"duration"`
+	MinAmount        string     `json:"minAmount"`
+	MaxAmount        string     `json:"maxAmount"`
+	TotalQuota       string     `json:"totalQuota"`
+	SoldQuota        string     `json:"soldQuota"`
+	Status           int        `json:"status"`
+	AutoRenewAllowed bool       `json:"autoRenewAllowed"`
+	EarlyRedeemRule  int        `json:"earlyRedeemRule"`
+	EarlyRedeemValue string     `json:"earlyRedeemValue"`
+	SubscribeStartAt *time.Time `json:"subscribeStartAt,omitempty"`
+	SubscribeEndAt   *time.Time `json:"subscribeEndAt,omitempty"`
+	// SettlementTimezone 为空表示使用系统默认时区
+	SettlementTimezone string `json:"settlementTimezone"`
+	CutoffTime         string `json:"cutoffTime"`
+	DayCount           string `json:"dayCount"`
+	CreatedAt          string `json:"createdAt"`
+	UpdatedAt          string `json:"updatedAt"`
+}
+
+func toAdminProduct(p *repository.WealthProductModel) *AdminProduct {
+	return &AdminProduct{
+		ID:                 p.ID,
+		Title:              p.Title,
+		Currency:           p.Currency,
+		ProductType:        p.ProductType,
+		APY:                p.APY.String(),
+		Duration:           p.Duration,
+		MinAmount:          p.MinAmount.String(),
+		MaxAmount:          p.MaxAmount.String(),
+		TotalQuota:         p.TotalQuota.String(),
+		SoldQuota:          p.SoldQuota.String(),
+		Status:             p.Status,
+		AutoRenewAllowed:   p.AutoRenewAllowed,
+		EarlyRedeemRule:    p.EarlyRedeemRule,
+		EarlyRedeemValue:   p.EarlyRedeemValue.String(),
+		SubscribeStartAt:   p.SubscribeStartAt,
+		SubscribeEndAt:     p.SubscribeEndAt,
+		SettlementTimezone: p.SettlementTimezone,
+		CutoffTime:         p.CutoffTime,
+		DayCount:           string(p.Basis()),
+		CreatedAt:          p.CreatedAt,
+		UpdatedAt:          p.UpdatedAt,
+	}
+}
+
+func invalidProduct(reason string) error {
+	return fmt.Errorf("%w: %s", ErrInvalidProduct, reason)
+}
+
+// applyTerms 校验条款并写入产品模型
+func applyTerms(p *repository.WealthProductModel, t *ProductTerms) error {
+	title := strings.TrimSpace(t.Title)
+	if title == "" {
+		return invalidProduct("title is required")
+	}
+
+	if p.ProductType == repository.WealthProductTypeFlexible {
+		if t.Duration != 0 || t.AutoRenewAllowed {
+			return invalidProduct("flexible products have no duration or auto-renew")
+		}
+	} else if t.Duration <= 0 {
+		return invalidProduct("duration must be positive")
+	}
+
+	minAmount, err := money.Parse(t.MinAmount)
+	if err != nil || !minAmount.IsPositive() {
+		return invalidProduct("minAmount must be positive")
+	}
+	maxAmount, err := money.Parse(t.MaxAmount)
+	if err != nil || maxAmount.LessThan(minAmount) {
+		return invalidProduct("maxAmount must not be below minAmount")
+	}
+	totalQuota, err := money.Parse(t.TotalQuota)
+	if err != nil || totalQuota.LessThan(minAmount) {
+		return invalidProduct("totalQuota must not be below minAmount")
+	}
+	if totalQuota.LessThan(p.SoldQuota) {
+		return invalidProduct("totalQuota must not be below the sold quota")
+	}
+
+	rule := t.EarlyRedeemRule
+	if rule == 0 {
+		rule = repository.EarlyRedeemForfeitInterest
+	}
+	value := money.Zero
+	if t.EarlyRedeemValue != "" {
+		if value, err = money.Parse(t.EarlyRedeemValue); err != nil || value.IsNegative() {
+			return invalidProduct("earlyRedeemValue must not be negative")
+		}
+	}
+	switch rule {
+	case repository.EarlyRedeemForfeitInterest:
+		value = money.Zero
+	case repository.EarlyRedeemKeepPercent:
+		if value.GreaterThan(money.NewFromInt(100)) {
+			return invalidProduct("earlyRedeemValue must be a percentage between 0 and 100")
+		}
+	case repository.EarlyRedeemFlatFee:
+	default:
+		return invalidProduct("unknown earlyRedeemRule")
+	}
+
+	if t.SubscribeStartAt != nil && t.SubscribeEndAt != nil && !t.SubscribeEndAt.After(*t.SubscribeStartAt) {
+		return invalidProduct("subscribeEndAt must be after subscribeStartAt")
+	}
+
+	p.Title = title
+	p.Duration = t.Duration
+	p.MinAmount = minAmount
+	p.MaxAmount = maxAmount
+	p.TotalQuota = totalQuota
+	p.AutoRenewAllowed = t.AutoRenewAllowed
+	p.EarlyRedeemRule = rule
+	p.EarlyRedeemValue = value
+	p.SubscribeStartAt = t.SubscribeStartAt
+	p.SubscribeEndAt = t.SubscribeEndAt
+	return nil
+}
+
+// ListAdminProducts 返回全部产品，包括暂停和已下架的
+func (s *WealthService) ListAdminProducts(ctx context.Context) ([]*AdminProduct, error) {
+	products, err := s.repo.ListProducts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*AdminProduct, 0, len(products))
+	for _, p := range products {
+		result = append(result, toAdminProduct(p))
+	}
+	return result, nil
+}
+
+// CreateProduct 创建产品，并写入初始利率作为利率历史的起点
+func (s *WealthService) CreateProduct(ctx context.Context, in *NewProduct) (*AdminProduct, error) {
+	productType := in.ProductType
+	if productType == 0 {
+		productType = repository.WealthProductTypeFixed
+	}
+	if productType != repository.WealthProductTypeFixed && productType != repository.WealthProductTypeFlexible {
+		return nil, invalidProduct("unknown productType")
+	}
+	currency := strings.ToUpper(strings.TrimSpace(in.Currency))
+	if currency == "" {
+		return nil, invalidProduct("currency is required")
+	}
+	apy, err := money.Parse(in.APY)
+	if err != nil || apy.IsNegative() {
+		return nil, invalidProduct("apy must not be negative")
+	}
+	timezone := strings.TrimSpace(in.SettlementTimezone)
+	if timezone != "" {
+		if _, err := time.LoadLocation(timezone); err != nil {
+			return nil, invalidProduct("unknown settlementTimezone")
+		}
+	}
+	if _, err := accrual.ParseCutoff(in.CutoffTime); err != nil {
+		return nil, invalidProduct("cutoffTime must be HH:MM")
+	}
+	basis, err := accrual.ParseDayCount(in.DayCount)
+	if err != nil {
+		return nil, invalidProduct("dayCount must be ACT/365 or ACT/360")
+	}
+
+	status := repository.WealthProductStatusActive
+	if in.Paused {
+		status = repository.WealthProductStatusPaused
+	}
+	product := &repository.WealthProductModel{
+		Currency:    currency,
+		ProductType: productType,
+		APY:         apy,
+		SoldQuota:   money.Zero,
+		Status:      status,
+
+		SettlementTimezone: timezone,
+		CutoffTime:         in.CutoffTime,
+		DayCount:           string(basis),
+	}
+	if err := applyTerms(product, &in.ProductTerms); err != nil {
+		return nil, err
+	}
+
+	err = s.uow.Do(ctx, func(tx *repository.TxRepository) error {
+		if err := tx.Wealth.CreateProduct(ctx, product); err != nil {
+			return err
+		}
+		return tx.Wealth.SaveProductRates(ctx, product.ID, baseRateDate, []*repository.WealthRateModel{
+			{ProductID: product.ID, EffectiveDate: baseRateDate, TierFloor: money.Zero, APY: apy},
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toAdminProduct(product), nil
+}
+
+// UpdateProduct 修改产品条款。已下架的产品不可修改；总额度不能低于已售额度。
+func (s *WealthService) UpdateProduct(ctx context.Context, productID int64, terms *ProductTerms) (*AdminProduct, error) {
+	product, err := s.repo.GetProductByID(ctx, productID)
+	if err != nil {
+		return nil, ErrProductNotFound
+	}
+	if product.Status == repository.WealthProductStatusRetired {
+		return nil, ErrProductRetired
+	}
+	if err := applyTerms(product, terms); err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdateProduct(ctx, product); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrProductNotFound
+		}
+		if errors.Is(err, repository.ErrQuotaExceeded) {
+			// 读取后有申购占用了额度
+			return nil, invalidProduct("totalQuota must not be below the sold quota")
+		}
+		return nil, err
+	}
+	return toAdminProduct(product), nil
+}
+
+// PauseProduct 暂停申购，存量订单照常计息和到期
+func (s *WealthService) PauseProduct(ctx context.Context, productID int64) error {
+	return s.transitionProduct(ctx, productID, repository.WealthProductStatusPaused)
+}
+
+// ResumeProduct 恢复暂停产品的申购
+func (s *WealthService) ResumeProduct(ctx context.Context, productID int64) error {
+	return s.transitionProduct(ctx, productID, repository.WealthProductStatusActive)
+}
+
+// RetireProduct 下架产品：不再申购与续期，存量订单持有到期。下架不可撤销。
+func (s *WealthService) RetireProduct(ctx context.Context, productID int64) error {
+	return s.transitionProduct(ctx, productID, repository.WealthProductStatusRetired)
+}
+
+func (s *WealthService) transitionProduct(ctx context.Context, productID int64, to int) error {
+	product, err := s.repo.GetProductByID(ctx, productID)
+	if err != nil {
+		return ErrProductNotFound
+	}
+
+	switch {
+	case product.Status == repository.WealthProductStatusRetired:
+		return ErrProductRetired
+	case product.Status == to:
+		return ErrInvalidProductTransition
+	case to == repository.WealthProductStatusActive && product.Status != repository.WealthProductStatusPaused:
+		return ErrInvalidProductTransition
+	}
+
+	if err := s.repo.UpdateProductStatus(ctx, productID, to); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrProductNotFound
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,239 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"monera-digital/internal/accrual"
+	"monera-digital/internal/money"
+	"monera-digital/internal/repository"
+)
+
+func validNewProduct() *NewProduct {
+	return &NewProduct{
+		ProductTerms: ProductTerms{
+			Title:            "USDT 30日稳健",
+			Duration:         30,
+			MinAmount:        "100",
+			MaxAmount:        "50000",
+			TotalQuota:       "1000000",
+			AutoRenewAllowed: true,
+			EarlyRedeemRule:  repository.EarlyRedeemKeepPercent,
+			EarlyRedeemValue: "50",
+		},
+		Currency: "usdt",
+		APY:      "6.5",
+	}
+}
+
+func TestWealthService_CreateProduct_SeedsBaseRate(t *testing.T) {
+	mockRepo := new(MockWealthRepository)
+	uow := NewMockUnitOfWork(mockRepo, nil, nil)
+	service := NewWealthService(mockRepo, nil, nil, uow)
+
+	var created *repository.WealthProductModel
+	mockRepo.On("CreateProduct", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		created = args.Get(1).(*repository.WealthProductModel)
+		created.ID = 12
+	}).Return(nil)
+	mockRepo.On("SaveProductRates", mock.Anything, int64(12), "1970-01-01", mock.Anything).Run(func(args mock.Arguments) {
+		rates := args.Get(3).([]*repository.WealthRateModel)
+		assert.Len(t, rates, 1)
+		assert.True(t, rates[0].TierFloor.IsZero())
+		assert.Equal(t, money.MustParse("6.5"), rates[0].APY)
+	}).Return(nil)
+
+	product, err := service.CreateProduct(context.Background(), validNewProduct())
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(12), product.ID)
+	assert.Equal(t, "USDT", created.Currency)
+	assert.Equal(t, repository.WealthProductTypeFixed, created.ProductType)
+	assert.Equal(t, repository.WealthProductStatusActive, created.Status)
+	assert.Equal(t, money.MustParse("50"), created.EarlyRedeemValue)
+	assert.Equal(t, string(accrual.DayCountACT365), created.DayCount)
+	assert.Equal(t, 1, uow.Commits)
+}
+
+func TestWealthService_CreateProduct_Validation(t *testing.T) {
+	opensAt := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	closesAt := opensAt.Add(-time.Hour)
+
+	tests := []struct {
+		name   string
+		modify func(p *NewProduct)
+	}{
+		{name: "missing title", modify: func(p *NewProduct) { p.Title = " " }},
+		{name: "missing currency", modify: func(p *NewProduct) { p.Currency = "" }},
+		{name: "negative apy", modify: func(p *NewProduct) { p.APY = "-1" }},
+		{name: "unknown product type", modify: func(p *NewProduct) { p.ProductType = 9 }},
+		{name: "fixed without duration", modify: func(p *NewProduct) { p.Duration = 0 }},
+		{name: "flexible with duration", modify: func(p *NewProduct) {
+			p.ProductType = repository.WealthProductTypeFlexible
+			p.AutoRenewAllowed = false
+		}},
+		{name: "zero min amount", modify: func(p *NewProduct) { p.MinAmount = "0" }},
+		{name: "max below min", modify: func(p *NewProduct) { p.MaxAmount = "50" }},
+		{name: "quota below min", modify: func(p *NewProduct) { p.TotalQuota = "10" }},
+		{name: "keep percent above 100", modify: func(p *NewProduct) { p.EarlyRedeemValue = "120" }},
+		{name: "unknown early redeem rule", modify: func(p *NewProduct) { p.EarlyRedeemRule = 7 }},
+		{name: "window closes before it opens", modify: func(p *NewProduct) {
+			p.SubscribeStartAt = &opensAt
+			p.SubscribeEndAt = &closesAt
+		}},
+		{name: "unknown settlement timezone", modify: func(p *NewProduct) { p.SettlementTimezone = "Mars/Olympus" }},
+		{name: "malformed cutoff time", modify: func(p *NewProduct) { p.CutoffTime = "25:00" }},
+		{name: "unknown day count", modify: func(p *NewProduct) { p.DayCount = "30/360" }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockWealthRepository)
+			service := NewWealthService(mockRepo, nil, nil, NewMockUnitOfWork(mockRepo, nil, nil))
+
+			in := validNewProduct()
+			tt.modify(in)
+			_, err := service.CreateProduct(context.Background(), in)
+
+			assert.ErrorIs(t, err, ErrInvalidProduct)
+			assert.False(t, wasCalled(&mockRepo.Mock, "CreateProduct"))
+		})
+	}
+}
+
+func TestWealthService_UpdateProduct(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		totalQuota  string
+		expectedErr error
+	}{
+		{name: "updates terms", status: repository.WealthProductStatusPaused, totalQuota: "800000"},
+		{name: "quota below sold", status: repository.WealthProductStatusActive, totalQuota: "1000", expectedErr: ErrInvalidProduct},
+		{name: "retired product", status: repository.WealthProductStatusRetired, totalQuota: "800000", expectedErr: ErrProductRetired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockWealthRepository)
+			service := NewWealthService(mockRepo, nil, nil, NewMockUnitOfWork(mockRepo, nil, nil))
+
+			mockRepo.On("GetProductByID", mock.Anything, int64(3)).Return(&repository.WealthProductModel{
+				ID:          3,
+				Currency:    "USDT",
+				ProductType: repository.WealthProductTypeFixed,
+				APY:         money.MustParse("5"),
+				SoldQuota:   money.MustParse("20000"),
+				Status:      tt.status,
+			}, nil)
+			var updated *repository.WealthProductModel
+			mockRepo.On("UpdateProduct", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+				updated = args.Get(1).(*repository.WealthProductModel)
+			}).Return(nil)
+
+			terms := validNewProduct().ProductTerms
+			terms.Duration = 60
+			terms.TotalQuota = tt.totalQuota
+			product, err := service.UpdateProduct(context.Background(), 3, &terms)
+
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				assert.False(t, wasCalled(&mockRepo.Mock, "UpdateProduct"))
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, 60, updated.Duration)
+			assert.Equal(t, "USDT", updated.Currency)
+			assert.Equal(t, tt.status, updated.Status)
+			assert.Equal(t, "20000", product.SoldQuota)
+		})
+	}
+}
+
+func TestWealthService_UpdateProduct_QuotaSoldConcurrently(t *testing.T) {
+	mockRepo := new(MockWealthRepository)
+	service := NewWealthService(mockRepo, nil, nil, NewMockUnitOfWork(mockRepo, nil, nil))
+
+	mockRepo.On("GetProductByID", mock.Anything, int64(3)).Return(&repository.WealthProductModel{
+		ID:          3,
+		Currency:    "USDT",
+		ProductType: repository.WealthProductTypeFixed,
+		APY:         money.MustParse("5"),
+		SoldQuota:   money.MustParse("20000"),
+		Status:      repository.WealthProductStatusActive,
+	}, nil)
+	// 读取后并发申购使已售额度超过新的总额度，条件更新不命中
+	mockRepo.On("UpdateProduct", mock.Anything, mock.Anything).Return(repository.ErrQuotaExceeded)
+
+	terms := validNewProduct().ProductTerms
+	terms.TotalQuota = "25000"
+	_, err := service.UpdateProduct(context.Background(), 3, &terms)
+
+	assert.ErrorIs(t, err, ErrInvalidProduct)
+	assert.Contains(t, err.Error(), "sold quota")
+}
+
+func TestWealthService_ProductStatusTransitions(t *testing.T) {
+	tests := []struct {
+		name        string
+		from        int
+		action      func(s *WealthService) error
+		to          int
+		expectedErr error
+	}{
+		{
+			name:   "pause active",
+			from:   repository.WealthProductStatusActive,
+			action: func(s *WealthService) error { return s.PauseProduct(context.Background(), 3) },
+			to:     repository.WealthProductStatusPaused,
+		},
+		{
+			name:   "resume paused",
+			from:   repository.WealthProductStatusPaused,
+			action: func(s *WealthService) error { return s.ResumeProduct(context.Background(), 3) },
+			to:     repository.WealthProductStatusActive,
+		},
+		{
+			name:   "retire paused",
+			from:   repository.WealthProductStatusPaused,
+			action: func(s *WealthService) error { return s.RetireProduct(context.Background(), 3) },
+			to:     repository.WealthProductStatusRetired,
+		},
+		{
+			name:        "resume active",
+			from:        repository.WealthProductStatusActive,
+			action:      func(s *WealthService) error { return s.ResumeProduct(context.Background(), 3) },
+			expectedErr: ErrInvalidProductTransition,
+		},
+		{
+			name:        "resume retired",
+			from:        repository.WealthProductStatusRetired,
+			action:      func(s *WealthService) error { return s.ResumeProduct(context.Background(), 3) },
+			expectedErr: ErrProductRetired,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockWealthRepository)
+			service := NewWealthService(mockRepo, nil, nil, NewMockUnitOfWork(mockRepo, nil, nil))
+
+			mockRepo.On("GetProductByID", mock.Anything, int64(3)).Return(&repository.WealthProductModel{ID: 3, Status: tt.from}, nil)
+			mockRepo.On("UpdateProductStatus", mock.Anything, int64(3), tt.to).Return(nil)
+
+			err := tt.action(service)
+
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				assert.False(t, wasCalled(&mockRepo.Mock, "UpdateProductStatus"))
+				return
+			}
+			assert.NoError(t, err)
+			mockRepo.AssertCalled(t, "UpdateProductStatus", mock.Anything, int64(3), tt.to)
+		})
+	}
+}
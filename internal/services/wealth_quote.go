@@ -0,0 +1,116 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"monera-digital/internal/accrual"
+	"monera-digital/internal/money"
+	"monera-digital/internal/repository"
+)
+
+// SubscribeQuoteTTL 申购报价的有效期，过期后需重新报价
+const SubscribeQuoteTTL = 5 * time.Minute
+
+// ProjectedAccrual 预计在 Date 入账的一日利息
+type ProjectedAccrual struct {
+	Date   string `json:"date"`
+	Amount string `json:"amount"`
+}
+
+// SubscriptionQuote 申购报价：按与计息任务相同的规则在服务端计算，申购时凭 QuoteID 下单
+type SubscriptionQuote struct {
+	QuoteID          string `json:"quoteId"`
+	ProductID        int64  `json:"productId"`
+	Currency         string `json:"currency"`
+	Amount           string `json:"amount"`
+	InterestExpected string `json:"interestExpected"`
+	// DailyInterest 起息首日的利息
+	DailyInterest string `json:"dailyInterest"`
+	StartDate     string `json:"startDate"`
+	// MaturityDate 到期日，本息在当日结算；活期产品为空
+	MaturityDate string             `json:"maturityDate,omitempty"`
+	Schedule     []ProjectedAccrual `json:"schedule"`
+	ExpiresAt    time.Time          `json:"expiresAt"`
+}
+
+// QuoteSubscription 为申购试算预期收益并签发报价。
+// 定期产品逐日按当日生效的分档利率计息，列出到期前每一笔入账；
+// 活期产品没有到期日，只给出首日利息，预期收益为 0。
+func (s *WealthService) QuoteSubscription(ctx context.Context, userID int, productID int64, amount string) (*SubscriptionQuote, error) {
+	principal, err := money.Parse(amount)
+	if err != nil {
+		return nil, ErrAmountBelowMin
+	}
+
+	product, _, err := s.checkSubscription(ctx, userID, productID, principal)
+	if err != nil {
+		return nil, err
+	}
+
+	rates, err := s.repo.ListProductRates(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.clock.Now()
+	today := product.Calendar().BusinessDate(now)
+
+	// 定期次日起息，计息日为 [start, end)；活期当日起息
+	flexible := product.ProductType == repository.WealthProductTypeFlexible
+	start := today.AddDate(0, 0, 1)
+	days := product.Duration
+	if flexible {
+		start = today
+		days = 1
+	}
+
+	schedule := make([]ProjectedAccrual, 0, days)
+	total := money.Zero
+	dailyInterest := money.Zero
+	for i := 0; i < days; i++ {
+		day := start.AddDate(0, 0, i)
+		interest := accrual.TieredDailyInterest(product.Currency, principal, product.TiersOn(rates, day.Format("2006-01-02")), product.Basis())
+		if i == 0 {
+			dailyInterest = interest
+		}
+		total = total.Add(interest)
+		// 计息日的利息在次日入账
+		schedule = append(schedule, ProjectedAccrual{Date: day.AddDate(0, 0, 1).Format("2006-01-02"), Amount: interest.String()})
+	}
+
+	quote := &repository.WealthQuoteModel{
+		ID:               uuid.New().String(),
+		UserID:           int64(userID),
+		ProductID:        productID,
+		Amount:           principal,
+		InterestExpected: total,
+		StartDate:        start.Format("2006-01-02"),
+		ExpiresAt:        now.Add(SubscribeQuoteTTL),
+	}
+	if flexible {
+		quote.InterestExpected = money.Zero
+		schedule = schedule[:0]
+	} else {
+		quote.EndDate = start.AddDate(0, 0, days).Format("2006-01-02")
+	}
+
+	if err := s.repo.CreateQuote(ctx, quote); err != nil {
+		return nil, err
+	}
+
+	return &SubscriptionQuote{
+		QuoteID:          quote.ID,
+		ProductID:        productID,
+		Currency:         product.Currency,
+		Amount:           principal.String(),
+		InterestExpected: quote.InterestExpected.String(),
+		DailyInterest:    dailyInterest.String(),
+		StartDate:        quote.StartDate,
+		MaturityDate:     quote.EndDate,
+		Schedule:         schedule,
+		ExpiresAt:        quote.ExpiresAt,
+	}, nil
+}
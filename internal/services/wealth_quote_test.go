@@ -0,0 +1,264 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"monera-digital/internal/accrual"
+	"monera-digital/internal/clock"
+	"monera-digital/internal/config"
+	"monera-digital/internal/money"
+	"monera-digital/internal/repository"
+)
+
+func newQuoteFixture(product *repository.WealthProductModel, rates []*repository.WealthRateModel) (*WealthService, *MockWealthRepository, *repository.WealthQuoteModel) {
+	mockRepo := new(MockWealthRepository)
+	service := NewWealthService(mockRepo, nil, nil, NewMockUnitOfWork(mockRepo, nil, nil))
+
+	mockRepo.On("GetProductByID", mock.Anything, product.ID).Return(product, nil)
+	mockRepo.On("GetOrdersByUserID", mock.Anything, int64(1)).Return([]*repository.WealthOrderModel{}, nil)
+	mockRepo.On("ListProductRates", mock.Anything, product.ID).Return(rates, nil)
+	saved := &repository.WealthQuoteModel{}
+	mockRepo.On("CreateQuote", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		*saved = *args.Get(1).(*repository.WealthQuoteModel)
+	}).Return(nil)
+	return service, mockRepo, saved
+}
+
+func fixedQuoteProduct() *repository.WealthProductModel {
+	return &repository.WealthProductModel{
+		ID:         1,
+		Currency:   "USDT",
+		APY:        money.MustParse("5.5"),
+		Duration:   7,
+		MinAmount:  money.MustParse("100"),
+		MaxAmount:  money.MustParse("50000"),
+		TotalQuota: money.MustParse("100000"),
+		SoldQuota:  money.Zero,
+		Status:     repository.WealthProductStatusActive,
+	}
+}
+
+func TestWealthService_QuoteSubscription_FixedTerm(t *testing.T) {
+	service, _, saved := newQuoteFixture(fixedQuoteProduct(), nil)
+
+	quote, err := service.QuoteSubscription(context.Background(), 1, 1, "5000")
+
+	assert.NoError(t, err)
+	today, _ := time.Parse("2006-01-02", time.Now().In(config.GetLocation()).Format("2006-01-02"))
+	expected := accrual.ExpectedInterest("USDT", money.MustParse("5000"), money.MustParse("5.5"), 7, accrual.DayCountACT365)
+
+	assert.Equal(t, today.AddDate(0, 0, 1).Format("2006-01-02"), quote.StartDate)
+	assert.Equal(t, today.AddDate(0, 0, 8).Format("2006-01-02"), quote.MaturityDate)
+	assert.Equal(t, expected.String(), quote.InterestExpected)
+	if assert.Len(t, quote.Schedule, 7) {
+		// 首日利息在起息次日入账，最后一笔在到期日入账
+		assert.Equal(t, today.AddDate(0, 0, 2).Format("2006-01-02"), quote.Schedule[0].Date)
+		assert.Equal(t, quote.MaturityDate, quote.Schedule[6].Date)
+	}
+
+	// 持久化的报价与返回给客户端的一致
+	assert.Equal(t, quote.QuoteID, saved.ID)
+	assert.Equal(t, int64(1), saved.UserID)
+	assert.Equal(t, expected, saved.InterestExpected)
+	assert.Equal(t, quote.MaturityDate, saved.EndDate)
+	assert.WithinDuration(t, time.Now().Add(SubscribeQuoteTTL), saved.ExpiresAt, time.Second)
+}
+
+func TestWealthService_QuoteSubscription_FollowsInjectedClock(t *testing.T) {
+	service, _, saved := newQuoteFixture(fixedQuoteProduct(), nil)
+	// UTC 3 月 1 日 23:30 已是业务时区的 3 月 2 日
+	now := time.Date(2026, 3, 1, 23, 30, 0, 0, time.UTC)
+	service.SetClock(clock.NewFake(now))
+
+	quote, err := service.QuoteSubscription(context.Background(), 1, 1, "5000")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "2026-03-03", quote.StartDate)
+	assert.Equal(t, "2026-03-10", quote.MaturityDate)
+	assert.Equal(t, now.Add(SubscribeQuoteTTL), saved.ExpiresAt)
+}
+
+func TestWealthService_QuoteSubscription_ProductBusinessCalendar(t *testing.T) {
+	tests := []struct {
+		name     string
+		timezone string
+		cutoff   string
+		dayCount string
+		now      time.Time
+		start    string
+		maturity string
+		daily    string
+	}{
+		// 上海 15:00 日切，15:00 起算次一营业日
+		{"before cutoff", "", "15:00", "", time.Date(2026, 3, 1, 6, 59, 0, 0, time.UTC), "2026-03-02", "2026-03-09", "0.753424"},
+		{"at cutoff", "", "15:00", "", time.Date(2026, 3, 1, 7, 0, 0, 0, time.UTC), "2026-03-03", "2026-03-10", "0.753424"},
+		// UTC 3 月 2 日 03:00 在纽约仍是 3 月 1 日
+		{"settlement zone behind UTC", "America/New_York", "", "", time.Date(2026, 3, 2, 3, 0, 0, 0, time.UTC), "2026-03-02", "2026-03-09", "0.753424"},
+		{"act/360", "", "", "ACT/360", time.Date(2026, 3, 1, 1, 0, 0, 0, time.UTC), "2026-03-02", "2026-03-09", "0.763888"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			product := fixedQuoteProduct()
+			product.SettlementTimezone = tt.timezone
+			product.CutoffTime = tt.cutoff
+			product.DayCount = tt.dayCount
+			service, _, _ := newQuoteFixture(product, nil)
+			service.SetClock(clock.NewFake(tt.now))
+
+			quote, err := service.QuoteSubscription(context.Background(), 1, 1, "5000")
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.start, quote.StartDate)
+			assert.Equal(t, tt.maturity, quote.MaturityDate)
+			assert.Equal(t, tt.daily, quote.DailyInterest)
+		})
+	}
+}
+
+func TestWealthService_QuoteSubscription_UsesRateInEffectEachDay(t *testing.T) {
+	today, _ := time.Parse("2006-01-02", time.Now().In(config.GetLocation()).Format("2006-01-02"))
+	// 起息后第 4 个计息日起调价，之前三天仍按旧利率
+	changeDate := today.AddDate(0, 0, 4).Format("2006-01-02")
+	service, _, _ := newQuoteFixture(fixedQuoteProduct(), []*repository.WealthRateModel{
+		{EffectiveDate: changeDate, TierFloor: money.Zero, APY: money.MustParse("10")},
+		{EffectiveDate: "1970-01-01", TierFloor: money.Zero, APY: money.MustParse("4")},
+	})
+
+	quote, err := service.QuoteSubscription(context.Background(), 1, 1, "10000")
+
+	assert.NoError(t, err)
+	principal := money.MustParse("10000")
+	oldRate := accrual.ExpectedInterest("USDT", principal, money.MustParse("4"), 3, accrual.DayCountACT365)
+	newRate := accrual.ExpectedInterest("USDT", principal, money.MustParse("10"), 4, accrual.DayCountACT365)
+	assert.Equal(t, oldRate.Add(newRate).String(), quote.InterestExpected)
+	assert.Equal(t, accrual.DailyInterest("USDT", principal, money.MustParse("4"), accrual.DayCountACT365).String(), quote.DailyInterest)
+}
+
+func TestWealthService_QuoteSubscription_Flexible(t *testing.T) {
+	product := fixedQuoteProduct()
+	product.ProductType = repository.WealthProductTypeFlexible
+	product.Duration = 0
+	service, _, saved := newQuoteFixture(product, nil)
+
+	quote, err := service.QuoteSubscription(context.Background(), 1, 1, "1000")
+
+	assert.NoError(t, err)
+	assert.Equal(t, time.Now().In(config.GetLocation()).Format("2006-01-02"), quote.StartDate)
+	assert.Equal(t, "", quote.MaturityDate)
+	assert.Equal(t, "0", quote.InterestExpected)
+	assert.Empty(t, quote.Schedule)
+	assert.Equal(t, accrual.DailyInterest("USDT", money.MustParse("1000"), money.MustParse("5.5"), accrual.DayCountACT365).String(), quote.DailyInterest)
+	assert.Equal(t, "", saved.EndDate)
+}
+
+func TestWealthService_QuoteSubscription_RejectsInvalidAmount(t *testing.T) {
+	for _, amount := range []string{"abc", "50", "60000"} {
+		t.Run(amount, func(t *testing.T) {
+			service, mockRepo, _ := newQuoteFixture(fixedQuoteProduct(), nil)
+
+			_, err := service.QuoteSubscription(context.Background(), 1, 1, amount)
+
+			assert.Error(t, err)
+			assert.False(t, wasCalled(&mockRepo.Mock, "CreateQuote"))
+		})
+	}
+}
+
+func TestWealthService_Subscribe_RejectsUnusableQuote(t *testing.T) {
+	usedAt := time.Now().Add(-time.Minute)
+	tests := []struct {
+		name    string
+		quote   *repository.WealthQuoteModel
+		wantErr error
+	}{
+		{"unknown quote", nil, ErrQuoteNotFound},
+		{"other user's quote", &repository.WealthQuoteModel{ID: "q-1", UserID: 2, ExpiresAt: time.Now().Add(time.Minute)}, ErrQuoteNotFound},
+		{"expired quote", &repository.WealthQuoteModel{ID: "q-1", UserID: 1, ExpiresAt: time.Now().Add(-time.Second)}, ErrQuoteExpired},
+		{"used quote", &repository.WealthQuoteModel{ID: "q-1", UserID: 1, ExpiresAt: time.Now().Add(time.Minute), UsedAt: &usedAt}, ErrQuoteExpired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockWealthRepository)
+			service := NewWealthService(mockRepo, nil, nil, NewMockUnitOfWork(mockRepo, nil, nil))
+			if tt.quote == nil {
+				mockRepo.On("GetQuote", mock.Anything, "q-1").Return(nil, repository.ErrNotFound)
+			} else {
+				mockRepo.On("GetQuote", mock.Anything, "q-1").Return(tt.quote, nil)
+			}
+
+			_, err := service.Subscribe(context.Background(), 1, "q-1", false, false)
+
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.False(t, wasCalled(&mockRepo.Mock, "GetProductByID"))
+		})
+	}
+}
+
+func TestWealthService_Subscribe_OrderTakesQuotedTerms(t *testing.T) {
+	mockRepo := new(MockWealthRepository)
+	mockAccountRepo := new(MockAccountRepository)
+	mockJournalRepo := new(MockJournalRepository)
+	service := NewWealthService(mockRepo, mockAccountRepo, mockJournalRepo, NewMockUnitOfWork(mockRepo, mockAccountRepo, mockJournalRepo))
+
+	mockRepo.On("GetProductByID", mock.Anything, int64(1)).Return(fixedQuoteProduct(), nil)
+	mockAccountRepo.On("GetAccountByUserIDAndCurrency", mock.Anything, int64(1), "USDT").Return(&repository.AccountModel{
+		ID:       1,
+		UserID:   1,
+		Currency: "USDT",
+		Balance:  money.MustParse("10000"),
+	}, nil)
+	var created *repository.WealthOrderModel
+	mockRepo.On("ReserveProductQuota", mock.Anything, int64(1), money.MustParse("5000")).Return(nil)
+	mockAccountRepo.On("FreezeBalance", mock.Anything, int64(1), money.MustParse("5000")).Return(nil)
+	mockRepo.On("CreateOrder", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		created = args.Get(1).(*repository.WealthOrderModel)
+	}).Return(nil)
+	mockJournalRepo.On("CreateJournalRecord", mock.Anything, mock.Anything).Return(nil)
+	quote := stubQuote(mockRepo, 1, "5000")
+
+	_, err := service.Subscribe(context.Background(), 1, "q-1", false, false)
+
+	assert.NoError(t, err)
+	mockRepo.AssertCalled(t, "UseQuote", mock.Anything, "q-1", int64(1))
+	assert.Equal(t, quote.Amount, created.Amount)
+	assert.Equal(t, quote.InterestExpected, created.InterestExpected)
+	assert.Equal(t, quote.StartDate, created.StartDate)
+	assert.Equal(t, quote.EndDate, created.EndDate)
+}
+
+func TestWealthService_Subscribe_QuoteConsumedConcurrently(t *testing.T) {
+	mockRepo := new(MockWealthRepository)
+	mockAccountRepo := new(MockAccountRepository)
+	uow := NewMockUnitOfWork(mockRepo, mockAccountRepo, nil)
+	service := NewWealthService(mockRepo, mockAccountRepo, nil, uow)
+
+	mockRepo.On("GetProductByID", mock.Anything, int64(1)).Return(fixedQuoteProduct(), nil)
+	mockAccountRepo.On("GetAccountByUserIDAndCurrency", mock.Anything, int64(1), "USDT").Return(&repository.AccountModel{
+		ID:       1,
+		UserID:   1,
+		Currency: "USDT",
+		Balance:  money.MustParse("10000"),
+	}, nil)
+	mockRepo.On("GetQuote", mock.Anything, "q-1").Return(&repository.WealthQuoteModel{
+		ID:        "q-1",
+		UserID:    1,
+		ProductID: 1,
+		Amount:    money.MustParse("5000"),
+		ExpiresAt: time.Now().Add(time.Minute),
+	}, nil)
+	// 读取时尚未使用，核销时已被另一笔申购用掉
+	mockRepo.On("UseQuote", mock.Anything, "q-1", int64(1)).Return(repository.ErrNotFound)
+
+	_, err := service.Subscribe(context.Background(), 1, "q-1", false, false)
+
+	assert.ErrorIs(t, err, ErrQuoteExpired)
+	assert.Equal(t, 1, uow.Rollbacks)
+	assert.False(t, wasCalled(&mockRepo.Mock, "ReserveProductQuota"))
+	assert.False(t, wasCalled(&mockAccountRepo.Mock, "FreezeBalance"))
+}
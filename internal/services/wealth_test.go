@@ -2,18 +2,22 @@ package services
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"monera-digital/internal/config"
+	"monera-digital/internal/money"
 	"monera-digital/internal/repository"
 )
 
 func TestWealthService_GetProducts(t *testing.T) {
 	mockRepo := new(MockWealthRepository)
 	mockJournalRepo := new(MockJournalRepository)
-	service := NewWealthService(mockRepo, nil, mockJournalRepo)
+	service := NewWealthService(mockRepo, nil, mockJournalRepo, NewMockUnitOfWork(mockRepo, nil, mockJournalRepo))
 
 	now := time.Now().Format(time.RFC3339)
 	mockRepo.On("GetActiveProducts", mock.Anything).Return([]*repository.WealthProductModel{
@@ -21,17 +25,18 @@ func TestWealthService_GetProducts(t *testing.T) {
 			ID:               1,
 			Title:            "USDT 7日增值",
 			Currency:         "USDT",
-			APY:              "5.50",
+			APY:              money.MustParse("5.50"),
 			Duration:         7,
-			MinAmount:        "100",
-			MaxAmount:        "50000",
-			TotalQuota:       "100000",
-			SoldQuota:        "50000",
+			MinAmount:        money.MustParse("100"),
+			MaxAmount:        money.MustParse("50000"),
+			TotalQuota:       money.MustParse("100000"),
+			SoldQuota:        money.MustParse("50000"),
 			Status:           2,
 			AutoRenewAllowed: true,
 			CreatedAt:        now,
 		},
 	}, nil)
+	mockRepo.On("GetProductRates", mock.Anything, int64(1), mock.Anything).Return([]*repository.WealthRateModel{}, nil)
 
 	products, total, err := service.GetProducts(context.Background(), 1, 10)
 
@@ -44,23 +49,126 @@ func TestWealthService_GetProducts(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+func TestWealthService_GetProducts_CurrentRateTiers(t *testing.T) {
+	mockRepo := new(MockWealthRepository)
+	service := NewWealthService(mockRepo, nil, nil, nil)
+
+	today := time.Now().In(config.GetLocation()).Format("2006-01-02")
+	mockRepo.On("GetActiveProducts", mock.Anything).Return([]*repository.WealthProductModel{
+		{ID: 1, Title: "USDT 活期", Currency: "USDT", APY: money.MustParse("5.5"), Status: 1},
+	}, nil)
+	mockRepo.On("GetProductRates", mock.Anything, int64(1), today).Return([]*repository.WealthRateModel{
+		{ProductID: 1, TierFloor: money.Zero, APY: money.MustParse("6")},
+		{ProductID: 1, TierFloor: money.MustParse("10000"), APY: money.MustParse("3")},
+	}, nil)
+
+	products, _, err := service.GetProducts(context.Background(), 1, 10)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 6.0, products[0].APY)
+	assert.Equal(t, []RateTier{{Floor: "0", APY: "6"}, {Floor: "10000", APY: "3"}}, products[0].APYTiers)
+}
+
+func TestWealthService_SetProductRates(t *testing.T) {
+	tomorrow := time.Now().In(config.GetLocation()).AddDate(0, 0, 1).Format("2006-01-02")
+	yesterday := time.Now().In(config.GetLocation()).AddDate(0, 0, -1).Format("2006-01-02")
+
+	tests := []struct {
+		name    string
+		date    string
+		tiers   []RateTier
+		wantErr error
+	}{
+		{"tiered schedule", tomorrow, []RateTier{{"0", "8"}, {"10000", "4"}}, nil},
+		{"retroactive date", yesterday, []RateTier{{"0", "8"}}, ErrRetroactiveRate},
+		{"bad date", "2026/01/01", []RateTier{{"0", "8"}}, ErrInvalidRateSchedule},
+		{"no tiers", tomorrow, nil, ErrInvalidRateSchedule},
+		{"first floor not zero", tomorrow, []RateTier{{"100", "8"}}, ErrInvalidRateSchedule},
+		{"floors not increasing", tomorrow, []RateTier{{"0", "8"}, {"0", "4"}}, ErrInvalidRateSchedule},
+		{"negative apy", tomorrow, []RateTier{{"0", "-1"}}, ErrInvalidRateSchedule},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockWealthRepository)
+			service := NewWealthService(mockRepo, nil, nil, NewMockUnitOfWork(mockRepo, nil, nil))
+
+			mockRepo.On("GetProductByID", mock.Anything, int64(1)).Return(&repository.WealthProductModel{ID: 1}, nil)
+			var saved []*repository.WealthRateModel
+			mockRepo.On("SaveProductRates", mock.Anything, int64(1), tt.date, mock.Anything).Run(func(args mock.Arguments) {
+				saved = args.Get(3).([]*repository.WealthRateModel)
+			}).Return(nil)
+
+			err := service.SetProductRates(context.Background(), 1, tt.date, tt.tiers)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.False(t, wasCalled(&mockRepo.Mock, "SaveProductRates"))
+				return
+			}
+			assert.NoError(t, err)
+			if assert.Len(t, saved, 2) {
+				assert.Equal(t, money.MustParse("10000"), saved[1].TierFloor)
+				assert.Equal(t, money.MustParse("4"), saved[1].APY)
+			}
+		})
+	}
+}
+
+func TestWealthService_GetProductRates_GroupsByEffectiveDate(t *testing.T) {
+	mockRepo := new(MockWealthRepository)
+	service := NewWealthService(mockRepo, nil, nil, nil)
+
+	mockRepo.On("GetProductByID", mock.Anything, int64(1)).Return(&repository.WealthProductModel{ID: 1}, nil)
+	mockRepo.On("ListProductRates", mock.Anything, int64(1)).Return([]*repository.WealthRateModel{
+		{EffectiveDate: "2026-03-01", TierFloor: money.Zero, APY: money.MustParse("8")},
+		{EffectiveDate: "2026-03-01", TierFloor: money.MustParse("10000"), APY: money.MustParse("4")},
+		{EffectiveDate: "1970-01-01", TierFloor: money.Zero, APY: money.MustParse("5.5")},
+	}, nil)
+
+	schedules, err := service.GetProductRates(context.Background(), 1)
+
+	assert.NoError(t, err)
+	assert.Len(t, schedules, 2)
+	assert.Equal(t, "2026-03-01", schedules[0].EffectiveDate)
+	assert.Len(t, schedules[0].Tiers, 2)
+	assert.Equal(t, []RateTier{{Floor: "0", APY: "5.5"}}, schedules[1].Tiers)
+}
+
+// stubQuote 让 mockRepo 返回一份未使用、未过期的报价 "q-1"，返回值可在下单前调整
+func stubQuote(mockRepo *MockWealthRepository, productID int64, amount string) *repository.WealthQuoteModel {
+	quote := &repository.WealthQuoteModel{
+		ID:               "q-1",
+		UserID:           1,
+		ProductID:        productID,
+		Amount:           money.MustParse(amount),
+		InterestExpected: money.MustParse("5.27"),
+		StartDate:        "2026-03-02",
+		EndDate:          "2026-03-09",
+		ExpiresAt:        time.Now().Add(time.Minute),
+	}
+	mockRepo.On("GetQuote", mock.Anything, "q-1").Return(quote, nil)
+	mockRepo.On("UseQuote", mock.Anything, "q-1", int64(1)).Return(nil)
+	return quote
+}
+
 func TestWealthService_Subscribe_InsufficientBalance(t *testing.T) {
 	mockRepo := new(MockWealthRepository)
 	mockAccountRepo := new(MockAccountRepository)
 	mockJournalRepo := new(MockJournalRepository)
-	service := NewWealthService(mockRepo, mockAccountRepo, mockJournalRepo)
+	service := NewWealthService(mockRepo, mockAccountRepo, mockJournalRepo, NewMockUnitOfWork(mockRepo, mockAccountRepo, mockJournalRepo))
 
 	now := time.Now().Format(time.RFC3339)
 	mockRepo.On("GetProductByID", mock.Anything, int64(1)).Return(&repository.WealthProductModel{
 		ID:               1,
 		Title:            "USDT 7日增值",
 		Currency:         "USDT",
-		APY:              "5.5",
+		APY:              money.MustParse("5.5"),
 		Duration:         7,
-		MinAmount:        "100",
-		MaxAmount:        "50000",
-		TotalQuota:       "100000",
-		SoldQuota:        "50000",
+		MinAmount:        money.MustParse("100"),
+		MaxAmount:        money.MustParse("50000"),
+		TotalQuota:       money.MustParse("100000"),
+		SoldQuota:        money.MustParse("50000"),
 		Status:           1,
 		AutoRenewAllowed: true,
 		CreatedAt:        now,
@@ -70,11 +178,12 @@ func TestWealthService_Subscribe_InsufficientBalance(t *testing.T) {
 		UserID:        1,
 		Type:          "FUND",
 		Currency:      "USDT",
-		Balance:       "100",
-		FrozenBalance: "0",
+		Balance:       money.MustParse("100"),
+		FrozenBalance: money.MustParse("0"),
 	}, nil)
 
-	_, err := service.Subscribe(context.Background(), 1, 1, "5000", false, "")
+	stubQuote(mockRepo, 1, "5000")
+	_, err := service.Subscribe(context.Background(), 1, "q-1", false, false)
 
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "insufficient")
@@ -83,20 +192,520 @@ func TestWealthService_Subscribe_InsufficientBalance(t *testing.T) {
 func TestWealthService_Subscribe_ProductNotFound(t *testing.T) {
 	mockRepo := new(MockWealthRepository)
 	mockJournalRepo := new(MockJournalRepository)
-	service := NewWealthService(mockRepo, nil, mockJournalRepo)
+	service := NewWealthService(mockRepo, nil, mockJournalRepo, NewMockUnitOfWork(mockRepo, nil, mockJournalRepo))
 
 	mockRepo.On("GetProductByID", mock.Anything, int64(999)).Return(nil, repository.ErrNotFound)
 
-	_, err := service.Subscribe(context.Background(), 1, 999, "1000", false, "")
+	stubQuote(mockRepo, 999, "1000")
+	_, err := service.Subscribe(context.Background(), 1, "q-1", false, false)
 
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "product")
 }
 
+func TestWealthService_Subscribe_OutsideWindow(t *testing.T) {
+	mockRepo := new(MockWealthRepository)
+	service := NewWealthService(mockRepo, nil, nil, NewMockUnitOfWork(mockRepo, nil, nil))
+
+	opensAt := time.Now().Add(24 * time.Hour)
+	mockRepo.On("GetProductByID", mock.Anything, int64(1)).Return(&repository.WealthProductModel{
+		ID:               1,
+		Currency:         "USDT",
+		Status:           repository.WealthProductStatusActive,
+		SubscribeStartAt: &opensAt,
+	}, nil)
+
+	stubQuote(mockRepo, 1, "5000")
+	_, err := service.Subscribe(context.Background(), 1, "q-1", false, false)
+
+	assert.ErrorIs(t, err, ErrProductNotAvailable)
+	assert.False(t, wasCalled(&mockRepo.Mock, "CreateOrder"))
+}
+
+func TestWealthService_Subscribe_QuotaTakenConcurrently(t *testing.T) {
+	mockRepo := new(MockWealthRepository)
+	mockAccountRepo := new(MockAccountRepository)
+	mockJournalRepo := new(MockJournalRepository)
+	uow := NewMockUnitOfWork(mockRepo, mockAccountRepo, mockJournalRepo)
+	service := NewWealthService(mockRepo, mockAccountRepo, mockJournalRepo, uow)
+
+	// 读到的已售额度还有余量，但占用时已被并发申购抢完
+	mockRepo.On("GetProductByID", mock.Anything, int64(1)).Return(&repository.WealthProductModel{
+		ID:         1,
+		Currency:   "USDT",
+		Duration:   7,
+		MinAmount:  money.MustParse("100"),
+		MaxAmount:  money.MustParse("50000"),
+		TotalQuota: money.MustParse("100000"),
+		SoldQuota:  money.MustParse("95000"),
+		Status:     repository.WealthProductStatusActive,
+	}, nil)
+	mockAccountRepo.On("GetAccountByUserIDAndCurrency", mock.Anything, int64(1), "USDT").Return(&repository.AccountModel{
+		ID:       1,
+		UserID:   1,
+		Currency: "USDT",
+		Balance:  money.MustParse("10000"),
+	}, nil)
+	mockRepo.On("ReserveProductQuota", mock.Anything, int64(1), money.MustParse("5000")).Return(repository.ErrQuotaExceeded)
+
+	stubQuote(mockRepo, 1, "5000")
+	_, err := service.Subscribe(context.Background(), 1, "q-1", false, false)
+
+	assert.ErrorIs(t, err, ErrQuotaExceeded)
+	assert.Equal(t, 1, uow.Rollbacks)
+	assert.False(t, wasCalled(&mockAccountRepo.Mock, "FreezeBalance"))
+	assert.False(t, wasCalled(&mockRepo.Mock, "CreateOrder"))
+}
+
+func TestWealthService_Subscribe_StoresCompoundFlag(t *testing.T) {
+	tests := []struct {
+		name      string
+		autoRenew bool
+		compound  bool
+		expected  bool
+	}{
+		{name: "auto-renew with compounding", autoRenew: true, compound: true, expected: true},
+		{name: "compounding ignored without auto-renew", autoRenew: false, compound: true, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockWealthRepository)
+			mockAccountRepo := new(MockAccountRepository)
+			mockJournalRepo := new(MockJournalRepository)
+			service := NewWealthService(mockRepo, mockAccountRepo, mockJournalRepo, NewMockUnitOfWork(mockRepo, mockAccountRepo, mockJournalRepo))
+
+			mockRepo.On("GetProductByID", mock.Anything, int64(1)).Return(&repository.WealthProductModel{
+				ID:               1,
+				Currency:         "USDT",
+				APY:              money.MustParse("5.5"),
+				Duration:         7,
+				MinAmount:        money.MustParse("100"),
+				MaxAmount:        money.MustParse("50000"),
+				TotalQuota:       money.MustParse("100000"),
+				SoldQuota:        money.Zero,
+				Status:           1,
+				AutoRenewAllowed: true,
+			}, nil)
+			mockAccountRepo.On("GetAccountByUserIDAndCurrency", mock.Anything, int64(1), "USDT").Return(&repository.AccountModel{
+				ID:       1,
+				UserID:   1,
+				Currency: "USDT",
+				Balance:  money.MustParse("10000"),
+			}, nil)
+			var created *repository.WealthOrderModel
+			mockAccountRepo.On("FreezeBalance", mock.Anything, int64(1), money.MustParse("5000")).Return(nil)
+			mockRepo.On("CreateOrder", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+				created = args.Get(1).(*repository.WealthOrderModel)
+				created.ID = 3
+			}).Return(nil)
+			mockRepo.On("ReserveProductQuota", mock.Anything, int64(1), money.MustParse("5000")).Return(nil)
+			mockJournalRepo.On("CreateJournalRecord", mock.Anything, mock.Anything).Return(nil)
+
+			stubQuote(mockRepo, 1, "5000")
+			_, err := service.Subscribe(context.Background(), 1, "q-1", tt.autoRenew, tt.compound)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.autoRenew, created.AutoRenew)
+			assert.Equal(t, tt.expected, created.AutoRenewCompound)
+		})
+	}
+}
+
+func TestWealthService_SetAutoRenewCompound(t *testing.T) {
+	maturity := time.Now().AddDate(0, 0, 30).Format("2006-01-02")
+	tests := []struct {
+		name          string
+		order         *repository.WealthOrderModel
+		renewAllowed  bool
+		compound      bool
+		wantAutoRenew bool
+		expectedErr   error
+	}{
+		{
+			name:          "enable compounding turns on auto-renew",
+			order:         &repository.WealthOrderModel{ID: 5, UserID: 1, ProductID: 1, EndDate: maturity, Status: 1},
+			renewAllowed:  true,
+			compound:      true,
+			wantAutoRenew: true,
+		},
+		{
+			name:          "disable compounding keeps auto-renew",
+			order:         &repository.WealthOrderModel{ID: 5, UserID: 1, ProductID: 1, EndDate: maturity, Status: 1, AutoRenew: true, AutoRenewCompound: true},
+			renewAllowed:  true,
+			compound:      false,
+			wantAutoRenew: true,
+		},
+		{
+			name:         "other user's order",
+			order:        &repository.WealthOrderModel{ID: 5, UserID: 2, ProductID: 1, EndDate: maturity, Status: 1},
+			renewAllowed: true,
+			compound:     true,
+			expectedErr:  ErrOrderNotFound,
+		},
+		{
+			name:         "settled order",
+			order:        &repository.WealthOrderModel{ID: 5, UserID: 1, ProductID: 1, EndDate: maturity, Status: 3},
+			renewAllowed: true,
+			compound:     true,
+			expectedErr:  ErrOrderAlreadyRedeemed,
+		},
+		{
+			name:         "product disallows auto-renew",
+			order:        &repository.WealthOrderModel{ID: 5, UserID: 1, ProductID: 1, EndDate: maturity, Status: 1},
+			renewAllowed: false,
+			compound:     true,
+			expectedErr:  ErrOrderNotRenewable,
+		},
+		{
+			name:         "flexible order",
+			order:        &repository.WealthOrderModel{ID: 5, UserID: 1, ProductID: 1, EndDate: maturity, Status: 1, ProductType: repository.WealthProductTypeFlexible},
+			renewAllowed: true,
+			compound:     true,
+			expectedErr:  ErrOrderNotRenewable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockWealthRepository)
+			service := NewWealthService(mockRepo, nil, nil, NewMockUnitOfWork(mockRepo, nil, nil))
+
+			mockRepo.On("GetOrderByID", mock.Anything, int64(5)).Return(tt.order, nil)
+			mockRepo.On("GetProductByID", mock.Anything, int64(1)).Return(&repository.WealthProductModel{ID: 1, AutoRenewAllowed: tt.renewAllowed}, nil)
+			mockRepo.On("UpdateRenewalOptions", mock.Anything, int64(5), tt.wantAutoRenew, tt.compound).Return(nil)
+			mockRepo.On("CreateOrderAudit", mock.Anything, mock.MatchedBy(func(a *repository.WealthOrderAuditModel) bool {
+				return a.OrderID == 5 && a.UserID == int64(tt.order.UserID) && a.Action == repository.WealthAuditAutoRenewCompound &&
+					a.NewValue == fmt.Sprintf(`{"autoRenew":%t,"compound":%t}`, tt.wantAutoRenew, tt.compound)
+			})).Return(nil)
+
+			err := service.SetAutoRenewCompound(context.Background(), 1, 5, tt.compound)
+
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				assert.False(t, wasCalled(&mockRepo.Mock, "UpdateRenewalOptions"))
+				return
+			}
+			assert.NoError(t, err)
+			mockRepo.AssertCalled(t, "UpdateRenewalOptions", mock.Anything, int64(5), tt.wantAutoRenew, tt.compound)
+			mockRepo.AssertCalled(t, "CreateOrderAudit", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestWealthService_SetAutoRenew(t *testing.T) {
+	maturity := time.Now().AddDate(0, 0, 30).Format("2006-01-02")
+	tomorrow := time.Now().In(config.GetLocation()).AddDate(0, 0, 1).Format("2006-01-02")
+	tests := []struct {
+		name         string
+		order        *repository.WealthOrderModel
+		renewAllowed bool
+		autoRenew    bool
+		wantCompound bool
+		expectedErr  error
+	}{
+		{
+			name:         "enable auto-renew",
+			order:        &repository.WealthOrderModel{ID: 5, UserID: 1, ProductID: 1, EndDate: maturity, Status: 1},
+			renewAllowed: true,
+			autoRenew:    true,
+		},
+		{
+			name:         "enable keeps compounding choice",
+			order:        &repository.WealthOrderModel{ID: 5, UserID: 1, ProductID: 1, EndDate: maturity, Status: 1, AutoRenewCompound: true},
+			renewAllowed: true,
+			autoRenew:    true,
+			wantCompound: true,
+		},
+		{
+			name:         "disable clears compounding",
+			order:        &repository.WealthOrderModel{ID: 5, UserID: 1, ProductID: 1, EndDate: maturity, Status: 1, AutoRenew: true, AutoRenewCompound: true},
+			renewAllowed: true,
+			autoRenew:    false,
+		},
+		{
+			name:         "disable allowed after product stops renewals",
+			order:        &repository.WealthOrderModel{ID: 5, UserID: 1, ProductID: 1, EndDate: maturity, Status: 1, AutoRenew: true},
+			renewAllowed: false,
+			autoRenew:    false,
+		},
+		{
+			name:         "enable rejected when product disallows",
+			order:        &repository.WealthOrderModel{ID: 5, UserID: 1, ProductID: 1, EndDate: maturity, Status: 1},
+			renewAllowed: false,
+			autoRenew:    true,
+			expectedErr:  ErrOrderNotRenewable,
+		},
+		{
+			name:         "past cutoff before maturity",
+			order:        &repository.WealthOrderModel{ID: 5, UserID: 1, ProductID: 1, EndDate: tomorrow, Status: 1},
+			renewAllowed: true,
+			autoRenew:    true,
+			expectedErr:  ErrRenewalCutoffPassed,
+		},
+		{
+			name:         "cancelled order",
+			order:        &repository.WealthOrderModel{ID: 5, UserID: 1, ProductID: 1, EndDate: maturity, Status: repository.WealthOrderStatusCancelled},
+			renewAllowed: true,
+			autoRenew:    true,
+			expectedErr:  ErrOrderAlreadyRedeemed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockWealthRepository)
+			service := NewWealthService(mockRepo, nil, nil, NewMockUnitOfWork(mockRepo, nil, nil))
+
+			mockRepo.On("GetOrderByID", mock.Anything, int64(5)).Return(tt.order, nil)
+			mockRepo.On("GetProductByID", mock.Anything, int64(1)).Return(&repository.WealthProductModel{ID: 1, AutoRenewAllowed: tt.renewAllowed}, nil)
+			mockRepo.On("UpdateRenewalOptions", mock.Anything, int64(5), tt.autoRenew, tt.wantCompound).Return(nil)
+			mockRepo.On("CreateOrderAudit", mock.Anything, mock.MatchedBy(func(a *repository.WealthOrderAuditModel) bool {
+				return a.OrderID == 5 && a.Action == repository.WealthAuditAutoRenew &&
+					a.OldValue == fmt.Sprintf(`{"autoRenew":%t,"compound":%t}`, tt.order.AutoRenew, tt.order.AutoRenewCompound) &&
+					a.NewValue == fmt.Sprintf(`{"autoRenew":%t,"compound":%t}`, tt.autoRenew, tt.wantCompound)
+			})).Return(nil)
+
+			err := service.SetAutoRenew(context.Background(), 1, 5, tt.autoRenew)
+
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				assert.False(t, wasCalled(&mockRepo.Mock, "UpdateRenewalOptions"))
+				return
+			}
+			assert.NoError(t, err)
+			mockRepo.AssertCalled(t, "UpdateRenewalOptions", mock.Anything, int64(5), tt.autoRenew, tt.wantCompound)
+			mockRepo.AssertCalled(t, "CreateOrderAudit", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestWealthService_CancelOrder_UnfreezesPendingOrder(t *testing.T) {
+	mockRepo := new(MockWealthRepository)
+	mockAccountRepo := new(MockAccountRepository)
+	mockJournalRepo := new(MockJournalRepository)
+	uow := NewMockUnitOfWork(mockRepo, mockAccountRepo, mockJournalRepo)
+	service := NewWealthService(mockRepo, mockAccountRepo, mockJournalRepo, uow)
+
+	tomorrow := time.Now().In(config.GetLocation()).AddDate(0, 0, 1).Format("2006-01-02")
+	mockRepo.On("GetOrderByID", mock.Anything, int64(5)).Return(&repository.WealthOrderModel{
+		ID:        5,
+		UserID:    1,
+		ProductID: 1,
+		Currency:  "USDT",
+		Amount:    money.MustParse("5000"),
+		StartDate: tomorrow,
+		EndDate:   time.Now().AddDate(0, 0, 8).Format("2006-01-02"),
+		Status:    1,
+	}, nil)
+	mockAccountRepo.On("GetAccountByUserIDAndCurrency", mock.Anything, int64(1), "USDT").Return(&repository.AccountModel{
+		ID:            1,
+		UserID:        1,
+		Currency:      "USDT",
+		Balance:       money.MustParse("8000"),
+		FrozenBalance: money.MustParse("5000"),
+	}, nil)
+	var journal *repository.JournalModel
+	mockRepo.On("CancelOrder", mock.Anything, int64(5)).Return(nil)
+	mockAccountRepo.On("UnfreezeBalance", mock.Anything, int64(1), money.MustParse("5000")).Return(nil)
+	mockJournalRepo.On("CreateJournalRecord", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		journal = args.Get(1).(*repository.JournalModel)
+	}).Return(nil)
+	mockRepo.On("ReleaseProductQuota", mock.Anything, int64(1), money.MustParse("5000")).Return(nil)
+
+	err := service.CancelOrder(context.Background(), 1, 5)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, uow.Commits)
+	assert.Equal(t, "CANCEL_UNFREEZE", journal.BizType)
+	assert.Equal(t, money.MustParse("5000"), journal.Amount)
+	assert.Equal(t, money.MustParse("8000"), journal.BalanceSnapshot)
+	assert.False(t, wasCalled(&mockAccountRepo.Mock, "DeductBalance"))
+}
+
+func TestWealthService_CancelOrder_Rejected(t *testing.T) {
+	today := time.Now().In(config.GetLocation()).Format("2006-01-02")
+	tomorrow := time.Now().In(config.GetLocation()).AddDate(0, 0, 1).Format("2006-01-02")
+	tests := []struct {
+		name        string
+		order       *repository.WealthOrderModel
+		expectedErr error
+	}{
+		{"already started", &repository.WealthOrderModel{ID: 5, UserID: 1, StartDate: today, Status: 1}, ErrOrderAlreadyStarted},
+		{"flexible order", &repository.WealthOrderModel{ID: 5, UserID: 1, StartDate: tomorrow, Status: 1, ProductType: repository.WealthProductTypeFlexible}, ErrOrderAlreadyStarted},
+		{"other user's order", &repository.WealthOrderModel{ID: 5, UserID: 2, StartDate: tomorrow, Status: 1}, ErrOrderNotFound},
+		{"already cancelled", &repository.WealthOrderModel{ID: 5, UserID: 1, StartDate: tomorrow, Status: repository.WealthOrderStatusCancelled}, ErrOrderAlreadyRedeemed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockWealthRepository)
+			mockAccountRepo := new(MockAccountRepository)
+			service := NewWealthService(mockRepo, mockAccountRepo, nil, NewMockUnitOfWork(mockRepo, mockAccountRepo, nil))
+
+			mockRepo.On("GetOrderByID", mock.Anything, int64(5)).Return(tt.order, nil)
+
+			err := service.CancelOrder(context.Background(), 1, 5)
+
+			assert.ErrorIs(t, err, tt.expectedErr)
+			assert.False(t, wasCalled(&mockRepo.Mock, "CancelOrder"))
+			assert.False(t, wasCalled(&mockAccountRepo.Mock, "UnfreezeBalance"))
+		})
+	}
+}
+
+func newFlexibleSubscribeFixture(existing []*repository.WealthOrderModel) (*WealthService, *MockWealthRepository, *MockAccountRepository, *MockJournalRepository) {
+	mockRepo := new(MockWealthRepository)
+	mockAccountRepo := new(MockAccountRepository)
+	mockJournalRepo := new(MockJournalRepository)
+	service := NewWealthService(mockRepo, mockAccountRepo, mockJournalRepo, NewMockUnitOfWork(mockRepo, mockAccountRepo, mockJournalRepo))
+
+	mockRepo.On("GetProductByID", mock.Anything, int64(2)).Return(&repository.WealthProductModel{
+		ID:          2,
+		Title:       "USDT 活期",
+		Currency:    "USDT",
+		APY:         money.MustParse("3"),
+		ProductType: repository.WealthProductTypeFlexible,
+		MinAmount:   money.MustParse("10"),
+		MaxAmount:   money.MustParse("20000"),
+		TotalQuota:  money.MustParse("1000000"),
+		SoldQuota:   money.MustParse("0"),
+		Status:      1,
+	}, nil)
+	mockRepo.On("GetOrdersByUserID", mock.Anything, int64(1)).Return(existing, nil)
+	mockAccountRepo.On("GetAccountByUserIDAndCurrency", mock.Anything, int64(1), "USDT").Return(&repository.AccountModel{
+		ID:       1,
+		UserID:   1,
+		Currency: "USDT",
+		Balance:  money.MustParse("50000"),
+	}, nil)
+	return service, mockRepo, mockAccountRepo, mockJournalRepo
+}
+
+func TestWealthService_Subscribe_FlexibleCreatesOrderStartingToday(t *testing.T) {
+	service, mockRepo, mockAccountRepo, mockJournalRepo := newFlexibleSubscribeFixture(nil)
+
+	var created *repository.WealthOrderModel
+	mockAccountRepo.On("FreezeBalance", mock.Anything, int64(1), money.MustParse("500")).Return(nil)
+	mockRepo.On("CreateOrder", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		created = args.Get(1).(*repository.WealthOrderModel)
+		created.ID = 9
+	}).Return(nil)
+	mockRepo.On("ReserveProductQuota", mock.Anything, int64(2), money.MustParse("500")).Return(nil)
+	mockJournalRepo.On("CreateJournalRecord", mock.Anything, mock.AnythingOfType("*repository.JournalModel")).Return(nil)
+
+	today := time.Now().In(config.GetLocation()).Format("2006-01-02")
+	quote := stubQuote(mockRepo, 2, "500")
+	quote.StartDate, quote.EndDate, quote.InterestExpected = today, "", money.Zero
+	orderID, err := service.Subscribe(context.Background(), 1, "q-1", true, false)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "9", orderID)
+	assert.Equal(t, today, created.StartDate)
+	assert.Equal(t, "", created.EndDate)
+	assert.False(t, created.AutoRenew)
+	assert.True(t, created.InterestExpected.IsZero())
+}
+
+func TestWealthService_Subscribe_FlexibleTopsUpHolding(t *testing.T) {
+	holding := &repository.WealthOrderModel{
+		ID:                7,
+		UserID:            1,
+		ProductID:         2,
+		ProductType:       repository.WealthProductTypeFlexible,
+		Amount:            money.MustParse("15000"),
+		PrincipalRedeemed: money.MustParse("1000"),
+		Status:            1,
+	}
+	service, mockRepo, mockAccountRepo, mockJournalRepo := newFlexibleSubscribeFixture([]*repository.WealthOrderModel{holding})
+
+	var journal *repository.JournalModel
+	mockAccountRepo.On("FreezeBalance", mock.Anything, int64(1), money.MustParse("6000")).Return(nil)
+	mockRepo.On("AddPrincipal", mock.Anything, int64(7), money.MustParse("6000")).Return(nil)
+	mockRepo.On("ReserveProductQuota", mock.Anything, int64(2), money.MustParse("6000")).Return(nil)
+	mockJournalRepo.On("CreateJournalRecord", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		journal = args.Get(1).(*repository.JournalModel)
+	}).Return(nil)
+
+	stubQuote(mockRepo, 2, "6000")
+	orderID, err := service.Subscribe(context.Background(), 1, "q-1", false, false)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "7", orderID)
+	assert.False(t, wasCalled(&mockRepo.Mock, "CreateOrder"))
+	assert.Equal(t, "SUBSCRIBE_FREEZE", journal.BizType)
+	assert.Equal(t, int64(7), *journal.RefID)
+}
+
+func TestWealthService_Subscribe_FlexibleHoldingAboveMax(t *testing.T) {
+	holding := &repository.WealthOrderModel{
+		ID:          7,
+		UserID:      1,
+		ProductID:   2,
+		ProductType: repository.WealthProductTypeFlexible,
+		Amount:      money.MustParse("15000"),
+		Status:      1,
+	}
+	service, mockRepo, _, _ := newFlexibleSubscribeFixture([]*repository.WealthOrderModel{holding})
+
+	stubQuote(mockRepo, 2, "6000")
+	_, err := service.Subscribe(context.Background(), 1, "q-1", false, false)
+
+	assert.ErrorIs(t, err, ErrAmountAboveMax)
+	assert.False(t, wasCalled(&mockRepo.Mock, "AddPrincipal"))
+}
+
+func TestWealthService_Redeem_FlexibleWithdrawal(t *testing.T) {
+	mockRepo := new(MockWealthRepository)
+	mockAccountRepo := new(MockAccountRepository)
+	mockJournalRepo := new(MockJournalRepository)
+	service := NewWealthService(mockRepo, mockAccountRepo, mockJournalRepo, NewMockUnitOfWork(mockRepo, mockAccountRepo, mockJournalRepo))
+
+	mockRepo.On("GetOrderByID", mock.Anything, int64(7)).Return(&repository.WealthOrderModel{
+		ID:              7,
+		UserID:          1,
+		ProductID:       2,
+		ProductType:     repository.WealthProductTypeFlexible,
+		Currency:        "USDT",
+		Amount:          money.MustParse("8000"),
+		InterestAccrued: money.MustParse("4"),
+		StartDate:       time.Now().AddDate(0, 0, -30).Format("2006-01-02"),
+		Status:          1,
+	}, nil)
+	mockAccountRepo.On("GetAccountByUserIDAndCurrency", mock.Anything, int64(1), "USDT").Return(&repository.AccountModel{
+		ID:            1,
+		UserID:        1,
+		Currency:      "USDT",
+		Balance:       money.MustParse("10000"),
+		FrozenBalance: money.MustParse("8000"),
+	}, nil)
+
+	var updatedOrder *repository.WealthOrderModel
+	mockAccountRepo.On("UnfreezeBalance", mock.Anything, int64(1), money.MustParse("2000")).Return(nil)
+	mockAccountRepo.On("AddBalance", mock.Anything, int64(1), money.MustParse("1")).Return(nil)
+	mockJournalRepo.On("CreateJournalRecord", mock.Anything, mock.AnythingOfType("*repository.JournalModel")).Return(nil)
+	mockRepo.On("ReleaseProductQuota", mock.Anything, int64(2), money.MustParse("2000")).Return(nil)
+	mockRepo.On("UpdateOrder", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		updatedOrder = args.Get(1).(*repository.WealthOrderModel)
+	}).Return(nil)
+
+	expectLockedReread(mockRepo, int64(7))
+	quote, err := service.Redeem(context.Background(), 1, 7, "partial", "2000")
+
+	assert.NoError(t, err)
+	// 活期随时赎回不算提前，利息按比例足额派发，不查询提前赎回规则
+	assert.False(t, quote.IsEarly)
+	assert.Equal(t, "2001", quote.Payout)
+	assert.False(t, wasCalled(&mockRepo.Mock, "GetProductByID"))
+	assert.Equal(t, 1, updatedOrder.Status)
+	assert.Equal(t, money.MustParse("3"), updatedOrder.InterestAccrued)
+	mockRepo.AssertExpectations(t)
+}
+
 func TestWealthService_GetOrders(t *testing.T) {
 	mockRepo := new(MockWealthRepository)
 	mockJournalRepo := new(MockJournalRepository)
-	service := NewWealthService(mockRepo, nil, mockJournalRepo)
+	service := NewWealthService(mockRepo, nil, mockJournalRepo, NewMockUnitOfWork(mockRepo, nil, mockJournalRepo))
 
 	now := time.Now().Format(time.RFC3339)
 	mockRepo.On("GetOrdersByUserID", mock.Anything, int64(1)).Return([]*repository.WealthOrderModel{
@@ -105,10 +714,10 @@ func TestWealthService_GetOrders(t *testing.T) {
 			UserID:           1,
 			ProductID:        1,
 			ProductTitle:     "USDT 7日增值",
-			Amount:           "5000",
-			InterestExpected: "52.88",
-			InterestPaid:     "0",
-			InterestAccrued:  "18.21",
+			Amount:           money.MustParse("5000"),
+			InterestExpected: money.MustParse("52.88"),
+			InterestPaid:     money.MustParse("0"),
+			InterestAccrued:  money.MustParse("18.21"),
 			StartDate:        "2026-01-10",
 			EndDate:          "2026-01-17",
 			AutoRenew:        false,
@@ -127,14 +736,56 @@ func TestWealthService_GetOrders(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+func TestWealthService_GetInterestHistory(t *testing.T) {
+	mockRepo := new(MockWealthRepository)
+	mockJournalRepo := new(MockJournalRepository)
+	service := NewWealthService(mockRepo, nil, mockJournalRepo, NewMockUnitOfWork(mockRepo, nil, mockJournalRepo))
+
+	mockRepo.On("GetOrderByID", mock.Anything, int64(1)).Return(&repository.WealthOrderModel{
+		ID:     1,
+		UserID: 1,
+		Status: 1,
+	}, nil)
+	mockRepo.On("GetInterestRecords", mock.Anything, int64(1)).Return([]*repository.InterestRecordModel{
+		{ID: 1, OrderID: 1, Amount: money.MustParse("1.506849"), Type: repository.InterestRecordTypeAccrual, Date: "2026-01-11"},
+		{ID: 2, OrderID: 1, Amount: money.MustParse("1.506849"), Type: repository.InterestRecordTypeAccrual, Date: "2026-01-12"},
+	}, nil)
+
+	records, err := service.GetInterestHistory(context.Background(), 1, 1)
+
+	assert.NoError(t, err)
+	assert.Len(t, records, 2)
+	assert.Equal(t, "2026-01-11", records[0].Date)
+	assert.Equal(t, "1.506849", records[0].Amount)
+	assert.Equal(t, repository.InterestRecordTypeAccrual, records[1].Type)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestWealthService_GetInterestHistory_OtherUsersOrder(t *testing.T) {
+	mockRepo := new(MockWealthRepository)
+	mockJournalRepo := new(MockJournalRepository)
+	service := NewWealthService(mockRepo, nil, mockJournalRepo, NewMockUnitOfWork(mockRepo, nil, mockJournalRepo))
+
+	mockRepo.On("GetOrderByID", mock.Anything, int64(1)).Return(&repository.WealthOrderModel{
+		ID:     1,
+		UserID: 2,
+	}, nil)
+
+	records, err := service.GetInterestHistory(context.Background(), 1, 1)
+
+	assert.ErrorIs(t, err, ErrOrderNotFound)
+	assert.Nil(t, records)
+	assert.False(t, wasCalled(&mockRepo.Mock, "GetInterestRecords"))
+}
+
 func TestWealthService_Redeem_OrderNotFound(t *testing.T) {
 	mockRepo := new(MockWealthRepository)
 	mockJournalRepo := new(MockJournalRepository)
-	service := NewWealthService(mockRepo, nil, mockJournalRepo)
+	service := NewWealthService(mockRepo, nil, mockJournalRepo, NewMockUnitOfWork(mockRepo, nil, mockJournalRepo))
 
 	mockRepo.On("GetOrderByID", mock.Anything, int64(999)).Return(nil, repository.ErrNotFound)
 
-	err := service.Redeem(context.Background(), 1, 999, "")
+	_, err := service.Redeem(context.Background(), 1, 999, "", "")
 
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "order")
@@ -143,7 +794,7 @@ func TestWealthService_Redeem_OrderNotFound(t *testing.T) {
 func TestWealthService_Redeem_AlreadyRedeemed(t *testing.T) {
 	mockRepo := new(MockWealthRepository)
 	mockJournalRepo := new(MockJournalRepository)
-	service := NewWealthService(mockRepo, nil, mockJournalRepo)
+	service := NewWealthService(mockRepo, nil, mockJournalRepo, NewMockUnitOfWork(mockRepo, nil, mockJournalRepo))
 
 	now := time.Now().Format(time.RFC3339)
 	mockRepo.On("GetOrderByID", mock.Anything, int64(1)).Return(&repository.WealthOrderModel{
@@ -151,19 +802,19 @@ func TestWealthService_Redeem_AlreadyRedeemed(t *testing.T) {
 		UserID:           1,
 		ProductID:        1,
 		ProductTitle:     "USDT 7日增值",
-		Amount:           "5000",
-		InterestExpected: "52.88",
-		InterestPaid:     "52.88",
-		InterestAccrued:  "0",
+		Amount:           money.MustParse("5000"),
+		InterestExpected: money.MustParse("52.88"),
+		InterestPaid:     money.MustParse("52.88"),
+		InterestAccrued:  money.MustParse("0"),
 		StartDate:        "2026-01-10",
 		EndDate:          "2026-01-17",
 		AutoRenew:        false,
 		Status:           3,
-		RedemptionAmount: "5000",
+		RedemptionAmount: money.MustParse("5000"),
 		CreatedAt:        now,
 	}, nil)
 
-	err := service.Redeem(context.Background(), 1, 1, "")
+	_, err := service.Redeem(context.Background(), 1, 1, "", "")
 
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "redeemed")
@@ -173,7 +824,7 @@ func TestWealthService_Redeem_Success(t *testing.T) {
 	mockRepo := new(MockWealthRepository)
 	mockAccountRepo := new(MockAccountRepository)
 	mockJournalRepo := new(MockJournalRepository)
-	service := NewWealthService(mockRepo, mockAccountRepo, mockJournalRepo)
+	service := NewWealthService(mockRepo, mockAccountRepo, mockJournalRepo, NewMockUnitOfWork(mockRepo, mockAccountRepo, mockJournalRepo))
 
 	now := time.Now().Format(time.RFC3339)
 	pastDate := time.Now().AddDate(0, 0, -10).Format("2006-01-02")
@@ -185,10 +836,10 @@ func TestWealthService_Redeem_Success(t *testing.T) {
 		ProductID:        1,
 		ProductTitle:     "USDT 7日增值",
 		Currency:         "USDT",
-		Amount:           "5000",
-		InterestExpected: "52.88",
-		InterestPaid:     "0",
-		InterestAccrued:  "18.21",
+		Amount:           money.MustParse("5000"),
+		InterestExpected: money.MustParse("52.88"),
+		InterestPaid:     money.MustParse("0"),
+		InterestAccrued:  money.MustParse("18.21"),
 		StartDate:        pastDate,
 		EndDate:          expiredDate,
 		AutoRenew:        false,
@@ -201,17 +852,19 @@ func TestWealthService_Redeem_Success(t *testing.T) {
 		UserID:        1,
 		Type:          "FUND",
 		Currency:      "USDT",
-		Balance:       "50000",
-		FrozenBalance: "5000",
+		Balance:       money.MustParse("50000"),
+		FrozenBalance: money.MustParse("5000"),
 	}, nil)
 
-	mockAccountRepo.On("UnfreezeBalance", mock.Anything, int64(1), "5000").Return(nil)
-	mockAccountRepo.On("AddBalance", mock.Anything, int64(1), "18.21").Return(nil)
+	mockAccountRepo.On("UnfreezeBalance", mock.Anything, int64(1), money.MustParse("5000")).Return(nil)
+	mockAccountRepo.On("AddBalance", mock.Anything, int64(1), money.MustParse("18.21")).Return(nil)
 
 	mockJournalRepo.On("CreateJournalRecord", mock.Anything, mock.AnythingOfType("*repository.JournalModel")).Return(nil)
+	mockRepo.On("ReleaseProductQuota", mock.Anything, int64(1), mock.Anything).Return(nil)
 	mockRepo.On("UpdateOrder", mock.Anything, mock.AnythingOfType("*repository.WealthOrderModel")).Return(nil)
 
-	err := service.Redeem(context.Background(), 1, 1, "full")
+	expectLockedReread(mockRepo, int64(1))
+	_, err := service.Redeem(context.Background(), 1, 1, "full", "")
 
 	assert.NoError(t, err)
 	mockAccountRepo.AssertExpectations(t)
@@ -219,6 +872,45 @@ func TestWealthService_Redeem_Success(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+func TestWealthService_Redeem_ConcurrentlyRedeemedRollsBack(t *testing.T) {
+	mockRepo := new(MockWealthRepository)
+	mockAccountRepo := new(MockAccountRepository)
+	mockJournalRepo := new(MockJournalRepository)
+	uow := NewMockUnitOfWork(mockRepo, mockAccountRepo, mockJournalRepo)
+	service := NewWealthService(mockRepo, mockAccountRepo, mockJournalRepo, uow)
+
+	order := repository.WealthOrderModel{
+		ID:              1,
+		UserID:          1,
+		ProductID:       1,
+		Currency:        "USDT",
+		Amount:          money.MustParse("5000"),
+		InterestAccrued: money.MustParse("18.21"),
+		StartDate:       time.Now().AddDate(0, 0, -10).Format("2006-01-02"),
+		EndDate:         time.Now().AddDate(0, 0, -1).Format("2006-01-02"),
+		Status:          1,
+	}
+	// 另一笔赎回在本次读取之后、加锁之前提交
+	redeemed := order
+	redeemed.Status = repository.WealthOrderStatusSettled
+	mockRepo.On("GetOrderByID", mock.Anything, int64(1)).Return(&order, nil)
+	mockRepo.On("GetOrderForUpdate", mock.Anything, int64(1)).Return(&redeemed, nil)
+	mockAccountRepo.On("GetAccountByUserIDAndCurrency", mock.Anything, int64(1), "USDT").Return(&repository.AccountModel{
+		ID:            1,
+		UserID:        1,
+		Currency:      "USDT",
+		Balance:       money.MustParse("50000"),
+		FrozenBalance: money.MustParse("5000"),
+	}, nil)
+
+	_, err := service.Redeem(context.Background(), 1, 1, "full", "")
+
+	assert.ErrorIs(t, err, ErrOrderAlreadyRedeemed)
+	assert.Equal(t, 1, uow.Rollbacks)
+	assert.False(t, wasCalled(&mockAccountRepo.Mock, "UnfreezeBalance"))
+	assert.False(t, wasCalled(&mockRepo.Mock, "UpdateOrder"))
+}
+
 func TestWealthService_Redeem_SetsCorrectStatus(t *testing.T) {
 	now := time.Now().Format(time.RFC3339)
 
@@ -226,7 +918,7 @@ func TestWealthService_Redeem_SetsCorrectStatus(t *testing.T) {
 		mockRepo := new(MockWealthRepository)
 		mockAccountRepo := new(MockAccountRepository)
 		mockJournalRepo := new(MockJournalRepository)
-		service := NewWealthService(mockRepo, mockAccountRepo, mockJournalRepo)
+		service := NewWealthService(mockRepo, mockAccountRepo, mockJournalRepo, NewMockUnitOfWork(mockRepo, mockAccountRepo, mockJournalRepo))
 
 		expiredDate := time.Now().AddDate(0, 0, -3).Format("2006-01-02")
 
@@ -236,8 +928,8 @@ func TestWealthService_Redeem_SetsCorrectStatus(t *testing.T) {
 			UserID:          1,
 			ProductID:       1,
 			Currency:        "USDT",
-			Amount:          "5000",
-			InterestAccrued: "18.21",
+			Amount:          money.MustParse("5000"),
+			InterestAccrued: money.MustParse("18.21"),
 			EndDate:         expiredDate,
 			Status:          1,
 			CreatedAt:       now,
@@ -247,18 +939,20 @@ func TestWealthService_Redeem_SetsCorrectStatus(t *testing.T) {
 			ID:            1,
 			UserID:        1,
 			Currency:      "USDT",
-			Balance:       "50000",
-			FrozenBalance: "5000",
+			Balance:       money.MustParse("50000"),
+			FrozenBalance: money.MustParse("5000"),
 		}, nil)
 
-		mockAccountRepo.On("UnfreezeBalance", mock.Anything, int64(1), "5000").Return(nil)
-		mockAccountRepo.On("AddBalance", mock.Anything, int64(1), "18.21").Return(nil)
+		mockAccountRepo.On("UnfreezeBalance", mock.Anything, int64(1), money.MustParse("5000")).Return(nil)
+		mockAccountRepo.On("AddBalance", mock.Anything, int64(1), money.MustParse("18.21")).Return(nil)
 		mockJournalRepo.On("CreateJournalRecord", mock.Anything, mock.AnythingOfType("*repository.JournalModel")).Return(nil)
+		mockRepo.On("ReleaseProductQuota", mock.Anything, int64(1), mock.Anything).Return(nil)
 		mockRepo.On("UpdateOrder", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
 			updatedOrder = args.Get(1).(*repository.WealthOrderModel)
 		}).Return(nil)
 
-		err := service.Redeem(context.Background(), 1, 1, "full")
+		expectLockedReread(mockRepo, int64(1))
+		_, err := service.Redeem(context.Background(), 1, 1, "full", "")
 
 		assert.NoError(t, err)
 		assert.NotNil(t, updatedOrder)
@@ -269,7 +963,7 @@ func TestWealthService_Redeem_SetsCorrectStatus(t *testing.T) {
 		mockRepo := new(MockWealthRepository)
 		mockAccountRepo := new(MockAccountRepository)
 		mockJournalRepo := new(MockJournalRepository)
-		service := NewWealthService(mockRepo, mockAccountRepo, mockJournalRepo)
+		service := NewWealthService(mockRepo, mockAccountRepo, mockJournalRepo, NewMockUnitOfWork(mockRepo, mockAccountRepo, mockJournalRepo))
 
 		futureDate := time.Now().AddDate(0, 0, 20).Format("2006-01-02")
 
@@ -279,8 +973,8 @@ func TestWealthService_Redeem_SetsCorrectStatus(t *testing.T) {
 			UserID:          1,
 			ProductID:       1,
 			Currency:        "USDT",
-			Amount:          "10000",
-			InterestAccrued: "0",
+			Amount:          money.MustParse("10000"),
+			InterestAccrued: money.MustParse("0"),
 			EndDate:         futureDate,
 			Status:          1,
 			CreatedAt:       now,
@@ -290,17 +984,19 @@ func TestWealthService_Redeem_SetsCorrectStatus(t *testing.T) {
 			ID:            2,
 			UserID:        1,
 			Currency:      "USDT",
-			Balance:       "40000",
-			FrozenBalance: "10000",
+			Balance:       money.MustParse("40000"),
+			FrozenBalance: money.MustParse("10000"),
 		}, nil)
 
-		mockAccountRepo.On("UnfreezeBalance", mock.Anything, int64(2), "10000").Return(nil)
+		mockAccountRepo.On("UnfreezeBalance", mock.Anything, int64(2), money.MustParse("10000")).Return(nil)
 		mockJournalRepo.On("CreateJournalRecord", mock.Anything, mock.AnythingOfType("*repository.JournalModel")).Return(nil)
+		mockRepo.On("ReleaseProductQuota", mock.Anything, int64(1), mock.Anything).Return(nil)
 		mockRepo.On("UpdateOrder", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
 			updatedOrder = args.Get(1).(*repository.WealthOrderModel)
 		}).Return(nil)
 
-		err := service.Redeem(context.Background(), 1, 2, "full")
+		expectLockedReread(mockRepo, int64(2))
+		_, err := service.Redeem(context.Background(), 1, 2, "full", "")
 
 		assert.NoError(t, err)
 		assert.NotNil(t, updatedOrder)
@@ -312,7 +1008,7 @@ func TestWealthService_Redeem_EarlyRedemption(t *testing.T) {
 	mockRepo := new(MockWealthRepository)
 	mockAccountRepo := new(MockAccountRepository)
 	mockJournalRepo := new(MockJournalRepository)
-	service := NewWealthService(mockRepo, mockAccountRepo, mockJournalRepo)
+	service := NewWealthService(mockRepo, mockAccountRepo, mockJournalRepo, NewMockUnitOfWork(mockRepo, mockAccountRepo, mockJournalRepo))
 
 	now := time.Now().Format(time.RFC3339)
 	today := time.Now().Format("2006-01-02")
@@ -324,13 +1020,14 @@ func TestWealthService_Redeem_EarlyRedemption(t *testing.T) {
 		ProductID:        1,
 		ProductTitle:     "USDT 30日稳健",
 		Currency:         "USDT",
-		Amount:           "10000",
-		InterestExpected: "65.75",
-		InterestPaid:     "0",
-		InterestAccrued:  "0",
+		Amount:           money.MustParse("10000"),
+		InterestExpected: money.MustParse("65.75"),
+		InterestPaid:     money.MustParse("0"),
+		InterestAccrued:  money.MustParse("0"),
 		StartDate:        today,
 		EndDate:          futureDate,
 		AutoRenew:        false,
+		EarlyRedeemRule:  repository.EarlyRedeemForfeitInterest,
 		Status:           1,
 		CreatedAt:        now,
 	}, nil)
@@ -340,18 +1037,345 @@ func TestWealthService_Redeem_EarlyRedemption(t *testing.T) {
 		UserID:        1,
 		Type:          "FUND",
 		Currency:      "USDT",
-		Balance:       "40000",
-		FrozenBalance: "10000",
+		Balance:       money.MustParse("40000"),
+		FrozenBalance: money.MustParse("10000"),
 	}, nil)
 
-	mockAccountRepo.On("UnfreezeBalance", mock.Anything, int64(2), "10000").Return(nil)
+	mockAccountRepo.On("UnfreezeBalance", mock.Anything, int64(2), money.MustParse("10000")).Return(nil)
 	mockJournalRepo.On("CreateJournalRecord", mock.Anything, mock.AnythingOfType("*repository.JournalModel")).Return(nil)
+	mockRepo.On("ReleaseProductQuota", mock.Anything, int64(1), mock.Anything).Return(nil)
 	mockRepo.On("UpdateOrder", mock.Anything, mock.AnythingOfType("*repository.WealthOrderModel")).Return(nil)
 
-	err := service.Redeem(context.Background(), 1, 2, "full")
+	expectLockedReread(mockRepo, int64(2))
+	_, err := service.Redeem(context.Background(), 1, 2, "full", "")
 
 	assert.NoError(t, err)
 	mockAccountRepo.AssertExpectations(t)
 	mockJournalRepo.AssertExpectations(t)
 	mockRepo.AssertExpectations(t)
 }
+
+// newEarlyRedeemFixture 未到期订单：本金 10000，已计利息 20
+func newEarlyRedeemFixture(rule int, value string) (*WealthService, *MockWealthRepository, *MockAccountRepository, *MockJournalRepository) {
+	mockRepo := new(MockWealthRepository)
+	mockAccountRepo := new(MockAccountRepository)
+	mockJournalRepo := new(MockJournalRepository)
+	service := NewWealthService(mockRepo, mockAccountRepo, mockJournalRepo, NewMockUnitOfWork(mockRepo, mockAccountRepo, mockJournalRepo))
+
+	mockRepo.On("GetOrderByID", mock.Anything, int64(2)).Return(&repository.WealthOrderModel{
+		ID:               2,
+		UserID:           1,
+		ProductID:        1,
+		Currency:         "USDT",
+		Amount:           money.MustParse("10000"),
+		InterestExpected: money.MustParse("60"),
+		InterestAccrued:  money.MustParse("20"),
+		StartDate:        time.Now().AddDate(0, 0, -10).Format("2006-01-02"),
+		EndDate:          time.Now().AddDate(0, 0, 20).Format("2006-01-02"),
+		EarlyRedeemRule:  rule,
+		EarlyRedeemValue: money.MustParse(value),
+		Status:           1,
+	}, nil)
+	mockAccountRepo.On("GetAccountByUserIDAndCurrency", mock.Anything, int64(1), "USDT").Return(&repository.AccountModel{
+		ID:            2,
+		UserID:        1,
+		Currency:      "USDT",
+		Balance:       money.MustParse("40000"),
+		FrozenBalance: money.MustParse("10000"),
+	}, nil)
+	return service, mockRepo, mockAccountRepo, mockJournalRepo
+}
+
+func TestWealthService_Redeem_Partial(t *testing.T) {
+	service, mockRepo, mockAccountRepo, mockJournalRepo := newEarlyRedeemFixture(repository.EarlyRedeemKeepPercent, "50")
+
+	var updatedOrder *repository.WealthOrderModel
+	mockAccountRepo.On("UnfreezeBalance", mock.Anything, int64(2), money.MustParse("2500")).Return(nil)
+	// 赎回 1/4 本金，对应利息 5，保留 50% 即 2.5
+	mockAccountRepo.On("AddBalance", mock.Anything, int64(2), money.MustParse("2.5")).Return(nil)
+	mockJournalRepo.On("CreateJournalRecord", mock.Anything, mock.AnythingOfType("*repository.JournalModel")).Return(nil)
+	mockRepo.On("ReleaseProductQuota", mock.Anything, int64(1), mock.Anything).Return(nil)
+	mockRepo.On("UpdateOrder", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		updatedOrder = args.Get(1).(*repository.WealthOrderModel)
+	}).Return(nil)
+
+	expectLockedReread(mockRepo, int64(2))
+	quote, err := service.Redeem(context.Background(), 1, 2, "partial", "2500")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "2502.5", quote.Payout)
+	assert.Equal(t, "2.5", quote.InterestForfeited)
+	assert.Equal(t, "7500", quote.RemainingPrincipal)
+	assert.True(t, quote.IsEarly)
+
+	assert.Equal(t, 1, updatedOrder.Status, "partially redeemed order stays active")
+	assert.Equal(t, money.MustParse("2500"), updatedOrder.PrincipalRedeemed)
+	assert.Equal(t, money.MustParse("15"), updatedOrder.InterestAccrued)
+	assert.Equal(t, money.MustParse("2.5"), updatedOrder.InterestPaid)
+	assert.Equal(t, money.MustParse("45"), updatedOrder.InterestExpected)
+	assert.Equal(t, "", updatedOrder.RedeemedAt)
+	assert.Equal(t, "partial", updatedOrder.RedemptionType.String)
+	// 只归还赎回部分的额度
+	mockRepo.AssertCalled(t, "ReleaseProductQuota", mock.Anything, int64(1), money.MustParse("2500"))
+}
+
+func TestWealthService_Redeem_PartialInvalidAmount(t *testing.T) {
+	for _, amount := range []string{"", "abc", "0", "-1", "10000.01"} {
+		service, mockRepo, _, _ := newEarlyRedeemFixture(repository.EarlyRedeemForfeitInterest, "0")
+
+		_, err := service.Redeem(context.Background(), 1, 2, "partial", amount)
+
+		assert.ErrorIs(t, err, ErrInvalidRedemptionAmount, amount)
+		assert.False(t, wasCalled(&mockRepo.Mock, "UpdateOrder"))
+	}
+}
+
+func TestWealthService_Redeem_InvalidType(t *testing.T) {
+	service, _, _, _ := newEarlyRedeemFixture(repository.EarlyRedeemForfeitInterest, "0")
+
+	_, err := service.Redeem(context.Background(), 1, 2, "half", "")
+
+	assert.ErrorIs(t, err, ErrInvalidRedemptionType)
+}
+
+func TestWealthService_Redeem_EarlyFlatFee(t *testing.T) {
+	service, mockRepo, mockAccountRepo, mockJournalRepo := newEarlyRedeemFixture(repository.EarlyRedeemFlatFee, "15")
+
+	var journals []*repository.JournalModel
+	var updatedOrder *repository.WealthOrderModel
+	mockAccountRepo.On("UnfreezeBalance", mock.Anything, int64(2), money.MustParse("10000")).Return(nil)
+	mockAccountRepo.On("AddBalance", mock.Anything, int64(2), money.MustParse("20")).Return(nil)
+	mockAccountRepo.On("DeductBalance", mock.Anything, int64(2), money.MustParse("15")).Return(nil)
+	mockJournalRepo.On("CreateJournalRecord", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		journals = append(journals, args.Get(1).(*repository.JournalModel))
+	}).Return(nil)
+	mockRepo.On("ReleaseProductQuota", mock.Anything, int64(1), mock.Anything).Return(nil)
+	mockRepo.On("UpdateOrder", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		updatedOrder = args.Get(1).(*repository.WealthOrderModel)
+	}).Return(nil)
+
+	expectLockedReread(mockRepo, int64(2))
+	quote, err := service.Redeem(context.Background(), 1, 2, "full", "")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "10005", quote.Payout)
+	assert.Equal(t, "15", quote.Fee)
+	assert.Equal(t, 4, updatedOrder.Status)
+	assert.Equal(t, money.MustParse("10005"), updatedOrder.RedemptionAmount)
+	assert.NotEmpty(t, updatedOrder.RedeemedAt)
+
+	if assert.Len(t, journals, 3) {
+		assert.Equal(t, "REDEEM_FEE", journals[2].BizType)
+		assert.Equal(t, money.MustParse("-15"), journals[2].Amount)
+		// 30000 可用 + 10000 本金 + 20 利息 - 15 手续费
+		assert.Equal(t, money.MustParse("40005"), journals[2].BalanceSnapshot)
+	}
+}
+
+func TestWealthService_QuoteRedemption(t *testing.T) {
+	tests := []struct {
+		name      string
+		rule      int
+		value     string
+		interest  string
+		forfeited string
+		fee       string
+		payout    string
+	}{
+		{"forfeit interest", repository.EarlyRedeemForfeitInterest, "0", "0", "20", "0", "10000"},
+		{"keep percent", repository.EarlyRedeemKeepPercent, "30", "6", "14", "0", "10006"},
+		{"flat fee", repository.EarlyRedeemFlatFee, "8", "20", "0", "8", "10012"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, mockRepo, mockAccountRepo, _ := newEarlyRedeemFixture(tt.rule, tt.value)
+
+			quote, err := service.QuoteRedemption(context.Background(), 1, 2, "", "")
+
+			assert.NoError(t, err)
+			assert.Equal(t, "full", quote.RedemptionType)
+			assert.Equal(t, tt.rule, quote.EarlyRedeemRule)
+			assert.Equal(t, "10000", quote.Principal)
+			assert.Equal(t, tt.interest, quote.InterestPaid)
+			assert.Equal(t, tt.forfeited, quote.InterestForfeited)
+			assert.Equal(t, tt.fee, quote.Fee)
+			assert.Equal(t, tt.payout, quote.Payout)
+			assert.Equal(t, "0", quote.RemainingPrincipal)
+
+			// 试算不产生任何写操作
+			assert.False(t, wasCalled(&mockRepo.Mock, "UpdateOrder"))
+			assert.False(t, wasCalled(&mockAccountRepo.Mock, "UnfreezeBalance"))
+		})
+	}
+}
+
+func TestWealthService_QuoteRedemption_MaturedPaysFullInterest(t *testing.T) {
+	mockRepo := new(MockWealthRepository)
+	service := NewWealthService(mockRepo, nil, nil, nil)
+
+	mockRepo.On("GetOrderByID", mock.Anything, int64(1)).Return(&repository.WealthOrderModel{
+		ID:                1,
+		UserID:            1,
+		ProductID:         1,
+		Currency:          "USDT",
+		Amount:            money.MustParse("5000"),
+		PrincipalRedeemed: money.MustParse("1000"),
+		InterestAccrued:   money.MustParse("18.21"),
+		EndDate:           time.Now().AddDate(0, 0, -1).Format("2006-01-02"),
+		Status:            1,
+	}, nil)
+
+	quote, err := service.QuoteRedemption(context.Background(), 1, 1, "full", "")
+
+	assert.NoError(t, err)
+	assert.False(t, quote.IsEarly)
+	assert.Equal(t, "4000", quote.Principal)
+	assert.Equal(t, "18.21", quote.InterestPaid)
+	assert.Equal(t, "4018.21", quote.Payout)
+	// 到期赎回不查询提前赎回规则
+	assert.False(t, wasCalled(&mockRepo.Mock, "GetProductByID"))
+}
+
+func TestWealthService_Subscribe_RollsBackOnStepFailure(t *testing.T) {
+	errBoom := errors.New("boom")
+	steps := []string{"ReserveProductQuota", "FreezeBalance", "CreateOrder", "CreateJournalRecord"}
+
+	for failAt, step := range steps {
+		t.Run(step, func(t *testing.T) {
+			mockRepo := new(MockWealthRepository)
+			mockAccountRepo := new(MockAccountRepository)
+			mockJournalRepo := new(MockJournalRepository)
+			uow := NewMockUnitOfWork(mockRepo, mockAccountRepo, mockJournalRepo)
+			service := NewWealthService(mockRepo, mockAccountRepo, mockJournalRepo, uow)
+
+			mockRepo.On("GetProductByID", mock.Anything, int64(1)).Return(&repository.WealthProductModel{
+				ID:         1,
+				Title:      "USDT 7日增值",
+				Currency:   "USDT",
+				APY:        money.MustParse("5.5"),
+				Duration:   7,
+				MinAmount:  money.MustParse("100"),
+				MaxAmount:  money.MustParse("50000"),
+				TotalQuota: money.MustParse("100000"),
+				SoldQuota:  money.MustParse("0"),
+				Status:     1,
+			}, nil)
+			mockAccountRepo.On("GetAccountByUserIDAndCurrency", mock.Anything, int64(1), "USDT").Return(&repository.AccountModel{
+				ID:       1,
+				UserID:   1,
+				Currency: "USDT",
+				Balance:  money.MustParse("10000"),
+			}, nil)
+
+			stepErr := func(i int) error {
+				if i == failAt {
+					return errBoom
+				}
+				return nil
+			}
+			mockRepo.On("ReserveProductQuota", mock.Anything, int64(1), money.MustParse("5000")).Return(stepErr(0))
+			mockAccountRepo.On("FreezeBalance", mock.Anything, int64(1), money.MustParse("5000")).Return(stepErr(1))
+			mockRepo.On("CreateOrder", mock.Anything, mock.AnythingOfType("*repository.WealthOrderModel")).Return(stepErr(2))
+			mockJournalRepo.On("CreateJournalRecord", mock.Anything, mock.AnythingOfType("*repository.JournalModel")).Return(stepErr(3))
+
+			stubQuote(mockRepo, 1, "5000")
+			_, err := service.Subscribe(context.Background(), 1, "q-1", false, false)
+
+			assert.Error(t, err)
+			assert.Equal(t, 0, uow.Commits)
+			assert.Equal(t, 1, uow.Rollbacks)
+			if step == "CreateJournalRecord" {
+				assert.ErrorIs(t, err, ErrJournalCreateFailed)
+			} else {
+				assert.ErrorIs(t, err, errBoom)
+			}
+
+			// 失败步骤之后的写操作都不应执行
+			mocks := map[string]*mock.Mock{
+				"ReserveProductQuota": &mockRepo.Mock,
+				"FreezeBalance":       &mockAccountRepo.Mock,
+				"CreateOrder":         &mockRepo.Mock,
+				"CreateJournalRecord": &mockJournalRepo.Mock,
+			}
+			for i, name := range steps {
+				assert.Equal(t, i <= failAt, wasCalled(mocks[name], name), name)
+			}
+			assert.False(t, wasCalled(&mockAccountRepo.Mock, "UnfreezeBalance"))
+		})
+	}
+}
+
+func TestWealthService_Redeem_RollsBackOnStepFailure(t *testing.T) {
+	errBoom := errors.New("boom")
+	steps := []string{"UnfreezeBalance", "PrincipalJournal", "AddBalance", "InterestJournal", "ReleaseProductQuota", "UpdateOrder"}
+
+	for failAt, step := range steps {
+		t.Run(step, func(t *testing.T) {
+			mockRepo := new(MockWealthRepository)
+			mockAccountRepo := new(MockAccountRepository)
+			mockJournalRepo := new(MockJournalRepository)
+			uow := NewMockUnitOfWork(mockRepo, mockAccountRepo, mockJournalRepo)
+			service := NewWealthService(mockRepo, mockAccountRepo, mockJournalRepo, uow)
+
+			mockRepo.On("GetOrderByID", mock.Anything, int64(1)).Return(&repository.WealthOrderModel{
+				ID:              1,
+				UserID:          1,
+				ProductID:       1,
+				Currency:        "USDT",
+				Amount:          money.MustParse("5000"),
+				InterestAccrued: money.MustParse("18.21"),
+				StartDate:       time.Now().AddDate(0, 0, -10).Format("2006-01-02"),
+				EndDate:         time.Now().AddDate(0, 0, -3).Format("2006-01-02"),
+				Status:          1,
+			}, nil)
+			mockAccountRepo.On("GetAccountByUserIDAndCurrency", mock.Anything, int64(1), "USDT").Return(&repository.AccountModel{
+				ID:            1,
+				UserID:        1,
+				Currency:      "USDT",
+				Balance:       money.MustParse("50000"),
+				FrozenBalance: money.MustParse("5000"),
+			}, nil)
+
+			stepErr := func(i int) error {
+				if i == failAt {
+					return errBoom
+				}
+				return nil
+			}
+			mockAccountRepo.On("UnfreezeBalance", mock.Anything, int64(1), money.MustParse("5000")).Return(stepErr(0))
+			mockJournalRepo.On("CreateJournalRecord", mock.Anything, mock.AnythingOfType("*repository.JournalModel")).Return(stepErr(1)).Once()
+			mockAccountRepo.On("AddBalance", mock.Anything, int64(1), money.MustParse("18.21")).Return(stepErr(2))
+			mockJournalRepo.On("CreateJournalRecord", mock.Anything, mock.AnythingOfType("*repository.JournalModel")).Return(stepErr(3)).Once()
+			mockRepo.On("ReleaseProductQuota", mock.Anything, int64(1), money.MustParse("5000")).Return(stepErr(4))
+			mockRepo.On("UpdateOrder", mock.Anything, mock.AnythingOfType("*repository.WealthOrderModel")).Return(stepErr(5))
+
+			expectLockedReread(mockRepo, int64(1))
+			_, err := service.Redeem(context.Background(), 1, 1, "full", "")
+
+			assert.Error(t, err)
+			assert.Equal(t, 0, uow.Commits)
+			assert.Equal(t, 1, uow.Rollbacks)
+			assert.Equal(t, failAt >= 2, wasCalled(&mockAccountRepo.Mock, "AddBalance"))
+			assert.Equal(t, failAt >= 4, wasCalled(&mockRepo.Mock, "ReleaseProductQuota"))
+			assert.Equal(t, failAt == 5, wasCalled(&mockRepo.Mock, "UpdateOrder"))
+		})
+	}
+}
+
+func TestWealthService_QuoteRedemption_UsesOrderTermsSnapshot(t *testing.T) {
+	service, mockRepo, _, _ := newEarlyRedeemFixture(repository.EarlyRedeemKeepPercent, "50")
+	// 产品下单后改成了固定手续费，存量订单仍按下单时的规则
+	mockRepo.On("GetProductByID", mock.Anything, int64(1)).Return(&repository.WealthProductModel{
+		ID:               1,
+		EarlyRedeemRule:  repository.EarlyRedeemFlatFee,
+		EarlyRedeemValue: money.MustParse("100"),
+	}, nil)
+
+	quote, err := service.QuoteRedemption(context.Background(), 1, 2, "full", "")
+
+	assert.NoError(t, err)
+	assert.Equal(t, repository.EarlyRedeemKeepPercent, quote.EarlyRedeemRule)
+	assert.Equal(t, "10", quote.InterestPaid)
+	assert.Equal(t, "0", quote.Fee)
+}
@@ -0,0 +1,112 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"monera-digital/internal/models"
+	"monera-digital/internal/repository"
+)
+
+var (
+	ErrInvalidCallbackSignature = errors.New("invalid callback signature")
+	// ErrCallbackIgnored 回调事件与提现状态无关，应答成功即可
+	ErrCallbackIgnored = errors.New("callback event ignored")
+)
+
+// custodyCallbackWindow 回调时间戳与服务器时间允许的偏差，超出视为重放
+const custodyCallbackWindow = 5 * time.Minute
+
+// CustodyCallback 托管方推送的提现状态，按提交时的 RequestID 关联订单
+type CustodyCallback struct {
+	RequestID string
+	Status    string // PROCESSING, CONFIRMED, COMPLETED, FAILED
+	TxHash    string
+	Reason    string
+}
+
+// VerifyCustodyCallback 经托管客户端验证 Safeheron 回调报文的签名并解密，校验时间戳后转换为提现状态回调，
+// 交易状态按与查询相同的规则映射。未配置托管客户端时拒绝所有回调；
+// 非交易状态变更的事件返回 ErrCallbackIgnored。
+func (s *WithdrawalService) VerifyCustodyCallback(body []byte) (*CustodyCallback, error) {
+	if s.safeheron == nil {
+		return nil, ErrInvalidCallbackSignature
+	}
+	event, err := s.safeheron.OpenWebhook(body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCallbackSignature, err)
+	}
+	skew := s.clock.Now().Sub(event.Timestamp)
+	if skew > custodyCallbackWindow || skew < -custodyCallbackWindow {
+		return nil, ErrInvalidCallbackSignature
+	}
+	if event.Withdrawal == nil || event.RequestID == "" {
+		return nil, ErrCallbackIgnored
+	}
+
+	cb := &CustodyCallback{
+		RequestID: event.RequestID,
+		Status:    string(event.Withdrawal.Status),
+		TxHash:    event.Withdrawal.TxHash,
+	}
+	if event.Withdrawal.Status == models.WithdrawalStatusFailed {
+		cb.Reason = "custody transaction " + event.Withdrawal.TransactionStatus
+	}
+	return cb, nil
+}
+
+// HandleCustodyCallback 按托管回调推进订单状态。重复推送已达到的状态或迟到的在途事件直接返回订单；
+// 状态机不允许的流转返回 ErrInvalidWithdrawalTransition；失败时自动退回已扣款的余额。
+// 提交结果未确认而停留在 PENDING 的订单收到成功回调时，在同一事务内先进入 PROCESSING 完成扣款。
+func (s *WithdrawalService) HandleCustodyCallback(ctx context.Context, cb CustodyCallback) (*models.WithdrawalOrder, error) {
+	order, err := s.repo.Withdrawal.GetOrderByRequestID(ctx, strings.TrimSpace(cb.RequestID))
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrWithdrawalNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	to := models.WithdrawalStatus(strings.ToUpper(strings.TrimSpace(cb.Status)))
+	if string(to) == order.Status {
+		return order, nil
+	}
+	// 在途事件可能晚于终态送达，只推进提交结果未确认的 PENDING 订单
+	if to == models.WithdrawalStatusProcessing && order.Status != string(models.WithdrawalStatusPending) {
+		return order, nil
+	}
+
+	now := sql.NullTime{Time: s.clock.Now(), Valid: true}
+	next := *order
+	next.Status = string(to)
+	if cb.TxHash != "" {
+		next.TransactionHash = sql.NullString{String: cb.TxHash, Valid: true}
+	}
+	switch to {
+	case models.WithdrawalStatusProcessing:
+	case models.WithdrawalStatusConfirmed:
+		next.ConfirmedAt = now
+	case models.WithdrawalStatusCompleted:
+		if !next.ConfirmedAt.Valid {
+			next.ConfirmedAt = now
+		}
+		next.CompletedAt = now
+	case models.WithdrawalStatusFailed:
+		next.FailureReason = sql.NullString{String: cb.Reason, Valid: cb.Reason != ""}
+	default:
+		return nil, ErrInvalidWithdrawalTransition
+	}
+
+	if order.Status == string(models.WithdrawalStatusPending) && to != models.WithdrawalStatusFailed {
+		next.SentAt = now
+	}
+	err = s.advance(ctx, order, &next)
+	if err != nil {
+		return nil, err
+	}
+	return &next, nil
+}
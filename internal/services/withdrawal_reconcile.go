@@ -0,0 +1,99 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"monera-digital/internal/logger"
+	"monera-digital/internal/models"
+)
+
+const (
+	// WithdrawalReconcileJobName 提现对账任务的名称
+	WithdrawalReconcileJobName = "withdrawal_reconcile"
+
+	// withdrawalReconcileAfter PENDING 超过该时长仍未收到回调时向托管方查询
+	withdrawalReconcileAfter = 10 * time.Minute
+	// withdrawalNotFoundFailAfter 托管方查无此单且订单超过该时长时判定提交未落地，置为 FAILED 解冻
+	withdrawalNotFoundFailAfter = time.Hour
+)
+
+// WithdrawalReconcileResult 单次对账的处理结果
+type WithdrawalReconcileResult struct {
+	Checked    int
+	Processing int // 托管方交易在途，补记扣款
+	Confirmed  int // 托管方交易已完成，补记扣款并确认
+	Failed     int // 托管方交易失败或查无此单，已解冻
+	Waiting    int // 查无此单但仍在安全窗口内
+}
+
+// ReconcilePending 对提交结果未确认而停留在 PENDING 的订单按 RequestID 查询托管方，按交易状态推进：
+// 在途的进入 PROCESSING 并扣款；已完成的在同一事务内扣款并进入 CONFIRMED；
+// 失败、拒绝、取消的置为 FAILED 并解冻；查无此单且超过安全窗口的置为 FAILED 并解冻。
+// 已被回调并发推进的订单跳过；未配置托管客户端时不做任何处理。
+func (s *WithdrawalService) ReconcilePending(ctx context.Context) (*WithdrawalReconcileResult, error) {
+	result := &WithdrawalReconcileResult{}
+	if s.safeheron == nil {
+		return result, nil
+	}
+
+	now := s.clock.Now()
+	orders, err := s.repo.Withdrawal.GetPendingOrdersBefore(ctx, now.Add(-withdrawalReconcileAfter))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pending withdrawals: %v", err)
+	}
+
+	var failed int
+	for _, order := range orders {
+		result.Checked++
+		shResp, err := s.safeheron.GetWithdrawal(ctx, order.RequestID)
+		switch {
+		case err == nil:
+			err = s.advance(ctx, order, s.custodyOrder(order, shResp))
+			if err == nil {
+				switch shResp.Status {
+				case models.WithdrawalStatusConfirmed:
+					result.Confirmed++
+				case models.WithdrawalStatusFailed:
+					result.Failed++
+				default:
+					result.Processing++
+				}
+			}
+		case errors.Is(err, ErrCustodyNotFound) && order.CreatedAt.Before(now.Add(-withdrawalNotFoundFailAfter)):
+			next := *order
+			next.Status = string(models.WithdrawalStatusFailed)
+			next.FailureReason = sql.NullString{String: "custody has no record of the request", Valid: true}
+			err = s.transition(ctx, order, &next)
+			if err == nil {
+				result.Failed++
+			}
+		case errors.Is(err, ErrCustodyNotFound):
+			result.Waiting++
+			err = nil
+		}
+		if errors.Is(err, ErrInvalidWithdrawalTransition) {
+			// 回调已先一步推进订单
+			err = nil
+		}
+		if err != nil {
+			failed++
+			logger.Error("[WithdrawalReconcile] Failed to reconcile order",
+				"order_id", order.ID, "request_id", order.RequestID, "error", err.Error())
+		}
+	}
+
+	logger.Info("[WithdrawalReconcile] Reconcile completed",
+		"checked", result.Checked,
+		"processing", result.Processing,
+		"confirmed", result.Confirmed,
+		"failed", result.Failed,
+		"waiting", result.Waiting)
+	if failed > 0 {
+		return result, fmt.Errorf("%d of %d pending withdrawals failed to reconcile", failed, len(orders))
+	}
+	return result, nil
+}
@@ -6,173 +6,275 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"monera-digital/internal/clock"
 	"monera-digital/internal/models"
+	"monera-digital/internal/money"
 	"monera-digital/internal/repository"
 )
 
-type ISafeheronService interface {
-	Withdraw(ctx context.Context, req SafeheronWithdrawalRequest) (*SafeheronWithdrawalResponse, error)
-}
+var (
+	ErrInvalidWithdrawalAmount     = errors.New("invalid amount")
+	ErrWithdrawalAddressNotFound   = errors.New("address not found")
+	ErrWithdrawalAddressNotOwned   = errors.New("address does not belong to user")
+	ErrWithdrawalNotFound          = errors.New("withdrawal not found")
+	ErrInvalidWithdrawalTransition = errors.New("illegal withdrawal status transition")
+)
+
+// 提现资金变动的流水类型：提交托管时扣款，托管失败时退回
+const (
+	WithdrawBizDebit  = "WITHDRAW"
+	WithdrawBizRefund = "WITHDRAW_REFUND"
+)
 
 type WithdrawalService struct {
 	repo      *repository.Repository
+	uow       repository.UnitOfWork
 	safeheron ISafeheronService
-	db        *sql.DB
+	clock     clock.Clock
 }
 
-func NewWithdrawalService(db *sql.DB, repo *repository.Repository, safeheron ISafeheronService) *WithdrawalService {
+func NewWithdrawalService(repo *repository.Repository, uow repository.UnitOfWork, safeheron ISafeheronService) *WithdrawalService {
 	return &WithdrawalService{
-		db:        db,
 		repo:      repo,
+		uow:       uow,
 		safeheron: safeheron,
+		clock:     clock.System,
 	}
 }
 
-// CreateWithdrawal handles the withdrawal process with transaction support
+// SetCustodyClient 替换托管出金客户端；为 nil 时拒绝新的提现
+func (s *WithdrawalService) SetCustodyClient(client ISafeheronService) {
+	s.safeheron = client
+}
+
+// SetClock 替换状态时间戳与流水时间的来源，供测试与模拟器拨动时间
+func (s *WithdrawalService) SetClock(c clock.Clock) {
+	s.clock = c
+}
+
+// CreateWithdrawal 从提现币种对应的资金账户出金：冻结金额并创建 PENDING 订单后调用 Safeheron。
+// 调用失败时订单置为 FAILED 并解冻；成功后订单按交易状态进入 PROCESSING（或已完成时直接到 CONFIRMED），
+// 同一事务内解冻扣款并写入扣款流水。
+// 托管结果无法确认时订单保持 PENDING、资金保持冻结，等待托管回调推进。
 func (s *WithdrawalService) CreateWithdrawal(ctx context.Context, userID int, req models.CreateWithdrawalRequest) (*models.WithdrawalOrder, error) {
-	// Validate and get resources
-	amount, address, err := s.validateWithdrawalRequest(ctx, userID, req)
+	if s.safeheron == nil {
+		return nil, ErrCustodyNotConfigured
+	}
+	currency, amount, address, err := s.validateWithdrawalRequest(ctx, userID, req)
 	if err != nil {
 		return nil, err
 	}
 
-	// Call Safeheron first (external API, not in transaction)
-	requestID := uuid.New().String()
+	order := &models.WithdrawalOrder{
+		UserID:       userID,
+		Amount:       amount.String(),
+		NetworkFee:   "0",
+		PlatformFee:  "0",
+		ActualAmount: amount.String(),
+		ChainType:    address.ChainType,
+		CoinType:     currency,
+		ToAddress:    address.WalletAddress,
+		RequestID:    uuid.New().String(),
+		Status:       string(models.WithdrawalStatusPending),
+	}
+
+	err = s.uow.Do(ctx, func(tx *repository.TxRepository) error {
+		account, err := tx.Account.GetAccountByType(ctx, int64(userID), repository.AccountTypeFund, currency)
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrInsufficientBalance
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get account: %v", err)
+		}
+		if account.Available().LessThan(amount) {
+			return ErrInsufficientBalance
+		}
+		if err := tx.Account.FreezeBalance(ctx, account.ID, amount); err != nil {
+			return err
+		}
+		if _, err := tx.Withdrawal.CreateOrder(ctx, order); err != nil {
+			return fmt.Errorf("failed to create order: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Safeheron 是外部调用，不放在事务内
 	shResp, err := s.safeheron.Withdraw(ctx, SafeheronWithdrawalRequest{
-		CoinType:  req.Asset,
+		CoinType:  currency,
 		ChainType: address.ChainType,
 		ToAddress: address.WalletAddress,
-		Amount:    req.Amount,
-		RequestID: requestID,
+		Amount:    amount.String(),
+		RequestID: order.RequestID,
 	})
-
+	if errors.Is(err, ErrCustodyOutcomeUnknown) {
+		// 托管方可能已受理，不能解冻；由回调按 RequestID 推进
+		return order, nil
+	}
 	if err != nil {
-		// Fail: Unfreeze (outside transaction)
-		if unfreezeErr := s.repo.Account.ReleaseFrozenBalance(ctx, userID, amount); unfreezeErr != nil {
-			return nil, fmt.Errorf("safeheron failed and failed to unfreeze balance: %w (unfreeze error: %v)", err, unfreezeErr)
+		next := *order
+		next.Status = string(models.WithdrawalStatusFailed)
+		next.FailureReason = sql.NullString{String: err.Error(), Valid: true}
+		if failErr := s.transition(ctx, order, &next); failErr != nil {
+			return nil, fmt.Errorf("safeheron failed and failed to release balance: %w (release error: %v)", err, failErr)
 		}
 		return nil, fmt.Errorf("safeheron failed: %w", err)
 	}
 
-	// Success: Execute DB operations in transaction
-	var createdOrder *models.WithdrawalOrder
-	txErr := s.withdrawWithTransaction(ctx, userID, amount, address, req, shResp, &createdOrder)
-	if txErr != nil {
-		return nil, txErr
+	// 查询回退拿到的交易可能已完成或已失败
+	next := s.custodyOrder(order, shResp)
+	if err := s.advance(ctx, order, next); err != nil {
+		return nil, err
 	}
-
-	return createdOrder, nil
+	if next.Status == string(models.WithdrawalStatusFailed) {
+		return nil, fmt.Errorf("safeheron failed: %w: transaction %s", ErrCustodyRejected, shResp.TransactionStatus)
+	}
+	return next, nil
 }
 
-// withdrawWithTransaction handles the DB operations in a transaction
-func (s *WithdrawalService) withdrawWithTransaction(ctx context.Context, userID int, amount float64, address *models.WithdrawalAddress, req models.CreateWithdrawalRequest, shResp *SafeheronWithdrawalResponse, orderPtr **models.WithdrawalOrder) error {
-	tx, err := s.db.BeginTx(ctx, nil)
-	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+// custodyOrder 按托管方返回的交易结果构造订单的下一状态
+func (s *WithdrawalService) custodyOrder(order *models.WithdrawalOrder, shResp *SafeheronWithdrawalResponse) *models.WithdrawalOrder {
+	now := sql.NullTime{Time: s.clock.Now(), Valid: true}
+	next := *order
+	next.Status = string(shResp.Status)
+	if shResp.NetworkFee != "" {
+		next.NetworkFee = shResp.NetworkFee
 	}
-	defer func() {
-		if err != nil {
-			tx.Rollback()
+	next.SafeheronOrderID = sql.NullString{String: shResp.SafeheronOrderID, Valid: shResp.SafeheronOrderID != ""}
+	next.TransactionHash = sql.NullString{String: shResp.TxHash, Valid: shResp.TxHash != ""}
+	switch shResp.Status {
+	case models.WithdrawalStatusFailed:
+		next.FailureReason = sql.NullString{String: "custody transaction " + shResp.TransactionStatus, Valid: true}
+	case models.WithdrawalStatusConfirmed:
+		next.ConfirmedAt = now
+		fallthrough
+	default:
+		next.SentAt = now
+	}
+	return &next
+}
+
+// advance 在一个事务内将订单推进到 next：PENDING 订单推进到 PROCESSING 之后的状态时，
+// 先进入 PROCESSING 完成扣款，避免停在已扣款的 PROCESSING
+func (s *WithdrawalService) advance(ctx context.Context, order, next *models.WithdrawalOrder) error {
+	return s.uow.Do(ctx, func(tx *repository.TxRepository) error {
+		from := order
+		to := models.WithdrawalStatus(next.Status)
+		if order.Status == string(models.WithdrawalStatusPending) && to != models.WithdrawalStatusProcessing && to != models.WithdrawalStatusFailed {
+			processing := *order
+			processing.Status = string(models.WithdrawalStatusProcessing)
+			processing.TransactionHash = next.TransactionHash
+			processing.SafeheronOrderID = next.SafeheronOrderID
+			processing.NetworkFee = next.NetworkFee
+			processing.SentAt = next.SentAt
+			if err := s.applyTransition(ctx, tx, order, &processing); err != nil {
+				return err
+			}
+			from = &processing
 		}
-	}()
+		return s.applyTransition(ctx, tx, from, next)
+	})
+}
 
-	// Execute all DB operations using the transaction
+// transition 将订单从当前状态推进到 next 的状态，并在同一事务内完成对应的资金变动
+func (s *WithdrawalService) transition(ctx context.Context, order, next *models.WithdrawalOrder) error {
+	return s.uow.Do(ctx, func(tx *repository.TxRepository) error {
+		return s.applyTransition(ctx, tx, order, next)
+	})
+}
 
-	// 1. Freeze Balance
-	_, err = tx.ExecContext(ctx,
-		`UPDATE account SET frozen_balance = frozen_balance + $1, version = version + 1, updated_at = $3 WHERE user_id = $2`,
-		amount, userID, time.Now())
-	if err != nil {
-		return fmt.Errorf("failed to freeze balance: %w", err)
+// applyTransition 在调用方事务内推进订单状态并完成资金变动：
+// 进入 PROCESSING 时解冻并扣款；FAILED 时未扣款的订单解冻，已扣款的订单退回余额。
+func (s *WithdrawalService) applyTransition(ctx context.Context, tx *repository.TxRepository, order, next *models.WithdrawalOrder) error {
+	from := models.WithdrawalStatus(order.Status)
+	to := models.WithdrawalStatus(next.Status)
+	if !from.CanTransitionTo(to) {
+		return ErrInvalidWithdrawalTransition
 	}
-
-	// 2. Deduct Balance
-	result, err := tx.ExecContext(ctx,
-		`UPDATE account SET frozen_balance = frozen_balance - $1, balance = balance - $1, version = version + 1, updated_at = $3 WHERE user_id = $2 AND frozen_balance >= $1`,
-		amount, userID, time.Now())
+	amount, err := money.Parse(order.Amount)
 	if err != nil {
-		return fmt.Errorf("failed to deduct balance: %w", err)
-	}
-	rows, _ := result.RowsAffected()
-	if rows == 0 {
-		return fmt.Errorf("failed to deduct balance: account not found or insufficient frozen balance")
+		return fmt.Errorf("invalid order amount %q: %v", order.Amount, err)
 	}
 
-	// 3. Create Order
-	order := &models.WithdrawalOrder{
-		UserID:           userID,
-		Amount:           req.Amount,
-		NetworkFee:       shResp.NetworkFee,
-		PlatformFee:      "0",
-		ActualAmount:     req.Amount,
-		ChainType:        address.ChainType,
-		CoinType:         req.Asset,
-		ToAddress:        address.WalletAddress,
-		SafeheronOrderID: sql.NullString{String: shResp.SafeheronOrderID, Valid: true},
-		TransactionHash:  sql.NullString{String: shResp.TxHash, Valid: true},
-		Status:           "SENT",
-	}
-
-	err = tx.QueryRowContext(ctx,
-		`INSERT INTO withdrawal_order (
-			user_id, amount, network_fee, platform_fee, actual_amount,
-			chain_type, coin_type, to_address, safeheron_order_id, transaction_hash,
-			status, created_at, updated_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
-		RETURNING id, created_at`,
-		order.UserID, order.Amount, order.NetworkFee, order.PlatformFee, order.ActualAmount,
-		order.ChainType, order.CoinType, order.ToAddress, order.SafeheronOrderID, order.TransactionHash,
-		order.Status, time.Now(), time.Now(),
-	).Scan(&order.ID, &order.CreatedAt)
-	if err != nil {
-		return fmt.Errorf("failed to create order: %w", err)
+	if err := tx.Withdrawal.TransitionOrder(ctx, next, from); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			// 订单状态已被并发推进
+			return ErrInvalidWithdrawalTransition
+		}
+		return fmt.Errorf("failed to update order: %w", err)
 	}
 
-	// Commit transaction
-	if err = tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+	account, err := tx.Account.GetAccountByType(ctx, int64(order.UserID), repository.AccountTypeFund, order.CoinType)
+	if err != nil {
+		return fmt.Errorf("failed to get account: %v", err)
 	}
 
-	*orderPtr = order
-	return nil
-}
-
-// validateWithdrawalRequest validates the withdrawal request and returns required resources
-func (s *WithdrawalService) validateWithdrawalRequest(ctx context.Context, userID int, req models.CreateWithdrawalRequest) (float64, *models.WithdrawalAddress, error) {
-	// Validate amount
-	amount, err := strconv.ParseFloat(req.Amount, 64)
-	if err != nil || amount <= 0 {
-		return 0, nil, errors.New("invalid amount")
+	var bizType, serialNo string
+	var delta money.Decimal
+	switch {
+	case to == models.WithdrawalStatusProcessing:
+		if err := tx.Account.UnfreezeBalance(ctx, account.ID, amount); err != nil {
+			return fmt.Errorf("failed to unfreeze balance: %w", err)
+		}
+		if err := tx.Account.DeductBalance(ctx, account.ID, amount); err != nil {
+			return fmt.Errorf("failed to deduct balance: %w", err)
+		}
+		bizType, serialNo, delta = WithdrawBizDebit, fmt.Sprintf("WITHDRAW-%d", order.ID), amount.Neg()
+	case to == models.WithdrawalStatusFailed && from == models.WithdrawalStatusPending:
+		return tx.Account.UnfreezeBalance(ctx, account.ID, amount)
+	case to == models.WithdrawalStatusFailed:
+		if err := tx.Account.AddBalance(ctx, account.ID, amount); err != nil {
+			return fmt.Errorf("failed to refund balance: %w", err)
+		}
+		bizType, serialNo, delta = WithdrawBizRefund, fmt.Sprintf("WITHDRAW-REFUND-%d", order.ID), amount
+	default:
+		return nil
 	}
 
-	// Get Account
-	account, err := s.repo.Account.GetByUserIDAndType(ctx, userID, "WEALTH")
+	// 快照取变动后的可用余额
+	updated, err := tx.Account.GetAccountByType(ctx, int64(order.UserID), repository.AccountTypeFund, order.CoinType)
 	if err != nil {
-		if err == repository.ErrNotFound {
-			return 0, nil, errors.New("account not found")
-		}
-		return 0, nil, err
+		return fmt.Errorf("failed to get account: %v", err)
 	}
+	orderID := int64(order.ID)
+	if err := tx.Journal.CreateJournalRecord(ctx, &repository.JournalModel{
+		SerialNo:        serialNo,
+		UserID:          int64(order.UserID),
+		AccountID:       account.ID,
+		Amount:          delta,
+		BalanceSnapshot: updated.Available(),
+		BizType:         bizType,
+		RefID:           &orderID,
+		CreatedAt:       s.clock.Now().Format(time.RFC3339),
+	}); err != nil {
+		return ErrJournalCreateFailed
+	}
+	return nil
+}
 
-	// Check balance
-	available := account.Balance - account.FrozenBalance
-	if available < amount {
-		return 0, nil, errors.New("insufficient balance")
+// validateWithdrawalRequest 校验金额与提现地址，返回提现币种、金额与地址
+func (s *WithdrawalService) validateWithdrawalRequest(ctx context.Context, userID int, req models.CreateWithdrawalRequest) (string, money.Decimal, *models.WithdrawalAddress, error) {
+	currency := strings.ToUpper(strings.TrimSpace(req.Asset))
+	amount, err := money.Parse(req.Amount)
+	if err != nil || currency == "" || !amount.IsPositive() || !money.Quantize(currency, amount).Equal(amount) {
+		return "", money.Zero, nil, ErrInvalidWithdrawalAmount
 	}
 
-	// Get Address
 	address, err := s.repo.Address.GetAddressByID(ctx, req.AddressID)
 	if err != nil {
-		return 0, nil, errors.New("address not found")
+		return "", money.Zero, nil, ErrWithdrawalAddressNotFound
 	}
 	if address.UserID != userID {
-		return 0, nil, errors.New("address does not belong to user")
+		return "", money.Zero, nil, ErrWithdrawalAddressNotOwned
 	}
 
-	return amount, address, nil
+	return currency, amount, address, nil
 }
 
 // GetWithdrawalHistory returns the withdrawal history for a user
@@ -2,13 +2,17 @@ package services
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"testing"
 	"time"
 
-	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"monera-digital/internal/clock"
 	"monera-digital/internal/models"
+	"monera-digital/internal/money"
 	"monera-digital/internal/repository"
 )
 
@@ -25,143 +29,544 @@ func (m *MockSafeheronService) Withdraw(ctx context.Context, req SafeheronWithdr
 	return args.Get(0).(*SafeheronWithdrawalResponse), args.Error(1)
 }
 
-// MockAccountRepositoryForWithdrawal implements repository.Account interface
-type MockAccountRepositoryForWithdrawal struct {
-	mock.Mock
+func (m *MockSafeheronService) GetWithdrawal(ctx context.Context, requestID string) (*SafeheronWithdrawalResponse, error) {
+	args := m.Called(ctx, requestID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*SafeheronWithdrawalResponse), args.Error(1)
 }
 
-func (m *MockAccountRepositoryForWithdrawal) GetByUserIDAndType(ctx context.Context, userID int, accountType string) (*models.Account, error) {
-	args := m.Called(ctx, userID, accountType)
+func (m *MockSafeheronService) OpenWebhook(body []byte) (*SafeheronWebhookEvent, error) {
+	args := m.Called(body)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
-	return args.Get(0).(*models.Account), args.Error(1)
+	return args.Get(0).(*SafeheronWebhookEvent), args.Error(1)
 }
 
-func (m *MockAccountRepositoryForWithdrawal) Create(ctx context.Context, account *models.Account) error {
-	args := m.Called(ctx, account)
-	return args.Error(0)
+func newTestWithdrawalService() (*WithdrawalService, *MockAccountRepository, *MockAddressRepository, *MockWithdrawalRepository, *MockJournalRepository, *MockSafeheronService, *MockUnitOfWork) {
+	accountRepo := new(MockAccountRepository)
+	addressRepo := new(MockAddressRepository)
+	withdrawalRepo := new(MockWithdrawalRepository)
+	journalRepo := new(MockJournalRepository)
+	safeheron := new(MockSafeheronService)
+	uow := NewMockUnitOfWork(nil, accountRepo, journalRepo)
+	uow.Repos.Withdrawal = withdrawalRepo
+	repo := &repository.Repository{
+		AccountV2:  accountRepo,
+		Address:    addressRepo,
+		Withdrawal: withdrawalRepo,
+		Journal:    journalRepo,
+	}
+	service := NewWithdrawalService(repo, uow, safeheron)
+	service.SetClock(clock.NewFake(time.Date(2026, 3, 10, 2, 0, 0, 0, time.UTC)))
+	return service, accountRepo, addressRepo, withdrawalRepo, journalRepo, safeheron, uow
 }
 
-func (m *MockAccountRepositoryForWithdrawal) UpdateFrozenBalance(ctx context.Context, userID int, amount float64) error {
-	args := m.Called(ctx, userID, amount)
-	return args.Error(0)
+var testWithdrawalAddress = &models.WithdrawalAddress{
+	ID:            10,
+	UserID:        1,
+	ChainType:     "TRC20",
+	WalletAddress: "Txyz...",
 }
 
-func (m *MockAccountRepositoryForWithdrawal) ReleaseFrozenBalance(ctx context.Context, userID int, amount float64) error {
-	args := m.Called(ctx, userID, amount)
-	return args.Error(0)
+func TestWithdrawalService_CreateWithdrawal_InsufficientBalance(t *testing.T) {
+	service, accountRepo, addressRepo, _, _, safeheron, uow := newTestWithdrawalService()
+
+	account := &repository.AccountModel{ID: 1, UserID: 1, Type: "FUND", Currency: "USDT", Balance: money.MustParse("150"), FrozenBalance: money.MustParse("100")}
+	addressRepo.On("GetAddressByID", mock.Anything, 10).Return(testWithdrawalAddress, nil)
+	accountRepo.On("GetAccountByType", mock.Anything, int64(1), "FUND", "USDT").Return(account, nil)
+
+	_, err := service.CreateWithdrawal(context.Background(), 1, models.CreateWithdrawalRequest{AddressID: 10, Amount: "100", Asset: "usdt"})
+
+	assert.ErrorIs(t, err, ErrInsufficientBalance)
+	assert.False(t, wasCalled(&accountRepo.Mock, "FreezeBalance"))
+	assert.False(t, wasCalled(&safeheron.Mock, "Withdraw"))
+	assert.Equal(t, 1, uow.Rollbacks)
 }
 
-func (m *MockAccountRepositoryForWithdrawal) DeductBalance(ctx context.Context, userID int, amount float64) error {
-	args := m.Called(ctx, userID, amount)
-	return args.Error(0)
+func TestWithdrawalService_CreateWithdrawal_DebitsAssetAccount(t *testing.T) {
+	service, accountRepo, addressRepo, withdrawalRepo, journalRepo, safeheron, uow := newTestWithdrawalService()
+
+	amount := money.MustParse("100")
+	account := &repository.AccountModel{ID: 1, UserID: 1, Type: "FUND", Currency: "USDT", Balance: money.MustParse("200"), FrozenBalance: money.Zero}
+	debited := &repository.AccountModel{ID: 1, UserID: 1, Type: "FUND", Currency: "USDT", Balance: money.MustParse("100"), FrozenBalance: money.Zero}
+	addressRepo.On("GetAddressByID", mock.Anything, 10).Return(testWithdrawalAddress, nil)
+	accountRepo.On("GetAccountByType", mock.Anything, int64(1), "FUND", "USDT").Return(account, nil).Twice()
+	accountRepo.On("GetAccountByType", mock.Anything, int64(1), "FUND", "USDT").Return(debited, nil).Once()
+	accountRepo.On("FreezeBalance", mock.Anything, int64(1), amount).Return(nil)
+	var requestID string
+	withdrawalRepo.On("CreateOrder", mock.Anything, mock.MatchedBy(func(o *models.WithdrawalOrder) bool {
+		return o.UserID == 1 && o.Amount == "100" && o.CoinType == "USDT" && o.Status == "PENDING" && o.RequestID != ""
+	})).Run(func(args mock.Arguments) {
+		o := args.Get(1).(*models.WithdrawalOrder)
+		o.ID = 7
+		requestID = o.RequestID
+	}).Return(&models.WithdrawalOrder{ID: 7}, nil)
+	safeheron.On("Withdraw", mock.Anything, mock.MatchedBy(func(r SafeheronWithdrawalRequest) bool {
+		return r.Amount == "100" && r.ToAddress == "Txyz..." && r.CoinType == "USDT" && r.ChainType == "TRC20" && r.RequestID == requestID
+	})).Return(&SafeheronWithdrawalResponse{TxHash: "0xtx", SafeheronOrderID: "sh-123", NetworkFee: "1.0", Status: models.WithdrawalStatusProcessing}, nil)
+	withdrawalRepo.On("TransitionOrder", mock.Anything, mock.MatchedBy(func(o *models.WithdrawalOrder) bool {
+		return o.ID == 7 && o.Status == "PROCESSING" && o.SafeheronOrderID.String == "sh-123" && o.SentAt.Valid && o.NetworkFee == "1.0"
+	}), models.WithdrawalStatusPending).Return(nil)
+	accountRepo.On("UnfreezeBalance", mock.Anything, int64(1), amount).Return(nil)
+	accountRepo.On("DeductBalance", mock.Anything, int64(1), amount).Return(nil)
+	journalRepo.On("CreateJournalRecord", mock.Anything, mock.MatchedBy(func(r *repository.JournalModel) bool {
+		return r.SerialNo == "WITHDRAW-7" && r.BizType == WithdrawBizDebit && r.AccountID == 1 &&
+			r.Amount.Equal(amount.Neg()) && r.BalanceSnapshot.Equal(money.MustParse("100")) &&
+			r.RefID != nil && *r.RefID == 7
+	})).Return(nil)
+
+	order, err := service.CreateWithdrawal(context.Background(), 1, models.CreateWithdrawalRequest{AddressID: 10, Amount: "100", Asset: "USDT"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 7, order.ID)
+	assert.Equal(t, "PROCESSING", order.Status)
+	assert.Equal(t, 2, uow.Commits)
+	accountRepo.AssertExpectations(t)
+	withdrawalRepo.AssertExpectations(t)
+	journalRepo.AssertExpectations(t)
 }
 
-func TestWithdrawalService_CreateWithdrawal_InsufficientBalance(t *testing.T) {
-	mockAccountRepo := new(MockAccountRepositoryForWithdrawal)
-	repo := &repository.Repository{Account: mockAccountRepo}
-	service := NewWithdrawalService(nil, repo, nil)
-
-	ctx := context.Background()
-	userID := 1
-	req := models.CreateWithdrawalRequest{
-		AddressID: 10,
-		Amount:    "100.0",
-		Asset:     "USDT",
-	}
+func TestWithdrawalService_CreateWithdrawal_SafeheronFailureUnfreezes(t *testing.T) {
+	service, accountRepo, addressRepo, withdrawalRepo, journalRepo, safeheron, _ := newTestWithdrawalService()
 
-	account := &models.Account{
-		UserID:        userID,
-		Balance:       50.0,
-		FrozenBalance: 0.0,
-	}
+	amount := money.MustParse("100")
+	account := &repository.AccountModel{ID: 1, UserID: 1, Type: "FUND", Currency: "USDT", Balance: money.MustParse("200"), FrozenBalance: money.Zero}
+	addressRepo.On("GetAddressByID", mock.Anything, 10).Return(testWithdrawalAddress, nil)
+	accountRepo.On("GetAccountByType", mock.Anything, int64(1), "FUND", "USDT").Return(account, nil)
+	accountRepo.On("FreezeBalance", mock.Anything, int64(1), amount).Return(nil)
+	withdrawalRepo.On("CreateOrder", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		args.Get(1).(*models.WithdrawalOrder).ID = 7
+	}).Return(&models.WithdrawalOrder{ID: 7}, nil)
+	safeheron.On("Withdraw", mock.Anything, mock.Anything).Return(nil, errors.New("timeout"))
+	withdrawalRepo.On("TransitionOrder", mock.Anything, mock.MatchedBy(func(o *models.WithdrawalOrder) bool {
+		return o.Status == "FAILED" && o.FailureReason.String == "timeout"
+	}), models.WithdrawalStatusPending).Return(nil)
+	accountRepo.On("UnfreezeBalance", mock.Anything, int64(1), amount).Return(nil)
 
-	mockAccountRepo.On("GetByUserIDAndType", ctx, userID, "WEALTH").Return(account, nil)
+	_, err := service.CreateWithdrawal(context.Background(), 1, models.CreateWithdrawalRequest{AddressID: 10, Amount: "100", Asset: "USDT"})
 
-	_, err := service.CreateWithdrawal(ctx, userID, req)
 	assert.Error(t, err)
-	assert.Equal(t, "insufficient balance", err.Error())
+	accountRepo.AssertExpectations(t)
+	withdrawalRepo.AssertExpectations(t)
+	assert.False(t, wasCalled(&accountRepo.Mock, "DeductBalance"))
+	assert.False(t, wasCalled(&journalRepo.Mock, "CreateJournalRecord"))
 }
 
-func TestWithdrawalService_CreateWithdrawal_WithMockDB(t *testing.T) {
-	// Setup sqlmock
-	db, sqlMock, err := sqlmock.New()
+func TestWithdrawalService_CreateWithdrawal_OutcomeUnknownKeepsFrozen(t *testing.T) {
+	service, accountRepo, addressRepo, withdrawalRepo, journalRepo, safeheron, _ := newTestWithdrawalService()
+
+	amount := money.MustParse("100")
+	account := &repository.AccountModel{ID: 1, UserID: 1, Type: "FUND", Currency: "USDT", Balance: money.MustParse("200"), FrozenBalance: money.Zero}
+	addressRepo.On("GetAddressByID", mock.Anything, 10).Return(testWithdrawalAddress, nil)
+	accountRepo.On("GetAccountByType", mock.Anything, int64(1), "FUND", "USDT").Return(account, nil)
+	accountRepo.On("FreezeBalance", mock.Anything, int64(1), amount).Return(nil)
+	withdrawalRepo.On("CreateOrder", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		args.Get(1).(*models.WithdrawalOrder).ID = 7
+	}).Return(&models.WithdrawalOrder{ID: 7}, nil)
+	safeheron.On("Withdraw", mock.Anything, mock.Anything).Return(nil, fmt.Errorf("%w: context deadline exceeded", ErrCustodyOutcomeUnknown))
+
+	order, err := service.CreateWithdrawal(context.Background(), 1, models.CreateWithdrawalRequest{AddressID: 10, Amount: "100", Asset: "USDT"})
+
 	assert.NoError(t, err)
-	defer db.Close()
+	assert.Equal(t, "PENDING", order.Status)
+	assert.NotEmpty(t, order.RequestID)
+	assert.False(t, wasCalled(&withdrawalRepo.Mock, "TransitionOrder"))
+	assert.False(t, wasCalled(&accountRepo.Mock, "UnfreezeBalance"))
+	assert.False(t, wasCalled(&journalRepo.Mock, "CreateJournalRecord"))
+}
 
-	// Setup Mocks
-	mockAccountRepo := new(MockAccountRepositoryForWithdrawal)
-	mockAddressRepo := new(MockAddressRepository)
-	mockWithdrawalRepo := new(MockWithdrawalRepository)
-	mockSafeheron := new(MockSafeheronService)
+func TestWithdrawalService_CreateWithdrawal_LookupFailedTransactionUnfreezes(t *testing.T) {
+	service, accountRepo, addressRepo, withdrawalRepo, journalRepo, safeheron, _ := newTestWithdrawalService()
 
-	// Combine into Repository
-	repo := &repository.Repository{
-		Account:    mockAccountRepo,
-		Address:    mockAddressRepo,
-		Withdrawal: mockWithdrawalRepo,
-	}
+	amount := money.MustParse("100")
+	account := &repository.AccountModel{ID: 1, UserID: 1, Type: "FUND", Currency: "USDT", Balance: money.MustParse("200"), FrozenBalance: money.Zero}
+	addressRepo.On("GetAddressByID", mock.Anything, 10).Return(testWithdrawalAddress, nil)
+	accountRepo.On("GetAccountByType", mock.Anything, int64(1), "FUND", "USDT").Return(account, nil)
+	accountRepo.On("FreezeBalance", mock.Anything, int64(1), amount).Return(nil)
+	withdrawalRepo.On("CreateOrder", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		args.Get(1).(*models.WithdrawalOrder).ID = 7
+	}).Return(&models.WithdrawalOrder{ID: 7}, nil)
+	// 超时后按 RequestID 查到的交易已被托管方拒绝
+	safeheron.On("Withdraw", mock.Anything, mock.Anything).
+		Return(&SafeheronWithdrawalResponse{SafeheronOrderID: "tx-1", Status: models.WithdrawalStatusFailed, TransactionStatus: "REJECTED"}, nil)
+	withdrawalRepo.On("TransitionOrder", mock.Anything, mock.MatchedBy(func(o *models.WithdrawalOrder) bool {
+		return o.Status == "FAILED" && o.FailureReason.String == "custody transaction REJECTED"
+	}), models.WithdrawalStatusPending).Return(nil)
+	accountRepo.On("UnfreezeBalance", mock.Anything, int64(1), amount).Return(nil)
 
-	service := NewWithdrawalService(db, repo, mockSafeheron)
+	_, err := service.CreateWithdrawal(context.Background(), 1, models.CreateWithdrawalRequest{AddressID: 10, Amount: "100", Asset: "USDT"})
 
-	ctx := context.Background()
-	userID := 1
-	req := models.CreateWithdrawalRequest{
-		AddressID: 10,
-		Amount:    "100.0",
-		Asset:     "USDT",
-	}
+	assert.ErrorIs(t, err, ErrCustodyRejected)
+	accountRepo.AssertExpectations(t)
+	withdrawalRepo.AssertExpectations(t)
+	assert.False(t, wasCalled(&accountRepo.Mock, "DeductBalance"))
+	assert.False(t, wasCalled(&journalRepo.Mock, "CreateJournalRecord"))
+}
+
+func TestWithdrawalService_CreateWithdrawal_LookupCompletedTransactionConfirms(t *testing.T) {
+	service, accountRepo, addressRepo, withdrawalRepo, journalRepo, safeheron, uow := newTestWithdrawalService()
 
-	// Mock Data
-	account := &models.Account{
-		UserID:        userID,
-		Balance:       200.0,
-		FrozenBalance: 0.0,
+	amount := money.MustParse("100")
+	account := &repository.AccountModel{ID: 1, UserID: 1, Type: "FUND", Currency: "USDT", Balance: money.MustParse("200"), FrozenBalance: money.Zero}
+	addressRepo.On("GetAddressByID", mock.Anything, 10).Return(testWithdrawalAddress, nil)
+	accountRepo.On("GetAccountByType", mock.Anything, int64(1), "FUND", "USDT").Return(account, nil)
+	accountRepo.On("FreezeBalance", mock.Anything, int64(1), amount).Return(nil)
+	withdrawalRepo.On("CreateOrder", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		args.Get(1).(*models.WithdrawalOrder).ID = 7
+	}).Return(&models.WithdrawalOrder{ID: 7}, nil)
+	safeheron.On("Withdraw", mock.Anything, mock.Anything).
+		Return(&SafeheronWithdrawalResponse{SafeheronOrderID: "tx-1", TxHash: "0xabc", Status: models.WithdrawalStatusConfirmed, TransactionStatus: "COMPLETED"}, nil)
+	withdrawalRepo.On("TransitionOrder", mock.Anything, mock.MatchedBy(func(o *models.WithdrawalOrder) bool {
+		return o.Status == "PROCESSING" && o.TransactionHash.String == "0xabc"
+	}), models.WithdrawalStatusPending).Return(nil).Once()
+	withdrawalRepo.On("TransitionOrder", mock.Anything, mock.MatchedBy(func(o *models.WithdrawalOrder) bool {
+		return o.Status == "CONFIRMED" && o.ConfirmedAt.Valid && o.SentAt.Valid
+	}), models.WithdrawalStatusProcessing).Return(nil).Once()
+	accountRepo.On("UnfreezeBalance", mock.Anything, int64(1), amount).Return(nil)
+	accountRepo.On("DeductBalance", mock.Anything, int64(1), amount).Return(nil)
+	journalRepo.On("CreateJournalRecord", mock.Anything, mock.Anything).Return(nil).Once()
+
+	order, err := service.CreateWithdrawal(context.Background(), 1, models.CreateWithdrawalRequest{AddressID: 10, Amount: "100", Asset: "USDT"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "CONFIRMED", order.Status)
+	// 冻结一次，扣款与确认同一事务
+	assert.Equal(t, 2, uow.Commits)
+	withdrawalRepo.AssertExpectations(t)
+	journalRepo.AssertExpectations(t)
+}
+
+func TestWithdrawalService_CreateWithdrawal_CustodyNotConfigured(t *testing.T) {
+	service, accountRepo, _, _, _, _, _ := newTestWithdrawalService()
+	service.SetCustodyClient(nil)
+
+	_, err := service.CreateWithdrawal(context.Background(), 1, models.CreateWithdrawalRequest{AddressID: 10, Amount: "100", Asset: "USDT"})
+
+	assert.ErrorIs(t, err, ErrCustodyNotConfigured)
+	assert.False(t, wasCalled(&accountRepo.Mock, "FreezeBalance"))
+}
+
+func TestWithdrawalService_CreateWithdrawal_RejectsForeignAddress(t *testing.T) {
+	service, accountRepo, addressRepo, _, _, _, _ := newTestWithdrawalService()
+
+	addressRepo.On("GetAddressByID", mock.Anything, 10).Return(&models.WithdrawalAddress{ID: 10, UserID: 2}, nil)
+
+	_, err := service.CreateWithdrawal(context.Background(), 1, models.CreateWithdrawalRequest{AddressID: 10, Amount: "100", Asset: "USDT"})
+
+	assert.ErrorIs(t, err, ErrWithdrawalAddressNotOwned)
+	assert.False(t, wasCalled(&accountRepo.Mock, "GetAccountByType"))
+}
+
+func processingWithdrawal() *models.WithdrawalOrder {
+	return &models.WithdrawalOrder{
+		ID:        7,
+		UserID:    1,
+		Amount:    "100.0000000000000000",
+		CoinType:  "USDT",
+		RequestID: "req-7",
+		Status:    "PROCESSING",
 	}
-	address := &models.WithdrawalAddress{
-		ID:            10,
-		UserID:        userID,
-		ChainType:     "TRC20",
-		WalletAddress: "Txyz...",
+}
+
+func TestWithdrawalService_HandleCustodyCallback_FailedRefunds(t *testing.T) {
+	service, accountRepo, _, withdrawalRepo, journalRepo, _, uow := newTestWithdrawalService()
+
+	amount := money.MustParse("100")
+	account := &repository.AccountModel{ID: 1, UserID: 1, Type: "FUND", Currency: "USDT", Balance: money.MustParse("50"), FrozenBalance: money.Zero}
+	refunded := &repository.AccountModel{ID: 1, UserID: 1, Type: "FUND", Currency: "USDT", Balance: money.MustParse("150"), FrozenBalance: money.Zero}
+	withdrawalRepo.On("GetOrderByRequestID", mock.Anything, "req-7").Return(processingWithdrawal(), nil)
+	withdrawalRepo.On("TransitionOrder", mock.Anything, mock.MatchedBy(func(o *models.WithdrawalOrder) bool {
+		return o.Status == "FAILED" && o.FailureReason.String == "address rejected"
+	}), models.WithdrawalStatusProcessing).Return(nil)
+	accountRepo.On("GetAccountByType", mock.Anything, int64(1), "FUND", "USDT").Return(account, nil).Once()
+	accountRepo.On("GetAccountByType", mock.Anything, int64(1), "FUND", "USDT").Return(refunded, nil).Once()
+	accountRepo.On("AddBalance", mock.Anything, int64(1), amount).Return(nil)
+	journalRepo.On("CreateJournalRecord", mock.Anything, mock.MatchedBy(func(r *repository.JournalModel) bool {
+		return r.SerialNo == "WITHDRAW-REFUND-7" && r.BizType == WithdrawBizRefund && r.Amount.Equal(amount) &&
+			r.BalanceSnapshot.Equal(money.MustParse("150")) && r.RefID != nil && *r.RefID == 7
+	})).Return(nil)
+
+	order, err := service.HandleCustodyCallback(context.Background(), CustodyCallback{RequestID: "req-7", Status: "failed", Reason: "address rejected"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "FAILED", order.Status)
+	assert.Equal(t, 1, uow.Commits)
+	accountRepo.AssertExpectations(t)
+	journalRepo.AssertExpectations(t)
+}
+
+func TestWithdrawalService_HandleCustodyCallback_Completed(t *testing.T) {
+	service, accountRepo, _, withdrawalRepo, journalRepo, _, _ := newTestWithdrawalService()
+
+	account := &repository.AccountModel{ID: 1, UserID: 1, Type: "FUND", Currency: "USDT"}
+	withdrawalRepo.On("GetOrderByRequestID", mock.Anything, "req-7").Return(processingWithdrawal(), nil)
+	withdrawalRepo.On("TransitionOrder", mock.Anything, mock.MatchedBy(func(o *models.WithdrawalOrder) bool {
+		return o.Status == "COMPLETED" && o.ConfirmedAt.Valid && o.CompletedAt.Valid && o.TransactionHash.String == "0xabc"
+	}), models.WithdrawalStatusProcessing).Return(nil)
+	accountRepo.On("GetAccountByType", mock.Anything, int64(1), "FUND", "USDT").Return(account, nil)
+
+	order, err := service.HandleCustodyCallback(context.Background(), CustodyCallback{RequestID: "req-7", Status: "COMPLETED", TxHash: "0xabc"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "COMPLETED", order.Status)
+	withdrawalRepo.AssertExpectations(t)
+	assert.False(t, wasCalled(&accountRepo.Mock, "AddBalance"))
+	assert.False(t, wasCalled(&journalRepo.Mock, "CreateJournalRecord"))
+}
+
+func TestWithdrawalService_HandleCustodyCallback_PendingCompletedDebitsFirst(t *testing.T) {
+	service, accountRepo, _, withdrawalRepo, journalRepo, _, uow := newTestWithdrawalService()
+
+	amount := money.MustParse("100")
+	pending := processingWithdrawal()
+	pending.Status = "PENDING"
+	account := &repository.AccountModel{ID: 1, UserID: 1, Type: "FUND", Currency: "USDT", Balance: money.MustParse("200"), FrozenBalance: amount}
+	withdrawalRepo.On("GetOrderByRequestID", mock.Anything, "req-7").Return(pending, nil)
+	withdrawalRepo.On("TransitionOrder", mock.Anything, mock.MatchedBy(func(o *models.WithdrawalOrder) bool {
+		return o.Status == "PROCESSING" && o.SentAt.Valid
+	}), models.WithdrawalStatusPending).Return(nil).Once()
+	withdrawalRepo.On("TransitionOrder", mock.Anything, mock.MatchedBy(func(o *models.WithdrawalOrder) bool {
+		return o.Status == "COMPLETED" && o.CompletedAt.Valid
+	}), models.WithdrawalStatusProcessing).Return(nil).Once()
+	accountRepo.On("GetAccountByType", mock.Anything, int64(1), "FUND", "USDT").Return(account, nil)
+	accountRepo.On("UnfreezeBalance", mock.Anything, int64(1), amount).Return(nil)
+	accountRepo.On("DeductBalance", mock.Anything, int64(1), amount).Return(nil)
+	journalRepo.On("CreateJournalRecord", mock.Anything, mock.MatchedBy(func(j *repository.JournalModel) bool {
+		return j.BizType == WithdrawBizDebit && j.Amount.Equal(amount.Neg())
+	})).Return(nil)
+
+	order, err := service.HandleCustodyCallback(context.Background(), CustodyCallback{RequestID: "req-7", Status: "COMPLETED", TxHash: "0xabc"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "COMPLETED", order.Status)
+	assert.Equal(t, 1, uow.Commits, "debit and completion must commit together")
+	withdrawalRepo.AssertExpectations(t)
+	accountRepo.AssertExpectations(t)
+	journalRepo.AssertExpectations(t)
+}
+
+func TestWithdrawalService_HandleCustodyCallback_PendingCompletedRollsBackDebit(t *testing.T) {
+	service, accountRepo, _, withdrawalRepo, journalRepo, _, uow := newTestWithdrawalService()
+
+	amount := money.MustParse("100")
+	pending := processingWithdrawal()
+	pending.Status = "PENDING"
+	account := &repository.AccountModel{ID: 1, UserID: 1, Type: "FUND", Currency: "USDT", Balance: money.MustParse("200"), FrozenBalance: amount}
+	withdrawalRepo.On("GetOrderByRequestID", mock.Anything, "req-7").Return(pending, nil)
+	withdrawalRepo.On("TransitionOrder", mock.Anything, mock.Anything, models.WithdrawalStatusPending).Return(nil).Once()
+	withdrawalRepo.On("TransitionOrder", mock.Anything, mock.Anything, models.WithdrawalStatusProcessing).Return(errors.New("db down")).Once()
+	accountRepo.On("GetAccountByType", mock.Anything, int64(1), "FUND", "USDT").Return(account, nil)
+	accountRepo.On("UnfreezeBalance", mock.Anything, int64(1), amount).Return(nil)
+	accountRepo.On("DeductBalance", mock.Anything, int64(1), amount).Return(nil)
+	journalRepo.On("CreateJournalRecord", mock.Anything, mock.Anything).Return(nil)
+
+	_, err := service.HandleCustodyCallback(context.Background(), CustodyCallback{RequestID: "req-7", Status: "COMPLETED"})
+
+	// 完成状态写入失败时扣款一并回滚，订单仍为 PENDING，可由重推的回调重试
+	assert.Error(t, err)
+	assert.Equal(t, 0, uow.Commits)
+	assert.Equal(t, 1, uow.Rollbacks)
+}
+
+func TestWithdrawalService_HandleCustodyCallback_RejectsIllegalTransition(t *testing.T) {
+	service, _, _, withdrawalRepo, _, _, _ := newTestWithdrawalService()
+
+	completed := processingWithdrawal()
+	completed.Status = "COMPLETED"
+	withdrawalRepo.On("GetOrderByRequestID", mock.Anything, "req-7").Return(completed, nil)
+
+	_, err := service.HandleCustodyCallback(context.Background(), CustodyCallback{RequestID: "req-7", Status: "FAILED"})
+	assert.ErrorIs(t, err, ErrInvalidWithdrawalTransition)
+	_, err = service.HandleCustodyCallback(context.Background(), CustodyCallback{RequestID: "req-7", Status: "SENT"})
+	assert.ErrorIs(t, err, ErrInvalidWithdrawalTransition)
+
+	// 重复推送已达到的状态不再变动
+	order, err := service.HandleCustodyCallback(context.Background(), CustodyCallback{RequestID: "req-7", Status: "COMPLETED"})
+	assert.NoError(t, err)
+	assert.Equal(t, "COMPLETED", order.Status)
+	assert.False(t, wasCalled(&withdrawalRepo.Mock, "TransitionOrder"))
+}
+
+func TestWithdrawalService_VerifyCustodyCallback(t *testing.T) {
+	service, _, _, _, _, safeheron, _ := newTestWithdrawalService()
+	now := time.Date(2026, 3, 10, 2, 0, 0, 0, time.UTC)
+	body := []byte(`{"sig":"..."}`)
+
+	safeheron.On("OpenWebhook", body).Return(&SafeheronWebhookEvent{
+		EventType: safeheronEventTransactionStatusChanged,
+		Timestamp: now.Add(-time.Minute),
+		RequestID: "req-7",
+		Withdrawal: &SafeheronWithdrawalResponse{
+			Status:            models.WithdrawalStatusFailed,
+			TransactionStatus: "REJECTED",
+			TxHash:            "0xabc",
+		},
+	}, nil).Once()
+	cb, err := service.VerifyCustodyCallback(body)
+	require.NoError(t, err)
+	assert.Equal(t, CustodyCallback{RequestID: "req-7", Status: "FAILED", TxHash: "0xabc", Reason: "custody transaction REJECTED"}, *cb)
+
+	// 验签或解密失败
+	safeheron.On("OpenWebhook", body).Return(nil, ErrCustodyBadSignature).Once()
+	_, err = service.VerifyCustodyCallback(body)
+	assert.ErrorIs(t, err, ErrInvalidCallbackSignature)
+
+	// 时间戳超出窗口视为重放
+	safeheron.On("OpenWebhook", body).Return(&SafeheronWebhookEvent{
+		EventType:  safeheronEventTransactionStatusChanged,
+		Timestamp:  now.Add(-10 * time.Minute),
+		RequestID:  "req-7",
+		Withdrawal: &SafeheronWithdrawalResponse{Status: models.WithdrawalStatusConfirmed},
+	}, nil).Once()
+	_, err = service.VerifyCustodyCallback(body)
+	assert.ErrorIs(t, err, ErrInvalidCallbackSignature)
+
+	// 与交易状态无关的事件
+	safeheron.On("OpenWebhook", body).Return(&SafeheronWebhookEvent{EventType: "AML_KYT_ALERT", Timestamp: now}, nil).Once()
+	_, err = service.VerifyCustodyCallback(body)
+	assert.ErrorIs(t, err, ErrCallbackIgnored)
+
+	// 未配置托管客户端时拒绝所有回调
+	unconfigured := NewWithdrawalService(&repository.Repository{}, nil, nil)
+	_, err = unconfigured.VerifyCustodyCallback(body)
+	assert.ErrorIs(t, err, ErrInvalidCallbackSignature)
+}
+
+func TestWithdrawalService_HandleCustodyCallback_LateProcessingIgnored(t *testing.T) {
+	service, _, _, withdrawalRepo, _, _, _ := newTestWithdrawalService()
+	confirmed := pendingWithdrawal(7, time.Hour)
+	confirmed.Status = "CONFIRMED"
+	withdrawalRepo.On("GetOrderByRequestID", mock.Anything, "req-7").Return(confirmed, nil)
+
+	order, err := service.HandleCustodyCallback(context.Background(), CustodyCallback{RequestID: "req-7", Status: "PROCESSING"})
+
+	require.NoError(t, err)
+	assert.Equal(t, "CONFIRMED", order.Status)
+	assert.False(t, wasCalled(&withdrawalRepo.Mock, "TransitionOrder"))
+}
+
+func pendingWithdrawal(id int, age time.Duration) *models.WithdrawalOrder {
+	now := time.Date(2026, 3, 10, 2, 0, 0, 0, time.UTC)
+	return &models.WithdrawalOrder{
+		ID:        id,
+		UserID:    1,
+		Amount:    "100",
+		CoinType:  "USDT",
+		RequestID: fmt.Sprintf("req-%d", id),
+		Status:    "PENDING",
+		CreatedAt: now.Add(-age),
 	}
-	shResp := &SafeheronWithdrawalResponse{
-		TxHash:           "0xtx",
-		SafeheronOrderID: "sh-123",
-		NetworkFee:       "1.0",
+}
+
+func TestWithdrawalService_ReconcilePending(t *testing.T) {
+	service, accountRepo, _, withdrawalRepo, journalRepo, safeheron, _ := newTestWithdrawalService()
+
+	amount := money.MustParse("100")
+	accepted := pendingWithdrawal(1, 15*time.Minute)
+	lost := pendingWithdrawal(2, 2*time.Hour)
+	recent := pendingWithdrawal(3, 20*time.Minute)
+	unknown := pendingWithdrawal(4, 30*time.Minute)
+	account := &repository.AccountModel{ID: 1, UserID: 1, Type: "FUND", Currency: "USDT", Balance: money.MustParse("500"), FrozenBalance: money.MustParse("300")}
+
+	withdrawalRepo.On("GetPendingOrdersBefore", mock.Anything, time.Date(2026, 3, 10, 1, 50, 0, 0, time.UTC)).
+		Return([]*models.WithdrawalOrder{accepted, lost, recent, unknown}, nil)
+	safeheron.On("GetWithdrawal", mock.Anything, "req-1").Return(&SafeheronWithdrawalResponse{SafeheronOrderID: "tx-1", TxHash: "0xabc", Status: models.WithdrawalStatusProcessing, TransactionStatus: "BROADCASTING"}, nil)
+	safeheron.On("GetWithdrawal", mock.Anything, "req-2").Return(nil, ErrCustodyNotFound)
+	safeheron.On("GetWithdrawal", mock.Anything, "req-3").Return(nil, ErrCustodyNotFound)
+	safeheron.On("GetWithdrawal", mock.Anything, "req-4").Return(nil, ErrCustodyOutcomeUnknown)
+	withdrawalRepo.On("TransitionOrder", mock.Anything, mock.MatchedBy(func(o *models.WithdrawalOrder) bool {
+		return o.ID == 1 && o.Status == "PROCESSING" && o.SafeheronOrderID.String == "tx-1" && o.TransactionHash.String == "0xabc" && o.SentAt.Valid
+	}), models.WithdrawalStatusPending).Return(nil).Once()
+	withdrawalRepo.On("TransitionOrder", mock.Anything, mock.MatchedBy(func(o *models.WithdrawalOrder) bool {
+		return o.ID == 2 && o.Status == "FAILED" && o.FailureReason.Valid
+	}), models.WithdrawalStatusPending).Return(nil).Once()
+	accountRepo.On("GetAccountByType", mock.Anything, int64(1), "FUND", "USDT").Return(account, nil)
+	accountRepo.On("UnfreezeBalance", mock.Anything, int64(1), amount).Return(nil).Twice()
+	accountRepo.On("DeductBalance", mock.Anything, int64(1), amount).Return(nil).Once()
+	journalRepo.On("CreateJournalRecord", mock.Anything, mock.MatchedBy(func(j *repository.JournalModel) bool {
+		return j.SerialNo == "WITHDRAW-1" && j.BizType == WithdrawBizDebit
+	})).Return(nil).Once()
+
+	result, err := service.ReconcilePending(context.Background())
+
+	// 查询结果未知的订单保持 PENDING，本次任务失败以便告警与重试
+	assert.Error(t, err)
+	assert.Equal(t, &WithdrawalReconcileResult{Checked: 4, Processing: 1, Failed: 1, Waiting: 1}, result)
+	withdrawalRepo.AssertExpectations(t)
+	accountRepo.AssertExpectations(t)
+	journalRepo.AssertExpectations(t)
+}
+
+func TestWithdrawalService_ReconcilePending_MapsCustodyStatus(t *testing.T) {
+	service, accountRepo, _, withdrawalRepo, journalRepo, safeheron, _ := newTestWithdrawalService()
+
+	amount := money.MustParse("100")
+	completed := pendingWithdrawal(1, 15*time.Minute)
+	orders := []*models.WithdrawalOrder{completed}
+	// 失败、拒绝、取消均为终态失败
+	for i, status := range []string{"FAILED", "REJECTED", "CANCELLED"} {
+		order := pendingWithdrawal(2+i, 15*time.Minute)
+		orders = append(orders, order)
+		safeheron.On("GetWithdrawal", mock.Anything, order.RequestID).
+			Return(&SafeheronWithdrawalResponse{SafeheronOrderID: "tx", Status: custodyWithdrawalStatus(status), TransactionStatus: status}, nil)
+		reason := "custody transaction " + status
+		id := order.ID
+		withdrawalRepo.On("TransitionOrder", mock.Anything, mock.MatchedBy(func(o *models.WithdrawalOrder) bool {
+			return o.ID == id && o.Status == "FAILED" && o.FailureReason.String == reason
+		}), models.WithdrawalStatusPending).Return(nil).Once()
 	}
+	account := &repository.AccountModel{ID: 1, UserID: 1, Type: "FUND", Currency: "USDT", Balance: money.MustParse("500"), FrozenBalance: money.MustParse("400")}
+
+	withdrawalRepo.On("GetPendingOrdersBefore", mock.Anything, mock.Anything).Return(orders, nil)
+	safeheron.On("GetWithdrawal", mock.Anything, "req-1").
+		Return(&SafeheronWithdrawalResponse{SafeheronOrderID: "tx-1", TxHash: "0xabc", Status: models.WithdrawalStatusConfirmed, TransactionStatus: "COMPLETED"}, nil)
+	// 已完成的交易先补记扣款，再确认
+	withdrawalRepo.On("TransitionOrder", mock.Anything, mock.MatchedBy(func(o *models.WithdrawalOrder) bool {
+		return o.ID == 1 && o.Status == "PROCESSING"
+	}), models.WithdrawalStatusPending).Return(nil).Once()
+	withdrawalRepo.On("TransitionOrder", mock.Anything, mock.MatchedBy(func(o *models.WithdrawalOrder) bool {
+		return o.ID == 1 && o.Status == "CONFIRMED" && o.ConfirmedAt.Valid
+	}), models.WithdrawalStatusProcessing).Return(nil).Once()
+	accountRepo.On("GetAccountByType", mock.Anything, int64(1), "FUND", "USDT").Return(account, nil)
+	accountRepo.On("UnfreezeBalance", mock.Anything, int64(1), amount).Return(nil).Times(4)
+	accountRepo.On("DeductBalance", mock.Anything, int64(1), amount).Return(nil).Once()
+	journalRepo.On("CreateJournalRecord", mock.Anything, mock.MatchedBy(func(j *repository.JournalModel) bool {
+		return j.SerialNo == "WITHDRAW-1" && j.BizType == WithdrawBizDebit
+	})).Return(nil).Once()
+
+	result, err := service.ReconcilePending(context.Background())
 
-	// Expectations
-	mockAccountRepo.On("GetByUserIDAndType", ctx, userID, "WEALTH").Return(account, nil)
-	mockAddressRepo.On("GetAddressByID", ctx, 10).Return(address, nil)
-
-	mockSafeheron.On("Withdraw", ctx, mock.MatchedBy(func(r SafeheronWithdrawalRequest) bool {
-		return r.Amount == "100.0" && r.ToAddress == "Txyz..." && r.CoinType == "USDT" && r.ChainType == "TRC20"
-	})).Return(shResp, nil)
-
-	// Mock DB transaction operations
-	sqlMock.ExpectBegin()
-	sqlMock.ExpectExec("UPDATE account SET frozen_balance = frozen_balance \\+ \\$1").
-		WithArgs(100.0, userID, sqlmock.AnyArg()).
-		WillReturnResult(sqlmock.NewResult(1, 1))
-	sqlMock.ExpectExec("UPDATE account SET frozen_balance = frozen_balance - \\$1, balance = balance - \\$1").
-		WithArgs(100.0, userID, sqlmock.AnyArg()).
-		WillReturnResult(sqlmock.NewResult(1, 1))
-	sqlMock.ExpectQuery("INSERT INTO withdrawal_order").
-		WithArgs(userID, "100.0", "1.0", "0", "100.0", "TRC20", "USDT", "Txyz...", "sh-123", "0xtx", "SENT", sqlmock.AnyArg(), sqlmock.AnyArg()).
-		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(1, time.Now()))
-	sqlMock.ExpectCommit()
-
-	// Execute
-	order, err := service.CreateWithdrawal(ctx, userID, req)
-
-	// Assertions
 	assert.NoError(t, err)
-	assert.Equal(t, 1, order.ID)
+	assert.Equal(t, &WithdrawalReconcileResult{Checked: 4, Confirmed: 1, Failed: 3}, result)
+	withdrawalRepo.AssertExpectations(t)
+	accountRepo.AssertExpectations(t)
+	journalRepo.AssertExpectations(t)
+}
+
+func TestWithdrawalService_ReconcilePending_SkipsOrdersAdvancedByCallback(t *testing.T) {
+	service, _, _, withdrawalRepo, _, safeheron, _ := newTestWithdrawalService()
+
+	withdrawalRepo.On("GetPendingOrdersBefore", mock.Anything, mock.Anything).
+		Return([]*models.WithdrawalOrder{pendingWithdrawal(1, 15*time.Minute)}, nil)
+	safeheron.On("GetWithdrawal", mock.Anything, "req-1").Return(&SafeheronWithdrawalResponse{SafeheronOrderID: "tx-1", Status: models.WithdrawalStatusProcessing}, nil)
+	withdrawalRepo.On("TransitionOrder", mock.Anything, mock.Anything, models.WithdrawalStatusPending).Return(repository.ErrNotFound)
+
+	result, err := service.ReconcilePending(context.Background())
 
-	mockAccountRepo.AssertExpectations(t)
-	mockAddressRepo.AssertExpectations(t)
-	mockSafeheron.AssertExpectations(t)
-	mockWithdrawalRepo.AssertExpectations(t)
-	assert.NoError(t, sqlMock.ExpectationsWereMet())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, result.Checked)
+	assert.Equal(t, 0, result.Processing)
+}
+
+func TestWithdrawalService_ReconcilePending_CustodyNotConfigured(t *testing.T) {
+	service, _, _, withdrawalRepo, _, _, _ := newTestWithdrawalService()
+	service.SetCustodyClient(nil)
+
+	_, err := service.ReconcilePending(context.Background())
+
+	assert.NoError(t, err)
+	assert.False(t, wasCalled(&withdrawalRepo.Mock, "GetPendingOrdersBefore"))
 }
@@ -0,0 +1,125 @@
+package tests
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"monera-digital/internal/money"
+	"monera-digital/internal/repository"
+	"monera-digital/internal/repository/postgres"
+)
+
+// TestAccountBalance_ConcurrentUpdates 并发冻结与加减余额：
+// 成功的操作全部体现在最终余额中，冻结额始终不超过余额，违反约束的直接写入被数据库拒绝
+func TestAccountBalance_ConcurrentUpdates(t *testing.T) {
+	db := getTestDB(t)
+	defer db.Close()
+
+	var userID, accountID int64
+	email := fmt.Sprintf("balance_race_%d@example.com", time.Now().UnixNano())
+	if err := db.QueryRow(`INSERT INTO users (email, password) VALUES ($1, 'x') RETURNING id`, email).Scan(&userID); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	defer db.Exec("DELETE FROM users WHERE id = $1", userID)
+	err := db.QueryRow(`
+		INSERT INTO account (user_id, type, currency, balance, frozen_balance)
+		VALUES ($1, 'FUND', 'USDT', 1000, 0)
+		RETURNING id
+	`, userID).Scan(&accountID)
+	if err != nil {
+		t.Fatalf("Failed to create account: %v", err)
+	}
+	defer db.Exec("DELETE FROM account WHERE id = $1", accountID)
+
+	ctx := context.Background()
+	uow := postgres.NewUnitOfWork(db)
+
+	// 50 笔 30 的冻结抢 1000 的余额，最多成功 33 笔
+	var frozen int32
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := uow.Do(ctx, func(tx *repository.TxRepository) error {
+				if err := tx.Account.FreezeBalance(ctx, accountID, money.MustParse("30")); err != nil {
+					return err
+				}
+				// 模拟申购事务中的建单与记账，拉长持锁时间
+				time.Sleep(2 * time.Millisecond)
+				return nil
+			})
+			switch {
+			case err == nil:
+				atomic.AddInt32(&frozen, 1)
+			case errors.Is(err, repository.ErrInsufficientBalance), errors.Is(err, repository.ErrVersionConflict):
+			default:
+				t.Errorf("Unexpected freeze error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if frozen == 0 || frozen > 33 {
+		t.Errorf("Expected between 1 and 33 freezes, got %d", frozen)
+	}
+	assertBalance(t, db, accountID, money.MustParse("1000"), money.NewFromInt(30*int64(frozen)))
+
+	// 并发加减各 40 笔，扣减不得动用冻结部分
+	repo := postgres.NewAccountRepository(db)
+	var added, deducted int32
+	for i := 0; i < 80; i++ {
+		wg.Add(1)
+		go func(add bool) {
+			defer wg.Done()
+			var err error
+			if add {
+				err = repo.AddBalance(ctx, accountID, money.MustParse("10"))
+			} else {
+				err = repo.DeductBalance(ctx, accountID, money.MustParse("10"))
+			}
+			switch {
+			case err == nil && add:
+				atomic.AddInt32(&added, 1)
+			case err == nil:
+				atomic.AddInt32(&deducted, 1)
+			case errors.Is(err, repository.ErrInsufficientBalance), errors.Is(err, repository.ErrVersionConflict):
+			default:
+				t.Errorf("Unexpected balance error: %v", err)
+			}
+		}(i%2 == 0)
+	}
+	wg.Wait()
+
+	balance := money.NewFromInt(1000 + 10*int64(added-deducted))
+	assertBalance(t, db, accountID, balance, money.NewFromInt(30*int64(frozen)))
+
+	// 绕过仓储的直接写入同样受数据库约束保护
+	if _, err := db.Exec(`UPDATE account SET frozen_balance = balance + 1 WHERE id = $1`, accountID); err == nil {
+		t.Error("Expected frozen balance above balance to violate constraint")
+	}
+	if _, err := db.Exec(`UPDATE account SET balance = -1, frozen_balance = 0 WHERE id = $1`, accountID); err == nil {
+		t.Error("Expected negative balance to violate constraint")
+	}
+}
+
+// assertBalance 校验账户余额与冻结额，并确认冻结额未超过余额
+func assertBalance(t *testing.T, db *sql.DB, accountID int64, balance, frozen money.Decimal) {
+	t.Helper()
+	var gotBalance, gotFrozen money.Decimal
+	if err := db.QueryRow("SELECT balance, frozen_balance FROM account WHERE id = $1", accountID).Scan(&gotBalance, &gotFrozen); err != nil {
+		t.Fatalf("Failed to read account: %v", err)
+	}
+	if !gotBalance.Equal(balance) || !gotFrozen.Equal(frozen) {
+		t.Errorf("Expected balance %s frozen %s, got balance %s frozen %s", balance, frozen, gotBalance, gotFrozen)
+	}
+	if gotFrozen.GreaterThan(gotBalance) {
+		t.Errorf("Frozen balance %s exceeds balance %s", gotFrozen, gotBalance)
+	}
+}
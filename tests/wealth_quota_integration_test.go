@@ -0,0 +1,82 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"monera-digital/internal/money"
+	"monera-digital/internal/repository"
+	"monera-digital/internal/repository/postgres"
+)
+
+// TestWealthQuotaReservation_ParallelSubscriptions 并发占用额度：
+// 40 笔 100 的申购抢 1000 的额度，只能成功 10 笔，已售额度不超过总额度
+func TestWealthQuotaReservation_ParallelSubscriptions(t *testing.T) {
+	db := getTestDB(t)
+	defer db.Close()
+
+	var productID int64
+	err := db.QueryRow(`
+		INSERT INTO wealth_product (title, currency, apy, duration, min_amount, max_amount, total_quota, sold_quota, status)
+		VALUES ('quota-race-test', 'USDT', 5, 7, 100, 1000, 1000, 0, 2)
+		RETURNING id
+	`).Scan(&productID)
+	if err != nil {
+		t.Fatalf("Failed to create product: %v", err)
+	}
+	defer db.Exec("DELETE FROM wealth_product WHERE id = $1", productID)
+
+	uow := postgres.NewUnitOfWork(db)
+	amount := money.MustParse("100")
+
+	var reserved, rejected int32
+	var wg sync.WaitGroup
+	for i := 0; i < 40; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := uow.Do(context.Background(), func(tx *repository.TxRepository) error {
+				if err := tx.Wealth.ReserveProductQuota(context.Background(), productID, amount); err != nil {
+					return err
+				}
+				// 模拟申购事务中的冻结、建单，拉长持锁时间
+				time.Sleep(5 * time.Millisecond)
+				return nil
+			})
+			switch {
+			case err == nil:
+				atomic.AddInt32(&reserved, 1)
+			case errors.Is(err, repository.ErrQuotaExceeded):
+				atomic.AddInt32(&rejected, 1)
+			default:
+				t.Errorf("Unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if reserved != 10 {
+		t.Errorf("Expected 10 reservations, got %d (rejected %d)", reserved, rejected)
+	}
+
+	var soldQuota money.Decimal
+	if err := db.QueryRow("SELECT sold_quota FROM wealth_product WHERE id = $1", productID).Scan(&soldQuota); err != nil {
+		t.Fatalf("Failed to read sold quota: %v", err)
+	}
+	if !soldQuota.Equal(money.MustParse("1000")) {
+		t.Errorf("Expected sold quota 1000, got %s", soldQuota)
+	}
+
+	// 归还后额度可再次占用
+	repo := postgres.NewWealthRepository(db)
+	if err := repo.ReleaseProductQuota(context.Background(), productID, amount); err != nil {
+		t.Fatalf("Release failed: %v", err)
+	}
+	if err := repo.ReserveProductQuota(context.Background(), productID, amount); err != nil {
+		t.Errorf("Expected released quota to be reservable, got %v", err)
+	}
+}
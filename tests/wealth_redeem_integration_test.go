@@ -0,0 +1,112 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"monera-digital/internal/money"
+	"monera-digital/internal/repository/postgres"
+	"monera-digital/internal/services"
+)
+
+// TestWealthRedeem_ConcurrentFullRedemptions 同一订单并发全额赎回：
+// 只有一笔成功，本金只解冻一次、利息只派发一次，其余返回 ErrOrderAlreadyRedeemed
+func TestWealthRedeem_ConcurrentFullRedemptions(t *testing.T) {
+	db := getTestDB(t)
+	defer db.Close()
+
+	var userID, accountID, productID, orderID int64
+	email := fmt.Sprintf("redeem_race_%d@example.com", time.Now().UnixNano())
+	if err := db.QueryRow(`INSERT INTO users (email, password) VALUES ($1, 'x') RETURNING id`, email).Scan(&userID); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	defer db.Exec("DELETE FROM users WHERE id = $1", userID)
+	err := db.QueryRow(`
+		INSERT INTO account (user_id, type, currency, balance, frozen_balance)
+		VALUES ($1, 'FUND', 'USDT', 10000, 5000)
+		RETURNING id
+	`, userID).Scan(&accountID)
+	if err != nil {
+		t.Fatalf("Failed to create account: %v", err)
+	}
+	defer db.Exec("DELETE FROM account WHERE id = $1", accountID)
+	defer db.Exec("DELETE FROM account_journal WHERE account_id = $1", accountID)
+	err = db.QueryRow(`
+		INSERT INTO wealth_product (title, currency, apy, duration, min_amount, max_amount, total_quota, sold_quota, status)
+		VALUES ('redeem-race-test', 'USDT', 5, 7, 100, 10000, 10000, 5000, 1)
+		RETURNING id
+	`).Scan(&productID)
+	if err != nil {
+		t.Fatalf("Failed to create product: %v", err)
+	}
+	defer db.Exec("DELETE FROM wealth_product WHERE id = $1", productID)
+
+	// 已到期未结算的订单，赎回时不收提前赎回费
+	today := time.Now().UTC()
+	err = db.QueryRow(`
+		INSERT INTO wealth_order (user_id, product_id, amount, interest_accrued, start_date, end_date, status)
+		VALUES ($1, $2, 5000, 10, $3, $4, 1)
+		RETURNING id
+	`, userID, productID, today.AddDate(0, 0, -10).Format("2006-01-02"), today.AddDate(0, 0, -3).Format("2006-01-02")).Scan(&orderID)
+	if err != nil {
+		t.Fatalf("Failed to create order: %v", err)
+	}
+	defer db.Exec("DELETE FROM wealth_order WHERE id = $1", orderID)
+
+	service := services.NewWealthService(
+		postgres.NewWealthRepository(db),
+		postgres.NewAccountRepository(db),
+		postgres.NewJournalRepository(db),
+		postgres.NewUnitOfWork(db),
+	)
+
+	var redeemed, rejected int32
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := service.Redeem(context.Background(), int(userID), orderID, "full", "")
+			switch {
+			case err == nil:
+				atomic.AddInt32(&redeemed, 1)
+			case errors.Is(err, services.ErrOrderAlreadyRedeemed):
+				atomic.AddInt32(&rejected, 1)
+			default:
+				t.Errorf("Unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if redeemed != 1 {
+		t.Fatalf("Expected exactly 1 redemption, got %d (rejected %d)", redeemed, rejected)
+	}
+
+	var balance, frozen money.Decimal
+	if err := db.QueryRow("SELECT balance, frozen_balance FROM account WHERE id = $1", accountID).Scan(&balance, &frozen); err != nil {
+		t.Fatalf("Failed to read account: %v", err)
+	}
+	if !balance.Equal(money.MustParse("10010")) || !frozen.IsZero() {
+		t.Errorf("Expected balance 10010 / frozen 0, got %s / %s", balance, frozen)
+	}
+
+	var principalRedeemed, soldQuota money.Decimal
+	if err := db.QueryRow("SELECT principal_redeemed FROM wealth_order WHERE id = $1", orderID).Scan(&principalRedeemed); err != nil {
+		t.Fatalf("Failed to read order: %v", err)
+	}
+	if !principalRedeemed.Equal(money.MustParse("5000")) {
+		t.Errorf("Expected principal_redeemed 5000, got %s", principalRedeemed)
+	}
+	if err := db.QueryRow("SELECT sold_quota FROM wealth_product WHERE id = $1", productID).Scan(&soldQuota); err != nil {
+		t.Fatalf("Failed to read sold quota: %v", err)
+	}
+	if !soldQuota.IsZero() {
+		t.Errorf("Expected quota released once (sold 0), got %s", soldQuota)
+	}
+}